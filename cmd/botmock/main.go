@@ -46,7 +46,7 @@ func main() {
 	docs.SwaggerInfo.BasePath = "/api/v1"
 
 	apiRouter := v1.NewAPI(router.Group("/api/v1")).Router()
-	musicrest.NewHandler(mock, apiRouter).Router()
+	musicrest.NewHandler(mock, nil, apiRouter).Router()
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	go func() {
 		err := router.Run(":" + cfg.Host.Mock)
@@ -2,22 +2,57 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/khodand/dca"
 	"github.com/pkg/errors"
 
+	chess "github.com/HalvaPovidlo/discordBotGo/internal/chess/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
 	"github.com/HalvaPovidlo/discordBotGo/internal/music/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
 	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
-const FilePath = "secret_config.json"
+const (
+	FilePath = "secret_config.json"
+	// FilePathEnv overrides FilePath, the file may be absent if everything is set through the environment
+	FilePathEnv = "HALVA_CONFIG"
+)
 
 type Config struct {
-	General GeneralConfig  `json:"general"`
-	Host    HostConfig     `json:"host"`
-	Discord DiscordConfig  `json:"discord"`
-	Youtube youtube.Config `json:"youtube"`
+	General GeneralConfig `json:"general"`
+	// Cogs enabled modules of the bot
+	Cogs        []string          `json:"cogs"`
+	Host        HostConfig        `json:"host"`
+	Credentials CredentialsConfig `json:"credentials"`
+	Cache       CacheConfig       `json:"cache"`
+	Discord     DiscordConfig     `json:"discord"`
+	Youtube     youtube.Config    `json:"youtube"`
+	Chess       chess.Config      `json:"chess"`
+	Sentry      SentryConfig      `json:"sentry"`
+	Cluster     ClusterConfig     `json:"cluster"`
+	Failover    FailoverConfig    `json:"failover"`
+	Admin       AdminConfig       `json:"admin"`
+	Alerts      AlertsConfig      `json:"alerts"`
+	Recording   RecordingConfig   `json:"recording"`
+	Export      ExportConfig      `json:"export"`
+	Broker      BrokerConfig      `json:"broker"`
+	Redis       RedisConfig       `json:"redis"`
+	Log         zap.Config        `json:"log"`
+	// Webhooks receive every event of the player as a JSON POST
+	Webhooks []string `json:"webhooks"`
+	// Limits of the guilds which didn't set their own with the settings command
+	Limits guild.Limits `json:"limits"`
+	// Features default state of the feature flags, guilds override it with the features command
+	Features map[string]bool `json:"features"`
 	// Sheets  SheetsConfig  `json:"sheets"`
 	// VK      VKConfig      `json:"vk"`
 	// Lichess LichessConfig `json:"lichess"`
@@ -34,6 +69,12 @@ type DiscordConfig struct {
 
 type VoiceConfig struct {
 	dca.EncodeOptions
+	// MaxBitrate in kbps the songs are encoded at the bitrate of the channel up to, 0 keeps 64 for every channel
+	MaxBitrate int                `json:"max_bitrate"`
+	FFmpeg     audio.FFmpegConfig `json:"ffmpeg"`
+	TTS        audio.TTSConfig    `json:"tts"`
+	// Headless the player doesn't join the voice channels, for the servers without the voice access
+	Headless audio.HeadlessConfig `json:"headless"`
 }
 
 type SheetsConfig struct {
@@ -52,6 +93,8 @@ type LichessConfig struct {
 
 type GeneralConfig struct {
 	Debug bool `json:"debug"`
+	// Maintenance the bot starts without taking new requests, it is switched at runtime by the admins
+	Maintenance bool `json:"maintenance"`
 }
 
 type HostConfig struct {
@@ -59,21 +102,427 @@ type HostConfig struct {
 	Bot  string `json:"bot"`
 	Mock string `json:"mock"`
 	Web  string `json:"web"`
+	// URL the bot port is reached at from outside in the links, http://ip:bot by default
+	URL string `json:"url"`
 }
 
-func InitConfig() (*Config, error) {
-	var config Config
-	jsonFile, err := ioutil.ReadFile(FilePath)
+// BotURL the public url of the bot port without the trailing slash
+func (h *HostConfig) BotURL() string {
+	if h.URL != "" {
+		return strings.TrimSuffix(h.URL, "/")
+	}
+	return "http://" + h.IP + ":" + h.Bot
+}
+
+// CacheConfig durations are written as "24h", "90m"
+type CacheConfig struct {
+	// SongsTTL how long unused songs and their files are kept
+	SongsTTL Duration `json:"songs_ttl"`
+	// ShortRefresh how often the list of all songs for the radio is reloaded
+	ShortRefresh Duration `json:"short_refresh"`
+	// SearchTTL how long the results of a YouTube search are reused, 0 turns it off
+	SearchTTL Duration `json:"search_ttl"`
+	// FramesDir the encoded songs are kept there if set
+	FramesDir string `json:"frames_dir"`
+	// FramesSizeMB limit of the encoded songs, the least recently played are removed
+	FramesSizeMB int64 `json:"frames_size_mb"`
+	// FramesMinPlays a song is encoded to the disk when it is played this many times
+	FramesMinPlays int `json:"frames_min_plays"`
+}
+
+// RecordingConfig the record command is disabled without Dir
+type RecordingConfig struct {
+	Dir string `json:"dir"`
+	// MaxLength of a recording, it stops by itself then
+	MaxLength Duration `json:"max_length"`
+	// Keep the recordings are removed after it
+	Keep Duration `json:"keep"`
+	// URL of the api in the download links, http://host.ip:host.bot/api/v1 by default
+	URL string `json:"url"`
+}
+
+// ExportConfig the playlists are exported to the services with a client,
+// the redirect url of a client is <host.url>/api/v1/export/<service>/callback
+type ExportConfig struct {
+	YouTube OAuthClientConfig `json:"youtube"`
+	Spotify OAuthClientConfig `json:"spotify"`
+}
+
+// BrokerConfig the player events are published to the brokers with a url
+type BrokerConfig struct {
+	NATS  NATSConfig  `json:"nats"`
+	Kafka KafkaConfig `json:"kafka"`
+}
+
+type NATSConfig struct {
+	// URL nats://[user:password@]host[:port]
+	URL string `json:"url"`
+	// Subject the type of the event is appended to
+	Subject string `json:"subject"`
+}
+
+type KafkaConfig struct {
+	// URL of the Kafka REST proxy
+	URL   string `json:"url"`
+	Topic string `json:"topic"`
+}
+
+// RedisConfig the queue of the player is kept in memory without URL
+type RedisConfig struct {
+	// URL redis://[:password@]host[:port][/db]
+	URL string `json:"url"`
+	// Prefix of the keys of the queue and the heartbeats
+	Prefix string `json:"prefix"`
+}
+
+type OAuthClientConfig struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// Duration time.Duration in the json format of time.ParseDuration
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Wrap(err, "duration must be a string")
+	}
+	v, err := time.ParseDuration(s)
 	if err != nil {
+		return errors.Wrapf(err, "parse duration %s", s)
+	}
+	d.Duration = v
+	return nil
+}
+
+// SentryConfig error reporting is disabled without DSN
+type SentryConfig struct {
+	DSN         string `json:"dsn"`
+	Environment string `json:"environment"`
+}
+
+// ClusterConfig several instances share the token, each guild is served by one of them
+type ClusterConfig struct {
+	Enabled bool `json:"enabled"`
+	// Instance unique name of the process, the hostname by default
+	Instance string `json:"instance"`
+	// LeaseTTL how long a guild waits for a dead instance before another one takes over
+	LeaseTTL Duration `json:"lease_ttl"`
+	// StateInterval how often the queue is saved for the takeover
+	StateInterval Duration `json:"state_interval"`
+}
+
+// FailoverConfig a standby bot with its own token and config takes over the voice when the primary stops beating
+type FailoverConfig struct {
+	// Role is primary or standby, empty without a standby
+	Role string `json:"role"`
+	// Interval of the heartbeats
+	Interval Duration `json:"interval"`
+	// Timeout without the beats of the primary before the standby takes over
+	Timeout Duration `json:"timeout"`
+	// StateInterval how often the queue is saved for the other bot
+	StateInterval Duration `json:"state_interval"`
+}
+
+// AdminConfig the admin api is disabled without Token
+type AdminConfig struct {
+	// Token is passed as "Authorization: Bearer <token>"
+	Token string `json:"token"`
+}
+
+// AlertsConfig the operators are alerted in a discord channel, disabled without Channel.
+// The thresholds are counted per Interval, 0 turns the rule off.
+type AlertsConfig struct {
+	Channel string `json:"channel"`
+	// Interval the metrics are checked at
+	Interval Duration `json:"interval"`
+	// Cooldown the same alert isn't repeated for
+	Cooldown Duration `json:"cooldown"`
+	// Errors logged errors and failed commands
+	Errors int `json:"errors"`
+	// QuotaLeft fraction of youtube.daily_quota, a key with less left is alerted
+	QuotaLeft float64 `json:"quota_left"`
+	// WriteFailures Firestore writes failed after the retries
+	WriteFailures int `json:"write_failures"`
+	// Reconnects of the voice connection
+	Reconnects int `json:"reconnects"`
+}
+
+// CredentialsConfig paths to the google service account files
+type CredentialsConfig struct {
+	Google   string `json:"google"`
+	Firebase string `json:"firebase"`
+}
+
+// InitConfig reads the config file and overrides it with the environment variables
+func InitConfig() (*Config, error) {
+	config := Config{
+		Cogs: []string{"music", "settings", "chess", "health", "mydata"},
+		Credentials: CredentialsConfig{
+			Google:   "halvabot-google.json",
+			Firebase: "halvabot-firebase.json",
+		},
+		Cache: CacheConfig{
+			SongsTTL:       Duration{24 * time.Hour},
+			ShortRefresh:   Duration{3 * time.Hour},
+			SearchTTL:      Duration{6 * time.Hour},
+			FramesSizeMB:   512,
+			FramesMinPlays: 2,
+		},
+		Features: map[string]bool{
+			string(guild.Autoplay): true,
+		},
+		Recording: RecordingConfig{
+			MaxLength: Duration{time.Hour},
+			Keep:      Duration{24 * time.Hour},
+		},
+		Discord: DiscordConfig{
+			Voice: VoiceConfig{MaxBitrate: 128},
+		},
+		Broker: BrokerConfig{
+			NATS:  NATSConfig{Subject: "halva.player"},
+			Kafka: KafkaConfig{Topic: "halva.player"},
+		},
+		Redis: RedisConfig{Prefix: "halva:player"},
+		Alerts: AlertsConfig{
+			Interval:      Duration{time.Minute},
+			Cooldown:      Duration{30 * time.Minute},
+			Errors:        20,
+			QuotaLeft:     0.1,
+			WriteFailures: 3,
+			Reconnects:    5,
+		},
+		Cluster: ClusterConfig{
+			LeaseTTL:      Duration{30 * time.Second},
+			StateInterval: Duration{15 * time.Second},
+		},
+		Failover: FailoverConfig{
+			Interval:      Duration{5 * time.Second},
+			Timeout:       Duration{30 * time.Second},
+			StateInterval: Duration{15 * time.Second},
+		},
+		Youtube: youtube.Config{
+			CacheSizeMB:     1024,
+			DownloadWorkers: youtube.DefaultDownloadWorkers,
+			DailyQuota:      youtube.DefaultDailyQuota,
+			ReserveQuota:    youtube.DefaultReserveQuota,
+			MaxSearchResult: 10,
+			Format:          ".m4a",
+			MimeType:        "audio/mp4",
+		},
+	}
+	path := FilePath
+	if p, ok := os.LookupEnv(FilePathEnv); ok {
+		path = p
+	}
+	jsonFile, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		err = json.Unmarshal(jsonFile, &config)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unmarshal failed")
+		}
+	case os.IsNotExist(err):
+		// everything comes from the environment
+	default:
 		return nil, errors.Wrap(err, "read failed")
 	}
-	err = json.Unmarshal(jsonFile, &config)
-	if err != nil {
-		return nil, errors.Wrap(err, "Unmarshal failed")
+	if err := config.mergeEnv(); err != nil {
+		return nil, errors.Wrap(err, "environment")
 	}
 
-	config.Discord.Voice = VoiceConfig{
-		EncodeOptions: *dca.StdEncodeOptions,
+	if config.Cluster.Instance == "" {
+		config.Cluster.Instance, _ = os.Hostname()
 	}
+
+	config.Discord.Voice.EncodeOptions = *dca.StdEncodeOptions
 	return &config, nil
 }
+
+func (c *Config) mergeEnv() error {
+	if err := envBool(&c.General.Debug, "HALVA_DEBUG"); err != nil {
+		return err
+	}
+	if err := envBool(&c.General.Maintenance, "HALVA_MAINTENANCE"); err != nil {
+		return err
+	}
+	if v, ok := os.LookupEnv("HALVA_COGS"); ok {
+		c.Cogs = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	}
+	if v, ok := os.LookupEnv("HALVA_WEBHOOKS"); ok {
+		c.Webhooks = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	}
+	envString(&c.Host.IP, "HALVA_HOST_IP")
+	envString(&c.Host.Bot, "HALVA_HOST_BOT")
+	envString(&c.Host.Mock, "HALVA_HOST_MOCK")
+	envString(&c.Host.Web, "HALVA_HOST_WEB")
+	envString(&c.Host.URL, "HALVA_HOST_URL")
+	envString(&c.Credentials.Google, "HALVA_GOOGLE_CREDENTIALS")
+	envString(&c.Credentials.Firebase, "HALVA_FIREBASE_CREDENTIALS")
+	envString(&c.Discord.Token, "HALVA_DISCORD_TOKEN")
+	envString(&c.Discord.Prefix, "HALVA_DISCORD_PREFIX")
+	if err := envBool(&c.Discord.Voice.FFmpeg.Enabled, "HALVA_FFMPEG_ENABLED"); err != nil {
+		return err
+	}
+	envString(&c.Discord.Voice.FFmpeg.Path, "HALVA_FFMPEG_PATH")
+	if err := envBool(&c.Discord.Voice.TTS.Enabled, "HALVA_TTS_ENABLED"); err != nil {
+		return err
+	}
+	envString(&c.Discord.Voice.TTS.Path, "HALVA_TTS_PATH")
+	if err := envBool(&c.Discord.Voice.Headless.Enabled, "HALVA_HEADLESS_ENABLED"); err != nil {
+		return err
+	}
+	envString(&c.Discord.Voice.Headless.GuildID, "HALVA_HEADLESS_GUILD")
+	envString(&c.Discord.Voice.Headless.ChannelID, "HALVA_HEADLESS_CHANNEL")
+	envString(&c.Discord.Voice.Headless.Dir, "HALVA_HEADLESS_DIR")
+	envString(&c.Recording.Dir, "HALVA_RECORDING_DIR")
+	envString(&c.Recording.URL, "HALVA_RECORDING_URL")
+	envString(&c.Export.YouTube.ClientID, "HALVA_EXPORT_YOUTUBE_CLIENT_ID")
+	envString(&c.Export.YouTube.ClientSecret, "HALVA_EXPORT_YOUTUBE_CLIENT_SECRET")
+	envString(&c.Export.Spotify.ClientID, "HALVA_EXPORT_SPOTIFY_CLIENT_ID")
+	envString(&c.Export.Spotify.ClientSecret, "HALVA_EXPORT_SPOTIFY_CLIENT_SECRET")
+	envString(&c.Broker.NATS.URL, "HALVA_NATS_URL")
+	envString(&c.Broker.Kafka.URL, "HALVA_KAFKA_URL")
+	envString(&c.Redis.URL, "HALVA_REDIS_URL")
+	envString(&c.Failover.Role, "HALVA_FAILOVER_ROLE")
+	if err := envBool(&c.Youtube.Download, "HALVA_YOUTUBE_DOWNLOAD"); err != nil {
+		return err
+	}
+	envString(&c.Youtube.OutputDir, "HALVA_YOUTUBE_OUTPUT")
+	if v, ok := os.LookupEnv("HALVA_YOUTUBE_API_KEYS"); ok {
+		c.Youtube.APIKeys = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	}
+	envString(&c.Chess.Token, "HALVA_CHESS_TOKEN")
+	envString(&c.Chess.ClientID, "HALVA_CHESS_CLIENT_ID")
+	envString(&c.Chess.RedirectURL, "HALVA_CHESS_REDIRECT_URL")
+	envString(&c.Chess.DigestChannel, "HALVA_CHESS_DIGEST_CHANNEL")
+	envString(&c.Chess.TeamID, "HALVA_CHESS_TEAM_ID")
+	envString(&c.Sentry.DSN, "HALVA_SENTRY_DSN")
+	envString(&c.Sentry.Environment, "HALVA_SENTRY_ENVIRONMENT")
+	if err := envBool(&c.Cluster.Enabled, "HALVA_CLUSTER_ENABLED"); err != nil {
+		return err
+	}
+	envString(&c.Cluster.Instance, "HALVA_CLUSTER_INSTANCE")
+	envString(&c.Admin.Token, "HALVA_ADMIN_TOKEN")
+	envString(&c.Alerts.Channel, "HALVA_ALERTS_CHANNEL")
+	envString(&c.Log.Level, "HALVA_LOG_LEVEL")
+	envString(&c.Log.Format, "HALVA_LOG_FORMAT")
+	return nil
+}
+
+// Validate checks the fields the bot can't start without and reports all problems at once
+func (c *Config) Validate() error {
+	problems := make([]string, 0)
+	if c.Discord.Token == "" {
+		problems = append(problems, "discord.token (HALVA_DISCORD_TOKEN) is required")
+	}
+	if len(c.Cogs) == 0 {
+		problems = append(problems, "cogs (HALVA_COGS) must list at least one cog")
+	}
+	if c.Discord.Prefix == "" {
+		problems = append(problems, "discord.prefix (HALVA_DISCORD_PREFIX) is required")
+	}
+	if _, err := strconv.ParseUint(c.Host.Bot, 10, 16); err != nil {
+		problems = append(problems, fmt.Sprintf("host.bot (HALVA_HOST_BOT) must be a port, got %q", c.Host.Bot))
+	}
+	for name, path := range map[string]string{
+		"credentials.google (HALVA_GOOGLE_CREDENTIALS)":     c.Credentials.Google,
+		"credentials.firebase (HALVA_FIREBASE_CREDENTIALS)": c.Credentials.Firebase,
+	} {
+		if _, err := os.Stat(path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: file %q is not readable", name, path))
+		}
+	}
+	if c.Discord.Voice.MaxBitrate < 0 || c.Discord.Voice.MaxBitrate > 512 {
+		problems = append(problems, "discord.voice.max_bitrate must be between 0 and 512 kbps")
+	}
+	if h := c.Discord.Voice.Headless; h.Enabled && (h.GuildID == "" || h.ChannelID == "") {
+		problems = append(problems, "discord.voice.headless.guild and channel (HALVA_HEADLESS_GUILD, HALVA_HEADLESS_CHANNEL) are required in the headless mode")
+	}
+	if c.Cache.SongsTTL.Duration <= 0 || c.Cache.ShortRefresh.Duration <= 0 {
+		problems = append(problems, "cache durations must be positive")
+	}
+	if c.Cache.FramesDir != "" && c.Cache.FramesSizeMB <= 0 {
+		problems = append(problems, "cache.frames_size_mb must be positive when frames_dir is set")
+	}
+	if c.Recording.Dir != "" && (c.Recording.MaxLength.Duration <= 0 || c.Recording.Keep.Duration <= c.Recording.MaxLength.Duration) {
+		problems = append(problems, "recording.max_length must be positive and shorter than recording.keep")
+	}
+	if c.Cluster.Enabled {
+		if c.Cluster.Instance == "" {
+			problems = append(problems, "cluster.instance (HALVA_CLUSTER_INSTANCE) is required in a cluster")
+		}
+		if c.Cluster.LeaseTTL.Duration <= 0 || c.Cluster.StateInterval.Duration <= 0 {
+			problems = append(problems, "cluster durations must be positive")
+		}
+	}
+	if c.Failover.Role != "" {
+		if c.Failover.Role != "primary" && c.Failover.Role != "standby" {
+			problems = append(problems, "failover.role (HALVA_FAILOVER_ROLE) is primary or standby")
+		}
+		if c.Cluster.Enabled {
+			problems = append(problems, "failover and cluster can't be enabled together")
+		}
+		if c.Failover.Interval.Duration <= 0 || c.Failover.StateInterval.Duration <= 0 || c.Failover.Timeout.Duration <= c.Failover.Interval.Duration {
+			problems = append(problems, "failover durations must be positive and the timeout longer than the interval")
+		}
+	}
+	if c.Youtube.MaxSearchResult <= 0 {
+		problems = append(problems, "youtube.max_search_result must be positive")
+	}
+	keys := int64(len(c.Youtube.APIKeys))
+	if keys == 0 {
+		keys = 1
+	}
+	if c.Youtube.ReserveQuota < 0 || c.Youtube.ReserveQuota > c.Youtube.DailyQuota*keys {
+		problems = append(problems, "youtube.reserve_quota must be between 0 and the daily quota of all keys")
+	}
+	if c.Youtube.Download && c.Youtube.OutputDir == "" {
+		problems = append(problems, "youtube.output (HALVA_YOUTUBE_OUTPUT) is required when download is enabled")
+	}
+	if c.Youtube.Download && c.Youtube.CacheSizeMB <= 0 {
+		problems = append(problems, "youtube.cache_size_mb must be positive when download is enabled")
+	}
+	if c.Youtube.Download && c.Youtube.DownloadWorkers <= 0 {
+		problems = append(problems, "youtube.download_workers must be positive when download is enabled")
+	}
+	if c.Alerts.Channel != "" {
+		if c.Alerts.Interval.Duration <= 0 || c.Alerts.Cooldown.Duration < 0 {
+			problems = append(problems, "alerts.interval must be positive and alerts.cooldown not negative")
+		}
+		if c.Alerts.Errors < 0 || c.Alerts.WriteFailures < 0 || c.Alerts.Reconnects < 0 {
+			problems = append(problems, "alerts thresholds must not be negative")
+		}
+		if c.Alerts.QuotaLeft < 0 || c.Alerts.QuotaLeft >= 1 {
+			problems = append(problems, "alerts.quota_left is a fraction of the daily quota from 0 to 1")
+		}
+	}
+	problems = append(problems, c.Log.Validate()...)
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func envString(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func envBool(dst *bool, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return errors.Wrapf(err, "%s must be a boolean", key)
+	}
+	*dst = b
+	return nil
+}
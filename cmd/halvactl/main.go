@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const usage = `halvactl controls the bot over its api.
+
+Usage:
+  halvactl [-url URL] [-token TOKEN] <command> [args]
+
+Commands:
+  play <song>       queue the song by name or link
+  playnext <song>   put the song at the front of the queue
+  skip              skip the current song
+  stop              stop the playback and clear the queue
+  now               show the current song
+  queue             list the queue
+  remove <pos>      remove the song at the position of queue
+  caches            list the caches
+  reload [cache..]  read the caches from Firestore again, all of them if none is given
+  events            print the player events until interrupted
+  maintenance [on [reason..]|off]
+                    show or switch the maintenance mode
+
+The url is the bot port, HALVACTL_URL by default. The token is HALVACTL_TOKEN or HALVA_ADMIN_TOKEN,
+the admin token for caches, reload and maintenance, the token of a linked account counts the songs for its user.
+`
+
+// requestTimeout the search of a song takes a while
+const requestTimeout = 30 * time.Second
+
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func main() {
+	flags := flag.NewFlagSet("halvactl", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	base := flags.String("url", os.Getenv("HALVACTL_URL"), "url of the bot api")
+	token := flags.String("token", firstEnv("HALVACTL_TOKEN", "HALVA_ADMIN_TOKEN"), "bearer token")
+	_ = flags.Parse(os.Args[1:])
+	if flags.NArg() == 0 || *base == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	c := &client{
+		base:  strings.TrimSuffix(*base, "/") + "/api/v1",
+		token: *token,
+		http:  &http.Client{Timeout: requestTimeout},
+	}
+	if err := c.run(flags.Arg(0), flags.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "halvactl:", err)
+		os.Exit(1)
+	}
+}
+
+func firstEnv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (c *client) run(cmd string, args []string) error {
+	switch cmd {
+	case "play", "playnext":
+		if len(args) == 0 {
+			return errors.New("the song is missing")
+		}
+		path := "/music/enqueue"
+		if cmd == "playnext" {
+			path = "/music/playnext"
+		}
+		var resp struct {
+			Song      pkg.Song `json:"song"`
+			Playbacks int      `json:"playbacks_count"`
+		}
+		if err := c.do(http.MethodPost, path, map[string]string{"song": strings.Join(args, " ")}, &resp); err != nil {
+			return err
+		}
+		fmt.Printf("queued %s, played %d times\n", songLine(&resp.Song), resp.Playbacks)
+	case "skip":
+		return c.do(http.MethodGet, "/music/skip", nil, nil)
+	case "stop":
+		return c.do(http.MethodGet, "/music/stop", nil, nil)
+	case "now":
+		var status pkg.PlayerStatus
+		if err := c.do(http.MethodGet, "/music/status", nil, &status); err != nil {
+			return err
+		}
+		if status.Now == nil || status.Now.Title == "" {
+			fmt.Println("nothing is playing")
+			return nil
+		}
+		fmt.Printf("%s %s/%s loop=%t radio=%t\n", songLine(status.Now),
+			time.Duration(status.Song.Pos)*time.Second, time.Duration(status.Song.Duration)*time.Second,
+			status.Loop, status.Radio)
+	case "queue":
+		var resp struct {
+			Songs []*pkg.Song `json:"songs"`
+		}
+		if err := c.do(http.MethodGet, "/music/queue", nil, &resp); err != nil {
+			return err
+		}
+		for i, s := range resp.Songs {
+			fmt.Printf("%d\t%s\n", i, songLine(s))
+		}
+	case "remove":
+		if len(args) != 1 {
+			return errors.New("the position is missing")
+		}
+		var song pkg.Song
+		if err := c.do(http.MethodDelete, "/music/queue/"+url.PathEscape(args[0]), nil, &song); err != nil {
+			return err
+		}
+		fmt.Println("removed", songLine(&song))
+	case "caches", "reload":
+		var resp struct {
+			Caches []string `json:"caches"`
+		}
+		var err error
+		if cmd == "caches" {
+			err = c.do(http.MethodGet, "/admin/caches", nil, &resp)
+		} else {
+			err = c.do(http.MethodPost, "/admin/caches/reload", map[string][]string{"caches": args}, &resp)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.Join(resp.Caches, "\n"))
+	case "maintenance":
+		var status struct {
+			Enabled bool   `json:"enabled"`
+			Reason  string `json:"reason"`
+		}
+		var err error
+		switch {
+		case len(args) == 0:
+			err = c.do(http.MethodGet, "/admin/maintenance", nil, &status)
+		case args[0] == "on" || args[0] == "off":
+			err = c.do(http.MethodPut, "/admin/maintenance", map[string]interface{}{
+				"enabled": args[0] == "on",
+				"reason":  strings.Join(args[1:], " "),
+			}, &status)
+		default:
+			return errors.New("maintenance is switched on or off")
+		}
+		if err != nil {
+			return err
+		}
+		if !status.Enabled {
+			fmt.Println("maintenance off")
+			return nil
+		}
+		fmt.Println("maintenance on", status.Reason)
+	case "events":
+		return c.events()
+	default:
+		return errors.Errorf("unknown command %s, see halvactl -h", cmd)
+	}
+	return nil
+}
+
+// do the request with the json body, the json answer is decoded into out if it isn't nil
+func (c *client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "read answer")
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var msg struct {
+			Message string `json:"message"`
+			Error   string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &msg)
+		if msg.Message == "" {
+			msg.Message = msg.Error
+		}
+		if msg.Message == "" {
+			msg.Message = http.StatusText(resp.StatusCode)
+		}
+		return errors.Errorf("%s %s: %s", method, path, msg.Message)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(data, out), "decode answer")
+}
+
+// events are printed one per line until the connection drops
+func (c *client) events() error {
+	u := "ws" + strings.TrimPrefix(c.base, "http") + "/music/events"
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(u, header)
+	if err != nil {
+		return errors.Wrap(err, "connect to the events")
+	}
+	defer conn.Close()
+	for {
+		var e player.Event
+		if err := conn.ReadJSON(&e); err != nil {
+			return errors.Wrap(err, "read event")
+		}
+		line := e.Time.Format("15:04:05") + " " + string(e.Type)
+		if e.Song != nil {
+			line += " " + songLine(e.Song)
+		}
+		if e.Error != "" {
+			line += " " + e.Error
+		}
+		fmt.Printf("%s queue=%d\n", line, e.Queue)
+	}
+}
+
+func songLine(s *pkg.Song) string {
+	if s.ArtistName == "" {
+		return s.Title
+	}
+	return s.ArtistName + " - " + s.Title
+}
@@ -0,0 +1,356 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	codeExpiration = 10 * time.Minute
+	// maxAttempts the code is dropped after so many wrong guesses
+	maxAttempts = 5
+	codeDigits  = 6
+	tokenBytes  = 32
+	// MaxNameLength of the name the client gives its link
+	MaxNameLength = 64
+	// MaxTokens of a user minted in Discord, the expired ones don't count
+	MaxTokens = 10
+	// DefaultTokenTTL of a minted token without the ttl
+	DefaultTokenTTL = 30 * 24 * time.Hour
+	MinTokenTTL     = time.Hour
+	MaxTokenTTL     = 365 * 24 * time.Hour
+	// minRevokePrefix of the link id typed to revoke it
+	minRevokePrefix = 8
+)
+
+// Scopes of the minted tokens, a linked client has them all
+const (
+	ScopeQueueRead  = "queue.read"
+	ScopeQueueWrite = "queue.write"
+	ScopeProfile    = "profile.read"
+	ScopeMyData     = "mydata"
+	ScopeAccount    = "account"
+)
+
+var Scopes = []string{ScopeQueueRead, ScopeQueueWrite, ScopeProfile, ScopeMyData, ScopeAccount}
+
+var (
+	ErrWrongCode = errors.New("wrong or expired code")
+	// ErrCodePending a code was sent recently, it has to be used or expire first
+	ErrCodePending = errors.New("a code was sent already")
+	ErrUnknownLink = errors.New("unknown link")
+	// ErrAmbiguousLink the prefix of the id matches several links
+	ErrAmbiguousLink = errors.New("several links start with the id")
+	ErrInvalidScope  = errors.Errorf("scopes are %s", strings.Join(Scopes, ", "))
+	ErrInvalidTTL    = errors.New("ttl is from 1h to 365d, like 12h or 30d")
+	ErrTooManyTokens = errors.Errorf("you have %d tokens already", MaxTokens)
+)
+
+// Link of a web or api client to a discord user, ID is the hash of the token the client holds
+type Link struct {
+	ID      string    `firestore:"-" json:"id"`
+	UserID  string    `firestore:"user" json:"user_id"`
+	Name    string    `firestore:"name" json:"name"`
+	Created time.Time `firestore:"created" json:"created"`
+	// Scopes of the token minted in Discord, empty for a linked client
+	Scopes []string `firestore:"scopes,omitempty" json:"scopes,omitempty"`
+	// Expires the minted token, zero for a linked client
+	Expires time.Time `firestore:"expires,omitempty" json:"expires"`
+}
+
+// Token is minted in Discord
+func (l *Link) Token() bool {
+	return len(l.Scopes) != 0
+}
+
+// Allows the requests of the scope
+func (l *Link) Allows(scope string) bool {
+	if !l.Token() {
+		return true
+	}
+	for _, sc := range l.Scopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Link) Expired(now time.Time) bool {
+	return !l.Expires.IsZero() && now.After(l.Expires)
+}
+
+// ParseScopes comma separated, at least one
+func ParseScopes(s string) ([]string, error) {
+	res := make([]string, 0, len(Scopes))
+	seen := make(map[string]bool, len(Scopes))
+	for _, p := range strings.Split(strings.ToLower(s), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		known := false
+		for _, sc := range Scopes {
+			known = known || sc == p
+		}
+		if !known {
+			return nil, ErrInvalidScope
+		}
+		seen[p] = true
+		res = append(res, p)
+	}
+	if len(res) == 0 {
+		return nil, ErrInvalidScope
+	}
+	return res, nil
+}
+
+type Storage interface {
+	SetLink(ctx context.Context, l *Link) error
+	GetLink(ctx context.Context, id string) (*Link, error)
+	DeleteLink(ctx context.Context, id string) error
+	UserLinks(ctx context.Context, userID string) ([]Link, error)
+}
+
+// Sender delivers the one-time code to the discord user
+type Sender interface {
+	SendCode(userID, code string) error
+}
+
+type pendingCode struct {
+	code     string
+	created  time.Time
+	attempts int
+}
+
+// Service links the tokens of the web and api clients to discord users,
+// so the songs and the lists of a user are the same in Discord and over http
+type Service struct {
+	storage Storage
+	sender  Sender
+
+	codesMx sync.Mutex
+	codes   map[string]*pendingCode // user id
+}
+
+func NewService(storage Storage, sender Sender) *Service {
+	return &Service{
+		storage: storage,
+		sender:  sender,
+		codes:   make(map[string]*pendingCode),
+	}
+}
+
+// SendCode to the discord user in the DM, one code at a time so the DMs can't be spammed
+func (s *Service) SendCode(userID string) error {
+	code, err := randomCode()
+	if err != nil {
+		return err
+	}
+	s.codesMx.Lock()
+	now := time.Now()
+	for k, v := range s.codes {
+		if now.Sub(v.created) > codeExpiration {
+			delete(s.codes, k)
+		}
+	}
+	if _, ok := s.codes[userID]; ok {
+		s.codesMx.Unlock()
+		return ErrCodePending
+	}
+	s.codes[userID] = &pendingCode{code: code, created: now}
+	s.codesMx.Unlock()
+
+	if err := s.sender.SendCode(userID, code); err != nil {
+		s.codesMx.Lock()
+		delete(s.codes, userID)
+		s.codesMx.Unlock()
+		return errors.Wrap(err, "send code")
+	}
+	return nil
+}
+
+// Link the client with the code sent to the user, the token is returned only once
+func (s *Service) Link(ctx context.Context, userID, code, name string) (string, *Link, error) {
+	if err := s.useCode(userID, code); err != nil {
+		return "", nil, err
+	}
+	return s.mint(ctx, &Link{UserID: userID, Name: name})
+}
+
+// CreateToken with the scopes for the user, the token is returned only once
+func (s *Service) CreateToken(ctx context.Context, userID, name string, scopes []string, ttl time.Duration) (string, *Link, error) {
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	if ttl < MinTokenTTL || ttl > MaxTokenTTL {
+		return "", nil, ErrInvalidTTL
+	}
+	links, err := s.Links(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	tokens := 0
+	for i := range links {
+		switch {
+		case !links[i].Token():
+		case links[i].Expired(now):
+			if err := s.unlink(ctx, links[i].ID); err != nil {
+				return "", nil, err
+			}
+		default:
+			tokens++
+		}
+	}
+	if tokens >= MaxTokens {
+		return "", nil, ErrTooManyTokens
+	}
+	return s.mint(ctx, &Link{UserID: userID, Name: name, Scopes: scopes, Expires: now.Add(ttl)})
+}
+
+// mint the token of the link, the id and the creation time are set
+func (s *Service) mint(ctx context.Context, l *Link) (string, *Link, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, errors.Wrap(err, "generate token")
+	}
+	token := hex.EncodeToString(b)
+	if len(l.Name) > MaxNameLength {
+		l.Name = l.Name[:MaxNameLength]
+	}
+	l.ID = hashToken(token)
+	l.Created = time.Now()
+	if err := s.storage.SetLink(ctx, l); err != nil {
+		return "", nil, errors.Wrap(err, "store link")
+	}
+	return token, l, nil
+}
+
+func (s *Service) useCode(userID, code string) error {
+	s.codesMx.Lock()
+	defer s.codesMx.Unlock()
+	p, ok := s.codes[userID]
+	if !ok || time.Since(p.created) > codeExpiration {
+		delete(s.codes, userID)
+		return ErrWrongCode
+	}
+	if subtle.ConstantTimeCompare([]byte(p.code), []byte(code)) != 1 {
+		p.attempts++
+		if p.attempts >= maxAttempts {
+			delete(s.codes, userID)
+		}
+		return ErrWrongCode
+	}
+	delete(s.codes, userID)
+	return nil
+}
+
+// Identify the user of the token and its scopes, empty for a linked client.
+// ErrUnknownLink if it isn't linked, expired or revoked. The link is read from the storage every time,
+// so a token revoked on one instance stops working on all of them.
+func (s *Service) Identify(ctx context.Context, token string) (string, []string, error) {
+	l, err := s.storage.GetLink(ctx, hashToken(token))
+	if err != nil {
+		return "", nil, err
+	}
+	if l.Expired(time.Now()) {
+		return "", nil, ErrUnknownLink
+	}
+	return l.UserID, l.Scopes, nil
+}
+
+// Links of the discord user
+func (s *Service) Links(ctx context.Context, userID string) ([]Link, error) {
+	links, err := s.storage.UserLinks(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "user links")
+	}
+	return links, nil
+}
+
+// Unlink the client of the user, ErrUnknownLink if the user has no such link
+func (s *Service) Unlink(ctx context.Context, userID, id string) error {
+	l, err := s.storage.GetLink(ctx, id)
+	if err != nil {
+		return err
+	}
+	if l.UserID != userID {
+		return ErrUnknownLink
+	}
+	return s.unlink(ctx, id)
+}
+
+// Revoke the link of the user by the start of its id, the list in Discord shows only the start
+func (s *Service) Revoke(ctx context.Context, userID, prefix string) (*Link, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < minRevokePrefix {
+		return nil, ErrUnknownLink
+	}
+	links, err := s.Links(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var found *Link
+	for i := range links {
+		if !strings.HasPrefix(links[i].ID, prefix) {
+			continue
+		}
+		if found != nil {
+			return nil, ErrAmbiguousLink
+		}
+		found = &links[i]
+	}
+	if found == nil {
+		return nil, ErrUnknownLink
+	}
+	if err := s.unlink(ctx, found.ID); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// UnlinkAll the clients of the user, returns how many were unlinked
+func (s *Service) UnlinkAll(ctx context.Context, userID string) (int, error) {
+	links, err := s.Links(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range links {
+		if err := s.unlink(ctx, links[i].ID); err != nil {
+			return i, err
+		}
+	}
+	return len(links), nil
+}
+
+func (s *Service) unlink(ctx context.Context, id string) error {
+	if err := s.storage.DeleteLink(ctx, id); err != nil {
+		return errors.Wrap(err, "delete link")
+	}
+	return nil
+}
+
+// hashToken only the hashes are stored, the tokens can't be taken from the database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", errors.Wrap(err, "generate code")
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n.Int64()), nil
+}
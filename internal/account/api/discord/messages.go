@@ -0,0 +1,69 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+)
+
+// shortID of the link in the list, enough to revoke it
+const shortID = 8
+
+const (
+	messageInvalidValue = ":x: **%s**"
+	messageNotFound     = ":x: **No token starts with the id, see the list**"
+	messageCreated      = ":key: **Token with %s, expires <t:%d:R>.** It is shown only once, " +
+		"pass it as `Authorization: Bearer <token>`:\n||`%s`||"
+	messageRevoked  = ":x: **Revoked** `%s`"
+	messageNoTokens = "**No tokens and no linked clients**"
+	messageSentDM   = ":envelope: **The answer is sent in DM**"
+	messageDMClosed = ":x: **The DM couldn't be sent, allow the direct messages from the server members**"
+	messageUsage    = "`%[1]stoken create <queue.read,queue.write,...> [ttl] [name]` create a token, 30d by default\n" +
+		"`%[1]stoken list` your tokens and linked clients\n" +
+		"`%[1]stoken revoke <id>` revoke a token or unlink a client"
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, channelID, msg string) {
+	s.sendComplexMessage(ds, channelID, &discordgo.MessageSend{Content: msg})
+}
+
+// linksList the newest first, a linked client has all the scopes and never expires
+func linksList(links []account.Link, now time.Time) string {
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Created.After(links[j].Created)
+	})
+	lines := make([]string, 0, len(links))
+	for i := range links {
+		l := &links[i]
+		name := l.Name
+		if name == "" {
+			name = "unnamed"
+		}
+		switch {
+		case !l.Token():
+			lines = append(lines, fmt.Sprintf("`%s` %s, linked client", l.ID[:shortID], name))
+		case l.Expired(now):
+			lines = append(lines, fmt.Sprintf("`%s` %s, %s, expired", l.ID[:shortID], name, strings.Join(l.Scopes, ", ")))
+		default:
+			lines = append(lines, fmt.Sprintf("`%s` %s, %s, expires <t:%d:R>", l.ID[:shortID], name, strings.Join(l.Scopes, ", "), l.Expires.Unix()))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
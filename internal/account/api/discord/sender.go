@@ -0,0 +1,33 @@
+package discord
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+const messageCode = ":key: **%s** is the code to link your account, it expires in 10 minutes.\n" +
+	"Ignore it if you didn't ask for it."
+
+// Sender DMs the codes of the account links
+type Sender struct {
+	session *discordgo.Session
+}
+
+func NewSender(session *discordgo.Session) *Sender {
+	return &Sender{
+		session: session,
+	}
+}
+
+func (s *Sender) SendCode(userID, code string) error {
+	channel, err := s.session.UserChannelCreate(userID)
+	if err != nil {
+		return errors.Wrapf(err, "open dm with %s", userID)
+	}
+	if _, err := s.session.ChannelMessageSend(channel.ID, fmt.Sprintf(messageCode, code)); err != nil {
+		return errors.Wrapf(err, "dm %s", userID)
+	}
+	return nil
+}
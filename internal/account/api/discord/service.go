@@ -0,0 +1,228 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "tokens"
+
+const (
+	tokenCommand = "token"
+
+	create = "create"
+	list   = "list"
+	revoke = "revoke"
+)
+
+var tokenSlashCommand = &discordgo.ApplicationCommand{
+	Name:        tokenCommand,
+	Description: "Personal tokens of the api",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        create,
+			Description: "Create a token, it is shown only once",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "scope",
+					Description: "Comma separated: " + strings.Join(account.Scopes, ","),
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "ttl",
+					Description: "Lifetime like 12h or 30d, 30d by default",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "Name in the list",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        list,
+			Description: "Your tokens and linked clients",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        revoke,
+			Description: "Revoke a token or unlink a client",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id",
+					Description: "The id from the list",
+					Required:    true,
+				},
+			},
+		},
+	},
+}
+
+type Tokens interface {
+	CreateToken(ctx context.Context, userID, name string, scopes []string, ttl time.Duration) (string, *account.Link, error)
+	Links(ctx context.Context, userID string) ([]account.Link, error)
+	Revoke(ctx context.Context, userID, prefix string) (*account.Link, error)
+}
+
+// Service mints the personal tokens of the api, the answers are seen only by the author
+type Service struct {
+	ctx    context.Context
+	tokens Tokens
+	prefix string
+	logger zap.Logger
+}
+
+func NewCog(ctx context.Context, tokens Tokens, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:    ctx,
+		tokens: tokens,
+		prefix: prefix,
+		logger: logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewGuildSlashCommand(tokenSlashCommand, s.tokenSlashHandler).RegisterCommand(session, logger)
+	command.NewMessageCommand(s.prefix+tokenCommand, s.tokenMessageHandler, debug).RegisterCommand(session, logger)
+}
+
+// RegisterRoutes the tokens are used by the api itself, see internal/api/v1
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// tokenSlashHandler answers with the ephemeral message
+func (s *Service) tokenSlashHandler(ds *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := command.InteractionUser(i)
+	data := i.ApplicationCommandData()
+	if user == nil || len(data.Options) == 0 {
+		return
+	}
+	sub := data.Options[0]
+	values := make(map[string]string, len(sub.Options))
+	for _, o := range sub.Options {
+		values[o.Name] = o.StringValue()
+	}
+	var content string
+	switch sub.Name {
+	case create:
+		content = s.create(user.ID, values["scope"], values["ttl"], values["name"])
+	case list:
+		content = s.list(user.ID)
+	case revoke:
+		content = s.revoke(user.ID, values["id"])
+	}
+	if err := command.RespondEphemeral(ds, i, content); err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to token command"))
+	}
+}
+
+// tokenMessageHandler the servers without the slash commands use the prefix, the answer is sent in DM
+func (s *Service) tokenMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+tokenCommand))
+	var content string
+	switch {
+	case len(args) >= 2 && len(args) <= 4 && strings.EqualFold(args[0], create):
+		ttl, name := "", ""
+		if len(args) > 2 {
+			ttl = args[2]
+		}
+		if len(args) > 3 {
+			name = args[3]
+		}
+		content = s.create(m.Author.ID, args[1], ttl, name)
+	case len(args) == 1 && strings.EqualFold(args[0], list):
+		content = s.list(m.Author.ID)
+	case len(args) == 2 && strings.EqualFold(args[0], revoke):
+		content = s.revoke(m.Author.ID, args[1])
+	default:
+		s.sendStringMessage(ds, m.ChannelID, fmt.Sprintf(messageUsage, s.prefix))
+		return
+	}
+	channel, err := ds.UserChannelCreate(m.Author.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "create dm channel"))
+		s.sendStringMessage(ds, m.ChannelID, discord.MessageInternalError)
+		return
+	}
+	if _, err := ds.ChannelMessageSend(channel.ID, content); err != nil {
+		s.sendStringMessage(ds, m.ChannelID, messageDMClosed)
+		return
+	}
+	if m.GuildID != "" {
+		s.sendStringMessage(ds, m.ChannelID, messageSentDM)
+	}
+}
+
+func (s *Service) create(userID, scope, ttl, name string) string {
+	scopes, err := account.ParseScopes(scope)
+	if err != nil {
+		return s.errorMessage(err)
+	}
+	lifetime := account.DefaultTokenTTL
+	if ttl != "" {
+		if lifetime, err = util.ParseDuration(ttl); err != nil {
+			return s.errorMessage(account.ErrInvalidTTL)
+		}
+	}
+	token, l, err := s.tokens.CreateToken(s.ctx, userID, name, scopes, lifetime)
+	if err != nil {
+		return s.errorMessage(err)
+	}
+	return fmt.Sprintf(messageCreated, strings.Join(l.Scopes, ", "), l.Expires.Unix(), token)
+}
+
+func (s *Service) list(userID string) string {
+	links, err := s.tokens.Links(s.ctx, userID)
+	if err != nil {
+		return s.errorMessage(err)
+	}
+	if len(links) == 0 {
+		return messageNoTokens
+	}
+	return linksList(links, time.Now())
+}
+
+func (s *Service) revoke(userID, id string) string {
+	l, err := s.tokens.Revoke(s.ctx, userID, id)
+	if err != nil {
+		return s.errorMessage(err)
+	}
+	return fmt.Sprintf(messageRevoked, l.ID[:shortID])
+}
+
+func (s *Service) errorMessage(err error) string {
+	switch {
+	case errors.Is(err, account.ErrUnknownLink):
+		return messageNotFound
+	case errors.Is(err, account.ErrAmbiguousLink), errors.Is(err, account.ErrInvalidScope),
+		errors.Is(err, account.ErrInvalidTTL), errors.Is(err, account.ErrTooManyTokens):
+		return fmt.Sprintf(messageInvalidValue, err)
+	}
+	s.logger.Error(errors.Wrap(err, "tokens"))
+	return discord.MessageInternalError
+}
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+)
+
+// code godoc
+// @summary  DM a one-time code to the discord user to link this client
+// @accept   json
+// @produce  json
+// @param    request  body      codeRequest  true  "Discord user ID"
+// @success  202      {object}  Response     "The code was sent"
+// @failure  400      {object}  Response     "Incorrect input"
+// @failure  429      {object}  Response     "A code was sent already, it expires in 10 minutes"
+// @failure  500      {object}  Response     "The DM couldn't be sent"
+// @router   /accounts/code [post]
+func (h *Handler) codeHandler(c *gin.Context) {
+	var req codeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	err := h.accounts.SendCode(req.UserID)
+	if errors.Is(err, account.ErrCodePending) {
+		c.JSON(http.StatusTooManyRequests, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, Response{Message: "the code was sent in a DM"})
+}
+
+// link godoc
+// @summary  Link this client to the discord user with the code from the DM
+// @accept   json
+// @produce  json
+// @param    request  body      linkRequest   true  "Discord user ID, the code and the name of the client"
+// @success  200      {object}  LinkResponse  "The token of the client"
+// @failure  400      {object}  Response      "Incorrect input"
+// @failure  403      {object}  Response      "Wrong or expired code"
+// @failure  500      {object}  Response      "Database error"
+// @router   /accounts/link [post]
+func (h *Handler) linkHandler(c *gin.Context) {
+	var req linkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	token, link, err := h.accounts.Link(c.Request.Context(), req.UserID, req.Code, req.Name)
+	if errors.Is(err, account.ErrWrongCode) {
+		c.JSON(http.StatusForbidden, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, LinkResponse{Token: token, Link: *link})
+}
+
+// me godoc
+// @summary   The discord user of the token and the linked clients
+// @produce   json
+// @success   200  {object}  MeResponse
+// @failure   401  {object}  Response  "The client isn't linked"
+// @failure   500  {object}  Response  "Database error"
+// @security  AccountToken
+// @router    /accounts/me [get]
+func (h *Handler) meHandler(c *gin.Context) {
+	userID := v1.UserID(c)
+	links, err := h.accounts.Links(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, MeResponse{UserID: userID, Links: links})
+}
+
+// unlink godoc
+// @summary   Unlink a client of the user, its token stops working
+// @produce   json
+// @param     id   path      string    true  "Link ID"
+// @success   204
+// @failure   401  {object}  Response  "The client isn't linked"
+// @failure   404  {object}  Response  "The user has no such link"
+// @failure   500  {object}  Response  "Database error"
+// @security  AccountToken
+// @router    /accounts/me/links/{id} [delete]
+func (h *Handler) unlinkHandler(c *gin.Context) {
+	err := h.accounts.Unlink(c.Request.Context(), v1.UserID(c), c.Param("id"))
+	if errors.Is(err, account.ErrUnknownLink) {
+		c.JSON(http.StatusNotFound, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+)
+
+type Accounts interface {
+	SendCode(userID string) error
+	Link(ctx context.Context, userID, code, name string) (string, *account.Link, error)
+	Links(ctx context.Context, userID string) ([]account.Link, error)
+	Unlink(ctx context.Context, userID, id string) error
+}
+
+// Handler the super group must identify the users with v1.Identify
+type Handler struct {
+	accounts Accounts
+	super    *gin.RouterGroup
+}
+
+func NewHandler(accounts Accounts, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		accounts: accounts,
+		super:    superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/accounts")
+	group.POST("/code", h.codeHandler)
+	group.POST("/link", h.linkHandler)
+	me := group.Group("/me", v1.RequireUser(), v1.RequireScope(account.ScopeAccount))
+	me.GET("", h.meHandler)
+	me.DELETE("/links/:id", h.unlinkHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
+
+type codeRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+type linkRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Code   string `json:"code" binding:"required"`
+	// Name of the client shown in the list of the links
+	Name string `json:"name"`
+}
+
+type LinkResponse struct {
+	// Token is passed as Authorization: Bearer <token>, it is shown only once
+	Token string       `json:"token"`
+	Link  account.Link `json:"link"`
+}
+
+type MeResponse struct {
+	UserID string         `json:"user_id"`
+	Links  []account.Link `json:"links"`
+}
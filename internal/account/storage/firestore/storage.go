@@ -0,0 +1,82 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const linksCollection = "account_links"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) SetLink(ctx context.Context, l *account.Link) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetLink user:%s", l.UserID)
+	_, err := s.client.Collection(linksCollection).Doc(l.ID).Set(ctx, l)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set link of %s to %s", l.UserID, linksCollection)
+	}
+	return nil
+}
+
+func (s *Storage) GetLink(ctx context.Context, id string) (*account.Link, error) {
+	doc, err := s.client.Collection(linksCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, account.ErrUnknownLink
+		}
+		return nil, errors.Wrapf(err, "failed to get link from %s", linksCollection)
+	}
+	var l account.Link
+	if err := doc.DataTo(&l); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	l.ID = doc.Ref.ID
+	return &l, nil
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, id string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteLink %s", id)
+	_, err := s.client.Collection(linksCollection).Doc(id).Delete(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete link from %s", linksCollection)
+	}
+	return nil
+}
+
+func (s *Storage) UserLinks(ctx context.Context, userID string) ([]account.Link, error) {
+	iter := s.client.Collection(linksCollection).Where("user", "==", userID).Documents(ctx)
+	defer iter.Stop()
+	res := make([]account.Link, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get links of %s from %s", userID, linksCollection)
+		}
+		var l account.Link
+		if err := doc.DataTo(&l); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		l.ID = doc.Ref.ID
+		res = append(res, l)
+	}
+	return res, nil
+}
@@ -0,0 +1,272 @@
+package achievement
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	// nightStart and nightEnd hours in the timezone of the guild
+	nightStart = 0
+	nightEnd   = 5
+	// maxArtists the distinct artists kept in the progress, enough for the explorer
+	maxArtists    = 25
+	handleTimeout = 30 * time.Second
+)
+
+var ErrNotFound = errors.New("no progress")
+
+// Progress of the user in all guilds, the counters are of the songs started at the request of the user
+type Progress struct {
+	UserID     string `firestore:"user_id" json:"user_id"`
+	Plays      int    `firestore:"plays" json:"plays"`
+	NightPlays int    `firestore:"night_plays" json:"night_plays"`
+	// Artists distinct, up to maxArtists
+	Artists  []string             `firestore:"artists" json:"artists"`
+	Unlocked map[string]time.Time `firestore:"unlocked" json:"unlocked"`
+}
+
+// Achievement is unlocked when the value of the progress reaches the goal
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	Goal        int
+	value       func(p *Progress) int
+}
+
+// Value of the progress towards the goal, capped by it
+func (a *Achievement) Value(p *Progress) int {
+	v := a.value(p)
+	if v > a.Goal {
+		return a.Goal
+	}
+	return v
+}
+
+// All the achievements in the order they are shown
+var All = []Achievement{
+	{ID: "first_request", Name: "First request", Description: "request a song", Goal: 1, value: plays},
+	{ID: "songs_100", Name: "Regular", Description: "request 100 songs", Goal: 100, value: plays},
+	{ID: "songs_1000", Name: "Resident DJ", Description: "request 1000 songs", Goal: 1000, value: plays},
+	{ID: "night_owl", Name: "Night owl", Description: "request 10 songs after midnight", Goal: 10, value: func(p *Progress) int {
+		return p.NightPlays
+	}},
+	{ID: "explorer", Name: "Explorer", Description: fmt.Sprintf("request the songs of %d artists", maxArtists), Goal: maxArtists, value: func(p *Progress) int {
+		return len(p.Artists)
+	}},
+}
+
+func plays(p *Progress) int {
+	return p.Plays
+}
+
+type Storage interface {
+	// Progress returns ErrNotFound if the user has none
+	Progress(ctx context.Context, userID string) (*Progress, error)
+	// UpdateProgress in a transaction, the progress is empty if the user has none
+	UpdateProgress(ctx context.Context, userID string, f func(p *Progress, exists bool)) (*Progress, error)
+	DeleteProgress(ctx context.Context, userID string) (int, error)
+}
+
+// History of the requests, the progress of a new user starts from it
+type History interface {
+	UserSongs(ctx context.Context, userID string) ([]pkg.Song, error)
+	UserPlays(ctx context.Context, userID string, from, to time.Time) ([]pkg.Play, error)
+}
+
+type Settings interface {
+	Get(guildID string) guild.Settings
+}
+
+// Notifier posts the unlocked achievements in the guild
+type Notifier interface {
+	Unlocked(guildID, userID string, achievements []Achievement)
+}
+
+// Service counts the started songs of the users and unlocks the achievements
+type Service struct {
+	ctx      context.Context
+	storage  Storage
+	history  History
+	settings Settings
+	notifier Notifier
+	logger   zap.Logger
+
+	mx sync.Mutex
+	// last song started in the guild, a looped song counts once
+	last map[string]pkg.SongID
+}
+
+func NewService(ctx context.Context, storage Storage, history History, settings Settings, logger zap.Logger) *Service {
+	return &Service{
+		ctx:      ctx,
+		storage:  storage,
+		history:  history,
+		settings: settings,
+		logger:   logger,
+		last:     make(map[string]pkg.SongID),
+	}
+}
+
+// SetNotifier the unlocked achievements are only stored without it
+func (s *Service) SetNotifier(n Notifier) {
+	s.notifier = n
+}
+
+// HandleEvent of the player, subscribe it to player.TrackStarted
+func (s *Service) HandleEvent(e player.Event) {
+	if e.Type != player.TrackStarted || e.Song == nil || e.GuildID == "" {
+		return
+	}
+	s.mx.Lock()
+	repeat := s.last[e.GuildID] == e.Song.ID
+	s.last[e.GuildID] = e.Song.ID
+	s.mx.Unlock()
+	if repeat || e.Song.Requester == nil || e.Song.Requester.Bot {
+		return
+	}
+	ctx, cancel := context.WithTimeout(contexts.WithLogger(s.ctx, s.logger), handleTimeout)
+	defer cancel()
+	unlocked, err := s.count(ctx, e.Song, e.Song.Requester.ID, e.GuildID, e.Time)
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "count achievements of %s", e.Song.Requester.ID))
+		return
+	}
+	if len(unlocked) != 0 && s.notifier != nil {
+		s.notifier.Unlocked(e.GuildID, e.Song.Requester.ID, unlocked)
+	}
+}
+
+// count the song, the progress of a new user is taken from the history which has the song already
+func (s *Service) count(ctx context.Context, song *pkg.Song, userID, guildID string, at time.Time) ([]Achievement, error) {
+	seed, err := s.seed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	night := s.night(guildID, at)
+	var unlocked []Achievement
+	_, err = s.storage.UpdateProgress(ctx, userID, func(p *Progress, exists bool) {
+		if !exists && seed != nil {
+			*p = *seed
+		} else {
+			p.Plays++
+			if night {
+				p.NightPlays++
+			}
+			p.Artists = addArtist(p.Artists, song)
+		}
+		unlocked = unlock(p, time.Now())
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update progress")
+	}
+	for i := range unlocked {
+		achievementsUnlocked.WithLabelValues(unlocked[i].ID).Inc()
+	}
+	return unlocked, nil
+}
+
+// seed the progress of the user from the history, nil if the user has the progress already
+func (s *Service) seed(ctx context.Context, userID string) (*Progress, error) {
+	_, err := s.storage.Progress(ctx, userID)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, errors.Wrap(err, "progress")
+	}
+	plays, err := s.history.UserPlays(ctx, userID, time.Time{}, time.Now().Add(time.Minute))
+	if err != nil {
+		return nil, errors.Wrap(err, "user plays")
+	}
+	songs, err := s.history.UserSongs(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "user songs")
+	}
+	p := &Progress{UserID: userID, Plays: len(plays)}
+	for i := range plays {
+		if s.night(plays[i].GuildID, plays[i].Time) {
+			p.NightPlays++
+		}
+	}
+	for i := range songs {
+		p.Artists = addArtist(p.Artists, &songs[i])
+	}
+	return p, nil
+}
+
+func (s *Service) night(guildID string, at time.Time) bool {
+	settings := s.settings.Get(guildID)
+	hour := at.In(settings.Location()).Hour()
+	return hour >= nightStart && hour < nightEnd
+}
+
+// Get the progress of the user, it is taken from the history for a new user
+func (s *Service) Get(ctx context.Context, userID string) (*Progress, error) {
+	p, err := s.storage.Progress(ctx, userID)
+	if err == nil {
+		return p, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, errors.Wrap(err, "progress")
+	}
+	seed, err := s.seed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.UpdateProgress(ctx, userID, func(p *Progress, exists bool) {
+		if !exists && seed != nil {
+			*p = *seed
+		}
+		unlock(p, time.Now())
+	})
+}
+
+// unlock the reached achievements, returns the new ones
+func unlock(p *Progress, now time.Time) []Achievement {
+	if p.Unlocked == nil {
+		p.Unlocked = make(map[string]time.Time)
+	}
+	var res []Achievement
+	for i := range All {
+		a := &All[i]
+		if _, ok := p.Unlocked[a.ID]; ok || a.Value(p) < a.Goal {
+			continue
+		}
+		p.Unlocked[a.ID] = now
+		res = append(res, *a)
+	}
+	return res
+}
+
+// addArtist by the url or the name, the songs without the artist are skipped
+func addArtist(artists []string, song *pkg.Song) []string {
+	key := song.ArtistURL
+	if key == "" {
+		key = strings.ToLower(strings.TrimSpace(song.ArtistName))
+	}
+	if key == "" || len(artists) >= maxArtists {
+		return artists
+	}
+	for _, a := range artists {
+		if a == key {
+			return artists
+		}
+	}
+	return append(artists, key)
+}
@@ -0,0 +1,64 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/achievement"
+	"github.com/HalvaPovidlo/discordBotGo/internal/profile"
+)
+
+const messageUsage = "`%[1]sstats` your songs and achievements, `%[1]sstats @member` of the member"
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, channelID, msg string) {
+	s.sendComplexMessage(ds, channelID, &discordgo.MessageSend{Content: msg})
+}
+
+// unlockedMessage mentions only the user
+func unlockedMessage(userID string, achievements []achievement.Achievement) *discordgo.MessageSend {
+	names := make([]string, 0, len(achievements))
+	for i := range achievements {
+		names = append(names, fmt.Sprintf("**%s**, %s", achievements[i].Name, achievements[i].Description))
+	}
+	return &discordgo.MessageSend{
+		Content:         fmt.Sprintf(":trophy: <@%s> unlocked %s", userID, strings.Join(names, "; ")),
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{userID}},
+	}
+}
+
+func statsEmbed(user *discordgo.User, pr *profile.Profile, p *achievement.Progress) *discordgo.MessageEmbed {
+	lines := make([]string, 0, len(achievement.All))
+	unlocked := 0
+	for i := range achievement.All {
+		a := &achievement.All[i]
+		if at, ok := p.Unlocked[a.ID]; ok {
+			unlocked++
+			lines = append(lines, fmt.Sprintf(":trophy: **%s**, %s <t:%d:d>", a.Name, a.Description, at.Unix()))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(":lock: **%s**, %s %d/%d", a.Name, a.Description, a.Value(p), a.Goal))
+	}
+	return &discordgo.MessageEmbed{
+		Title: "Stats of " + user.Username,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Requests", Value: fmt.Sprint(pr.Playbacks), Inline: true},
+			{Name: "Songs", Value: fmt.Sprint(pr.Songs), Inline: true},
+			{Name: "Listened", Value: fmt.Sprintf("%.1fh in 30 days", pr.ListenedHours), Inline: true},
+			{Name: fmt.Sprintf("Achievements %d/%d", unlocked, len(achievement.All)), Value: strings.Join(lines, "\n")},
+		},
+	}
+}
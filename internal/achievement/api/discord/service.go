@@ -0,0 +1,108 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/achievement"
+	"github.com/HalvaPovidlo/discordBotGo/internal/profile"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "achievements"
+
+const statsCommand = "stats"
+
+type Achievements interface {
+	Get(ctx context.Context, userID string) (*achievement.Progress, error)
+}
+
+type Profiles interface {
+	Get(ctx context.Context, userID string) (*profile.Profile, error)
+}
+
+// Channels where the music of the guild is announced
+type Channels interface {
+	AnnounceChannel(guildID string) string
+}
+
+type Service struct {
+	ctx          context.Context
+	achievements Achievements
+	profiles     Profiles
+	channels     Channels
+	session      *discordgo.Session
+	prefix       string
+	logger       zap.Logger
+}
+
+func NewCog(ctx context.Context, achievements Achievements, profiles Profiles, channels Channels, session *discordgo.Session, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:          ctx,
+		achievements: achievements,
+		profiles:     profiles,
+		channels:     channels,
+		session:      session,
+		prefix:       prefix,
+		logger:       logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+statsCommand, s.statsMessageHandler, debug).RegisterCommand(session, logger)
+}
+
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Unlocked is announced where the music of the guild is, the achievements are only stored without such a channel
+func (s *Service) Unlocked(guildID, userID string, achievements []achievement.Achievement) {
+	channelID := s.channels.AnnounceChannel(guildID)
+	if channelID == "" {
+		return
+	}
+	s.sendComplexMessage(s.session, channelID, unlockedMessage(userID, achievements))
+}
+
+// statsMessageHandler the stats of the author or the mentioned member
+func (s *Service) statsMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+statsCommand))
+	user := m.Author
+	switch {
+	case len(args) == 1 && len(m.Mentions) == 1:
+		user = m.Mentions[0]
+	case len(args) != 0:
+		s.sendStringMessage(ds, m.ChannelID, fmt.Sprintf(messageUsage, s.prefix))
+		return
+	}
+	p, err := s.achievements.Get(s.ctx, user.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "achievements of %s", user.ID))
+		s.sendStringMessage(ds, m.ChannelID, discord.MessageInternalError)
+		return
+	}
+	pr, err := s.profiles.Get(s.ctx, user.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "profile of %s", user.ID))
+		s.sendStringMessage(ds, m.ChannelID, discord.MessageInternalError)
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{statsEmbed(user, pr, p)},
+	})
+}
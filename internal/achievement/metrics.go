@@ -0,0 +1,13 @@
+package achievement
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var achievementsUnlocked = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "achievement",
+	Name:      "unlocked_total",
+	Help:      "Achievements unlocked by the users, by achievement.",
+}, []string{"achievement"})
@@ -0,0 +1,80 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/achievement"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const progressCollection = "achievements"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) Progress(ctx context.Context, userID string) (*achievement.Progress, error) {
+	doc, err := s.client.Collection(progressCollection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, achievement.ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get progress of %s from %s", userID, progressCollection)
+	}
+	var p achievement.Progress
+	if err := doc.DataTo(&p); err != nil {
+		return nil, errors.Wrap(err, "unable to marshal data")
+	}
+	return &p, nil
+}
+
+// UpdateProgress f may run several times if the document changes meanwhile
+func (s *Storage) UpdateProgress(ctx context.Context, userID string, f func(p *achievement.Progress, exists bool)) (*achievement.Progress, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: UpdateProgress %s", userID)
+	ref := s.client.Collection(progressCollection).Doc(userID)
+	var res achievement.Progress
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		res = achievement.Progress{UserID: userID}
+		doc, err := tx.Get(ref)
+		exists := err == nil
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if exists {
+			if err := doc.DataTo(&res); err != nil {
+				return errors.Wrap(err, "unable to marshal data")
+			}
+		}
+		f(&res, exists)
+		res.UserID = userID
+		return tx.Set(ref, &res)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update progress of %s in %s", userID, progressCollection)
+	}
+	return &res, nil
+}
+
+func (s *Storage) DeleteProgress(ctx context.Context, userID string) (int, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteProgress %s", userID)
+	if _, err := s.Progress(ctx, userID); errors.Is(err, achievement.ErrNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if _, err := s.client.Collection(progressCollection).Doc(userID).Delete(ctx); err != nil {
+		return 0, errors.Wrapf(err, "failed to delete progress of %s from %s", userID, progressCollection)
+	}
+	return 1, nil
+}
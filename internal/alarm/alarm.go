@@ -0,0 +1,253 @@
+package alarm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	// MaxAlarms per guild
+	MaxAlarms = 10
+	// maxLateness an alarm missed for longer, e.g. while the bot was down, waits for its next time
+	maxLateness = 10 * time.Minute
+
+	// Radio source plays the songs of the server
+	Radio = "radio"
+	// playlistSource prefixes the name of a playlist of the author
+	playlistSource = "playlist:"
+)
+
+var (
+	ErrNotFound      = errors.New("alarm not found")
+	ErrTooMany       = errors.Errorf("guild has %d alarms already", MaxAlarms)
+	ErrInvalidTime   = errors.New("time is HH:MM in 24 hours")
+	ErrInvalidDays   = errors.New("days are daily, weekdays, weekends or like mon,wed,fri")
+	ErrInvalidSource = errors.New("play radio or playlist:<name>")
+	ErrNoChannel     = errors.New("the server has no voice channel for the alarms")
+)
+
+// Alarm joins the voice channel of the guild settings at the time and plays the playlist or the radio
+type Alarm struct {
+	ID      string `firestore:"-"`
+	GuildID string `firestore:"guild_id"`
+	UserID  string `firestore:"user_id"`
+	// Time is HH:MM in the timezone of the guild when the alarm was scheduled
+	Time     string `firestore:"time"`
+	Days     string `firestore:"days"`
+	Timezone string `firestore:"timezone,omitempty"`
+	// Playlist of the author, the radio plays if empty
+	Playlist string    `firestore:"playlist,omitempty"`
+	Next     time.Time `firestore:"next"`
+	Created  time.Time `firestore:"created"`
+}
+
+// Source as it is typed in the command
+func (a *Alarm) Source() string {
+	if a.Playlist == "" {
+		return Radio
+	}
+	return playlistSource + a.Playlist
+}
+
+func (a *Alarm) schedule() (cron.Schedule, error) {
+	clock, err := time.Parse("15:04", a.Time)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	days, ok := util.CronDays(a.Days)
+	if !ok {
+		return nil, ErrInvalidDays
+	}
+	spec := fmt.Sprintf("%d %d * * %s", clock.Minute(), clock.Hour(), days)
+	if a.Timezone != "" {
+		spec = "CRON_TZ=" + a.Timezone + " " + spec
+	}
+	return cron.ParseStandard(spec)
+}
+
+type Storage interface {
+	GuildAlarms(ctx context.Context, guildID string) ([]Alarm, error)
+	// DueAlarms the ones with the next time before now
+	DueAlarms(ctx context.Context, now time.Time) ([]Alarm, error)
+	// AddAlarm sets the id
+	AddAlarm(ctx context.Context, a *Alarm) error
+	SetNext(ctx context.Context, id string, next time.Time) error
+	DeleteAlarm(ctx context.Context, id string) error
+}
+
+type Player interface {
+	PlayAll(ctx context.Context, songs []*pkg.Song, userID, guildID, channelID string) (int, error)
+	SetRadio(ctx context.Context, b bool, guildID, channelID string) error
+}
+
+type Playlists interface {
+	Playlist(ctx context.Context, userID, name string) (*library.Playlist, error)
+}
+
+type Settings interface {
+	Get(guildID string) guild.Settings
+}
+
+// Service of the alarms, they are read from Firestore when due so any instance of a cluster can fire them
+type Service struct {
+	storage   Storage
+	player    Player
+	playlists Playlists
+	settings  Settings
+	// handles the guild on this instance, the alarms of the other guilds are left to their instances
+	handles func(guildID string) bool
+	logger  zap.Logger
+}
+
+func NewService(storage Storage, player Player, playlists Playlists, settings Settings, handles func(guildID string) bool, logger zap.Logger) *Service {
+	return &Service{
+		storage:   storage,
+		player:    player,
+		playlists: playlists,
+		settings:  settings,
+		handles:   handles,
+		logger:    logger,
+	}
+}
+
+// List the alarms of the guild in the order they were scheduled
+func (s *Service) List(ctx context.Context, guildID string) ([]Alarm, error) {
+	alarms, err := s.storage.GuildAlarms(ctx, guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "guild alarms")
+	}
+	sort.Slice(alarms, func(i, j int) bool {
+		return alarms[i].Created.Before(alarms[j].Created)
+	})
+	return alarms, nil
+}
+
+// Add the alarm at the clock on the days, source is radio or playlist:<name> of the user
+func (s *Service) Add(ctx context.Context, guildID, userID, clock, days, source string) (*Alarm, error) {
+	settings := s.settings.Get(guildID)
+	if settings.AlarmChannel == "" {
+		return nil, ErrNoChannel
+	}
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	a := &Alarm{
+		GuildID:  guildID,
+		UserID:   userID,
+		Time:     parsed.Format("15:04"),
+		Days:     strings.ToLower(days),
+		Timezone: settings.Timezone,
+		Created:  time.Now(),
+	}
+	switch {
+	case strings.EqualFold(source, Radio):
+	case strings.HasPrefix(strings.ToLower(source), playlistSource) && len(source) > len(playlistSource):
+		p, err := s.playlists.Playlist(ctx, userID, source[len(playlistSource):])
+		if err != nil {
+			return nil, err
+		}
+		a.Playlist = p.Name
+	default:
+		return nil, ErrInvalidSource
+	}
+	schedule, err := a.schedule()
+	if err != nil {
+		return nil, err
+	}
+	a.Next = schedule.Next(time.Now())
+	alarms, err := s.storage.GuildAlarms(ctx, guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "guild alarms")
+	}
+	if len(alarms) >= MaxAlarms {
+		return nil, ErrTooMany
+	}
+	if err := s.storage.AddAlarm(ctx, a); err != nil {
+		return nil, errors.Wrap(err, "add alarm")
+	}
+	return a, nil
+}
+
+// Delete the alarm at the position of List from 0
+func (s *Service) Delete(ctx context.Context, guildID string, pos int) (*Alarm, error) {
+	alarms, err := s.List(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if pos < 0 || pos >= len(alarms) {
+		return nil, ErrNotFound
+	}
+	if err := s.storage.DeleteAlarm(ctx, alarms[pos].ID); err != nil {
+		return nil, errors.Wrap(err, "delete alarm")
+	}
+	return &alarms[pos], nil
+}
+
+// Run fires the due alarms, it is a job of the scheduler running every minute
+func (s *Service) Run(ctx context.Context) error {
+	now := time.Now()
+	due, err := s.storage.DueAlarms(ctx, now)
+	if err != nil {
+		return errors.Wrap(err, "due alarms")
+	}
+	for i := range due {
+		a := &due[i]
+		if !s.handles(a.GuildID) {
+			continue
+		}
+		if now.Sub(a.Next) > maxLateness {
+			alarmsMissed.Inc()
+			s.logger.Warnw("alarm missed",
+				"guild", a.GuildID,
+				"alarm", a.ID,
+				"time", a.Next)
+		} else if err := s.fire(ctx, a); err != nil {
+			s.logger.Error(errors.Wrapf(err, "fire alarm %s", a.ID))
+		}
+		schedule, err := a.schedule()
+		if err != nil {
+			s.logger.Error(errors.Wrapf(err, "schedule of alarm %s", a.ID))
+			continue
+		}
+		if err := s.storage.SetNext(ctx, a.ID, schedule.Next(now)); err != nil {
+			s.logger.Error(errors.Wrapf(err, "set next time of alarm %s", a.ID))
+		}
+	}
+	return nil
+}
+
+// fire the playlist is queued after the songs already there, the radio starts if nothing plays
+func (s *Service) fire(ctx context.Context, a *Alarm) error {
+	channelID := s.settings.Get(a.GuildID).AlarmChannel
+	if channelID == "" {
+		return ErrNoChannel
+	}
+	if a.Playlist == "" {
+		alarmsFired.WithLabelValues(Radio).Inc()
+		return s.player.SetRadio(ctx, true, a.GuildID, channelID)
+	}
+	p, err := s.playlists.Playlist(ctx, a.UserID, a.Playlist)
+	if err != nil {
+		return errors.Wrapf(err, "playlist %s", a.Playlist)
+	}
+	songs := make([]*pkg.Song, 0, len(p.Songs))
+	for i := range p.Songs {
+		songs = append(songs, p.Songs[i].Song())
+	}
+	alarmsFired.WithLabelValues("playlist").Inc()
+	_, err = s.player.PlayAll(ctx, songs, a.UserID, a.GuildID, channelID)
+	return err
+}
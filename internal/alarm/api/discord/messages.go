@@ -0,0 +1,66 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/alarm"
+)
+
+const (
+	messageNoPermission     = ":x: **Only server managers can change the schedule**"
+	messageInvalidValue     = ":x: **%s**"
+	messageNotFound         = ":x: **No alarm at the position, see the schedule**"
+	messagePlaylistNotFound = ":x: **You have no such playlist**"
+	messageNoChannel        = ":x: **Set the voice channel first with** `%ssettings alarms <#channel>`"
+	messageAlarmAdded       = ":alarm_clock: **Scheduled** %s, next <t:%d:R>"
+	messageAlarmDeleted     = ":x: **Deleted** %s"
+	messageNoAlarms         = "**Nothing is scheduled**"
+	messageUsage            = "`%[1]sschedule` show the alarms\n" +
+		"`%[1]sschedule <HH:MM> <daily|weekdays|weekends|mon,wed,...> <radio|playlist:name>` play your playlist or the radio\n" +
+		"`%[1]sschedule delete <n>` delete the alarm"
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, m *discordgo.MessageCreate, msg string) {
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{Content: msg})
+}
+
+func (s *Service) sendUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageUsage, s.prefix))
+}
+
+func (s *Service) sendAlarmsMessage(ds *discordgo.Session, m *discordgo.MessageCreate, alarms []alarm.Alarm) {
+	if len(alarms) == 0 {
+		s.sendStringMessage(ds, m, messageNoAlarms)
+		return
+	}
+	lines := make([]string, 0, len(alarms))
+	for i := range alarms {
+		lines = append(lines, fmt.Sprintf("%d. %s by <@%s>", i+1, alarmLine(&alarms[i]), alarms[i].UserID))
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{Title: "Schedule", Description: strings.Join(lines, "\n")}},
+	})
+}
+
+func alarmLine(a *alarm.Alarm) string {
+	zone := a.Timezone
+	if zone == "" {
+		zone = "UTC"
+	}
+	return fmt.Sprintf("`%s %s %s` %s", a.Time, a.Days, a.Source(), zone)
+}
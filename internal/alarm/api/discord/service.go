@@ -0,0 +1,124 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/alarm"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "alarms"
+
+const (
+	schedule = "schedule"
+
+	remove = "delete"
+)
+
+type Alarms interface {
+	List(ctx context.Context, guildID string) ([]alarm.Alarm, error)
+	Add(ctx context.Context, guildID, userID, clock, days, source string) (*alarm.Alarm, error)
+	Delete(ctx context.Context, guildID string, pos int) (*alarm.Alarm, error)
+}
+
+type Service struct {
+	ctx    context.Context
+	alarms Alarms
+	prefix string
+	logger zap.Logger
+}
+
+func NewCog(ctx context.Context, alarms Alarms, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:    ctx,
+		alarms: alarms,
+		prefix: prefix,
+		logger: logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+schedule, s.scheduleMessageHandler, debug).RegisterCommand(session, logger)
+}
+
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// scheduleMessageHandler lists the alarms, server managers add and delete them
+func (s *Service) scheduleMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+schedule))
+	if len(args) == 0 {
+		alarms, err := s.alarms.List(s.ctx, m.GuildID)
+		if err != nil {
+			s.sendStringMessage(ds, m, s.errorMessage(err))
+			return
+		}
+		s.sendAlarmsMessage(ds, m, alarms)
+		return
+	}
+	if len(args) != 2 && len(args) != 3 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	if len(args) == 2 {
+		pos, err := strconv.Atoi(args[1])
+		if !strings.EqualFold(args[0], remove) || err != nil {
+			s.sendUsageMessage(ds, m)
+			return
+		}
+		a, err := s.alarms.Delete(s.ctx, m.GuildID, pos-1)
+		if err != nil {
+			s.sendStringMessage(ds, m, s.errorMessage(err))
+			return
+		}
+		s.sendStringMessage(ds, m, fmt.Sprintf(messageAlarmDeleted, alarmLine(a)))
+		return
+	}
+	a, err := s.alarms.Add(s.ctx, m.GuildID, m.Author.ID, args[0], args[1], args[2])
+	if err != nil {
+		s.sendStringMessage(ds, m, s.errorMessage(err))
+		return
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageAlarmAdded, alarmLine(a), a.Next.Unix()))
+}
+
+func (s *Service) errorMessage(err error) string {
+	switch {
+	case errors.Is(err, alarm.ErrNotFound):
+		return messageNotFound
+	case errors.Is(err, alarm.ErrNoChannel):
+		return fmt.Sprintf(messageNoChannel, s.prefix)
+	case errors.Is(err, library.ErrNotFound):
+		return messagePlaylistNotFound
+	case errors.Is(err, alarm.ErrTooMany), errors.Is(err, alarm.ErrInvalidTime),
+		errors.Is(err, alarm.ErrInvalidDays), errors.Is(err, alarm.ErrInvalidSource):
+		return fmt.Sprintf(messageInvalidValue, err)
+	}
+	s.logger.Error(errors.Wrap(err, "alarms"))
+	return discord.MessageInternalError
+}
@@ -0,0 +1,21 @@
+package alarm
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	alarmsFired = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "alarm",
+		Name:      "fired_total",
+		Help:      "Scheduled playbacks started, by source.",
+	}, []string{"source"})
+	alarmsMissed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "alarm",
+		Name:      "missed_total",
+		Help:      "Scheduled playbacks skipped because they were due too long ago.",
+	})
+)
@@ -0,0 +1,82 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/alarm"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const alarmsCollection = "alarms"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) GuildAlarms(ctx context.Context, guildID string) ([]alarm.Alarm, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: GuildAlarms %s", guildID)
+	return s.alarms(s.client.Collection(alarmsCollection).Where("guild_id", "==", guildID).Documents(ctx))
+}
+
+func (s *Storage) DueAlarms(ctx context.Context, now time.Time) ([]alarm.Alarm, error) {
+	return s.alarms(s.client.Collection(alarmsCollection).Where("next", "<=", now).Documents(ctx))
+}
+
+func (s *Storage) alarms(iter *firestore.DocumentIterator) ([]alarm.Alarm, error) {
+	defer iter.Stop()
+	res := make([]alarm.Alarm, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var a alarm.Alarm
+		if err := doc.DataTo(&a); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		a.ID = doc.Ref.ID
+		res = append(res, a)
+	}
+	return res, nil
+}
+
+func (s *Storage) AddAlarm(ctx context.Context, a *alarm.Alarm) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: AddAlarm %s", a.GuildID)
+	ref, _, err := s.client.Collection(alarmsCollection).Add(ctx, a)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add alarm to %s", alarmsCollection)
+	}
+	a.ID = ref.ID
+	return nil
+}
+
+func (s *Storage) SetNext(ctx context.Context, id string, next time.Time) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetNext %s", id)
+	_, err := s.client.Collection(alarmsCollection).Doc(id).Update(ctx, []firestore.Update{{Path: "next", Value: next}})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update %s in %s", id, alarmsCollection)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteAlarm(ctx context.Context, id string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteAlarm %s", id)
+	if _, err := s.client.Collection(alarmsCollection).Doc(id).Delete(ctx); err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", id, alarmsCollection)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	RuleErrors     = "errors"
+	RuleQuota      = "quota"
+	RuleFirestore  = "firestore"
+	RuleReconnects = "reconnects"
+
+	// maxMessageLength of discord, the alerts over it are cut
+	maxMessageLength = 2000
+)
+
+// Rules the thresholds are counted per Interval, a zero threshold turns the rule off
+type Rules struct {
+	Interval time.Duration
+	// Cooldown the same alert isn't posted again for
+	Cooldown time.Duration
+	// Errors logged errors and failed commands
+	Errors float64
+	// QuotaLeft fraction of DailyQuota, a key with less left is alerted
+	QuotaLeft  float64
+	DailyQuota float64
+	// WriteFailures Firestore writes failed after the retries
+	WriteFailures float64
+	// Reconnects of the voice connection
+	Reconnects float64
+}
+
+// Alert the key deduplicates the alerts of the same rule and subject
+type Alert struct {
+	Rule string
+	Key  string
+	Text string
+}
+
+// Sender posts the alerts, discordgo.Session is one
+type Sender interface {
+	ChannelMessageSend(channelID, content string) (*discordgo.Message, error)
+}
+
+// Service checks the metrics every interval and posts all new alerts of a check in one message
+type Service struct {
+	rules    Rules
+	gatherer prometheus.Gatherer
+	sender   Sender
+	channel  string
+	logger   zap.Logger
+
+	mx sync.Mutex
+	// counters the values of the previous check
+	counters map[string]float64
+	// sent the last time an alert was posted by key
+	sent map[string]time.Time
+	// suppressed the repeats during the cooldown, they are mentioned in the next alert
+	suppressed map[string]int
+}
+
+func NewService(rules Rules, gatherer prometheus.Gatherer, sender Sender, channel string, logger zap.Logger) *Service {
+	return &Service{
+		rules:      rules,
+		gatherer:   gatherer,
+		sender:     sender,
+		channel:    channel,
+		logger:     logger,
+		counters:   make(map[string]float64),
+		sent:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+// Run until the context is done
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.rules.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := s.Check(now); err != nil {
+				s.logger.Warn(errors.Wrap(err, "check alerts"))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check evaluates the rules and posts the alerts which are not in the cooldown.
+// The failures to post are logged as warnings, an error would count towards the error rate.
+func (s *Service) Check(now time.Time) error {
+	values, err := read(s.gatherer)
+	if err != nil {
+		return err
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	alerts := s.evaluate(values)
+	lines := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		if last, ok := s.sent[a.Key]; ok && now.Sub(last) < s.rules.Cooldown {
+			s.suppressed[a.Key]++
+			alertsSuppressed.WithLabelValues(a.Rule).Inc()
+			continue
+		}
+		line := ":rotating_light: " + a.Text
+		if n := s.suppressed[a.Key]; n > 0 {
+			line += fmt.Sprintf(" (%d more since the last alert)", n)
+		}
+		lines = append(lines, line)
+		s.sent[a.Key] = now
+		delete(s.suppressed, a.Key)
+		alertsSent.WithLabelValues(a.Rule).Inc()
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	text := strings.Join(lines, "\n")
+	if len(text) > maxMessageLength {
+		text = text[:maxMessageLength-3] + "..."
+	}
+	if _, err := s.sender.ChannelMessageSend(s.channel, text); err != nil {
+		return errors.Wrapf(err, "post %d alerts to %s", len(lines), s.channel)
+	}
+	return nil
+}
+
+// evaluate the counters are compared with the previous check, the first check compares them with the start
+func (s *Service) evaluate(values *metrics) []Alert {
+	delta := func(name string) float64 {
+		d := values.counters[name] - s.counters[name]
+		s.counters[name] = values.counters[name]
+		if d < 0 {
+			return 0
+		}
+		return d
+	}
+	interval := s.rules.Interval.String()
+	alerts := make([]Alert, 0)
+
+	loggerErrors := make([]string, 0, len(values.loggerErrors))
+	errorsTotal := 0.0
+	for name := range values.loggerErrors {
+		if d := delta("log:" + name); d > 0 {
+			errorsTotal += d
+			loggerErrors = append(loggerErrors, fmt.Sprintf("%s %.0f", loggerName(name), d))
+		}
+	}
+	sort.Strings(loggerErrors)
+	failed := delta("commands_failed")
+	if s.rules.Errors > 0 && errorsTotal+failed >= s.rules.Errors {
+		alerts = append(alerts, Alert{
+			Rule: RuleErrors,
+			Key:  RuleErrors,
+			Text: fmt.Sprintf("**Error rate**: %.0f errors logged (%s) and %.0f commands failed in %s",
+				errorsTotal, strings.Join(loggerErrors, ", "), failed, interval),
+		})
+	}
+
+	if s.rules.QuotaLeft > 0 && s.rules.DailyQuota > 0 {
+		keys := make([]string, 0, len(values.quotaLeft))
+		for key := range values.quotaLeft {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			left := values.quotaLeft[key]
+			if left < s.rules.QuotaLeft*s.rules.DailyQuota {
+				alerts = append(alerts, Alert{
+					Rule: RuleQuota,
+					Key:  RuleQuota + ":" + key,
+					Text: fmt.Sprintf("**YouTube quota** of key %s is nearly exhausted: %.0f of %.0f units left", key, left, s.rules.DailyQuota),
+				})
+			}
+		}
+	}
+
+	writes := delta("firestore_write_failures")
+	if s.rules.WriteFailures > 0 && writes >= s.rules.WriteFailures {
+		alerts = append(alerts, Alert{
+			Rule: RuleFirestore,
+			Key:  RuleFirestore,
+			Text: fmt.Sprintf("**Firestore**: %.0f writes failed after the retries in %s", writes, interval),
+		})
+	}
+
+	reconnects := delta("voice_reconnects")
+	if s.rules.Reconnects > 0 && reconnects >= s.rules.Reconnects {
+		alerts = append(alerts, Alert{
+			Rule: RuleReconnects,
+			Key:  RuleReconnects,
+			Text: fmt.Sprintf("**Voice** connection flapping: %.0f reconnects in %s", reconnects, interval),
+		})
+	}
+	return alerts
+}
+
+// loggerName the root logger has no name
+func loggerName(name string) string {
+	if name == "" {
+		return "root"
+	}
+	return name
+}
+
+// metrics the rules are evaluated on
+type metrics struct {
+	// counters by the names the deltas are kept under
+	counters     map[string]float64
+	loggerErrors map[string]float64
+	quotaLeft    map[string]float64
+}
+
+func read(g prometheus.Gatherer) (*metrics, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, errors.Wrap(err, "gather metrics")
+	}
+	m := &metrics{
+		counters:     make(map[string]float64),
+		loggerErrors: make(map[string]float64),
+		quotaLeft:    make(map[string]float64),
+	}
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel()))
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			counter := metric.GetCounter().GetValue()
+			switch f.GetName() {
+			case "halvabot_log_errors_total":
+				m.loggerErrors[labels["logger"]] = counter
+				m.counters["log:"+labels["logger"]] = counter
+			case "halvabot_commands_calls_total":
+				if labels["result"] == "failed" {
+					m.counters["commands_failed"] += counter
+				}
+			case "halvabot_firestore_write_failures_total":
+				m.counters["firestore_write_failures"] += counter
+			case "halvabot_audio_voice_reconnects_total":
+				m.counters["voice_reconnects"] += counter
+			case "halvabot_youtube_quota_left_units":
+				m.quotaLeft[labels["key"]] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,21 @@
+package alert
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	alertsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "alerts",
+		Name:      "sent_total",
+		Help:      "Alerts posted to the admin channel by rule.",
+	}, []string{"rule"})
+	alertsSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "alerts",
+		Name:      "suppressed_total",
+		Help:      "Alerts not posted because the same one was posted during the cooldown, by rule.",
+	}, []string{"rule"})
+)
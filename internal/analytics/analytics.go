@@ -0,0 +1,213 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	// DateLayout of the days in the queries and the series, the days are in UTC
+	DateLayout = "2006-01-02"
+	// DefaultDays of the range without the start
+	DefaultDays = 30
+	MaxDays     = 366
+
+	topRequesters = 10
+	// cacheTTL the ranges with today change, every range is read from Firestore at most this often
+	cacheTTL = 10 * time.Minute
+)
+
+var ErrInvalidRange = errors.New("the range is from one day to 366 days")
+
+type Plays interface {
+	GuildPlays(ctx context.Context, guildID string, from, to time.Time) ([]pkg.Play, error)
+}
+
+// Range of the days [From, To], both are midnights in UTC
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// NewRange the empty to is today, the empty from is DefaultDays before it
+func NewRange(from, to string) (Range, error) {
+	var r Range
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	r.To = today
+	if to != "" {
+		t, err := time.Parse(DateLayout, to)
+		if err != nil {
+			return r, errors.Wrap(ErrInvalidRange, err.Error())
+		}
+		r.To = t
+	}
+	r.From = r.To.AddDate(0, 0, 1-DefaultDays)
+	if from != "" {
+		t, err := time.Parse(DateLayout, from)
+		if err != nil {
+			return r, errors.Wrap(ErrInvalidRange, err.Error())
+		}
+		r.From = t
+	}
+	if r.To.Before(r.From) || r.days() > MaxDays {
+		return r, ErrInvalidRange
+	}
+	return r, nil
+}
+
+func (r Range) days() int {
+	return int(r.To.Sub(r.From)/(24*time.Hour)) + 1
+}
+
+// Day of the plays per day series
+type Day struct {
+	Date      string `json:"date"`
+	Plays     int    `json:"plays"`
+	Listeners int    `json:"listeners"`
+	// Hours of the requested songs, the songs of unknown duration aren't counted
+	Hours float64 `json:"hours"`
+}
+
+// Hour of the day in UTC
+type Hour struct {
+	Hour  int `json:"hour"`
+	Plays int `json:"plays"`
+}
+
+type Requester struct {
+	UserID string `json:"user_id"`
+	Plays  int    `json:"plays"`
+}
+
+// Analytics of a guild over the range, computed from the history of the requested songs
+type Analytics struct {
+	GuildID string `json:"guild_id"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Plays   int    `json:"plays"`
+	// UniqueListeners requested a song in the range
+	UniqueListeners int         `json:"unique_listeners"`
+	Days            []Day       `json:"days"`
+	Hours           []Hour      `json:"hours"`
+	PeakHour        int         `json:"peak_hour"`
+	TopRequesters   []Requester `json:"top_requesters"`
+	Computed        time.Time   `json:"computed"`
+}
+
+type cached struct {
+	analytics *Analytics
+	expires   time.Time
+}
+
+// Service computes the analytics and keeps them for a while
+type Service struct {
+	plays Plays
+
+	mx    sync.Mutex
+	cache map[string]cached // guild id, from and to
+}
+
+func NewService(plays Plays) *Service {
+	return &Service{
+		plays: plays,
+		cache: make(map[string]cached),
+	}
+}
+
+// Get the analytics of the guild, the range is checked by NewRange
+func (s *Service) Get(ctx context.Context, guildID string, r Range) (*Analytics, error) {
+	now := time.Now()
+	key := guildID + "/" + r.From.Format(DateLayout) + "/" + r.To.Format(DateLayout)
+	s.mx.Lock()
+	for k, v := range s.cache {
+		if now.After(v.expires) {
+			delete(s.cache, k)
+		}
+	}
+	c, ok := s.cache[key]
+	s.mx.Unlock()
+	if ok {
+		return c.analytics, nil
+	}
+
+	plays, err := s.plays.GuildPlays(ctx, guildID, r.From, r.To.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, errors.Wrap(err, "guild plays")
+	}
+	a := compute(guildID, r, plays)
+	a.Computed = now
+
+	s.mx.Lock()
+	s.cache[key] = cached{analytics: a, expires: now.Add(cacheTTL)}
+	s.mx.Unlock()
+	return a, nil
+}
+
+func compute(guildID string, r Range, plays []pkg.Play) *Analytics {
+	a := &Analytics{
+		GuildID: guildID,
+		From:    r.From.Format(DateLayout),
+		To:      r.To.Format(DateLayout),
+		Plays:   len(plays),
+		Days:    make([]Day, r.days()),
+		Hours:   make([]Hour, 24),
+	}
+	for i := range a.Days {
+		a.Days[i].Date = r.From.AddDate(0, 0, i).Format(DateLayout)
+	}
+	for i := range a.Hours {
+		a.Hours[i].Hour = i
+	}
+
+	requesters := make(map[string]int)
+	listeners := make([]map[string]struct{}, len(a.Days))
+	for i := range plays {
+		p := &plays[i]
+		t := p.Time.UTC()
+		day := int(t.Sub(r.From) / (24 * time.Hour))
+		if day < 0 || day >= len(a.Days) {
+			continue
+		}
+		a.Days[day].Plays++
+		a.Days[day].Hours += p.Duration / time.Hour.Seconds()
+		a.Hours[t.Hour()].Plays++
+		if p.UserID == "" {
+			continue
+		}
+		requesters[p.UserID]++
+		if listeners[day] == nil {
+			listeners[day] = make(map[string]struct{})
+		}
+		listeners[day][p.UserID] = struct{}{}
+	}
+	for i := range a.Days {
+		a.Days[i].Listeners = len(listeners[i])
+	}
+	for i := range a.Hours {
+		if a.Hours[i].Plays > a.Hours[a.PeakHour].Plays {
+			a.PeakHour = i
+		}
+	}
+
+	a.UniqueListeners = len(requesters)
+	a.TopRequesters = make([]Requester, 0, len(requesters))
+	for id, n := range requesters {
+		a.TopRequesters = append(a.TopRequesters, Requester{UserID: id, Plays: n})
+	}
+	sort.Slice(a.TopRequesters, func(i, j int) bool {
+		if a.TopRequesters[i].Plays != a.TopRequesters[j].Plays {
+			return a.TopRequesters[i].Plays > a.TopRequesters[j].Plays
+		}
+		return a.TopRequesters[i].UserID < a.TopRequesters[j].UserID
+	})
+	if len(a.TopRequesters) > topRequesters {
+		a.TopRequesters = a.TopRequesters[:topRequesters]
+	}
+	return a
+}
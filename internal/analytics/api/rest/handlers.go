@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/analytics"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/storage/firestore"
+)
+
+// analytics godoc
+// @summary  Plays per day, plays per hour, unique listeners and top requesters of the guild, updated every 10 minutes
+// @produce  json
+// @param    id    path      string  true   "Guild ID"
+// @param    from  query     string  false  "First day, YYYY-MM-DD in UTC, 30 days before to by default"
+// @param    to    query     string  false  "Last day, YYYY-MM-DD in UTC, today by default"
+// @success  200   {object}  analytics.Analytics
+// @failure  400   {object}  Response  "Incorrect range"
+// @failure  503   {object}  Response  "Firestore is unavailable"
+// @failure  500   {object}  Response  "Database error"
+// @router   /guilds/{id}/analytics [get]
+func (h *Handler) analyticsHandler(c *gin.Context) {
+	if a, ok := h.get(c); ok {
+		c.JSON(http.StatusOK, a)
+	}
+}
+
+// plays godoc
+// @summary  Plays, listeners and hours of the requested songs per day in the guild
+// @produce  json
+// @param    id    path      string  true   "Guild ID"
+// @param    from  query     string  false  "First day, YYYY-MM-DD in UTC, 30 days before to by default"
+// @param    to    query     string  false  "Last day, YYYY-MM-DD in UTC, today by default"
+// @success  200   {array}   analytics.Day
+// @failure  400   {object}  Response  "Incorrect range"
+// @failure  503   {object}  Response  "Firestore is unavailable"
+// @failure  500   {object}  Response  "Database error"
+// @router   /guilds/{id}/analytics/plays [get]
+func (h *Handler) playsHandler(c *gin.Context) {
+	if a, ok := h.get(c); ok {
+		c.JSON(http.StatusOK, a.Days)
+	}
+}
+
+// hours godoc
+// @summary  Plays per hour of the day in UTC and the peak hour in the guild
+// @produce  json
+// @param    id    path      string  true   "Guild ID"
+// @param    from  query     string  false  "First day, YYYY-MM-DD in UTC, 30 days before to by default"
+// @param    to    query     string  false  "Last day, YYYY-MM-DD in UTC, today by default"
+// @success  200   {object}  HoursResponse
+// @failure  400   {object}  Response  "Incorrect range"
+// @failure  503   {object}  Response  "Firestore is unavailable"
+// @failure  500   {object}  Response  "Database error"
+// @router   /guilds/{id}/analytics/hours [get]
+func (h *Handler) hoursHandler(c *gin.Context) {
+	if a, ok := h.get(c); ok {
+		c.JSON(http.StatusOK, HoursResponse{Hours: a.Hours, PeakHour: a.PeakHour})
+	}
+}
+
+// requesters godoc
+// @summary  Unique listeners and the users who requested the most songs in the guild
+// @produce  json
+// @param    id    path      string  true   "Guild ID"
+// @param    from  query     string  false  "First day, YYYY-MM-DD in UTC, 30 days before to by default"
+// @param    to    query     string  false  "Last day, YYYY-MM-DD in UTC, today by default"
+// @success  200   {object}  RequestersResponse
+// @failure  400   {object}  Response  "Incorrect range"
+// @failure  503   {object}  Response  "Firestore is unavailable"
+// @failure  500   {object}  Response  "Database error"
+// @router   /guilds/{id}/analytics/requesters [get]
+func (h *Handler) requestersHandler(c *gin.Context) {
+	if a, ok := h.get(c); ok {
+		c.JSON(http.StatusOK, RequestersResponse{UniqueListeners: a.UniqueListeners, TopRequesters: a.TopRequesters})
+	}
+}
+
+// get writes the error response itself
+func (h *Handler) get(c *gin.Context) (*analytics.Analytics, bool) {
+	r, err := analytics.NewRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return nil, false
+	}
+	a, err := h.analytics.Get(c.Request.Context(), c.Param("id"), r)
+	if errors.Is(err, firestore.ErrOffline) {
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return nil, false
+	}
+	return a, true
+}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/analytics"
+)
+
+type Analytics interface {
+	Get(ctx context.Context, guildID string, r analytics.Range) (*analytics.Analytics, error)
+}
+
+type Handler struct {
+	analytics Analytics
+	super     *gin.RouterGroup
+}
+
+func NewHandler(analytics Analytics, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		analytics: analytics,
+		super:     superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/guilds/:id/analytics")
+	group.GET("", h.analyticsHandler)
+	group.GET("/plays", h.playsHandler)
+	group.GET("/hours", h.hoursHandler)
+	group.GET("/requesters", h.requestersHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
+
+type HoursResponse struct {
+	Hours    []analytics.Hour `json:"hours"`
+	PeakHour int              `json:"peak_hour"`
+}
+
+type RequestersResponse struct {
+	UniqueListeners int                   `json:"unique_listeners"`
+	TopRequesters   []analytics.Requester `json:"top_requesters"`
+}
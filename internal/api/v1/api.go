@@ -1,7 +1,16 @@
 package v1
 
 import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	sentrygin "github.com/getsentry/sentry-go/gin"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
 )
 
 type API struct {
@@ -23,6 +32,8 @@ func (h *API) Router() *gin.RouterGroup {
 			},
 		}),
 		gin.Recovery(),
+		// reports the panic and passes it on to gin.Recovery
+		sentrygin.New(sentrygin.Options{Repanic: true}),
 		CORS(),
 	)
 	return h.super
@@ -33,7 +44,7 @@ func CORS() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -43,3 +54,98 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+const (
+	// userIDKey of the discord user in the gin context
+	userIDKey = "user_id"
+	// scopesKey of the token minted in Discord, not set for a linked client
+	scopesKey = "scopes"
+)
+
+// Accounts resolve the discord user of the token of a linked client and the scopes of a minted token,
+// account.ErrUnknownLink if the token is unknown, expired or revoked
+type Accounts interface {
+	Identify(ctx context.Context, token string) (string, []string, error)
+}
+
+// Identify the request by the account token, the requests without a token stay anonymous.
+// An unknown, expired or revoked token gets 401, the admin token is left to Admin.
+func Identify(accounts Accounts, adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			c.Next()
+			return
+		}
+		token := strings.TrimPrefix(header, "Bearer ")
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			c.Next()
+			return
+		}
+		userID, scopes, err := accounts.Identify(c.Request.Context(), token)
+		switch {
+		case errors.Is(err, account.ErrUnknownLink):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "unknown, expired or revoked token"})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"message": "the token can't be checked now"})
+			return
+		}
+		c.Set(userIDKey, userID)
+		if len(scopes) != 0 {
+			c.Set(scopesKey, scopes)
+		}
+		c.Next()
+	}
+}
+
+// RequireUser refuses the anonymous requests
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if UserID(c) == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "link the account first"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope refuses the tokens minted without the scope, the linked clients and the anonymous requests pass.
+// RequireUser goes first where the anonymous requests aren't allowed.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, ok := c.Get(scopesKey)
+		if !ok {
+			c.Next()
+			return
+		}
+		for _, s := range scopes.([]string) {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "the token has no " + scope + " scope"})
+	}
+}
+
+// UserID of the linked account, empty if the request is anonymous
+func UserID(c *gin.Context) string {
+	return c.GetString(userIDKey)
+}
+
+// Admin protects the group with the bearer token, the group is disabled without a token
+func Admin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "wrong admin token"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,14 @@
+package app
+
+import (
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	adapi "github.com/HalvaPovidlo/discordBotGo/internal/account/api/discord"
+	accountfire "github.com/HalvaPovidlo/discordBotGo/internal/account/storage/firestore"
+)
+
+// NewAccounts links the web and api clients to the discord users, the codes are sent in the DMs
+func NewAccounts(session *discordgo.Session, storage *Storage) *account.Service {
+	return account.NewService(accountfire.NewStorage(storage.Client.Client), adapi.NewSender(session))
+}
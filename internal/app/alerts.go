@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/alert"
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+)
+
+// NewAlerts posts to the admin channel when the metrics cross the thresholds, returns nil without a channel
+func NewAlerts(a *App, session *discordgo.Session, checks *health.Service) *alert.Service {
+	cfg := a.Config().Alerts
+	if cfg.Channel == "" {
+		return nil
+	}
+	rules := alert.Rules{
+		Interval:      cfg.Interval.Duration,
+		Cooldown:      cfg.Cooldown.Duration,
+		Errors:        float64(cfg.Errors),
+		QuotaLeft:     cfg.QuotaLeft,
+		DailyQuota:    float64(a.Config().Youtube.DailyQuota),
+		WriteFailures: float64(cfg.WriteFailures),
+		Reconnects:    float64(cfg.Reconnects),
+	}
+	logger := a.Logger().Named("alerts")
+	alerts := alert.NewService(rules, prometheus.DefaultGatherer, session, cfg.Channel, logger)
+	checks.Add("Alerts", func(_ context.Context) (string, error) {
+		return fmt.Sprintf("to %s every %s", cfg.Channel, cfg.Interval.Duration), nil
+	})
+	a.Append(Hook{
+		Name: "alerts",
+		Start: func(ctx context.Context) error {
+			supervisor.Go(ctx, logger, "alerts", alerts.Run)
+			return nil
+		},
+	})
+	return alerts
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/cmd/config"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const stopTimeout = 10 * time.Second
+
+// Hook of a subsystem, both functions are optional
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// App owns the lifecycle of the subsystems: they are started in the order they were built and stopped in the reverse
+type App struct {
+	config *config.Config
+	logger zap.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	hooks    []Hook
+	started  int
+	created  time.Time
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+func New(cfg *config.Config, logger zap.Logger) *App {
+	ctx, cancel := context.WithCancel(contexts.WithLogger(context.Background(), logger))
+	return &App{
+		config:   cfg,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		created:  time.Now(),
+		shutdown: make(chan struct{}),
+	}
+}
+
+func (a *App) Config() *config.Config {
+	return a.config
+}
+
+func (a *App) Logger() zap.Logger {
+	return a.logger
+}
+
+// Context is cancelled after all subsystems are stopped
+func (a *App) Context() context.Context {
+	return a.ctx
+}
+
+// Uptime since the app was created
+func (a *App) Uptime() time.Duration {
+	return time.Since(a.created)
+}
+
+func (a *App) Append(h Hook) {
+	a.hooks = append(a.hooks, h)
+}
+
+// Start stops the already started subsystems if one of them fails
+func (a *App) Start() error {
+	for _, h := range a.hooks {
+		if h.Start != nil {
+			if err := h.Start(a.ctx); err != nil {
+				a.Stop()
+				return errors.Wrapf(err, "start %s", h.Name)
+			}
+		}
+		a.started++
+	}
+	return nil
+}
+
+// Stop gives every subsystem its own timeout, so a stuck one doesn't take the time of the rest
+func (a *App) Stop() {
+	for i := a.started - 1; i >= 0; i-- {
+		h := a.hooks[i]
+		if h.Stop == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(contexts.WithLogger(context.Background(), a.logger), stopTimeout)
+		if err := h.Stop(ctx); err != nil {
+			a.logger.Error(errors.Wrapf(err, "stop %s", h.Name))
+		}
+		cancel()
+	}
+	a.started = 0
+	a.cancel()
+}
+
+// Shutdown makes Run stop the app as if it got SIGTERM
+func (a *App) Shutdown() {
+	a.once.Do(func() { close(a.shutdown) })
+}
+
+// Run starts the app and stops it on SIGINT, SIGTERM or Shutdown
+func (a *App) Run() error {
+	if err := a.Start(); err != nil {
+		return err
+	}
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	select {
+	case <-sc:
+	case <-a.shutdown:
+	}
+	a.logger.Infow("Graceful shutdown")
+	a.Stop()
+	return nil
+}
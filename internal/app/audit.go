@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+	auditfire "github.com/HalvaPovidlo/discordBotGo/internal/audit/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/usage"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+// NewAudit records every executed command until the storage stops, the usage stats count them too
+func NewAudit(a *App, storage *Storage, stats *usage.Stats) *audit.Log {
+	ctx, stop := context.WithCancel(a.Context())
+	log := audit.NewLog(ctx, auditfire.NewStorage(storage.Client.Client), a.Logger().Named("audit"))
+	command.SetAuditor(func(e command.Execution) {
+		log.Record(e)
+		stats.Record(e)
+	})
+	a.Append(Hook{
+		Name: "audit",
+		Stop: func(_ context.Context) error {
+			stop()
+			return nil
+		},
+	})
+	return log
+}
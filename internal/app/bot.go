@@ -0,0 +1,64 @@
+package app
+
+import "github.com/HalvaPovidlo/discordBotGo/internal/usage"
+
+// Bot wires the whole bot: discord, storage, youtube, the cogs and the http api
+func Bot(a *App) error {
+	session, err := NewSession(a)
+	if err != nil {
+		return err
+	}
+	storage, err := NewStorage(a)
+	if err != nil {
+		return err
+	}
+	RunMigrations(a, storage)
+	checks := NewHealth(a, session, storage)
+	NewAlerts(a, session, checks)
+	yt, err := NewYouTube(a, storage, checks)
+	if err != nil {
+		return err
+	}
+	settings := NewGuildSettings(a, storage)
+	usageStats := usage.NewStats()
+	auditLog := NewAudit(a, storage, usageStats)
+	accounts := NewAccounts(session, storage)
+	lib := NewLibrary(a, storage)
+	exporter := NewExporter(a, lib)
+	userData := NewUserData(storage, accounts, lib, auditLog)
+	redisClient, err := NewRedis(a, checks)
+	if err != nil {
+		return err
+	}
+	cluster := NewCluster(a, storage)
+	standby := NewFailover(a, storage, redisClient, checks)
+	jobs := NewScheduler(a, storage, cluster, standby)
+	// every instance keeps its own list for the radio
+	err = jobs.AddLocal("songs-short-cache", "@every "+a.Config().Cache.ShortRefresh.String(), storage.Songs.RefreshShortCache)
+	if err != nil {
+		return err
+	}
+	charts, err := NewTrends(storage, jobs)
+	if err != nil {
+		return err
+	}
+	artists, err := NewArtists(a, storage, jobs)
+	if err != nil {
+		return err
+	}
+	caches := NewCaches(settings, artists, jobs, yt)
+	mode := NewMaintenance(a, session, checks)
+	admin := NewConsole(a, checks, caches, mode)
+	profiles := NewProfiles(storage, lib)
+	cogs, err := NewCogs(a, session, storage, yt, settings, lib, charts, artists, exporter, userData, accounts, profiles, auditLog, checks, cluster, standby, redisClient, jobs, caches, admin, mode, usageStats)
+	if err != nil {
+		return err
+	}
+	recaps, err := NewRecaps(a, session, storage, settings, jobs)
+	if err != nil {
+		return err
+	}
+	NewHTTPServer(a, cogs, yt, auditLog, accounts, userData, lib, artists, exporter, profiles, NewAnalytics(storage), recaps, NewImporter(yt, storage), caches, settings, mode, usageStats)
+	StartConsole(a, admin)
+	return nil
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/lock"
+	lockfire "github.com/HalvaPovidlo/discordBotGo/internal/lock/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+// Cluster lets several instances run with the same token.
+// Every instance receives all messages, but a guild is served only by the one holding its lease.
+type Cluster struct {
+	locks      *lock.Manager
+	onTakeover []func(guildID string)
+	onLost     []func(guildID string)
+}
+
+const guildKeyPrefix = "guild-"
+
+// NewCluster returns nil if the cluster is disabled in the config
+func NewCluster(a *App, storage *Storage) *Cluster {
+	cfg := a.Config().Cluster
+	if !cfg.Enabled {
+		return nil
+	}
+	c := &Cluster{
+		locks: lock.NewManager(lockfire.NewStorage(storage.Client.Client), cfg.Instance, cfg.LeaseTTL.Duration, a.Logger().Named("cluster")),
+	}
+	c.locks.OnLost(func(key string) {
+		if !strings.HasPrefix(key, guildKeyPrefix) {
+			return
+		}
+		for _, h := range c.onLost {
+			h(strings.TrimPrefix(key, guildKeyPrefix))
+		}
+	})
+	command.SetGuildFilter(func(guildID string) bool {
+		return c.handles(a.Context(), guildID)
+	})
+	a.Append(Hook{
+		Name: "cluster",
+		Start: func(ctx context.Context) error {
+			c.locks.Renew(ctx)
+			return nil
+		},
+		// the cogs are stopped and the state is saved by now
+		Stop: func(ctx context.Context) error {
+			return c.locks.ReleaseAll(ctx)
+		},
+	})
+	return c
+}
+
+// OnTakeover is called when the guild of a dead or stopped instance is taken over
+func (c *Cluster) OnTakeover(h func(guildID string)) {
+	c.onTakeover = append(c.onTakeover, h)
+}
+
+// OnLost is called when the lease of the guild can't be renewed and another instance may serve it
+func (c *Cluster) OnLost(h func(guildID string)) {
+	c.onLost = append(c.onLost, h)
+}
+
+// handles doesn't ask the storage while another instance holds the lease of the guild
+func (c *Cluster) handles(ctx context.Context, guildID string) bool {
+	takeover, err := c.locks.Acquire(ctx, guildKeyPrefix+guildID)
+	if err != nil {
+		if !errors.Is(err, lock.ErrHeld) {
+			contexts.LoggerFromContext(ctx).Errorw("acquire guild lease",
+				"guild", guildID,
+				"err", err)
+		}
+		return false
+	}
+	if takeover {
+		for _, h := range c.onTakeover {
+			h(guildID)
+		}
+	}
+	return true
+}
@@ -0,0 +1,394 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	adapi "github.com/HalvaPovidlo/discordBotGo/internal/account/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/achievement"
+	acvapi "github.com/HalvaPovidlo/discordBotGo/internal/achievement/api/discord"
+	achievementfire "github.com/HalvaPovidlo/discordBotGo/internal/achievement/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/alarm"
+	aapi "github.com/HalvaPovidlo/discordBotGo/internal/alarm/api/discord"
+	alarmfire "github.com/HalvaPovidlo/discordBotGo/internal/alarm/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/approval"
+	approvalfire "github.com/HalvaPovidlo/discordBotGo/internal/approval/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/artist"
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+	"github.com/HalvaPovidlo/discordBotGo/internal/autoradio"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess"
+	capi "github.com/HalvaPovidlo/discordBotGo/internal/chess/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/auth"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/stats"
+	chessfire "github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/console"
+	"github.com/HalvaPovidlo/discordBotGo/internal/export"
+	"github.com/HalvaPovidlo/discordBotGo/internal/failover"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	gapi "github.com/HalvaPovidlo/discordBotGo/internal/guild/api/discord"
+	guildfire "github.com/HalvaPovidlo/discordBotGo/internal/guild/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	hapi "github.com/HalvaPovidlo/discordBotGo/internal/health/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	"github.com/HalvaPovidlo/discordBotGo/internal/listening"
+	listeningfire "github.com/HalvaPovidlo/discordBotGo/internal/listening/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music"
+	dapi "github.com/HalvaPovidlo/discordBotGo/internal/music/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/broker"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/recording"
+	ytsearch "github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	musicredis "github.com/HalvaPovidlo/discordBotGo/internal/music/storage/redis"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/suggest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/webhook"
+	"github.com/HalvaPovidlo/discordBotGo/internal/profile"
+	"github.com/HalvaPovidlo/discordBotGo/internal/quiz"
+	qapi "github.com/HalvaPovidlo/discordBotGo/internal/quiz/api/discord"
+	quizfire "github.com/HalvaPovidlo/discordBotGo/internal/quiz/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/quota"
+	quotafire "github.com/HalvaPovidlo/discordBotGo/internal/quota/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/reload"
+	"github.com/HalvaPovidlo/discordBotGo/internal/reminder"
+	rapi "github.com/HalvaPovidlo/discordBotGo/internal/reminder/api/discord"
+	reminderfire "github.com/HalvaPovidlo/discordBotGo/internal/reminder/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/scheduler"
+	"github.com/HalvaPovidlo/discordBotGo/internal/soundboard"
+	sapi "github.com/HalvaPovidlo/discordBotGo/internal/soundboard/api/discord"
+	soundfire "github.com/HalvaPovidlo/discordBotGo/internal/soundboard/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/trends"
+	"github.com/HalvaPovidlo/discordBotGo/internal/usage"
+	"github.com/HalvaPovidlo/discordBotGo/internal/userdata"
+	udapi "github.com/HalvaPovidlo/discordBotGo/internal/userdata/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/cog"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/redis"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+)
+
+// NewGuildSettings the custom prefixes work for all commands, the guilds have the defaults while Firestore is unavailable
+func NewGuildSettings(a *App, storage *Storage) *guild.Service {
+	cfg := a.Config()
+	settings := guild.NewService(guildfire.NewStorage(storage.Client.Client), guild.Settings{
+		Prefix:   cfg.Discord.Prefix,
+		Volume:   100,
+		Limits:   cfg.Limits,
+		Features: cfg.Features,
+	})
+	storage.Firestore.Run(a.Context(), settings.Load)
+	command.SetPrefixResolver(cfg.Discord.Prefix, settings.Prefix)
+	return settings
+}
+
+// NewBrokers the events of the player are forwarded to the brokers with a url in the config
+func NewBrokers(a *App) (*broker.Forwarder, error) {
+	cfg := a.Config().Broker
+	logger := a.Logger().Named("broker")
+	publishers := make([]broker.Publisher, 0, 2)
+	if cfg.NATS.URL != "" {
+		nats, err := broker.NewNATS(cfg.NATS.URL, cfg.NATS.Subject, logger)
+		if err != nil {
+			return nil, err
+		}
+		publishers = append(publishers, nats)
+	}
+	if cfg.Kafka.URL != "" {
+		publishers = append(publishers, broker.NewKafka(cfg.Kafka.URL, cfg.Kafka.Topic))
+	}
+	return broker.NewForwarder(logger, publishers...), nil
+}
+
+// NewQueueStore the queue is kept in Redis if it is configured, in memory otherwise
+func NewQueueStore(a *App, redisClient *redis.Client) player.QueueStore {
+	if redisClient == nil {
+		return nil
+	}
+	return musicredis.NewQueue(redisClient, a.Config().Redis.Prefix)
+}
+
+// voice connection of the music player, to Discord or simulated in the headless mode
+type voice interface {
+	player.VoiceClient
+	Channel() (guildID, channelID string)
+}
+
+// NewCogs builds the cogs enabled in the config.
+// The commands are not accepted anymore as soon as the cogs start to stop.
+func NewCogs(a *App, session *discordgo.Session, storage *Storage, yt *ytsearch.YouTube, settings *guild.Service, lib *library.Service, charts *trends.Service, artists *artist.Service, exporter *export.Service, userData *userdata.Service, accounts *account.Service, profiles *profile.Service, auditLog *audit.Log, checks *health.Service, cluster *Cluster, standby *failover.Monitor, redisClient *redis.Client, jobs *scheduler.Scheduler, caches *reload.Registry, admin *console.Console, mode *maintenance.Mode, usageStats *usage.Stats) (*cog.Registry, error) {
+	cfg := a.Config()
+	logger := a.Logger()
+	ctx, stopCogs := context.WithCancel(a.Context())
+	cogs := cog.NewRegistry(cfg.Cogs)
+
+	// the soundboard plays through the voice connection of the music player
+	var voiceClient voice
+	var rawAudioPlayer *audio.Player
+	var musicPlayer *player.Service
+	var musicCommands *dapi.Service
+	if cogs.Enabled(music.Name) {
+		logger := logger.Named(music.Name)
+		var frames *audio.FrameCache
+		if cfg.Cache.FramesDir != "" {
+			var err error
+			frames, err = audio.NewFrameCache(cfg.Cache.FramesDir, cfg.Cache.FramesSizeMB<<20, cfg.Cache.FramesMinPlays, logger.Named("audio"))
+			if err != nil {
+				stopCogs()
+				return nil, errors.Wrap(err, "frame cache")
+			}
+			checks.Add("Encoded songs", func(_ context.Context) (string, error) {
+				size, maxSize, n := frames.Usage()
+				return fmt.Sprintf("%d files, %d of %d MB", n, size>>20, maxSize>>20), nil
+			})
+		}
+		encode := audio.EncodeFile
+		if cfg.Discord.Voice.FFmpeg.Enabled {
+			encode = audio.NewFFmpegEncoder(cfg.Discord.Voice.FFmpeg, logger.Named("audio"))
+		}
+		var speak audio.Encoder
+		if cfg.Discord.Voice.TTS.Enabled {
+			speak = audio.NewTTS(cfg.Discord.Voice.TTS, logger.Named("audio"))
+		}
+		rawAudioPlayer = audio.NewPlayer(&cfg.Discord.Voice.EncodeOptions, cfg.Discord.Voice.MaxBitrate, encode, speak, frames, logger.Named("audio"))
+		if cfg.Discord.Voice.Headless.Enabled {
+			logger.Warnw("headless mode, the bot doesn't join the voice channels",
+				"guild", cfg.Discord.Voice.Headless.GuildID,
+				"channel", cfg.Discord.Voice.Headless.ChannelID)
+			voiceClient = audio.NewHeadlessClient(ctx, cfg.Discord.Voice.Headless, &cfg.Discord.Voice.EncodeOptions, logger.Named("headless"))
+			rawAudioPlayer.SetHeadless()
+		} else {
+			voiceClient = audio.NewVoiceClient(session)
+		}
+		musicPlayer = player.NewMusicService(ctx, storage.Songs, yt, settings, voiceClient, rawAudioPlayer, NewQueueStore(a, redisClient), logger.Named("player"))
+		musicPlayer.SetSuggester(suggest.NewService(storage.Songs))
+		musicPlayer.SetMaintenance(mode)
+		// the deploy can follow right away, the queue is resumed by the next start
+		mode.OnChange(func(st maintenance.Status) {
+			if !st.Enabled {
+				return
+			}
+			if err := musicPlayer.SaveState(ctx); err != nil {
+				logger.Error(errors.Wrap(err, "save player state for maintenance"))
+			}
+		})
+		restore := func(ctx context.Context) {
+			defer supervisor.Recover(logger, "player restore")
+			if err := musicPlayer.Restore(ctx); err != nil {
+				logger.Error(errors.Wrap(err, "restore player"))
+			}
+		}
+		if cluster != nil {
+			// the state belongs to the instance which serves the guild
+			cluster.OnTakeover(func(_ string) { go restore(ctx) })
+			// another instance serves the guild now, the player mustn't play along
+			cluster.OnLost(func(guildID string) {
+				if current, _ := voiceClient.Channel(); current == guildID {
+					logger.Warnw("guild lease lost, leaving the voice channel", "guild", guildID)
+					go musicPlayer.Disconnect()
+				}
+			})
+		}
+		if standby != nil {
+			standby.OnTakeover(restore)
+			// the standby saves the queue and leaves the voice channel, the primary restores it on the takeover
+			standby.OnHandback(func(ctx context.Context) {
+				if err := musicPlayer.Shutdown(ctx); err != nil {
+					logger.Error(errors.Wrap(err, "hand back the player"))
+				}
+			})
+		}
+		a.Append(Hook{
+			Name: "player",
+			Start: func(ctx context.Context) error {
+				switch {
+				case cluster != nil:
+					musicPlayer.KeepState(ctx, cfg.Cluster.StateInterval.Duration)
+					return nil
+				case standby != nil:
+					// the standby resumes the queue saved by the primary
+					if standby.Role() == failover.Primary {
+						musicPlayer.KeepState(ctx, cfg.Failover.StateInterval.Duration)
+					}
+					// the standby restores on the takeover, the primary waits for the handback if the standby serves
+					if !standby.Active() {
+						return nil
+					}
+				}
+				go restore(ctx)
+				return nil
+			},
+		})
+		if len(cfg.Webhooks) != 0 {
+			musicPlayer.Subscribe(webhook.NewNotifier(cfg.Webhooks, logger.Named("webhook")).Notify)
+		}
+		forwarder, err := NewBrokers(a)
+		if err != nil {
+			stopCogs()
+			return nil, err
+		}
+		if forwarder.Len() != 0 {
+			musicPlayer.Subscribe(forwarder.Forward)
+		}
+		tracker := listening.NewTracker(session, musicPlayer, voiceClient, listeningfire.NewStorage(storage.Client.Client), logger.Named("listening"))
+		supervisor.Go(ctx, logger, "listening", tracker.Run)
+		session.AddHandler(autoradio.NewWatcher(ctx, musicPlayer, voiceClient, settings, command.Handles, logger.Named("autoradio")).VoiceStateUpdate)
+		checks.Add("Voice", func(_ context.Context) (string, error) {
+			state := musicPlayer.State()
+			if state.GuildID == "" {
+				return "not connected", nil
+			}
+			return fmt.Sprintf("<#%s>, %d in the queue", state.ChannelID, len(state.Queue)), nil
+		})
+		admin.Add("skip", "<guild> skips the current song in the guild", func(_ context.Context, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", errors.New("usage: skip <guild>")
+			}
+			if guildID := musicPlayer.State().GuildID; guildID != args[0] {
+				return "", errors.Errorf("the player is not in the guild %s", args[0])
+			}
+			song := musicPlayer.NowPlaying()
+			if song == nil {
+				return "", errors.New("nothing is playing")
+			}
+			musicPlayer.Skip()
+			return "skipped " + song.Title, nil
+		})
+		var recordings *recording.Service
+		var recorder dapi.Recordings
+		if cfg.Recording.Dir != "" {
+			url := cfg.Recording.URL
+			if url == "" {
+				url = cfg.Host.BotURL() + "/api/v1"
+			}
+			var err error
+			recordings, err = recording.NewService(recording.Config{
+				Dir:       cfg.Recording.Dir,
+				MaxLength: cfg.Recording.MaxLength.Duration,
+				Keep:      cfg.Recording.Keep.Duration,
+				URL:       url,
+			}, rawAudioPlayer, logger.Named("recording"))
+			if err != nil {
+				stopCogs()
+				return nil, errors.Wrap(err, "recordings")
+			}
+			recordings.Cleanup(ctx)
+			recorder = recordings
+		}
+		approvals := approval.NewService(approvalfire.NewStorage(storage.Client.Client))
+		// the timers of the requests are lost on restart
+		if err := jobs.Add("song-approvals-expire", "@hourly", approvals.Expire); err != nil {
+			stopCogs()
+			return nil, err
+		}
+		quotas := quota.NewService(quotafire.NewStorage(storage.Client.Client))
+		if err := jobs.Add("request-quotas-reset", "5 0 * * *", quotas.Reset); err != nil {
+			stopCogs()
+			return nil, err
+		}
+		musicCommands = dapi.NewCog(ctx, musicPlayer, settings, recorder, yt, lib, charts, artists, approvals, quotas, exporter, storage.Songs, cfg.Discord.Prefix, logger, cfg.Discord.API)
+		musicCommands.SetMaintenance(mode)
+		cogs.Add(music.NewCog(musicCommands, musicPlayer, recordings, session))
+	}
+
+	if cogs.Enabled(sapi.Name) {
+		if rawAudioPlayer == nil {
+			stopCogs()
+			return nil, errors.New("soundboard cog requires the music cog")
+		}
+		sounds := soundboard.NewService(soundfire.NewStorage(storage.Client.Client), rawAudioPlayer, voiceClient)
+		storage.Firestore.Run(a.Context(), sounds.Load)
+		caches.Add("soundboard", sounds.Load)
+		cogs.Add(sapi.NewCog(ctx, sounds, cfg.Discord.Prefix, logger.Named(sapi.Name)))
+	}
+
+	if cogs.Enabled(aapi.Name) {
+		if musicPlayer == nil {
+			stopCogs()
+			return nil, errors.New("alarms cog requires the music cog")
+		}
+		alarms := alarm.NewService(alarmfire.NewStorage(storage.Client.Client), musicPlayer, lib, settings, command.Handles, logger.Named(aapi.Name))
+		// every instance looks for the due alarms, each fires the ones of the guilds it serves
+		if err := jobs.AddLocal("music-alarms", "* * * * *", alarms.Run); err != nil {
+			stopCogs()
+			return nil, err
+		}
+		cogs.Add(aapi.NewCog(ctx, alarms, cfg.Discord.Prefix, logger.Named(aapi.Name)))
+	}
+
+	if cogs.Enabled(qapi.Name) {
+		if musicPlayer == nil {
+			stopCogs()
+			return nil, errors.New("quiz cog requires the music cog")
+		}
+		games := quiz.NewService(ctx, musicPlayer, quizfire.NewStorage(storage.Client.Client), logger.Named(qapi.Name))
+		cogs.Add(qapi.NewCog(ctx, games, cfg.Discord.Prefix, logger.Named(qapi.Name)))
+	}
+
+	if cogs.Enabled(acvapi.Name) {
+		if musicPlayer == nil {
+			stopCogs()
+			return nil, errors.New("achievements cog requires the music cog")
+		}
+		achievements := achievement.NewService(ctx, achievementfire.NewStorage(storage.Client.Client), storage.Songs, settings, logger.Named(acvapi.Name))
+		commands := acvapi.NewCog(ctx, achievements, profiles, musicCommands, session, cfg.Discord.Prefix, logger.Named(acvapi.Name))
+		achievements.SetNotifier(commands)
+		musicPlayer.Subscribe(achievements.HandleEvent, player.TrackStarted)
+		cogs.Add(commands)
+	}
+
+	if cogs.Enabled(rapi.Name) {
+		reminders := reminder.NewService(reminderfire.NewStorage(storage.Client.Client), session, settings, command.Handles, logger.Named(rapi.Name))
+		// like the alarms, each instance posts the due reminders of the guilds it serves
+		if err := jobs.AddLocal("reminders", "* * * * *", reminders.Run); err != nil {
+			stopCogs()
+			return nil, err
+		}
+		cogs.Add(rapi.NewCog(ctx, reminders, cfg.Discord.Prefix, logger.Named(rapi.Name)))
+	}
+
+	settingsCog := gapi.NewCog(ctx, settings, auditLog, cfg.Discord.Prefix, logger.Named(gapi.Name))
+	command.SetChannelFilter(settingsCog.AllowsChannel)
+	cogs.Add(settingsCog)
+	cogs.Add(hapi.NewCog(ctx, checks, prometheus.DefaultGatherer, usageStats, cfg.Discord.Prefix, logger.Named(hapi.Name)))
+	cogs.Add(udapi.NewCog(ctx, userData, cfg.Discord.Prefix, logger.Named(udapi.Name)))
+	cogs.Add(adapi.NewCog(ctx, accounts, cfg.Discord.Prefix, logger.Named(adapi.Name)))
+
+	if cogs.Enabled(chess.Name) {
+		lichessClient := lichess.NewClient()
+		chessStorage := chessfire.NewStorage(storage.Client.Client)
+		chessStats := stats.NewService(lichessClient, chessStorage)
+		chessDigest := stats.NewDigestService(lichessClient, chessStorage)
+		chessRatings := stats.NewRatingService(lichessClient)
+		chessAuth := auth.NewService(lichess.NewOAuth(cfg.Chess.ClientID, cfg.Chess.RedirectURL), lichessClient, chessStorage)
+		commands := capi.NewCog(ctx, cfg.Discord.Prefix, lichessClient, chessStorage, chessStats, chessDigest, chessRatings, chessAuth, cfg.Chess, logger.Named(chess.Name))
+		if err := commands.RegisterJobs(session, jobs); err != nil {
+			stopCogs()
+			return nil, err
+		}
+		cogs.Add(chess.NewCog(commands, chessAuth, logger.Named(chess.Name)))
+	}
+
+	if err := cogs.Validate(); err != nil {
+		stopCogs()
+		return nil, err
+	}
+	a.Append(Hook{
+		Name: "cogs",
+		Start: func(_ context.Context) error {
+			cogs.RegisterCommands(session, cfg.General.Debug, logger)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			command.StopAccepting()
+			defer stopCogs()
+			return cogs.Shutdown(ctx)
+		},
+	})
+	return cogs, nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/console"
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/reload"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+)
+
+// NewConsole of the admin commands on stdin, the music cog adds skip
+func NewConsole(a *App, checks *health.Service, caches *reload.Registry, mode *maintenance.Mode) *console.Console {
+	c := console.New()
+	c.Add("status", "shows the health checks", func(ctx context.Context, _ []string) (string, error) {
+		results := checks.Run(ctx)
+		lines := make([]string, 0, len(results))
+		for _, r := range results {
+			if r.Err != nil {
+				lines = append(lines, fmt.Sprintf("%s: FAIL %s", r.Name, r.Err))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", r.Name, r.Value))
+		}
+		return strings.Join(lines, "\n"), nil
+	})
+	c.Add("drop", "cache [name...] reloads the caches from the storage, all of them if none is given", func(ctx context.Context, args []string) (string, error) {
+		if len(args) == 0 || args[0] != "cache" {
+			return "", errors.New("usage: drop cache [name...]")
+		}
+		reloaded, err := caches.Reload(ctx, args[1:]...)
+		if err != nil {
+			return "", err
+		}
+		return "reloaded " + strings.Join(reloaded, ", "), nil
+	})
+	c.Add("maintenance", "on [reason...] | off rejects the new requests while the queue plays to the end", func(_ context.Context, args []string) (string, error) {
+		if len(args) == 0 || args[0] != "on" && args[0] != "off" {
+			return "", errors.New("usage: maintenance on [reason...] | off")
+		}
+		st := mode.Set(args[0] == "on", strings.Join(args[1:], " "))
+		if !st.Enabled {
+			return "maintenance off", nil
+		}
+		return "maintenance on", nil
+	})
+	c.Add("shutdown", "stops the bot gracefully", func(_ context.Context, _ []string) (string, error) {
+		a.Shutdown()
+		return "shutting down", nil
+	})
+	return c
+}
+
+// StartConsole after the rest of the subsystems, only when the bot is run in a terminal
+func StartConsole(a *App, c *console.Console) {
+	if !console.IsTerminal(os.Stdin) {
+		return
+	}
+	logger := a.Logger().Named("console")
+	a.Append(Hook{
+		Name: "console",
+		Start: func(ctx context.Context) error {
+			supervisor.Go(ctx, logger, "console", func(ctx context.Context) {
+				c.Run(ctx, os.Stdin, os.Stdout)
+			})
+			return nil
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	dpkg "github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+)
+
+// NewSession opens the session right away, the handlers can be added to the open session
+func NewSession(a *App) (*discordgo.Session, error) {
+	cfg := a.Config()
+	session, err := dpkg.OpenSession(cfg.Discord.Token, cfg.General.Debug, a.Logger().Named("discord"))
+	if err != nil {
+		return nil, errors.Wrap(err, "discord open session failed")
+	}
+	a.Append(Hook{
+		Name: "discord session",
+		Stop: func(_ context.Context) error {
+			if err := session.Close(); err != nil {
+				return err
+			}
+			a.Logger().Infow("Bot session closed")
+			return nil
+		},
+	})
+	return session, nil
+}
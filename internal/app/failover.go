@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/failover"
+	failfire "github.com/HalvaPovidlo/discordBotGo/internal/failover/storage/firestore"
+	failredis "github.com/HalvaPovidlo/discordBotGo/internal/failover/storage/redis"
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/redis"
+)
+
+// NewFailover returns nil without a role in the config.
+// Both bots receive all messages, only the active one handles the commands and runs the jobs.
+// The beats go to Redis if it is configured, to Firestore otherwise.
+func NewFailover(a *App, storage *Storage, redisClient *redis.Client, checks *health.Service) *failover.Monitor {
+	cfg := a.Config()
+	if cfg.Failover.Role == "" {
+		return nil
+	}
+	var beats failover.Storage = failfire.NewStorage(storage.Client.Client)
+	if redisClient != nil {
+		beats = failredis.NewStorage(redisClient, cfg.Redis.Prefix)
+	}
+	m := failover.NewMonitor(beats, cfg.Failover.Role, cfg.Failover.Interval.Duration, cfg.Failover.Timeout.Duration, a.Logger().Named("failover"))
+	command.SetGuildFilter(func(_ string) bool {
+		return m.Active()
+	})
+	checks.Add("Failover", func(_ context.Context) (string, error) {
+		if m.Active() {
+			return m.Role() + ", serving", nil
+		}
+		return m.Role() + ", waiting", nil
+	})
+	a.Append(Hook{
+		Name: "failover",
+		Start: func(ctx context.Context) error {
+			m.Start(ctx)
+			return nil
+		},
+		// the cogs are stopped and the queue is saved by now
+		Stop: m.Stop,
+	})
+	return m
+}
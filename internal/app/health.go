@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+)
+
+// NewHealth checks of the shared subsystems, the cogs add their own
+func NewHealth(a *App, session *discordgo.Session, storage *Storage) *health.Service {
+	checks := health.NewService()
+	checks.Add("Uptime", func(_ context.Context) (string, error) {
+		return a.Uptime().Round(time.Second).String(), nil
+	})
+	checks.Add("Gateway", func(_ context.Context) (string, error) {
+		if !session.DataReady {
+			return "", errors.New("disconnected")
+		}
+		return session.HeartbeatLatency().Round(time.Millisecond).String(), nil
+	})
+	checks.Add("Firestore", func(ctx context.Context) (string, error) {
+		start := time.Now()
+		if err := storage.Client.Ping(ctx); err != nil {
+			return "", err
+		}
+		latency := time.Since(start).Round(time.Millisecond).String()
+		if !storage.Firestore.Available() {
+			return "", errors.Errorf("degraded, recovering (%s)", latency)
+		}
+		return latency, nil
+	})
+	checks.Add("Songs cache", func(_ context.Context) (string, error) {
+		return fmt.Sprintf("%d songs, radio picks from %d", storage.Cache.Len(), storage.Songs.ShortCacheLen()), nil
+	})
+	return checks
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	"github.com/HalvaPovidlo/discordBotGo/docs"
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	acrest "github.com/HalvaPovidlo/discordBotGo/internal/account/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/analytics"
+	anrest "github.com/HalvaPovidlo/discordBotGo/internal/analytics/api/rest"
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/artist"
+	artrest "github.com/HalvaPovidlo/discordBotGo/internal/artist/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+	arest "github.com/HalvaPovidlo/discordBotGo/internal/audit/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/dashboard"
+	"github.com/HalvaPovidlo/discordBotGo/internal/export"
+	erest "github.com/HalvaPovidlo/discordBotGo/internal/export/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	grest "github.com/HalvaPovidlo/discordBotGo/internal/guild/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/importer"
+	irest "github.com/HalvaPovidlo/discordBotGo/internal/importer/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	lrest "github.com/HalvaPovidlo/discordBotGo/internal/library/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	mtrest "github.com/HalvaPovidlo/discordBotGo/internal/maintenance/api/rest"
+	mrest "github.com/HalvaPovidlo/discordBotGo/internal/music/api/rest"
+	ytsearch "github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/profile"
+	prest "github.com/HalvaPovidlo/discordBotGo/internal/profile/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/recap"
+	rrest "github.com/HalvaPovidlo/discordBotGo/internal/recap/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/reload"
+	rlrest "github.com/HalvaPovidlo/discordBotGo/internal/reload/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/usage"
+	usrest "github.com/HalvaPovidlo/discordBotGo/internal/usage/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/userdata"
+	udrest "github.com/HalvaPovidlo/discordBotGo/internal/userdata/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/cog"
+)
+
+// NewHTTPServer serves the api of the cogs, the admin api, swagger and metrics on the bot port
+func NewHTTPServer(a *App, cogs *cog.Registry, yt *ytsearch.YouTube, auditLog *audit.Log, accounts *account.Service, userData *userdata.Service, lib *library.Service, artists *artist.Service, exporter *export.Service, profiles *profile.Service, guildAnalytics *analytics.Service, recaps *recap.Service, songImporter *importer.Service, caches *reload.Registry, settings *guild.Service, mode *maintenance.Mode, usageStats *usage.Stats) *http.Server {
+	cfg := a.Config()
+	if !cfg.General.Debug {
+		gin.SetMode(gin.ReleaseMode)
+		gin.DisableConsoleColor()
+	}
+	router := gin.New()
+	docs.SwaggerInfo.Host = cfg.Host.IP + ":" + cfg.Host.Bot
+	docs.SwaggerInfo.BasePath = "/api/v1"
+	apiRouter := v1.NewAPI(router.Group("/api/v1")).Router()
+	apiRouter.Use(v1.Identify(accounts, cfg.Admin.Token))
+	acrest.NewHandler(accounts, apiRouter).Router()
+	udrest.NewHandler(userData, apiRouter).Router()
+	prest.NewHandler(profiles, apiRouter).Router()
+	shares := lrest.NewHandler(lib, apiRouter)
+	shares.Router()
+	shares.PageRouter(&router.RouterGroup)
+	dashboard.NewHandler().Router(&router.RouterGroup)
+	artrest.NewHandler(artists, apiRouter).Router()
+	erest.NewHandler(exporter, apiRouter).Router()
+	anrest.NewHandler(guildAnalytics, apiRouter).Router()
+	rrest.NewHandler(recaps, apiRouter).Router()
+	cogs.RegisterRoutes(apiRouter)
+	admin := apiRouter.Group("/admin", v1.Admin(cfg.Admin.Token))
+	arest.NewHandler(auditLog, admin).Router()
+	irest.NewHandler(songImporter, admin).Router()
+	mrest.NewAdminHandler(yt, admin).Router()
+	rlrest.NewAdminHandler(caches, admin).Router()
+	mtrest.NewAdminHandler(mode, admin).Router()
+	usrest.NewAdminHandler(usageStats, admin).Router()
+	// the settings are under the guilds next to the analytics, with the admin token
+	grest.NewAdminHandler(settings, apiRouter.Group("", v1.Admin(cfg.Admin.Token))).Router()
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	server := &http.Server{
+		Addr:    ":" + cfg.Host.Bot,
+		Handler: router,
+	}
+	a.Append(Hook{
+		Name: "http server",
+		Start: func(_ context.Context) error {
+			go func() {
+				err := server.ListenAndServe()
+				if err != nil && err != http.ErrServerClosed {
+					a.Logger().Error(err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	})
+	return server
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/analytics"
+	"github.com/HalvaPovidlo/discordBotGo/internal/artist"
+	artistfire "github.com/HalvaPovidlo/discordBotGo/internal/artist/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/export"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	libraryfire "github.com/HalvaPovidlo/discordBotGo/internal/library/storage/firestore"
+	listeningfire "github.com/HalvaPovidlo/discordBotGo/internal/listening/storage/firestore"
+	ytsearch "github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/profile"
+	"github.com/HalvaPovidlo/discordBotGo/internal/recap"
+	rdapi "github.com/HalvaPovidlo/discordBotGo/internal/recap/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/reload"
+	"github.com/HalvaPovidlo/discordBotGo/internal/scheduler"
+	"github.com/HalvaPovidlo/discordBotGo/internal/trends"
+	trendsfire "github.com/HalvaPovidlo/discordBotGo/internal/trends/storage/firestore"
+)
+
+// NewLibrary the favorites and the playlists of the users, the shared playlists are served on the bot port
+func NewLibrary(a *App, storage *Storage) *library.Service {
+	return library.NewService(libraryfire.NewStorage(storage.Client.Client), a.Config().Host.BotURL())
+}
+
+// NewExporter of the playlists to the services with an oauth client in the config
+func NewExporter(a *App, lib *library.Service) *export.Service {
+	cfg := a.Config()
+	exporter := export.NewService(lib)
+	callback := func(service string) string {
+		return cfg.Host.BotURL() + "/api/v1/export/" + service + "/callback"
+	}
+	if c := cfg.Export.YouTube; c.ClientID != "" {
+		exporter.Add("youtube", export.NewYouTube(c.ClientID, c.ClientSecret, callback("youtube")))
+	}
+	if c := cfg.Export.Spotify; c.ClientID != "" {
+		exporter.Add("spotify", export.NewSpotify(c.ClientID, c.ClientSecret, callback("spotify")))
+	}
+	return exporter
+}
+
+// NewArtists the artist documents are aggregated from the songs every night
+func NewArtists(a *App, storage *Storage, jobs *scheduler.Scheduler) (*artist.Service, error) {
+	artists := artist.NewService(storage.Songs, artistfire.NewStorage(storage.Client.Client))
+	storage.Firestore.Run(a.Context(), artists.Load)
+	if err := jobs.Add("music-artists", "30 0 * * *", artists.Aggregate); err != nil {
+		return nil, err
+	}
+	return artists, nil
+}
+
+// NewCaches the memory caches the admin api reloads, the cogs add theirs
+func NewCaches(settings *guild.Service, artists *artist.Service, jobs *scheduler.Scheduler, yt *ytsearch.YouTube) *reload.Registry {
+	caches := reload.NewRegistry()
+	caches.Add("settings", settings.Load)
+	caches.Add("artists", artists.Load)
+	caches.Add("schedules", jobs.Load)
+	// the searches are dropped, they are made again on demand
+	caches.Add("searches", yt.FlushSearches)
+	return caches
+}
+
+// NewAnalytics of the guilds from the history of the requested songs
+func NewAnalytics(storage *Storage) *analytics.Service {
+	return analytics.NewService(storage.Songs)
+}
+
+// NewTrends the snapshots of the previous day are taken after midnight in UTC
+func NewTrends(storage *Storage, jobs *scheduler.Scheduler) (*trends.Service, error) {
+	t := trends.NewService(storage.Songs, trendsfire.NewStorage(storage.Client.Client))
+	if err := jobs.Add("songs-snapshot", "10 0 * * *", t.Snapshot); err != nil {
+		return nil, err
+	}
+	if err := jobs.Add("songs-snapshots-compact", "@weekly", t.Compact); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NewProfiles aggregates the songs and the library of the users for the website
+func NewProfiles(storage *Storage, lib *library.Service) *profile.Service {
+	return profile.NewService(storage.Songs, lib, listeningfire.NewStorage(storage.Client.Client))
+}
+
+// NewRecaps of the months, the guilds with the recap feature get them posted on the first day of the month
+func NewRecaps(a *App, session *discordgo.Session, storage *Storage, settings *guild.Service, jobs *scheduler.Scheduler) (*recap.Service, error) {
+	recaps := recap.NewService(storage.Songs, listeningfire.NewStorage(storage.Client.Client))
+	poster := rdapi.NewPoster(recaps, settings, a.Logger().Named("recap"))
+	if err := poster.RegisterJobs(session, jobs); err != nil {
+		return nil, err
+	}
+	return recaps, nil
+}
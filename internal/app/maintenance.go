@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+)
+
+// NewMaintenance the mode of the whole bot, general.maintenance enables it from the start.
+// The status of the bot shows the banner while it is enabled.
+func NewMaintenance(a *App, session *discordgo.Session, checks *health.Service) *maintenance.Mode {
+	mode := maintenance.NewMode(a.Config().General.Maintenance)
+	logger := a.Logger().Named("maintenance")
+	mode.OnChange(func(st maintenance.Status) {
+		logger.Infow("maintenance mode switched", "enabled", st.Enabled, "reason", st.Reason)
+		if err := setBanner(session, st); err != nil {
+			logger.Error(errors.Wrap(err, "set maintenance banner"))
+		}
+	})
+	checks.Add("Maintenance", func(_ context.Context) (string, error) {
+		st := mode.Status()
+		if !st.Enabled {
+			return "off", nil
+		}
+		return "on since " + st.Since.Format("15:04 MST") + " " + st.Reason, nil
+	})
+	a.Append(Hook{
+		Name: "maintenance banner",
+		Start: func(_ context.Context) error {
+			if st := mode.Status(); st.Enabled {
+				if err := setBanner(session, st); err != nil {
+					logger.Error(errors.Wrap(err, "set maintenance banner"))
+				}
+			}
+			return nil
+		},
+	})
+	return mode
+}
+
+// setBanner the next song sets the listening status again when the mode is off
+func setBanner(session *discordgo.Session, st maintenance.Status) error {
+	if !st.Enabled {
+		return session.UpdateStatusComplex(discordgo.UpdateStatusData{Status: string(discordgo.StatusOnline)})
+	}
+	name := "maintenance"
+	if st.Reason != "" {
+		name += ": " + st.Reason
+	}
+	return session.UpdateStatusComplex(discordgo.UpdateStatusData{
+		Status:     string(discordgo.StatusDoNotDisturb),
+		Activities: []*discordgo.Activity{{Name: name, Type: discordgo.ActivityTypeGame}},
+	})
+}
@@ -0,0 +1,17 @@
+package app
+
+import (
+	"github.com/HalvaPovidlo/discordBotGo/internal/migration"
+	migrationfire "github.com/HalvaPovidlo/discordBotGo/internal/migration/storage/firestore"
+)
+
+// RunMigrations before the subsystems load their data, they run when Firestore is back if it is unavailable
+func RunMigrations(a *App, storage *Storage) {
+	runner := migration.NewRunner(
+		migrationfire.NewStorage(storage.Client.Client),
+		migrationfire.Migrations(storage.Client.Client),
+		a.Config().Cluster.Instance,
+		a.Logger().Named("migration"),
+	)
+	storage.Firestore.Run(a.Context(), runner.Run)
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/redis"
+)
+
+// NewRedis returns nil without the url in the config
+func NewRedis(a *App, checks *health.Service) (*redis.Client, error) {
+	cfg := a.Config().Redis
+	if cfg.URL == "" {
+		return nil, nil
+	}
+	client, err := redis.NewClient(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	a.Append(Hook{
+		Name: "redis",
+		Stop: func(_ context.Context) error {
+			return client.Close()
+		},
+	})
+	checks.Add("Redis", func(ctx context.Context) (string, error) {
+		start := time.Now()
+		if err := client.Ping(ctx); err != nil {
+			return "", err
+		}
+		return time.Since(start).Round(time.Millisecond).String(), nil
+	})
+	return client, nil
+}
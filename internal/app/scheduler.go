@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/failover"
+	"github.com/HalvaPovidlo/discordBotGo/internal/scheduler"
+	schedulefire "github.com/HalvaPovidlo/discordBotGo/internal/scheduler/storage/firestore"
+)
+
+// NewScheduler the jobs are added while the subsystems are built and start with the app
+func NewScheduler(a *App, storage *Storage, cluster *Cluster, standby *failover.Monitor) *scheduler.Scheduler {
+	var locker scheduler.Locker
+	switch {
+	case cluster != nil:
+		locker = cluster.locks
+	case standby != nil:
+		locker = standby
+	}
+	s := scheduler.New(schedulefire.NewStorage(storage.Client.Client), locker, a.Logger().Named("scheduler"))
+	ctx, stopJobs := context.WithCancel(a.Context())
+	a.Append(Hook{
+		Name: "scheduler",
+		Start: func(_ context.Context) error {
+			storage.Firestore.Run(ctx, s.Load)
+			s.Start(ctx)
+			return nil
+		},
+		Stop: func(_ context.Context) error {
+			stopJobs()
+			return nil
+		},
+	})
+	return s
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/dependency"
+)
+
+const (
+	// pingTimeout for Firestore at startup, the bot starts degraded without it
+	pingTimeout = 10 * time.Second
+	// recoverInterval how often an unavailable Firestore is checked
+	recoverInterval = 30 * time.Second
+)
+
+// Storage firestore client shared by all modules and the songs on top of it.
+// The subsystems which can't load their data at startup run Firestore steps, they are repeated when it comes back.
+type Storage struct {
+	Client    *firestore.Client
+	Songs     *firestore.Service
+	Cache     *firestore.SongsCache
+	Firestore *dependency.Dependency
+}
+
+// NewStorage the background writes stop before the last flush
+func NewStorage(a *App) (*Storage, error) {
+	cfg := a.Config()
+	workers, stopWorkers := context.WithCancel(a.Context())
+	cache := firestore.NewSongsCache(workers, cfg.Cache.SongsTTL.Duration)
+	client, err := firestore.NewFirestoreClient(workers, cfg.Credentials.Firebase, cfg.General.Debug)
+	if err != nil {
+		stopWorkers()
+		return nil, errors.Wrap(err, "firestore client")
+	}
+	songs, err := firestore.NewFirestoreService(workers, client, cache)
+	if err != nil {
+		stopWorkers()
+		return nil, errors.Wrap(err, "firestore service")
+	}
+	available := dependency.New("firestore", client.Ping, a.Logger())
+	pingCtx, cancel := context.WithTimeout(a.Context(), pingTimeout)
+	defer cancel()
+	if err := client.Ping(pingCtx); err != nil {
+		// only the cached songs and the links play, the playbacks are not counted
+		songs.SetOffline(true)
+		available.Fail(err, func(ctx context.Context) error {
+			songs.SetOffline(false)
+			return songs.RefreshShortCache(ctx)
+		})
+	}
+	a.Append(Hook{
+		Name: "storage",
+		Start: func(_ context.Context) error {
+			available.Watch(workers, recoverInterval)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			stopWorkers()
+			defer cache.Clear()
+			if err := client.Flush(ctx); err != nil {
+				a.Logger().Error(errors.Wrap(err, "flush firestore"))
+			}
+			return client.Close()
+		},
+	})
+	return &Storage{
+		Client:    client,
+		Songs:     songs,
+		Cache:     cache,
+		Firestore: available,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	"github.com/HalvaPovidlo/discordBotGo/internal/achievement"
+	achievementfire "github.com/HalvaPovidlo/discordBotGo/internal/achievement/storage/firestore"
+	approvalfire "github.com/HalvaPovidlo/discordBotGo/internal/approval/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+	chessfire "github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	libraryfire "github.com/HalvaPovidlo/discordBotGo/internal/library/storage/firestore"
+	listeningfire "github.com/HalvaPovidlo/discordBotGo/internal/listening/storage/firestore"
+	quizfire "github.com/HalvaPovidlo/discordBotGo/internal/quiz/storage/firestore"
+	quotafire "github.com/HalvaPovidlo/discordBotGo/internal/quota/storage/firestore"
+	reminderfire "github.com/HalvaPovidlo/discordBotGo/internal/reminder/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/userdata"
+)
+
+// NewUserData the sources of the data kept about the users for the export and the deletion.
+// The audit entries are exported but kept, the deletion itself is recorded there. The links go last,
+// the client which asked for the deletion stays linked until everything else is erased.
+func NewUserData(storage *Storage, accounts *account.Service, lib *library.Service, auditLog *audit.Log) *userdata.Service {
+	data := userdata.NewService()
+	songs := storage.Songs
+	data.Add("songs", func(ctx context.Context, userID string) (interface{}, error) {
+		return songs.UserSongs(ctx, userID)
+	}, songs.DeleteUserSongs)
+	data.Add("plays", func(ctx context.Context, userID string) (interface{}, error) {
+		return songs.UserPlays(ctx, userID, time.Time{}, time.Now())
+	}, songs.ForgetUserPlays)
+	data.Add("favorites", func(ctx context.Context, userID string) (interface{}, error) {
+		return lib.Favorites(ctx, userID)
+	}, lib.DeleteFavorites)
+	data.Add("playlists", func(ctx context.Context, userID string) (interface{}, error) {
+		return lib.Playlists(ctx, userID)
+	}, lib.DeletePlaylists)
+	// the playlists take their links with them, the ones left by a failed deletion go here
+	shares := libraryfire.NewStorage(storage.Client.Client)
+	data.Add("playlist_shares", func(ctx context.Context, userID string) (interface{}, error) {
+		return shares.UserShares(ctx, userID)
+	}, shares.DeleteUserShares)
+	quotas := quotafire.NewStorage(storage.Client.Client)
+	data.Add("request_quotas", func(ctx context.Context, userID string) (interface{}, error) {
+		return quotas.UserUsage(ctx, userID)
+	}, quotas.DeleteUserUsage)
+	approvals := approvalfire.NewStorage(storage.Client.Client)
+	data.Add("song_approvals", func(ctx context.Context, userID string) (interface{}, error) {
+		return approvals.UserRequests(ctx, userID)
+	}, approvals.DeleteUserRequests)
+	listening := listeningfire.NewStorage(storage.Client.Client)
+	data.Add("listening", func(ctx context.Context, userID string) (interface{}, error) {
+		return listening.Days(ctx, userID, "", "9999-12-31")
+	}, listening.DeleteDays)
+	quizScores := quizfire.NewStorage(storage.Client.Client)
+	data.Add("quiz", func(ctx context.Context, userID string) (interface{}, error) {
+		return quizScores.UserScores(ctx, userID)
+	}, quizScores.DeleteUserScores)
+	achievements := achievementfire.NewStorage(storage.Client.Client)
+	data.Add("achievements", func(ctx context.Context, userID string) (interface{}, error) {
+		p, err := achievements.Progress(ctx, userID)
+		if errors.Is(err, achievement.ErrNotFound) {
+			return nil, nil
+		}
+		return p, err
+	}, achievements.DeleteProgress)
+	reminders := reminderfire.NewStorage(storage.Client.Client)
+	data.Add("reminders", func(ctx context.Context, userID string) (interface{}, error) {
+		return reminders.UserReminders(ctx, userID)
+	}, reminders.DeleteUserReminders)
+	chess := chessfire.NewStorage(storage.Client.Client)
+	data.Add("lichess", func(ctx context.Context, userID string) (interface{}, error) {
+		link, err := chess.GetLink(ctx, userID)
+		if errors.Is(err, chessfire.ErrNotFound) {
+			return nil, nil
+		}
+		return link, err
+	}, func(ctx context.Context, userID string) (int, error) {
+		// the oauth token is erased but never exported
+		if _, err := chess.GetLink(ctx, userID); errors.Is(err, chessfire.ErrNotFound) {
+			return 0, chess.DeleteToken(ctx, userID)
+		} else if err != nil {
+			return 0, err
+		}
+		if err := chess.DeleteLink(ctx, userID); err != nil {
+			return 0, err
+		}
+		return 1, chess.DeleteToken(ctx, userID)
+	})
+	data.Add("chess_races", func(ctx context.Context, userID string) (interface{}, error) {
+		return chess.UserRaces(ctx, userID)
+	}, chess.DeleteUserRaces)
+	data.Add("commands", func(ctx context.Context, userID string) (interface{}, error) {
+		return auditLog.Entries(ctx, audit.Query{UserID: userID, Limit: audit.MaxLimit})
+	}, nil)
+	data.Add("links", func(ctx context.Context, userID string) (interface{}, error) {
+		return accounts.Links(ctx, userID)
+	}, accounts.UnlinkAll)
+	return data
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	ytdl "github.com/kkdai/youtube/v2"
+	"github.com/pkg/errors"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/internal/importer"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/download"
+	ytsearch "github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+)
+
+// NewYouTube with download enabled the files are kept in the cache bounded by youtube.cache_size_mb
+func NewYouTube(a *App, storage *Storage, checks *health.Service) (*ytsearch.YouTube, error) {
+	cfg := a.Config()
+	var files ytsearch.Files
+	if cfg.Youtube.Download {
+		cache, err := download.NewCache(cfg.Youtube.OutputDir, cfg.Youtube.CacheSizeMB<<20, storage.Client, a.Logger().Named("youtube"))
+		if err != nil {
+			return nil, errors.Wrap(err, "download cache")
+		}
+		storage.Firestore.Run(a.Context(), cache.Load)
+		files = cache
+		checks.Add("Downloads", func(_ context.Context) (string, error) {
+			size, maxSize, n := cache.Usage()
+			return fmt.Sprintf("%d files, %d of %d MB", n, size>>20, maxSize>>20), nil
+		})
+	}
+	keys, err := youtubeKeys(a)
+	if err != nil {
+		return nil, err
+	}
+	yt := ytsearch.NewYouTubeClient(
+		&ytdl.Client{
+			Debug:      cfg.General.Debug,
+			HTTPClient: http.DefaultClient,
+		},
+		keys,
+		storage.Cache,
+		files,
+		cfg.Youtube,
+	)
+	yt.SetSearchTTL(cfg.Cache.SearchTTL.Duration)
+	checks.Add("YouTube quota", func(_ context.Context) (string, error) {
+		if yt.LibraryOnly() {
+			return "library mode, only the downloaded songs play", nil
+		}
+		left, daily := yt.QuotaLeft()
+		if left <= 0 {
+			return "", errors.Errorf("exhausted, %d units a day", daily)
+		}
+		if len(keys) > 1 {
+			return fmt.Sprintf("%d of %d units (%s)", left, daily, yt.QuotaByKey()), nil
+		}
+		return fmt.Sprintf("%d of %d units", left, daily), nil
+	})
+	return yt, nil
+}
+
+// youtubeKeys the keys are named by their position, so they don't leak to the metrics and logs
+func youtubeKeys(a *App) ([]ytsearch.Key, error) {
+	cfg := a.Config()
+	if len(cfg.Youtube.APIKeys) == 0 {
+		service, err := youtube.NewService(a.Context(), option.WithCredentialsFile(cfg.Credentials.Google))
+		if err != nil {
+			return nil, errors.Wrap(err, "youtube init failed")
+		}
+		return []ytsearch.Key{{Name: "credentials", Service: service}}, nil
+	}
+	keys := make([]ytsearch.Key, 0, len(cfg.Youtube.APIKeys))
+	for i, key := range cfg.Youtube.APIKeys {
+		name := fmt.Sprintf("key%d", i+1)
+		service, err := youtube.NewService(a.Context(), option.WithAPIKey(key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "youtube init failed with %s", name)
+		}
+		keys = append(keys, ytsearch.Key{Name: name, Service: service})
+	}
+	return keys, nil
+}
+
+// NewImporter seeds the songs from the libraries of other bots, the metadata comes from the videos API
+func NewImporter(yt *ytsearch.YouTube, storage *Storage) *importer.Service {
+	return importer.NewService(yt, storage.Songs)
+}
@@ -0,0 +1,107 @@
+package approval
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+// Timeout of a request, it is rejected if no DJ decides in time
+const Timeout = 15 * time.Minute
+
+var ErrNotFound = errors.New("the request was already decided or it's too late")
+
+type Storage interface {
+	Set(ctx context.Context, r *Request) error
+	// Take deletes the request and returns it, ErrNotFound if there is no such request
+	Take(ctx context.Context, id string) (*Request, error)
+	// Expire deletes the requests created before the time
+	Expire(ctx context.Context, before time.Time) (int, error)
+}
+
+// Request of a song by a member who isn't a DJ, it waits in the pending list
+type Request struct {
+	ID      string   `firestore:"-"`
+	GuildID string   `firestore:"guild_id"`
+	UserID  string   `firestore:"user_id"`
+	Song    pkg.Song `firestore:"song"`
+	// VoiceChannelID the song is played in
+	VoiceChannelID string `firestore:"voice_channel_id"`
+	// ChannelID and MessageID of the message with the buttons
+	ChannelID string    `firestore:"channel_id"`
+	MessageID string    `firestore:"message_id,omitempty"`
+	Next      bool      `firestore:"next,omitempty"`
+	Created   time.Time `firestore:"created"`
+}
+
+// Service the pending requests are kept in Firestore, so a restart doesn't lose them
+type Service struct {
+	storage Storage
+}
+
+func NewService(storage Storage) *Service {
+	return &Service{storage: storage}
+}
+
+// Add the request to the pending list, its id is set
+func (s *Service) Add(ctx context.Context, r *Request) error {
+	r.ID = uuid.New().String()[:8]
+	r.Created = time.Now()
+	if err := s.storage.Set(ctx, r); err != nil {
+		return errors.Wrap(err, "add request")
+	}
+	requests.WithLabelValues("pending").Inc()
+	return nil
+}
+
+// SetMessage of the request after the buttons were sent
+func (s *Service) SetMessage(ctx context.Context, r *Request, messageID string) error {
+	r.MessageID = messageID
+	return s.storage.Set(ctx, r)
+}
+
+// Approve takes the request to play its song, only one DJ gets it
+func (s *Service) Approve(ctx context.Context, id string) (*Request, error) {
+	return s.take(ctx, id, "approved")
+}
+
+// Reject takes the request to drop it
+func (s *Service) Reject(ctx context.Context, id string) (*Request, error) {
+	return s.take(ctx, id, "rejected")
+}
+
+// Expired takes the request if nobody decided in time
+func (s *Service) Expired(ctx context.Context, id string) (*Request, error) {
+	return s.take(ctx, id, "expired")
+}
+
+func (s *Service) take(ctx context.Context, id, result string) (*Request, error) {
+	r, err := s.storage.Take(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if result != "expired" && time.Since(r.Created) > Timeout {
+		result = "expired"
+		err = ErrNotFound
+	}
+	requests.WithLabelValues(result).Inc()
+	if err != nil {
+		return nil, err
+	}
+	r.Song.ID = pkg.GetIDFromURL(r.Song.URL)
+	return r, nil
+}
+
+// Expire the requests left after a restart, the others are expired by the cog when their time is up
+func (s *Service) Expire(ctx context.Context) error {
+	n, err := s.storage.Expire(ctx, time.Now().Add(-Timeout))
+	if err != nil {
+		return errors.Wrap(err, "expire requests")
+	}
+	requests.WithLabelValues("expired").Add(float64(n))
+	return nil
+}
@@ -0,0 +1,13 @@
+package approval
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "approval",
+	Name:      "requests_total",
+	Help:      "Song requests waiting for a DJ by the state: pending, approved, rejected or expired.",
+}, []string{"state"})
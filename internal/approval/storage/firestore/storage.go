@@ -0,0 +1,124 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/approval"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const approvalsCollection = "song_approvals"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) Set(ctx context.Context, r *approval.Request) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: Set approval request %s guild:%s", r.ID, r.GuildID)
+	if _, err := s.client.Collection(approvalsCollection).Doc(r.ID).Set(ctx, r); err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", r.ID, approvalsCollection)
+	}
+	return nil
+}
+
+// Take the transaction gives the request to only one of the racing DJs
+func (s *Storage) Take(ctx context.Context, id string) (*approval.Request, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: Take approval request %s", id)
+	ref := s.client.Collection(approvalsCollection).Doc(id)
+	var r approval.Request
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return approval.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := doc.DataTo(&r); err != nil {
+			return errors.Wrap(err, "unable to marshal data")
+		}
+		return tx.Delete(ref)
+	})
+	if errors.Is(err, approval.ErrNotFound) {
+		return nil, approval.ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to take %s from %s", id, approvalsCollection)
+	}
+	r.ID = id
+	return &r, nil
+}
+
+// UserRequests pending in all guilds, for the export of the user data
+func (s *Storage) UserRequests(ctx context.Context, userID string) ([]approval.Request, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: UserRequests %s", userID)
+	iter := s.client.Collection(approvalsCollection).Where("user_id", "==", userID).Documents(ctx)
+	defer iter.Stop()
+	res := make([]approval.Request, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s of %s", approvalsCollection, userID)
+		}
+		var r approval.Request
+		if err := doc.DataTo(&r); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		r.ID = doc.Ref.ID
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// DeleteUserRequests pending, their buttons answer that the request is gone
+func (s *Storage) DeleteUserRequests(ctx context.Context, userID string) (int, error) {
+	requests, err := s.UserRequests(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range requests {
+		if _, err := s.client.Collection(approvalsCollection).Doc(requests[i].ID).Delete(ctx); err != nil {
+			return i, errors.Wrapf(err, "failed to delete %s from %s", requests[i].ID, approvalsCollection)
+		}
+	}
+	return len(requests), nil
+}
+
+func (s *Storage) Expire(ctx context.Context, before time.Time) (int, error) {
+	iter := s.client.Collection(approvalsCollection).Where("created", "<", before).Documents(ctx)
+	defer iter.Stop()
+	n := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return n, errors.Wrapf(err, "failed to get expired %s", approvalsCollection)
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return n, errors.Wrapf(err, "failed to delete %s from %s", doc.Ref.ID, approvalsCollection)
+		}
+		n++
+	}
+	if n > 0 {
+		contexts.LoggerFromContext(ctx).Infof("DB: Expired %d approval requests", n)
+	}
+	return n, nil
+}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/artist"
+)
+
+// artist godoc
+// @summary  Total plays and the songs of the artist, the most played first, updated daily
+// @produce  json
+// @param    id   path      string  true  "Artist ID, the id of the YouTube channel"
+// @success  200  {object}  artist.Artist
+// @failure  404  {object}  Response  "Unknown artist"
+// @failure  500  {object}  Response  "Database error"
+// @router   /music/artists/{id} [get]
+func (h *Handler) artistHandler(c *gin.Context) {
+	a, err := h.artists.Get(c.Request.Context(), c.Param("id"))
+	h.respond(c, a, err)
+}
+
+// find godoc
+// @summary  The artist by the name, the exact name first, then the most played artist containing it
+// @produce  json
+// @param    name  query     string  true  "Name of the artist"
+// @success  200   {object}  artist.Artist
+// @failure  404   {object}  Response  "Unknown artist"
+// @failure  500   {object}  Response  "Database error"
+// @router   /music/artists [get]
+func (h *Handler) findHandler(c *gin.Context) {
+	a, err := h.artists.Find(c.Request.Context(), c.Query("name"))
+	h.respond(c, a, err)
+}
+
+func (h *Handler) respond(c *gin.Context, a *artist.Artist, err error) {
+	if errors.Is(err, artist.ErrNotFound) {
+		c.JSON(http.StatusNotFound, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/artist"
+)
+
+type Artists interface {
+	Get(ctx context.Context, id string) (*artist.Artist, error)
+	Find(ctx context.Context, name string) (*artist.Artist, error)
+}
+
+type Handler struct {
+	artists Artists
+	super   *gin.RouterGroup
+}
+
+func NewHandler(artists Artists, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		artists: artists,
+		super:   superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/music/artists")
+	group.GET("", h.findHandler)
+	group.GET("/:id", h.artistHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,184 @@
+package artist
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+// MaxSongs of an artist document, the least played are left out
+const MaxSongs = 500
+
+var ErrNotFound = errors.New("artist not found")
+
+// Songs of the library, the copy of the list the radio picks from
+type Songs interface {
+	ShortSongs() []pkg.Song
+}
+
+type Storage interface {
+	// Summaries of all artists without the songs
+	Summaries(ctx context.Context) ([]Summary, error)
+	// Get returns ErrNotFound if there is no such artist
+	Get(ctx context.Context, id string) (*Artist, error)
+	Set(ctx context.Context, artists []Artist) error
+}
+
+type Song struct {
+	ID        string `firestore:"id" json:"id"`
+	Title     string `firestore:"title" json:"title"`
+	URL       string `firestore:"url" json:"url"`
+	Playbacks int    `firestore:"playbacks" json:"playbacks"`
+}
+
+// Artist the songs of the library grouped by the url of the artist, the most played first
+type Artist struct {
+	ID      string    `firestore:"id" json:"id"`
+	Name    string    `firestore:"name" json:"name"`
+	URL     string    `firestore:"url" json:"url"`
+	Plays   int       `firestore:"plays" json:"plays"`
+	Count   int       `firestore:"count" json:"count"`
+	Songs   []Song    `firestore:"songs" json:"songs"`
+	Updated time.Time `firestore:"updated" json:"updated"`
+}
+
+// Summary of an artist to find it by the name
+type Summary struct {
+	ID    string `firestore:"id"`
+	Name  string `firestore:"name"`
+	Plays int    `firestore:"plays"`
+	Count int    `firestore:"count"`
+}
+
+// Service keeps the artist documents up to date and finds them by the name
+type Service struct {
+	songs   Songs
+	storage Storage
+
+	mx        sync.RWMutex
+	summaries map[string]Summary // id
+}
+
+func NewService(songs Songs, storage Storage) *Service {
+	return &Service{
+		songs:     songs,
+		storage:   storage,
+		summaries: make(map[string]Summary),
+	}
+}
+
+// ID of the artist from the url, the last part of the path, e.g. the id of the YouTube channel
+func ID(artistURL string) string {
+	u, err := url.Parse(artistURL)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	return path.Base(strings.TrimSuffix(u.Path, "/"))
+}
+
+// Load the summaries of the artists, a Firestore step
+func (s *Service) Load(ctx context.Context) error {
+	summaries, err := s.storage.Summaries(ctx)
+	if err != nil {
+		return errors.Wrap(err, "artist summaries")
+	}
+	s.mx.Lock()
+	s.summaries = make(map[string]Summary, len(summaries))
+	for _, sum := range summaries {
+		s.summaries[sum.ID] = sum
+	}
+	s.mx.Unlock()
+	return nil
+}
+
+// Aggregate the songs of the library by the artist, only the artists with new plays or songs are written
+func (s *Service) Aggregate(ctx context.Context) error {
+	artists := make(map[string]*Artist)
+	for _, song := range s.songs.ShortSongs() {
+		id := ID(song.ArtistURL)
+		if id == "" || id == "." || id == "/" {
+			continue
+		}
+		a, ok := artists[id]
+		if !ok {
+			a = &Artist{ID: id, Name: song.ArtistName, URL: song.ArtistURL}
+			artists[id] = a
+		}
+		a.Plays += song.Playbacks
+		a.Count++
+		a.Songs = append(a.Songs, Song{ID: song.ID.String(), Title: song.Title, URL: song.URL, Playbacks: song.Playbacks})
+	}
+
+	now := time.Now()
+	changed := make([]Artist, 0)
+	s.mx.RLock()
+	for id, a := range artists {
+		if old, ok := s.summaries[id]; ok && old.Plays == a.Plays && old.Count == a.Count && old.Name == a.Name {
+			continue
+		}
+		sort.Slice(a.Songs, func(i, j int) bool {
+			if a.Songs[i].Playbacks != a.Songs[j].Playbacks {
+				return a.Songs[i].Playbacks > a.Songs[j].Playbacks
+			}
+			return a.Songs[i].Title < a.Songs[j].Title
+		})
+		if len(a.Songs) > MaxSongs {
+			a.Songs = a.Songs[:MaxSongs]
+		}
+		a.Updated = now
+		changed = append(changed, *a)
+	}
+	s.mx.RUnlock()
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := s.storage.Set(ctx, changed); err != nil {
+		return errors.Wrap(err, "set artists")
+	}
+	s.mx.Lock()
+	for i := range changed {
+		a := &changed[i]
+		s.summaries[a.ID] = Summary{ID: a.ID, Name: a.Name, Plays: a.Plays, Count: a.Count}
+	}
+	s.mx.Unlock()
+	return nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*Artist, error) {
+	return s.storage.Get(ctx, id)
+}
+
+// Find the artist by the name, the exact name first, then the most played artist containing the name
+func (s *Service) Find(ctx context.Context, name string) (*Artist, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil, ErrNotFound
+	}
+	var best *Summary
+	exact := false
+	s.mx.RLock()
+	for id := range s.summaries {
+		sum := s.summaries[id]
+		n := strings.ToLower(sum.Name)
+		if !strings.Contains(n, name) {
+			continue
+		}
+		isExact := n == name
+		if best == nil || isExact && !exact || isExact == exact && sum.Plays > best.Plays {
+			best, exact = &sum, isExact
+		}
+	}
+	s.mx.RUnlock()
+	if best == nil {
+		return nil, ErrNotFound
+	}
+	return s.storage.Get(ctx, best.ID)
+}
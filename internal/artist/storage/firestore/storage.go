@@ -0,0 +1,84 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/artist"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	artistsCollection = "artists"
+	// batchSize the documents have up to 500 songs, the batch stays far below the limit of the request size
+	batchSize = 50
+)
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) Summaries(ctx context.Context) ([]artist.Summary, error) {
+	iter := s.client.Collection(artistsCollection).Select("id", "name", "plays", "count").Documents(ctx)
+	defer iter.Stop()
+	res := make([]artist.Summary, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s", artistsCollection)
+		}
+		var sum artist.Summary
+		if err := doc.DataTo(&sum); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, sum)
+	}
+	return res, nil
+}
+
+func (s *Storage) Get(ctx context.Context, id string) (*artist.Artist, error) {
+	doc, err := s.client.Collection(artistsCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, artist.ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get artist %s", id)
+	}
+	var a artist.Artist
+	if err := doc.DataTo(&a); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &a, nil
+}
+
+func (s *Storage) Set(ctx context.Context, artists []artist.Artist) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetArtists artists:%d", len(artists))
+	for start := 0; start < len(artists); start += batchSize {
+		end := start + batchSize
+		if end > len(artists) {
+			end = len(artists)
+		}
+		batch := s.client.Batch()
+		for i := start; i < end; i++ {
+			batch.Set(s.client.Collection(artistsCollection).Doc(artists[i].ID), &artists[i])
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return errors.Wrapf(err, "failed to set %d artists", end-start)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+)
+
+// entries godoc
+// @summary   Executed commands, the newest first
+// @produce   json
+// @param     guild    query     string  false  "Guild ID"
+// @param     user     query     string  false  "User ID"
+// @param     command  query     string  false  "Command with the default prefix"
+// @param     limit    query     int     false  "Number of entries, 10 by default, 100 at most"
+// @success   200      {array}   audit.Entry
+// @failure   400      {object}  Response  "Incorrect limit"
+// @failure   401      {object}  Response  "Wrong admin token"
+// @failure   500      {object}  Response  "Database error"
+// @security  AdminToken
+// @router    /admin/audit [get]
+func (h *Handler) entriesHandler(c *gin.Context) {
+	q := audit.Query{
+		GuildID: c.Query("guild"),
+		UserID:  c.Query("user"),
+		Command: c.Query("command"),
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, Response{Message: "limit must be a positive number"})
+			return
+		}
+		q.Limit = limit
+	}
+	entries, err := h.log.Entries(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+)
+
+type Log interface {
+	Entries(ctx context.Context, q audit.Query) ([]audit.Entry, error)
+}
+
+// Handler the super group must be protected by the admin token
+type Handler struct {
+	log   Log
+	super *gin.RouterGroup
+}
+
+func NewHandler(log Log, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		log:   log,
+		super: superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/audit")
+	group.GET("", h.entriesHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	// DefaultLimit of the entries returned by a query
+	DefaultLimit = 10
+	MaxLimit     = 100
+
+	pending = 256
+)
+
+// Entry of an executed command
+type Entry struct {
+	ID        string        `firestore:"-" json:"id"`
+	Command   string        `firestore:"command" json:"command"`
+	Args      string        `firestore:"args,omitempty" json:"args,omitempty"`
+	GuildID   string        `firestore:"guild" json:"guild_id"`
+	ChannelID string        `firestore:"channel" json:"channel_id"`
+	UserID    string        `firestore:"user" json:"user_id"`
+	Outcome   string        `firestore:"outcome" json:"outcome"`
+	Time      time.Time     `firestore:"time" json:"time"`
+	Elapsed   time.Duration `firestore:"elapsed" json:"elapsed"`
+}
+
+// Query empty fields match everything, the newest entries come first
+type Query struct {
+	GuildID string
+	UserID  string
+	Command string
+	Limit   int
+}
+
+type Storage interface {
+	AddEntry(ctx context.Context, e *Entry) error
+	Entries(ctx context.Context, q Query) ([]Entry, error)
+}
+
+// Log writes the entries in the background so the commands don't wait for the database
+type Log struct {
+	storage Storage
+	entries chan *Entry
+	logger  zap.Logger
+}
+
+func NewLog(ctx context.Context, storage Storage, logger zap.Logger) *Log {
+	l := &Log{
+		storage: storage,
+		entries: make(chan *Entry, pending),
+		logger:  logger,
+	}
+	supervisor.Go(ctx, logger, "audit", l.write)
+	return l
+}
+
+// Record is a command.Auditor, the entry is dropped if the database falls behind
+func (l *Log) Record(e command.Execution) {
+	entry := &Entry{
+		ID:        uuid.New().String(),
+		Command:   e.Command,
+		Args:      e.Args,
+		GuildID:   e.GuildID,
+		ChannelID: e.ChannelID,
+		UserID:    e.UserID,
+		Outcome:   e.Outcome,
+		Time:      e.Time,
+		Elapsed:   e.Elapsed,
+	}
+	select {
+	case l.entries <- entry:
+	default:
+		l.logger.Warnw("audit entry dropped",
+			"command", e.Command,
+			"guild", e.GuildID,
+			"user", e.UserID)
+	}
+}
+
+func (l *Log) Entries(ctx context.Context, q Query) ([]Entry, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	entries, err := l.storage.Entries(ctx, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "query audit entries")
+	}
+	return entries, nil
+}
+
+func (l *Log) write(ctx context.Context) {
+	for {
+		select {
+		case e := <-l.entries:
+			if err := l.storage.AddEntry(ctx, e); err != nil {
+				l.logger.Error(errors.Wrap(err, "add audit entry"))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
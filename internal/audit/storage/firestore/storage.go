@@ -0,0 +1,67 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const auditCollection = "audit"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) AddEntry(ctx context.Context, e *audit.Entry) error {
+	contexts.LoggerFromContext(ctx).Debugf("DB: AddEntry %s %s", e.GuildID, e.Command)
+	_, err := s.client.Collection(auditCollection).Doc(e.ID).Set(ctx, e)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", e.ID, auditCollection)
+	}
+	return nil
+}
+
+// Entries the filters combined with the order by time need composite indexes in Firestore
+func (s *Storage) Entries(ctx context.Context, q audit.Query) ([]audit.Entry, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: Entries guild:%s user:%s command:%s", q.GuildID, q.UserID, q.Command)
+	query := s.client.Collection(auditCollection).Query
+	if q.GuildID != "" {
+		query = query.Where("guild", "==", q.GuildID)
+	}
+	if q.UserID != "" {
+		query = query.Where("user", "==", q.UserID)
+	}
+	if q.Command != "" {
+		query = query.Where("command", "==", q.Command)
+	}
+	iter := query.OrderBy("time", firestore.Desc).Limit(q.Limit).Documents(ctx)
+	defer iter.Stop()
+	res := make([]audit.Entry, 0, q.Limit)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get entries from %s", auditCollection)
+		}
+		var e audit.Entry
+		if err := doc.DataTo(&e); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		e.ID = doc.Ref.ID
+		res = append(res, e)
+	}
+	return res, nil
+}
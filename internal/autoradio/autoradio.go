@@ -0,0 +1,116 @@
+package autoradio
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+type Player interface {
+	NowPlaying() *pkg.Song
+	SetRadio(ctx context.Context, b bool, guildID, channelID string) error
+	Disconnect()
+}
+
+type Voice interface {
+	Channel() (guildID, channelID string)
+}
+
+type Settings interface {
+	Get(guildID string) guild.Settings
+}
+
+// Watcher starts the radio in the radio channel of the guild settings when it fills and nothing plays,
+// and stops the radio it started when the channel empties
+type Watcher struct {
+	ctx      context.Context
+	player   Player
+	voice    Voice
+	settings Settings
+	handles  func(guildID string) bool
+	logger   zap.Logger
+
+	// mx the updates come concurrently
+	mx sync.Mutex
+	// started the guild the radio was started in, the player serves one guild at a time
+	started string
+}
+
+// NewWatcher handles only the guilds of this instance, handles is called with the guild of every voice update
+func NewWatcher(ctx context.Context, player Player, voice Voice, settings Settings, handles func(guildID string) bool, logger zap.Logger) *Watcher {
+	return &Watcher{
+		ctx:      ctx,
+		player:   player,
+		voice:    voice,
+		settings: settings,
+		handles:  handles,
+		logger:   logger,
+	}
+}
+
+// VoiceStateUpdate is registered as the discordgo handler, the state is already updated when it runs
+func (w *Watcher) VoiceStateUpdate(ds *discordgo.Session, vs *discordgo.VoiceStateUpdate) {
+	if vs.GuildID == "" || !w.handles(vs.GuildID) {
+		return
+	}
+	radio := w.settings.Get(vs.GuildID).Radio
+	if radio.Channel == "" {
+		return
+	}
+	n := listeners(ds, vs.GuildID, radio.Channel)
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	guildID, channelID := w.voice.Channel()
+	switch {
+	case n == 0 && w.started == vs.GuildID:
+		w.started = ""
+		if guildID != vs.GuildID || channelID != radio.Channel {
+			return
+		}
+		if err := w.player.SetRadio(w.ctx, false, "", ""); err != nil {
+			w.logger.Error(errors.Wrap(err, "stop radio"))
+		}
+		w.player.Disconnect()
+		actions.WithLabelValues("stopped").Inc()
+		w.logger.Infow("radio channel emptied", "guild", vs.GuildID)
+	// a radio disconnected by a member stays off until the channel empties
+	case n >= radio.MinListeners() && w.started == "" && guildID == "" && w.player.NowPlaying() == nil:
+		if err := w.player.SetRadio(w.ctx, true, vs.GuildID, radio.Channel); err != nil {
+			w.logger.Error(errors.Wrapf(err, "start radio in %s", vs.GuildID))
+			return
+		}
+		w.started = vs.GuildID
+		actions.WithLabelValues("started").Inc()
+		w.logger.Infow("radio channel filled", "guild", vs.GuildID, "listeners", n)
+	}
+}
+
+// listeners the members in the channel, the bots don't count
+func listeners(ds *discordgo.Session, guildID, channelID string) int {
+	g, err := ds.State.Guild(guildID)
+	if err != nil {
+		return 0
+	}
+	users := make([]string, 0)
+	ds.State.RLock()
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == channelID && vs.UserID != ds.State.User.ID {
+			users = append(users, vs.UserID)
+		}
+	}
+	ds.State.RUnlock()
+	n := 0
+	for _, userID := range users {
+		if m, err := ds.State.Member(guildID, userID); err == nil && m.User != nil && m.User.Bot {
+			continue
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,13 @@
+package autoradio
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var actions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "autoradio",
+	Name:      "actions_total",
+	Help:      "Radio started when the radio channel filled or stopped when it emptied, by action.",
+}, []string{"action"})
@@ -0,0 +1,140 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+)
+
+const (
+	arenaDefaultName = "Halva Arena"
+	podiumSize       = 3
+)
+
+var podiumMedals = []string{":first_place:", ":second_place:", ":third_place:"}
+
+// arenaHandler expects clock as minutes+increment and the duration in minutes
+func (s *Service) arenaHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) < 2 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	if s.config.Token == "" {
+		s.sendStringMessage(ds, m, messageArenaDisabled)
+		return
+	}
+	clock, increment, err := parseTimeControl(args[0])
+	if err != nil {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	duration, err := strconv.Atoi(args[1])
+	if err != nil {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	name := arenaDefaultName
+	if len(args) > 2 {
+		name = strings.Join(args[2:], " ")
+	}
+
+	t, err := s.client.CreateArena(s.ctx, s.config.Token, &lichess.ArenaRequest{
+		Name:      name,
+		Clock:     clock,
+		Increment: increment,
+		Duration:  duration,
+		TeamID:    s.config.TeamID,
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "create arena"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	err = s.storage.SetTournament(s.ctx, &firestore.Tournament{
+		ID:        t.ID,
+		Name:      t.FullName,
+		ChannelID: m.ChannelID,
+		Created:   time.Now(),
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "store arena"))
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageArenaCreated, t.FullName, t.URL()))
+}
+
+// checkArenas posts the podium of the tournaments created by the bot when they finish
+func (s *Service) checkArenas(session *discordgo.Session) {
+	active, err := s.storage.ActiveTournaments(s.ctx)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "get active arenas"))
+		return
+	}
+	for i := range active {
+		stored := &active[i]
+		t, err := s.client.GetTournament(s.ctx, stored.ID)
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "get arena"))
+			continue
+		}
+		if !t.IsFinished {
+			continue
+		}
+		results, err := s.client.TournamentResults(s.ctx, stored.ID, podiumSize)
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "get arena results"))
+			continue
+		}
+		s.sendComplexMessage(session, stored.ChannelID, s.podiumMessage(t, results))
+		stored.Finished = true
+		if err := s.storage.SetTournament(s.ctx, stored); err != nil {
+			s.logger.Error(errors.Wrap(err, "finish arena"))
+		}
+	}
+}
+
+func (s *Service) podiumMessage(t *lichess.Tournament, results []lichess.TournamentResult) *discordgo.MessageSend {
+	members := s.lichessMembers()
+	lines := make([]string, 0, len(results))
+	for i := range results {
+		r := &results[i]
+		medal := ""
+		if r.Rank >= 1 && r.Rank <= len(podiumMedals) {
+			medal = podiumMedals[r.Rank-1]
+		}
+		player := r.Username
+		if userID, ok := members[strings.ToLower(r.Username)]; ok {
+			player = fmt.Sprintf("<@%s> (%s)", userID, r.Username)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s — %d points", medal, player, r.Score))
+	}
+	return &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       t.FullName + " is over",
+				URL:         t.URL(),
+				Description: strings.Join(lines, "\n"),
+			},
+		},
+	}
+}
+
+// lichessMembers maps lowercase lichess usernames to discord user ids
+func (s *Service) lichessMembers() map[string]string {
+	links, err := s.storage.AllLinks(s.ctx)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "get all links"))
+		return nil
+	}
+	members := make(map[string]string, len(links))
+	for userID, l := range links {
+		members[strings.ToLower(l.Lichess)] = userID
+	}
+	return members
+}
@@ -0,0 +1,60 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/stats"
+)
+
+func (s *Service) postDigest(session *discordgo.Session) {
+	d, err := s.digest.Weekly(s.ctx)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "build weekly digest"))
+		return
+	}
+	if d.Empty() {
+		return
+	}
+	s.sendComplexMessage(session, s.config.DigestChannel, digestMessage(d))
+}
+
+func digestMessage(d *stats.Digest) *discordgo.MessageSend {
+	fields := make([]*discordgo.MessageEmbedField, 0, 3)
+	if len(d.Ratings) != 0 {
+		lines := make([]string, 0, len(d.Ratings))
+		for i := range d.Ratings {
+			r := &d.Ratings[i]
+			lines = append(lines, fmt.Sprintf("<@%s> %s %d → %d (%+d)", r.UserID, r.Perf, r.Old, r.New, r.Diff()))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Rating changes", Value: strings.Join(lines, "\n")})
+	}
+	if len(d.Games) != 0 {
+		lines := make([]string, 0, len(d.Games))
+		for i := range d.Games {
+			g := &d.Games[i]
+			lines = append(lines, fmt.Sprintf("<@%s> (%d) beat %s (%d) [game](%s)", g.UserID, g.Rating, g.Opponent, g.OpponentRating, g.URL))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Notable games", Value: strings.Join(lines, "\n")})
+	}
+	if len(d.Puzzles) != 0 {
+		lines := make([]string, 0, len(d.Puzzles))
+		for i := range d.Puzzles {
+			p := &d.Puzzles[i]
+			lines = append(lines, fmt.Sprintf("%d. <@%s> %d puzzles", i+1, p.UserID, p.Solved))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Puzzle leaderboard", Value: strings.Join(lines, "\n")})
+	}
+	return &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Weekly chess digest",
+				Description: fmt.Sprintf("%s - %s", d.From.Format("02.01"), d.To.Format("02.01.2006")),
+				Fields:      fields,
+			},
+		},
+	}
+}
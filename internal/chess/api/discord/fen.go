@@ -0,0 +1,31 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/fen"
+)
+
+func (s *Service) fenHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	position := strings.Join(args, " ")
+	if err := fen.Validate(position); err != nil {
+		s.sendStringMessage(ds, m, fmt.Sprintf(messageInvalidFEN, err.Error()))
+		return
+	}
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Analyse on lichess",
+				URL:         fen.AnalysisURL(position),
+				Description: "`" + position + "`",
+				Image: &discordgo.MessageEmbedImage{
+					URL: fen.ImageURL(position),
+				},
+			},
+		},
+	}
+	s.sendComplexMessage(ds, m.ChannelID, msg)
+}
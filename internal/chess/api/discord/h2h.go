@@ -0,0 +1,41 @@
+package discord
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+)
+
+func (s *Service) h2hHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	mentions := discord.ParseMentions(m.Content)
+	if len(mentions) != 2 {
+		s.sendStringMessage(ds, m, fmt.Sprintf(messageH2HUsage, s.prefix))
+		return
+	}
+	names := make([]string, 0, len(mentions))
+	for _, id := range mentions {
+		name, err := s.lichessName(id)
+		if err != nil {
+			if errors.Is(err, firestore.ErrNotFound) {
+				s.sendNotLinkedMessage(ds, m)
+				return
+			}
+			s.logger.Error(errors.Wrap(err, "get chess link"))
+			s.sendInternalErrorMessage(ds, m)
+			return
+		}
+		names = append(names, name)
+	}
+
+	h, err := s.stats.HeadToHead(s.ctx, names[0], names[1])
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "h2h %s vs %s", names[0], names[1]))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendH2HMessage(ds, m, h)
+}
@@ -0,0 +1,44 @@
+package discord
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/scheduler"
+)
+
+const (
+	// digestSpec mondays at noon
+	digestSpec     = "0 12 * * 1"
+	arenaCheckSpec = "@every 5m"
+	teamCheckSpec  = "@every 10m"
+)
+
+type Jobs interface {
+	Add(name, spec string, job scheduler.Job) error
+}
+
+// RegisterJobs the weekly digest, the podiums of the bot arenas and the team announcements
+func (s *Service) RegisterJobs(session *discordgo.Session, jobs Jobs) error {
+	if s.config.DigestChannel != "" {
+		err := jobs.Add("chess-digest", digestSpec, func(_ context.Context) error {
+			s.postDigest(session)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err := jobs.Add("chess-arenas", arenaCheckSpec, func(_ context.Context) error {
+		s.checkArenas(session)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return jobs.Add("chess-teams", teamCheckSpec, func(_ context.Context) error {
+		s.checkTeams(session)
+		return nil
+	})
+}
@@ -0,0 +1,56 @@
+package discord
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+)
+
+func (s *Service) linkHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) != 1 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	user, err := s.client.GetUser(s.ctx, args[0])
+	if err != nil {
+		if errors.Is(err, lichess.ErrUserNotFound) {
+			s.sendStringMessage(ds, m, messageLichessNotFound)
+			return
+		}
+		s.logger.Error(errors.Wrap(err, "get lichess user"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	err = s.storage.SetLink(s.ctx, m.Author.ID, &firestore.Link{
+		Lichess: user.Username,
+		Linked:  time.Now(),
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "set chess link"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendStringMessage(ds, m, messageLinked+" `"+user.Username+"`")
+}
+
+func (s *Service) unlinkHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if err := s.storage.DeleteLink(s.ctx, m.Author.ID); err != nil {
+		s.logger.Error(errors.Wrap(err, "delete chess link"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendStringMessage(ds, m, messageUnlinked)
+}
+
+// lichessName returns linked lichess username of the discord user
+func (s *Service) lichessName(userID string) (string, error) {
+	l, err := s.storage.GetLink(s.ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return l.Lichess, nil
+}
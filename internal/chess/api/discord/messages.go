@@ -0,0 +1,153 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+)
+
+const (
+	messageLinked            = ":white_check_mark: **Lichess account linked**"
+	messageUnlinked          = ":x: **Lichess account unlinked**"
+	messageLichessNotFound   = ":x: **Lichess account not found**"
+	messageNotLinked         = ":x: **Lichess account is not linked.** Use `%schess link <username>`"
+	messageH2HUsage          = ":x: **Mention two members:** `%schess h2h @a @b`"
+	messageAuthLink          = "Authorize HalvaBot to play on lichess on your behalf: %s"
+	messageAuthSent          = ":envelope: **Check your direct messages**"
+	messageLoggedOut         = ":x: **Lichess authorization revoked**"
+	messageNotAuthorized     = ":x: **Authorize the bot first:** `%schess auth`"
+	messageNoChallenges      = "**No incoming challenges**"
+	messageChallengeAccepted = ":white_check_mark: **Challenge accepted**"
+	messageChallengeDeclined = ":x: **Challenge declined**"
+	messageSeekCreated       = ":mag_right: **Seeking an opponent**"
+	messageSeekFinished      = "<@%s> **seek finished, check your games on lichess**"
+	messageSeekFailed        = ":x: **Seek failed**"
+	messageNotUCI            = ":x: **Write the move in UCI notation, e.g.** `e2e4`"
+	messageVotingClosed      = ":x: **Voting is closed**"
+	messageVoteCounted       = ":white_check_mark: **Vote counted**"
+	messageVoteChessDisabled = ":x: **Vote chess is not configured**"
+	messageVoteGameRunning   = ":x: **The server is already playing a game**"
+	messageNoVoteGame        = ":x: **The server is not playing**"
+	messageVoteGameStarted   = ":chess_pawn: **The server plays against lichess AI level %d as %s** https://lichess.org/%s"
+	messageVoteGameFinished  = ":checkered_flag: **Game over:** %s %s https://lichess.org/%s"
+	messageVoteYourMove      = ":ballot_box: **Our move!** Vote with `%schess vote <move>`, %.0f seconds left"
+	messageVoteMovePlayed    = ":chess_pawn: **Played** `%s` with %d votes"
+	messageVoteIllegalMove   = ":x: `%s` **is illegal**"
+	messageVoteNoMoves       = ":x: **No legal moves voted, voting again**"
+	messageVoteAbandoned     = ":checkered_flag: **Game over:** nobody voted %d times in a row, the server gave up https://lichess.org/%s"
+	messageInvalidFEN        = ":x: **Invalid FEN:** %s"
+	messageArenaDisabled     = ":x: **Arenas are not configured**"
+	messageArenaCreated      = ":trophy: **%s** %s"
+	messageRaceRunning       = ":x: **The race is already running in this channel**"
+	messageNoRace            = ":x: **There is no race in this channel**"
+	messageRaceStarted       = ":checkered_flag: **Puzzle race:** %d puzzles, %.0f minutes. Answer with `%schess solve <moves>`"
+	messageRacePuzzle        = "**Puzzle #%d** rating %d, %d moves to find %s"
+	messageRaceNoWinners     = "Nobody solved a puzzle"
+	messageNoTeam            = ":x: **No lichess team is tied to the server.** Use `%schess team <team id>`"
+	messageTeamNotFound      = ":x: **Lichess team not found**"
+	messageTeamNoPermission  = ":x: **Only server managers can change the team**"
+	messageTeamSet           = ":white_check_mark: **%s** %s is tied to the server, announcements go to this channel"
+	messageTeamRemoved       = ":x: **Lichess team untied**"
+	messageTeamRoleSet       = ":white_check_mark: **Linked team members get** <@&%s>"
+	messageTeamRoleRemoved   = ":x: **Team role disabled**"
+	messageTeamNewMember     = ":wave: **%s joined the team!** https://lichess.org/@/%s"
+	messageTeamArena         = ":trophy: **%s** starts <t:%d:f> %s"
+	messageUsage             = "`%[1]schess` open challenge\n" +
+		"`%[1]schess link <username>` link your lichess account\n" +
+		"`%[1]schess unlink` unlink your lichess account\n" +
+		"`%[1]schess h2h @a @b` head-to-head stats\n" +
+		"`%[1]schess rating [@member]` ratings and their history\n" +
+		"`%[1]schess auth` allow the bot to act on your behalf\n" +
+		"`%[1]schess logout` revoke the authorization\n" +
+		"`%[1]schess challenges` incoming challenges\n" +
+		"`%[1]schess accept <id>` accept the challenge\n" +
+		"`%[1]schess decline <id> [reason]` decline the challenge\n" +
+		"`%[1]schess seek <minutes+increment> [rated]` seek a game\n" +
+		"`%[1]schess vote start [white|black] [level]` play with the server against lichess AI\n" +
+		"`%[1]schess vote <move>` vote for the next move\n" +
+		"`%[1]schess vote stop` resign the server game\n" +
+		"`%[1]schess fen <FEN>` show the position\n" +
+		"`%[1]schess arena <minutes+increment> <duration> [name]` create an arena\n" +
+		"`%[1]schess race [puzzles] [minutes]` start a puzzle race\n" +
+		"`%[1]schess solve <moves>` answer the current race puzzle\n" +
+		"`%[1]schess team [<team id>|off]` lichess team of the server\n" +
+		"`%[1]schess team role <@role|off>` role of the linked team members"
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, m *discordgo.MessageCreate, msg string) {
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{Content: msg})
+}
+
+func (s *Service) sendInternalErrorMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, discord.MessageInternalError)
+}
+
+func (s *Service) sendUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageUsage, s.prefix))
+}
+
+func (s *Service) sendNotLinkedMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageNotLinked, s.prefix))
+}
+
+func (s *Service) sendH2HMessage(ds *discordgo.Session, m *discordgo.MessageCreate, h *firestore.HeadToHead) {
+	total := h.WinsA + h.WinsB + h.Draws
+	openings := make([]string, 0, len(h.Openings))
+	for _, o := range h.Openings {
+		openings = append(openings, fmt.Sprintf("%s (%d)", o.Name, o.Count))
+	}
+	openingsValue := strings.Join(openings, "\n")
+	if openingsValue == "" {
+		openingsValue = "-"
+	}
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       fmt.Sprintf("%s vs %s", h.PlayerA, h.PlayerB),
+				URL:         fmt.Sprintf("https://lichess.org/@/%s/search?players.b=%s", h.PlayerA, h.PlayerB),
+				Description: fmt.Sprintf("%d games", total),
+				Fields: []*discordgo.MessageEmbedField{
+					{
+						Name:   h.PlayerA,
+						Value:  fmt.Sprintf("%d wins", h.WinsA),
+						Inline: true,
+					},
+					{
+						Name:   "Draws",
+						Value:  fmt.Sprintf("%d", h.Draws),
+						Inline: true,
+					},
+					{
+						Name:   h.PlayerB,
+						Value:  fmt.Sprintf("%d wins", h.WinsB),
+						Inline: true,
+					},
+					{
+						Name:  "Common openings",
+						Value: openingsValue,
+					},
+				},
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: "Updated " + h.Updated.Format("02.01.2006 15:04"),
+				},
+			},
+		},
+	}
+	s.sendComplexMessage(ds, m.ChannelID, msg)
+}
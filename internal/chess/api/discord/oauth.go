@@ -0,0 +1,167 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/auth"
+)
+
+const seekTimeout = 10 * time.Minute
+
+func (s *Service) authHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	u, err := s.auth.AuthURL(s.ctx, m.Author.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "create lichess auth url"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	channel, err := ds.UserChannelCreate(m.Author.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "create dm channel"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendComplexMessage(ds, channel.ID, &discordgo.MessageSend{Content: fmt.Sprintf(messageAuthLink, u)})
+	s.sendStringMessage(ds, m, messageAuthSent)
+}
+
+func (s *Service) logoutHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if err := s.auth.Revoke(s.ctx, m.Author.ID); err != nil {
+		s.logger.Error(errors.Wrap(err, "revoke lichess token"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendStringMessage(ds, m, messageLoggedOut)
+}
+
+func (s *Service) challengesHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	token, ok := s.userToken(ds, m)
+	if !ok {
+		return
+	}
+	challenges, err := s.client.IncomingChallenges(s.ctx, token)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "list challenges"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	if len(challenges) == 0 {
+		s.sendStringMessage(ds, m, messageNoChallenges)
+		return
+	}
+	lines := make([]string, 0, len(challenges))
+	for i := range challenges {
+		c := &challenges[i]
+		rated := "casual"
+		if c.Rated {
+			rated = "rated"
+		}
+		lines = append(lines, fmt.Sprintf("`%s` %s (%d) %s %s", c.ID, c.Challenger.Name, c.Challenger.Rating, c.Speed, rated))
+	}
+	s.sendStringMessage(ds, m, strings.Join(lines, "\n"))
+}
+
+func (s *Service) acceptHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) != 1 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	token, ok := s.userToken(ds, m)
+	if !ok {
+		return
+	}
+	if err := s.client.AcceptChallenge(s.ctx, token, args[0]); err != nil {
+		s.logger.Error(errors.Wrap(err, "accept challenge"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendStringMessage(ds, m, messageChallengeAccepted+" https://lichess.org/"+args[0])
+}
+
+func (s *Service) declineHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	token, ok := s.userToken(ds, m)
+	if !ok {
+		return
+	}
+	reason := ""
+	if len(args) == 2 {
+		reason = args[1]
+	}
+	if err := s.client.DeclineChallenge(s.ctx, token, args[0], reason); err != nil {
+		s.logger.Error(errors.Wrap(err, "decline challenge"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendStringMessage(ds, m, messageChallengeDeclined)
+}
+
+// seekHandler expects time control as minutes+increment, e.g. 5+3
+func (s *Service) seekHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	minutes, increment, err := parseTimeControl(args[0])
+	if err != nil {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	rated := len(args) == 2 && args[1] == "rated"
+	token, ok := s.userToken(ds, m)
+	if !ok {
+		return
+	}
+	s.sendStringMessage(ds, m, messageSeekCreated)
+	go func() {
+		ctx, cancel := context.WithTimeout(s.ctx, seekTimeout)
+		defer cancel()
+		if err := s.client.Seek(ctx, token, minutes, increment, rated); err != nil {
+			s.logger.Error(errors.Wrap(err, "seek game"))
+			s.sendStringMessage(ds, m, messageSeekFailed)
+			return
+		}
+		s.sendStringMessage(ds, m, fmt.Sprintf(messageSeekFinished, m.Author.ID))
+	}()
+}
+
+// userToken sends the explanation to the channel if user has not authorized the bot
+func (s *Service) userToken(ds *discordgo.Session, m *discordgo.MessageCreate) (string, bool) {
+	token, err := s.auth.Token(s.ctx, m.Author.ID)
+	if err != nil {
+		if errors.Is(err, auth.ErrNotAuthorized) {
+			s.sendStringMessage(ds, m, fmt.Sprintf(messageNotAuthorized, s.prefix))
+			return "", false
+		}
+		s.logger.Error(errors.Wrap(err, "get lichess token"))
+		s.sendInternalErrorMessage(ds, m)
+		return "", false
+	}
+	return token, true
+}
+
+func parseTimeControl(s string) (int, int, error) {
+	parts := strings.Split(s, "+")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("time control should be minutes+increment")
+	}
+	minutes, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse minutes")
+	}
+	increment, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse increment")
+	}
+	return minutes, increment, nil
+}
@@ -0,0 +1,193 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/race"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+)
+
+const (
+	defaultRacePuzzles  = 5
+	maxRacePuzzles      = 20
+	defaultRaceDuration = 10 * time.Minute
+	// racePuzzleAttempts per puzzle of the race, lichess may return a puzzle twice
+	racePuzzleAttempts = 3
+)
+
+type raceEntry struct {
+	race *race.Race
+	done chan struct{}
+}
+
+// races one race per text channel
+type races struct {
+	mx      sync.Mutex
+	channel map[string]*raceEntry
+}
+
+// raceHandler expects optional number of puzzles and duration in minutes
+func (s *Service) raceHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	n, duration := defaultRacePuzzles, defaultRaceDuration
+	if len(args) > 0 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 1 || v > maxRacePuzzles {
+			s.sendUsageMessage(ds, m)
+			return
+		}
+		n = v
+	}
+	if len(args) > 1 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			s.sendUsageMessage(ds, m)
+			return
+		}
+		duration = time.Duration(v) * time.Minute
+	}
+
+	s.races.mx.Lock()
+	if _, ok := s.races.channel[m.ChannelID]; ok {
+		s.races.mx.Unlock()
+		s.sendStringMessage(ds, m, messageRaceRunning)
+		return
+	}
+	// reserve the channel while puzzles are loading
+	entry := &raceEntry{done: make(chan struct{})}
+	s.races.channel[m.ChannelID] = entry
+	s.races.mx.Unlock()
+
+	puzzles, err := s.racePuzzles(n)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "load race puzzles"))
+		s.sendInternalErrorMessage(ds, m)
+		s.races.mx.Lock()
+		delete(s.races.channel, m.ChannelID)
+		s.races.mx.Unlock()
+		return
+	}
+
+	r := race.NewRace(puzzles, duration)
+	s.races.mx.Lock()
+	entry.race = r
+	s.races.mx.Unlock()
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageRaceStarted, n, duration.Minutes(), s.prefix))
+	s.sendPuzzleMessage(ds, m.ChannelID, 1, r.Current())
+	go s.finishRace(ds, m.ChannelID, entry, len(puzzles))
+}
+
+// racePuzzles n different puzzles. They are fetched without the account token,
+// with it lichess keeps returning the same unplayed puzzle.
+func (s *Service) racePuzzles(n int) ([]*lichess.Puzzle, error) {
+	puzzles := make([]*lichess.Puzzle, 0, n)
+	seen := make(map[string]bool, n)
+	for attempt := 0; len(puzzles) < n; attempt++ {
+		if attempt == n*racePuzzleAttempts {
+			return nil, errors.Errorf("only %d different puzzles of %d", len(puzzles), n)
+		}
+		p, err := s.client.NextPuzzle(s.ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		if seen[p.ID] {
+			continue
+		}
+		seen[p.ID] = true
+		puzzles = append(puzzles, p)
+	}
+	return puzzles, nil
+}
+
+func (s *Service) solveHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	s.races.mx.Lock()
+	entry, ok := s.races.channel[m.ChannelID]
+	var r *race.Race
+	if ok {
+		r = entry.race
+	}
+	s.races.mx.Unlock()
+	if r == nil {
+		s.sendStringMessage(ds, m, messageNoRace)
+		return
+	}
+	correct, next := r.Answer(m.Author.ID, strings.Join(args, " "))
+	if !correct {
+		if next != nil {
+			_ = ds.MessageReactionAdd(m.ChannelID, m.ID, "❌")
+		}
+		return
+	}
+	_ = ds.MessageReactionAdd(m.ChannelID, m.ID, "✅")
+	if next == nil {
+		close(entry.done)
+		return
+	}
+	solved := 0
+	for _, sc := range r.Scores() {
+		solved += sc.Solved
+	}
+	s.sendPuzzleMessage(ds, m.ChannelID, solved+1, next)
+}
+
+func (s *Service) finishRace(ds *discordgo.Session, channelID string, entry *raceEntry, puzzles int) {
+	timer := time.NewTimer(time.Until(entry.race.Deadline))
+	select {
+	case <-timer.C:
+	case <-entry.done:
+		timer.Stop()
+	case <-s.ctx.Done():
+		timer.Stop()
+		return
+	}
+	s.races.mx.Lock()
+	delete(s.races.channel, channelID)
+	s.races.mx.Unlock()
+
+	scores := entry.race.Scores()
+	lines := make([]string, 0, len(scores))
+	result := make(map[string]int, len(scores))
+	for i, sc := range scores {
+		medal := fmt.Sprintf("%d.", i+1)
+		if i < len(podiumMedals) {
+			medal = podiumMedals[i]
+		}
+		lines = append(lines, fmt.Sprintf("%s <@%s> %d", medal, sc.UserID, sc.Solved))
+		result[sc.UserID] = sc.Solved
+	}
+	if len(lines) == 0 {
+		lines = append(lines, messageRaceNoWinners)
+	}
+	s.sendComplexMessage(ds, channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Puzzle race is over",
+				Description: strings.Join(lines, "\n"),
+			},
+		},
+	})
+
+	err := s.storage.AddRaceResult(s.ctx, &firestore.RaceResult{
+		ChannelID: channelID,
+		Started:   entry.race.Started,
+		Puzzles:   puzzles,
+		Scores:    result,
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "store race result"))
+	}
+}
+
+func (s *Service) sendPuzzleMessage(ds *discordgo.Session, channelID string, number int, p *lichess.Puzzle) {
+	if p == nil {
+		return
+	}
+	s.sendComplexMessage(ds, channelID, strmsg(fmt.Sprintf(messageRacePuzzle, number, p.Rating, len(p.PlayerMoves()), p.URL())))
+}
@@ -0,0 +1,83 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/chart"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/stats"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+)
+
+const ratingChartName = "rating.png"
+
+var ratingPerfs = []string{"Bullet", "Blitz", "Rapid", "Classical", "Correspondence"}
+
+// ratingHandler shows ratings of the author or of the mentioned member
+func (s *Service) ratingHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	userID := m.Author.ID
+	if mentions := discord.ParseMentions(m.Content); len(mentions) != 0 {
+		userID = mentions[0]
+	}
+	name, err := s.lichessName(userID)
+	if err != nil {
+		if errors.Is(err, firestore.ErrNotFound) {
+			s.sendNotLinkedMessage(ds, m)
+			return
+		}
+		s.logger.Error(errors.Wrap(err, "get chess link"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	r, err := s.ratings.Rating(s.ctx, name)
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "rating of %s", name))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, ratingMessage(r))
+}
+
+func ratingMessage(r *stats.Rating) *discordgo.MessageSend {
+	fields := make([]*discordgo.MessageEmbedField, 0, len(ratingPerfs))
+	for _, name := range ratingPerfs {
+		p, ok := r.User.Perfs[strings.ToLower(name)]
+		if !ok || p.Games == 0 {
+			continue
+		}
+		rating := fmt.Sprintf("%d", p.Rating)
+		if p.Prov {
+			rating += "?"
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   chart.Legend[name] + " " + name,
+			Value:  fmt.Sprintf("%s (%d games)", rating, p.Games),
+			Inline: true,
+		})
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:  r.User.Username,
+		URL:    "https://lichess.org/@/" + r.User.Username,
+		Fields: fields,
+	}
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+	if len(r.Chart) != 0 {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + ratingChartName}
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Last year, %d - %d", r.Bounds.Min, r.Bounds.Max),
+		}
+		msg.Files = []*discordgo.File{
+			{
+				Name:        ratingChartName,
+				ContentType: "image/png",
+				Reader:      bytes.NewReader(r.Chart),
+			},
+		}
+	}
+	return msg
+}
@@ -1,44 +1,197 @@
 package discord
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 
 	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
-	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
-	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/stats"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/vote"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
-const chess = "chess"
+const (
+	chess = "chess"
+
+	link   = "link"
+	unlink = "unlink"
+	h2h    = "h2h"
+	rating = "rating"
+
+	authorize  = "auth"
+	logout     = "logout"
+	challenges = "challenges"
+	accept     = "accept"
+	decline    = "decline"
+	seek       = "seek"
+	voting     = "vote"
+	position   = "fen"
+	arena      = "arena"
+	puzzleRace = "race"
+	solve      = "solve"
+	team       = "team"
+)
 
 type chessClient interface {
 	StartOpenGame() (*lichess.OpenGameResponse, error)
+	GetUser(ctx context.Context, username string) (*lichess.User, error)
+	IncomingChallenges(ctx context.Context, token string) ([]lichess.Challenge, error)
+	AcceptChallenge(ctx context.Context, token, id string) error
+	DeclineChallenge(ctx context.Context, token, id, reason string) error
+	Seek(ctx context.Context, token string, minutes, increment int, rated bool) error
+	ChallengeAI(ctx context.Context, token string, level int, color string) (*lichess.AIGame, error)
+	StreamBoardGame(ctx context.Context, token, id string) (<-chan lichess.BoardState, error)
+	MakeMove(ctx context.Context, token, id, move string) error
+	ResignGame(ctx context.Context, token, id string) error
+	AbortGame(ctx context.Context, token, id string) error
+	CreateArena(ctx context.Context, token string, r *lichess.ArenaRequest) (*lichess.Tournament, error)
+	GetTournament(ctx context.Context, id string) (*lichess.Tournament, error)
+	TournamentResults(ctx context.Context, id string, n int) ([]lichess.TournamentResult, error)
+	NextPuzzle(ctx context.Context, token string) (*lichess.Puzzle, error)
+	GetTeam(ctx context.Context, id string) (*lichess.Team, error)
+	NewTeamMembers(ctx context.Context, id string, since time.Time) ([]lichess.TeamMember, error)
+	UserTeams(ctx context.Context, username string) ([]lichess.Team, error)
+	TeamArenas(ctx context.Context, id string, max int) ([]lichess.TeamArena, error)
+}
+
+type chessAuth interface {
+	AuthURL(ctx context.Context, userID string) (string, error)
+	Token(ctx context.Context, userID string) (string, error)
+	Revoke(ctx context.Context, userID string) error
+}
+
+type chessStorage interface {
+	GetLink(ctx context.Context, userID string) (*firestore.Link, error)
+	SetLink(ctx context.Context, userID string, link *firestore.Link) error
+	DeleteLink(ctx context.Context, userID string) error
+	AllLinks(ctx context.Context) (map[string]firestore.Link, error)
+	SetTournament(ctx context.Context, t *firestore.Tournament) error
+	ActiveTournaments(ctx context.Context) ([]firestore.Tournament, error)
+	AddRaceResult(ctx context.Context, r *firestore.RaceResult) error
+	GetTeam(ctx context.Context, guildID string) (*firestore.Team, error)
+	SetTeam(ctx context.Context, t *firestore.Team) error
+	DeleteTeam(ctx context.Context, guildID string) error
+	AllTeams(ctx context.Context) ([]firestore.Team, error)
+}
+
+type chessStats interface {
+	HeadToHead(ctx context.Context, a, b string) (*firestore.HeadToHead, error)
+}
+
+type chessRatings interface {
+	Rating(ctx context.Context, username string) (*stats.Rating, error)
+}
+
+type chessDigest interface {
+	Weekly(ctx context.Context) (*stats.Digest, error)
+}
+
+type Config struct {
+	DigestChannel string `json:"digest_channel,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	RedirectURL   string `json:"redirect_url,omitempty"`
+	Token         string `json:"token,omitempty"`
+	VoteWindow    int    `json:"vote_window,omitempty"` // seconds
+	// VoteIdleWindows without a single vote in a row end the game
+	VoteIdleWindows int    `json:"vote_idle_windows,omitempty"`
+	TeamID          string `json:"team_id,omitempty"`
 }
 
 type Service struct {
-	ctx    contexts.Context
-	client chessClient
-	prefix string
-	logger zap.Logger
+	ctx     context.Context
+	client  chessClient
+	storage chessStorage
+	stats   chessStats
+	digest  chessDigest
+	ratings chessRatings
+	auth    chessAuth
+	votes   voteChess
+	races   races
+	prefix  string
+	config  Config
+	logger  zap.Logger
 }
 
-func NewCog(ctx contexts.Context, prefix string, client chessClient, logger zap.Logger) *Service {
+func NewCog(ctx context.Context, prefix string, client chessClient, storage chessStorage, stats chessStats, digest chessDigest, ratings chessRatings, auth chessAuth, config Config, logger zap.Logger) *Service {
 	s := Service{
-		ctx:    ctx,
-		prefix: prefix,
-		client: client,
-		logger: logger,
+		ctx:     ctx,
+		prefix:  prefix,
+		client:  client,
+		storage: storage,
+		stats:   stats,
+		digest:  digest,
+		ratings: ratings,
+		auth:    auth,
+		config:  config,
+		logger:  logger,
+		votes:   voteChess{ballot: vote.NewBallot()},
+		races:   races{channel: make(map[string]*raceEntry)},
 	}
 
 	return &s
 }
 
 func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
-	command.NewMessageCommand(s.prefix+chess, s.chessMessageHandler, debug).RegisterCommand(session, logger)
+	// the FEN is case-sensitive, the other subcommands lowercase the message themselves
+	command.NewMessageCommand(s.prefix+chess, s.chessMessageHandler, debug).InGroup(string(guild.Chess)).KeepCase().RegisterCommand(session, logger)
+	command.NewComponentCommand(voteButtonPrefix, s.voteButtonHandler).RegisterCommand(session, logger)
 }
 
 func (s *Service) chessMessageHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+chess))
+	if len(args) == 0 {
+		s.openGameHandler(session, m)
+		return
+	}
+	if !strings.EqualFold(args[0], position) && len(m.Content) <= command.MaxMessageLength {
+		m.Content = strings.ToLower(m.Content)
+		args = strings.Fields(strings.TrimPrefix(m.Content, s.prefix+chess))
+	}
+	switch strings.ToLower(args[0]) {
+	case link:
+		s.linkHandler(session, m, args[1:])
+	case unlink:
+		s.unlinkHandler(session, m)
+	case h2h:
+		s.h2hHandler(session, m)
+	case rating:
+		s.ratingHandler(session, m)
+	case authorize:
+		s.authHandler(session, m)
+	case logout:
+		s.logoutHandler(session, m)
+	case challenges:
+		s.challengesHandler(session, m)
+	case accept:
+		s.acceptHandler(session, m, args[1:])
+	case decline:
+		s.declineHandler(session, m, args[1:])
+	case seek:
+		s.seekHandler(session, m, args[1:])
+	case voting:
+		s.voteHandler(session, m, args[1:])
+	case position:
+		s.fenHandler(session, m, args[1:])
+	case arena:
+		s.arenaHandler(session, m, args[1:])
+	case puzzleRace:
+		s.raceHandler(session, m, args[1:])
+	case solve:
+		s.solveHandler(session, m, args[1:])
+	case team:
+		s.teamHandler(session, m, args[1:])
+	default:
+		s.sendUsageMessage(session, m)
+	}
+}
+
+func (s *Service) openGameHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
 	game, err := s.client.StartOpenGame()
 	if err != nil {
 		s.sendInternalErrorMessage(session, m)
@@ -46,15 +199,3 @@ func (s *Service) chessMessageHandler(session *discordgo.Session, m *discordgo.M
 	}
 	go session.ChannelMessageSend(m.ChannelID, game.Challenge.URL)
 }
-
-func (s *Service) sendInternalErrorMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
-	go func() {
-		_, err := ds.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Content: discord.MessageInternalError})
-		if err != nil {
-			s.logger.Errorw("sending message",
-				"channel", m.ChannelID,
-				"msg", discord.MessageInternalError,
-				"err", err)
-		}
-	}()
-}
@@ -0,0 +1,315 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+)
+
+const (
+	teamArenasLimit = 10
+	// guildMembersPage the most members discord returns at once
+	guildMembersPage = 1000
+
+	teamRole = "role"
+	teamOff  = "off"
+)
+
+// teamHandler shows the team of the guild, ties the team or sets the role of its members
+func (s *Service) teamHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.showTeam(ds, m)
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageTeamNoPermission)
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case teamOff:
+		if err := s.storage.DeleteTeam(s.ctx, m.GuildID); err != nil {
+			s.logger.Error(errors.Wrap(err, "delete chess team"))
+			s.sendInternalErrorMessage(ds, m)
+			return
+		}
+		s.sendStringMessage(ds, m, messageTeamRemoved)
+	case teamRole:
+		s.teamRoleHandler(ds, m, args[1:])
+	default:
+		s.setTeam(ds, m, args[0])
+	}
+}
+
+func (s *Service) showTeam(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	stored, err := s.storage.GetTeam(s.ctx, m.GuildID)
+	if err != nil {
+		if errors.Is(err, firestore.ErrNotFound) {
+			s.sendStringMessage(ds, m, fmt.Sprintf(messageNoTeam, s.prefix))
+			return
+		}
+		s.logger.Error(errors.Wrap(err, "get chess team"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	info, err := s.client.GetTeam(s.ctx, stored.TeamID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "get lichess team"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	role := "-"
+	if stored.RoleID != "" {
+		role = "<@&" + stored.RoleID + ">"
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title: info.Name,
+				URL:   info.URL(),
+				Fields: []*discordgo.MessageEmbedField{
+					{Name: "Members", Value: fmt.Sprintf("%d", info.NbMembers), Inline: true},
+					{Name: "Announcements", Value: "<#" + stored.ChannelID + ">", Inline: true},
+					{Name: "Role", Value: role, Inline: true},
+				},
+			},
+		},
+	})
+}
+
+// setTeam announcements go to the channel of the command
+func (s *Service) setTeam(ds *discordgo.Session, m *discordgo.MessageCreate, teamID string) {
+	info, err := s.client.GetTeam(s.ctx, teamID)
+	if err != nil {
+		if errors.Is(err, lichess.ErrTeamNotFound) {
+			s.sendStringMessage(ds, m, messageTeamNotFound)
+			return
+		}
+		s.logger.Error(errors.Wrap(err, "get lichess team"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	stored := &firestore.Team{
+		GuildID:    m.GuildID,
+		TeamID:     info.ID,
+		ChannelID:  m.ChannelID,
+		LastJoined: time.Now(),
+	}
+	if old, err := s.storage.GetTeam(s.ctx, m.GuildID); err == nil {
+		stored.RoleID = old.RoleID
+	}
+	if err := s.storage.SetTeam(s.ctx, stored); err != nil {
+		s.logger.Error(errors.Wrap(err, "set chess team"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageTeamSet, info.Name, info.URL()))
+}
+
+func (s *Service) teamRoleHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) != 1 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	stored, err := s.storage.GetTeam(s.ctx, m.GuildID)
+	if err != nil {
+		if errors.Is(err, firestore.ErrNotFound) {
+			s.sendStringMessage(ds, m, fmt.Sprintf(messageNoTeam, s.prefix))
+			return
+		}
+		s.logger.Error(errors.Wrap(err, "get chess team"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	switch {
+	case strings.EqualFold(args[0], teamOff):
+		stored.RoleID = ""
+	case len(m.MentionRoles) == 1:
+		stored.RoleID = m.MentionRoles[0]
+	default:
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	if err := s.storage.SetTeam(s.ctx, stored); err != nil {
+		s.logger.Error(errors.Wrap(err, "set chess team"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	if stored.RoleID == "" {
+		s.sendStringMessage(ds, m, messageTeamRoleRemoved)
+		return
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageTeamRoleSet, stored.RoleID))
+}
+
+// teamRoles what the role sync of one check looked up, shared by the teams.
+// Only the linked users are looked up on lichess, and the members of a guild are fetched once.
+type teamRoles struct {
+	links map[string]firestore.Link
+	// guilds the roles of the members by the user id
+	guilds map[string]map[string][]string
+	// userTeams the team ids by the lichess username
+	userTeams map[string]map[string]bool
+}
+
+// checkTeams announces new members and arenas of the tied teams and keeps the team roles in sync
+func (s *Service) checkTeams(session *discordgo.Session) {
+	teams, err := s.storage.AllTeams(s.ctx)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "get chess teams"))
+		return
+	}
+	roles := &teamRoles{
+		guilds:    make(map[string]map[string][]string),
+		userTeams: make(map[string]map[string]bool),
+	}
+	for i := range teams {
+		t := &teams[i]
+		members, err := s.client.NewTeamMembers(s.ctx, t.TeamID, t.LastJoined)
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "get team members"))
+			continue
+		}
+		s.announceMembers(session, t, members)
+		s.announceArenas(session, t)
+		if err := s.storage.SetTeam(s.ctx, t); err != nil {
+			s.logger.Error(errors.Wrap(err, "set chess team"))
+		}
+		if t.RoleID != "" {
+			s.syncTeamRole(session, t, roles)
+		}
+	}
+}
+
+func (s *Service) announceMembers(session *discordgo.Session, t *firestore.Team, members []lichess.TeamMember) {
+	last := t.LastJoined
+	for i := range members {
+		joined := members[i].Joined()
+		if !joined.After(t.LastJoined) {
+			continue
+		}
+		s.sendComplexMessage(session, t.ChannelID, &discordgo.MessageSend{
+			Content: fmt.Sprintf(messageTeamNewMember, members[i].Username, members[i].ID),
+		})
+		if joined.After(last) {
+			last = joined
+		}
+	}
+	t.LastJoined = last
+}
+
+func (s *Service) announceArenas(session *discordgo.Session, t *firestore.Team) {
+	arenas, err := s.client.TeamArenas(s.ctx, t.TeamID, teamArenasLimit)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "get team arenas"))
+		return
+	}
+	announced := make(map[string]bool, len(t.Arenas))
+	for _, id := range t.Arenas {
+		announced[id] = true
+	}
+	// only the fetched arenas are kept so the list doesn't grow
+	ids := make([]string, 0, len(arenas))
+	for i := range arenas {
+		a := &arenas[i]
+		if !announced[a.ID] && !a.Finished() {
+			s.sendComplexMessage(session, t.ChannelID, &discordgo.MessageSend{
+				Content: fmt.Sprintf(messageTeamArena, a.FullName, a.Starts().Unix(), a.URL()),
+			})
+			announced[a.ID] = true
+		}
+		if announced[a.ID] {
+			ids = append(ids, a.ID)
+		}
+	}
+	t.Arenas = ids
+}
+
+// syncTeamRole gives the role to the linked team members and takes it from the rest
+func (s *Service) syncTeamRole(session *discordgo.Session, t *firestore.Team, roles *teamRoles) {
+	if roles.links == nil {
+		links, err := s.storage.AllLinks(s.ctx)
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "get all links"))
+			return
+		}
+		roles.links = links
+	}
+	members, ok := roles.guilds[t.GuildID]
+	if !ok {
+		var err error
+		if members, err = guildMemberRoles(session, t.GuildID); err != nil {
+			s.logger.Error(errors.Wrap(err, "get guild members"))
+			return
+		}
+		roles.guilds[t.GuildID] = members
+	}
+	for userID, l := range roles.links {
+		memberRoles, ok := members[userID]
+		if !ok {
+			// not in the guild
+			continue
+		}
+		teams, ok := roles.userTeams[strings.ToLower(l.Lichess)]
+		if !ok {
+			userTeams, err := s.client.UserTeams(s.ctx, l.Lichess)
+			if err != nil {
+				s.logger.Error(errors.Wrap(err, "get lichess user teams"))
+				continue
+			}
+			teams = make(map[string]bool, len(userTeams))
+			for i := range userTeams {
+				teams[userTeams[i].ID] = true
+			}
+			roles.userTeams[strings.ToLower(l.Lichess)] = teams
+		}
+		hasRole := false
+		for _, r := range memberRoles {
+			if r == t.RoleID {
+				hasRole = true
+				break
+			}
+		}
+		var err error
+		shouldHave := teams[t.TeamID]
+		switch {
+		case shouldHave && !hasRole:
+			err = session.GuildMemberRoleAdd(t.GuildID, userID, t.RoleID)
+		case !shouldHave && hasRole:
+			err = session.GuildMemberRoleRemove(t.GuildID, userID, t.RoleID)
+		}
+		if err != nil {
+			s.logger.Errorw("sync team role",
+				"guild", t.GuildID,
+				"user", userID,
+				"err", err)
+		}
+	}
+}
+
+// guildMemberRoles the roles of all members of the guild by the user id, a page of members per request
+func guildMemberRoles(session *discordgo.Session, guildID string) (map[string][]string, error) {
+	res := make(map[string][]string)
+	after := ""
+	for {
+		members, err := session.GuildMembers(guildID, after, guildMembersPage)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if m.User != nil {
+				res[m.User.ID] = m.Roles
+				after = m.User.ID
+			}
+		}
+		if len(members) < guildMembersPage {
+			return res, nil
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/vote"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	voteStart         = "start"
+	voteStop          = "stop"
+	voteButtonPrefix  = "chess_vote:"
+	defaultVoteWindow = time.Minute
+	// defaultVoteIdleWindows without votes end an abandoned game
+	defaultVoteIdleWindows = 3
+	defaultAILevel         = 3
+	maxVoteButtons         = 25
+)
+
+type voteGame struct {
+	id        string
+	white     bool
+	channelID string
+	messageID string
+	cancel    func()
+}
+
+// voteChess the server plays one game against lichess AI at a time
+type voteChess struct {
+	mx     sync.Mutex
+	game   *voteGame
+	ballot *vote.Ballot
+}
+
+func (s *Service) voteHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case voteStart:
+		s.voteStartHandler(ds, m, args[1:])
+	case voteStop:
+		s.voteStopHandler(ds, m)
+	default:
+		if !vote.IsUCI(strings.ToLower(args[0])) {
+			s.sendStringMessage(ds, m, messageNotUCI)
+			return
+		}
+		if !s.votes.ballot.Vote(m.Author.ID, args[0]) {
+			s.sendStringMessage(ds, m, messageVotingClosed)
+			return
+		}
+		s.updateVoteMessage(ds)
+	}
+}
+
+func (s *Service) voteStartHandler(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	if s.config.Token == "" {
+		s.sendStringMessage(ds, m, messageVoteChessDisabled)
+		return
+	}
+	color, level := "white", defaultAILevel
+	for _, a := range args {
+		a = strings.ToLower(a)
+		if a == "white" || a == "black" {
+			color = a
+		} else if l, err := strconv.Atoi(a); err == nil && l >= 1 && l <= 8 {
+			level = l
+		}
+	}
+
+	s.votes.mx.Lock()
+	defer s.votes.mx.Unlock()
+	if s.votes.game != nil {
+		s.sendStringMessage(ds, m, messageVoteGameRunning)
+		return
+	}
+	game, err := s.client.ChallengeAI(s.ctx, s.config.Token, level, color)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "challenge lichess ai"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	states, err := s.client.StreamBoardGame(ctx, s.config.Token, game.ID)
+	if err != nil {
+		cancel()
+		s.logger.Error(errors.Wrap(err, "stream vote chess game"))
+		s.sendInternalErrorMessage(ds, m)
+		return
+	}
+	s.votes.game = &voteGame{
+		id:        game.ID,
+		white:     color == "white",
+		channelID: m.ChannelID,
+		cancel:    cancel,
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageVoteGameStarted, level, color, game.ID))
+	go s.runVoteGame(ctx, ds, s.votes.game, states)
+}
+
+func (s *Service) voteStopHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.votes.mx.Lock()
+	game := s.votes.game
+	s.votes.mx.Unlock()
+	if game == nil {
+		s.sendStringMessage(ds, m, messageNoVoteGame)
+		return
+	}
+	if err := s.client.ResignGame(s.ctx, s.config.Token, game.id); err != nil {
+		s.logger.Error(errors.Wrap(err, "resign vote chess game"))
+		s.sendInternalErrorMessage(ds, m)
+	}
+}
+
+func (s *Service) runVoteGame(ctx context.Context, ds *discordgo.Session, game *voteGame, states <-chan lichess.BoardState) {
+	defer func() {
+		game.cancel()
+		s.votes.ballot.Close()
+		s.votes.mx.Lock()
+		s.votes.game = nil
+		s.votes.mx.Unlock()
+	}()
+
+	for state := range states {
+		if state.Finished() {
+			s.sendComplexMessage(ds, game.channelID, strmsg(fmt.Sprintf(messageVoteGameFinished, state.Status, state.Winner, game.id)))
+			return
+		}
+		if state.WhiteToMove() != game.white {
+			continue
+		}
+		if !s.collectVotes(ctx, ds, game) {
+			return
+		}
+	}
+	s.sendComplexMessage(ds, game.channelID, strmsg(fmt.Sprintf(messageVoteGameFinished, "aborted", "", game.id)))
+}
+
+// collectVotes opens voting windows until one of the voted moves is accepted by lichess.
+// The game is given up after voteIdleWindows without votes in a row.
+func (s *Service) collectVotes(ctx context.Context, ds *discordgo.Session, game *voteGame) bool {
+	idle := 0
+	for {
+		s.votes.ballot.Open()
+		msg, err := ds.ChannelMessageSendComplex(game.channelID, strmsg(fmt.Sprintf(messageVoteYourMove, s.prefix, s.voteWindow().Seconds())))
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "send vote message"))
+		} else {
+			s.votes.mx.Lock()
+			game.messageID = msg.ID
+			s.votes.mx.Unlock()
+		}
+
+		timer := time.NewTimer(s.voteWindow())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		s.votes.ballot.Close()
+
+		results := s.votes.ballot.Results()
+		if len(results) == 0 {
+			idle++
+			if idle >= s.voteIdleWindows() {
+				s.abandonVoteGame(ctx, ds, game, idle)
+				return false
+			}
+		} else {
+			idle = 0
+		}
+		for _, c := range results {
+			err := s.client.MakeMove(ctx, s.config.Token, game.id, c.Move)
+			if err == nil {
+				s.sendComplexMessage(ds, game.channelID, strmsg(fmt.Sprintf(messageVoteMovePlayed, c.Move, c.Votes)))
+				return true
+			}
+			if !errors.Is(err, lichess.ErrIllegalMove) {
+				s.logger.Error(errors.Wrap(err, "make voted move"))
+				return false
+			}
+			s.sendComplexMessage(ds, game.channelID, strmsg(fmt.Sprintf(messageVoteIllegalMove, c.Move)))
+		}
+		s.sendComplexMessage(ds, game.channelID, strmsg(messageVoteNoMoves))
+	}
+}
+
+// abandonVoteGame resigns the game nobody plays, a game too short to resign is aborted
+func (s *Service) abandonVoteGame(ctx context.Context, ds *discordgo.Session, game *voteGame, idle int) {
+	if err := s.client.ResignGame(ctx, s.config.Token, game.id); err != nil {
+		if err := s.client.AbortGame(ctx, s.config.Token, game.id); err != nil {
+			s.logger.Error(errors.Wrap(err, "give up vote chess game"))
+		}
+	}
+	s.sendComplexMessage(ds, game.channelID, strmsg(fmt.Sprintf(messageVoteAbandoned, idle, game.id)))
+}
+
+func (s *Service) voteButtonHandler(ds *discordgo.Session, i *discordgo.InteractionCreate, move string) {
+	user := command.InteractionUser(i)
+	msg := messageVoteCounted
+	if user == nil || !s.votes.ballot.Vote(user.ID, move) {
+		msg = messageVotingClosed
+	}
+	if err := command.RespondEphemeral(ds, i, msg); err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to vote"))
+	}
+	s.updateVoteMessage(ds)
+}
+
+// updateVoteMessage shows the candidates as buttons so others can join the vote with one click
+func (s *Service) updateVoteMessage(ds *discordgo.Session) {
+	s.votes.mx.Lock()
+	game := s.votes.game
+	var channelID, messageID string
+	if game != nil {
+		channelID, messageID = game.channelID, game.messageID
+	}
+	s.votes.mx.Unlock()
+	if messageID == "" {
+		return
+	}
+
+	results := s.votes.ballot.Results()
+	if len(results) > maxVoteButtons {
+		results = results[:maxVoteButtons]
+	}
+	buttons := make([]discordgo.Button, 0, len(results))
+	for _, c := range results {
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("%s (%d)", c.Move, c.Votes),
+			Style:    discordgo.PrimaryButton,
+			CustomID: voteButtonPrefix + c.Move,
+		})
+	}
+	edit := discordgo.NewMessageEdit(channelID, messageID)
+	edit.Components = command.ButtonRows(buttons)
+	if _, err := ds.ChannelMessageEditComplex(edit); err != nil {
+		s.logger.Error(errors.Wrap(err, "update vote message"))
+	}
+}
+
+func (s *Service) voteWindow() time.Duration {
+	if s.config.VoteWindow > 0 {
+		return time.Duration(s.config.VoteWindow) * time.Second
+	}
+	return defaultVoteWindow
+}
+
+func (s *Service) voteIdleWindows() int {
+	if s.config.VoteIdleWindows > 0 {
+		return s.config.VoteIdleWindows
+	}
+	return defaultVoteIdleWindows
+}
+
+func strmsg(msg string) *discordgo.MessageSend {
+	return &discordgo.MessageSend{Content: msg}
+}
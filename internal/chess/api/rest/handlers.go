@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/auth"
+)
+
+// oauthCallback godoc
+// @summary  Lichess redirects here after the user granted access to the bot
+// @produce  plain
+// @param    code   query     string  true  "Authorization code"
+// @param    state  query     string  true  "State of the flow started in discord"
+// @success  200    string    string
+// @failure  400    string    string  "Unknown or expired state, or another lichess account than the linked one"
+// @failure  400    string    string  "No code or state"
+// @failure  500    string    string  "Token exchange failed, the details are only logged"
+// @router   /chess/oauth/callback [get]
+func (h *Handler) oauthCallbackHandler(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		c.String(http.StatusBadRequest, "Lichess authorization failed: "+e)
+		return
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.String(http.StatusBadRequest, "No code or state, start the authorization in discord")
+		return
+	}
+	_, err := h.auth.Complete(c.Request.Context(), state, code)
+	if err != nil {
+		if errors.Is(err, auth.ErrUnknownState) {
+			c.String(http.StatusBadRequest, "Authorization link expired, request a new one in discord")
+			return
+		}
+		if errors.Is(err, auth.ErrOtherAccount) {
+			c.String(http.StatusBadRequest, "The lichess account differs from the one linked in discord, log in with the linked one")
+			return
+		}
+		// the callback is public, the errors of lichess and the storage stay in the log
+		h.logger.Error(errors.Wrap(err, "complete lichess oauth"))
+		c.String(http.StatusInternalServerError, "Lichess authorization failed, try again later")
+		return
+	}
+	c.String(http.StatusOK, "Lichess account authorized, you can close this page")
+}
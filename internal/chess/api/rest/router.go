@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+type Auth interface {
+	Complete(ctx context.Context, state, code string) (string, error)
+}
+
+type Handler struct {
+	auth   Auth
+	super  *gin.RouterGroup
+	logger zap.Logger
+}
+
+func NewHandler(auth Auth, superGroup *gin.RouterGroup, logger zap.Logger) *Handler {
+	return &Handler{
+		auth:   auth,
+		super:  superGroup,
+		logger: logger,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	chess := h.super.Group("/chess")
+	chess.GET("/oauth/callback", h.oauthCallbackHandler)
+	return chess
+}
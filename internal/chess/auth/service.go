@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const stateExpiration = 10 * time.Minute
+
+var (
+	ErrUnknownState  = errors.New("unknown or expired oauth state")
+	ErrNotAuthorized = errors.New("user has not authorized the bot on lichess")
+	// ErrOtherAccount the token was granted by another lichess account than the linked one
+	ErrOtherAccount = errors.New("lichess account differs from the linked one")
+)
+
+type OAuth interface {
+	AuthCodeURL(state, verifier string) string
+	Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error)
+}
+
+type Client interface {
+	Account(ctx context.Context, token string) (*lichess.User, error)
+	RevokeToken(ctx context.Context, token string) error
+}
+
+type Storage interface {
+	GetToken(ctx context.Context, userID string) (*firestore.Token, error)
+	SetToken(ctx context.Context, userID string, t *firestore.Token) error
+	DeleteToken(ctx context.Context, userID string) error
+	GetLink(ctx context.Context, userID string) (*firestore.Link, error)
+	SetLink(ctx context.Context, userID string, link *firestore.Link) error
+	SetOAuthState(ctx context.Context, state string, st *firestore.OAuthState) error
+	TakeOAuthState(ctx context.Context, state string) (*firestore.OAuthState, error)
+	DeleteOAuthStates(ctx context.Context, before time.Time) (int, error)
+}
+
+// Service keeps lichess OAuth tokens of discord users.
+// The pending flows are stored, so lichess may redirect to any instance.
+type Service struct {
+	oauth   OAuth
+	client  Client
+	storage Storage
+}
+
+func NewService(oauth OAuth, client Client, storage Storage) *Service {
+	return &Service{
+		oauth:   oauth,
+		client:  client,
+		storage: storage,
+	}
+}
+
+// AuthURL starts the OAuth flow for the discord user
+func (s *Service) AuthURL(ctx context.Context, userID string) (string, error) {
+	state, err := lichess.RandomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := lichess.RandomString()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if _, err := s.storage.DeleteOAuthStates(ctx, now.Add(-stateExpiration)); err != nil {
+		contexts.LoggerFromContext(ctx).Error(errors.Wrap(err, "delete expired oauth states"))
+	}
+	err = s.storage.SetOAuthState(ctx, state, &firestore.OAuthState{
+		UserID:   userID,
+		Verifier: verifier,
+		Created:  now,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "store oauth state")
+	}
+	return s.oauth.AuthCodeURL(state, verifier), nil
+}
+
+// Complete exchanges the code from the lichess redirect and returns the discord user id.
+// The account which granted the token must be the linked one, it is linked if the user has none.
+func (s *Service) Complete(ctx context.Context, state, code string) (string, error) {
+	if state == "" {
+		return "", ErrUnknownState
+	}
+	p, err := s.storage.TakeOAuthState(ctx, state)
+	if err != nil {
+		if errors.Is(err, firestore.ErrNotFound) {
+			return "", ErrUnknownState
+		}
+		return "", err
+	}
+	if time.Since(p.Created) > stateExpiration {
+		return "", ErrUnknownState
+	}
+
+	token, err := s.oauth.Exchange(ctx, code, p.Verifier)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkAccount(ctx, p.UserID, token.AccessToken); err != nil {
+		if rerr := s.client.RevokeToken(ctx, token.AccessToken); rerr != nil {
+			contexts.LoggerFromContext(ctx).Error(errors.Wrap(rerr, "revoke lichess token"))
+		}
+		return "", err
+	}
+	err = s.storage.SetToken(ctx, p.UserID, &firestore.Token{
+		AccessToken: token.AccessToken,
+		Expiry:      token.Expiry,
+		Scopes:      lichess.Scopes,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "store token")
+	}
+	return p.UserID, nil
+}
+
+// checkAccount of the token against the chess link of the user, links it if there is none
+func (s *Service) checkAccount(ctx context.Context, userID, token string) error {
+	account, err := s.client.Account(ctx, token)
+	if err != nil {
+		return errors.Wrap(err, "get lichess account")
+	}
+	link, err := s.storage.GetLink(ctx, userID)
+	switch {
+	case errors.Is(err, firestore.ErrNotFound):
+		err = s.storage.SetLink(ctx, userID, &firestore.Link{
+			Lichess: account.Username,
+			Linked:  time.Now(),
+		})
+		return errors.Wrap(err, "set chess link")
+	case err != nil:
+		return errors.Wrap(err, "get chess link")
+	case !strings.EqualFold(link.Lichess, account.Username):
+		return ErrOtherAccount
+	}
+	return nil
+}
+
+// Token returns valid access token of the discord user
+func (s *Service) Token(ctx context.Context, userID string) (string, error) {
+	t, err := s.storage.GetToken(ctx, userID)
+	if err != nil {
+		if errors.Is(err, firestore.ErrNotFound) {
+			return "", ErrNotAuthorized
+		}
+		return "", err
+	}
+	if !t.Expiry.IsZero() && time.Now().After(t.Expiry) {
+		return "", ErrNotAuthorized
+	}
+	return t.AccessToken, nil
+}
+
+// Revoke forgets and revokes the token of the discord user
+func (s *Service) Revoke(ctx context.Context, userID string) error {
+	token, err := s.Token(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotAuthorized) {
+			return nil
+		}
+		return err
+	}
+	if err := s.client.RevokeToken(ctx, token); err != nil {
+		contexts.LoggerFromContext(ctx).Error(errors.Wrap(err, "revoke lichess token"))
+	}
+	return s.storage.DeleteToken(ctx, userID)
+}
@@ -0,0 +1,186 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+)
+
+const (
+	width   = 800
+	height  = 400
+	padding = 20
+	// horizontal grid line every gridStep rating points
+	gridStep = 100
+)
+
+var (
+	background = color.RGBA{R: 0x2f, G: 0x31, B: 0x36, A: 0xff}
+	gridColor  = color.RGBA{R: 0x4f, G: 0x54, B: 0x5c, A: 0xff}
+
+	// Colors of the time controls, must match Legend
+	Colors = map[string]color.RGBA{
+		"Bullet":         {R: 0xe7, G: 0x4c, B: 0x3c, A: 0xff},
+		"Blitz":          {R: 0x34, G: 0x98, B: 0xdb, A: 0xff},
+		"Rapid":          {R: 0x2e, G: 0xcc, B: 0x71, A: 0xff},
+		"Classical":      {R: 0xe6, G: 0x7e, B: 0x22, A: 0xff},
+		"Correspondence": {R: 0x9b, G: 0x59, B: 0xb6, A: 0xff},
+	}
+	Legend = map[string]string{
+		"Bullet":         "🟥",
+		"Blitz":          "🟦",
+		"Rapid":          "🟩",
+		"Classical":      "🟧",
+		"Correspondence": "🟪",
+	}
+)
+
+var ErrNoData = errors.New("no rating history in the period")
+
+// Bounds of the plotted ratings
+type Bounds struct {
+	Min int
+	Max int
+}
+
+// Rating draws the lines of the time controls listed in Colors since the date as PNG
+func Rating(history []lichess.RatingHistory, since time.Time) ([]byte, Bounds, error) {
+	series := filter(history, since)
+	if len(series) == 0 {
+		return nil, Bounds{}, ErrNoData
+	}
+	b := bounds(series)
+	now := time.Now()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, background)
+	scaleY := func(rating int) int {
+		return height - padding - (rating-b.Min)*(height-2*padding)/(b.Max-b.Min)
+	}
+	scaleX := func(t time.Time) int {
+		return padding + int(float64(width-2*padding)*t.Sub(since).Seconds()/now.Sub(since).Seconds())
+	}
+	for r := b.Min; r <= b.Max; r += gridStep {
+		line(img, padding, scaleY(r), width-padding, scaleY(r), gridColor)
+	}
+	for name, points := range series {
+		c := Colors[name]
+		for i := 1; i < len(points); i++ {
+			thickLine(img,
+				scaleX(points[i-1].Date()), scaleY(points[i-1].Rating()),
+				scaleX(points[i].Date()), scaleY(points[i].Rating()), c)
+		}
+		// the current rating holds until today
+		last := points[len(points)-1]
+		thickLine(img, scaleX(last.Date()), scaleY(last.Rating()), width-padding, scaleY(last.Rating()), c)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, Bounds{}, errors.Wrap(err, "encode png")
+	}
+	return buf.Bytes(), b, nil
+}
+
+func filter(history []lichess.RatingHistory, since time.Time) map[string][]lichess.RatingPoint {
+	res := make(map[string][]lichess.RatingPoint)
+	for i := range history {
+		h := &history[i]
+		if _, ok := Colors[h.Name]; !ok {
+			continue
+		}
+		// the last rating before the period starts the line from the left border
+		var before *lichess.RatingPoint
+		points := make([]lichess.RatingPoint, 0, len(h.Points))
+		for j := range h.Points {
+			p := h.Points[j]
+			if p.Date().Before(since) {
+				before = &p
+				continue
+			}
+			points = append(points, p)
+		}
+		if before != nil {
+			points = append([]lichess.RatingPoint{{since.Year(), int(since.Month()) - 1, since.Day(), before.Rating()}}, points...)
+		}
+		if len(points) != 0 {
+			res[h.Name] = points
+		}
+	}
+	return res
+}
+
+// bounds rounded to the grid
+func bounds(series map[string][]lichess.RatingPoint) Bounds {
+	b := Bounds{Min: 1 << 30}
+	for _, points := range series {
+		for _, p := range points {
+			if p.Rating() < b.Min {
+				b.Min = p.Rating()
+			}
+			if p.Rating() > b.Max {
+				b.Max = p.Rating()
+			}
+		}
+	}
+	b.Min = b.Min / gridStep * gridStep
+	b.Max = (b.Max/gridStep + 1) * gridStep
+	return b
+}
+
+func fill(img *image.RGBA, c color.RGBA) {
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func thickLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			line(img, x0+dx, y0+dy, x1+dx, y1+dy, c)
+		}
+	}
+}
+
+// line Bresenham's algorithm
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, sx := abs(x1-x0), 1
+	if x0 > x1 {
+		sx = -1
+	}
+	dy, sy := -abs(y1-y0), 1
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,42 @@
+package chess
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "chess"
+
+// Cog joins the discord commands and the lichess oauth callback
+type Cog struct {
+	*discord.Service
+	auth   rest.Auth
+	logger zap.Logger
+}
+
+func NewCog(commands *discord.Service, auth rest.Auth, logger zap.Logger) *Cog {
+	return &Cog{
+		Service: commands,
+		auth:    auth,
+		logger:  logger,
+	}
+}
+
+func (c *Cog) Name() string {
+	return Name
+}
+
+func (c *Cog) RegisterRoutes(router *gin.RouterGroup) {
+	rest.NewHandler(c.auth, router, c.logger).Router()
+}
+
+// Shutdown the watchers stop with the context of the bot
+func (c *Cog) Shutdown(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package fen
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	boardImageURL = "https://lichess1.org/export/fen.gif"
+	analysisURL   = "https://lichess.org/analysis/standard/"
+)
+
+var (
+	castlingRegexp  = regexp.MustCompile(`^(-|K?Q?k?q?)$`)
+	enPassantRegexp = regexp.MustCompile(`^(-|[a-h][36])$`)
+)
+
+// Validate checks that fen describes a legal-looking chess position.
+// The move counters may be omitted.
+func Validate(fen string) error {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 || len(fields) > 6 {
+		return errors.New("fen should have from 4 to 6 fields")
+	}
+	if err := validateBoard(fields[0]); err != nil {
+		return err
+	}
+	if fields[1] != "w" && fields[1] != "b" {
+		return errors.New("active color should be w or b")
+	}
+	if fields[2] == "" || !castlingRegexp.MatchString(fields[2]) {
+		return errors.New("invalid castling availability")
+	}
+	if !enPassantRegexp.MatchString(fields[3]) {
+		return errors.New("invalid en passant square")
+	}
+	if len(fields) > 4 {
+		if n, err := strconv.Atoi(fields[4]); err != nil || n < 0 {
+			return errors.New("invalid halfmove clock")
+		}
+	}
+	if len(fields) > 5 {
+		if n, err := strconv.Atoi(fields[5]); err != nil || n < 1 {
+			return errors.New("invalid fullmove number")
+		}
+	}
+	return nil
+}
+
+func validateBoard(board string) error {
+	ranks := strings.Split(board, "/")
+	if len(ranks) != 8 {
+		return errors.New("board should have 8 ranks")
+	}
+	kings := map[rune]int{}
+	for i, rank := range ranks {
+		files := 0
+		for _, c := range rank {
+			switch {
+			case c >= '1' && c <= '8':
+				files += int(c - '0')
+			case strings.ContainsRune("pnbrqkPNBRQK", c):
+				if (c == 'p' || c == 'P') && (i == 0 || i == 7) {
+					return errors.New("pawns can't stand on the first or the last rank")
+				}
+				if c == 'k' || c == 'K' {
+					kings[c]++
+				}
+				files++
+			default:
+				return errors.Errorf("unexpected symbol %q", c)
+			}
+		}
+		if files != 8 {
+			return errors.Errorf("rank %d should have 8 files", 8-i)
+		}
+	}
+	if kings['K'] != 1 || kings['k'] != 1 {
+		return errors.New("each side should have exactly one king")
+	}
+	return nil
+}
+
+// ImageURL rendered board from the point of view of the side to move
+func ImageURL(fen string) string {
+	params := url.Values{}
+	params.Set("fen", fen)
+	params.Set("color", "white")
+	if fields := strings.Fields(fen); len(fields) > 1 && fields[1] == "b" {
+		params.Set("color", "black")
+	}
+	return boardImageURL + "?" + params.Encode()
+}
+
+func AnalysisURL(fen string) string {
+	return analysisURL + strings.ReplaceAll(strings.Join(strings.Fields(fen), " "), " ", "_")
+}
@@ -0,0 +1,69 @@
+package fen
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	type test struct {
+		in    string
+		valid bool
+	}
+
+	testCases := []test{
+		{
+			in:    "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+			valid: true,
+		},
+		{
+			in:    "8/8/8/4k3/8/8/4K3/8 w - -",
+			valid: true,
+		},
+		{
+			in:    "8/8/8/4k3/8/8/4K3/8 w - - 0",
+			valid: true,
+		},
+		{
+			in:    "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 extra",
+			valid: false,
+		},
+		{
+			in:    "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+			valid: false,
+		},
+		{
+			in:    "rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			valid: false,
+		},
+		{
+			in:    "rnbq1bnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQ - 0 1",
+			valid: false,
+		},
+		{
+			in:    "8/8/8/4k3/8/8/4K3/7P w - - 0 1",
+			valid: false,
+		},
+		{
+			in:    "8/8/8/4k3/8/8/4K3/8 x - - 0 1",
+			valid: false,
+		},
+		{
+			in:    "8/8/8/4k3/8/8/4K3/8 w QK - 0 1",
+			valid: false,
+		},
+		{
+			in:    "8/8/8/4k3/8/8/4K3/8 w - e4 0 1",
+			valid: false,
+		},
+		{
+			in:    "8/8/8/4k3/8/8/4K3/8 w - - 0 0",
+			valid: false,
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		err := Validate(tc.in)
+		if (err == nil) != tc.valid {
+			t.Errorf("input: %s got %v, wanted valid=%t", tc.in, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lichessAIChallengeURL = "https://lichess.org/api/challenge/ai"
+	lichessBoardGameURL   = "https://lichess.org/api/board/game/"
+
+	StatusStarted = "started"
+)
+
+var ErrIllegalMove = errors.New("illegal move")
+
+type AIGame struct {
+	ID string `json:"id"`
+}
+
+// BoardState the position of the game streamed by the board API
+type BoardState struct {
+	Moves  string `json:"moves"`
+	Status string `json:"status"`
+	Winner string `json:"winner,omitempty"`
+}
+
+// WhiteToMove reports whether it's white's turn in the position
+func (b *BoardState) WhiteToMove() bool {
+	return len(strings.Fields(b.Moves))%2 == 0
+}
+
+func (b *BoardState) Finished() bool {
+	return b.Status != StatusStarted && b.Status != "created"
+}
+
+type boardEvent struct {
+	Type  string     `json:"type"`
+	State BoardState `json:"state"`
+	BoardState
+}
+
+// ChallengeAI starts a game of the token owner against the lichess AI of the level 1-8
+func (c *Client) ChallengeAI(ctx context.Context, token string, level int, color string) (*AIGame, error) {
+	form := url.Values{}
+	form.Set("level", strconv.Itoa(level))
+	form.Set("color", color)
+	resp, err := c.do(ctx, http.MethodPost, lichessAIChallengeURL, token, form)
+	if err != nil {
+		return nil, errors.Wrap(err, "challenge ai")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, errors.Errorf("challenge ai: status %d", resp.StatusCode)
+	}
+	var game AIGame
+	if err := json.NewDecoder(resp.Body).Decode(&game); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal game")
+	}
+	return &game, nil
+}
+
+// StreamBoardGame sends every new state of the game, the channel is closed when the stream ends
+func (c *Client) StreamBoardGame(ctx context.Context, token, id string) (<-chan BoardState, error) {
+	resp, err := c.do(ctx, http.MethodGet, lichessBoardGameURL+"stream/"+url.PathEscape(id), token, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "stream board game")
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("stream board game: status %d", resp.StatusCode)
+	}
+	out := make(chan BoardState, 1)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var e boardEvent
+			if err := dec.Decode(&e); err != nil {
+				return
+			}
+			switch e.Type {
+			case "gameFull":
+				out <- e.State
+			case "gameState":
+				out <- e.BoardState
+			}
+		}
+	}()
+	return out, nil
+}
+
+// MakeMove plays the move in UCI format
+func (c *Client) MakeMove(ctx context.Context, token, id, move string) error {
+	u := lichessBoardGameURL + url.PathEscape(id) + "/move/" + url.PathEscape(move)
+	resp, err := c.do(ctx, http.MethodPost, u, token, nil)
+	if err != nil {
+		return errors.Wrap(err, "make move")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusBadRequest {
+		return ErrIllegalMove
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("make move: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) ResignGame(ctx context.Context, token, id string) error {
+	return c.expectOK(ctx, http.MethodPost, lichessBoardGameURL+url.PathEscape(id)+"/resign", token, nil)
+}
+
+// AbortGame ends the game which is too short to resign
+func (c *Client) AbortGame(ctx context.Context, token, id string) error {
+	return c.expectOK(ctx, http.MethodPost, lichessBoardGameURL+url.PathEscape(id)+"/abort", token, nil)
+}
@@ -0,0 +1,108 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lichessChallengeURL = "https://lichess.org/api/challenge"
+	lichessSeekURL      = "https://lichess.org/api/board/seek"
+)
+
+type Challenge struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	Rated      bool   `json:"rated"`
+	Speed      string `json:"speed"`
+	Challenger struct {
+		Name   string `json:"name"`
+		Rating int    `json:"rating"`
+	} `json:"challenger"`
+}
+
+type challengesResponse struct {
+	In  []Challenge `json:"in"`
+	Out []Challenge `json:"out"`
+}
+
+// IncomingChallenges returns challenges sent to the token owner
+func (c *Client) IncomingChallenges(ctx context.Context, token string) ([]Challenge, error) {
+	resp, err := c.do(ctx, http.MethodGet, lichessChallengeURL, token, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "list challenges")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("list challenges: status %d", resp.StatusCode)
+	}
+	var challenges challengesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&challenges); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal challenges")
+	}
+	return challenges.In, nil
+}
+
+func (c *Client) AcceptChallenge(ctx context.Context, token, id string) error {
+	return c.expectOK(ctx, http.MethodPost, lichessChallengeURL+"/"+url.PathEscape(id)+"/accept", token, nil)
+}
+
+func (c *Client) DeclineChallenge(ctx context.Context, token, id, reason string) error {
+	form := url.Values{}
+	if reason != "" {
+		form.Set("reason", reason)
+	}
+	return c.expectOK(ctx, http.MethodPost, lichessChallengeURL+"/"+url.PathEscape(id)+"/decline", token, form)
+}
+
+// Seek creates a public seek and blocks until lichess pairs it with an opponent
+// or ctx is done. The seek lives while the connection is open.
+func (c *Client) Seek(ctx context.Context, token string, minutes, increment int, rated bool) error {
+	form := url.Values{}
+	form.Set("time", strconv.Itoa(minutes))
+	form.Set("increment", strconv.Itoa(increment))
+	form.Set("rated", strconv.FormatBool(rated))
+	return c.expectOK(ctx, http.MethodPost, lichessSeekURL, token, form)
+}
+
+func (c *Client) expectOK(ctx context.Context, method, u, token string, form url.Values) error {
+	resp, err := c.do(ctx, method, u, token, form)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s", method, u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("%s %s: status %d", method, u, resp.StatusCode)
+	}
+	// Streaming endpoints keep the connection till the end of the action
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, u, token string, form url.Values) (*http.Response, error) {
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "create new req to lichess")
+	}
+	if form != nil {
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+	return c.client.Do(req)
+}
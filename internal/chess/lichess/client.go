@@ -1,6 +1,7 @@
 package lichess
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -50,6 +51,20 @@ func (c *Client) StartOpenGame() (*OpenGameResponse, error) {
 	return &game, nil
 }
 
+func (c *Client) get(ctx context.Context, url, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create new get req to lichess")
+	}
+	req.Header.Add("Accept", accept)
+	return c.client.GetRequest(req)
+}
+
+// toID lichess user ids are lowercase usernames
+func toID(username string) string {
+	return strings.ToLower(username)
+}
+
 type OpenGameResponse struct {
 	Challenge struct {
 		ID         string      `json:"id"`
@@ -0,0 +1,137 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lichessGamesURL = "https://lichess.org/api/games/user/"
+	maxH2HGames     = 300
+	maxRecentGames  = 100
+	gameURL         = "https://lichess.org/"
+)
+
+type GamePlayer struct {
+	User struct {
+		Name string `json:"name"`
+		ID   string `json:"id"`
+	} `json:"user"`
+	Rating int `json:"rating"`
+}
+
+type Game struct {
+	ID         string `json:"id"`
+	Rated      bool   `json:"rated"`
+	Speed      string `json:"speed"`
+	Status     string `json:"status"`
+	Winner     string `json:"winner,omitempty"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastMoveAt int64  `json:"lastMoveAt"`
+	Players    struct {
+		White GamePlayer `json:"white"`
+		Black GamePlayer `json:"black"`
+	} `json:"players"`
+	Opening struct {
+		ECO  string `json:"eco"`
+		Name string `json:"name"`
+	} `json:"opening"`
+}
+
+// GamesBetween exports the last games that username played against opponent
+func (c *Client) GamesBetween(ctx context.Context, username, opponent string) ([]Game, error) {
+	params := url.Values{}
+	params.Set("vs", opponent)
+	params.Set("opening", "true")
+	params.Set("moves", "false")
+	params.Set("max", strconv.Itoa(maxH2HGames))
+	resp, err := c.get(ctx, lichessGamesURL+url.PathEscape(username)+"?"+params.Encode(), "application/x-ndjson")
+	if err != nil {
+		return nil, errors.Wrapf(err, "export games %s vs %s", username, opponent)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("export games %s vs %s: status %d", username, opponent, resp.StatusCode)
+	}
+	return decodeGames(resp.Body)
+}
+
+// GamesSince exports the rated games of username that were played after since
+func (c *Client) GamesSince(ctx context.Context, username string, since time.Time) ([]Game, error) {
+	params := url.Values{}
+	params.Set("since", strconv.FormatInt(since.UnixNano()/int64(time.Millisecond), 10))
+	params.Set("rated", "true")
+	params.Set("moves", "false")
+	params.Set("max", strconv.Itoa(maxRecentGames))
+	resp, err := c.get(ctx, lichessGamesURL+url.PathEscape(username)+"?"+params.Encode(), "application/x-ndjson")
+	if err != nil {
+		return nil, errors.Wrapf(err, "export games of %s", username)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("export games of %s: status %d", username, resp.StatusCode)
+	}
+	return decodeGames(resp.Body)
+}
+
+func decodeGames(r io.Reader) ([]Game, error) {
+	games := make([]Game, 0)
+	dec := json.NewDecoder(r)
+	for {
+		var g Game
+		err := dec.Decode(&g)
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to unmarshal game")
+		}
+		games = append(games, g)
+	}
+}
+
+// Finished reports whether the game was actually played till the end
+func (g *Game) Finished() bool {
+	switch g.Status {
+	case "created", "started", "aborted", "noStart":
+		return false
+	}
+	return true
+}
+
+func (g *Game) URL() string {
+	return gameURL + g.ID
+}
+
+// IsWhite reports whether username played with white pieces
+func (g *Game) IsWhite(username string) bool {
+	return g.Players.White.User.ID == toID(username)
+}
+
+// Opponent returns the player on the other side of the board
+func (g *Game) Opponent(username string) *GamePlayer {
+	if g.IsWhite(username) {
+		return &g.Players.Black
+	}
+	return &g.Players.White
+}
+
+// Player returns the side of the board username played
+func (g *Game) Player(username string) *GamePlayer {
+	if g.IsWhite(username) {
+		return &g.Players.White
+	}
+	return &g.Players.Black
+}
+
+// Won reports whether username won the game
+func (g *Game) Won(username string) bool {
+	return g.Winner != "" && (g.Winner == "white") == g.IsWhite(username)
+}
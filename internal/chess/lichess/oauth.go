@@ -0,0 +1,97 @@
+package lichess
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	lichessAuthURL    = "https://lichess.org/oauth"
+	lichessTokenURL   = "https://lichess.org/api/token"
+	lichessAccountURL = "https://lichess.org/api/account"
+)
+
+// Scopes required to act on behalf of the user
+var Scopes = []string{"challenge:read", "challenge:write", "board:play"}
+
+type OAuth struct {
+	config oauth2.Config
+}
+
+func NewOAuth(clientID, redirectURL string) *OAuth {
+	return &OAuth{
+		config: oauth2.Config{
+			ClientID:    clientID,
+			RedirectURL: redirectURL,
+			Scopes:      Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   lichessAuthURL,
+				TokenURL:  lichessTokenURL,
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+		},
+	}
+}
+
+// AuthCodeURL returns url of lichess consent page, lichess requires PKCE
+func (o *OAuth) AuthCodeURL(state, verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return o.config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(sum[:])),
+	)
+}
+
+func (o *OAuth) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	token, err := o.config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		return nil, errors.Wrap(err, "exchange lichess code")
+	}
+	return token, nil
+}
+
+// RevokeToken makes the token unusable
+func (c *Client) RevokeToken(ctx context.Context, token string) error {
+	resp, err := c.do(ctx, http.MethodDelete, lichessTokenURL, token, nil)
+	if err != nil {
+		return errors.Wrap(err, "revoke token")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return errors.Errorf("revoke token: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Account of the user who granted the token
+func (c *Client) Account(ctx context.Context, token string) (*User, error) {
+	resp, err := c.do(ctx, http.MethodGet, lichessAccountURL, token, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "get account")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get account: status %d", resp.StatusCode)
+	}
+	var user User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal account")
+	}
+	return &user, nil
+}
+
+// RandomString url safe string for oauth states and code verifiers
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "read random bytes")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,56 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lichessNextPuzzleURL = "https://lichess.org/api/puzzle/next"
+	puzzleURL            = "https://lichess.org/training/"
+)
+
+type Puzzle struct {
+	ID         string   `json:"id"`
+	Rating     int      `json:"rating"`
+	Solution   []string `json:"solution"`
+	Themes     []string `json:"themes"`
+	InitialPly int      `json:"initialPly"`
+}
+
+func (p *Puzzle) URL() string {
+	return puzzleURL + p.ID
+}
+
+// PlayerMoves the moves of the solving side, the other moves are the opponent's answers
+func (p *Puzzle) PlayerMoves() []string {
+	moves := make([]string, 0, (len(p.Solution)+1)/2)
+	for i := 0; i < len(p.Solution); i += 2 {
+		moves = append(moves, p.Solution[i])
+	}
+	return moves
+}
+
+type puzzleResponse struct {
+	Puzzle Puzzle `json:"puzzle"`
+}
+
+// NextPuzzle returns a random puzzle, the token is optional
+func (c *Client) NextPuzzle(ctx context.Context, token string) (*Puzzle, error) {
+	resp, err := c.do(ctx, http.MethodGet, lichessNextPuzzleURL, token, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "get next puzzle")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get next puzzle: status %d", resp.StatusCode)
+	}
+	var p puzzleResponse
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal puzzle")
+	}
+	return &p.Puzzle, nil
+}
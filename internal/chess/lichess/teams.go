@@ -0,0 +1,153 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lichessTeamURL = "https://lichess.org/api/team/"
+	teamURL        = "https://lichess.org/team/"
+)
+
+var ErrTeamNotFound = errors.New("lichess team not found")
+
+type Team struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	NbMembers int    `json:"nbMembers"`
+}
+
+func (t *Team) URL() string {
+	return teamURL + t.ID
+}
+
+type TeamMember struct {
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	JoinedTeamAt int64  `json:"joinedTeamAt"` // unix ms
+}
+
+func (m *TeamMember) Joined() time.Time {
+	return time.UnixMilli(m.JoinedTeamAt)
+}
+
+// TeamArena tournament of the team
+type TeamArena struct {
+	ID       string `json:"id"`
+	FullName string `json:"fullName"`
+	StartsAt int64  `json:"startsAt"` // unix ms
+	Minutes  int    `json:"minutes"`
+	Status   int    `json:"status"`
+}
+
+// Finished lichess uses 30 for finished tournaments
+func (a *TeamArena) Finished() bool {
+	return a.Status >= 30
+}
+
+func (a *TeamArena) Starts() time.Time {
+	return time.UnixMilli(a.StartsAt)
+}
+
+func (a *TeamArena) URL() string {
+	return tournamentURL + a.ID
+}
+
+func (c *Client) GetTeam(ctx context.Context, id string) (*Team, error) {
+	resp, err := c.get(ctx, lichessTeamURL+url.PathEscape(id), "application/json")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get team %s", id)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrTeamNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get team %s: status %d", id, resp.StatusCode)
+	}
+	var t Team
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal team")
+	}
+	return &t, nil
+}
+
+// NewTeamMembers returns the members who joined the team after the time, the most recent first.
+// The roster is streamed in that order, so the rest of it isn't read.
+func (c *Client) NewTeamMembers(ctx context.Context, id string, since time.Time) ([]TeamMember, error) {
+	resp, err := c.get(ctx, lichessTeamURL+url.PathEscape(id)+"/users", "application/x-ndjson")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get team %s members", id)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get team %s members: status %d", id, resp.StatusCode)
+	}
+	members := make([]TeamMember, 0)
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var m TeamMember
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			return members, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to unmarshal team member")
+		}
+		if !m.Joined().After(since) {
+			return members, nil
+		}
+		members = append(members, m)
+	}
+}
+
+// UserTeams returns the teams the user is a member of
+func (c *Client) UserTeams(ctx context.Context, username string) ([]Team, error) {
+	resp, err := c.get(ctx, lichessTeamURL+"of/"+url.PathEscape(username), "application/json")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get teams of %s", username)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get teams of %s: status %d", username, resp.StatusCode)
+	}
+	teams := make([]Team, 0)
+	if err := json.NewDecoder(resp.Body).Decode(&teams); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal teams")
+	}
+	return teams, nil
+}
+
+// TeamArenas returns the latest arenas of the team
+func (c *Client) TeamArenas(ctx context.Context, id string, max int) ([]TeamArena, error) {
+	u := lichessTeamURL + url.PathEscape(id) + "/arena?max=" + strconv.Itoa(max)
+	resp, err := c.get(ctx, u, "application/x-ndjson")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get team %s arenas", id)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get team %s arenas: status %d", id, resp.StatusCode)
+	}
+	arenas := make([]TeamArena, 0, max)
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var a TeamArena
+		err := dec.Decode(&a)
+		if err == io.EOF {
+			return arenas, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to unmarshal arena")
+		}
+		arenas = append(arenas, a)
+	}
+}
@@ -0,0 +1,109 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lichessTournamentURL = "https://lichess.org/api/tournament"
+	tournamentURL        = "https://lichess.org/tournament/"
+)
+
+type ArenaRequest struct {
+	Name      string
+	Clock     int // minutes
+	Increment int // seconds
+	Duration  int // minutes
+	TeamID    string
+}
+
+type Tournament struct {
+	ID         string `json:"id"`
+	FullName   string `json:"fullName"`
+	IsFinished bool   `json:"isFinished"`
+}
+
+func (t *Tournament) URL() string {
+	return tournamentURL + t.ID
+}
+
+type TournamentResult struct {
+	Rank     int    `json:"rank"`
+	Score    int    `json:"score"`
+	Rating   int    `json:"rating"`
+	Username string `json:"username"`
+}
+
+// CreateArena creates the arena on behalf of the token owner, restricted to the team members if TeamID is set
+func (c *Client) CreateArena(ctx context.Context, token string, r *ArenaRequest) (*Tournament, error) {
+	form := url.Values{}
+	form.Set("name", r.Name)
+	form.Set("clockTime", strconv.Itoa(r.Clock))
+	form.Set("clockIncrement", strconv.Itoa(r.Increment))
+	form.Set("minutes", strconv.Itoa(r.Duration))
+	if r.TeamID != "" {
+		form.Set("conditions.teamMember.teamId", r.TeamID)
+	}
+	resp, err := c.do(ctx, http.MethodPost, lichessTournamentURL, token, form)
+	if err != nil {
+		return nil, errors.Wrap(err, "create arena")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("create arena: status %d", resp.StatusCode)
+	}
+	var t Tournament
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal tournament")
+	}
+	return &t, nil
+}
+
+func (c *Client) GetTournament(ctx context.Context, id string) (*Tournament, error) {
+	resp, err := c.get(ctx, lichessTournamentURL+"/"+url.PathEscape(id), "application/json")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get tournament %s", id)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get tournament %s: status %d", id, resp.StatusCode)
+	}
+	var t Tournament
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal tournament")
+	}
+	return &t, nil
+}
+
+// TournamentResults returns the top n players of the tournament
+func (c *Client) TournamentResults(ctx context.Context, id string, n int) ([]TournamentResult, error) {
+	u := lichessTournamentURL + "/" + url.PathEscape(id) + "/results?nb=" + strconv.Itoa(n)
+	resp, err := c.get(ctx, u, "application/x-ndjson")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get tournament %s results", id)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get tournament %s results: status %d", id, resp.StatusCode)
+	}
+	results := make([]TournamentResult, 0, n)
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var r TournamentResult
+		err := dec.Decode(&r)
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to unmarshal result")
+		}
+		results = append(results, r)
+	}
+}
@@ -0,0 +1,83 @@
+package lichess
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const lichessUserURL = "https://lichess.org/api/user/"
+
+var ErrUserNotFound = errors.New("lichess user not found")
+
+type Perf struct {
+	Games  int  `json:"games"`
+	Rating int  `json:"rating"`
+	Prov   bool `json:"prov,omitempty"`
+}
+
+type User struct {
+	ID       string          `json:"id"`
+	Username string          `json:"username"`
+	Perfs    map[string]Perf `json:"perfs"`
+}
+
+// GetUser returns public data of the lichess account
+func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
+	resp, err := c.get(ctx, lichessUserURL+url.PathEscape(username), "application/json")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get user %s", username)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get user %s: status %d", username, resp.StatusCode)
+	}
+
+	var user User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal user")
+	}
+	return &user, nil
+}
+
+// RatingPoint is [year, month (0-11), day, rating]
+type RatingPoint [4]int
+
+func (p RatingPoint) Date() time.Time {
+	return time.Date(p[0], time.Month(p[1]+1), p[2], 0, 0, 0, 0, time.UTC)
+}
+
+func (p RatingPoint) Rating() int {
+	return p[3]
+}
+
+type RatingHistory struct {
+	Name   string        `json:"name"`
+	Points []RatingPoint `json:"points"`
+}
+
+func (c *Client) RatingHistory(ctx context.Context, username string) ([]RatingHistory, error) {
+	resp, err := c.get(ctx, lichessUserURL+url.PathEscape(username)+"/rating-history", "application/json")
+	if err != nil {
+		return nil, errors.Wrapf(err, "get rating history %s", username)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get rating history %s: status %d", username, resp.StatusCode)
+	}
+	var history []RatingHistory
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal rating history")
+	}
+	return history, nil
+}
@@ -0,0 +1,90 @@
+package race
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+)
+
+type Score struct {
+	UserID string
+	Solved int
+}
+
+// Race the members solve the same puzzles one by one, the first correct answer gets the point
+type Race struct {
+	mx       sync.Mutex
+	puzzles  []*lichess.Puzzle
+	current  int
+	scores   map[string]int
+	Started  time.Time
+	Deadline time.Time
+}
+
+func NewRace(puzzles []*lichess.Puzzle, duration time.Duration) *Race {
+	now := time.Now()
+	return &Race{
+		puzzles:  puzzles,
+		scores:   make(map[string]int),
+		Started:  now,
+		Deadline: now.Add(duration),
+	}
+}
+
+// Current returns nil when all puzzles are solved or the time is over
+func (r *Race) Current() *lichess.Puzzle {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.currentLocked()
+}
+
+func (r *Race) currentLocked() *lichess.Puzzle {
+	if r.current >= len(r.puzzles) || time.Now().After(r.Deadline) {
+		return nil
+	}
+	return r.puzzles[r.current]
+}
+
+// Answer checks the player moves of the current puzzle separated by spaces.
+// The next puzzle is returned if the answer is correct.
+func (r *Race) Answer(userID, answer string) (correct bool, next *lichess.Puzzle) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	p := r.currentLocked()
+	if p == nil {
+		return false, nil
+	}
+	moves := strings.Fields(strings.ToLower(answer))
+	expected := p.PlayerMoves()
+	if len(moves) != len(expected) {
+		return false, p
+	}
+	for i := range moves {
+		if moves[i] != expected[i] {
+			return false, p
+		}
+	}
+	r.scores[userID]++
+	r.current++
+	return true, r.currentLocked()
+}
+
+// Scores sorted from the best
+func (r *Race) Scores() []Score {
+	r.mx.Lock()
+	res := make([]Score, 0, len(r.scores))
+	for u, s := range r.scores {
+		res = append(res, Score{UserID: u, Solved: s})
+	}
+	r.mx.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Solved == res[j].Solved {
+			return res[i].UserID < res[j].UserID
+		}
+		return res[i].Solved > res[j].Solved
+	})
+	return res
+}
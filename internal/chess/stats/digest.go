@@ -0,0 +1,185 @@
+package stats
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	notableGamesNumber = 3
+	puzzlePerf         = "puzzle"
+)
+
+var digestPerfs = []string{"bullet", "blitz", "rapid", "classical", "correspondence"}
+
+type DigestClient interface {
+	GetUser(ctx context.Context, username string) (*lichess.User, error)
+	GamesSince(ctx context.Context, username string, since time.Time) ([]lichess.Game, error)
+}
+
+type DigestStorage interface {
+	AllLinks(ctx context.Context) (map[string]firestore.Link, error)
+	GetRatings(ctx context.Context, userID string) (*firestore.RatingSnapshot, error)
+	SetRatings(ctx context.Context, userID string, r *firestore.RatingSnapshot) error
+}
+
+type RatingChange struct {
+	UserID  string
+	Lichess string
+	Perf    string
+	Old     int
+	New     int
+}
+
+func (r *RatingChange) Diff() int {
+	return r.New - r.Old
+}
+
+type NotableGame struct {
+	UserID         string
+	Lichess        string
+	Opponent       string
+	Rating         int
+	OpponentRating int
+	URL            string
+}
+
+type PuzzleScore struct {
+	UserID  string
+	Lichess string
+	Solved  int
+}
+
+type Digest struct {
+	From    time.Time
+	To      time.Time
+	Ratings []RatingChange
+	Games   []NotableGame
+	Puzzles []PuzzleScore
+}
+
+func (d *Digest) Empty() bool {
+	return len(d.Ratings) == 0 && len(d.Games) == 0 && len(d.Puzzles) == 0
+}
+
+type DigestService struct {
+	client  DigestClient
+	storage DigestStorage
+}
+
+func NewDigestService(client DigestClient, storage DigestStorage) *DigestService {
+	return &DigestService{
+		client:  client,
+		storage: storage,
+	}
+}
+
+// Weekly builds the digest of the linked users' activity since the previous one
+// and stores the new rating snapshots.
+// The changes are counted from the snapshots stored by the previous digest, but the current ratings,
+// the solved puzzles and the games are played on lichess and the bot never sees them. So they are fetched
+// live, once a week per user: collecting them into the storage in between would fetch the same data more often.
+func (s *DigestService) Weekly(ctx context.Context) (*Digest, error) {
+	links, err := s.storage.AllLinks(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get all links")
+	}
+	now := time.Now()
+	d := &Digest{
+		From: now.Add(-7 * 24 * time.Hour),
+		To:   now,
+	}
+	for userID, link := range links {
+		if err := s.addUser(ctx, d, userID, link.Lichess); err != nil {
+			contexts.LoggerFromContext(ctx).Error(errors.Wrapf(err, "digest for %s", link.Lichess))
+		}
+	}
+
+	sort.Slice(d.Ratings, func(i, j int) bool {
+		return d.Ratings[i].Diff() > d.Ratings[j].Diff()
+	})
+	sort.Slice(d.Games, func(i, j int) bool {
+		return d.Games[i].OpponentRating-d.Games[i].Rating > d.Games[j].OpponentRating-d.Games[j].Rating
+	})
+	if len(d.Games) > notableGamesNumber {
+		d.Games = d.Games[:notableGamesNumber]
+	}
+	sort.Slice(d.Puzzles, func(i, j int) bool {
+		return d.Puzzles[i].Solved > d.Puzzles[j].Solved
+	})
+	return d, nil
+}
+
+func (s *DigestService) addUser(ctx context.Context, d *Digest, userID, username string) error {
+	user, err := s.client.GetUser(ctx, username)
+	if err != nil {
+		return errors.Wrap(err, "get lichess user")
+	}
+	current := &firestore.RatingSnapshot{
+		Lichess: user.Username,
+		Ratings: make(map[string]int),
+		Puzzles: user.Perfs[puzzlePerf].Games,
+		Updated: d.To,
+	}
+	for _, perf := range digestPerfs {
+		if p, ok := user.Perfs[perf]; ok && p.Games > 0 {
+			current.Ratings[perf] = p.Rating
+		}
+	}
+
+	previous, err := s.storage.GetRatings(ctx, userID)
+	switch {
+	case err == nil && previous.Lichess == current.Lichess:
+		for perf, rating := range current.Ratings {
+			if old, ok := previous.Ratings[perf]; ok && old != rating {
+				d.Ratings = append(d.Ratings, RatingChange{
+					UserID:  userID,
+					Lichess: user.Username,
+					Perf:    perf,
+					Old:     old,
+					New:     rating,
+				})
+			}
+		}
+		if solved := current.Puzzles - previous.Puzzles; solved > 0 {
+			d.Puzzles = append(d.Puzzles, PuzzleScore{
+				UserID:  userID,
+				Lichess: user.Username,
+				Solved:  solved,
+			})
+		}
+	case err != nil && err != firestore.ErrNotFound:
+		return errors.Wrap(err, "get previous ratings")
+	}
+	if err := s.storage.SetRatings(ctx, userID, current); err != nil {
+		return errors.Wrap(err, "set ratings")
+	}
+
+	games, err := s.client.GamesSince(ctx, user.Username, d.From)
+	if err != nil {
+		return errors.Wrap(err, "get recent games")
+	}
+	for i := range games {
+		g := &games[i]
+		if !g.Won(user.Username) {
+			continue
+		}
+		me, opponent := g.Player(user.Username), g.Opponent(user.Username)
+		d.Games = append(d.Games, NotableGame{
+			UserID:         userID,
+			Lichess:        user.Username,
+			Opponent:       opponent.User.Name,
+			Rating:         me.Rating,
+			OpponentRating: opponent.Rating,
+			URL:            g.URL(),
+		})
+	}
+	return nil
+}
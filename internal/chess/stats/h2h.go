@@ -0,0 +1,103 @@
+package stats
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	h2hExpiration = 6 * time.Hour
+	topOpenings   = 3
+)
+
+type Client interface {
+	GamesBetween(ctx context.Context, username, opponent string) ([]lichess.Game, error)
+}
+
+type Storage interface {
+	GetHeadToHead(ctx context.Context, a, b string) (*firestore.HeadToHead, error)
+	SetHeadToHead(ctx context.Context, h *firestore.HeadToHead) error
+}
+
+type Service struct {
+	client  Client
+	storage Storage
+}
+
+func NewService(client Client, storage Storage) *Service {
+	return &Service{
+		client:  client,
+		storage: storage,
+	}
+}
+
+// HeadToHead returns results of a against b, cached results are used if they are fresh enough
+func (s *Service) HeadToHead(ctx context.Context, a, b string) (*firestore.HeadToHead, error) {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	cached, err := s.storage.GetHeadToHead(ctx, a, b)
+	if err == nil && time.Since(cached.Updated) < h2hExpiration {
+		return cached, nil
+	}
+	if err != nil && err != firestore.ErrNotFound {
+		contexts.LoggerFromContext(ctx).Error(errors.Wrap(err, "get cached h2h"))
+	}
+
+	games, err := s.client.GamesBetween(ctx, a, b)
+	if err != nil {
+		return nil, errors.Wrap(err, "load games from lichess")
+	}
+	h := aggregate(a, b, games)
+	if err := s.storage.SetHeadToHead(ctx, h); err != nil {
+		contexts.LoggerFromContext(ctx).Error(errors.Wrap(err, "cache h2h"))
+	}
+	return h, nil
+}
+
+func aggregate(a, b string, games []lichess.Game) *firestore.HeadToHead {
+	h := &firestore.HeadToHead{
+		PlayerA: a,
+		PlayerB: b,
+		Updated: time.Now(),
+	}
+	openings := make(map[string]int)
+	for i := range games {
+		g := &games[i]
+		if !g.Finished() {
+			continue
+		}
+		if g.Opening.Name != "" {
+			openings[g.Opening.Name]++
+		}
+		switch {
+		case g.Winner == "":
+			h.Draws++
+		case g.Won(a):
+			h.WinsA++
+		default:
+			h.WinsB++
+		}
+	}
+
+	h.Openings = make([]firestore.Opening, 0, len(openings))
+	for name, count := range openings {
+		h.Openings = append(h.Openings, firestore.Opening{Name: name, Count: count})
+	}
+	sort.Slice(h.Openings, func(i, j int) bool {
+		if h.Openings[i].Count == h.Openings[j].Count {
+			return h.Openings[i].Name < h.Openings[j].Name
+		}
+		return h.Openings[i].Count > h.Openings[j].Count
+	})
+	if len(h.Openings) > topOpenings {
+		h.Openings = h.Openings[:topOpenings]
+	}
+	return h
+}
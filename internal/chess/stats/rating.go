@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/chart"
+	"github.com/HalvaPovidlo/discordBotGo/internal/chess/lichess"
+)
+
+const (
+	chartExpiration = 10 * time.Minute
+	chartPeriod     = 365 * 24 * time.Hour
+)
+
+type RatingClient interface {
+	GetUser(ctx context.Context, username string) (*lichess.User, error)
+	RatingHistory(ctx context.Context, username string) ([]lichess.RatingHistory, error)
+}
+
+// Rating current ratings of the user and the chart of the last year
+type Rating struct {
+	User    *lichess.User
+	Chart   []byte
+	Bounds  chart.Bounds
+	created time.Time
+}
+
+type RatingService struct {
+	client RatingClient
+
+	cacheMx sync.Mutex
+	cache   map[string]*Rating
+}
+
+func NewRatingService(client RatingClient) *RatingService {
+	return &RatingService{
+		client: client,
+		cache:  make(map[string]*Rating),
+	}
+}
+
+// Rating generated charts are cached for a short time because they are requested in bursts
+func (s *RatingService) Rating(ctx context.Context, username string) (*Rating, error) {
+	key := strings.ToLower(username)
+	s.cacheMx.Lock()
+	now := time.Now()
+	for k, v := range s.cache {
+		if now.Sub(v.created) > chartExpiration {
+			delete(s.cache, k)
+		}
+	}
+	cached, ok := s.cache[key]
+	s.cacheMx.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	user, err := s.client.GetUser(ctx, username)
+	if err != nil {
+		return nil, errors.Wrap(err, "get lichess user")
+	}
+	history, err := s.client.RatingHistory(ctx, username)
+	if err != nil {
+		return nil, errors.Wrap(err, "get rating history")
+	}
+	r := &Rating{
+		User:    user,
+		created: now,
+	}
+	r.Chart, r.Bounds, err = chart.Rating(history, now.Add(-chartPeriod))
+	if err != nil && !errors.Is(err, chart.ErrNoData) {
+		return nil, errors.Wrap(err, "draw rating chart")
+	}
+
+	s.cacheMx.Lock()
+	s.cache[key] = r
+	s.cacheMx.Unlock()
+	return r, nil
+}
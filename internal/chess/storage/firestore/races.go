@@ -0,0 +1,91 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+)
+
+const racesCollection = "chess_races"
+
+// RaceResult solved puzzles per discord user
+type RaceResult struct {
+	ChannelID string         `firestore:"channel_id" json:"channel_id"`
+	Started   time.Time      `firestore:"started" json:"started"`
+	Puzzles   int            `firestore:"puzzles" json:"puzzles"`
+	Scores    map[string]int `firestore:"scores" json:"scores"`
+}
+
+// UserRace the score of the user in a race, the other racers aren't exported
+type UserRace struct {
+	ChannelID string    `json:"channel_id"`
+	Started   time.Time `json:"started"`
+	Puzzles   int       `json:"puzzles"`
+	Score     int       `json:"score"`
+}
+
+func (s *Storage) AddRaceResult(ctx context.Context, r *RaceResult) error {
+	_, _, err := s.client.Collection(racesCollection).Add(ctx, r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add race to %s", racesCollection)
+	}
+	return nil
+}
+
+// UserRaces the races the user scored in
+func (s *Storage) UserRaces(ctx context.Context, userID string) ([]UserRace, error) {
+	refs, races, err := s.userRaces(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]UserRace, 0, len(refs))
+	for i := range races {
+		res = append(res, UserRace{
+			ChannelID: races[i].ChannelID,
+			Started:   races[i].Started,
+			Puzzles:   races[i].Puzzles,
+			Score:     races[i].Scores[userID],
+		})
+	}
+	return res, nil
+}
+
+// DeleteUserRaces removes the score of the user, the races stay for the other racers
+func (s *Storage) DeleteUserRaces(ctx context.Context, userID string) (int, error) {
+	refs, _, err := s.userRaces(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i, ref := range refs {
+		if _, err := ref.Update(ctx, []firestore.Update{{FieldPath: firestore.FieldPath{"scores", userID}, Value: firestore.Delete}}); err != nil {
+			return i, errors.Wrapf(err, "failed to delete the score of %s from %s", ref.ID, racesCollection)
+		}
+	}
+	return len(refs), nil
+}
+
+func (s *Storage) userRaces(ctx context.Context, userID string) ([]*firestore.DocumentRef, []RaceResult, error) {
+	iter := s.client.Collection(racesCollection).WherePath(firestore.FieldPath{"scores", userID}, ">=", 0).Documents(ctx)
+	defer iter.Stop()
+	refs := make([]*firestore.DocumentRef, 0)
+	races := make([]RaceResult, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get %s of %s", racesCollection, userID)
+		}
+		var r RaceResult
+		if err := doc.DataTo(&r); err != nil {
+			return nil, nil, errors.Wrap(err, "unable to marshal data")
+		}
+		refs = append(refs, doc.Ref)
+		races = append(races, r)
+	}
+	return refs, races, nil
+}
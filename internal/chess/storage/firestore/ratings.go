@@ -0,0 +1,66 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const ratingsCollection = "chess_ratings"
+
+// RatingSnapshot ratings of the linked user at the moment of the last digest
+type RatingSnapshot struct {
+	Lichess string         `firestore:"lichess"`
+	Ratings map[string]int `firestore:"ratings"`
+	Puzzles int            `firestore:"puzzles"`
+	Updated time.Time      `firestore:"updated"`
+}
+
+// AllLinks returns lichess links of all discord users
+func (s *Storage) AllLinks(ctx context.Context) (map[string]Link, error) {
+	iter := s.client.Collection(usersCollection).Documents(ctx)
+	defer iter.Stop()
+	res := make(map[string]Link)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var l Link
+		if err := doc.DataTo(&l); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res[doc.Ref.ID] = l
+	}
+	return res, nil
+}
+
+func (s *Storage) GetRatings(ctx context.Context, userID string) (*RatingSnapshot, error) {
+	doc, err := s.client.Collection(ratingsCollection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get %s from %s", userID, ratingsCollection)
+	}
+	var r RatingSnapshot
+	if err := doc.DataTo(&r); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &r, nil
+}
+
+func (s *Storage) SetRatings(ctx context.Context, userID string, r *RatingSnapshot) error {
+	_, err := s.client.Collection(ratingsCollection).Doc(userID).Set(ctx, r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", userID, ratingsCollection)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	usersCollection = "chess_users"
+	h2hCollection   = "chess_h2h"
+)
+
+var ErrNotFound = errors.New("no docs found")
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+// Link is a connection between discord user and lichess account
+type Link struct {
+	Lichess string    `firestore:"lichess"`
+	Linked  time.Time `firestore:"linked"`
+}
+
+// Opening how many times the opening appeared in games
+type Opening struct {
+	Name  string `firestore:"name"`
+	Count int    `firestore:"count"`
+}
+
+// HeadToHead aggregated results of PlayerA against PlayerB
+type HeadToHead struct {
+	PlayerA  string    `firestore:"player_a"`
+	PlayerB  string    `firestore:"player_b"`
+	WinsA    int       `firestore:"wins_a"`
+	WinsB    int       `firestore:"wins_b"`
+	Draws    int       `firestore:"draws"`
+	Openings []Opening `firestore:"openings"`
+	Updated  time.Time `firestore:"updated"`
+}
+
+// Swap returns the same results from PlayerB point of view
+func (h *HeadToHead) Swap() *HeadToHead {
+	return &HeadToHead{
+		PlayerA:  h.PlayerB,
+		PlayerB:  h.PlayerA,
+		WinsA:    h.WinsB,
+		WinsB:    h.WinsA,
+		Draws:    h.Draws,
+		Openings: h.Openings,
+		Updated:  h.Updated,
+	}
+}
+
+func (s *Storage) GetLink(ctx context.Context, userID string) (*Link, error) {
+	doc, err := s.client.Collection(usersCollection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get %s from %s", userID, usersCollection)
+	}
+	var l Link
+	if err := doc.DataTo(&l); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &l, nil
+}
+
+func (s *Storage) SetLink(ctx context.Context, userID string, link *Link) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetLink user:%s lichess:%s", userID, link.Lichess)
+	_, err := s.client.Collection(usersCollection).Doc(userID).Set(ctx, link)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", userID, usersCollection)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, userID string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteLink user:%s", userID)
+	_, err := s.client.Collection(usersCollection).Doc(userID).Delete(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", userID, usersCollection)
+	}
+	return nil
+}
+
+// GetHeadToHead returns cached results with the PlayerA == a
+func (s *Storage) GetHeadToHead(ctx context.Context, a, b string) (*HeadToHead, error) {
+	key, swapped := h2hKey(a, b)
+	doc, err := s.client.Collection(h2hCollection).Doc(key).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get %s from %s", key, h2hCollection)
+	}
+	var h HeadToHead
+	if err := doc.DataTo(&h); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	if swapped {
+		return h.Swap(), nil
+	}
+	return &h, nil
+}
+
+func (s *Storage) SetHeadToHead(ctx context.Context, h *HeadToHead) error {
+	key, swapped := h2hKey(h.PlayerA, h.PlayerB)
+	if swapped {
+		h = h.Swap()
+	}
+	_, err := s.client.Collection(h2hCollection).Doc(key).Set(ctx, h)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", key, h2hCollection)
+	}
+	return nil
+}
+
+// h2hKeySeparator can't be in a lichess username, unlike "_"
+const h2hKeySeparator = ":"
+
+// h2hKey the same pair of players is always stored under one document
+func h2hKey(a, b string) (string, bool) {
+	if a > b {
+		return b + h2hKeySeparator + a, true
+	}
+	return a + h2hKeySeparator + b, false
+}
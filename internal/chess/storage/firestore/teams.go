@@ -0,0 +1,78 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const teamsCollection = "chess_teams"
+
+// Team lichess team tied to the discord guild
+type Team struct {
+	GuildID   string `firestore:"guild_id"`
+	TeamID    string `firestore:"team_id"`
+	ChannelID string `firestore:"channel_id"`
+	// RoleID is given to the linked members of the team, empty to disable
+	RoleID string `firestore:"role_id"`
+	// LastJoined of the announced members
+	LastJoined time.Time `firestore:"last_joined"`
+	// Arenas already announced
+	Arenas []string `firestore:"arenas"`
+}
+
+func (s *Storage) GetTeam(ctx context.Context, guildID string) (*Team, error) {
+	doc, err := s.client.Collection(teamsCollection).Doc(guildID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get %s from %s", guildID, teamsCollection)
+	}
+	var t Team
+	if err := doc.DataTo(&t); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &t, nil
+}
+
+func (s *Storage) SetTeam(ctx context.Context, t *Team) error {
+	_, err := s.client.Collection(teamsCollection).Doc(t.GuildID).Set(ctx, t)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", t.GuildID, teamsCollection)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteTeam(ctx context.Context, guildID string) error {
+	_, err := s.client.Collection(teamsCollection).Doc(guildID).Delete(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", guildID, teamsCollection)
+	}
+	return nil
+}
+
+func (s *Storage) AllTeams(ctx context.Context) ([]Team, error) {
+	iter := s.client.Collection(teamsCollection).Documents(ctx)
+	defer iter.Stop()
+	res := make([]Team, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var t Team
+		if err := doc.DataTo(&t); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
@@ -0,0 +1,122 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	tokensCollection = "chess_tokens"
+	statesCollection = "chess_oauth_states"
+)
+
+// Token lichess OAuth token granted by the discord user
+type Token struct {
+	AccessToken string    `firestore:"access_token"`
+	Expiry      time.Time `firestore:"expiry"`
+	Scopes      []string  `firestore:"scopes"`
+}
+
+func (s *Storage) GetToken(ctx context.Context, userID string) (*Token, error) {
+	doc, err := s.client.Collection(tokensCollection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get %s from %s", userID, tokensCollection)
+	}
+	var t Token
+	if err := doc.DataTo(&t); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &t, nil
+}
+
+func (s *Storage) SetToken(ctx context.Context, userID string, t *Token) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetToken user:%s", userID)
+	_, err := s.client.Collection(tokensCollection).Doc(userID).Set(ctx, t)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", userID, tokensCollection)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteToken(ctx context.Context, userID string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteToken user:%s", userID)
+	_, err := s.client.Collection(tokensCollection).Doc(userID).Delete(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", userID, tokensCollection)
+	}
+	return nil
+}
+
+// OAuthState of the flow started in discord, kept until lichess redirects back to any of the instances
+type OAuthState struct {
+	UserID   string    `firestore:"user"`
+	Verifier string    `firestore:"verifier"`
+	Created  time.Time `firestore:"created"`
+}
+
+func (s *Storage) SetOAuthState(ctx context.Context, state string, st *OAuthState) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetOAuthState user:%s", st.UserID)
+	_, err := s.client.Collection(statesCollection).Doc(state).Set(ctx, st)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set state of %s to %s", st.UserID, statesCollection)
+	}
+	return nil
+}
+
+// TakeOAuthState deletes the state in the transaction, so it is used only once. ErrNotFound if it is unknown.
+func (s *Storage) TakeOAuthState(ctx context.Context, state string) (*OAuthState, error) {
+	ref := s.client.Collection(statesCollection).Doc(state)
+	var st OAuthState
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		if err := doc.DataTo(&st); err != nil {
+			return errors.Wrap(err, "failed to parse doc into struct")
+		}
+		return tx.Delete(ref)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to take state from %s", statesCollection)
+	}
+	return &st, nil
+}
+
+// DeleteOAuthStates of the flows started before the time and never completed
+func (s *Storage) DeleteOAuthStates(ctx context.Context, before time.Time) (int, error) {
+	iter := s.client.Collection(statesCollection).Where("created", "<", before).Documents(ctx)
+	defer iter.Stop()
+	n := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return n, errors.Wrapf(err, "failed to get expired states from %s", statesCollection)
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return n, errors.Wrapf(err, "failed to delete state from %s", statesCollection)
+		}
+		n++
+	}
+	if n > 0 {
+		contexts.LoggerFromContext(ctx).Infof("DB: DeleteOAuthStates %d", n)
+	}
+	return n, nil
+}
@@ -0,0 +1,49 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+)
+
+const tournamentsCollection = "chess_tournaments"
+
+// Tournament created by the bot, results are posted to ChannelID when it finishes
+type Tournament struct {
+	ID        string    `firestore:"id"`
+	Name      string    `firestore:"name"`
+	ChannelID string    `firestore:"channel_id"`
+	Created   time.Time `firestore:"created"`
+	Finished  bool      `firestore:"finished"`
+}
+
+func (s *Storage) SetTournament(ctx context.Context, t *Tournament) error {
+	_, err := s.client.Collection(tournamentsCollection).Doc(t.ID).Set(ctx, t)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", t.ID, tournamentsCollection)
+	}
+	return nil
+}
+
+func (s *Storage) ActiveTournaments(ctx context.Context) ([]Tournament, error) {
+	iter := s.client.Collection(tournamentsCollection).Where("finished", "==", false).Documents(ctx)
+	defer iter.Stop()
+	res := make([]Tournament, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var t Tournament
+		if err := doc.DataTo(&t); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
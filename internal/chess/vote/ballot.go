@@ -0,0 +1,80 @@
+package vote
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var uciRegexp = regexp.MustCompile(`^[a-h][1-8][a-h][1-8][qrbn]?$`)
+
+// IsUCI reports whether the move is written in UCI notation, e.g. e2e4
+func IsUCI(move string) bool {
+	return uciRegexp.MatchString(move)
+}
+
+type Candidate struct {
+	Move  string
+	Votes int
+}
+
+// Ballot one vote per user, the last vote of the user wins
+type Ballot struct {
+	mx    sync.Mutex
+	open  bool
+	votes map[string]string // user move
+}
+
+func NewBallot() *Ballot {
+	return &Ballot{
+		votes: make(map[string]string),
+	}
+}
+
+// Open clears previous votes and starts accepting new ones
+func (b *Ballot) Open() {
+	b.mx.Lock()
+	b.open = true
+	b.votes = make(map[string]string)
+	b.mx.Unlock()
+}
+
+func (b *Ballot) Close() {
+	b.mx.Lock()
+	b.open = false
+	b.mx.Unlock()
+}
+
+// Vote returns false if the voting is closed
+func (b *Ballot) Vote(userID, move string) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if !b.open {
+		return false
+	}
+	b.votes[userID] = strings.ToLower(move)
+	return true
+}
+
+// Results candidates sorted by the number of votes
+func (b *Ballot) Results() []Candidate {
+	b.mx.Lock()
+	counts := make(map[string]int)
+	for _, m := range b.votes {
+		counts[m]++
+	}
+	b.mx.Unlock()
+
+	res := make([]Candidate, 0, len(counts))
+	for m, c := range counts {
+		res = append(res, Candidate{Move: m, Votes: c})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Votes == res[j].Votes {
+			return res[i].Move < res[j].Move
+		}
+		return res[i].Votes > res[j].Votes
+	})
+	return res
+}
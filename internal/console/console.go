@@ -0,0 +1,114 @@
+package console
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Handler returns the text printed for the operator
+type Handler func(ctx context.Context, args []string) (string, error)
+
+type command struct {
+	usage   string
+	handler Handler
+}
+
+// Console of the admin commands typed on stdin, the subsystems add theirs while the bot is wired
+type Console struct {
+	mx       sync.RWMutex
+	commands map[string]command
+}
+
+func New() *Console {
+	return &Console{commands: make(map[string]command)}
+}
+
+// Add replaces the command with the same name, usage is shown by help
+func (c *Console) Add(name, usage string, h Handler) {
+	c.mx.Lock()
+	c.commands[name] = command{usage: usage, handler: h}
+	c.mx.Unlock()
+}
+
+// IsTerminal the console is started only for an operator, not when stdin is a pipe or /dev/null
+func IsTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Run reads the commands line by line until the input ends or the context is done
+func (c *Console) Run(ctx context.Context, in io.Reader, out io.Writer) {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if res := c.Execute(ctx, line); res != "" {
+				fmt.Fprintln(out, res)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Execute the line, the error is returned as the text
+func (c *Console) Execute(ctx context.Context, line string) string {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return ""
+	}
+	name := strings.ToLower(args[0])
+	if name == "help" {
+		return c.help()
+	}
+	c.mx.RLock()
+	cmd, ok := c.commands[name]
+	c.mx.RUnlock()
+	if !ok {
+		return fmt.Sprintf("unknown command %s, see help", name)
+	}
+	res, err := call(ctx, cmd.handler, args[1:])
+	if err != nil {
+		return errors.Wrap(err, name).Error()
+	}
+	return res
+}
+
+// call keeps the console reading when a command panics in the middle of an incident
+func call(ctx context.Context, h Handler, args []string) (res string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, args)
+}
+
+func (c *Console) help() string {
+	c.mx.RLock()
+	lines := make([]string, 0, len(c.commands)+1)
+	for name, cmd := range c.commands {
+		lines = append(lines, name+" "+cmd.usage)
+	}
+	c.mx.RUnlock()
+	sort.Strings(lines)
+	return strings.Join(append(lines, "help shows the commands"), "\n")
+}
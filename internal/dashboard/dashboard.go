@@ -0,0 +1,40 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// static the page talks to the api of the bot, so it is served as is
+//
+//go:embed static
+var static embed.FS
+
+// Handler of the built-in dashboard, a page over the music api and its events for the self-hosted bots
+type Handler struct {
+	assets http.FileSystem
+}
+
+func NewHandler() *Handler {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		// the directory is embedded, it is always there
+		panic(err)
+	}
+	return &Handler{assets: http.FS(sub)}
+}
+
+// Router the page is outside of the api, it logs in with the account link of the user
+func (h *Handler) Router(root *gin.RouterGroup) *gin.RouterGroup {
+	group := root.Group("/dashboard")
+	group.GET("", h.pageHandler)
+	group.StaticFS("/assets", h.assets)
+	return group
+}
+
+func (h *Handler) pageHandler(c *gin.Context) {
+	c.FileFromFS("/", h.assets)
+}
@@ -0,0 +1,170 @@
+"use strict";
+
+// the token of the account link, it is sent as the bearer of the api requests
+const tokenKey = "halvabot-token";
+const api = "/api/v1";
+let status = {};
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+async function request(method, path, body) {
+  const headers = {};
+  const token = localStorage.getItem(tokenKey);
+  if (token) {
+    headers["Authorization"] = "Bearer " + token;
+  }
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+  const resp = await fetch(api + path, {method, headers, body: body === undefined ? undefined : JSON.stringify(body)});
+  const text = await resp.text();
+  const data = text ? JSON.parse(text) : {};
+  if (!resp.ok) {
+    throw new Error(data.message || data.error || resp.statusText);
+  }
+  return data;
+}
+
+function songText(song) {
+  return song.artist_name ? song.title + " — " + song.artist_name : song.title;
+}
+
+function showError(id, err) {
+  $(id).textContent = err ? err.message : "";
+}
+
+async function refresh() {
+  try {
+    status = await request("GET", "/music/status");
+    const queue = await request("GET", "/music/queue");
+    render(queue.songs || []);
+    showError("error");
+  } catch (err) {
+    showError("error", err);
+  }
+}
+
+function render(songs) {
+  const now = $("now");
+  if (status.now && status.now.title) {
+    now.textContent = songText(status.now);
+    now.classList.remove("muted");
+  } else {
+    now.textContent = "Nothing is playing";
+    now.classList.add("muted");
+  }
+  const paused = Boolean(status.song && status.song.paused);
+  $("pause").textContent = paused ? "Resume" : "Pause";
+  $("pause").classList.toggle("on", paused);
+  $("loop").classList.toggle("on", status.loop);
+  $("radio").classList.toggle("on", status.radio);
+  $("queue-length").textContent = songs.length ? songs.length + " songs" : "empty";
+  const list = $("queue");
+  list.replaceChildren();
+  songs.forEach((song, pos) => {
+    const item = document.createElement("li");
+    item.textContent = songText(song);
+    const remove = document.createElement("button");
+    remove.textContent = "×";
+    remove.title = "Remove";
+    remove.onclick = () => control(() => request("DELETE", "/music/queue/" + pos));
+    item.appendChild(remove);
+    list.appendChild(item);
+  });
+}
+
+async function control(action) {
+  try {
+    await action();
+    showError("error");
+  } catch (err) {
+    showError("error", err);
+  }
+  refresh();
+}
+
+// listen the events of the player, every event refreshes the page and the socket reconnects when it drops
+function listen() {
+  const scheme = location.protocol === "https:" ? "wss://" : "ws://";
+  const socket = new WebSocket(scheme + location.host + api + "/music/events");
+  socket.onopen = () => {
+    $("connection").textContent = "live";
+    refresh();
+  };
+  socket.onmessage = () => refresh();
+  socket.onclose = () => {
+    $("connection").textContent = "offline";
+    setTimeout(listen, 5000);
+  };
+}
+
+function showDashboard() {
+  $("login").hidden = true;
+  $("dashboard").hidden = false;
+  listen();
+}
+
+function showLogin() {
+  $("dashboard").hidden = true;
+  $("login").hidden = false;
+}
+
+$("code-form").onsubmit = async (e) => {
+  e.preventDefault();
+  try {
+    await request("POST", "/accounts/code", {user_id: $("user-id").value.trim()});
+    $("link-form").hidden = false;
+    showError("login-error");
+  } catch (err) {
+    showError("login-error", err);
+  }
+};
+
+$("link-form").onsubmit = async (e) => {
+  e.preventDefault();
+  try {
+    const link = await request("POST", "/accounts/link", {
+      user_id: $("user-id").value.trim(),
+      code: $("code").value.trim(),
+      name: "Dashboard",
+    });
+    localStorage.setItem(tokenKey, link.token);
+    showDashboard();
+  } catch (err) {
+    showError("login-error", err);
+  }
+};
+
+$("logout").onclick = () => {
+  localStorage.removeItem(tokenKey);
+  location.reload();
+};
+
+$("pause").onclick = () => control(() => request("POST", status.song && status.song.paused ? "/music/resume" : "/music/pause"));
+$("skip").onclick = () => control(() => request("GET", "/music/skip"));
+$("shuffle").onclick = () => control(() => request("POST", "/music/shuffle"));
+$("loop").onclick = () => control(() => request("POST", "/music/setloop", {enable: !status.loop}));
+$("radio").onclick = () => control(() => request("POST", "/music/setradio", {enable: !status.radio}));
+$("enqueue-form").onsubmit = (e) => {
+  e.preventDefault();
+  const song = $("song").value.trim();
+  $("song").value = "";
+  control(() => request("POST", "/music/enqueue", {song}));
+};
+
+// the stored token is checked once, an unlinked one asks to log in again
+(async () => {
+  if (!localStorage.getItem(tokenKey)) {
+    showLogin();
+    return;
+  }
+  try {
+    await request("GET", "/accounts/me");
+    showDashboard();
+  } catch (err) {
+    localStorage.removeItem(tokenKey);
+    showLogin();
+  }
+})();
@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/export"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+)
+
+// callback godoc
+// @summary  The music service redirects here after the user granted access, the playlist is created then
+// @produce  plain
+// @param    service  path      string  true  "youtube or spotify"
+// @param    code     query     string  true  "Authorization code"
+// @param    state    query     string  true  "State of the export started in discord"
+// @success  200      string    string
+// @failure  400      string    string  "Unknown or expired state"
+// @failure  404      string    string  "The playlist was deleted"
+// @failure  500      string    string  "Export failed"
+// @router   /export/{service}/callback [get]
+func (h *Handler) callbackHandler(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		c.String(http.StatusBadRequest, "Authorization failed: "+e)
+		return
+	}
+	res, err := h.exporter.Complete(c.Request.Context(), c.Param("service"), c.Query("state"), c.Query("code"))
+	switch {
+	case errors.Is(err, export.ErrUnknownState):
+		c.String(http.StatusBadRequest, "Export link expired, request a new one in discord")
+		return
+	case errors.Is(err, library.ErrNotFound):
+		c.String(http.StatusNotFound, "The playlist was deleted")
+		return
+	case err != nil:
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	msg := fmt.Sprintf("Exported %d songs: %s", res.Exported, res.URL)
+	if res.Skipped != 0 {
+		msg += fmt.Sprintf("\n%d songs weren't found", res.Skipped)
+	}
+	c.String(http.StatusOK, msg)
+}
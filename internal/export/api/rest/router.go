@@ -0,0 +1,31 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/export"
+)
+
+type Exporter interface {
+	Complete(ctx context.Context, service, state, code string) (*export.Result, error)
+}
+
+type Handler struct {
+	exporter Exporter
+	super    *gin.RouterGroup
+}
+
+func NewHandler(exporter Exporter, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		exporter: exporter,
+		super:    superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/export")
+	group.GET("/:service/callback", h.callbackHandler)
+	return group
+}
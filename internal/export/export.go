@@ -0,0 +1,152 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+)
+
+const (
+	stateExpiration = 10 * time.Minute
+	// exportTimeout the songs are added one by one, a long playlist takes a while
+	exportTimeout = 3 * time.Minute
+)
+
+var (
+	ErrUnknownService = errors.New("the playlists can't be exported there")
+	ErrUnknownState   = errors.New("unknown or expired oauth state")
+	ErrEmpty          = errors.New("the playlist is empty")
+)
+
+// Provider of a music service, the token of the user is used once and isn't kept
+type Provider interface {
+	AuthCodeURL(state string) string
+	Exchange(ctx context.Context, code string) (*oauth2.Token, error)
+	Export(ctx context.Context, token *oauth2.Token, p *library.Playlist) (*Result, error)
+}
+
+type Library interface {
+	Playlist(ctx context.Context, userID, name string) (*library.Playlist, error)
+}
+
+// Result of an export, the songs the service doesn't have are skipped
+type Result struct {
+	URL      string
+	Exported int
+	Skipped  int
+}
+
+type pending struct {
+	service string
+	userID  string
+	name    string
+	created time.Time
+}
+
+// Service exports the playlists of the users to their accounts on the music services
+type Service struct {
+	library   Library
+	providers map[string]Provider
+
+	mx      sync.Mutex
+	pending map[string]pending // state
+}
+
+func NewService(library Library) *Service {
+	return &Service{
+		library:   library,
+		providers: make(map[string]Provider),
+		pending:   make(map[string]pending),
+	}
+}
+
+// Add the provider of the service, the name is the one in the commands and the callback url
+func (s *Service) Add(service string, p Provider) {
+	s.providers[service] = p
+}
+
+// Services the playlists can be exported to
+func (s *Service) Services() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthURL starts the export of the playlist, the user grants the access to the account on the page
+func (s *Service) AuthURL(ctx context.Context, userID, name, service string) (string, error) {
+	p, ok := s.providers[strings.ToLower(service)]
+	if !ok {
+		return "", ErrUnknownService
+	}
+	playlist, err := s.library.Playlist(ctx, userID, name)
+	if err != nil {
+		return "", err
+	}
+	if len(playlist.Songs) == 0 {
+		return "", ErrEmpty
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate state")
+	}
+	state := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	s.mx.Lock()
+	for k, v := range s.pending {
+		if now.Sub(v.created) > stateExpiration {
+			delete(s.pending, k)
+		}
+	}
+	s.pending[state] = pending{
+		service: strings.ToLower(service),
+		userID:  userID,
+		name:    name,
+		created: now,
+	}
+	s.mx.Unlock()
+	return p.AuthCodeURL(state), nil
+}
+
+// Complete exchanges the code from the redirect of the service and creates the playlist there
+func (s *Service) Complete(ctx context.Context, service, state, code string) (*Result, error) {
+	s.mx.Lock()
+	pend, ok := s.pending[state]
+	delete(s.pending, state)
+	s.mx.Unlock()
+	if !ok || pend.service != service || time.Since(pend.created) > stateExpiration {
+		return nil, ErrUnknownState
+	}
+	p := s.providers[service]
+
+	token, err := p.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	playlist, err := s.library.Playlist(ctx, pend.userID, pend.name)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+	res, err := p.Export(ctx, token, playlist)
+	if err != nil {
+		exports.WithLabelValues(service, "error").Inc()
+		return nil, errors.Wrapf(err, "export to %s", service)
+	}
+	exports.WithLabelValues(service, "ok").Inc()
+	exportedSongs.WithLabelValues(service).Add(float64(res.Exported))
+	return res, nil
+}
@@ -0,0 +1,21 @@
+package export
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	exports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "export",
+		Name:      "playlists_total",
+		Help:      "Playlists exported to the music services by the result.",
+	}, []string{"service", "result"})
+	exportedSongs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "export",
+		Name:      "songs_total",
+		Help:      "Songs added to the exported playlists.",
+	}, []string{"service"})
+)
@@ -0,0 +1,159 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+)
+
+const (
+	spotifyAPI = "https://api.spotify.com/v1"
+	// maxSpotifyTracks in one call of adding the tracks
+	maxSpotifyTracks = 100
+)
+
+// noiseRe the parts of the video titles which only get in the way of the search
+var noiseRe = regexp.MustCompile(`(?i)\([^)]*\)|\[[^\]]*]|official\s+(music\s+)?video|lyrics?|\bhd\b|\b4k\b`)
+
+// Spotify creates private playlists, the songs are searched by their titles and the first track is taken
+type Spotify struct {
+	config oauth2.Config
+}
+
+func NewSpotify(clientID, clientSecret, redirectURL string) *Spotify {
+	return &Spotify{
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"playlist-modify-private"},
+			Endpoint:     endpoints.Spotify,
+		},
+	}
+}
+
+func (s *Spotify) AuthCodeURL(state string) string {
+	return s.config.AuthCodeURL(state)
+}
+
+func (s *Spotify) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	token, err := s.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "exchange spotify code")
+	}
+	return token, nil
+}
+
+// Export the songs without a found track are skipped
+func (s *Spotify) Export(ctx context.Context, token *oauth2.Token, p *library.Playlist) (*Result, error) {
+	client := s.config.Client(ctx, token)
+	var me struct {
+		ID string `json:"id"`
+	}
+	if err := spotifyDo(ctx, client, http.MethodGet, spotifyAPI+"/me", nil, &me); err != nil {
+		return nil, errors.Wrap(err, "get user")
+	}
+
+	res := &Result{}
+	uris := make([]string, 0, len(p.Songs))
+	for i := range p.Songs {
+		uri, err := s.search(ctx, client, &p.Songs[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "search %s", p.Songs[i].Title)
+		}
+		if uri == "" {
+			res.Skipped++
+			continue
+		}
+		uris = append(uris, uri)
+	}
+
+	var created struct {
+		ID           string `json:"id"`
+		ExternalURLs struct {
+			Spotify string `json:"spotify"`
+		} `json:"external_urls"`
+	}
+	body := map[string]interface{}{"name": p.Name, "public": false}
+	if err := spotifyDo(ctx, client, http.MethodPost, spotifyAPI+"/users/"+url.PathEscape(me.ID)+"/playlists", body, &created); err != nil {
+		return nil, errors.Wrap(err, "create playlist")
+	}
+	res.URL = created.ExternalURLs.Spotify
+	for start := 0; start < len(uris); start += maxSpotifyTracks {
+		end := start + maxSpotifyTracks
+		if end > len(uris) {
+			end = len(uris)
+		}
+		body := map[string]interface{}{"uris": uris[start:end]}
+		if err := spotifyDo(ctx, client, http.MethodPost, spotifyAPI+"/playlists/"+created.ID+"/tracks", body, nil); err != nil {
+			return nil, errors.Wrap(err, "add tracks")
+		}
+		res.Exported += end - start
+	}
+	return res, nil
+}
+
+// search the uri of the first track, empty if nothing is found
+func (s *Spotify) search(ctx context.Context, client *http.Client, e *library.Entry) (string, error) {
+	q := strings.Join(strings.Fields(noiseRe.ReplaceAllString(e.Title, " ")), " ")
+	if q == "" {
+		return "", nil
+	}
+	var found struct {
+		Tracks struct {
+			Items []struct {
+				URI string `json:"uri"`
+			} `json:"items"`
+		} `json:"tracks"`
+	}
+	query := url.Values{"q": {q}, "type": {"track"}, "limit": {"1"}}
+	if err := spotifyDo(ctx, client, http.MethodGet, spotifyAPI+"/search?"+query.Encode(), nil, &found); err != nil {
+		return "", err
+	}
+	if len(found.Tracks.Items) == 0 {
+		return "", nil
+	}
+	return found.Tracks.Items[0].URI, nil
+}
+
+func spotifyDo(ctx context.Context, client *http.Client, method, u string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "marshal body")
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return errors.Wrap(err, "new request")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "do request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("status %d: %s", resp.StatusCode, msg)
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(out), "decode response")
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const youtubePlaylistURL = "https://www.youtube.com/playlist?list="
+
+// YouTube creates private playlists, every added video costs 50 units of the quota of the project of the client
+type YouTube struct {
+	config oauth2.Config
+}
+
+func NewYouTube(clientID, clientSecret, redirectURL string) *YouTube {
+	return &YouTube{
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{youtube.YoutubeScope},
+			Endpoint:     google.Endpoint,
+		},
+	}
+}
+
+func (y *YouTube) AuthCodeURL(state string) string {
+	return y.config.AuthCodeURL(state)
+}
+
+func (y *YouTube) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	token, err := y.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "exchange google code")
+	}
+	return token, nil
+}
+
+// Export the songs of other services and the removed videos are skipped
+func (y *YouTube) Export(ctx context.Context, token *oauth2.Token, p *library.Playlist) (*Result, error) {
+	service, err := youtube.NewService(ctx, option.WithTokenSource(y.config.TokenSource(ctx, token)))
+	if err != nil {
+		return nil, errors.Wrap(err, "youtube service")
+	}
+	created, err := service.Playlists.Insert([]string{"snippet", "status"}, &youtube.Playlist{
+		Snippet: &youtube.PlaylistSnippet{Title: p.Name},
+		Status:  &youtube.PlaylistStatus{PrivacyStatus: "private"},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "create playlist")
+	}
+	res := &Result{URL: youtubePlaylistURL + created.Id}
+	prefix := string(pkg.ServiceYouTube) + "_"
+	for i := range p.Songs {
+		if !strings.HasPrefix(p.Songs[i].ID, prefix) {
+			res.Skipped++
+			continue
+		}
+		_, err := service.PlaylistItems.Insert([]string{"snippet"}, &youtube.PlaylistItem{
+			Snippet: &youtube.PlaylistItemSnippet{
+				PlaylistId: created.Id,
+				ResourceId: &youtube.ResourceId{Kind: "youtube#video", VideoId: strings.TrimPrefix(p.Songs[i].ID, prefix)},
+			},
+		}).Context(ctx).Do()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, errors.Wrap(ctx.Err(), "add videos")
+			}
+			contexts.LoggerFromContext(ctx).Warnw("video isn't added", "id", p.Songs[i].ID, "err", err)
+			res.Skipped++
+			continue
+		}
+		res.Exported++
+	}
+	return res, nil
+}
@@ -0,0 +1,209 @@
+package failover
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	Primary = "primary"
+	Standby = "standby"
+
+	beatTimeout = 5 * time.Second
+)
+
+// ErrStandby the other bot serves the guilds now
+var ErrStandby = errors.New("the other bot serves the guilds")
+
+// Beat of a bot, the bot is dead if it didn't beat for the timeout
+type Beat struct {
+	Time time.Time `firestore:"time" json:"time"`
+	// Active the bot serves the guilds
+	Active bool `firestore:"active" json:"active"`
+}
+
+type Storage interface {
+	SetBeat(ctx context.Context, role string, beat Beat) error
+	// Beat of the role, nil if it never beat
+	Beat(ctx context.Context, role string) (*Beat, error)
+}
+
+// Handler is called from the goroutine of the monitor, the next check waits for it
+type Handler func(ctx context.Context)
+
+// Monitor runs in both bots. The primary serves the guilds unless the standby does,
+// the standby serves them while the primary doesn't beat and hands them back when it beats again.
+type Monitor struct {
+	storage  Storage
+	role     string
+	interval time.Duration
+	timeout  time.Duration
+	logger   zap.Logger
+
+	mx         sync.Mutex
+	active     bool
+	onTakeover []Handler
+	onHandback []Handler
+}
+
+func NewMonitor(storage Storage, role string, interval, timeout time.Duration, logger zap.Logger) *Monitor {
+	return &Monitor{
+		storage:  storage,
+		role:     role,
+		interval: interval,
+		timeout:  timeout,
+		logger:   logger,
+	}
+}
+
+func (m *Monitor) Role() string {
+	return m.role
+}
+
+// Active the bot serves the guilds
+func (m *Monitor) Active() bool {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.active
+}
+
+// Acquire lets only the active bot run the scheduled jobs
+func (m *Monitor) Acquire(_ context.Context, _ string) (bool, error) {
+	if !m.Active() {
+		return false, ErrStandby
+	}
+	return false, nil
+}
+
+// OnTakeover is called when the bot starts to serve: the standby after the primary died,
+// the primary after the standby handed the guilds back
+func (m *Monitor) OnTakeover(h Handler) {
+	m.onTakeover = append(m.onTakeover, h)
+}
+
+// OnHandback is called in the standby before the primary serves again, the queue has to be saved by then
+func (m *Monitor) OnHandback(h Handler) {
+	m.onHandback = append(m.onHandback, h)
+}
+
+// Start the first check of the primary decides whether it serves from the start, without the handlers
+func (m *Monitor) Start(ctx context.Context) {
+	ctx = contexts.WithLogger(ctx, m.logger)
+	if m.role == Primary {
+		m.checkPrimary(ctx, true)
+	}
+	supervisor.Go(ctx, m.logger, "failover", func(ctx context.Context) {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if m.role == Primary {
+					m.checkPrimary(ctx, false)
+				} else {
+					m.checkStandby(ctx)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// Stop the standby tells the primary to serve again, the queue is saved by the player before
+func (m *Monitor) Stop(ctx context.Context) error {
+	if m.role != Standby || !m.Active() {
+		return nil
+	}
+	m.setActive(false)
+	return m.storage.SetBeat(ctx, Standby, Beat{Time: time.Now(), Active: false})
+}
+
+func (m *Monitor) checkPrimary(ctx context.Context, first bool) {
+	standby, err := m.beat(ctx, Standby)
+	if err != nil {
+		m.logger.Error(errors.Wrap(err, "standby beat"))
+		if first {
+			m.setActive(true)
+		}
+		return
+	}
+	active := !(m.alive(standby) && standby.Active)
+	changed := m.setActive(active)
+	m.setBeat(ctx, Primary, active)
+	if changed && active && !first {
+		m.logger.Infow("the standby handed the guilds back")
+		m.call(ctx, m.onTakeover)
+	}
+}
+
+func (m *Monitor) checkStandby(ctx context.Context) {
+	primary, err := m.beat(ctx, Primary)
+	if err != nil {
+		// the storage is down, not the primary
+		m.logger.Error(errors.Wrap(err, "primary beat"))
+		return
+	}
+	alive := m.alive(primary)
+	switch active := m.Active(); {
+	case active && alive:
+		m.logger.Infow("the primary is back, handing the guilds back")
+		m.call(ctx, m.onHandback)
+		m.setActive(false)
+		m.setBeat(ctx, Standby, false)
+	case !active && !alive:
+		m.logger.Warnw("the primary doesn't beat, taking over")
+		takeovers.Inc()
+		m.setActive(true)
+		m.setBeat(ctx, Standby, true)
+		m.call(ctx, m.onTakeover)
+	default:
+		m.setBeat(ctx, Standby, active)
+	}
+}
+
+func (m *Monitor) alive(beat *Beat) bool {
+	return beat != nil && time.Since(beat.Time) < m.timeout
+}
+
+func (m *Monitor) beat(ctx context.Context, role string) (*Beat, error) {
+	ctx, cancel := context.WithTimeout(ctx, beatTimeout)
+	defer cancel()
+	return m.storage.Beat(ctx, role)
+}
+
+func (m *Monitor) setBeat(ctx context.Context, role string, active bool) {
+	ctx, cancel := context.WithTimeout(ctx, beatTimeout)
+	defer cancel()
+	if err := m.storage.SetBeat(ctx, role, Beat{Time: time.Now(), Active: active}); err != nil {
+		m.logger.Error(errors.Wrap(err, "beat"))
+	}
+}
+
+// setActive returns true if it changed
+func (m *Monitor) setActive(active bool) bool {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if active {
+		activeGauge.Set(1)
+	} else {
+		activeGauge.Set(0)
+	}
+	changed := m.active != active
+	m.active = active
+	return changed
+}
+
+func (m *Monitor) call(ctx context.Context, handlers []Handler) {
+	for _, h := range handlers {
+		h := h
+		supervisor.Safe(m.logger, "failover handler", func() { h(ctx) })
+	}
+}
@@ -0,0 +1,21 @@
+package failover
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	activeGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "failover",
+		Name:      "active",
+		Help:      "1 if the bot serves the guilds, 0 while the other one does.",
+	})
+	takeovers = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "failover",
+		Name:      "takeovers_total",
+		Help:      "Times the standby took over from the dead primary.",
+	})
+)
@@ -0,0 +1,47 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/failover"
+)
+
+const heartbeatsCollection = "heartbeats"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+// SetBeat isn't logged, it is written every few seconds
+func (s *Storage) SetBeat(ctx context.Context, role string, beat failover.Beat) error {
+	if _, err := s.client.Collection(heartbeatsCollection).Doc(role).Set(ctx, beat); err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", role, heartbeatsCollection)
+	}
+	return nil
+}
+
+func (s *Storage) Beat(ctx context.Context, role string) (*failover.Beat, error) {
+	doc, err := s.client.Collection(heartbeatsCollection).Doc(role).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s from %s", role, heartbeatsCollection)
+	}
+	var beat failover.Beat
+	if err := doc.DataTo(&beat); err != nil {
+		return nil, errors.Wrap(err, "unable to marshal data")
+	}
+	return &beat, nil
+}
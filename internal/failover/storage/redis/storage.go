@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/failover"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/redis"
+)
+
+// Storage keeps the beats as JSON in <prefix>:heartbeat:<role>
+type Storage struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewStorage(client *redis.Client, prefix string) *Storage {
+	return &Storage{
+		client: client,
+		prefix: prefix + ":heartbeat:",
+	}
+}
+
+func (s *Storage) SetBeat(ctx context.Context, role string, beat failover.Beat) error {
+	data, err := json.Marshal(beat)
+	if err != nil {
+		return errors.Wrap(err, "encode beat")
+	}
+	if _, err := s.client.Do(ctx, "SET", s.prefix+role, string(data)); err != nil {
+		return errors.Wrapf(err, "set beat of %s", role)
+	}
+	return nil
+}
+
+func (s *Storage) Beat(ctx context.Context, role string) (*failover.Beat, error) {
+	data, err := s.client.Bytes(ctx, "GET", s.prefix+role)
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get beat of %s", role)
+	}
+	var beat failover.Beat
+	if err := json.Unmarshal(data, &beat); err != nil {
+		return nil, errors.Wrap(err, "decode beat")
+	}
+	return &beat, nil
+}
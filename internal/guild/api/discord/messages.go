@@ -0,0 +1,246 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	messageNoPermission = ":x: **Only server managers can change the settings**"
+	messageInvalidValue = ":x: **%s**"
+	messageUnavailable  = ":x: **The settings can't be changed right now, try again later**"
+	messageUsage        = "`%[1]ssettings` show the settings\n" +
+		"`%[1]ssettings prefix <prefix>` command prefix\n" +
+		"`%[1]ssettings dj <@role>` role that controls the player\n" +
+		"`%[1]ssettings announce <#channel>` channel for the bot announcements\n" +
+		"`%[1]ssettings volume <1-200>` volume in percent\n" +
+		"`%[1]ssettings maxqueue <songs>` queue limit\n" +
+		"`%[1]ssettings maxduration <minutes>` longer songs need a DJ to confirm them\n" +
+		"`%[1]ssettings dailyrequests <songs>` songs a member who isn't a DJ requests per day\n" +
+		"`%[1]ssettings skipvotes <1-100>` percent of the listeners who vote to skip a song, 50 by default\n" +
+		"`%[1]ssettings autoradio <on|off>` start radio when the queue ends\n" +
+		"`%[1]ssettings radiochannel <#voice channel>` join with the radio when the channel has listeners and nothing plays\n" +
+		"`%[1]ssettings radiolisteners <1-99>` listeners in the radio channel who start it, 2 by default\n" +
+		"`%[1]ssettings safesearch <on|off>` skip the age restricted songs\n" +
+		"`%[1]ssettings block <link|word>` block the song or the titles with the word, `block off` clears the list\n" +
+		"`%[1]ssettings unblock <link|word>` remove from the blocklist\n" +
+		"`%[1]ssettings alarms <#voice channel>` channel the scheduled music plays in\n" +
+		"`%[1]ssettings timezone <Area/City>` timezone of the schedules, UTC by default\n" +
+		"`off` resets any setting to the default"
+	messageFeaturesUsage = "`%[1]sfeatures` show the experimental features\n" +
+		"`%[1]sfeatures <feature> <on|off|default>` turn the feature on or off for the server"
+	messageRestrictUsage = "`%[1]srestrict` show where the commands work\n" +
+		"`%[1]srestrict [music|chess] <#channel> [#channel...]` allow the commands only in the channels, all groups if omitted\n" +
+		"`%[1]srestrict [music|chess] off` allow the commands everywhere"
+	messageRestricted        = "<@%s> **the %s commands work in** %s"
+	messageNoAuditPermission = ":x: **Only server managers can see the audit log**"
+	messageNoAuditEntries    = "**No commands found**"
+	messageAuditUsage        = "`%[1]saudit [@user] [command] [1-%[2]d]` show the last executed commands"
+	// maxAuditArgs the long queries are cut
+	maxAuditArgs = 40
+	// maxBlocklistField the embed field takes up to 1024 characters
+	maxBlocklistField = 1000
+
+	messageQuickStartTitle = "Thanks for adding me to %s"
+	messageQuickStart      = "`%[1]splay <song or link>` join your voice channel and play the song, `%[1]sskip` the next one\n" +
+		"`%[1]sradio` play the songs of the server when the queue ends\n" +
+		"`%[1]ssettings` the prefix, the DJ role, the volume and the limits of the server\n" +
+		"`%[1]srestrict music <#channel>` keep the music commands in one channel\n" +
+		"`%[1]sfeatures` the experimental features\n" +
+		"`/start` shows this message again, `/settings` the settings of the server"
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, m *discordgo.MessageCreate, msg string) {
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{Content: msg})
+}
+
+func (s *Service) sendUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageUsage, s.prefix))
+}
+
+func (s *Service) sendFeaturesUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageFeaturesUsage, s.prefix))
+}
+
+func (s *Service) sendRestrictUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageRestrictUsage, s.prefix))
+}
+
+func (s *Service) sendRestrictMessage(ds *discordgo.Session, m *discordgo.MessageCreate, g guild.Settings) {
+	fields := make([]*discordgo.MessageEmbedField, 0, len(guild.Groups))
+	for _, group := range guild.Groups {
+		channels := "everywhere"
+		if ids := g.Channels[string(group)]; len(ids) != 0 {
+			channels = mentionChannels(ids)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: string(group), Value: channels, Inline: true})
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:  "Command channels",
+				Fields: fields,
+			},
+		},
+	})
+}
+
+func mentionChannels(ids []string) string {
+	mentions := make([]string, 0, len(ids))
+	for _, id := range ids {
+		mentions = append(mentions, "<#"+id+">")
+	}
+	return strings.Join(mentions, " ")
+}
+
+func (s *Service) sendAuditUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageAuditUsage, s.prefix, maxAuditEntries))
+}
+
+func (s *Service) sendAuditMessage(ds *discordgo.Session, m *discordgo.MessageCreate, entries []audit.Entry) {
+	if len(entries) == 0 {
+		s.sendStringMessage(ds, m, messageNoAuditEntries)
+		return
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		args := e.Args
+		if len([]rune(args)) > maxAuditArgs {
+			args = string([]rune(args)[:maxAuditArgs]) + "…"
+		}
+		line := strings.TrimSpace(e.Command + " " + args)
+		fmt.Fprintf(&b, "<t:%d:R> <@%s> `%s`", e.Time.Unix(), e.UserID, strings.ReplaceAll(line, "`", "'"))
+		if e.Outcome != command.OutcomeOK {
+			b.WriteString(" " + e.Outcome)
+		}
+		b.WriteString("\n")
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Audit log",
+				Description: b.String(),
+			},
+		},
+	})
+}
+
+func (s *Service) sendFeaturesMessage(ds *discordgo.Session, m *discordgo.MessageCreate, g guild.Settings) {
+	fields := make([]*discordgo.MessageEmbedField, 0, len(guild.Flags))
+	for _, f := range guild.Flags {
+		state := off
+		if g.Features[string(f)] {
+			state = on
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: string(f), Value: state, Inline: true})
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:  "Experimental features",
+				Fields: fields,
+			},
+		},
+	})
+}
+
+func (s *Service) sendSettingsMessage(ds *discordgo.Session, m *discordgo.MessageCreate, g guild.Settings) {
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{settingsEmbed(g)}})
+}
+
+func settingsEmbed(g guild.Settings) *discordgo.MessageEmbed {
+	orNone := func(v string) string {
+		if v == "" {
+			return "-"
+		}
+		return v
+	}
+	dj, channel := "", ""
+	if g.DJRole != "" {
+		dj = "<@&" + g.DJRole + ">"
+	}
+	if g.AnnounceChannel != "" {
+		channel = "<#" + g.AnnounceChannel + ">"
+	}
+	maxQueue := "unlimited"
+	if g.Limits.MaxQueue > 0 {
+		maxQueue = fmt.Sprintf("%d songs", g.Limits.MaxQueue)
+	}
+	maxDuration := "unlimited"
+	if g.Limits.MaxDuration > 0 {
+		maxDuration = fmt.Sprintf("%d min", g.Limits.MaxDuration)
+	}
+	dailyRequests := "unlimited"
+	if g.Limits.DailyRequests > 0 {
+		dailyRequests = fmt.Sprintf("%d songs", g.Limits.DailyRequests)
+	}
+	autoRadio := off
+	if g.Radio.AutoStart {
+		autoRadio = on
+	}
+	safeSearch := off
+	if g.SafeSearch {
+		safeSearch = on
+	}
+	radioChannel := ""
+	if g.Radio.Channel != "" {
+		radioChannel = fmt.Sprintf("<#%s>, %d+ listeners", g.Radio.Channel, g.Radio.MinListeners())
+	}
+	alarmChannel := ""
+	if g.AlarmChannel != "" {
+		alarmChannel = "<#" + g.AlarmChannel + ">"
+	}
+	zone := g.Timezone
+	if zone == "" {
+		zone = "UTC"
+	}
+	blocklist := strings.Join(g.Blocklist, ", ")
+	if len([]rune(blocklist)) > maxBlocklistField {
+		blocklist = string([]rune(blocklist)[:maxBlocklistField]) + "…"
+	}
+	return &discordgo.MessageEmbed{
+		Title: "Server settings",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Prefix", Value: "`" + g.Prefix + "`", Inline: true},
+			{Name: "DJ role", Value: orNone(dj), Inline: true},
+			{Name: "Announcements", Value: orNone(channel), Inline: true},
+			{Name: "Volume", Value: fmt.Sprintf("%d%%", g.Volume), Inline: true},
+			{Name: "Queue limit", Value: maxQueue, Inline: true},
+			{Name: "Song limit", Value: maxDuration, Inline: true},
+			{Name: "Daily requests", Value: dailyRequests, Inline: true},
+			{Name: "Vote skip", Value: fmt.Sprintf("%d%% of listeners", g.Limits.SkipPercent()), Inline: true},
+			{Name: "Auto radio", Value: autoRadio, Inline: true},
+			{Name: "Safe search", Value: safeSearch, Inline: true},
+			{Name: "Radio channel", Value: orNone(radioChannel), Inline: true},
+			{Name: "Alarms", Value: orNone(alarmChannel), Inline: true},
+			{Name: "Timezone", Value: zone, Inline: true},
+			{Name: "Blocklist", Value: orNone(blocklist)},
+		},
+	}
+}
+
+// quickStartEmbed the prefix is the one of the guild
+func quickStartEmbed(guildName, prefix string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf(messageQuickStartTitle, guildName),
+		Description: fmt.Sprintf(messageQuickStart, prefix),
+	}
+}
@@ -0,0 +1,101 @@
+package discord
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+)
+
+const (
+	// onboardWindow the guilds are created on every connect, only the ones joined recently are new
+	onboardWindow  = 10 * time.Minute
+	onboardTimeout = 30 * time.Second
+)
+
+var (
+	startCommand    = &discordgo.ApplicationCommand{Name: "start", Description: "How to use the bot"}
+	settingsCommand = &discordgo.ApplicationCommand{Name: "settings", Description: "Settings of the server"}
+)
+
+// guildCreateHandler onboards the new guild: the settings document, the slash commands and the quick start for the inviter
+func (s *Service) guildCreateHandler(ds *discordgo.Session, g *discordgo.GuildCreate) {
+	if g.Unavailable || time.Since(g.JoinedAt) > onboardWindow || !command.Handles(g.ID) {
+		return
+	}
+	stored, err := s.settings.Stored(g.ID)
+	if err != nil || stored {
+		return
+	}
+	go supervisor.Safe(s.logger, "guild onboarding", func() {
+		s.onboard(ds, g.Guild)
+	})
+}
+
+func (s *Service) onboard(ds *discordgo.Session, g *discordgo.Guild) {
+	ctx, cancel := context.WithTimeout(s.ctx, onboardTimeout)
+	defer cancel()
+	s.logger.Infow("onboarding guild",
+		"guild", g.ID,
+		"name", g.Name)
+	if _, err := s.settings.Update(ctx, g.ID, func(settings *guild.Settings) {
+		settings.Joined = g.JoinedAt
+	}); err != nil {
+		s.logger.Error(errors.Wrapf(err, "create settings of %s", g.ID))
+	}
+	if err := command.RegisterGuildCommands(ds, g.ID); err != nil {
+		s.logger.Error(err)
+	}
+	channel, err := ds.UserChannelCreate(s.inviter(ds, g))
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "open DM for the quick start of %s", g.ID))
+		return
+	}
+	s.sendComplexMessage(ds, channel.ID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{quickStartEmbed(g.Name, s.settings.Get(g.ID).Prefix)},
+	})
+}
+
+// inviter is found in the audit log of the guild, the owner gets the message without the permission to read it
+func (s *Service) inviter(ds *discordgo.Session, g *discordgo.Guild) string {
+	log, err := ds.GuildAuditLog(g.ID, "", "", int(discordgo.AuditLogActionBotAdd), 10)
+	if err != nil {
+		return g.OwnerID
+	}
+	for _, e := range log.AuditLogEntries {
+		if e.TargetID == ds.State.User.ID && e.UserID != "" {
+			return e.UserID
+		}
+	}
+	return g.OwnerID
+}
+
+func (s *Service) startSlashHandler(ds *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := i.GuildID
+	if g, err := ds.State.Guild(i.GuildID); err == nil {
+		name = g.Name
+	}
+	s.respondEmbed(ds, i, quickStartEmbed(name, s.settings.Get(i.GuildID).Prefix))
+}
+
+func (s *Service) settingsSlashHandler(ds *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.respondEmbed(ds, i, settingsEmbed(s.settings.Get(i.GuildID)))
+}
+
+func (s *Service) respondEmbed(ds *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	err := ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  uint64(discordgo.MessageFlagsEphemeral),
+		},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to slash command"))
+	}
+}
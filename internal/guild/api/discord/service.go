@@ -0,0 +1,470 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/audit"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "settings"
+
+const (
+	settings = "settings"
+	features = "features"
+	auditLog = "audit"
+	restrict = "restrict"
+
+	prefix    = "prefix"
+	dj        = "dj"
+	announce  = "announce"
+	volume    = "volume"
+	maxQueue  = "maxqueue"
+	maxLength = "maxduration"
+	daily     = "dailyrequests"
+	skipVotes = "skipvotes"
+	autoRadio = "autoradio"
+	radioChan = "radiochannel"
+	listeners = "radiolisteners"
+	safe      = "safesearch"
+	block     = "block"
+	unblock   = "unblock"
+	alarms    = "alarms"
+	timezone  = "timezone"
+	off       = "off"
+	on        = "on"
+	// reset a feature to the config value
+	reset = "default"
+
+	maxPrefixLength = guild.MaxPrefixLength
+	maxVolume       = guild.MaxVolume
+	maxBlocklist    = 100
+	maxListeners    = guild.MaxRadioListeners
+	maxSkipVotes    = guild.MaxSkipVotes
+	// maxAuditEntries fit into one message
+	maxAuditEntries = 25
+)
+
+type Settings interface {
+	Get(guildID string) guild.Settings
+	Stored(guildID string) (bool, error)
+	Update(ctx context.Context, guildID string, update func(*guild.Settings)) (guild.Settings, error)
+}
+
+type Audit interface {
+	Entries(ctx context.Context, q audit.Query) ([]audit.Entry, error)
+}
+
+type Service struct {
+	ctx      context.Context
+	settings Settings
+	audit    Audit
+	prefix   string
+	logger   zap.Logger
+}
+
+func NewCog(ctx context.Context, settings Settings, audit Audit, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:      ctx,
+		settings: settings,
+		audit:    audit,
+		prefix:   prefix,
+		logger:   logger,
+	}
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+settings, s.settingsMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewMessageCommand(s.prefix+features, s.featuresMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewMessageCommand(s.prefix+auditLog, s.auditMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewMessageCommand(s.prefix+restrict, s.restrictMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewGuildSlashCommand(startCommand, s.startSlashHandler).RegisterCommand(session, logger)
+	command.NewGuildSlashCommand(settingsCommand, s.settingsSlashHandler).RegisterCommand(session, logger)
+	session.AddHandler(s.guildCreateHandler)
+}
+
+func (s *Service) settingsMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+settings))
+	if len(args) == 0 {
+		s.sendSettingsMessage(ds, m, s.settings.Get(m.GuildID))
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	if len(args) != 2 {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	update, err := s.parseUpdate(m, strings.ToLower(args[0]), args[1])
+	if err != nil {
+		s.sendStringMessage(ds, m, fmt.Sprintf(messageInvalidValue, err))
+		return
+	}
+	updated, err := s.settings.Update(s.ctx, m.GuildID, update)
+	if errors.Is(err, guild.ErrNotLoaded) {
+		s.sendStringMessage(ds, m, messageUnavailable)
+		return
+	}
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "update guild settings"))
+		s.sendStringMessage(ds, m, discord.MessageInternalError)
+		return
+	}
+	s.sendSettingsMessage(ds, m, updated)
+}
+
+func (s *Service) featuresMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+features))
+	if len(args) == 0 {
+		s.sendFeaturesMessage(ds, m, s.settings.Get(m.GuildID))
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	if len(args) != 2 {
+		s.sendFeaturesUsageMessage(ds, m)
+		return
+	}
+	flag, ok := guild.KnownFlag(strings.ToLower(args[0]))
+	if !ok {
+		s.sendStringMessage(ds, m, fmt.Sprintf(messageInvalidValue, "unknown feature "+args[0]))
+		return
+	}
+	var update func(*guild.Settings)
+	switch strings.ToLower(args[1]) {
+	case on, off:
+		enabled := strings.EqualFold(args[1], on)
+		update = func(g *guild.Settings) {
+			if g.Features == nil {
+				g.Features = make(guild.Features)
+			}
+			g.Features[string(flag)] = enabled
+		}
+	case reset:
+		update = func(g *guild.Settings) { delete(g.Features, string(flag)) }
+	default:
+		s.sendFeaturesUsageMessage(ds, m)
+		return
+	}
+	updated, err := s.settings.Update(s.ctx, m.GuildID, update)
+	if errors.Is(err, guild.ErrNotLoaded) {
+		s.sendStringMessage(ds, m, messageUnavailable)
+		return
+	}
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "update guild features"))
+		s.sendStringMessage(ds, m, discord.MessageInternalError)
+		return
+	}
+	s.sendFeaturesMessage(ds, m, updated)
+}
+
+// restrictMessageHandler the mentioned channels replace the allowed ones of the group, all groups if it's omitted
+func (s *Service) restrictMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+restrict))
+	if len(args) == 0 {
+		s.sendRestrictMessage(ds, m, s.settings.Get(m.GuildID))
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	groups := guild.Groups
+	if g, ok := guild.KnownGroup(strings.ToLower(args[0])); ok {
+		groups = []guild.Group{g}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		s.sendRestrictUsageMessage(ds, m)
+		return
+	}
+	channels := make([]string, 0, len(args))
+	for _, arg := range args {
+		if strings.EqualFold(arg, off) && len(args) == 1 {
+			break
+		}
+		channel := strings.TrimSuffix(strings.TrimPrefix(arg, "<#"), ">")
+		if _, err := strconv.ParseUint(channel, 10, 64); err != nil {
+			s.sendRestrictUsageMessage(ds, m)
+			return
+		}
+		channels = append(channels, channel)
+	}
+	updated, err := s.settings.Update(s.ctx, m.GuildID, func(g *guild.Settings) {
+		if g.Channels == nil {
+			g.Channels = make(guild.Channels)
+		}
+		for _, group := range groups {
+			if len(channels) == 0 {
+				delete(g.Channels, string(group))
+				continue
+			}
+			g.Channels[string(group)] = channels
+		}
+	})
+	if errors.Is(err, guild.ErrNotLoaded) {
+		s.sendStringMessage(ds, m, messageUnavailable)
+		return
+	}
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "update guild channels"))
+		s.sendStringMessage(ds, m, discord.MessageInternalError)
+		return
+	}
+	s.sendRestrictMessage(ds, m, updated)
+}
+
+// AllowsChannel the command filter, the author is told where the commands of the group work
+func (s *Service) AllowsChannel(ds *discordgo.Session, m *discordgo.MessageCreate, group string) bool {
+	if m.GuildID == "" {
+		return true
+	}
+	g := s.settings.Get(m.GuildID)
+	if g.Allows(guild.Group(group), m.ChannelID) {
+		return true
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageRestricted, m.Author.ID, group, mentionChannels(g.Channels[group])))
+	return false
+}
+
+// auditMessageHandler the arguments filter by the mentioned user, the command name and set the number of entries
+func (s *Service) auditMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoAuditPermission)
+		return
+	}
+	q := audit.Query{GuildID: m.GuildID}
+	if len(m.Mentions) == 1 {
+		q.UserID = m.Mentions[0].ID
+	}
+	for _, arg := range strings.Fields(strings.TrimPrefix(m.Content, s.prefix+auditLog)) {
+		if strings.HasPrefix(arg, "<@") {
+			continue
+		}
+		if n, err := strconv.Atoi(arg); err == nil {
+			if n < 1 || n > maxAuditEntries {
+				s.sendAuditUsageMessage(ds, m)
+				return
+			}
+			q.Limit = n
+			continue
+		}
+		// the entries keep the default prefix
+		q.Command = s.prefix + strings.TrimPrefix(strings.ToLower(arg), s.prefix)
+	}
+	entries, err := s.audit.Entries(s.ctx, q)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "get audit entries"))
+		s.sendStringMessage(ds, m, discord.MessageInternalError)
+		return
+	}
+	s.sendAuditMessage(ds, m, entries)
+}
+
+// parseUpdate "off" resets the value to the default
+func (s *Service) parseUpdate(m *discordgo.MessageCreate, key, value string) (func(*guild.Settings), error) {
+	isOff := strings.EqualFold(value, off)
+	switch key {
+	case prefix:
+		if isOff {
+			value = ""
+		}
+		if len(value) > maxPrefixLength {
+			return nil, errors.Errorf("prefix is longer than %d", maxPrefixLength)
+		}
+		return func(g *guild.Settings) { g.Prefix = value }, nil
+	case dj:
+		role := ""
+		if !isOff {
+			if len(m.MentionRoles) != 1 {
+				return nil, errors.New("mention the role")
+			}
+			role = m.MentionRoles[0]
+		}
+		return func(g *guild.Settings) { g.DJRole = role }, nil
+	case announce:
+		channel := ""
+		if !isOff {
+			channel = strings.TrimSuffix(strings.TrimPrefix(value, "<#"), ">")
+			if _, err := strconv.ParseUint(channel, 10, 64); err != nil {
+				return nil, errors.New("mention the channel")
+			}
+		}
+		return func(g *guild.Settings) { g.AnnounceChannel = channel }, nil
+	case alarms:
+		channel := ""
+		if !isOff {
+			channel = strings.TrimSuffix(strings.TrimPrefix(value, "<#"), ">")
+			if _, err := strconv.ParseUint(channel, 10, 64); err != nil {
+				return nil, errors.New("mention the voice channel or put its id")
+			}
+		}
+		return func(g *guild.Settings) { g.AlarmChannel = channel }, nil
+	case radioChan:
+		channel := ""
+		if !isOff {
+			channel = strings.TrimSuffix(strings.TrimPrefix(value, "<#"), ">")
+			if _, err := strconv.ParseUint(channel, 10, 64); err != nil {
+				return nil, errors.New("mention the voice channel or put its id")
+			}
+		}
+		return func(g *guild.Settings) { g.Radio.Channel = channel }, nil
+	case listeners:
+		n := 0
+		if !isOff {
+			var err error
+			n, err = strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxListeners {
+				return nil, errors.Errorf("listeners is a number from 1 to %d", maxListeners)
+			}
+		}
+		return func(g *guild.Settings) { g.Radio.Listeners = n }, nil
+	case timezone:
+		zone := ""
+		if !isOff {
+			loc, err := time.LoadLocation(value)
+			if err != nil || strings.EqualFold(value, "local") {
+				return nil, errors.New("timezone is like Europe/Moscow or UTC")
+			}
+			zone = loc.String()
+		}
+		return func(g *guild.Settings) { g.Timezone = zone }, nil
+	case volume:
+		v := 0
+		if !isOff {
+			var err error
+			v, err = strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || v < 1 || v > maxVolume {
+				return nil, errors.Errorf("volume is a percent from 1 to %d", maxVolume)
+			}
+		}
+		return func(g *guild.Settings) { g.Volume = v }, nil
+	case maxQueue:
+		n := 0
+		if !isOff {
+			var err error
+			n, err = strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, errors.New("queue limit is a number of songs")
+			}
+		}
+		return func(g *guild.Settings) { g.Limits.MaxQueue = n }, nil
+	case maxLength:
+		n := 0
+		if !isOff {
+			var err error
+			n, err = strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("song limit is a number of minutes")
+			}
+		}
+		return func(g *guild.Settings) { g.Limits.MaxDuration = n }, nil
+	case daily:
+		n := 0
+		if !isOff {
+			var err error
+			n, err = strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("daily limit is a number of songs")
+			}
+		}
+		return func(g *guild.Settings) { g.Limits.DailyRequests = n }, nil
+	case skipVotes:
+		n := 0
+		if !isOff {
+			var err error
+			n, err = strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || n < 1 || n > maxSkipVotes {
+				return nil, errors.Errorf("skip votes is a percent from 1 to %d", maxSkipVotes)
+			}
+		}
+		return func(g *guild.Settings) { g.Limits.SkipVotes = n }, nil
+	case autoRadio:
+		if !isOff && !strings.EqualFold(value, on) {
+			return nil, errors.New("use on or off")
+		}
+		return func(g *guild.Settings) { g.Radio.AutoStart = !isOff }, nil
+	case safe:
+		if !isOff && !strings.EqualFold(value, on) {
+			return nil, errors.New("use on or off")
+		}
+		return func(g *guild.Settings) { g.SafeSearch = !isOff }, nil
+	case block:
+		if isOff {
+			return func(g *guild.Settings) { g.Blocklist = nil }, nil
+		}
+		entry := blocklistEntry(value)
+		if len(s.settings.Get(m.GuildID).Blocklist) >= maxBlocklist {
+			return nil, errors.Errorf("blocklist is limited to %d entries", maxBlocklist)
+		}
+		return func(g *guild.Settings) {
+			for _, b := range g.Blocklist {
+				if b == entry {
+					return
+				}
+			}
+			// the slice is shared with the cached settings
+			g.Blocklist = append(append([]string(nil), g.Blocklist...), entry)
+		}, nil
+	case unblock:
+		entry := blocklistEntry(value)
+		return func(g *guild.Settings) {
+			list := make([]string, 0, len(g.Blocklist))
+			for _, b := range g.Blocklist {
+				if b != entry {
+					list = append(list, b)
+				}
+			}
+			g.Blocklist = list
+		}, nil
+	}
+	return nil, errors.Errorf("unknown setting %s", key)
+}
+
+// blocklistEntry the links are blocked by the video id, the rest as words of the titles
+func blocklistEntry(value string) string {
+	if id := pkg.GetIDFromURL(value); id.ID != "" {
+		return id.ID
+	}
+	return strings.ToLower(value)
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
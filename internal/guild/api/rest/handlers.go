@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+// settings godoc
+// @summary   Settings of the guild merged with the defaults
+// @produce   json
+// @security  AdminToken
+// @param     id   path      string  true  "Guild ID"
+// @success   200  {object}  guild.Settings
+// @failure   400  {object}  Response  "Incorrect guild id"
+// @failure   401  {object}  Response  "Wrong admin token"
+// @router    /guilds/{id}/settings [get]
+func (h *AdminHandler) settingsHandler(c *gin.Context) {
+	id := c.Param("id")
+	if !snowflake(id) {
+		c.JSON(http.StatusBadRequest, Response{Message: "guild id is a number"})
+		return
+	}
+	c.JSON(http.StatusOK, h.settings.Get(id))
+}
+
+// update godoc
+// @summary   Change the settings of the guild, the omitted fields are kept and the empty ones reset to the defaults
+// @accept    json
+// @produce   json
+// @security  AdminToken
+// @param     id        path      string         true  "Guild ID"
+// @param     settings  body      settingsPatch  true  "The changed settings"
+// @success   200       {object}  guild.Settings  "The settings merged with the defaults"
+// @failure   400       {object}  Response        "Incorrect value"
+// @failure   401       {object}  Response        "Wrong admin token"
+// @failure   503       {object}  Response        "The settings are not loaded yet"
+// @failure   500       {object}  Response        "Database error"
+// @router    /guilds/{id}/settings [patch]
+func (h *AdminHandler) updateHandler(c *gin.Context) {
+	id := c.Param("id")
+	if !snowflake(id) {
+		c.JSON(http.StatusBadRequest, Response{Message: "guild id is a number"})
+		return
+	}
+	var patch settingsPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	if err := patch.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	e := command.Execution{Command: "PATCH /guilds/settings", GuildID: id, Outcome: command.OutcomeOK, Time: time.Now()}
+	updated, err := h.settings.Update(c.Request.Context(), id, patch.apply)
+	if err != nil {
+		e.Outcome = err.Error()
+	}
+	command.Record(e)
+	if errors.Is(err, guild.ErrNotLoaded) {
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// validate the same bounds as the settings command
+func (p *settingsPatch) validate() error {
+	if p.Prefix != nil && len(*p.Prefix) > guild.MaxPrefixLength {
+		return errors.Errorf("prefix is longer than %d", guild.MaxPrefixLength)
+	}
+	for name, id := range map[string]*string{"dj_role": p.DJRole, "announce_channel": p.AnnounceChannel} {
+		if id != nil && *id != "" && !snowflake(*id) {
+			return errors.Errorf("%s is an id", name)
+		}
+	}
+	if p.Volume != nil && (*p.Volume < 0 || *p.Volume > guild.MaxVolume) {
+		return errors.Errorf("volume is a percent from 1 to %d", guild.MaxVolume)
+	}
+	if p.Timezone != nil && *p.Timezone != "" {
+		if _, err := time.LoadLocation(*p.Timezone); err != nil || strings.EqualFold(*p.Timezone, "local") {
+			return errors.New("timezone is like Europe/Moscow or UTC")
+		}
+	}
+	if l := p.Limits; l != nil {
+		for name, n := range map[string]*int{"max_queue": l.MaxQueue, "max_duration": l.MaxDuration, "daily_requests": l.DailyRequests} {
+			if n != nil && *n < 0 {
+				return errors.Errorf("%s is not negative", name)
+			}
+		}
+		if l.SkipVotes != nil && (*l.SkipVotes < 0 || *l.SkipVotes > guild.MaxSkipVotes) {
+			return errors.Errorf("skip_votes is a percent from 1 to %d", guild.MaxSkipVotes)
+		}
+	}
+	if r := p.Radio; r != nil {
+		if r.Channel != nil && *r.Channel != "" && !snowflake(*r.Channel) {
+			return errors.New("radio channel is an id")
+		}
+		if r.Listeners != nil && (*r.Listeners < 0 || *r.Listeners > guild.MaxRadioListeners) {
+			return errors.Errorf("listeners is a number from 1 to %d", guild.MaxRadioListeners)
+		}
+	}
+	return nil
+}
+
+func (p *settingsPatch) apply(g *guild.Settings) {
+	setString(&g.Prefix, p.Prefix)
+	setString(&g.DJRole, p.DJRole)
+	setString(&g.AnnounceChannel, p.AnnounceChannel)
+	setInt(&g.Volume, p.Volume)
+	if p.SafeSearch != nil {
+		g.SafeSearch = *p.SafeSearch
+	}
+	if p.Timezone != nil {
+		g.Timezone = ""
+		if loc, err := time.LoadLocation(*p.Timezone); err == nil && *p.Timezone != "" {
+			g.Timezone = loc.String()
+		}
+	}
+	if l := p.Limits; l != nil {
+		setInt(&g.Limits.MaxQueue, l.MaxQueue)
+		setInt(&g.Limits.MaxDuration, l.MaxDuration)
+		setInt(&g.Limits.DailyRequests, l.DailyRequests)
+		setInt(&g.Limits.SkipVotes, l.SkipVotes)
+	}
+	if r := p.Radio; r != nil {
+		if r.AutoStart != nil {
+			g.Radio.AutoStart = *r.AutoStart
+		}
+		setString(&g.Radio.Channel, r.Channel)
+		setInt(&g.Radio.Listeners, r.Listeners)
+	}
+}
+
+func setString(dst, v *string) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func setInt(dst, v *int) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// snowflake the discord ids are numbers
+func snowflake(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+)
+
+type Settings interface {
+	Get(guildID string) guild.Settings
+	Update(ctx context.Context, guildID string, update func(*guild.Settings)) (guild.Settings, error)
+}
+
+// AdminHandler of the guild settings for the dashboard, it is mounted on a group with the admin token
+type AdminHandler struct {
+	settings Settings
+	super    *gin.RouterGroup
+}
+
+func NewAdminHandler(settings Settings, superGroup *gin.RouterGroup) *AdminHandler {
+	return &AdminHandler{
+		settings: settings,
+		super:    superGroup,
+	}
+}
+
+func (h *AdminHandler) Router() *gin.RouterGroup {
+	group := h.super.Group("/guilds/:id/settings")
+	group.GET("", h.settingsHandler)
+	group.PATCH("", h.updateHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
+
+// settingsPatch the omitted fields are kept, the empty strings and zeros reset the setting to the default
+type settingsPatch struct {
+	Prefix          *string      `json:"prefix,omitempty"`
+	DJRole          *string      `json:"dj_role,omitempty"`
+	AnnounceChannel *string      `json:"announce_channel,omitempty"`
+	Volume          *int         `json:"volume,omitempty"`
+	SafeSearch      *bool        `json:"safe_search,omitempty"`
+	Timezone        *string      `json:"timezone,omitempty"`
+	Limits          *limitsPatch `json:"limits,omitempty"`
+	Radio           *radioPatch  `json:"radio,omitempty"`
+}
+
+type limitsPatch struct {
+	MaxQueue      *int `json:"max_queue,omitempty"`
+	MaxDuration   *int `json:"max_duration,omitempty"`
+	DailyRequests *int `json:"daily_requests,omitempty"`
+	SkipVotes     *int `json:"skip_votes,omitempty"`
+}
+
+type radioPatch struct {
+	AutoStart *bool   `json:"auto_start,omitempty"`
+	Channel   *string `json:"channel,omitempty"`
+	Listeners *int    `json:"listeners,omitempty"`
+}
@@ -0,0 +1,50 @@
+package guild
+
+// Group of the commands restricted to the channels together
+type Group string
+
+const (
+	Music Group = "music"
+	Chess Group = "chess"
+)
+
+// Groups known to the bot, the restrictions of the rest are ignored
+var Groups = []Group{Music, Chess}
+
+// Channels the commands of a group work in, the key is the group name, everywhere if the group has none
+type Channels map[string][]string
+
+func (c Channels) copy() Channels {
+	if c == nil {
+		return nil
+	}
+	res := make(Channels, len(c))
+	for k, v := range c {
+		res[k] = append([]string(nil), v...)
+	}
+	return res
+}
+
+// KnownGroup finds the group by its name
+func KnownGroup(name string) (Group, bool) {
+	for _, g := range Groups {
+		if string(g) == name {
+			return g, true
+		}
+	}
+	return "", false
+}
+
+// Allows the commands of the group in the channel
+func (s *Settings) Allows(g Group, channelID string) bool {
+	allowed := s.Channels[string(g)]
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, id := range allowed {
+		if id == channelID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package guild
+
+// Flag of an experimental feature, disabled unless turned on in the config or for the guild
+type Flag string
+
+const (
+	// Autoplay starts the radio when the queue ends if the guild enabled it
+	Autoplay Flag = "autoplay"
+	// TrimSilence skips the silence at the beginning and the end of the songs
+	TrimSilence Flag = "trim_silence"
+	// Announce speaks the title in the voice channel before each song
+	Announce Flag = "announce"
+	// Record allows DJs to record the listening session
+	Record Flag = "record"
+	// Recap posts the monthly recap in the announce channel and DMs the top requesters their own
+	Recap Flag = "recap"
+	// Approval the songs requested by the members who aren't DJs wait for a DJ to approve them
+	Approval Flag = "approval"
+)
+
+// Flags known to the bot, the rest are ignored
+var Flags = []Flag{Autoplay, TrimSilence, Announce, Record, Recap, Approval}
+
+// Features overrides of the flags, the key is the flag name
+type Features map[string]bool
+
+func (f Features) merge(d Features) Features {
+	res := make(Features, len(d)+len(f))
+	for k, v := range d {
+		res[k] = v
+	}
+	for k, v := range f {
+		res[k] = v
+	}
+	return res
+}
+
+// KnownFlag finds the flag by its name
+func KnownFlag(name string) (Flag, bool) {
+	for _, f := range Flags {
+		if string(f) == name {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// Enabled checks the guild override first and then the defaults from the config
+func (s *Service) Enabled(guildID string, f Flag) bool {
+	return s.Get(guildID).Features[string(f)]
+}
@@ -0,0 +1,234 @@
+package guild
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Bounds of the settings changed by the admins of the guild
+const (
+	MaxPrefixLength   = 5
+	MaxVolume         = 200
+	MaxRadioListeners = 99
+	MaxSkipVotes      = 100
+)
+
+// Settings of the guild, zero values fall back to the defaults
+type Settings struct {
+	GuildID string `firestore:"-" json:"guild_id"`
+	Prefix  string `firestore:"prefix,omitempty" json:"prefix,omitempty"`
+	// DJRole members with the role control the player, everyone does if empty
+	DJRole          string `firestore:"dj_role,omitempty" json:"dj_role,omitempty"`
+	AnnounceChannel string `firestore:"announce_channel,omitempty" json:"announce_channel,omitempty"`
+	// Volume in percent
+	Volume int    `firestore:"volume,omitempty" json:"volume,omitempty"`
+	Limits Limits `firestore:"limits" json:"limits"`
+	Radio  Radio  `firestore:"radio" json:"radio"`
+	// SafeSearch skips the age restricted songs in the search and the radio
+	SafeSearch bool `firestore:"safe_search,omitempty" json:"safe_search,omitempty"`
+	// Blocklist of YouTube video ids and words of the titles, in lowercase
+	Blocklist []string `firestore:"blocklist,omitempty" json:"blocklist,omitempty"`
+	// Filters of the audio chain in order, as name=value
+	Filters []string `firestore:"filters,omitempty" json:"filters,omitempty"`
+	// Features overrides the feature flags of the config
+	Features Features `firestore:"features,omitempty" json:"features,omitempty"`
+	// Channels restrict the command groups to the text channels
+	Channels Channels `firestore:"channels,omitempty" json:"channels,omitempty"`
+	// Joined when the bot was added to the guild, zero for the guilds joined before the onboarding
+	Joined time.Time `firestore:"joined,omitempty" json:"joined,omitempty"`
+	// AlarmChannel the voice channel the scheduled playback joins
+	AlarmChannel string `firestore:"alarm_channel,omitempty" json:"alarm_channel,omitempty"`
+	// Timezone of the schedules as Area/City, UTC if empty
+	Timezone string `firestore:"timezone,omitempty" json:"timezone,omitempty"`
+}
+
+// Location of the timezone, UTC if it is unknown
+func (s *Settings) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+type Limits struct {
+	// MaxQueue songs waiting in the queue, unlimited if 0
+	MaxQueue int `firestore:"max_queue,omitempty" json:"max_queue,omitempty"`
+	// MaxDuration of a song in minutes, longer songs need a DJ to confirm them, unlimited if 0
+	MaxDuration int `firestore:"max_duration,omitempty" json:"max_duration,omitempty"`
+	// DailyRequests songs a member who isn't a DJ requests per day in UTC, unlimited if 0
+	DailyRequests int `firestore:"daily_requests,omitempty" json:"daily_requests,omitempty"`
+	// SkipVotes percent of the listeners who vote to skip a song, DefaultSkipVotes if 0
+	SkipVotes int `firestore:"skip_votes,omitempty" json:"skip_votes,omitempty"`
+}
+
+// DefaultSkipVotes half of the listeners skip the song
+const DefaultSkipVotes = 50
+
+// SkipPercent of the listeners who skip the song by voting
+func (l *Limits) SkipPercent() int {
+	if l.SkipVotes > 0 {
+		return l.SkipVotes
+	}
+	return DefaultSkipVotes
+}
+
+// DefaultRadioListeners in the radio channel start the radio
+const DefaultRadioListeners = 2
+
+type Radio struct {
+	// AutoStart radio when the queue ends
+	AutoStart bool `firestore:"auto_start,omitempty" json:"auto_start,omitempty"`
+	// Channel the bot joins with the radio when it has Listeners and nothing plays, the radio stops when it empties
+	Channel string `firestore:"channel,omitempty" json:"channel,omitempty"`
+	// Listeners in the Channel, DefaultRadioListeners if 0
+	Listeners int `firestore:"listeners,omitempty" json:"listeners,omitempty"`
+}
+
+// MinListeners who start the radio in the channel
+func (r *Radio) MinListeners() int {
+	if r.Listeners > 0 {
+		return r.Listeners
+	}
+	return DefaultRadioListeners
+}
+
+// Blocks the song if its id or a word of the title is in the blocklist
+func (s *Settings) Blocks(id, title string) bool {
+	title = strings.ToLower(title)
+	for _, b := range s.Blocklist {
+		if b == id || strings.Contains(title, b) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Settings) withDefaults(d *Settings) Settings {
+	res := *s
+	if res.Prefix == "" {
+		res.Prefix = d.Prefix
+	}
+	if res.DJRole == "" {
+		res.DJRole = d.DJRole
+	}
+	if res.AnnounceChannel == "" {
+		res.AnnounceChannel = d.AnnounceChannel
+	}
+	if res.Volume == 0 {
+		res.Volume = d.Volume
+	}
+	if res.Limits.MaxQueue == 0 {
+		res.Limits.MaxQueue = d.Limits.MaxQueue
+	}
+	if res.Limits.MaxDuration == 0 {
+		res.Limits.MaxDuration = d.Limits.MaxDuration
+	}
+	if res.Limits.DailyRequests == 0 {
+		res.Limits.DailyRequests = d.Limits.DailyRequests
+	}
+	if res.Limits.SkipVotes == 0 {
+		res.Limits.SkipVotes = d.Limits.SkipVotes
+	}
+	res.Features = res.Features.merge(d.Features)
+	return res
+}
+
+type Storage interface {
+	AllSettings(ctx context.Context) ([]Settings, error)
+	SetSettings(ctx context.Context, s *Settings) error
+}
+
+// ErrNotLoaded the settings can't be changed before they are loaded, a write would drop the stored ones
+var ErrNotLoaded = errors.New("guild settings are not loaded")
+
+// Service keeps all the settings in memory, the bot is the only writer
+type Service struct {
+	storage  Storage
+	defaults Settings
+
+	mx       sync.RWMutex
+	settings map[string]*Settings
+	loaded   bool
+}
+
+// NewService every guild has the defaults until Load
+func NewService(storage Storage, defaults Settings) *Service {
+	return &Service{
+		storage:  storage,
+		defaults: defaults,
+		settings: make(map[string]*Settings),
+	}
+}
+
+func (s *Service) Load(ctx context.Context) error {
+	all, err := s.storage.AllSettings(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load guild settings")
+	}
+	settings := make(map[string]*Settings, len(all))
+	for i := range all {
+		settings[all[i].GuildID] = &all[i]
+	}
+	s.mx.Lock()
+	s.settings = settings
+	s.loaded = true
+	s.mx.Unlock()
+	return nil
+}
+
+// Get returns the settings of the guild merged with the defaults
+func (s *Service) Get(guildID string) Settings {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	stored, ok := s.settings[guildID]
+	if !ok {
+		stored = &Settings{GuildID: guildID}
+	}
+	return stored.withDefaults(&s.defaults)
+}
+
+// Stored the guild has its settings document, it was onboarded or changed a setting
+func (s *Service) Stored(guildID string) (bool, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	if !s.loaded {
+		return false, ErrNotLoaded
+	}
+	_, ok := s.settings[guildID]
+	return ok, nil
+}
+
+func (s *Service) Prefix(guildID string) string {
+	return s.Get(guildID).Prefix
+}
+
+// Update applies the function to the stored settings, not merged with the defaults, and saves them
+func (s *Service) Update(ctx context.Context, guildID string, update func(*Settings)) (Settings, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if !s.loaded {
+		return Settings{}, ErrNotLoaded
+	}
+	updated := Settings{GuildID: guildID}
+	if stored, ok := s.settings[guildID]; ok {
+		updated = *stored
+		// the cached map must not change if saving fails
+		updated.Features = stored.Features.merge(nil)
+		updated.Channels = stored.Channels.copy()
+	}
+	update(&updated)
+	updated.GuildID = guildID
+	if err := s.storage.SetSettings(ctx, &updated); err != nil {
+		return Settings{}, errors.Wrap(err, "save guild settings")
+	}
+	s.settings[guildID] = &updated
+	return updated.withDefaults(&s.defaults), nil
+}
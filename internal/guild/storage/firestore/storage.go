@@ -0,0 +1,69 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/retry"
+)
+
+const guildsCollection = "guilds"
+
+// setRetry the settings are written as a whole document, so a repeated write is harmless
+var setRetry = retry.Policy{
+	Attempts:  4,
+	Base:      100 * time.Millisecond,
+	Max:       2 * time.Second,
+	Retryable: retry.Transient,
+}
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) AllSettings(ctx context.Context) ([]guild.Settings, error) {
+	contexts.LoggerFromContext(ctx).Info("DB: AllSettings")
+	iter := s.client.Collection(guildsCollection).Documents(ctx)
+	defer iter.Stop()
+	res := make([]guild.Settings, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var settings guild.Settings
+		if err := doc.DataTo(&settings); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		settings.GuildID = doc.Ref.ID
+		res = append(res, settings)
+	}
+	return res, nil
+}
+
+func (s *Storage) SetSettings(ctx context.Context, settings *guild.Settings) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetSettings %s", settings.GuildID)
+	err := setRetry.Do(ctx, "set_settings", func() error {
+		_, err := s.client.Collection(guildsCollection).Doc(settings.GuildID).Set(ctx, settings)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", settings.GuildID, guildsCollection)
+	}
+	return nil
+}
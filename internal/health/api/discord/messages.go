@@ -0,0 +1,139 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/internal/usage"
+)
+
+const (
+	messageNoPermission = ":x: **Only server managers can see the health of the bot**"
+	messageStatsFailed  = ":x: **Couldn't read the metrics**"
+	messageNoUsage      = "No commands since the start"
+
+	colorHealthy   = 0x2ecc71
+	colorUnhealthy = 0xe74c3c
+	colorStats     = 0x3498db
+	// maxUsageLines the most used commands shown
+	maxUsageLines = 20
+	// maxFieldValue discord limit of an embed field
+	maxFieldValue = 1024
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, m *discordgo.MessageCreate, msg string) {
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{Content: msg})
+}
+
+func (s *Service) sendHealthMessage(ds *discordgo.Session, m *discordgo.MessageCreate, results []health.Result) {
+	color := colorHealthy
+	fields := make([]*discordgo.MessageEmbedField, 0, len(results))
+	for _, r := range results {
+		name, value := ":white_check_mark: "+r.Name, r.Value
+		if r.Err != nil {
+			color = colorUnhealthy
+			name, value = ":x: "+r.Name, r.Err.Error()
+		}
+		if value == "" {
+			value = "-"
+		}
+		if len(value) > maxFieldValue {
+			value = value[:maxFieldValue-3] + "..."
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true})
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:  "Health",
+				Color:  color,
+				Fields: fields,
+			},
+		},
+	})
+}
+
+func (s *Service) sendStatsMessage(ds *discordgo.Session, m *discordgo.MessageCreate, stats *health.Stats) {
+	refreshed := "never"
+	if !stats.ShortRefreshed.IsZero() {
+		refreshed = time.Since(stats.ShortRefreshed).Round(time.Second).String() + " ago"
+	}
+	quotas := make([]string, 0, len(stats.Quotas))
+	for _, q := range stats.Quotas {
+		quotas = append(quotas, fmt.Sprintf("%s: %.0f used, %.0f left", q.Key, q.Used, q.Left))
+	}
+	if len(quotas) == 0 {
+		quotas = append(quotas, "-")
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   "Songs cache",
+			Value:  fmt.Sprintf("%.1f%% hits, %.0f hits, %.0f misses", stats.HitRate()*100, stats.CacheHits, stats.CacheMisses),
+			Inline: true,
+		},
+		{
+			Name:   "Short cache",
+			Value:  fmt.Sprintf("%.0f songs, refreshed %s", stats.ShortCacheSize, refreshed),
+			Inline: true,
+		},
+		{Name: "YouTube quota", Value: strings.Join(quotas, "\n")},
+		{Name: "Voice connections", Value: fmt.Sprintf("%.0f", stats.VoiceConnections), Inline: true},
+		{
+			Name:   "Memory",
+			Value:  fmt.Sprintf("%.0f of %.0f MB heap, %.0f goroutines, %.0f GC runs", stats.HeapAlloc/(1<<20), stats.HeapSys/(1<<20), stats.Goroutines, stats.GCRuns),
+			Inline: true,
+		},
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:  "Bot stats",
+				Color:  colorStats,
+				Fields: fields,
+			},
+		},
+	})
+}
+
+func (s *Service) sendUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate, r usage.Report) {
+	lines := make([]string, 0, maxUsageLines)
+	for i, c := range r.Commands {
+		if i == maxUsageLines {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("`%s` %d calls, %d failed, %.0f ms avg, %.0f ms max", c.Command, c.Calls, c.Failures, c.AvgMS, c.MaxMS))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, messageNoUsage)
+	}
+	title := "Command usage"
+	if r.GuildID == "" {
+		title += " of the bot"
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       title,
+				Color:       colorStats,
+				Description: strings.Join(lines, "\n"),
+				Footer:      &discordgo.MessageEmbedFooter{Text: "since " + r.Since.UTC().Format("2006-01-02 15:04 MST")},
+			},
+		},
+	})
+}
@@ -0,0 +1,116 @@
+package discord
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/health"
+	"github.com/HalvaPovidlo/discordBotGo/internal/usage"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "health"
+
+const (
+	healthCommand = "health"
+	statsCommand  = "botstats"
+	usageCommand  = "usage"
+	// usageAll the usage of the whole bot instead of the guild
+	usageAll = "all"
+)
+
+type Health interface {
+	Run(ctx context.Context) []health.Result
+}
+
+type Usage interface {
+	Report(guildID string) usage.Report
+}
+
+type Service struct {
+	ctx     context.Context
+	health  Health
+	metrics prometheus.Gatherer
+	usage   Usage
+	prefix  string
+	logger  zap.Logger
+}
+
+// NewCog the stats are read from the metrics of the gatherer
+func NewCog(ctx context.Context, health Health, metrics prometheus.Gatherer, usage Usage, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:     ctx,
+		health:  health,
+		metrics: metrics,
+		usage:   usage,
+		prefix:  prefix,
+		logger:  logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+healthCommand, s.healthMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewMessageCommand(s.prefix+statsCommand, s.statsMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewMessageCommand(s.prefix+usageCommand, s.usageMessageHandler, debug).RegisterCommand(session, logger)
+}
+
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func (s *Service) healthMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	s.sendHealthMessage(ds, m, s.health.Run(s.ctx))
+}
+
+func (s *Service) statsMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	stats, err := health.ReadStats(s.metrics)
+	if err != nil {
+		s.logger.Error(err)
+		s.sendStringMessage(ds, m, messageStatsFailed)
+		return
+	}
+	s.sendStatsMessage(ds, m, stats)
+}
+
+// usageMessageHandler the commands of the guild, "all" for the whole bot
+func (s *Service) usageMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	guildID := m.GuildID
+	if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+usageCommand)), usageAll) {
+		guildID = ""
+	}
+	s.sendUsageMessage(ds, m, s.usage.Report(guildID))
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const checkTimeout = 5 * time.Second
+
+// Check returns the value shown in the report, an error marks the check as failed
+type Check func(ctx context.Context) (string, error)
+
+type Result struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+// Service runs the checks of the subsystems, they are added while the bot is wired
+type Service struct {
+	mx     sync.RWMutex
+	names  []string
+	checks map[string]Check
+}
+
+func NewService() *Service {
+	return &Service{
+		checks: make(map[string]Check),
+	}
+}
+
+// Add replaces the check with the same name
+func (s *Service) Add(name string, check Check) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if _, ok := s.checks[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.checks[name] = check
+}
+
+// Run checks concurrently, the results keep the order the checks were added
+func (s *Service) Run(ctx context.Context) []Result {
+	s.mx.RLock()
+	names := append([]string(nil), s.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = s.checks[name]
+	}
+	s.mx.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := checks[i](ctx)
+			results[i] = Result{Name: names[i], Value: value, Err: err}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
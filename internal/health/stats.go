@@ -0,0 +1,100 @@
+package health
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Quota of a YouTube key for today
+type Quota struct {
+	Key  string
+	Used float64
+	Left float64
+}
+
+// Stats of the caches, quotas and the process read from the metrics
+type Stats struct {
+	CacheHits        float64
+	CacheMisses      float64
+	ShortCacheSize   float64
+	ShortRefreshed   time.Time
+	Quotas           []Quota
+	VoiceConnections float64
+	HeapAlloc        float64
+	HeapSys          float64
+	Goroutines       float64
+	GCRuns           float64
+}
+
+// HitRate of the songs cache, 0 without lookups
+func (s *Stats) HitRate() float64 {
+	if total := s.CacheHits + s.CacheMisses; total > 0 {
+		return s.CacheHits / total
+	}
+	return 0
+}
+
+// ReadStats from the registry the metrics of the subsystems are registered in, usually prometheus.DefaultGatherer
+func ReadStats(g prometheus.Gatherer) (*Stats, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, errors.Wrap(err, "gather metrics")
+	}
+	stats := &Stats{}
+	quotas := make(map[string]*Quota)
+	quota := func(key string) *Quota {
+		q, ok := quotas[key]
+		if !ok {
+			q = &Quota{Key: key}
+			quotas[key] = q
+		}
+		return q
+	}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			label := ""
+			if len(m.GetLabel()) > 0 {
+				label = m.GetLabel()[0].GetValue()
+			}
+			gauge := m.GetGauge().GetValue()
+			switch f.GetName() {
+			case "halvabot_songs_cache_requests_total":
+				if label == "hit" {
+					stats.CacheHits = m.GetCounter().GetValue()
+				} else {
+					stats.CacheMisses = m.GetCounter().GetValue()
+				}
+			case "halvabot_songs_cache_short_size":
+				stats.ShortCacheSize = gauge
+			case "halvabot_songs_cache_short_refreshed_timestamp_seconds":
+				if gauge > 0 {
+					stats.ShortRefreshed = time.Unix(int64(gauge), 0)
+				}
+			case "halvabot_youtube_quota_used_units":
+				quota(label).Used = gauge
+			case "halvabot_youtube_quota_left_units":
+				quota(label).Left = gauge
+			case "halvabot_audio_voice_connections":
+				stats.VoiceConnections = gauge
+			case "go_memstats_heap_alloc_bytes":
+				stats.HeapAlloc = gauge
+			case "go_memstats_heap_sys_bytes":
+				stats.HeapSys = gauge
+			case "go_goroutines":
+				stats.Goroutines = gauge
+			case "go_gc_duration_seconds":
+				stats.GCRuns = float64(m.GetSummary().GetSampleCount())
+			}
+		}
+	}
+	for _, q := range quotas {
+		stats.Quotas = append(stats.Quotas, *q)
+	}
+	sort.Slice(stats.Quotas, func(i, j int) bool {
+		return stats.Quotas[i].Key < stats.Quotas[j].Key
+	})
+	return stats, nil
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/importer"
+)
+
+// import godoc
+// @summary   Import the songs from a file of another bot or a list of YouTube links
+// @accept    plain
+// @accept    mpfd
+// @produce   json
+// @param     format  query     string  false  "csv, json or text, the extension of the uploaded file by default"
+// @param     file    formData  file    false  "The file, the body is the file if it isn't a form"
+// @success   200     {object}  ReportResponse
+// @failure   400     {object}  Response        "Unknown format, too many songs or a broken file"
+// @failure   401     {object}  Response        "Wrong admin token"
+// @failure   500     {object}  ReportResponse  "YouTube or database error, the report counts the songs imported before it"
+// @security  AdminToken
+// @router    /admin/import [post]
+func (h *Handler) importHandler(c *gin.Context) {
+	format := c.Query("format")
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+			return
+		}
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(header.Filename), ".")
+			if format == "txt" {
+				format = importer.FormatText
+			}
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	entries, err := importer.Parse(format, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	report, err := h.importer.Import(c.Request.Context(), entries)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ReportResponse{Report: report, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ReportResponse{Report: report})
+}
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/importer"
+)
+
+type Importer interface {
+	Import(ctx context.Context, entries []importer.Entry) (importer.Report, error)
+}
+
+// Handler the super group must be protected by the admin token
+type Handler struct {
+	importer Importer
+	super    *gin.RouterGroup
+}
+
+func NewHandler(importer Importer, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		importer: importer,
+		super:    superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/import")
+	group.POST("", h.importHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
+
+// ReportResponse the message is set if the import stopped halfway
+type ReportResponse struct {
+	importer.Report
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	// MaxEntries of one import, larger libraries are imported in parts
+	MaxEntries = 5000
+	// MaxFileSize in bytes
+	MaxFileSize = 5 << 20
+)
+
+type Videos interface {
+	// Videos resolves the metadata in bulk, the unknown videos are left out
+	Videos(ctx context.Context, ids []string) ([]*pkg.Song, error)
+}
+
+type Songs interface {
+	GetSong(ctx context.Context, id pkg.SongID) (*pkg.Song, error)
+	SetSong(ctx context.Context, song *pkg.Song) error
+}
+
+// Report of an import
+type Report struct {
+	Entries int `json:"entries"`
+	// Invalid entries aren't YouTube videos
+	Invalid    int `json:"invalid"`
+	Duplicates int `json:"duplicates"`
+	// Existing songs are in the library already, they are left as they are
+	Existing int `json:"existing"`
+	// NotFound videos are deleted or private
+	NotFound int `json:"not_found"`
+	Imported int `json:"imported"`
+}
+
+// Service seeds the songs from the libraries of other bots
+type Service struct {
+	videos Videos
+	songs  Songs
+}
+
+func NewService(videos Videos, songs Songs) *Service {
+	return &Service{
+		videos: videos,
+		songs:  songs,
+	}
+}
+
+// Import the entries, the report counts the songs imported before an error
+func (s *Service) Import(ctx context.Context, entries []Entry) (Report, error) {
+	report := Report{Entries: len(entries)}
+	playbacks := make(map[string]int)
+	ids := make([]string, 0, len(entries))
+	for i := range entries {
+		id := VideoID(entries[i].URL)
+		if id == "" {
+			report.Invalid++
+			continue
+		}
+		if _, ok := playbacks[id]; ok {
+			report.Duplicates++
+			playbacks[id] += entries[i].Playbacks
+			continue
+		}
+		playbacks[id] = entries[i].Playbacks
+		ids = append(ids, id)
+	}
+
+	missing := make([]string, 0, len(ids))
+	for _, id := range ids {
+		_, err := s.songs.GetSong(ctx, pkg.SongID{ID: id, Service: pkg.ServiceYouTube})
+		if err == nil {
+			report.Existing++
+			continue
+		}
+		missing = append(missing, id)
+	}
+
+	songs, err := s.videos.Videos(ctx, missing)
+	for _, song := range songs {
+		song.Playbacks = playbacks[song.ID.ID]
+		if err := s.songs.SetSong(ctx, song); err != nil {
+			return report, errors.Wrapf(err, "set song %s", song.ID)
+		}
+		report.Imported++
+	}
+	if err != nil {
+		return report, errors.Wrap(err, "resolve videos")
+	}
+	report.NotFound = len(missing) - len(songs)
+	contexts.LoggerFromContext(ctx).Infow("library imported",
+		"entries", report.Entries,
+		"imported", report.Imported,
+		"existing", report.Existing)
+	return report, nil
+}
@@ -0,0 +1,193 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Formats of the imported files
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+var (
+	ErrUnknownFormat = errors.New("the format is csv, json or text")
+	ErrTooMany       = errors.New("too many songs in one import")
+)
+
+var videoIDRe = regexp.MustCompile(`^[\w-]{11}$`)
+
+// Entry of an imported file, only the YouTube videos are imported
+type Entry struct {
+	URL       string
+	Playbacks int
+}
+
+// jsonTrack of the exports of the bots on lavalink and of plain lists, the first non-empty url field is used
+type jsonTrack struct {
+	URL       string `json:"url"`
+	URI       string `json:"uri"`
+	Link      string `json:"link"`
+	Playbacks int    `json:"playbacks"`
+}
+
+// Parse the file, the csv has a url column or urls in the first column, the json is a list of urls
+// or of tracks, or an object with the tracks or the songs, the text has a url per line
+func Parse(format string, r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+	if len(data) > MaxFileSize {
+		return nil, ErrTooMany
+	}
+	var entries []Entry
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		entries, err = parseCSV(data)
+	case FormatJSON:
+		entries, err = parseJSON(data)
+	case FormatText:
+		entries = parseText(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > MaxEntries {
+		return nil, ErrTooMany
+	}
+	return entries, nil
+}
+
+func parseCSV(data []byte) ([]Entry, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "parse csv")
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	urlColumn, playbacksColumn := 0, -1
+	header := false
+	for i, name := range records[0] {
+		switch strings.ToLower(name) {
+		case "url", "uri", "link":
+			urlColumn, header = i, true
+		case "playbacks", "plays":
+			playbacksColumn, header = i, true
+		}
+	}
+	if header {
+		records = records[1:]
+	}
+	entries := make([]Entry, 0, len(records))
+	for _, record := range records {
+		if urlColumn >= len(record) {
+			continue
+		}
+		e := Entry{URL: record[urlColumn]}
+		if playbacksColumn >= 0 && playbacksColumn < len(record) {
+			e.Playbacks, _ = strconv.Atoi(record[playbacksColumn])
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseJSON(data []byte) ([]Entry, error) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		var wrapped struct {
+			Tracks []json.RawMessage `json:"tracks"`
+			Songs  []json.RawMessage `json:"songs"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, errors.Wrap(err, "parse json")
+		}
+		list = append(wrapped.Tracks, wrapped.Songs...)
+	}
+	entries := make([]Entry, 0, len(list))
+	for _, raw := range list {
+		var link string
+		if err := json.Unmarshal(raw, &link); err == nil {
+			entries = append(entries, Entry{URL: link})
+			continue
+		}
+		var t struct {
+			jsonTrack
+			Info *jsonTrack `json:"info"`
+		}
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, errors.Wrap(err, "parse json track")
+		}
+		if t.Info != nil {
+			t.jsonTrack = *t.Info
+		}
+		e := Entry{URL: t.URL, Playbacks: t.Playbacks}
+		if e.URL == "" {
+			e.URL = t.URI
+		}
+		if e.URL == "" {
+			e.URL = t.Link
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseText(data []byte) []Entry {
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, Entry{URL: line})
+	}
+	return entries
+}
+
+// VideoID of the YouTube links: watch, youtu.be, shorts, embed and music.youtube.com, empty for the other links
+func VideoID(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	id := ""
+	switch host {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com", "m.youtube.com", "music.youtube.com", "youtube-nocookie.com":
+		if v := u.Query().Get("v"); v != "" {
+			id = v
+			break
+		}
+		for _, prefix := range []string{"/shorts/", "/embed/", "/v/", "/live/"} {
+			if strings.HasPrefix(u.Path, prefix) {
+				id = strings.Trim(strings.TrimPrefix(u.Path, prefix), "/")
+			}
+		}
+	}
+	if !videoIDRe.MatchString(id) {
+		return ""
+	}
+	return id
+}
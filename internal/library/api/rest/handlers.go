@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+)
+
+// shared godoc
+// @summary  Read-only playlist of the share link
+// @produce  json
+// @param    token  path      string  true  "Token of the share link"
+// @success  200    {object}  library.Playlist
+// @failure  404    {object}  Response  "The link is unknown or disabled"
+// @failure  500    {object}  Response  "Database error"
+// @router   /share/{token} [get]
+func (h *Handler) sharedHandler(c *gin.Context) {
+	p, err := h.library.Shared(c.Request.Context(), c.Param("token"))
+	if errors.Is(err, library.ErrNotFound) {
+		c.JSON(http.StatusNotFound, Response{Message: "playlist not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// pageHandler the same playlist as html for the people without a client
+func (h *Handler) pageHandler(c *gin.Context) {
+	p, err := h.library.Shared(c.Request.Context(), c.Param("token"))
+	code := http.StatusOK
+	if errors.Is(err, library.ErrNotFound) {
+		code, p = http.StatusNotFound, nil
+	} else if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Status(code)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	_ = page.Execute(c.Writer, p)
+}
@@ -0,0 +1,50 @@
+package rest
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+)
+
+// page of a shared playlist, nil shows that the link doesn't work
+var page = template.Must(template.New("playlist").Funcs(template.FuncMap{
+	"duration": duration,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .}}{{.Name}}{{else}}Playlist not found{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 720px; margin: 2em auto; padding: 0 1em; color: #222; }
+li { margin: .4em 0; }
+.artist, .duration { color: #777; }
+</style>
+</head>
+<body>
+{{if .}}
+<h1>{{.Name}}</h1>
+<p>{{len .Songs}} songs, updated {{.Updated.Format "2006-01-02"}}</p>
+<ol>
+{{range .Songs}}<li><a href="{{.URL}}">{{.Title}}</a>{{if .ArtistName}} <span class="artist">— {{.ArtistName}}</span>{{end}}{{with duration .Duration}} <span class="duration">{{.}}</span>{{end}}</li>
+{{end}}</ol>
+{{else}}
+<h1>Playlist not found</h1>
+<p>The link is wrong or the playlist isn't shared anymore.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// duration m:ss or h:mm:ss, empty if it is unknown
+func duration(seconds float64) string {
+	s := int(math.Round(seconds))
+	switch {
+	case s <= 0:
+		return ""
+	case s >= 3600:
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s%3600/60, s%60)
+	default:
+		return fmt.Sprintf("%d:%02d", s/60, s%60)
+	}
+}
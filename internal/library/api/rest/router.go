@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+)
+
+type Library interface {
+	Shared(ctx context.Context, token string) (*library.Playlist, error)
+}
+
+type Handler struct {
+	library Library
+	super   *gin.RouterGroup
+}
+
+func NewHandler(library Library, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		library: library,
+		super:   superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/share")
+	group.GET("/:token", h.sharedHandler)
+	return group
+}
+
+// PageRouter the html page of the shared playlists for the browsers, outside of the api
+func (h *Handler) PageRouter(root *gin.RouterGroup) *gin.RouterGroup {
+	group := root.Group("/share")
+	group.GET("/:token", h.pageHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
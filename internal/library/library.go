@@ -0,0 +1,335 @@
+package library
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	MaxFavorites     = 500
+	MaxPlaylists     = 25
+	MaxPlaylistSongs = 200
+
+	// SharePath of the page of a shared playlist, the json is at the same path of the api
+	SharePath       = "/share/"
+	shareTokenBytes = 16
+)
+
+var (
+	ErrNotFound    = errors.New("playlist not found")
+	ErrExists      = errors.New("playlist exists already")
+	ErrFull        = errors.New("the limit is reached")
+	ErrInvalidName = errors.New("the name is 1-32 letters, digits, - or _")
+	ErrNoSong      = errors.New("no such song in the playlist")
+	ErrNotShared   = errors.New("the playlist isn't shared")
+)
+
+var nameRe = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,32}$`)
+
+// Entry of the favorites or a playlist, enough to show the song and to play it again
+type Entry struct {
+	ID         string    `firestore:"id" json:"id"`
+	Title      string    `firestore:"title" json:"title"`
+	ArtistName string    `firestore:"artist_name,omitempty" json:"artist_name,omitempty"`
+	URL        string    `firestore:"url" json:"url"`
+	ArtworkURL string    `firestore:"artwork_url,omitempty" json:"artwork_url,omitempty"`
+	Duration   float64   `firestore:"duration,omitempty" json:"duration,omitempty"` // seconds
+	Added      time.Time `firestore:"added" json:"added"`
+}
+
+func EntryFromSong(s *pkg.Song) Entry {
+	return Entry{
+		ID:         s.ID.String(),
+		Title:      s.Title,
+		ArtistName: s.ArtistName,
+		URL:        s.URL,
+		ArtworkURL: s.ArtworkURL,
+		Duration:   s.Duration,
+		Added:      time.Now(),
+	}
+}
+
+// Song to queue the entry, the stream isn't known yet
+func (e *Entry) Song() *pkg.Song {
+	return &pkg.Song{
+		ID:         pkg.GetIDFromURL(e.URL),
+		Title:      e.Title,
+		ArtistName: e.ArtistName,
+		URL:        e.URL,
+		ArtworkURL: e.ArtworkURL,
+		Duration:   e.Duration,
+	}
+}
+
+// Playlist of a user, the name is unique for the user ignoring the case
+type Playlist struct {
+	Name    string    `firestore:"name" json:"name"`
+	Songs   []Entry   `firestore:"songs" json:"songs"`
+	Created time.Time `firestore:"created" json:"created"`
+	Updated time.Time `firestore:"updated" json:"updated"`
+	// Share token of the public link, empty if the playlist isn't shared
+	Share string `firestore:"share,omitempty" json:"-"`
+}
+
+// Share of a playlist, the token is the id of the document, anyone with it reads the playlist
+type Share struct {
+	UserID  string    `firestore:"user_id" json:"user_id"`
+	Key     string    `firestore:"key" json:"key"`
+	Created time.Time `firestore:"created" json:"created"`
+}
+
+type Storage interface {
+	Favorites(ctx context.Context, userID string) ([]Entry, error)
+	SetFavorite(ctx context.Context, userID string, e *Entry) error
+	DeleteFavorite(ctx context.Context, userID, id string) error
+	Playlists(ctx context.Context, userID string) ([]Playlist, error)
+	// GetPlaylist returns ErrNotFound if the user has no such playlist
+	GetPlaylist(ctx context.Context, userID, key string) (*Playlist, error)
+	SetPlaylist(ctx context.Context, userID, key string, p *Playlist) error
+	DeletePlaylist(ctx context.Context, userID, key string) error
+	// GetShare returns ErrNotFound if there is no such token
+	GetShare(ctx context.Context, token string) (*Share, error)
+	SetShare(ctx context.Context, token string, share *Share) error
+	DeleteShare(ctx context.Context, token string) error
+}
+
+// Service keeps the favorite songs and the playlists of the users
+type Service struct {
+	storage Storage
+	// shareURL of the bot the share links are built with, e.g. https://bot.example.com
+	shareURL string
+}
+
+func NewService(storage Storage, shareURL string) *Service {
+	return &Service{
+		storage:  storage,
+		shareURL: strings.TrimSuffix(shareURL, "/"),
+	}
+}
+
+// Key of the playlist in the storage
+func Key(name string) string {
+	return strings.ToLower(name)
+}
+
+// ToggleFavorite adds the song or removes it if it is a favorite already, true if it was added
+func (s *Service) ToggleFavorite(ctx context.Context, userID string, song *pkg.Song) (bool, error) {
+	favorites, err := s.Favorites(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	id := song.ID.String()
+	for i := range favorites {
+		if favorites[i].ID == id {
+			if err := s.storage.DeleteFavorite(ctx, userID, id); err != nil {
+				return false, errors.Wrap(err, "delete favorite")
+			}
+			return false, nil
+		}
+	}
+	if len(favorites) >= MaxFavorites {
+		return false, ErrFull
+	}
+	e := EntryFromSong(song)
+	if err := s.storage.SetFavorite(ctx, userID, &e); err != nil {
+		return false, errors.Wrap(err, "set favorite")
+	}
+	return true, nil
+}
+
+// Favorites of the user, the recently added first
+func (s *Service) Favorites(ctx context.Context, userID string) ([]Entry, error) {
+	favorites, err := s.storage.Favorites(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "favorites")
+	}
+	return favorites, nil
+}
+
+// Playlists of the user, the recently updated first
+func (s *Service) Playlists(ctx context.Context, userID string) ([]Playlist, error) {
+	playlists, err := s.storage.Playlists(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "playlists")
+	}
+	return playlists, nil
+}
+
+func (s *Service) Playlist(ctx context.Context, userID, name string) (*Playlist, error) {
+	return s.storage.GetPlaylist(ctx, userID, Key(name))
+}
+
+func (s *Service) CreatePlaylist(ctx context.Context, userID, name string) (*Playlist, error) {
+	if !nameRe.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	playlists, err := s.Playlists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range playlists {
+		if Key(playlists[i].Name) == Key(name) {
+			return nil, ErrExists
+		}
+	}
+	if len(playlists) >= MaxPlaylists {
+		return nil, ErrFull
+	}
+	now := time.Now()
+	p := &Playlist{Name: name, Songs: []Entry{}, Created: now, Updated: now}
+	if err := s.storage.SetPlaylist(ctx, userID, Key(name), p); err != nil {
+		return nil, errors.Wrap(err, "create playlist")
+	}
+	return p, nil
+}
+
+// AddToPlaylist appends the song to the end
+func (s *Service) AddToPlaylist(ctx context.Context, userID, name string, song *pkg.Song) (*Playlist, error) {
+	p, err := s.Playlist(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Songs) >= MaxPlaylistSongs {
+		return nil, ErrFull
+	}
+	p.Songs = append(p.Songs, EntryFromSong(song))
+	p.Updated = time.Now()
+	if err := s.storage.SetPlaylist(ctx, userID, Key(name), p); err != nil {
+		return nil, errors.Wrap(err, "add to playlist")
+	}
+	return p, nil
+}
+
+// RemoveFromPlaylist the song at the position counted from 1
+func (s *Service) RemoveFromPlaylist(ctx context.Context, userID, name string, position int) (*Entry, error) {
+	p, err := s.Playlist(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if position < 1 || position > len(p.Songs) {
+		return nil, ErrNoSong
+	}
+	removed := p.Songs[position-1]
+	p.Songs = append(p.Songs[:position-1], p.Songs[position:]...)
+	p.Updated = time.Now()
+	if err := s.storage.SetPlaylist(ctx, userID, Key(name), p); err != nil {
+		return nil, errors.Wrap(err, "remove from playlist")
+	}
+	return &removed, nil
+}
+
+func (s *Service) DeletePlaylist(ctx context.Context, userID, name string) error {
+	p, err := s.Playlist(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+	if p.Share != "" {
+		if err := s.storage.DeleteShare(ctx, p.Share); err != nil {
+			return errors.Wrap(err, "delete share")
+		}
+	}
+	if err := s.storage.DeletePlaylist(ctx, userID, Key(name)); err != nil {
+		return errors.Wrap(err, "delete playlist")
+	}
+	return nil
+}
+
+// DeleteFavorites of the user, returns how many were deleted
+func (s *Service) DeleteFavorites(ctx context.Context, userID string) (int, error) {
+	favorites, err := s.Favorites(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range favorites {
+		if err := s.storage.DeleteFavorite(ctx, userID, favorites[i].ID); err != nil {
+			return i, errors.Wrap(err, "delete favorite")
+		}
+	}
+	return len(favorites), nil
+}
+
+// DeletePlaylists of the user with their share links, returns how many were deleted
+func (s *Service) DeletePlaylists(ctx context.Context, userID string) (int, error) {
+	playlists, err := s.Playlists(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range playlists {
+		if playlists[i].Share != "" {
+			if err := s.storage.DeleteShare(ctx, playlists[i].Share); err != nil {
+				return i, errors.Wrap(err, "delete share")
+			}
+		}
+		if err := s.storage.DeletePlaylist(ctx, userID, Key(playlists[i].Name)); err != nil {
+			return i, errors.Wrap(err, "delete playlist")
+		}
+	}
+	return len(playlists), nil
+}
+
+// SharePlaylist returns the public read-only link, the same one while the playlist stays shared
+func (s *Service) SharePlaylist(ctx context.Context, userID, name string) (string, error) {
+	p, err := s.Playlist(ctx, userID, name)
+	if err != nil {
+		return "", err
+	}
+	if p.Share != "" {
+		return s.shareURL + SharePath + p.Share, nil
+	}
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate token")
+	}
+	token := hex.EncodeToString(b)
+	if err := s.storage.SetShare(ctx, token, &Share{UserID: userID, Key: Key(name), Created: time.Now()}); err != nil {
+		return "", errors.Wrap(err, "set share")
+	}
+	p.Share = token
+	if err := s.storage.SetPlaylist(ctx, userID, Key(name), p); err != nil {
+		return "", errors.Wrap(err, "share playlist")
+	}
+	return s.shareURL + SharePath + token, nil
+}
+
+// UnsharePlaylist the old link stops working, sharing again gives a new one
+func (s *Service) UnsharePlaylist(ctx context.Context, userID, name string) error {
+	p, err := s.Playlist(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+	if p.Share == "" {
+		return ErrNotShared
+	}
+	if err := s.storage.DeleteShare(ctx, p.Share); err != nil {
+		return errors.Wrap(err, "delete share")
+	}
+	p.Share = ""
+	if err := s.storage.SetPlaylist(ctx, userID, Key(name), p); err != nil {
+		return errors.Wrap(err, "unshare playlist")
+	}
+	return nil
+}
+
+// Shared playlist of the token, ErrNotFound if the token is unknown or the playlist is gone
+func (s *Service) Shared(ctx context.Context, token string) (*Playlist, error) {
+	share, err := s.storage.GetShare(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.storage.GetPlaylist(ctx, share.UserID, share.Key)
+	if err != nil {
+		return nil, err
+	}
+	if p.Share != token {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
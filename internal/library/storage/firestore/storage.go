@@ -0,0 +1,205 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	// usersCollection is shared with the songs of the users
+	usersCollection     = "users"
+	favoritesCollection = "favorites"
+	playlistsCollection = "playlists"
+	// sharesCollection the tokens of the public playlist links
+	sharesCollection = "playlist_shares"
+)
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) favorites(userID string) *firestore.CollectionRef {
+	return s.client.Collection(usersCollection).Doc(userID).Collection(favoritesCollection)
+}
+
+func (s *Storage) playlists(userID string) *firestore.CollectionRef {
+	return s.client.Collection(usersCollection).Doc(userID).Collection(playlistsCollection)
+}
+
+func (s *Storage) Favorites(ctx context.Context, userID string) ([]library.Entry, error) {
+	iter := s.favorites(userID).OrderBy("added", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+	res := make([]library.Entry, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get favorites of %s", userID)
+		}
+		var e library.Entry
+		if err := doc.DataTo(&e); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+func (s *Storage) SetFavorite(ctx context.Context, userID string, e *library.Entry) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetFavorite user:%s song:%s", userID, e.ID)
+	if _, err := s.favorites(userID).Doc(e.ID).Set(ctx, e); err != nil {
+		return errors.Wrapf(err, "failed to set favorite %s of %s", e.ID, userID)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteFavorite(ctx context.Context, userID, id string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteFavorite user:%s song:%s", userID, id)
+	if _, err := s.favorites(userID).Doc(id).Delete(ctx); err != nil {
+		return errors.Wrapf(err, "failed to delete favorite %s of %s", id, userID)
+	}
+	return nil
+}
+
+func (s *Storage) Playlists(ctx context.Context, userID string) ([]library.Playlist, error) {
+	iter := s.playlists(userID).OrderBy("updated", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+	res := make([]library.Playlist, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get playlists of %s", userID)
+		}
+		var p library.Playlist
+		if err := doc.DataTo(&p); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+func (s *Storage) GetPlaylist(ctx context.Context, userID, key string) (*library.Playlist, error) {
+	doc, err := s.playlists(userID).Doc(key).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, library.ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get playlist %s of %s", key, userID)
+	}
+	var p library.Playlist
+	if err := doc.DataTo(&p); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &p, nil
+}
+
+func (s *Storage) SetPlaylist(ctx context.Context, userID, key string, p *library.Playlist) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetPlaylist user:%s playlist:%s", userID, key)
+	if _, err := s.playlists(userID).Doc(key).Set(ctx, p); err != nil {
+		return errors.Wrapf(err, "failed to set playlist %s of %s", key, userID)
+	}
+	return nil
+}
+
+func (s *Storage) DeletePlaylist(ctx context.Context, userID, key string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeletePlaylist user:%s playlist:%s", userID, key)
+	if _, err := s.playlists(userID).Doc(key).Delete(ctx); err != nil {
+		return errors.Wrapf(err, "failed to delete playlist %s of %s", key, userID)
+	}
+	return nil
+}
+
+func (s *Storage) GetShare(ctx context.Context, token string) (*library.Share, error) {
+	doc, err := s.client.Collection(sharesCollection).Doc(token).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, library.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "failed to get share")
+	}
+	var share library.Share
+	if err := doc.DataTo(&share); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &share, nil
+}
+
+func (s *Storage) SetShare(ctx context.Context, token string, share *library.Share) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetShare user:%s playlist:%s", share.UserID, share.Key)
+	if _, err := s.client.Collection(sharesCollection).Doc(token).Set(ctx, share); err != nil {
+		return errors.Wrapf(err, "failed to set share of %s of %s", share.Key, share.UserID)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteShare(ctx context.Context, token string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteShare")
+	if _, err := s.client.Collection(sharesCollection).Doc(token).Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete share")
+	}
+	return nil
+}
+
+// UserShares the links to the playlists of the user, for the export of the user data
+func (s *Storage) UserShares(ctx context.Context, userID string) ([]library.Share, error) {
+	_, shares, err := s.userShares(ctx, userID)
+	return shares, err
+}
+
+// DeleteUserShares the links left by the playlists deleted before their links
+func (s *Storage) DeleteUserShares(ctx context.Context, userID string) (int, error) {
+	tokens, _, err := s.userShares(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range tokens {
+		if err := s.DeleteShare(ctx, tokens[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(tokens), nil
+}
+
+func (s *Storage) userShares(ctx context.Context, userID string) ([]string, []library.Share, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: UserShares %s", userID)
+	iter := s.client.Collection(sharesCollection).Where("user_id", "==", userID).Documents(ctx)
+	defer iter.Stop()
+	tokens := make([]string, 0)
+	shares := make([]library.Share, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get shares of %s", userID)
+		}
+		var share library.Share
+		if err := doc.DataTo(&share); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to parse doc into struct")
+		}
+		tokens = append(tokens, doc.Ref.ID)
+		shares = append(shares, share)
+	}
+	return tokens, shares, nil
+}
@@ -0,0 +1,146 @@
+package listening
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	// DateLayout of the days, the days are in UTC
+	DateLayout = "2006-01-02"
+
+	sampleInterval = 15 * time.Second
+	flushInterval  = 5 * time.Minute
+	flushTimeout   = 10 * time.Second
+)
+
+// Day of a user, the seconds the user was in the voice channel while the bot played
+type Day struct {
+	Date    string  `firestore:"date" json:"date"`
+	Seconds float64 `firestore:"seconds" json:"seconds"`
+}
+
+type Storage interface {
+	AddSeconds(ctx context.Context, userID, date string, seconds float64) error
+	// Days in [from, to], the dates are in DateLayout
+	Days(ctx context.Context, userID, from, to string) ([]Day, error)
+}
+
+type Player interface {
+	NowPlaying() *pkg.Song
+}
+
+type Voice interface {
+	Channel() (guildID, channelID string)
+}
+
+type key struct {
+	userID string
+	date   string
+}
+
+// Tracker samples the members of the voice channel of the bot while it plays,
+// the seconds are kept in memory and written every few minutes
+type Tracker struct {
+	session *discordgo.Session
+	player  Player
+	voice   Voice
+	storage Storage
+	logger  zap.Logger
+
+	mx      sync.Mutex
+	pending map[key]float64
+}
+
+func NewTracker(session *discordgo.Session, player Player, voice Voice, storage Storage, logger zap.Logger) *Tracker {
+	return &Tracker{
+		session: session,
+		player:  player,
+		voice:   voice,
+		storage: storage,
+		logger:  logger,
+		pending: make(map[key]float64),
+	}
+}
+
+// Run until the context is done, the pending seconds are written then
+func (t *Tracker) Run(ctx context.Context) {
+	sample := time.NewTicker(sampleInterval)
+	defer sample.Stop()
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	ctx = contexts.WithLogger(ctx, t.logger)
+	for {
+		select {
+		case now := <-sample.C:
+			t.sample(now)
+		case <-flush.C:
+			t.flush(ctx)
+		case <-ctx.Done():
+			ctx, cancel := context.WithTimeout(contexts.WithLogger(context.Background(), t.logger), flushTimeout)
+			t.flush(ctx)
+			cancel()
+			return
+		}
+	}
+}
+
+// sample the deafened members and the bots don't listen
+func (t *Tracker) sample(now time.Time) {
+	if t.player.NowPlaying() == nil {
+		return
+	}
+	guildID, channelID := t.voice.Channel()
+	if channelID == "" {
+		return
+	}
+	g, err := t.session.State.Guild(guildID)
+	if err != nil {
+		return
+	}
+	date := now.UTC().Format(DateLayout)
+	inChannel := make([]string, 0)
+	t.session.State.RLock()
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == channelID && !vs.Deaf && !vs.SelfDeaf && vs.UserID != t.session.State.User.ID {
+			inChannel = append(inChannel, vs.UserID)
+		}
+	}
+	t.session.State.RUnlock()
+
+	listeners := 0
+	t.mx.Lock()
+	for _, userID := range inChannel {
+		if m, err := t.session.State.Member(guildID, userID); err == nil && m.User != nil && m.User.Bot {
+			continue
+		}
+		t.pending[key{userID: userID, date: date}] += sampleInterval.Seconds()
+		listeners++
+	}
+	t.mx.Unlock()
+	listenedSeconds.Add(float64(listeners) * sampleInterval.Seconds())
+}
+
+// flush the seconds which couldn't be written are kept for the next time
+func (t *Tracker) flush(ctx context.Context) {
+	t.mx.Lock()
+	pending := t.pending
+	t.pending = make(map[key]float64)
+	t.mx.Unlock()
+	for k, seconds := range pending {
+		if err := t.storage.AddSeconds(ctx, k.userID, k.date, seconds); err != nil {
+			t.logger.Error(errors.Wrapf(err, "add listening seconds of %s", k.userID))
+			t.mx.Lock()
+			t.pending[k] += seconds
+			t.mx.Unlock()
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package listening
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var listenedSeconds = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "listening",
+	Name:      "seconds_total",
+	Help:      "Seconds the members spent in the voice channel while the bot played, summed over the members.",
+})
@@ -0,0 +1,88 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/listening"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	// usersCollection is shared with the songs of the users
+	usersCollection     = "users"
+	listeningCollection = "listening"
+)
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) days(userID string) *firestore.CollectionRef {
+	return s.client.Collection(usersCollection).Doc(userID).Collection(listeningCollection)
+}
+
+// AddSeconds the doc of the day is created by the first write
+func (s *Storage) AddSeconds(ctx context.Context, userID, date string, seconds float64) error {
+	contexts.LoggerFromContext(ctx).Debugf("DB: AddSeconds user:%s date:%s seconds:%.0f", userID, date, seconds)
+	_, err := s.days(userID).Doc(date).Set(ctx, map[string]interface{}{
+		"date":    date,
+		"seconds": firestore.Increment(seconds),
+	}, firestore.MergeAll)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add listening %s of %s", date, userID)
+	}
+	return nil
+}
+
+func (s *Storage) Days(ctx context.Context, userID, from, to string) ([]listening.Day, error) {
+	iter := s.days(userID).Where("date", ">=", from).Where("date", "<=", to).OrderBy("date", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+	res := make([]listening.Day, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get listening of %s", userID)
+		}
+		var d listening.Day
+		if err := doc.DataTo(&d); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, d)
+	}
+	return res, nil
+}
+
+// DeleteDays all the listening days of the user
+func (s *Storage) DeleteDays(ctx context.Context, userID string) (int, error) {
+	iter := s.days(userID).Documents(ctx)
+	defer iter.Stop()
+	deleted := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return deleted, errors.Wrapf(err, "failed to get listening of %s", userID)
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return deleted, errors.Wrapf(err, "failed to delete listening %s of %s", doc.Ref.ID, userID)
+		}
+		deleted++
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteDays user:%s deleted:%d", userID, deleted)
+	return deleted, nil
+}
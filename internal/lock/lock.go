@@ -0,0 +1,178 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// ErrHeld the lease belongs to another live instance
+var ErrHeld = errors.New("lock is held by another instance")
+
+// HeldError is ErrHeld with the end of the lease of the other instance
+type HeldError struct {
+	Owner   string
+	Expires time.Time
+}
+
+func (e *HeldError) Error() string {
+	return ErrHeld.Error()
+}
+
+func (e *HeldError) Is(target error) bool {
+	return target == ErrHeld
+}
+
+type Storage interface {
+	// Acquire takes or prolongs the lease, takeover is true if it was taken from another instance.
+	// A lease of another instance is a *HeldError.
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (takeover bool, err error)
+	Release(ctx context.Context, key, owner string) error
+}
+
+// Manager holds leases of this instance and renews them until released.
+// An instance that dies stops renewing, so its leases expire and others take over.
+type Manager struct {
+	storage Storage
+	owner   string
+	ttl     time.Duration
+	logger  zap.Logger
+
+	mx sync.Mutex
+	// held the last renewal of the leases of the instance
+	held map[string]time.Time
+	// others the ends of the leases of the other instances, they aren't asked for until then
+	others map[string]time.Time
+	onLost []func(key string)
+}
+
+func NewManager(storage Storage, owner string, ttl time.Duration, logger zap.Logger) *Manager {
+	return &Manager{
+		storage: storage,
+		owner:   owner,
+		ttl:     ttl,
+		logger:  logger,
+		held:    make(map[string]time.Time),
+		others:  make(map[string]time.Time),
+	}
+}
+
+// OnLost is called when a lease can't be renewed, the instance must stop serving the key
+func (m *Manager) OnLost(h func(key string)) {
+	m.onLost = append(m.onLost, h)
+}
+
+func (m *Manager) Owner() string {
+	return m.owner
+}
+
+// Acquire is cheap for the keys the instance already holds and for the live leases of the other instances
+func (m *Manager) Acquire(ctx context.Context, key string) (takeover bool, err error) {
+	m.mx.Lock()
+	_, held := m.held[key]
+	expires, other := m.others[key]
+	m.mx.Unlock()
+	if held {
+		return false, nil
+	}
+	if other && time.Now().Before(expires) {
+		return false, ErrHeld
+	}
+	takeover, err = m.storage.Acquire(ctx, key, m.owner, m.ttl)
+	if err != nil {
+		var heldErr *HeldError
+		if errors.As(err, &heldErr) {
+			m.mx.Lock()
+			m.others[key] = heldErr.Expires
+			m.mx.Unlock()
+		}
+		return false, err
+	}
+	m.mx.Lock()
+	m.held[key] = time.Now()
+	delete(m.others, key)
+	m.mx.Unlock()
+	return takeover, nil
+}
+
+// ReleaseAll lets other instances take over right away
+func (m *Manager) ReleaseAll(ctx context.Context) error {
+	m.mx.Lock()
+	keys := m.keys()
+	m.held = make(map[string]time.Time)
+	m.mx.Unlock()
+	for _, key := range keys {
+		if err := m.storage.Release(ctx, key, m.owner); err != nil {
+			return errors.Wrapf(err, "release %s", key)
+		}
+	}
+	return nil
+}
+
+// Renew prolongs the leases three times per ttl. A lease taken by another instance is lost right away,
+// the one that fails to renew is lost when it expires.
+func (m *Manager) Renew(ctx context.Context) {
+	supervisor.Go(ctx, m.logger, "lock renewal", func(ctx context.Context) {
+		ticker := time.NewTicker(m.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.renew(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+func (m *Manager) renew(ctx context.Context) {
+	m.mx.Lock()
+	keys := m.keys()
+	m.mx.Unlock()
+	for _, key := range keys {
+		now := time.Now()
+		_, err := m.storage.Acquire(ctx, key, m.owner, m.ttl)
+		m.mx.Lock()
+		renewed, ok := m.held[key]
+		switch {
+		case !ok:
+			// released meanwhile
+			m.mx.Unlock()
+			continue
+		case err == nil:
+			m.held[key] = now
+			m.mx.Unlock()
+			continue
+		case !errors.Is(err, ErrHeld) && now.Sub(renewed) < m.ttl:
+			m.mx.Unlock()
+			m.logger.Warnw("lease renewal failed",
+				"key", key,
+				"owner", m.owner,
+				"err", err)
+			continue
+		}
+		delete(m.held, key)
+		m.mx.Unlock()
+		m.logger.Errorw("lease lost",
+			"key", key,
+			"owner", m.owner,
+			"err", err)
+		for _, h := range m.onLost {
+			h(key)
+		}
+	}
+}
+
+func (m *Manager) keys() []string {
+	keys := make([]string, 0, len(m.held))
+	for k := range m.held {
+		keys = append(keys, k)
+	}
+	return keys
+}
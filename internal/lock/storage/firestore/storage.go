@@ -0,0 +1,97 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/lock"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const locksCollection = "locks"
+
+type lease struct {
+	Owner   string    `firestore:"owner"`
+	Expires time.Time `firestore:"expires"`
+}
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+// Acquire the transaction makes only one of the racing instances win
+func (s *Storage) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	ref := s.client.Collection(locksCollection).Doc(key)
+	takeover := false
+	var held *lock.HeldError
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		takeover = false
+		held = nil
+		now := time.Now()
+		doc, err := tx.Get(ref)
+		switch {
+		case status.Code(err) == codes.NotFound:
+		case err != nil:
+			return err
+		default:
+			var l lease
+			if err := doc.DataTo(&l); err != nil {
+				return errors.Wrap(err, "unable to marshal data")
+			}
+			if l.Owner != owner && l.Expires.After(now) {
+				held = &lock.HeldError{Owner: l.Owner, Expires: l.Expires}
+				return held
+			}
+			takeover = l.Owner != owner
+		}
+		return tx.Set(ref, lease{Owner: owner, Expires: now.Add(ttl)})
+	})
+	if err != nil {
+		if held != nil {
+			return false, held
+		}
+		return false, errors.Wrapf(err, "failed to set %s to %s", key, locksCollection)
+	}
+	if takeover {
+		contexts.LoggerFromContext(ctx).Infof("DB: took over lock %s", key)
+	}
+	return takeover, nil
+}
+
+// Release keeps the lease if it was already taken by another instance
+func (s *Storage) Release(ctx context.Context, key, owner string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: Release lock %s", key)
+	ref := s.client.Collection(locksCollection).Doc(key)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var l lease
+		if err := doc.DataTo(&l); err != nil {
+			return errors.Wrap(err, "unable to marshal data")
+		}
+		if l.Owner != owner {
+			return nil
+		}
+		return tx.Delete(ref)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", key, locksCollection)
+	}
+	return nil
+}
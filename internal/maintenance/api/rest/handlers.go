@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// status godoc
+// @summary   Whether the bot is in the maintenance mode and rejects the new requests
+// @produce   json
+// @security  AdminToken
+// @success   200  {object}  maintenance.Status
+// @failure   401  {object}  Response  "Wrong admin token"
+// @router    /admin/maintenance [get]
+func (h *AdminHandler) statusHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.Status())
+}
+
+// set godoc
+// @summary   Switch the maintenance mode until the restart, general.maintenance is the default
+// @description  The queued songs play to the end, the player state is saved and the status of the bot shows the reason.
+// @accept    json
+// @produce   json
+// @security  AdminToken
+// @param     mode  body      setRequest  true  "Enabled and the reason"
+// @success   200   {object}  maintenance.Status
+// @failure   400   {object}  Response  "Incorrect input"
+// @failure   401   {object}  Response  "Wrong admin token"
+// @router    /admin/maintenance [put]
+func (h *AdminHandler) setHandler(c *gin.Context) {
+	var req setRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h.mode.Set(req.Enabled, req.Reason))
+}
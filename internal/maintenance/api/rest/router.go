@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+)
+
+type Mode interface {
+	Status() maintenance.Status
+	Set(enabled bool, reason string) maintenance.Status
+}
+
+// AdminHandler of the maintenance mode, it is mounted on the admin group
+type AdminHandler struct {
+	mode  Mode
+	super *gin.RouterGroup
+}
+
+func NewAdminHandler(mode Mode, superGroup *gin.RouterGroup) *AdminHandler {
+	return &AdminHandler{
+		mode:  mode,
+		super: superGroup,
+	}
+}
+
+func (h *AdminHandler) Router() *gin.RouterGroup {
+	group := h.super.Group("/maintenance")
+	group.GET("", h.statusHandler)
+	group.PUT("", h.setHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
+
+type setRequest struct {
+	Enabled bool `json:"enabled"`
+	// Reason shown to the members who request a song
+	Reason string `json:"reason"`
+}
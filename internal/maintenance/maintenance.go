@@ -0,0 +1,92 @@
+package maintenance
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ErrMaintenance the bot doesn't take new requests, the queued songs still play
+var ErrMaintenance = errors.New("the bot is under maintenance")
+
+var enabledGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "halvabot",
+	Name:      "maintenance_enabled",
+	Help:      "1 while the bot is in the maintenance mode and rejects the new requests.",
+})
+
+type Status struct {
+	Enabled bool `json:"enabled"`
+	// Reason shown to the members, e.g. the time of the deploy
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since,omitempty"`
+}
+
+// Mode of the whole bot, it is switched by the admins before a deploy
+type Mode struct {
+	mx       sync.RWMutex
+	status   Status
+	watchers []func(Status)
+}
+
+// NewMode enabled is the mode at the start, general.maintenance in the config
+func NewMode(enabled bool) *Mode {
+	m := &Mode{}
+	if enabled {
+		m.status = Status{Enabled: true, Since: time.Now()}
+		enabledGauge.Set(1)
+	}
+	return m
+}
+
+func (m *Mode) Status() Status {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.status
+}
+
+func (m *Mode) Enabled() bool {
+	return m.Status().Enabled
+}
+
+// Set the reason is dropped when the mode is off, the watchers are called on every change
+func (m *Mode) Set(enabled bool, reason string) Status {
+	m.mx.Lock()
+	if !enabled {
+		reason = ""
+	}
+	changed := m.status.Enabled != enabled || m.status.Reason != reason
+	if m.status.Enabled != enabled {
+		m.status.Since = time.Now()
+	}
+	m.status.Enabled = enabled
+	m.status.Reason = reason
+	if !enabled {
+		m.status.Since = time.Time{}
+	}
+	status := m.status
+	watchers := m.watchers
+	m.mx.Unlock()
+
+	if enabled {
+		enabledGauge.Set(1)
+	} else {
+		enabledGauge.Set(0)
+	}
+	if changed {
+		for _, w := range watchers {
+			w(status)
+		}
+	}
+	return status
+}
+
+// OnChange the watcher is called outside of the lock, so it may read the mode
+func (m *Mode) OnChange(w func(Status)) {
+	m.mx.Lock()
+	m.watchers = append(m.watchers, w)
+	m.mx.Unlock()
+}
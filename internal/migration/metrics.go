@@ -0,0 +1,13 @@
+package migration
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var migrations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "migrations",
+	Name:      "runs_total",
+	Help:      "Migrations run by this instance by result, applied or failed.",
+}, []string{"result"})
@@ -0,0 +1,105 @@
+package migration
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// staleAfter a migration claimed so long ago is considered abandoned by a dead instance
+const staleAfter = 30 * time.Minute
+
+// ErrRunning another instance runs the migration
+var ErrRunning = errors.New("the migration is running on another instance")
+
+// Migration of the stored documents, Up must be safe to run again after it failed halfway
+type Migration struct {
+	// Version orders the migrations, it is never reused
+	Version int
+	Name    string
+	Up      func(ctx context.Context) error
+}
+
+// Record of a migration in the storage
+type Record struct {
+	Version  int       `firestore:"version"`
+	Name     string    `firestore:"name"`
+	Owner    string    `firestore:"owner"`
+	Started  time.Time `firestore:"started"`
+	Finished time.Time `firestore:"finished,omitempty"`
+}
+
+func (r *Record) Done() bool {
+	return !r.Finished.IsZero()
+}
+
+type Storage interface {
+	// Claim records the migration as started, false if it is done, ErrRunning if another instance runs it
+	Claim(ctx context.Context, m *Migration, owner string, staleAfter time.Duration) (bool, error)
+	Finish(ctx context.Context, version int) error
+	// Release the claim of a failed migration, it runs again on the next start
+	Release(ctx context.Context, version int) error
+}
+
+// Runner applies the migrations in order, one instance of the cluster runs each of them
+type Runner struct {
+	storage    Storage
+	migrations []Migration
+	owner      string
+	logger     zap.Logger
+}
+
+func NewRunner(storage Storage, migrations []Migration, owner string, logger zap.Logger) *Runner {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return &Runner{
+		storage:    storage,
+		migrations: sorted,
+		owner:      owner,
+		logger:     logger,
+	}
+}
+
+// Run the pending migrations. The storage errors are returned to repeat the run when Firestore is back,
+// a failed migration is only logged, the next ones wait for it to be fixed.
+func (r *Runner) Run(ctx context.Context) error {
+	ctx = contexts.WithLogger(ctx, r.logger)
+	for i := range r.migrations {
+		m := &r.migrations[i]
+		claimed, err := r.storage.Claim(ctx, m, r.owner, staleAfter)
+		if errors.Is(err, ErrRunning) {
+			r.logger.Infow("migration is running on another instance", "version", m.Version, "name", m.Name)
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "claim migration %d", m.Version)
+		}
+		if !claimed {
+			continue
+		}
+		r.logger.Infow("running migration", "version", m.Version, "name", m.Name)
+		start := time.Now()
+		if err := m.Up(ctx); err != nil {
+			migrations.WithLabelValues("failed").Inc()
+			r.logger.Error(errors.Wrapf(err, "migration %d %s", m.Version, m.Name))
+			if err := r.storage.Release(ctx, m.Version); err != nil {
+				r.logger.Error(errors.Wrapf(err, "release migration %d", m.Version))
+			}
+			return nil
+		}
+		if err := r.storage.Finish(ctx, m.Version); err != nil {
+			return errors.Wrapf(err, "finish migration %d", m.Version)
+		}
+		migrations.WithLabelValues("applied").Inc()
+		r.logger.Infow("migration applied", "version", m.Version, "name", m.Name, "elapsed", time.Since(start))
+	}
+	return nil
+}
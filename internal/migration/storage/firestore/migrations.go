@@ -0,0 +1,79 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/migration"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+// batchSize maximum of the writes in a batch by the firestore docs
+const batchSize = 500
+
+// Migrations of the documents, append the new ones with the next version
+func Migrations(client *firestore.Client) []migration.Migration {
+	return []migration.Migration{
+		{
+			Version: 1,
+			Name:    "songs-service",
+			Up: func(ctx context.Context) error {
+				// the first songs were saved before the service was stored, all of them are from YouTube
+				return updateAll(ctx, client, client.Collection("songs").Query, func(doc *firestore.DocumentSnapshot) []firestore.Update {
+					if s, err := doc.DataAt("service"); err == nil && s != "" {
+						return nil
+					}
+					return []firestore.Update{{Path: "service", Value: string(pkg.ServiceYouTube)}}
+				})
+			},
+		},
+	}
+}
+
+// updateAll applies the updates returned for the documents of the query in batches, nil skips the document
+func updateAll(ctx context.Context, client *firestore.Client, q firestore.Query, update func(doc *firestore.DocumentSnapshot) []firestore.Update) error {
+	iter := q.Documents(ctx)
+	defer iter.Stop()
+	batch := client.Batch()
+	pending, updated := 0, 0
+	commit := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return errors.Wrap(err, "commit batch")
+		}
+		updated += pending
+		batch, pending = client.Batch(), 0
+		return nil
+	}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to get docs")
+		}
+		updates := update(doc)
+		if len(updates) == 0 {
+			continue
+		}
+		batch.Update(doc.Ref, updates)
+		pending++
+		if pending == batchSize {
+			if err := commit(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := commit(); err != nil {
+		return err
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: migration updated %d docs", updated)
+	return nil
+}
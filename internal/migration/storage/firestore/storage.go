@@ -0,0 +1,88 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/migration"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const migrationsCollection = "migrations"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+// doc the versions are padded to list the migrations in order in the console
+func (s *Storage) doc(version int) *firestore.DocumentRef {
+	return s.client.Collection(migrationsCollection).Doc(fmt.Sprintf("%04d", version))
+}
+
+// Claim the transaction makes only one of the racing instances run the migration
+func (s *Storage) Claim(ctx context.Context, m *migration.Migration, owner string, staleAfter time.Duration) (bool, error) {
+	ref := s.doc(m.Version)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		doc, err := tx.Get(ref)
+		switch {
+		case status.Code(err) == codes.NotFound:
+		case err != nil:
+			return err
+		default:
+			var r migration.Record
+			if err := doc.DataTo(&r); err != nil {
+				return errors.Wrap(err, "unable to marshal data")
+			}
+			if r.Done() {
+				return errDone
+			}
+			if r.Started.Add(staleAfter).After(now) {
+				return migration.ErrRunning
+			}
+		}
+		return tx.Set(ref, migration.Record{Version: m.Version, Name: m.Name, Owner: owner, Started: now})
+	})
+	switch {
+	case errors.Is(err, errDone):
+		return false, nil
+	case errors.Is(err, migration.ErrRunning):
+		return false, migration.ErrRunning
+	case err != nil:
+		return false, errors.Wrapf(err, "failed to claim %d in %s", m.Version, migrationsCollection)
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: Claim migration %d", m.Version)
+	return true, nil
+}
+
+// errDone ends the transaction of a done migration without a write
+var errDone = errors.New("the migration is done")
+
+func (s *Storage) Finish(ctx context.Context, version int) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: Finish migration %d", version)
+	_, err := s.doc(version).Update(ctx, []firestore.Update{{Path: "finished", Value: time.Now()}})
+	if err != nil {
+		return errors.Wrapf(err, "failed to finish %d in %s", version, migrationsCollection)
+	}
+	return nil
+}
+
+func (s *Storage) Release(ctx context.Context, version int) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: Release migration %d", version)
+	if _, err := s.doc(version).Delete(ctx); err != nil {
+		return errors.Wrapf(err, "failed to delete %d from %s", version, migrationsCollection)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/approval"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	approveButtonPrefix = "music:approve:"
+	rejectButtonPrefix  = "music:reject:"
+
+	messageApprovalPending  = ":raised_hand: <@%s> **requested** `%s - %s` %s, a DJ has to approve it"
+	messageApprove          = "Approve"
+	messageReject           = "Reject"
+	messageApproved         = ":white_check_mark: **Approved by <@%s>** `%s - %s` requested by <@%s>"
+	messageRejected         = ":no_entry: **Rejected by <@%s>** `%s - %s` requested by <@%s>"
+	messageApprovalExpired  = ":hourglass: **Nobody approved** `%s - %s` requested by <@%s>"
+	messageApprovalFailed   = ":x: **The request couldn't be saved, try again later**"
+	messageApprovalNotFound = ":x: **The request was already decided or it's too late**"
+)
+
+// Approvals of the songs requested by the members who aren't DJs in the guilds with the approval feature
+type Approvals interface {
+	Add(ctx context.Context, r *approval.Request) error
+	SetMessage(ctx context.Context, r *approval.Request, messageID string) error
+	Approve(ctx context.Context, id string) (*approval.Request, error)
+	Reject(ctx context.Context, id string) (*approval.Request, error)
+	Expired(ctx context.Context, id string) (*approval.Request, error)
+}
+
+// requestApproval finds the song and puts it in the pending list instead of the queue
+func (s *Service) requestApproval(ds *dg.Session, m *dg.MessageCreate, query, channelID string, next bool) {
+	song, err := s.player.Find(s.ctx, query, m.GuildID)
+	if err != nil {
+		switch {
+		case errors.Is(err, youtube.ErrSongNotFound):
+			s.sendNotFoundMessage(ds, m)
+		case errors.Is(err, youtube.ErrUnavailable) || errors.Is(err, youtube.ErrQuotaExhausted):
+			s.sendYouTubeUnavailableMessage(ds, m)
+		case errors.Is(err, youtube.ErrLibraryOnly):
+			s.sendComplexMessage(ds, m.ChannelID, strmsg(messageLibraryOnly), statusLevel)
+		case errors.Is(err, youtube.ErrExplicit) || errors.Is(err, player.ErrBlocked):
+			s.sendAgeRestrictionMessage(ds, m)
+		default:
+			s.logger.Error(errors.Wrapf(err, "find song=%s", query))
+			s.sendInternalErrorMessage(ds, m, statusLevel)
+		}
+		return
+	}
+	r := &approval.Request{
+		GuildID:        m.GuildID,
+		UserID:         m.Author.ID,
+		Song:           *song,
+		VoiceChannelID: channelID,
+		ChannelID:      m.ChannelID,
+		Next:           next,
+	}
+	if err := s.approvals.Add(s.ctx, r); err != nil {
+		s.logger.Error(err)
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageApprovalFailed), statusLevel)
+		return
+	}
+	// sent even where the status messages are deleted, the DJs have to see it
+	msg, err := ds.ChannelMessageSendComplex(m.ChannelID, &dg.MessageSend{
+		Content: fmt.Sprintf(messageApprovalPending, m.Author.ID, song.ArtistName, song.Title, formatSeconds(song.Duration)),
+		Components: command.ButtonRows([]dg.Button{
+			{Label: messageApprove, Style: dg.SuccessButton, CustomID: approveButtonPrefix + r.ID},
+			{Label: messageReject, Style: dg.DangerButton, CustomID: rejectButtonPrefix + r.ID},
+		}),
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "send approval request"))
+		return
+	}
+	if err := s.approvals.SetMessage(s.ctx, r, msg.ID); err != nil {
+		s.logger.Error(errors.Wrap(err, "set approval message"))
+	}
+	time.AfterFunc(approval.Timeout, func() {
+		s.expireApproval(ds, r.ID)
+	})
+}
+
+// expireApproval removes the buttons if nobody decided, the request may be gone already
+func (s *Service) expireApproval(ds *dg.Session, id string) {
+	r, err := s.approvals.Expired(s.ctx, id)
+	if errors.Is(err, approval.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "expire approval %s", id))
+		return
+	}
+	s.editApprovalMessage(ds, r, fmt.Sprintf(messageApprovalExpired, r.Song.ArtistName, r.Song.Title, r.UserID))
+}
+
+func (s *Service) editApprovalMessage(ds *dg.Session, r *approval.Request, content string) {
+	if r.MessageID == "" {
+		return
+	}
+	_, err := ds.ChannelMessageEditComplex(&dg.MessageEdit{
+		ID:         r.MessageID,
+		Channel:    r.ChannelID,
+		Content:    &content,
+		Components: []dg.MessageComponent{},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "edit approval message %s", r.MessageID))
+	}
+}
+
+// approveButtonHandler a DJ queues the requested song as if the member had the DJ role
+func (s *Service) approveButtonHandler(ds *dg.Session, i *dg.InteractionCreate, id string) {
+	user, ok := s.approvalDJ(ds, i)
+	if !ok {
+		return
+	}
+	r, err := s.approvals.Approve(s.ctx, id)
+	if err != nil {
+		s.approvalNotFound(ds, i, id, err)
+		return
+	}
+	s.respondApproval(ds, i, fmt.Sprintf(messageApproved, user.ID, r.Song.ArtistName, r.Song.Title, r.UserID))
+	song := r.Song
+	if _, err := s.player.PlayConfirmed(s.ctx, &song, r.UserID, r.GuildID, r.VoiceChannelID, r.Next); err != nil {
+		if errors.Is(err, player.ErrQueueFull) {
+			s.sendComplexMessage(ds, r.ChannelID, strmsg(messageQueueFull), statusLevel)
+			return
+		}
+		if errors.Is(err, maintenance.ErrMaintenance) {
+			s.sendMaintenanceMessage(ds, r.ChannelID)
+			return
+		}
+		s.logger.Error(errors.Wrapf(err, "play approved song=%s", song.Title))
+		s.sendComplexMessage(ds, r.ChannelID, strmsg(messageConfirmFailed), statusLevel)
+		return
+	}
+	s.reportDownload(ds, r.ChannelID, &song)
+}
+
+func (s *Service) rejectButtonHandler(ds *dg.Session, i *dg.InteractionCreate, id string) {
+	user, ok := s.approvalDJ(ds, i)
+	if !ok {
+		return
+	}
+	r, err := s.approvals.Reject(s.ctx, id)
+	if err != nil {
+		s.approvalNotFound(ds, i, id, err)
+		return
+	}
+	s.respondApproval(ds, i, fmt.Sprintf(messageRejected, user.ID, r.Song.ArtistName, r.Song.Title, r.UserID))
+}
+
+func (s *Service) approvalDJ(ds *dg.Session, i *dg.InteractionCreate) (*dg.User, bool) {
+	user := command.InteractionUser(i)
+	if user == nil || i.Member == nil || !s.isMemberDJ(ds, i.GuildID, i.ChannelID, user.ID, i.Member.Roles) {
+		s.respondEphemeral(ds, i, messageNotDJ)
+		return nil, false
+	}
+	return user, true
+}
+
+func (s *Service) approvalNotFound(ds *dg.Session, i *dg.InteractionCreate, id string, err error) {
+	if !errors.Is(err, approval.ErrNotFound) {
+		s.logger.Error(errors.Wrapf(err, "take approval %s", id))
+	}
+	s.respondEphemeral(ds, i, messageApprovalNotFound)
+}
+
+// respondApproval the decision replaces the buttons
+func (s *Service) respondApproval(ds *dg.Session, i *dg.InteractionCreate, content string) {
+	err := ds.InteractionRespond(i.Interaction, &dg.InteractionResponse{
+		Type: dg.InteractionResponseUpdateMessage,
+		Data: &dg.InteractionResponseData{
+			Content:    content,
+			Components: []dg.MessageComponent{},
+		},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to approval button"))
+	}
+}
+
+// needsApproval the guild moderates the requests and the author isn't a DJ
+func (s *Service) needsApproval(ds *dg.Session, m *dg.MessageCreate) bool {
+	return s.settings.Enabled(m.GuildID, guild.Approval) && !s.isDJ(ds, m)
+}
@@ -0,0 +1,117 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/artist"
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	artistCommand      = "artist"
+	artistButtonPrefix = "music:artist:"
+	artistSongs        = 10
+	// artistQueue songs of the artist queued by the button, the most played
+	artistQueue = 10
+
+	messageArtist         = ":microphone: **%s** %d plays, %d songs"
+	messageArtistPlayAll  = "Play all"
+	messageArtistQueued   = ":notes: <@%s> **queued %d songs by %s**"
+	messageArtistNotFound = ":x: **Artist not found**"
+	messageArtistUsage    = ":x: **Write the name of the artist:** `%sartist <name>`"
+)
+
+// Artists the songs of the library grouped by the artist
+type Artists interface {
+	Get(ctx context.Context, id string) (*artist.Artist, error)
+	Find(ctx context.Context, name string) (*artist.Artist, error)
+}
+
+// artistMessageHandler the top songs of the artist, the button queues them
+func (s *Service) artistMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	name := strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+artistCommand))
+	if name == "" {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageArtistUsage, s.prefix)), statusLevel)
+		return
+	}
+	a, err := s.artists.Find(s.ctx, name)
+	if errors.Is(err, artist.ErrNotFound) {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageArtistNotFound), statusLevel)
+		return
+	}
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "find artist"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	lines := make([]string, 0, artistSongs)
+	for i := 0; i < len(a.Songs) && i < artistSongs; i++ {
+		lines = append(lines, fmt.Sprintf("%d. [%s](%s) %d plays", i+1, a.Songs[i].Title, a.Songs[i].URL, a.Songs[i].Playbacks))
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Embeds: []*dg.MessageEmbed{{
+			Title:       fmt.Sprintf(messageArtist, a.Name, a.Plays, a.Count),
+			URL:         a.URL,
+			Description: strings.Join(lines, "\n"),
+		}},
+		Components: command.ButtonRows([]dg.Button{{
+			Label:    messageArtistPlayAll,
+			Style:    dg.PrimaryButton,
+			CustomID: artistButtonPrefix + a.ID,
+		}}),
+	}, statusLevel)
+}
+
+// artistButtonHandler queues the most played songs of the artist until the queue is full
+func (s *Service) artistButtonHandler(ds *dg.Session, i *dg.InteractionCreate, id string) {
+	user := command.InteractionUser(i)
+	if user == nil || i.GuildID == "" {
+		return
+	}
+	if s.settings.Enabled(i.GuildID, guild.Approval) && (i.Member == nil || !s.isMemberDJ(ds, i.GuildID, i.ChannelID, user.ID, i.Member.Roles)) {
+		s.respondEphemeral(ds, i, messageNotDJ)
+		return
+	}
+	channelID, err := findVoiceChannelID(ds, i.GuildID, user.ID)
+	if err != nil {
+		s.respondEphemeral(ds, i, messageNotVoiceChannel)
+		return
+	}
+	a, err := s.artists.Get(s.ctx, id)
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "get artist %s", id))
+		s.respondEphemeral(ds, i, messageArtistNotFound)
+		return
+	}
+	err = ds.InteractionRespond(i.Interaction, &dg.InteractionResponse{Type: dg.InteractionResponseDeferredMessageUpdate})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to artist button"))
+	}
+	queued := 0
+	for j := 0; j < len(a.Songs) && j < artistQueue; j++ {
+		_, _, err := s.player.Play(s.ctx, a.Songs[j].URL, user.ID, i.GuildID, channelID)
+		if errors.Is(err, player.ErrQueueFull) {
+			break
+		}
+		if errors.Is(err, maintenance.ErrMaintenance) {
+			s.sendMaintenanceMessage(ds, i.ChannelID)
+			return
+		}
+		if err != nil {
+			s.logger.Warnw("artist song isn't queued", "song", a.Songs[j].ID, "err", err)
+			continue
+		}
+		queued++
+	}
+	msg := fmt.Sprintf(messageArtistQueued, user.ID, queued, a.Name)
+	s.sendComplexMessage(ds, i.ChannelID, strmsg(msg), statusLevel)
+}
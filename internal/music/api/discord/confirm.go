@@ -0,0 +1,94 @@
+package discord
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	confirmButtonPrefix = "music:confirm:"
+	// confirmTimeout the long song is forgotten if no DJ confirms it
+	confirmTimeout = 10 * time.Minute
+)
+
+// pendingSong is longer than the limit of the guild and waits for a DJ
+type pendingSong struct {
+	song      *pkg.Song
+	userID    string
+	guildID   string
+	channelID string
+	next      bool
+	created   time.Time
+}
+
+// addPending returns the id of the confirmation button
+func (s *Service) addPending(p *pendingSong) string {
+	id := uuid.New().String()[:8]
+	s.pendingMx.Lock()
+	defer s.pendingMx.Unlock()
+	for k, v := range s.pending {
+		if time.Since(v.created) > confirmTimeout {
+			delete(s.pending, k)
+		}
+	}
+	s.pending[id] = p
+	return id
+}
+
+func (s *Service) takePending(id string) (*pendingSong, bool) {
+	s.pendingMx.Lock()
+	defer s.pendingMx.Unlock()
+	p, ok := s.pending[id]
+	delete(s.pending, id)
+	if !ok || time.Since(p.created) > confirmTimeout {
+		return nil, false
+	}
+	return p, true
+}
+
+// confirmButtonHandler a DJ plays the long song, the button is removed when it is used
+func (s *Service) confirmButtonHandler(ds *discordgo.Session, i *discordgo.InteractionCreate, id string) {
+	user := command.InteractionUser(i)
+	if user == nil || i.Member == nil || !s.isMemberDJ(ds, i.GuildID, i.ChannelID, user.ID, i.Member.Roles) {
+		s.respondEphemeral(ds, i, messageNotDJ)
+		return
+	}
+	p, ok := s.takePending(id)
+	if !ok {
+		s.respondEphemeral(ds, i, messageConfirmExpired)
+		return
+	}
+	err := ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    confirmedMessage(p.song, user.ID),
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to confirm button"))
+	}
+	if _, err := s.player.PlayConfirmed(s.ctx, p.song, p.userID, p.guildID, p.channelID, p.next); err != nil {
+		if errors.Is(err, maintenance.ErrMaintenance) {
+			s.sendMaintenanceMessage(ds, i.ChannelID)
+			return
+		}
+		s.logger.Error(errors.Wrapf(err, "play confirmed song=%s", p.song.Title))
+		s.sendComplexMessage(ds, i.ChannelID, strmsg(messageConfirmFailed), statusLevel)
+		return
+	}
+	s.reportDownload(ds, i.ChannelID, p.song)
+}
+
+func (s *Service) respondEphemeral(ds *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	if err := command.RespondEphemeral(ds, i, msg); err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to confirm button"))
+	}
+}
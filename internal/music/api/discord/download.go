@@ -0,0 +1,63 @@
+package discord
+
+import (
+	"fmt"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+)
+
+const (
+	messageDownloading      = ":arrow_down: **Downloading** `%s - %s` %d%%"
+	messageDownloaded       = ":white_check_mark: **Downloaded** `%s - %s`"
+	messageDownloadCanceled = ":x: **Download canceled** `%s - %s`"
+	messageDownloadFailed   = ":x: **Download failed** `%s - %s`, it keeps streaming"
+)
+
+// Downloads of the songs in the background
+type Downloads interface {
+	WatchDownload(id pkg.SongID) (<-chan youtube.Progress, bool)
+}
+
+// reportDownload edits a message in the channel as the song downloads, only in the status channels
+func (s *Service) reportDownload(ds *dg.Session, channelID string, song *pkg.Song) {
+	if s.downloads == nil || s.toDelete(channelID, statusLevel) {
+		return
+	}
+	progress, ok := s.downloads.WatchDownload(song.ID)
+	if !ok {
+		return
+	}
+	go supervisor.Safe(s.logger, "download progress", func() {
+		var msg *dg.Message
+		for p := range progress {
+			content := downloadContent(song, p)
+			var err error
+			if msg == nil {
+				msg, err = ds.ChannelMessageSend(channelID, content)
+			} else {
+				_, err = ds.ChannelMessageEdit(channelID, msg.ID, content)
+			}
+			if err != nil {
+				s.logger.Error(errors.Wrap(err, "report download"))
+			}
+		}
+	})
+}
+
+func downloadContent(song *pkg.Song, p youtube.Progress) string {
+	switch {
+	case !p.Done:
+		return fmt.Sprintf(messageDownloading, song.ArtistName, song.Title, p.Percent)
+	case p.Err == nil:
+		return fmt.Sprintf(messageDownloaded, song.ArtistName, song.Title)
+	case errors.Is(p.Err, youtube.ErrDownloadCanceled):
+		return fmt.Sprintf(messageDownloadCanceled, song.ArtistName, song.Title)
+	default:
+		return fmt.Sprintf(messageDownloadFailed, song.ArtistName, song.Title)
+	}
+}
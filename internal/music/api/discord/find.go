@@ -0,0 +1,85 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	find             = "find"
+	findButtonPrefix = "music:find:"
+	// findResults one button for each
+	findResults = 5
+
+	messageFindResults = ":mag: **Found in the library**"
+	messageFindUsage   = ":x: **Write a part of the title or the artist:** `%sfind <text>`"
+	messageFindFailed  = ":x: **The song can't be queued, try `%splay` with the link**"
+)
+
+// Songs of the library, the search doesn't spend the YouTube quota
+type Songs interface {
+	SearchSongs(query string, n int) []pkg.Song
+}
+
+// findMessageHandler the buttons queue the found songs by their links
+func (s *Service) findMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	query := strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+find))
+	if query == "" {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageFindUsage, s.prefix)), statusLevel)
+		return
+	}
+	songs := s.songs.SearchSongs(query, findResults)
+	if len(songs) == 0 {
+		s.sendNotFoundMessage(ds, m)
+		return
+	}
+	lines := make([]string, 0, len(songs))
+	buttons := make([]dg.Button, 0, len(songs))
+	for i := range songs {
+		line := fmt.Sprintf("%d. [%s](%s)", i+1, songs[i].Title, songs[i].URL)
+		if songs[i].ArtistName != "" {
+			line += " — " + songs[i].ArtistName
+		}
+		lines = append(lines, line+" "+intToEmoji(songs[i].Playbacks))
+		buttons = append(buttons, dg.Button{
+			Label:    strconv.Itoa(i + 1),
+			Style:    dg.SecondaryButton,
+			CustomID: findButtonPrefix + songs[i].URL,
+		})
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Embeds:     []*dg.MessageEmbed{{Title: messageFindResults, Description: strings.Join(lines, "\n")}},
+		Components: command.ButtonRows(buttons),
+	}, statusLevel)
+}
+
+// findButtonHandler the click plays the song as if its author sent the play command with the link
+func (s *Service) findButtonHandler(ds *dg.Session, i *dg.InteractionCreate, url string) {
+	if command.InteractionUser(i) == nil || i.GuildID == "" || pkg.GetIDFromURL(url).Service == "" {
+		s.respondEphemeral(ds, i, fmt.Sprintf(messageFindFailed, s.prefix))
+		return
+	}
+	s.playFromButton(ds, i, url)
+}
+
+// playFromButton the clicking member is the author of the play command
+func (s *Service) playFromButton(ds *dg.Session, i *dg.InteractionCreate, query string) {
+	err := ds.InteractionRespond(i.Interaction, &dg.InteractionResponse{Type: dg.InteractionResponseDeferredMessageUpdate})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to find button"))
+	}
+	s.play(ds, &dg.MessageCreate{Message: &dg.Message{
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Author:    command.InteractionUser(i),
+		Member:    i.Member,
+	}}, query, false)
+}
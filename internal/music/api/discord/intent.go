@@ -0,0 +1,93 @@
+package discord
+
+import (
+	"strings"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+// intent the phrases a member says to the bot instead of the command
+type intent struct {
+	phrases []string
+	command string
+	// query the rest of the text is the argument, otherwise nothing follows the phrase
+	query bool
+}
+
+// intents are tried in order, the longer phrases go before the ones they start with
+var intents = []intent{
+	{phrases: []string{"play next", "playnext", "queue next"}, command: playNext, query: true},
+	{phrases: []string{"preview", "let me hear"}, command: preview, query: true},
+	{phrases: []string{"seek to", "seek", "jump to", "rewind to", "fast forward to"}, command: seek, query: true},
+	{phrases: []string{"find", "search for", "search", "look for", "look up"}, command: find, query: true},
+	{phrases: []string{"radio", "play radio", "play the radio", "toggle radio", "toggle the radio"}, command: radio},
+	{phrases: []string{"play", "play me", "put on", "queue up", "i want to hear", "i wanna hear", "listen to"}, command: play, query: true},
+	{phrases: []string{"skip", "skip it", "skip this", "skip this song", "next", "next song"}, command: skip},
+	{phrases: []string{"pause", "pause it", "pause this", "pause the music", "hold on"}, command: pause},
+	{phrases: []string{"resume", "unpause", "continue", "go on", "resume the music"}, command: resume},
+	{phrases: []string{"now", "now playing", "what's playing", "whats playing", "what is playing", "what song is this", "what is this song"}, command: nowPlaying},
+	{phrases: []string{"queue", "show queue", "show the queue", "what's next", "whats next", "what is next"}, command: queueCommand},
+	{phrases: []string{"shuffle", "shuffle the queue", "mix it up"}, command: shuffleQueue},
+	{phrases: []string{"undo", "undo that", "take that back"}, command: undo},
+	{phrases: []string{"loop", "repeat", "loop this", "repeat this", "repeat this song"}, command: loop},
+	{phrases: []string{"leave", "disconnect", "go away", "get out", "bye"}, command: disconnect},
+}
+
+// fillers are dropped from the beginning of the text
+var fillers = [][]string{{"hey"}, {"hi"}, {"please"}, {"pls"}, {"can", "you"}, {"could", "you"}, {"would", "you"}, {"will", "you"}}
+
+// parseIntent maps the text after the mention of the bot to a command with the prefix,
+// e.g. "can you play some daft punk please" to "play some daft punk"
+func parseIntent(prefix string) command.MentionParser {
+	return func(text string) (string, bool) {
+		words := strings.Fields(text)
+		lower := make([]string, len(words))
+		for i, w := range words {
+			lower[i] = strings.ToLower(strings.Trim(w, ",.!?"))
+		}
+		for trimmed := true; trimmed; {
+			trimmed = false
+			for _, f := range fillers {
+				if hasWords(lower, f) {
+					words, lower = words[len(f):], lower[len(f):]
+					trimmed = true
+				}
+			}
+		}
+		if n := len(lower); n > 0 && lower[n-1] == "please" {
+			words, lower = words[:n-1], lower[:n-1]
+		}
+		if len(words) == 0 {
+			return "", false
+		}
+		for _, in := range intents {
+			for _, phrase := range in.phrases {
+				p := strings.Fields(phrase)
+				if !hasWords(lower, p) {
+					continue
+				}
+				if !in.query && len(p) == len(lower) {
+					return prefix + in.command, true
+				}
+				if in.query && len(p) < len(lower) {
+					query := strings.TrimRight(strings.Join(words[len(p):], " "), "?!.")
+					return strings.TrimSpace(prefix+in.command) + " " + query, true
+				}
+			}
+		}
+		return "", false
+	}
+}
+
+// hasWords the text starts with the words
+func hasWords(text, words []string) bool {
+	if len(text) < len(words) {
+		return false
+	}
+	for i, w := range words {
+		if text[i] != w {
+			return false
+		}
+	}
+	return true
+}
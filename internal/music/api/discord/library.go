@@ -0,0 +1,409 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/export"
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	favorite        = "fav"
+	favoriteList    = "list"
+	playlist        = "playlist"
+	playlistShow    = "show"
+	playlistCreate  = "create"
+	playlistAdd     = "add"
+	playlistRemove  = "remove"
+	playlistDelete  = "delete"
+	playlistShare   = "share"
+	playlistUnshare = "unshare"
+	playlistExport  = "export"
+	playlistPlay    = "play"
+	playlistShuffle = "shuffle"
+
+	// maxListed entries of a list in a message, the rest are counted
+	maxListed = 20
+
+	messageFavoriteAdded    = ":heart: **Added to favorites** `%s - %s`"
+	messageFavoriteRemoved  = ":broken_heart: **Removed from favorites** `%s - %s`"
+	messageFavorites        = ":heart: **Favorites**"
+	messagePlaylists        = ":notepad_spiral: **Playlists**"
+	messagePlaylistCreated  = ":white_check_mark: **Playlist %s created**"
+	messagePlaylistAdded    = ":white_check_mark: **Added to %s** `%s - %s`, %d songs"
+	messagePlaylistRemoved  = ":x: **Removed from %s** `%s - %s`"
+	messagePlaylistDeleted  = ":x: **Playlist %s deleted**"
+	messagePlaylistShared   = ":link: **Playlist %s** %s"
+	messagePlaylistUnshared = ":no_entry_sign: **The link to %s doesn't work anymore**"
+	messageExportLink       = "Open the link to export **%s** to %s, it works once for 10 minutes\n%s"
+	messageExportSent       = ":incoming_envelope: **The export link is sent in DM**"
+	messageExportServices   = ":x: **The playlists are exported to %s**"
+	messageExportDisabled   = ":x: **The export is disabled**"
+	messagePlaylistQueued   = ":notes: **%s** %d of %d songs queued"
+	messagePlaylistLoading  = ":hourglass_flowing_sand: **Loading the playlist** %d of %d songs"
+	messageYouTubePlaylist  = "YouTube playlist"
+	messagePlaylistNone     = ":x: **No song of %s can be queued here**"
+	messagePlaylistDJOnly   = ":x: **Only DJs can queue playlists while the requests are limited or approved**"
+	messageLibraryError     = ":x: **%s**"
+	messageListEmpty        = "Nothing here yet"
+	messageMore             = "and %d more"
+	messagePlaylistUsage    = "`%[1]splaylist` your playlists\n" +
+		"`%[1]splaylist show <name>` songs of the playlist\n" +
+		"`%[1]splaylist create <name>` new playlist\n" +
+		"`%[1]splaylist add <name>` add the current song\n" +
+		"`%[1]splaylist remove <name> <number>` remove the song\n" +
+		"`%[1]splaylist delete <name>` delete the playlist\n" +
+		"`%[1]splaylist share <name>` public link to the playlist\n" +
+		"`%[1]splaylist unshare <name>` disable the link\n" +
+		"`%[1]splaylist export <name> <service>` copy to your youtube or spotify account\n" +
+		"`%[1]splaylist play <name> [shuffle]` queue the songs"
+)
+
+// Library of the favorite songs and the playlists of the users
+type Library interface {
+	ToggleFavorite(ctx context.Context, userID string, song *pkg.Song) (bool, error)
+	Favorites(ctx context.Context, userID string) ([]library.Entry, error)
+	Playlists(ctx context.Context, userID string) ([]library.Playlist, error)
+	Playlist(ctx context.Context, userID, name string) (*library.Playlist, error)
+	CreatePlaylist(ctx context.Context, userID, name string) (*library.Playlist, error)
+	AddToPlaylist(ctx context.Context, userID, name string, song *pkg.Song) (*library.Playlist, error)
+	RemoveFromPlaylist(ctx context.Context, userID, name string, position int) (*library.Entry, error)
+	DeletePlaylist(ctx context.Context, userID, name string) error
+	SharePlaylist(ctx context.Context, userID, name string) (string, error)
+	UnsharePlaylist(ctx context.Context, userID, name string) error
+}
+
+// Exporter of the playlists to the accounts of the users on the music services
+type Exporter interface {
+	Services() []string
+	AuthURL(ctx context.Context, userID, name, service string) (string, error)
+}
+
+// favoriteMessageHandler toggles the current song in the favorites or lists them
+func (s *Service) favoriteMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+favorite))
+	if len(args) == 1 && strings.ToLower(args[0]) == favoriteList {
+		favorites, err := s.library.Favorites(s.ctx, m.Author.ID)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		lines := make([]string, 0, len(favorites))
+		for i := range favorites {
+			lines = append(lines, entryLine(i+1, &favorites[i]))
+		}
+		s.sendListMessage(ds, m, messageFavorites, lines, statusLevel)
+		return
+	}
+	song := s.player.NowPlaying()
+	if song == nil {
+		s.sendNotPlayingMessage(ds, m)
+		return
+	}
+	added, err := s.library.ToggleFavorite(s.ctx, m.Author.ID, song)
+	if err != nil {
+		s.libraryError(ds, m, err)
+		return
+	}
+	msg := messageFavoriteRemoved
+	if added {
+		msg = messageFavoriteAdded
+	}
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(msg, song.ArtistName, song.Title)), statusLevel)
+}
+
+// playlistMessageHandler the playlists belong to the author, they are the same on every server
+func (s *Service) playlistMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+playlist))
+	userID := m.Author.ID
+	if len(args) == 0 {
+		playlists, err := s.library.Playlists(s.ctx, userID)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		lines := make([]string, 0, len(playlists))
+		for i := range playlists {
+			lines = append(lines, fmt.Sprintf("**%s** %d songs", playlists[i].Name, len(playlists[i].Songs)))
+		}
+		s.sendListMessage(ds, m, messagePlaylists, lines, statusLevel)
+		return
+	}
+	if len(args) < 2 {
+		s.sendPlaylistUsageMessage(ds, m)
+		return
+	}
+	name := args[1]
+	switch strings.ToLower(args[0]) {
+	case playlistShow:
+		p, err := s.library.Playlist(s.ctx, userID, name)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		lines := make([]string, 0, len(p.Songs))
+		for i := range p.Songs {
+			lines = append(lines, entryLine(i+1, &p.Songs[i]))
+		}
+		s.sendListMessage(ds, m, ":notepad_spiral: **"+p.Name+"**", lines, statusLevel)
+	case playlistCreate:
+		p, err := s.library.CreatePlaylist(s.ctx, userID, name)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistCreated, p.Name)), statusLevel)
+	case playlistAdd:
+		song := s.player.NowPlaying()
+		if song == nil {
+			s.sendNotPlayingMessage(ds, m)
+			return
+		}
+		p, err := s.library.AddToPlaylist(s.ctx, userID, name, song)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		msg := fmt.Sprintf(messagePlaylistAdded, p.Name, song.ArtistName, song.Title, len(p.Songs))
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+	case playlistRemove:
+		if len(args) != 3 {
+			s.sendPlaylistUsageMessage(ds, m)
+			return
+		}
+		position, err := strconv.Atoi(args[2])
+		if err != nil {
+			s.sendPlaylistUsageMessage(ds, m)
+			return
+		}
+		e, err := s.library.RemoveFromPlaylist(s.ctx, userID, name, position)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		msg := fmt.Sprintf(messagePlaylistRemoved, name, e.ArtistName, e.Title)
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+	case playlistDelete:
+		if err := s.library.DeletePlaylist(s.ctx, userID, name); err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistDeleted, name)), statusLevel)
+	case playlistShare:
+		link, err := s.library.SharePlaylist(s.ctx, userID, name)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistShared, name, link)), infoLevel)
+	case playlistUnshare:
+		if err := s.library.UnsharePlaylist(s.ctx, userID, name); err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistUnshared, name)), statusLevel)
+	case playlistExport:
+		s.exportPlaylist(ds, m, name, args[2:])
+	case playlistPlay:
+		if len(args) > 3 || len(args) == 3 && strings.ToLower(args[2]) != playlistShuffle {
+			s.sendPlaylistUsageMessage(ds, m)
+			return
+		}
+		s.playPlaylist(ds, m, name, len(args) == 3)
+	default:
+		s.sendPlaylistUsageMessage(ds, m)
+	}
+}
+
+// playPlaylist the daily quota and the approval are per song, so the playlists bypass them only for DJs
+func (s *Service) playPlaylist(ds *dg.Session, m *dg.MessageCreate, name string, shuffle bool) {
+	if s.rejectInMaintenance(ds, m.ChannelID) {
+		return
+	}
+	if (s.dailyLimit(ds, m) > 0 || s.needsApproval(ds, m)) && !s.isDJ(ds, m) {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messagePlaylistDJOnly), statusLevel)
+		return
+	}
+	channelID, err := findAuthorVoiceChannelID(ds, m)
+	if err != nil {
+		s.sendNotInVoiceWarning(ds, m)
+		return
+	}
+	p, err := s.library.Playlist(s.ctx, m.Author.ID, name)
+	if err != nil {
+		s.libraryError(ds, m, err)
+		return
+	}
+	songs := make([]*pkg.Song, 0, len(p.Songs))
+	for i := range p.Songs {
+		songs = append(songs, p.Songs[i].Song())
+	}
+	if shuffle {
+		rand.Shuffle(len(songs), func(i, j int) {
+			songs[i], songs[j] = songs[j], songs[i]
+		})
+	}
+	s.setLastChannel(m)
+	queued, err := s.player.PlayAll(s.ctx, songs, m.Author.ID, m.GuildID, channelID)
+	switch {
+	case errors.Is(err, player.ErrQueueFull):
+		s.sendQueueFullMessage(ds, m)
+	case err != nil:
+		s.logger.Error(errors.Wrapf(err, "play playlist %s", name))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	case queued == 0:
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistNone, p.Name)), statusLevel)
+	default:
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistQueued, p.Name, queued, len(songs))), statusLevel)
+	}
+}
+
+// playYouTubePlaylist a playlist or an album link of YouTube Music is queued like a playlist of the library
+func (s *Service) playYouTubePlaylist(ds *dg.Session, m *dg.MessageCreate, link, channelID string) {
+	if (s.dailyLimit(ds, m) > 0 || s.needsApproval(ds, m)) && !s.isDJ(ds, m) {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messagePlaylistDJOnly), statusLevel)
+		return
+	}
+	s.setLastChannel(m)
+	s.sendSearchingMessage(ds, m)
+	title, songs, err := s.player.FindPlaylist(s.ctx, link, s.playlistProgress(ds, m.ChannelID))
+	switch {
+	case errors.Is(err, youtube.ErrEmptyPlaylist) || errors.Is(err, youtube.ErrSongNotFound):
+		s.sendNotFoundMessage(ds, m)
+		return
+	case errors.Is(err, youtube.ErrUnavailable) || errors.Is(err, youtube.ErrQuotaExhausted):
+		s.sendYouTubeUnavailableMessage(ds, m)
+		return
+	case errors.Is(err, youtube.ErrLibraryOnly):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageLibraryOnly), statusLevel)
+		return
+	case err != nil:
+		s.logger.Error(errors.Wrapf(err, "load youtube playlist %s", link))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	if title == "" {
+		title = messageYouTubePlaylist
+	}
+	queued, err := s.player.PlayAll(s.ctx, songs, m.Author.ID, m.GuildID, channelID)
+	switch {
+	case errors.Is(err, player.ErrQueueFull):
+		s.sendQueueFullMessage(ds, m)
+	case err != nil:
+		s.logger.Error(errors.Wrapf(err, "play youtube playlist %s", link))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	case queued == 0:
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistNone, title)), statusLevel)
+	default:
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistQueued, title, queued, len(songs))), statusLevel)
+	}
+}
+
+// playlistProgress posts the loaded songs of a playlist resolved by the Data API and edits the message after every batch
+func (s *Service) playlistProgress(ds *dg.Session, channelID string) func(loaded, total int) {
+	if s.toDelete(channelID, statusLevel) {
+		return nil
+	}
+	var msg *dg.Message
+	return func(loaded, total int) {
+		content := fmt.Sprintf(messagePlaylistLoading, loaded, total)
+		var err error
+		if msg == nil {
+			msg, err = ds.ChannelMessageSend(channelID, content)
+		} else {
+			_, err = ds.ChannelMessageEdit(channelID, msg.ID, content)
+		}
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "report playlist progress"))
+		}
+	}
+}
+
+// exportPlaylist the link is personal, so it goes to DM
+func (s *Service) exportPlaylist(ds *dg.Session, m *dg.MessageCreate, name string, args []string) {
+	services := s.exporter.Services()
+	if len(services) == 0 {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageExportDisabled), statusLevel)
+		return
+	}
+	if len(args) != 1 {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageExportServices, strings.Join(services, ", "))), statusLevel)
+		return
+	}
+	service := strings.ToLower(args[0])
+	u, err := s.exporter.AuthURL(s.ctx, m.Author.ID, name, service)
+	switch {
+	case errors.Is(err, export.ErrUnknownService):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageExportServices, strings.Join(services, ", "))), statusLevel)
+		return
+	case errors.Is(err, export.ErrEmpty):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageLibraryError, err)), statusLevel)
+		return
+	case err != nil:
+		s.libraryError(ds, m, err)
+		return
+	}
+	channel, err := ds.UserChannelCreate(m.Author.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "create dm channel"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	// the dm channel isn't one of the channels of the guild, so the message isn't filtered by the level
+	if _, err := ds.ChannelMessageSendComplex(channel.ID, strmsg(fmt.Sprintf(messageExportLink, name, service, u))); err != nil {
+		s.logger.Error(errors.Wrap(err, "send export link"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageExportSent), statusLevel)
+}
+
+// libraryError the mistakes of the user are shown as they are
+func (s *Service) libraryError(ds *dg.Session, m *dg.MessageCreate, err error) {
+	switch {
+	case errors.Is(err, library.ErrNotFound), errors.Is(err, library.ErrExists), errors.Is(err, library.ErrFull),
+		errors.Is(err, library.ErrInvalidName), errors.Is(err, library.ErrNoSong), errors.Is(err, library.ErrNotShared):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageLibraryError, err)), statusLevel)
+	default:
+		s.logger.Error(errors.Wrap(err, "library"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	}
+}
+
+func (s *Service) sendPlaylistUsageMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePlaylistUsage, s.prefix)), statusLevel)
+}
+
+func (s *Service) sendListMessage(ds *dg.Session, m *dg.MessageCreate, title string, lines []string, level int) {
+	description := messageListEmpty
+	if len(lines) > maxListed {
+		more := fmt.Sprintf(messageMore, len(lines)-maxListed)
+		lines = append(lines[:maxListed:maxListed], more)
+	}
+	if len(lines) != 0 {
+		description = strings.Join(lines, "\n")
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Embeds: []*dg.MessageEmbed{{Title: title, Description: description}},
+	}, level)
+}
+
+func entryLine(n int, e *library.Entry) string {
+	line := fmt.Sprintf("%d. [%s](%s)", n, e.Title, e.URL)
+	if e.ArtistName != "" {
+		line += " — " + e.ArtistName
+	}
+	return line
+}
@@ -0,0 +1,39 @@
+package discord
+
+import (
+	dg "github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+)
+
+const messageMaintenance = ":construction: **The bot is under maintenance, new songs can't be requested now.** The queue plays to the end"
+
+type Maintenance interface {
+	Status() maintenance.Status
+}
+
+// SetMaintenance the requests are refused with its reason while it is enabled and the status isn't changed by the songs
+func (s *Service) SetMaintenance(m Maintenance) {
+	s.maintenance = m
+}
+
+func (s *Service) inMaintenance() bool {
+	return s.maintenance != nil && s.maintenance.Status().Enabled
+}
+
+// rejectInMaintenance tells the members that the request isn't taken before anything is searched
+func (s *Service) rejectInMaintenance(ds *dg.Session, channelID string) bool {
+	if !s.inMaintenance() {
+		return false
+	}
+	s.sendMaintenanceMessage(ds, channelID)
+	return true
+}
+
+func (s *Service) sendMaintenanceMessage(ds *dg.Session, channelID string) {
+	msg := messageMaintenance
+	if reason := s.maintenance.Status().Reason; reason != "" {
+		msg += ": " + reason
+	}
+	s.sendComplexMessage(ds, channelID, strmsg(msg), statusLevel)
+}
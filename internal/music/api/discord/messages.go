@@ -3,17 +3,28 @@ package discord
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
 
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/recording"
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
 )
 
 const (
 	messageSearching       = ":trumpet: **Searching** :mag_right:"
 	messageFound           = "**Song found** :notes:"
+	messagePlayingNext     = "**Playing next** :arrow_heading_up:"
+	messageTooLong         = ":hourglass: **The song is longer than %d min**"
+	messageConfirm         = "Play anyway"
+	messageConfirmed       = ":white_check_mark: **Confirmed by <@%s>**"
+	messageConfirmExpired  = ":x: **The song was already confirmed or it's too late**"
+	messageConfirmFailed   = ":x: **The confirmed song couldn't be played**"
 	messageNotFound        = ":x: **Song not found**"
 	messageAgeRestriction  = ":underage: **Song is blocked**"
 	messageLoopEnabled     = ":white_check_mark: **Loop enabled**"
@@ -21,6 +32,35 @@ const (
 	messageRadioEnabled    = ":white_check_mark: **Radio enabled**"
 	messageRadioDisabled   = ":x: **Radio disabled**"
 	messageNotVoiceChannel = ":x: **You have to be in a voice channel to use this command**"
+	messageRestarting      = ":arrows_counterclockwise: **Restarting, the queue will be back in a minute**"
+	messageQueueFull       = ":x: **The queue is full**"
+	messageUnavailable     = ":x: **YouTube is unavailable, try again later**"
+	messageLibraryOnly     = ":x: **Only the downloaded songs play right now, this one isn't downloaded**"
+	messageNotDJ           = ":x: **Only DJs can do this**"
+	messageReconnected     = ":arrows_counterclockwise: **Voice reconnected, resuming**"
+	messageNotPlaying      = ":x: **Nothing is playing**"
+	messagePaused          = ":pause_button: **Paused**, `%sresume` to continue"
+	messageAlreadyPaused   = ":pause_button: **Already paused**, `%sresume` to continue"
+	messageResumed         = ":arrow_forward: **Resumed**"
+	messageNotPaused       = ":x: **The song is not paused**"
+	messageSeek            = ":fast_forward: **Playing from**"
+	messageSeekUsage       = "`%sseek <time>` plays the current song from the time like 83, 1:23 or 1:02:03"
+	messageInvalidPosition = ":x: **The song is shorter**"
+	messageSongVolume      = ":loud_sound: **Song volume**"
+	messageSongVolumeUsage = "`%ssongvolume <1-%d|off>` volume of the current song in percent of the server volume, applies from its next play"
+	messageRecording       = ":red_circle: **Recording**"
+	messageFilters         = ":control_knobs: **Filters**"
+	messageFiltersUsage    = "`%[1]sfilters` show the audio filters\n" +
+		"`%[1]sfilters set <filter> [filter...]` filters in order, they apply from the next song\n" +
+		"`%[1]sfilters off` remove the filters\n" +
+		"Filters: %[2]s"
+	messageInvalidFilters      = ":x: **%s**"
+	messageSettingsUnavailable = ":x: **The settings can't be changed right now, try again later**"
+	messageRecordingSaved      = ":floppy_disk: **Recording saved**"
+	messageRecordingEmpty      = ":x: **Nothing was recorded**"
+	messageRecordingFailed     = ":x: **Recording failed**"
+	messageRecordingOff        = ":x: **Recording is disabled on this server**"
+	messageRecordingRetry      = ":x: **The recording is finishing, try again in a moment**"
 )
 
 const (
@@ -52,6 +92,29 @@ func (s *Service) sendFoundMessage(ds *dg.Session, m *dg.MessageCreate, artist,
 	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
 }
 
+func (s *Service) sendPlayingNextMessage(ds *dg.Session, m *dg.MessageCreate, artist, title string, playbacks int) {
+	msg := fmt.Sprintf("%s `%s - %s` %s", messagePlayingNext, artist, title, intToEmoji(playbacks))
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+}
+
+// sendTooLongMessage the button waits for a DJ
+func (s *Service) sendTooLongMessage(ds *dg.Session, m *dg.MessageCreate, song *pkg.Song, id string) {
+	msg := fmt.Sprintf(messageTooLong, s.settings.Get(m.GuildID).Limits.MaxDuration)
+	msg += fmt.Sprintf(" `%s - %s` %s, a DJ can play it anyway", song.ArtistName, song.Title, formatSeconds(song.Duration))
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Content: msg,
+		Components: command.ButtonRows([]dg.Button{{
+			Label:    messageConfirm,
+			Style:    dg.PrimaryButton,
+			CustomID: confirmButtonPrefix + id,
+		}}),
+	}, statusLevel)
+}
+
+func confirmedMessage(song *pkg.Song, userID string) string {
+	return fmt.Sprintf(messageConfirmed+" `%s - %s`", userID, song.ArtistName, song.Title)
+}
+
 func (s *Service) sendNotFoundMessage(ds *dg.Session, m *dg.MessageCreate) {
 	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageNotFound), statusLevel)
 }
@@ -80,7 +143,87 @@ func (s *Service) sendNotInVoiceWarning(ds *dg.Session, m *dg.MessageCreate) {
 	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageNotVoiceChannel), statusLevel)
 }
 
-func (s *Service) sendNowPlayingMessage(ds *dg.Session, m *dg.MessageCreate, song *pkg.Song, pos float64) {
+func (s *Service) sendQueueFullMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageQueueFull), statusLevel)
+}
+
+func (s *Service) sendYouTubeUnavailableMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageUnavailable), statusLevel)
+}
+
+func (s *Service) sendNotDJMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageNotDJ), statusLevel)
+}
+
+func (s *Service) sendNotPlayingMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageNotPlaying), statusLevel)
+}
+
+func (s *Service) sendSongVolumeUsageMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageSongVolumeUsage, s.prefix, maxSongVolume)), statusLevel)
+}
+
+func (s *Service) sendSongVolumeMessage(ds *dg.Session, m *dg.MessageCreate, song *pkg.Song, percent int) {
+	volume := "server volume"
+	if percent != 0 {
+		volume = fmt.Sprintf("%d%%", percent)
+	}
+	msg := fmt.Sprintf("%s `%s - %s` %s from the next play", messageSongVolume, song.ArtistName, song.Title, volume)
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+}
+
+func (s *Service) sendFiltersUsageMessage(ds *dg.Session, m *dg.MessageCreate) {
+	usage := "`" + strings.Join(audio.FilterUsage(), "` `") + "`"
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageFiltersUsage, s.prefix, usage)), statusLevel)
+}
+
+func (s *Service) sendInvalidFiltersMessage(ds *dg.Session, m *dg.MessageCreate, err error) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageInvalidFilters, err)), statusLevel)
+}
+
+func (s *Service) sendSettingsUnavailableMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageSettingsUnavailable), statusLevel)
+}
+
+func (s *Service) sendFiltersMessage(ds *dg.Session, m *dg.MessageCreate, chain audio.Filters) {
+	msg := messageFilters + " " + formatFilters(chain.Strings())
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+}
+
+func (s *Service) sendRecordingDisabledMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageRecordingOff), statusLevel)
+}
+
+func (s *Service) sendRecordingRetryMessage(ds *dg.Session, m *dg.MessageCreate) {
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(messageRecordingRetry), statusLevel)
+}
+
+func (s *Service) sendRecordingStartedMessage(ds *dg.Session, m *dg.MessageCreate) {
+	msg := fmt.Sprintf("%s up to %s, `%s` again to stop", messageRecording, s.recordings.MaxLength(), s.prefix+record)
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+}
+
+func (s *Service) sendRecordingMessage(ds *dg.Session, channelID string, r recording.Result) {
+	var msg string
+	switch {
+	case r.Err != nil:
+		s.logger.Error(errors.Wrap(r.Err, "recording"))
+		msg = messageRecordingFailed
+	case r.URL == "":
+		msg = messageRecordingEmpty
+	default:
+		msg = fmt.Sprintf("%s %s, %.1f MB, kept for %s\n%s", messageRecordingSaved,
+			formatSeconds(r.Duration.Seconds()), float64(r.Size)/(1<<20), s.recordings.Keep(), r.URL)
+	}
+	s.sendComplexMessage(ds, channelID, strmsg(msg), statusLevel)
+}
+
+func (s *Service) sendReconnectedMessage(ds *dg.Session, channelID string, song *pkg.Song, pos time.Duration) {
+	msg := fmt.Sprintf("%s `%s - %s` from %s", messageReconnected, song.ArtistName, song.Title, pos.Truncate(time.Second))
+	s.sendComplexMessage(ds, channelID, strmsg(msg), statusLevel)
+}
+
+func (s *Service) sendNowPlayingMessage(ds *dg.Session, m *dg.MessageCreate, song *pkg.Song, stats pkg.SessionStats) {
 	msg := &dg.MessageSend{
 		Embeds: []*dg.MessageEmbed{
 			{
@@ -102,19 +245,25 @@ func (s *Service) sendNowPlayingMessage(ds *dg.Session, m *dg.MessageCreate, son
 				},
 				Fields: []*dg.MessageEmbedField{
 					{
-						Name:   "Duration",
-						Value:  (time.Duration(song.Duration) * time.Second).String(),
+						Name:   "Position",
+						Value:  nowPosition(stats),
 						Inline: true,
 					},
 					{
 						Name:   "Estimated time",
-						Value:  (time.Duration(song.Duration-pos) * time.Second).String(),
+						Value:  (time.Duration(stats.Duration-stats.Pos) * time.Second).String(),
 						Inline: true,
 					},
 				},
 			},
 		},
 	}
+	if len(stats.Filters) > 0 {
+		msg.Embeds[0].Fields = append(msg.Embeds[0].Fields, &dg.MessageEmbedField{
+			Name:  "Filters",
+			Value: formatFilters(stats.Filters),
+		})
+	}
 	s.sendComplexMessage(ds, m.ChannelID, msg, infoLevel)
 }
 
@@ -169,6 +318,37 @@ func (s *Service) loadChannelsID(ds *dg.Session, guildID string) {
 	}
 }
 
+func formatFilters(filters []string) string {
+	if len(filters) == 0 {
+		return "none"
+	}
+	return "`" + strings.Join(filters, "` → `") + "`"
+}
+
+// formatPosition as 1:05 / 3:20
+func formatPosition(pos, duration float64) string {
+	return formatSeconds(pos) + " / " + formatSeconds(duration)
+}
+
+// nowPosition of the current song, the paused one is marked
+func nowPosition(stats pkg.SessionStats) string {
+	if stats.Paused {
+		return formatPosition(stats.Pos, stats.Duration) + " :pause_button:"
+	}
+	return formatPosition(stats.Pos, stats.Duration)
+}
+
+func formatSeconds(seconds float64) string {
+	total := int(seconds)
+	if total < 0 {
+		total = 0
+	}
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
 func intToEmoji(n int) string {
 	if n == 0 {
 		return ""
@@ -0,0 +1,58 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
+)
+
+const (
+	preview = "preview "
+
+	messagePreview      = ":headphones: **Preview** `%s - %s`, %d seconds from the middle, `%splay` to queue it"
+	messagePreviewGuild = ":x: **The bot is playing on another server**"
+)
+
+// previewMessageHandler the excerpt plays over the music, the song is neither queued nor counted
+func (s *Service) previewMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	query := util.StandardizeSpaces(strings.TrimPrefix(m.Content, s.prefix+preview))
+	channelID, err := findAuthorVoiceChannelID(ds, m)
+	if err != nil {
+		s.sendNotInVoiceWarning(ds, m)
+		return
+	}
+	s.sendSearchingMessage(ds, m)
+	_, err = s.player.Preview(s.ctx, query, m.GuildID, channelID, func(song *pkg.Song) {
+		msg := fmt.Sprintf(messagePreview, song.ArtistName, song.Title, int(player.PreviewLength.Seconds()), s.prefix)
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, youtube.ErrSongNotFound):
+		s.sendNotFoundMessage(ds, m)
+	case errors.Is(err, youtube.ErrUnavailable) || errors.Is(err, youtube.ErrQuotaExhausted):
+		s.sendYouTubeUnavailableMessage(ds, m)
+	case errors.Is(err, youtube.ErrLibraryOnly):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageLibraryOnly), statusLevel)
+	case errors.Is(err, youtube.ErrExplicit) || errors.Is(err, player.ErrBlocked):
+		s.sendAgeRestrictionMessage(ds, m)
+	case errors.Is(err, player.ErrOtherGuild):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messagePreviewGuild), statusLevel)
+	case errors.Is(err, player.ErrNotConnected):
+		s.sendNotInVoiceWarning(ds, m)
+	case errors.Is(err, maintenance.ErrMaintenance):
+		s.sendMaintenanceMessage(ds, m.ChannelID)
+	default:
+		s.logger.Error(errors.Wrapf(err, "preview song=%s", query))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	}
+}
@@ -0,0 +1,268 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	queueCommand  = "queue"
+	clearQueue    = "clear"
+	shuffleQueue  = "shuffle"
+	skipTo        = "skipto"
+	removeCommand = "remove"
+	moveCommand   = "move"
+	undo          = "undo"
+	// queueButtonPrefix the page to show follows it
+	queueButtonPrefix = "music:queue:"
+	// queuePage songs on a page of the queue
+	queuePage = 10
+
+	messageQueue         = ":notes: **Queue**"
+	messageQueueNow      = "**Now:** "
+	messageQueueFooter   = "Page %d of %d, %d songs, %s"
+	messageQueueEmpty    = ":x: **The queue is empty**"
+	messageQueueCleared  = ":wastebasket: **Queue cleared**, `%sundo` to bring it back"
+	messageQueueShuffled = ":twisted_rightwards_arrows: **Queue shuffled**, `%sundo` to bring the order back"
+	messageSkippedTo     = ":fast_forward: **Skipped to** `%s - %s`, `%sundo` to bring the songs back"
+	messageSongRemoved   = ":x: **Removed** `%s - %s`, `%sundo` to bring it back"
+	messageSongMoved     = ":arrow_up_down: **Moved** `%s - %s` **to %d**, `%sundo` to bring the order back"
+	messageMoveUsage     = "`%smove <from> <to>` moves the song to another position of `%squeue`"
+	messageNoPosition    = ":x: **No song at the position, see** `%squeue`"
+	messageUndone        = ":leftwards_arrow_with_hook: **Undone %s**"
+	messageNothingToUndo = ":x: **Nothing to undo**"
+)
+
+// queueMessageHandler lists the queued songs with the positions the other commands take, a page from 1 may follow
+func (s *Service) queueMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, infoLevel)
+	page, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+queueCommand)))
+	if err != nil {
+		page = 1
+	}
+	embed, buttons := s.queuePage(page - 1)
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Embeds:     []*dg.MessageEmbed{embed},
+		Components: buttons,
+	}, infoLevel)
+}
+
+// queueButtonHandler turns the page, the queue is read again
+func (s *Service) queueButtonHandler(ds *dg.Session, i *dg.InteractionCreate, value string) {
+	page, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	embed, buttons := s.queuePage(page)
+	err = ds.InteractionRespond(i.Interaction, &dg.InteractionResponse{
+		Type: dg.InteractionResponseUpdateMessage,
+		Data: &dg.InteractionResponseData{
+			Embeds:     []*dg.MessageEmbed{embed},
+			Components: buttons,
+		},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to queue button"))
+	}
+}
+
+// queuePage from 0, it is clamped to the queue. The buttons are there only if the queue has more pages.
+func (s *Service) queuePage(page int) (*dg.MessageEmbed, []dg.MessageComponent) {
+	songs := s.player.Queue()
+	pages := (len(songs) + queuePage - 1) / queuePage
+	if pages == 0 {
+		pages = 1
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	lines := make([]string, 0, queuePage+1)
+	if now := s.player.NowPlaying(); now != nil {
+		lines = append(lines, messageQueueNow+queueLine(0, now))
+	}
+	total := 0.0
+	for i, song := range songs {
+		total += song.Duration
+		if i >= page*queuePage && i < (page+1)*queuePage {
+			lines = append(lines, queueLine(i+1, song))
+		}
+	}
+	description := messageQueueEmpty
+	if len(lines) != 0 {
+		description = strings.Join(lines, "\n")
+	}
+	embed := &dg.MessageEmbed{
+		Title:       messageQueue,
+		Description: description,
+		Footer:      &dg.MessageEmbedFooter{Text: fmt.Sprintf(messageQueueFooter, page+1, pages, len(songs), formatSeconds(total))},
+	}
+	if pages == 1 {
+		return embed, []dg.MessageComponent{}
+	}
+	return embed, command.ButtonRows([]dg.Button{
+		{Label: "◀", Style: dg.SecondaryButton, CustomID: queueButtonPrefix + strconv.Itoa(page-1), Disabled: page == 0},
+		{Label: "▶", Style: dg.SecondaryButton, CustomID: queueButtonPrefix + strconv.Itoa(page+1), Disabled: page == pages-1},
+	})
+}
+
+// clearMessageHandler the queue is cleared only, the current song plays to the end
+func (s *Service) clearMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	if err := s.player.Clear(); err != nil {
+		s.queueError(ds, m, err, "clear queue")
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageQueueCleared, s.prefix)), statusLevel)
+}
+
+func (s *Service) shuffleMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	if err := s.player.Shuffle(); err != nil {
+		s.queueError(ds, m, err, "shuffle queue")
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageQueueShuffled, s.prefix)), statusLevel)
+}
+
+// skipToMessageHandler the songs before the position are dropped with the current one
+func (s *Service) skipToMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	pos, ok := s.queuePosition(ds, m, skipTo)
+	if !ok {
+		return
+	}
+	song, err := s.player.SkipTo(pos)
+	if err != nil {
+		s.queueError(ds, m, err, "skip to")
+		return
+	}
+	msg := fmt.Sprintf(messageSkippedTo, song.ArtistName, song.Title, s.prefix)
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+}
+
+func (s *Service) removeMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	pos, ok := s.queuePosition(ds, m, removeCommand)
+	if !ok {
+		return
+	}
+	song, err := s.player.Remove(pos)
+	if err != nil {
+		s.queueError(ds, m, err, "remove song")
+		return
+	}
+	msg := fmt.Sprintf(messageSongRemoved, song.ArtistName, song.Title, s.prefix)
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+}
+
+// moveMessageHandler the positions are from 1 as the queue shows them
+func (s *Service) moveMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+moveCommand))
+	if len(args) != 2 {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageMoveUsage, s.prefix, s.prefix)), statusLevel)
+		return
+	}
+	from, errFrom := strconv.Atoi(args[0])
+	to, errTo := strconv.Atoi(args[1])
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageMoveUsage, s.prefix, s.prefix)), statusLevel)
+		return
+	}
+	song, err := s.player.Move(from-1, to-1)
+	if err != nil {
+		s.queueError(ds, m, err, "move song")
+		return
+	}
+	msg := fmt.Sprintf(messageSongMoved, song.ArtistName, song.Title, to, s.prefix)
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(msg), statusLevel)
+}
+
+// undoMessageHandler reverts the last clear, shuffle, remove or skipto made a few minutes ago
+func (s *Service) undoMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	action, err := s.player.Undo()
+	if err != nil {
+		s.queueError(ds, m, err, "undo")
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageUndone, action)), statusLevel)
+}
+
+// queuePosition the position after the command is from 1 as the queue shows it, the player's one is returned
+func (s *Service) queuePosition(ds *dg.Session, m *dg.MessageCreate, name string) (int, bool) {
+	value := strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+name))
+	pos, err := strconv.Atoi(value)
+	if err != nil || pos < 1 {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageNoPosition, s.prefix)), statusLevel)
+		return 0, false
+	}
+	return pos - 1, true
+}
+
+func (s *Service) queueError(ds *dg.Session, m *dg.MessageCreate, err error, action string) {
+	switch {
+	case errors.Is(err, player.ErrQueueEmpty):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageQueueEmpty), statusLevel)
+	case errors.Is(err, player.ErrNotQueued):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageNoPosition, s.prefix)), statusLevel)
+	case errors.Is(err, player.ErrNothingToUndo):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageNothingToUndo), statusLevel)
+	default:
+		s.logger.Error(errors.Wrap(err, action))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	}
+}
+
+// queueLine the current song has no number, the queued ones have the position from 1
+func queueLine(n int, song *pkg.Song) string {
+	line := fmt.Sprintf("[%s](%s)", song.Title, song.URL)
+	if n > 0 {
+		line = fmt.Sprintf("%d. %s", n, line)
+	}
+	if song.ArtistName != "" {
+		line += " — " + song.ArtistName
+	}
+	if song.Duration > 0 {
+		line += " `" + formatSeconds(song.Duration) + "`"
+	}
+	if song.Requester != nil {
+		line += " <@" + song.Requester.ID + ">"
+	}
+	return line
+}
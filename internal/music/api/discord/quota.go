@@ -0,0 +1,47 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/quota"
+)
+
+const messageQuotaExceeded = ":x: <@%s> **you requested %d songs today, the limit resets at midnight UTC**"
+
+// Quotas of the songs the members who aren't DJs request per day
+type Quotas interface {
+	Take(ctx context.Context, guildID, userID string, limit int) (int, error)
+	Refund(ctx context.Context, guildID, userID string, limit int) error
+}
+
+// dailyLimit of the author, the DJs are unlimited
+func (s *Service) dailyLimit(ds *dg.Session, m *dg.MessageCreate) int {
+	limit := s.settings.Get(m.GuildID).Limits.DailyRequests
+	if limit <= 0 || s.isDJ(ds, m) {
+		return 0
+	}
+	return limit
+}
+
+// takeQuota the request goes through if the quotas can't be read
+func (s *Service) takeQuota(ds *dg.Session, m *dg.MessageCreate, limit int) bool {
+	_, err := s.quotas.Take(s.ctx, m.GuildID, m.Author.ID, limit)
+	if errors.Is(err, quota.ErrExceeded) {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageQuotaExceeded, m.Author.ID, limit)), statusLevel)
+		return false
+	}
+	if err != nil {
+		s.logger.Error(err)
+	}
+	return true
+}
+
+func (s *Service) refundQuota(m *dg.MessageCreate, limit int) {
+	if err := s.quotas.Refund(s.ctx, m.GuildID, m.Author.ID, limit); err != nil {
+		s.logger.Error(err)
+	}
+}
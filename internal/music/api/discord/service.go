@@ -3,6 +3,7 @@ package discord
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -10,10 +11,15 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
 
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
 	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/recording"
 	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/suggest"
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
-	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	dpkg "github.com/HalvaPovidlo/discordBotGo/pkg/discord"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
@@ -21,59 +27,142 @@ import (
 
 const (
 	play       = "play "
+	playNext   = "playnext "
 	skip       = "skip"
 	skipFS     = "fs"
+	pause      = "pause"
+	resume     = "resume"
+	seek       = "seek"
 	loop       = "loop"
 	nowPlaying = "now"
 	random     = "random"
 	radio      = "radio"
 	disconnect = "disconnect"
 	hello      = "hello"
+	songVolume = "songvolume"
+	record     = "record"
+	filters    = "filters"
+	filtersSet = "set"
+	// filtersOff clears the chain
+	filtersOff = "off"
+
+	maxSongVolume = 200
 )
 
 type Player interface {
-	Play(ctx contexts.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error)
+	Play(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error)
+	PlayNext(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error)
+	PlayConfirmed(ctx context.Context, song *pkg.Song, userID, guildID, channelID string, next bool) (int, error)
+	PlayAll(ctx context.Context, songs []*pkg.Song, userID, guildID, channelID string) (int, error)
+	FindPlaylist(ctx context.Context, link string, progress func(loaded, total int)) (string, []*pkg.Song, error)
+	Find(ctx context.Context, query, guildID string) (*pkg.Song, error)
+	Preview(ctx context.Context, query, guildID, channelID string, started func(song *pkg.Song)) (*pkg.Song, error)
+	Suggest(query string) (suggest.Suggestion, bool)
 	Skip()
+	Pause(ctx context.Context) error
+	Resume(ctx context.Context) error
+	Seek(pos time.Duration) error
 	SetLoop(b bool)
 	LoopStatus() bool
 	NowPlaying() *pkg.Song
 	SongStatus() pkg.SessionStats
 	Disconnect() //
-	SubscribeOnErrors(h player.ErrorHandler)
-	Random(ctx contexts.Context, n int) ([]*pkg.Song, error)
-	SetRadio(ctx contexts.Context, b bool, guildID, channelID string) error
+	SetSongVolume(ctx context.Context, percent int) (*pkg.Song, error)
+	Filters(guildID string) (audio.Filters, error)
+	SetFilters(ctx context.Context, guildID string, filters audio.Filters) error
+	Subscribe(h player.EventHandler, types ...player.EventType) (unsubscribe func())
+	Random(ctx context.Context, n int) ([]*pkg.Song, error)
+	SetRadio(ctx context.Context, b bool, guildID, channelID string) error
 	RadioStatus() bool
+	Queue() []*pkg.Song
+	Clear() error
+	Shuffle() error
+	SkipTo(pos int) (*pkg.Song, error)
+	Remove(pos int) (*pkg.Song, error)
+	Move(from, to int) (*pkg.Song, error)
+	Undo() (string, error)
 	// Connect(guildID, channelID string)
 	// Enqueue(s *pkg.SongRequest)
 	// Stop()
 }
 
+type GuildSettings interface {
+	Get(guildID string) guild.Settings
+	Enabled(guildID string, f guild.Flag) bool
+}
+
+// Recordings of the listening sessions, nil if they are disabled
+type Recordings interface {
+	Start(done func(recording.Result)) error
+	Stop() bool
+	MaxLength() time.Duration
+	Keep() time.Duration
+}
+
 type APIConfig struct {
 	OpenChannels   []string `json:"open,omitempty"`
 	StatusChannels []string `json:"status,omitempty"`
 }
 
 type Service struct {
-	ctx    contexts.Context
-	player Player
-	prefix string
-	logger zap.Logger
+	ctx        context.Context
+	player     Player
+	settings   GuildSettings
+	recordings Recordings
+	downloads  Downloads
+	library    Library
+	charts     Charts
+	artists    Artists
+	approvals  Approvals
+	quotas     Quotas
+	exporter   Exporter
+	songs      Songs
+	prefix     string
+	logger     zap.Logger
+	// maintenance is nil if the mode is never enabled
+	maintenance Maintenance
+
+	lastChannelMx sync.Mutex
+	lastChannel   string // of the last play or radio command
+	lastGuild     string
 
 	channelsMx     sync.RWMutex
 	allChannels    map[string]string   // id name
 	openChannels   map[string]struct{} // name{}
 	statusChannels map[string]struct{} // name{}
+
+	pendingMx sync.Mutex
+	pending   map[string]*pendingSong // button id
+
+	pollsMx sync.Mutex
+	polls   map[string]*poll // id
+
+	skipMx    sync.Mutex
+	skipVotes map[string]*skipVote // guild id
 }
 
-func NewCog(ctx contexts.Context, player Player, prefix string, logger zap.Logger, config APIConfig) *Service {
+func NewCog(ctx context.Context, player Player, settings GuildSettings, recordings Recordings, downloads Downloads, library Library, charts Charts, artists Artists, approvals Approvals, quotas Quotas, exporter Exporter, songs Songs, prefix string, logger zap.Logger, config APIConfig) *Service {
 	s := Service{
 		ctx:            ctx,
 		player:         player,
+		settings:       settings,
+		recordings:     recordings,
+		downloads:      downloads,
+		library:        library,
+		charts:         charts,
+		artists:        artists,
+		approvals:      approvals,
+		quotas:         quotas,
+		exporter:       exporter,
+		songs:          songs,
 		prefix:         prefix,
 		logger:         logger,
 		allChannels:    make(map[string]string),
 		openChannels:   make(map[string]struct{}),
 		statusChannels: make(map[string]struct{}),
+		pending:        make(map[string]*pendingSong),
+		polls:          make(map[string]*poll),
+		skipVotes:      make(map[string]*skipVote),
 	}
 
 	s.channelsMx.Lock()
@@ -85,8 +174,6 @@ func NewCog(ctx contexts.Context, player Player, prefix string, logger zap.Logge
 		s.statusChannels[v] = t
 	}
 	s.channelsMx.Unlock()
-
-	s.player.SubscribeOnErrors(s.HandleError)
 	return &s
 }
 
@@ -107,16 +194,53 @@ func registerSlashBasicCommand(s *discordgo.Session, debug bool) (unregisterComm
 
 func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
 	registerSlashBasicCommand(session, debug)
-	command.NewMessageCommand(s.prefix+play, s.playMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+skip, s.skipMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+skipFS, s.skipMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+loop, s.loopMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+nowPlaying, s.nowpMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+random, s.randomMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+radio, s.radioMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+disconnect, s.disconnectMessageHandler, debug).RegisterCommand(session, logger)
-	command.NewMessageCommand(s.prefix+hello, s.helloMessageHandler, debug).RegisterCommand(session, logger)
-	s.updateListeningStatus(contexts.Background(), session)
+	s.messageCommand(play, s.playMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(playNext, s.playNextMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(skip, s.skipMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(skipFS, s.skipMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(pause, s.pauseMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(resume, s.resumeMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(seek, s.seekMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(loop, s.loopMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(nowPlaying, s.nowpMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(random, s.randomMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(radio, s.radioMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(disconnect, s.disconnectMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(hello, s.helloMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(songVolume, s.songVolumeMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(record, s.recordMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(filters, s.filtersMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(favorite, s.favoriteMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(playlist, s.playlistMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(top, s.topMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(find, s.findMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(artistCommand, s.artistMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(queueCommand, s.queueMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(clearQueue, s.clearMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(shuffleQueue, s.shuffleMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(skipTo, s.skipToMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(removeCommand, s.removeMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(moveCommand, s.moveMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(undo, s.undoMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(preview, s.previewMessageHandler, debug).RegisterCommand(session, logger)
+	s.messageCommand(voteCommand, s.voteMessageHandler, debug).RegisterCommand(session, logger)
+	command.AddMentionParser(parseIntent(s.prefix))
+	command.NewComponentCommand(confirmButtonPrefix, s.confirmButtonHandler).RegisterCommand(session, logger)
+	command.NewComponentCommand(findButtonPrefix, s.findButtonHandler).RegisterCommand(session, logger)
+	command.NewComponentCommand(suggestButtonPrefix, s.suggestButtonHandler).RegisterCommand(session, logger)
+	command.NewComponentCommand(artistButtonPrefix, s.artistButtonHandler).RegisterCommand(session, logger)
+	command.NewComponentCommand(approveButtonPrefix, s.approveButtonHandler).RegisterCommand(session, logger)
+	command.NewComponentCommand(rejectButtonPrefix, s.rejectButtonHandler).RegisterCommand(session, logger)
+	command.NewComponentCommand(voteButtonPrefix, s.voteButtonHandler).RegisterCommand(session, logger)
+	command.NewComponentCommand(queueButtonPrefix, s.queueButtonHandler).RegisterCommand(session, logger)
+	s.player.Subscribe(func(e player.Event) {
+		s.handlePlayerEvent(session, e)
+	}, player.TrackStarted, player.TrackFinished, player.Disconnected, player.Reconnected, player.Failed)
+}
+
+// messageCommand the music commands work only in the channels the guild allows for them
+func (s *Service) messageCommand(name string, handler command.MessageHandler, debug bool) *command.Message {
+	return command.NewMessageCommand(s.prefix+name, handler, debug).InGroup(string(guild.Music))
 }
 
 func (s *Service) helloMessageHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
@@ -125,23 +249,88 @@ func (s *Service) helloMessageHandler(session *discordgo.Session, m *discordgo.M
 
 func (s *Service) playMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
 	s.deleteMessage(ds, m, statusLevel)
-	query := strings.TrimPrefix(m.Content, s.prefix+play)
+	s.play(ds, m, strings.TrimPrefix(m.Content, s.prefix+play), false)
+}
+
+// playNextMessageHandler the song jumps the queue, so only DJs can do it
+func (s *Service) playNextMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	s.play(ds, m, strings.TrimPrefix(m.Content, s.prefix+playNext), true)
+}
+
+func (s *Service) play(ds *discordgo.Session, m *discordgo.MessageCreate, query string, next bool) {
 	query = util.StandardizeSpaces(query)
 
-	id, err := findAuthorVoiceChannelID(ds, m)
+	channelID, err := findAuthorVoiceChannelID(ds, m)
 	if err != nil {
 		s.sendNotInVoiceWarning(ds, m)
 		s.logger.Error(err, "failed to find author's voice channel")
 		return
 	}
+	if s.rejectInMaintenance(ds, m.ChannelID) {
+		return
+	}
+	if pkg.GetPlaylistIDFromURL(query) != "" {
+		s.playYouTubePlaylist(ds, m, strings.TrimSpace(query), channelID)
+		return
+	}
+	s.setLastChannel(m)
+	limit := s.dailyLimit(ds, m)
+	if !s.takeQuota(ds, m, limit) {
+		return
+	}
 	s.sendSearchingMessage(ds, m)
-	song, playbacks, err := s.player.Play(s.ctx, query, m.Author.ID, m.GuildID, id)
+	if s.needsApproval(ds, m) {
+		s.requestApproval(ds, m, query, channelID, next)
+		return
+	}
+	enqueue := s.player.Play
+	if next {
+		enqueue = s.player.PlayNext
+	}
+	song, playbacks, err := enqueue(s.ctx, query, m.Author.ID, m.GuildID, channelID)
 	if err != nil {
+		// the song isn't queued, the long ones confirmed by a DJ later don't count either
+		s.refundQuota(m, limit)
 		if errors.Is(err, youtube.ErrSongNotFound) {
-			s.sendNotFoundMessage(ds, m)
+			s.sendNotFoundOrSuggestion(ds, m, query)
 			return
 		}
-		if strings.Contains(err.Error(), "can't bypass age restriction") {
+		var poor *player.PoorMatchError
+		if errors.As(err, &poor) {
+			s.sendPoorMatchMessage(ds, m, poor.Song, poor.Suggestion)
+			return
+		}
+		if errors.Is(err, player.ErrQueueFull) {
+			s.sendQueueFullMessage(ds, m)
+			return
+		}
+		if errors.Is(err, player.ErrTooLong) {
+			id := s.addPending(&pendingSong{
+				song:      song,
+				userID:    m.Author.ID,
+				guildID:   m.GuildID,
+				channelID: channelID,
+				next:      next,
+				created:   time.Now(),
+			})
+			s.sendTooLongMessage(ds, m, song, id)
+			return
+		}
+		if errors.Is(err, youtube.ErrUnavailable) || errors.Is(err, youtube.ErrQuotaExhausted) {
+			s.sendYouTubeUnavailableMessage(ds, m)
+			return
+		}
+		if errors.Is(err, youtube.ErrLibraryOnly) {
+			s.sendComplexMessage(ds, m.ChannelID, strmsg(messageLibraryOnly), statusLevel)
+			return
+		}
+		if errors.Is(err, youtube.ErrExplicit) || errors.Is(err, player.ErrBlocked) ||
+			strings.Contains(err.Error(), "can't bypass age restriction") {
 			s.sendAgeRestrictionMessage(ds, m)
 			return
 		}
@@ -149,14 +338,80 @@ func (s *Service) playMessageHandler(ds *discordgo.Session, m *discordgo.Message
 		s.sendInternalErrorMessage(ds, m, statusLevel)
 		return
 	}
-	s.sendFoundMessage(ds, m, song.ArtistName, song.Title, playbacks)
+	if next {
+		s.sendPlayingNextMessage(ds, m, song.ArtistName, song.Title, playbacks)
+	} else {
+		s.sendFoundMessage(ds, m, song.ArtistName, song.Title, playbacks)
+	}
+	s.reportDownload(ds, m.ChannelID, song)
 }
 
 func (s *Service) skipMessageHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
+	// the command names are prefixes, skipto is handled by its own command
+	if len(m.Content) >= len(s.prefix+skipTo) && strings.EqualFold(m.Content[:len(s.prefix+skipTo)], s.prefix+skipTo) {
+		return
+	}
 	s.deleteMessage(session, m, statusLevel)
+	if !s.isDJ(session, m) {
+		s.voteSkip(session, m)
+		return
+	}
+	s.resetSkipVotes(m.GuildID)
 	s.player.Skip()
 }
 
+func (s *Service) pauseMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	err := s.player.Pause(s.ctx)
+	switch {
+	case errors.Is(err, player.ErrNotPlaying):
+		s.sendNotPlayingMessage(ds, m)
+	case errors.Is(err, player.ErrPaused):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageAlreadyPaused, s.prefix)), statusLevel)
+	case err != nil:
+		s.logger.Error(errors.Wrap(err, "pause"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	default:
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messagePaused, s.prefix)), statusLevel)
+	}
+}
+
+func (s *Service) resumeMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	err := s.player.Resume(s.ctx)
+	switch {
+	case errors.Is(err, player.ErrNotPaused):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageNotPaused), statusLevel)
+	case err != nil:
+		s.logger.Error(errors.Wrap(err, "resume"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	default:
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageResumed), statusLevel)
+	}
+}
+
+// seekMessageHandler jumps to the timestamp of the current song, e.g. seek 1:23
+func (s *Service) seekMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	pos, err := util.ParseTimestamp(strings.TrimPrefix(m.Content, s.prefix+seek))
+	if err != nil {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageSeekUsage, s.prefix)), statusLevel)
+		return
+	}
+	err = s.player.Seek(pos)
+	switch {
+	case errors.Is(err, player.ErrNotPlaying):
+		s.sendNotPlayingMessage(ds, m)
+	case errors.Is(err, player.ErrInvalidPosition):
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageInvalidPosition), statusLevel)
+	case err != nil:
+		s.logger.Error(errors.Wrap(err, "seek"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+	default:
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageSeek+" "+formatSeconds(pos.Seconds())), statusLevel)
+	}
+}
+
 func (s *Service) loopMessageHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
 	s.deleteMessage(session, m, statusLevel)
 	b := s.player.LoopStatus()
@@ -166,7 +421,7 @@ func (s *Service) loopMessageHandler(session *discordgo.Session, m *discordgo.Me
 
 func (s *Service) nowpMessageHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
 	s.deleteMessage(session, m, infoLevel)
-	s.sendNowPlayingMessage(session, m, s.player.NowPlaying(), s.player.SongStatus().Pos)
+	s.sendNowPlayingMessage(session, m, s.player.NowPlaying(), s.player.SongStatus())
 }
 
 func (s *Service) randomMessageHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
@@ -193,8 +448,11 @@ func (s *Service) radioMessageHandler(ds *discordgo.Session, m *discordgo.Messag
 		s.logger.Error(err, "failed to find author's voice channel")
 		return
 	}
+	s.setLastChannel(m)
 	err = s.player.SetRadio(s.ctx, true, m.GuildID, id)
-	if err != nil {
+	if errors.Is(err, maintenance.ErrMaintenance) {
+		s.sendMaintenanceMessage(ds, m.ChannelID)
+	} else if err != nil {
 		s.sendInternalErrorMessage(ds, m, statusLevel)
 		s.logger.Error(errors.Wrap(err, "enable radio"))
 	} else {
@@ -204,33 +462,219 @@ func (s *Service) radioMessageHandler(ds *discordgo.Session, m *discordgo.Messag
 
 func (s *Service) disconnectMessageHandler(session *discordgo.Session, m *discordgo.MessageCreate) {
 	s.deleteMessage(session, m, statusLevel)
+	if !s.isDJ(session, m) {
+		s.sendNotDJMessage(session, m)
+		return
+	}
 	s.player.Disconnect()
 }
 
-func (s *Service) HandleError(err error) {
-	s.logger.Error(errors.Wrap(err, "discord api"))
+// songVolumeMessageHandler the volume is kept with the song, "off" returns it to the guild volume
+func (s *Service) songVolumeMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	value := strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+songVolume))
+	percent := 0
+	if value != "off" {
+		var err error
+		percent, err = strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || percent < 1 || percent > maxSongVolume {
+			s.sendSongVolumeUsageMessage(ds, m)
+			return
+		}
+	}
+	song, err := s.player.SetSongVolume(s.ctx, percent)
+	if err != nil {
+		if errors.Is(err, player.ErrNotPlaying) {
+			s.sendNotPlayingMessage(ds, m)
+			return
+		}
+		s.logger.Error(errors.Wrap(err, "set song volume"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	s.sendSongVolumeMessage(ds, m, song, percent)
+}
+
+// filtersMessageHandler shows the chain of the guild, DJs set it in order or turn it off
+func (s *Service) filtersMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+filters))
+	if len(args) == 0 {
+		chain, err := s.player.Filters(m.GuildID)
+		if err != nil {
+			s.logger.Warnw("stored filters", "guild", m.GuildID, "err", err)
+		}
+		s.sendFiltersMessage(ds, m, chain)
+		return
+	}
+	var specs []string
+	switch strings.ToLower(args[0]) {
+	case filtersSet:
+		specs = args[1:]
+		if len(specs) == 0 {
+			s.sendFiltersUsageMessage(ds, m)
+			return
+		}
+	case filtersOff:
+		if len(args) != 1 {
+			s.sendFiltersUsageMessage(ds, m)
+			return
+		}
+	default:
+		s.sendFiltersUsageMessage(ds, m)
+		return
+	}
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	chain, err := audio.ParseFilters(specs)
+	if err != nil {
+		s.sendInvalidFiltersMessage(ds, m, err)
+		return
+	}
+	if err := s.player.SetFilters(s.ctx, m.GuildID, chain); err != nil {
+		if errors.Is(err, guild.ErrNotLoaded) {
+			s.sendSettingsUnavailableMessage(ds, m)
+			return
+		}
+		s.logger.Error(errors.Wrap(err, "set filters"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	s.sendFiltersMessage(ds, m, chain)
 }
 
-func (s *Service) updateListeningStatus(ctx context.Context, session *discordgo.Session) {
-	// TODO: dirty temp code
-	// better way to use channels like error chan
-	timer := time.NewTicker(5 * time.Second)
-	go func() {
-		defer timer.Stop()
-		for {
-			select {
-			case <-timer.C:
-				song := s.player.NowPlaying()
-				title := ""
-				if song != nil {
-					title = song.Title
-				}
-				_ = session.UpdateListeningStatus(title)
-			case <-ctx.Done():
-				return
-			}
+// recordMessageHandler starts the recording or stops the running one, the link is posted where it started
+func (s *Service) recordMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if s.recordings == nil || !s.settings.Enabled(m.GuildID, guild.Record) {
+		s.sendRecordingDisabledMessage(ds, m)
+		return
+	}
+	if !s.isDJ(ds, m) {
+		s.sendNotDJMessage(ds, m)
+		return
+	}
+	if s.recordings.Stop() {
+		return
+	}
+	channelID := m.ChannelID
+	err := s.recordings.Start(func(r recording.Result) {
+		s.sendRecordingMessage(ds, channelID, r)
+	})
+	if err != nil {
+		if errors.Is(err, audio.ErrRecording) {
+			// stopped by the limit meanwhile
+			s.sendRecordingRetryMessage(ds, m)
+			return
 		}
-	}()
+		s.logger.Error(errors.Wrap(err, "start recording"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	s.sendRecordingStartedMessage(ds, m)
+}
+
+// isDJ everyone is a DJ until the guild sets the role, server managers always are
+func (s *Service) isDJ(session *discordgo.Session, m *discordgo.MessageCreate) bool {
+	var roles []string
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	return s.isMemberDJ(session, m.GuildID, m.ChannelID, m.Author.ID, roles)
+}
+
+func (s *Service) isMemberDJ(session *discordgo.Session, guildID, channelID, userID string, roles []string) bool {
+	role := s.settings.Get(guildID).DJRole
+	if role == "" {
+		return true
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return dpkg.HasPermission(session, userID, channelID, discordgo.PermissionManageServer)
+}
+
+// AnnounceRestart warns the listeners in the announce channel of the guild or where the music was requested.
+// The message is sent synchronously since the bot is going down.
+func (s *Service) AnnounceRestart(session *discordgo.Session) {
+	s.lastChannelMx.Lock()
+	guildID := s.lastGuild
+	s.lastChannelMx.Unlock()
+	if s.player.NowPlaying() == nil {
+		return
+	}
+	channelID := s.AnnounceChannel(guildID)
+	if channelID == "" {
+		return
+	}
+	if _, err := session.ChannelMessageSend(channelID, messageRestarting); err != nil {
+		s.logger.Error(errors.Wrap(err, "announce restart"))
+	}
+}
+
+// AnnounceChannel of the guild or where the music was requested, empty if the messages there are deleted
+func (s *Service) AnnounceChannel(guildID string) string {
+	if announce := s.settings.Get(guildID).AnnounceChannel; announce != "" {
+		return announce
+	}
+	s.lastChannelMx.Lock()
+	channelID, lastGuild := s.lastChannel, s.lastGuild
+	s.lastChannelMx.Unlock()
+	if channelID == "" || lastGuild != guildID || s.toDelete(channelID, statusLevel) {
+		return ""
+	}
+	return channelID
+}
+
+// handlePlayerEvent the listening status shows the current song, the maintenance banner is kept.
+// The votes to skip reset when a song starts.
+func (s *Service) handlePlayerEvent(session *discordgo.Session, e player.Event) {
+	if e.Type == player.TrackStarted || e.Type == player.Disconnected {
+		s.resetSkipVotes(e.GuildID)
+	}
+	if s.inMaintenance() && (e.Type == player.TrackStarted || e.Type == player.TrackFinished || e.Type == player.Disconnected) {
+		return
+	}
+	switch e.Type {
+	case player.TrackStarted:
+		_ = session.UpdateListeningStatus(e.Song.Title)
+	case player.TrackFinished:
+		if e.Queue == 0 {
+			_ = session.UpdateListeningStatus("")
+		}
+	case player.Disconnected:
+		_ = session.UpdateListeningStatus("")
+	case player.Reconnected:
+		s.announceReconnect(session, e)
+	case player.Failed:
+		s.logger.Error(errors.Wrap(e.Err, "discord api"))
+	}
+}
+
+// announceReconnect in the channel where the music was requested
+func (s *Service) announceReconnect(session *discordgo.Session, e player.Event) {
+	s.lastChannelMx.Lock()
+	channelID, guildID := s.lastChannel, s.lastGuild
+	s.lastChannelMx.Unlock()
+	if channelID == "" || guildID != e.GuildID {
+		return
+	}
+	s.sendReconnectedMessage(session, channelID, e.Song, e.Pos)
+}
+
+func (s *Service) setLastChannel(m *discordgo.MessageCreate) {
+	s.lastChannelMx.Lock()
+	s.lastChannel = m.ChannelID
+	s.lastGuild = m.GuildID
+	s.lastChannelMx.Unlock()
 }
 
 func (s *Service) deleteMessage(session *discordgo.Session, m *discordgo.MessageCreate, level int) {
@@ -246,20 +690,9 @@ func (s *Service) deleteMessage(session *discordgo.Session, m *discordgo.Message
 }
 
 func findAuthorVoiceChannelID(s *discordgo.Session, m *discordgo.MessageCreate) (string, error) {
-	guild, err := s.State.Guild(m.GuildID)
-	if err != nil {
-		return "", err
-	}
-	id := ""
-	for _, voiceState := range guild.VoiceStates {
-		if voiceState.UserID == m.Author.ID {
-			id = voiceState.ChannelID
-			break
-		}
-	}
-	if id == "" {
-		return "", errors.New("unable to find user voice channel")
-	}
+	return findVoiceChannelID(s, m.GuildID, m.Author.ID)
+}
 
-	return id, nil
+func findVoiceChannelID(s *discordgo.Session, guildID, userID string) (string, error) {
+	return dpkg.VoiceChannelID(s, guildID, userID)
 }
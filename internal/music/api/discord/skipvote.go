@@ -0,0 +1,103 @@
+package discord
+
+import (
+	"fmt"
+
+	dg "github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	messageSkipVoted  = ":track_next: **%s voted to skip** `%s - %s` %d of %d votes"
+	messageSkipPassed = ":track_next: **Skipped** `%s - %s` by %d votes"
+	messageSkipVoice  = ":x: **Join the voice channel of the bot to vote for the skip**"
+)
+
+// skipVote of the listeners for the current song of the guild
+type skipVote struct {
+	songID pkg.SongID
+	voters map[string]struct{}
+}
+
+// voteSkip counts the vote of a member who isn't a DJ, the song is skipped when enough listeners voted.
+// The votes reset when a song starts, a vote for an older song doesn't count either.
+func (s *Service) voteSkip(ds *dg.Session, m *dg.MessageCreate) {
+	song := s.player.NowPlaying()
+	if song == nil {
+		s.sendNotPlayingMessage(ds, m)
+		return
+	}
+	channelID, err := findAuthorVoiceChannelID(ds, m)
+	if err != nil || channelID == "" {
+		s.sendNotInVoiceWarning(ds, m)
+		return
+	}
+	// the bot has no voice state in the headless mode, the channel of the voter counts then
+	if botChannelID, err := findVoiceChannelID(ds, m.GuildID, ds.State.User.ID); err == nil && botChannelID != channelID {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageSkipVoice), statusLevel)
+		return
+	}
+	settings := s.settings.Get(m.GuildID)
+	needed := skipVotesNeeded(listeners(ds, m.GuildID, channelID), settings.Limits.SkipPercent())
+
+	s.skipMx.Lock()
+	v, ok := s.skipVotes[m.GuildID]
+	if !ok || v.songID != song.ID {
+		v = &skipVote{songID: song.ID, voters: make(map[string]struct{})}
+		s.skipVotes[m.GuildID] = v
+	}
+	v.voters[m.Author.ID] = struct{}{}
+	votes := len(v.voters)
+	if votes >= needed {
+		delete(s.skipVotes, m.GuildID)
+	}
+	s.skipMx.Unlock()
+
+	if votes < needed {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageSkipVoted, m.Author.Username, song.ArtistName, song.Title, votes, needed)), statusLevel)
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, strmsg(fmt.Sprintf(messageSkipPassed, song.ArtistName, song.Title, votes)), statusLevel)
+	s.player.Skip()
+}
+
+// resetSkipVotes of the guild, the song changed
+func (s *Service) resetSkipVotes(guildID string) {
+	s.skipMx.Lock()
+	delete(s.skipVotes, guildID)
+	s.skipMx.Unlock()
+}
+
+// skipVotesNeeded the percent of the listeners rounded up, at least one
+func skipVotesNeeded(listeners, percent int) int {
+	n := (listeners*percent + 99) / 100
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// listeners the members in the channel, the bots don't count
+func listeners(ds *dg.Session, guildID, channelID string) int {
+	g, err := ds.State.Guild(guildID)
+	if err != nil {
+		return 0
+	}
+	users := make([]string, 0)
+	ds.State.RLock()
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == channelID && vs.UserID != ds.State.User.ID {
+			users = append(users, vs.UserID)
+		}
+	}
+	ds.State.RUnlock()
+	n := 0
+	for _, userID := range users {
+		if member, err := ds.State.Member(guildID, userID); err == nil && member.User != nil && member.User.Bot {
+			continue
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+package discord
+
+import (
+	"testing"
+
+	dg "github.com/bwmarrin/discordgo"
+)
+
+func TestSkipVotesNeeded(t *testing.T) {
+	type test struct {
+		listeners int
+		percent   int
+		out       int
+	}
+
+	testCases := []test{
+		{listeners: 4, percent: 50, out: 2},
+		{listeners: 5, percent: 50, out: 3},
+		{listeners: 3, percent: 34, out: 2},
+		{listeners: 1, percent: 50, out: 1},
+		{listeners: 7, percent: 100, out: 7},
+		{listeners: 7, percent: 0, out: 1},
+		{listeners: 0, percent: 50, out: 1},
+		{listeners: 0, percent: 100, out: 1},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		if n := skipVotesNeeded(tc.listeners, tc.percent); n != tc.out {
+			t.Errorf("input: %d listeners %d%% got %d, wanted %d", tc.listeners, tc.percent, n, tc.out)
+		}
+	}
+}
+
+func TestListeners(t *testing.T) {
+	ds := &dg.Session{State: dg.NewState()}
+	ds.State.User = &dg.User{ID: "bot"}
+	if err := ds.State.GuildAdd(&dg.Guild{
+		ID: "guild",
+		VoiceStates: []*dg.VoiceState{
+			{GuildID: "guild", ChannelID: "voice", UserID: "bot"},
+			{GuildID: "guild", ChannelID: "voice", UserID: "alice"},
+			{GuildID: "guild", ChannelID: "voice", UserID: "bob"},
+			{GuildID: "guild", ChannelID: "voice", UserID: "other-bot"},
+			{GuildID: "guild", ChannelID: "other", UserID: "carol"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.State.MemberAdd(&dg.Member{GuildID: "guild", User: &dg.User{ID: "other-bot", Bot: true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	type test struct {
+		guildID   string
+		channelID string
+		out       int
+	}
+
+	testCases := []test{
+		{guildID: "guild", channelID: "voice", out: 2},
+		{guildID: "guild", channelID: "other", out: 1},
+		{guildID: "guild", channelID: "empty", out: 0},
+		{guildID: "unknown", channelID: "voice", out: 0},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		if n := listeners(ds, tc.guildID, tc.channelID); n != tc.out {
+			t.Errorf("input: %s %s got %d, wanted %d", tc.guildID, tc.channelID, n, tc.out)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	dg "github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/suggest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	suggestButtonPrefix = "music:suggest:"
+	// maxCustomID discord limit of the custom id of a button
+	maxCustomID = 100
+	// maxLabel discord limit of the label of a button
+	maxLabel = 80
+
+	messageDidYouMean = "Did you mean %s?"
+	messagePoorMatch  = ":thinking: **YouTube found** `%s - %s`"
+	messagePlayAnyway = "Play it anyway"
+)
+
+// sendNotFoundOrSuggestion offers the suggestion for the query if there is one
+func (s *Service) sendNotFoundOrSuggestion(ds *dg.Session, m *dg.MessageCreate, query string) {
+	sug, ok := s.player.Suggest(query)
+	button, fits := suggestionButton(sug)
+	if !ok || !fits {
+		s.sendNotFoundMessage(ds, m)
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Content:    messageNotFound,
+		Components: command.ButtonRows([]dg.Button{button}),
+	}, statusLevel)
+}
+
+// sendPoorMatchMessage the member picks the suggestion or the song YouTube found
+func (s *Service) sendPoorMatchMessage(ds *dg.Session, m *dg.MessageCreate, found *pkg.Song, sug suggest.Suggestion) {
+	buttons := make([]dg.Button, 0, 2)
+	if button, ok := suggestionButton(sug); ok {
+		buttons = append(buttons, button)
+	}
+	buttons = append(buttons, dg.Button{
+		Label:    messagePlayAnyway,
+		Style:    dg.SecondaryButton,
+		CustomID: findButtonPrefix + found.URL,
+	})
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Content:    fmt.Sprintf(messagePoorMatch, found.ArtistName, found.Title),
+		Components: command.ButtonRows(buttons),
+	}, statusLevel)
+}
+
+// suggestionButton plays the library song by its link or the popular query, ok is false if the query is too long for it
+func suggestionButton(sug suggest.Suggestion) (dg.Button, bool) {
+	label := fmt.Sprintf(messageDidYouMean, sug.Text)
+	if utf8.RuneCountInString(label) > maxLabel {
+		label = string([]rune(label)[:maxLabel-1]) + "…"
+	}
+	id := suggestButtonPrefix + sug.Text
+	if sug.URL != "" {
+		id = findButtonPrefix + sug.URL
+	}
+	if len(id) > maxCustomID {
+		return dg.Button{}, false
+	}
+	return dg.Button{Label: label, Style: dg.PrimaryButton, CustomID: id}, true
+}
+
+// suggestButtonHandler the click plays the suggested query as if its author sent the play command with it
+func (s *Service) suggestButtonHandler(ds *dg.Session, i *dg.InteractionCreate, query string) {
+	if command.InteractionUser(i) == nil || i.GuildID == "" || query == "" {
+		s.respondEphemeral(ds, i, fmt.Sprintf(messageFindFailed, s.prefix))
+		return
+	}
+	s.playFromButton(ds, i, query)
+}
@@ -0,0 +1,90 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/internal/trends"
+)
+
+const (
+	top         = "top"
+	topTrending = "trending"
+
+	topSongs     = 10
+	trendingSize = 5
+
+	messageTop      = ":trophy: **Top songs**"
+	messageTrending = ":chart_with_upwards_trend: **Trending this week**"
+	messageNoTrends = "Nothing changed"
+	messageNoCharts = ":x: **The charts are unavailable, try again later**"
+)
+
+// Charts of the songs, all time and the trends of the last week
+type Charts interface {
+	Top(ctx context.Context, n int) ([]pkg.Song, error)
+	Trending(ctx context.Context, n int) (rising, falling []trends.Trend, err error)
+}
+
+// topMessageHandler the all time top or the songs rising and falling the most
+func (s *Service) topMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, infoLevel)
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+top))
+	if len(args) == 1 && strings.ToLower(args[0]) == topTrending {
+		rising, falling, err := s.charts.Trending(s.ctx, trendingSize)
+		if err != nil {
+			s.chartsError(ds, m, err)
+			return
+		}
+		s.sendTrendingMessage(ds, m, rising, falling)
+		return
+	}
+	songs, err := s.charts.Top(s.ctx, topSongs)
+	if err != nil {
+		s.chartsError(ds, m, err)
+		return
+	}
+	lines := make([]string, 0, len(songs))
+	for i := range songs {
+		lines = append(lines, fmt.Sprintf("%d. [%s](%s) %d plays", i+1, songs[i].Title, songs[i].URL, songs[i].Playbacks))
+	}
+	s.sendListMessage(ds, m, messageTop, lines, infoLevel)
+}
+
+func (s *Service) chartsError(ds *dg.Session, m *dg.MessageCreate, err error) {
+	if errors.Is(err, firestore.ErrOffline) {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageNoCharts), infoLevel)
+		return
+	}
+	s.logger.Error(errors.Wrap(err, "charts"))
+	s.sendInternalErrorMessage(ds, m, infoLevel)
+}
+
+func (s *Service) sendTrendingMessage(ds *dg.Session, m *dg.MessageCreate, rising, falling []trends.Trend) {
+	field := func(name, arrow string, list []trends.Trend) *dg.MessageEmbedField {
+		value := messageNoTrends
+		if len(list) != 0 {
+			lines := make([]string, 0, len(list))
+			for i := range list {
+				lines = append(lines, fmt.Sprintf("%s %+d %s, %d plays", arrow, list[i].Change(), list[i].Title, list[i].Recent))
+			}
+			value = strings.Join(lines, "\n")
+		}
+		return &dg.MessageEmbedField{Name: name, Value: value}
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &dg.MessageSend{
+		Embeds: []*dg.MessageEmbed{{
+			Title: messageTrending,
+			Fields: []*dg.MessageEmbedField{
+				field("Rising", ":arrow_up:", rising),
+				field("Falling", ":arrow_down:", falling),
+			},
+		}},
+	}, infoLevel)
+}
@@ -0,0 +1,283 @@
+package discord
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	dg "github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	voteCommand      = "vote"
+	voteButtonPrefix = "music:vote:"
+	// voteCandidates songs on the ballot
+	voteCandidates = 4
+	// voteDuration the members vote for, then the winner is queued
+	voteDuration = 45 * time.Second
+
+	messageVoteTitle    = ":ballot_box: **Vote for the next song**"
+	messageVoteEnds     = "Voting ends <t:%d:R>, started by <@%s>"
+	messageVoteRunning  = ":x: **A vote is already running on the server**"
+	messageVoteFew      = ":x: **Not enough songs for a vote**"
+	messageVoteDJOnly   = ":x: **Only DJs can start a vote while the requests are limited or approved**"
+	messageVoteExpired  = ":x: **The vote is over**"
+	messageVoteVoice    = ":x: **Join the voice channel of the vote to vote**"
+	messageVoted        = ":white_check_mark: **You voted for** `%s - %s`"
+	messageVoteWon      = ":ballot_box: **%s - %s** won with %d of %d votes"
+	messageVoteNobody   = ":ballot_box: **Nobody voted**"
+	messageVoteNotAdded = ":x: **%s - %s** won but can't be queued here"
+)
+
+// poll of the next song, the members in its voice channel vote with the buttons
+type poll struct {
+	id        string
+	songs     []*pkg.Song
+	userID    string
+	guildID   string
+	voiceID   string
+	channelID string
+	messageID string
+	ends      time.Time
+	// votes the candidate of every member, the last click counts
+	votes map[string]int
+}
+
+// voteMessageHandler the candidates are random songs of the library like the radio plays, or of a playlist of the author
+func (s *Service) voteMessageHandler(ds *dg.Session, m *dg.MessageCreate) {
+	s.deleteMessage(ds, m, statusLevel)
+	if s.rejectInMaintenance(ds, m.ChannelID) {
+		return
+	}
+	if (s.dailyLimit(ds, m) > 0 || s.needsApproval(ds, m)) && !s.isDJ(ds, m) {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageVoteDJOnly), statusLevel)
+		return
+	}
+	channelID, err := findAuthorVoiceChannelID(ds, m)
+	if err != nil {
+		s.sendNotInVoiceWarning(ds, m)
+		return
+	}
+	var songs []*pkg.Song
+	if name := strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+voteCommand)); name != "" {
+		p, err := s.library.Playlist(s.ctx, m.Author.ID, name)
+		if err != nil {
+			s.libraryError(ds, m, err)
+			return
+		}
+		for i := range p.Songs {
+			songs = append(songs, p.Songs[i].Song())
+		}
+		rand.Shuffle(len(songs), func(i, j int) {
+			songs[i], songs[j] = songs[j], songs[i]
+		})
+	} else if songs, err = s.player.Random(s.ctx, voteCandidates); err != nil {
+		s.logger.Error(errors.Wrap(err, "random songs for the vote"))
+		s.sendInternalErrorMessage(ds, m, statusLevel)
+		return
+	}
+	if len(songs) > voteCandidates {
+		songs = songs[:voteCandidates]
+	}
+	if len(songs) < 2 {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageVoteFew), statusLevel)
+		return
+	}
+	p := &poll{
+		id:        uuid.New().String()[:8],
+		songs:     songs,
+		userID:    m.Author.ID,
+		guildID:   m.GuildID,
+		voiceID:   channelID,
+		channelID: m.ChannelID,
+		ends:      time.Now().Add(voteDuration),
+		votes:     make(map[string]int),
+	}
+	if !s.addPoll(p) {
+		s.sendComplexMessage(ds, m.ChannelID, strmsg(messageVoteRunning), statusLevel)
+		return
+	}
+	s.setLastChannel(m)
+	buttons := make([]dg.Button, 0, len(songs))
+	for i := range songs {
+		buttons = append(buttons, dg.Button{
+			Label:    strconv.Itoa(i + 1),
+			Style:    dg.PrimaryButton,
+			CustomID: voteButtonPrefix + p.id + ":" + strconv.Itoa(i),
+		})
+	}
+	// sent even where the status messages are deleted, it is needed until the end of the vote
+	msg, err := ds.ChannelMessageSendComplex(m.ChannelID, &dg.MessageSend{
+		Embeds:     []*dg.MessageEmbed{pollEmbed(p, true)},
+		Components: command.ButtonRows(buttons),
+	})
+	if err != nil {
+		s.takePoll(p.id)
+		s.logger.Error(errors.Wrap(err, "send vote"))
+		return
+	}
+	s.pollsMx.Lock()
+	p.messageID = msg.ID
+	s.pollsMx.Unlock()
+	time.AfterFunc(voteDuration, func() {
+		s.finishPoll(ds, p.id)
+	})
+}
+
+// voteButtonHandler the value is the poll and the candidate
+func (s *Service) voteButtonHandler(ds *dg.Session, i *dg.InteractionCreate, value string) {
+	user := command.InteractionUser(i)
+	parts := strings.Split(value, ":")
+	if user == nil || len(parts) != 2 {
+		s.respondEphemeral(ds, i, messageVoteExpired)
+		return
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		s.respondEphemeral(ds, i, messageVoteExpired)
+		return
+	}
+	s.pollsMx.Lock()
+	p, ok := s.polls[parts[0]]
+	if !ok || n < 0 || n >= len(p.songs) {
+		s.pollsMx.Unlock()
+		s.respondEphemeral(ds, i, messageVoteExpired)
+		return
+	}
+	s.pollsMx.Unlock()
+	if channelID, err := findVoiceChannelID(ds, i.GuildID, user.ID); err != nil || channelID != p.voiceID {
+		s.respondEphemeral(ds, i, messageVoteVoice)
+		return
+	}
+	s.pollsMx.Lock()
+	p.votes[user.ID] = n
+	embed := pollEmbed(p, true)
+	s.pollsMx.Unlock()
+	err = ds.InteractionRespond(i.Interaction, &dg.InteractionResponse{
+		Type: dg.InteractionResponseUpdateMessage,
+		Data: &dg.InteractionResponseData{Embeds: []*dg.MessageEmbed{embed}},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to vote button"))
+		return
+	}
+	song := p.songs[n]
+	if _, err := ds.FollowupMessageCreate(i.Interaction, false, &dg.WebhookParams{
+		Content: fmt.Sprintf(messageVoted, song.ArtistName, song.Title),
+		Flags:   uint64(dg.MessageFlagsEphemeral),
+	}); err != nil {
+		s.logger.Error(errors.Wrap(err, "confirm vote"))
+	}
+}
+
+// finishPoll queues the song with the most votes, a random one of the tied
+func (s *Service) finishPoll(ds *dg.Session, id string) {
+	p, ok := s.takePoll(id)
+	if !ok {
+		return
+	}
+	// a click could take the poll before it was taken
+	s.pollsMx.Lock()
+	counts := p.counts()
+	voters := len(p.votes)
+	embed := pollEmbed(p, false)
+	s.pollsMx.Unlock()
+	best := make([]int, 0, len(counts))
+	for n, c := range counts {
+		switch {
+		case c == 0:
+		case len(best) == 0 || c > counts[best[0]]:
+			best = []int{n}
+		case c == counts[best[0]]:
+			best = append(best, n)
+		}
+	}
+	if len(best) == 0 {
+		s.editPollMessage(ds, p, embed, messageVoteNobody)
+		return
+	}
+	winner := best[rand.Intn(len(best))]
+	song := p.songs[winner]
+	s.editPollMessage(ds, p, embed, fmt.Sprintf(messageVoteWon, song.ArtistName, song.Title, counts[winner], voters))
+	queued, err := s.player.PlayAll(s.ctx, []*pkg.Song{song}, p.userID, p.guildID, p.voiceID)
+	switch {
+	case errors.Is(err, player.ErrQueueFull):
+		s.sendComplexMessage(ds, p.channelID, strmsg(messageQueueFull), statusLevel)
+	case errors.Is(err, maintenance.ErrMaintenance):
+		s.sendMaintenanceMessage(ds, p.channelID)
+	case err != nil:
+		s.logger.Error(errors.Wrapf(err, "queue the winner of vote %s", p.id))
+		s.sendComplexMessage(ds, p.channelID, strmsg(messageConfirmFailed), statusLevel)
+	case queued == 0:
+		s.sendComplexMessage(ds, p.channelID, strmsg(fmt.Sprintf(messageVoteNotAdded, song.ArtistName, song.Title)), statusLevel)
+	}
+}
+
+// addPoll false if the guild has a vote already
+func (s *Service) addPoll(p *poll) bool {
+	s.pollsMx.Lock()
+	defer s.pollsMx.Unlock()
+	for _, other := range s.polls {
+		if other.guildID == p.guildID {
+			return false
+		}
+	}
+	s.polls[p.id] = p
+	return true
+}
+
+func (s *Service) takePoll(id string) (*poll, bool) {
+	s.pollsMx.Lock()
+	defer s.pollsMx.Unlock()
+	p, ok := s.polls[id]
+	delete(s.polls, id)
+	return p, ok
+}
+
+// counts of the votes by candidate
+func (p *poll) counts() []int {
+	counts := make([]int, len(p.songs))
+	for _, n := range p.votes {
+		counts[n]++
+	}
+	return counts
+}
+
+// pollEmbed the candidates with their votes and the end of an open poll, called under pollsMx
+func pollEmbed(p *poll, open bool) *dg.MessageEmbed {
+	counts := p.counts()
+	lines := make([]string, 0, len(p.songs)+2)
+	for i, song := range p.songs {
+		lines = append(lines, fmt.Sprintf("%d. `%s - %s` %s **%d**", i+1, song.ArtistName, song.Title, formatSeconds(song.Duration), counts[i]))
+	}
+	if open {
+		lines = append(lines, "", fmt.Sprintf(messageVoteEnds, p.ends.Unix(), p.userID))
+	}
+	return &dg.MessageEmbed{Title: messageVoteTitle, Description: strings.Join(lines, "\n")}
+}
+
+// editPollMessage the result replaces the buttons
+func (s *Service) editPollMessage(ds *dg.Session, p *poll, embed *dg.MessageEmbed, content string) {
+	if p.messageID == "" {
+		return
+	}
+	_, err := ds.ChannelMessageEditComplex(&dg.MessageEdit{
+		ID:         p.messageID,
+		Channel:    p.channelID,
+		Content:    &content,
+		Embeds:     []*dg.MessageEmbed{embed},
+		Components: []dg.MessageComponent{},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrapf(err, "edit vote message %s", p.messageID))
+	}
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LibraryMode interface {
+	SetLibraryOnly(on bool)
+	LibraryOnly() bool
+}
+
+// AdminHandler of the switches of the whole bot, it is mounted on the admin group
+type AdminHandler struct {
+	mode  LibraryMode
+	super *gin.RouterGroup
+}
+
+func NewAdminHandler(mode LibraryMode, superGroup *gin.RouterGroup) *AdminHandler {
+	return &AdminHandler{
+		mode:  mode,
+		super: superGroup,
+	}
+}
+
+func (h *AdminHandler) Router() *gin.RouterGroup {
+	music := h.super.Group("/music")
+	music.GET("/library-only", h.libraryOnlyHandler)
+	music.PUT("/library-only", h.setLibraryOnlyHandler)
+	return music
+}
+
+type libraryOnly struct {
+	Enabled bool `json:"enabled"`
+}
+
+// libraryOnly godoc
+// @summary   Whether only the downloaded songs play and YouTube isn't called
+// @produce   json
+// @security  AdminToken
+// @success   200  {object}  libraryOnly
+// @failure   401  {object}  Response  "Wrong admin token"
+// @router    /admin/music/library-only [get]
+func (h *AdminHandler) libraryOnlyHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, libraryOnly{Enabled: h.mode.LibraryOnly()})
+}
+
+// setLibraryOnly godoc
+// @summary   Switch the library mode until the restart, youtube.library_only is the default
+// @accept    json
+// @produce   json
+// @security  AdminToken
+// @param     mode  body      libraryOnly  true  "Enabled"
+// @success   200   {object}  libraryOnly
+// @failure   400   {object}  Response  "Incorrect input"
+// @failure   401   {object}  Response  "Wrong admin token"
+// @router    /admin/music/library-only [put]
+func (h *AdminHandler) setLibraryOnlyHandler(c *gin.Context) {
+	var json libraryOnly
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	h.mode.SetLibraryOnly(json.Enabled)
+	c.JSON(http.StatusOK, libraryOnly{Enabled: h.mode.LibraryOnly()})
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+)
+
+const (
+	eventsWriteTimeout = 10 * time.Second
+	eventsPingInterval = 30 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	// the api has no cookies to steal, the pages on other hosts may show the player
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// events godoc
+// @summary      The events of the player as JSON messages
+// @description  A WebSocket, every message is an event: track_started, track_finished, queue_updated, queue_ended,
+// @description  connected, disconnected, reconnected or error. The events are dropped while the client is too slow.
+// @produce      json
+// @success      101  {object}  player.Event
+// @router       /music/events [get]
+func (h *Handler) eventsHandler(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	events := make(chan player.Event, 16)
+	unsubscribe := h.player.Subscribe(func(e player.Event) {
+		select {
+		case events <- e:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	// the reads only notice the client going away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	ping := time.NewTicker(eventsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case e := <-events:
+			_ = conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventsWriteTimeout)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -4,18 +4,65 @@ package rest
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
-	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
 )
 
 type songQuery struct {
-	Song string `json:"song" binding:"exists"`
+	Song string `json:"song" binding:"required"`
+	// Next puts the song at the front of the queue like /music/playnext
+	Next bool `json:"next,omitempty"`
 }
 
 type enableQuery struct {
-	Enable bool `json:"enable" binding:"exists"`
+	// Enable is a pointer, so false passes the required check
+	Enable *bool `json:"enable" binding:"required"`
+}
+
+type seekQuery struct {
+	// Position in seconds, a pointer so 0 passes the required check
+	Position *float64 `json:"position" binding:"required"`
+}
+
+type moveQuery struct {
+	// To the position from 0, a pointer so 0 passes the required check
+	To *int `json:"to" binding:"required"`
+}
+
+type filtersQuery struct {
+	// Filters an empty list clears them, only a missing one is refused
+	Filters []string `json:"filters" binding:"required"`
+}
+
+type FiltersResponse struct {
+	Filters []string `json:"filters"`
+}
+
+// QueuedSong the song with the user who requested it
+type QueuedSong struct {
+	*pkg.Song
+	// Position from 0 as DELETE /music/queue/{pos} takes it
+	Position    int    `json:"position"`
+	RequesterID string `json:"requester_id,omitempty"`
+	// Duration in seconds, the one of the song isn't serialized
+	Duration float64 `json:"duration"`
+}
+
+type QueueResponse struct {
+	Songs []QueuedSong `json:"songs"`
+	// Duration of all the queued songs in seconds
+	Duration float64 `json:"duration"`
 }
 
 type EnqueueResponse struct {
@@ -23,14 +70,27 @@ type EnqueueResponse struct {
 	PlaybacksCount int      `json:"playbacks_count"`
 }
 
+// PlaylistResponse the songs of a playlist link queued by /music/enqueue
+type PlaylistResponse struct {
+	Title string `json:"title"`
+	// Queued of the Total songs, the rest didn't fit into the queue
+	Queued int `json:"queued"`
+	Total  int `json:"total"`
+}
+
 // enqueue godoc
-// @summary  Play the song from YouTube by name or url
+// @summary  Play the song from YouTube by name or url, a playlist url queues its songs in order
 // @accept   json
 // @produce  json
-// @param    query  body      songQuery        true  "Song name or url"
-// @success  200    {object}  EnqueueResponse  "The song that was added to the queue"
-// @failure  400    {object}  Response         "Incorrect input"
-// @failure  500    {object}  Response         "Internal error. This does not necessarily mean that the song will not play. For example, if there is a database error, the song will still be added to the queue."
+// @param    query  body      songQuery         true  "Song name or url, with next it goes to the front of the queue"
+// @success  200    {object}  EnqueueResponse   "The song that was added to the queue"
+// @success  200    {object}  PlaylistResponse  "The songs of the playlist that were added to the queue, for a playlist url"
+// @failure  400    {object}  Response          "Incorrect input"
+// @failure  403    {object}  Response          "The song is longer than the limit of the server or blocked"
+// @failure  404    {object}  Response          "The playlist is private, deleted or empty"
+// @failure  409    {object}  Response          "The found song has nothing of the query, the message has a suggestion, or the queue is full for the playlist"
+// @failure  503    {object}  Response          "The song isn't downloaded and the bot plays only the downloaded songs, YouTube is unavailable for the playlist, or the bot is under maintenance"
+// @failure  500    {object}  Response          "Internal error. This does not necessarily mean that the song will not play. For example, if there is a database error, the song will still be added to the queue."
 // @router   /music/enqueue [post]
 func (h *Handler) enqueueHandler(c *gin.Context) {
 	var json songQuery
@@ -38,11 +98,127 @@ func (h *Handler) enqueueHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if pkg.GetPlaylistIDFromURL(json.Song) != "" {
+		h.enqueuePlaylist(c, json.Song)
+		return
+	}
+	if json.Next {
+		h.playNext(c, json.Song)
+		return
+	}
 
-	song, playbacks, err := h.player.Play(contexts.Context{Context: c}, json.Song, "", "", "")
+	song, playbacks, err := h.player.Play(c.Request.Context(), json.Song, v1.UserID(c), "", "")
+	if errors.Is(err, youtube.ErrPlaylistLink) {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, player.ErrTooLong) || errors.Is(err, player.ErrBlocked) || errors.Is(err, youtube.ErrExplicit) {
+		c.JSON(http.StatusForbidden, Response{Message: err.Error()})
+		return
+	}
+	var poor *player.PoorMatchError
+	if errors.As(err, &poor) {
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, youtube.ErrLibraryOnly) || errors.Is(err, maintenance.ErrMaintenance) {
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+	}
+	c.JSON(http.StatusOK, EnqueueResponse{Song: *song, PlaybacksCount: playbacks})
+}
+
+// enqueuePlaylist the songs go to the end of the queue even with next
+func (h *Handler) enqueuePlaylist(c *gin.Context, link string) {
+	title, songs, err := h.player.FindPlaylist(c.Request.Context(), link, nil)
+	if errors.Is(err, youtube.ErrEmptyPlaylist) || errors.Is(err, youtube.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, Response{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, youtube.ErrUnavailable) || errors.Is(err, youtube.ErrQuotaExhausted) || errors.Is(err, youtube.ErrLibraryOnly) {
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	queued, err := h.player.PlayAll(c.Request.Context(), songs, v1.UserID(c), "", "")
+	if errors.Is(err, player.ErrQueueFull) {
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, maintenance.ErrMaintenance) {
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, PlaylistResponse{Title: title, Queued: queued, Total: len(songs)})
+}
+
+// playNext godoc
+// @summary  Play the song from YouTube by name or url right after the current one
+// @accept   json
+// @produce  json
+// @param    query  body      songQuery        true  "Song name or url"
+// @success  200    {object}  EnqueueResponse  "The song that was put at the front of the queue"
+// @failure  400    {object}  Response         "Incorrect input"
+// @failure  403    {object}  Response         "The song is longer than the limit of the server or blocked"
+// @failure  409    {object}  Response         "The found song has nothing of the query, the message has a suggestion"
+// @failure  503    {object}  Response         "The song isn't downloaded and the bot plays only the downloaded songs, or the bot is under maintenance"
+// @failure  500    {object}  Response         "Internal error"
+// @router   /music/playnext [post]
+func (h *Handler) playNextHandler(c *gin.Context) {
+	var json songQuery
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.playNext(c, json.Song)
+}
+
+// playNext is recorded as the playnext command whichever endpoint is called
+func (h *Handler) playNext(c *gin.Context, query string) {
+	e := command.Execution{Command: "POST /music/playnext", Args: query, UserID: v1.UserID(c), Outcome: command.OutcomeOK, Time: time.Now()}
+	song, playbacks, err := h.player.PlayNext(c.Request.Context(), query, e.UserID, "", "")
+	if errors.Is(err, youtube.ErrPlaylistLink) {
+		e.Outcome = err.Error()
+		command.Record(e)
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, player.ErrTooLong) || errors.Is(err, player.ErrBlocked) || errors.Is(err, youtube.ErrExplicit) {
+		e.Outcome = err.Error()
+		command.Record(e)
+		c.JSON(http.StatusForbidden, Response{Message: err.Error()})
+		return
+	}
+	var poor *player.PoorMatchError
+	if errors.As(err, &poor) {
+		e.Outcome = err.Error()
+		command.Record(e)
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, youtube.ErrLibraryOnly) || errors.Is(err, maintenance.ErrMaintenance) {
+		e.Outcome = err.Error()
+		command.Record(e)
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return
+	}
+	if err != nil && song == nil {
+		e.Outcome = err.Error()
+		command.Record(e)
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
 	}
+	command.Record(e)
 	c.JSON(http.StatusOK, EnqueueResponse{Song: *song, PlaybacksCount: playbacks})
 }
 
@@ -57,13 +233,82 @@ func (h *Handler) skipHandler(c *gin.Context) {
 }
 
 // stop godoc
-// @summary  Skip the current song and play next from the queue
+// @summary  Stop the playback, clear the queue and turn the radio off
 // @produce  plain
 // @success  200  string  string
 // @router   /music/stop [get]
 func (h *Handler) stopHandler(c *gin.Context) {
-	// h.player.Stop()
-	c.String(http.StatusNotImplemented, "")
+	h.player.Stop()
+	c.String(http.StatusOK, "")
+}
+
+// pause godoc
+// @summary  Pause the current song, the queue keeps its place
+// @produce  json
+// @success  200  string    string
+// @failure  409  {object}  Response  "Nothing is playing or the song is paused already"
+// @router   /music/pause [post]
+func (h *Handler) pauseHandler(c *gin.Context) {
+	err := h.player.Pause(c.Request.Context())
+	if errors.Is(err, player.ErrNotPlaying) || errors.Is(err, player.ErrPaused) {
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.String(http.StatusOK, "")
+}
+
+// seek godoc
+// @summary  Play the current song from the position, the queue, the loop and the radio stay as they are
+// @accept   json
+// @produce  json
+// @param    query  body      seekQuery  true  "Position in seconds"
+// @success  200    string    string
+// @failure  400    {object}  Response  "Incorrect input or the position is outside of the song"
+// @failure  409    {object}  Response  "Nothing is playing"
+// @router   /music/seek [post]
+func (h *Handler) seekHandler(c *gin.Context) {
+	var json seekQuery
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.player.Seek(time.Duration(*json.Position * float64(time.Second)))
+	if errors.Is(err, player.ErrInvalidPosition) {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, player.ErrNotPlaying) {
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.String(http.StatusOK, "")
+}
+
+// resume godoc
+// @summary  Resume the paused song where it stopped
+// @produce  json
+// @success  200  string    string
+// @failure  409  {object}  Response  "The song is not paused"
+// @router   /music/resume [post]
+func (h *Handler) resumeHandler(c *gin.Context) {
+	err := h.player.Resume(c.Request.Context())
+	if errors.Is(err, player.ErrNotPaused) {
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.String(http.StatusOK, "")
 }
 
 // loopStatus godoc
@@ -95,6 +340,7 @@ func (h *Handler) setLoopHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	h.player.SetLoop(*json.Enable)
 	c.String(http.StatusOK, "")
 }
 
@@ -135,6 +381,103 @@ func (h *Handler) nowPlayingHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, entry)
 }
 
+// queue godoc
+// @summary  Songs waiting in the queue, without the one playing now
+// @produce  json
+// @success  200  {object}  QueueResponse  "The songs in the order they play"
+// @router   /music/queue [get]
+func (h *Handler) queueHandler(c *gin.Context) {
+	songs := h.player.Queue()
+	resp := QueueResponse{Songs: make([]QueuedSong, 0, len(songs))}
+	for i, song := range songs {
+		queued := QueuedSong{Song: song, Position: i, Duration: song.Duration}
+		if song.Requester != nil {
+			queued.RequesterID = song.Requester.ID
+		}
+		resp.Songs = append(resp.Songs, queued)
+		resp.Duration += song.Duration
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// remove godoc
+// @summary  Remove the song from the queue
+// @produce  json
+// @param    pos  path      int       true  "Position of the song in the queue from 0"
+// @success  200  {object}  pkg.Song  "The removed song"
+// @failure  400  {object}  Response  "Incorrect input"
+// @failure  404  {object}  Response  "There is no song at the position"
+// @router   /music/queue/{pos} [delete]
+func (h *Handler) removeHandler(c *gin.Context) {
+	pos, err := strconv.Atoi(c.Param("pos"))
+	if err != nil || pos < 0 {
+		c.JSON(http.StatusBadRequest, Response{Message: "the position is a number from 0"})
+		return
+	}
+	song, err := h.player.Remove(pos)
+	if errors.Is(err, player.ErrNotQueued) {
+		c.JSON(http.StatusNotFound, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, song)
+}
+
+// move godoc
+// @summary  Move the song to another position in the queue, the songs between shift by one
+// @accept   json
+// @produce  json
+// @param    pos    path      int        true  "Position of the song in the queue from 0"
+// @param    query  body      moveQuery  true  "New position from 0"
+// @success  200    {object}  pkg.Song   "The moved song"
+// @failure  400    {object}  Response   "Incorrect input"
+// @failure  404    {object}  Response   "There is no song at one of the positions"
+// @router   /music/queue/{pos} [patch]
+func (h *Handler) moveHandler(c *gin.Context) {
+	pos, err := strconv.Atoi(c.Param("pos"))
+	if err != nil || pos < 0 {
+		c.JSON(http.StatusBadRequest, Response{Message: "the position is a number from 0"})
+		return
+	}
+	var json moveQuery
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	song, err := h.player.Move(pos, *json.To)
+	if errors.Is(err, player.ErrNotQueued) {
+		c.JSON(http.StatusNotFound, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, song)
+}
+
+// shuffle godoc
+// @summary  Shuffle the queued songs, the current one plays on
+// @produce  json
+// @success  200  {object}  QueueResponse  "The songs in the new order"
+// @failure  409  {object}  Response       "The queue is empty"
+// @router   /music/shuffle [post]
+func (h *Handler) shuffleHandler(c *gin.Context) {
+	err := h.player.Shuffle()
+	if errors.Is(err, player.ErrQueueEmpty) {
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	h.queueHandler(c)
+}
+
 // radiostatus godoc
 // @summary  Is radio mode enabled
 // @produce  plain
@@ -157,6 +500,7 @@ func (h *Handler) radioStatusHandler(c *gin.Context) {
 // @param    query  body      enableQuery  true  "Send true to enable and false to disable"
 // @success  200    string    string
 // @failure  400    {object}  Response  "Incorrect input"
+// @failure  503    {object}  Response  "The bot is under maintenance"
 // @router   /music/setradio [post]
 func (h *Handler) setRadioHandler(c *gin.Context) {
 	var json enableQuery
@@ -164,9 +508,80 @@ func (h *Handler) setRadioHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.player.SetRadio(contexts.Context{Context: c}, json.Enable, "", ""); err != nil {
+	err := h.player.SetRadio(c.Request.Context(), *json.Enable, "", "")
+	if errors.Is(err, maintenance.ErrMaintenance) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.String(http.StatusOK, "")
 }
+
+// filters godoc
+// @summary  Audio filters of the server the bot is in
+// @produce  json
+// @success  200  {object}  FiltersResponse  "The filters in order"
+// @failure  409  {object}  Response         "The bot is not in a voice channel"
+// @router   /music/filters [get]
+func (h *Handler) filtersHandler(c *gin.Context) {
+	filters, err := h.player.Filters("")
+	if err != nil {
+		filtersError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, FiltersResponse{Filters: filters.Strings()})
+}
+
+// setFilters godoc
+// @summary  Set the audio filters of the server the bot is in, they apply from the next song
+// @accept   json
+// @produce  json
+// @param    query  body      filtersQuery     true  "Filters in order as name=value: speed, nightcore, bassboost, eq, normalize. Empty to clear"
+// @success  200    {object}  FiltersResponse  "The filters with the defaults"
+// @failure  400    {object}  Response         "Incorrect input"
+// @failure  409    {object}  Response         "The bot is not in a voice channel"
+// @router   /music/filters [put]
+func (h *Handler) setFiltersHandler(c *gin.Context) {
+	var json filtersQuery
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	filters, err := audio.ParseFilters(json.Filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	if err := h.player.SetFilters(c.Request.Context(), "", filters); err != nil {
+		filtersError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, FiltersResponse{Filters: filters.Strings()})
+}
+
+func filtersError(c *gin.Context, err error) {
+	if errors.Is(err, player.ErrNotConnected) {
+		c.JSON(http.StatusConflict, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+}
+
+// recording godoc
+// @summary  Download the recording of a listening session
+// @produce  audio/ogg
+// @param    name  path  string  true  "Name of the recording from the link"
+// @success  200
+// @failure  404  {object}  Response  "The recording doesn't exist or was removed"
+// @router   /music/recordings/{name} [get]
+func (h *Handler) recordingHandler(c *gin.Context) {
+	path, ok := h.recordings.Path(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, Response{Message: "recording not found"})
+		return
+	}
+	c.FileAttachment(path, "recording.ogg")
+}
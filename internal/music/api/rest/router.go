@@ -1,49 +1,93 @@
 package rest
 
 import (
+	"context"
+	"time"
+
 	"github.com/gin-gonic/gin"
 
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
-	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
 )
 
 type Player interface {
-	Play(ctx contexts.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error)
+	Play(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error)
+	PlayNext(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error)
+	FindPlaylist(ctx context.Context, link string, progress func(loaded, total int)) (string, []*pkg.Song, error)
+	PlayAll(ctx context.Context, songs []*pkg.Song, userID, guildID, channelID string) (int, error)
 	Skip()
+	Stop()
+	Pause(ctx context.Context) error
+	Resume(ctx context.Context) error
+	Seek(pos time.Duration) error
 	SetLoop(b bool)
 	LoopStatus() bool
-	SetRadio(ctx contexts.Context, b bool, guildID, channelID string) error
+	SetRadio(ctx context.Context, b bool, guildID, channelID string) error
 	RadioStatus() bool
 	NowPlaying() *pkg.Song
+	Queue() []*pkg.Song
+	Remove(pos int) (*pkg.Song, error)
+	Move(from, to int) (*pkg.Song, error)
+	Shuffle() error
 	SongStatus() pkg.SessionStats
 	Status() pkg.PlayerStatus
+	Filters(guildID string) (audio.Filters, error)
+	SetFilters(ctx context.Context, guildID string, filters audio.Filters) error
+	Subscribe(h player.EventHandler, types ...player.EventType) (unsubscribe func())
+}
+
+type Recordings interface {
+	Path(name string) (string, bool)
 }
 
-// Handler TODO: Auth
+// Handler of the music api, the super group must run v1.Identify
 type Handler struct {
-	player Player
-	super  *gin.RouterGroup
+	player     Player
+	recordings Recordings
+	super      *gin.RouterGroup
 }
 
-func NewHandler(player Player, superGroup *gin.RouterGroup) *Handler {
+// NewHandler recordings may be nil, then they are not served
+func NewHandler(player Player, recordings Recordings, superGroup *gin.RouterGroup) *Handler {
 	return &Handler{
-		player: player,
-		super:  superGroup,
+		player:     player,
+		recordings: recordings,
+		super:      superGroup,
 	}
 }
 
 func (h *Handler) Router() *gin.RouterGroup {
 	music := h.super.Group("/music")
-	music.POST("/enqueue", h.enqueueHandler)
-	music.GET("/skip", h.skipHandler)
-	music.GET("/stop", h.stopHandler)
-	music.GET("/loopstatus", h.loopStatusHandler)
-	music.POST("/setloop", h.setLoopHandler)
-	music.GET("/radiostatus", h.radioStatusHandler)
-	music.POST("/setradio", h.setRadioHandler)
-	music.GET("/songstatus", h.songStatusHandler)
-	music.GET("/status", h.statusHandler)
-	music.GET("/now", h.nowPlayingHandler)
+	// only a linked client or a token with the scope controls the player
+	write := music.Group("", v1.RequireUser(), v1.RequireScope(account.ScopeQueueWrite))
+	write.POST("/enqueue", h.enqueueHandler)
+	write.POST("/playnext", h.playNextHandler)
+	write.GET("/skip", h.skipHandler)
+	write.GET("/stop", h.stopHandler)
+	write.POST("/pause", h.pauseHandler)
+	write.POST("/resume", h.resumeHandler)
+	write.POST("/seek", h.seekHandler)
+	write.POST("/setloop", h.setLoopHandler)
+	write.POST("/setradio", h.setRadioHandler)
+	write.DELETE("/queue/:pos", h.removeHandler)
+	write.PATCH("/queue/:pos", h.moveHandler)
+	write.POST("/shuffle", h.shuffleHandler)
+	write.PUT("/filters", h.setFiltersHandler)
+	read := music.Group("", v1.RequireScope(account.ScopeQueueRead))
+	read.GET("/loopstatus", h.loopStatusHandler)
+	read.GET("/radiostatus", h.radioStatusHandler)
+	read.GET("/songstatus", h.songStatusHandler)
+	read.GET("/status", h.statusHandler)
+	read.GET("/now", h.nowPlayingHandler)
+	read.GET("/queue", h.queueHandler)
+	read.GET("/filters", h.filtersHandler)
+	read.GET("/events", h.eventsHandler)
+	if h.recordings != nil {
+		read.GET("/recordings/:name", h.recordingHandler)
+	}
 	return music
 }
 
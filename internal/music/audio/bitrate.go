@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"github.com/khodand/dca"
+)
+
+// minBitrate of the encoder in kbps, the lowest bitrate of a voice channel is 8
+const minBitrate = 8
+
+// withBitrate of the voice channel in kbps capped by maxBitrate, the options are kept if either is 0.
+// The frames stay at the configured duration, discordgo sends them at a fixed 20 ms pace.
+func withBitrate(opts *dca.EncodeOptions, bitrate, maxBitrate int) *dca.EncodeOptions {
+	if bitrate <= 0 || maxBitrate <= 0 {
+		return opts
+	}
+	if bitrate > maxBitrate {
+		bitrate = maxBitrate
+	}
+	if bitrate < minBitrate {
+		bitrate = minBitrate
+	}
+	if bitrate == opts.Bitrate {
+		return opts
+	}
+	adapted := *opts
+	adapted.Bitrate = bitrate
+	return &adapted
+}
+
+// Bitrate of the connected voice channel in kbps, 0 if it is unknown
+func (c *Client) Bitrate() int {
+	if c.conn == nil {
+		return 0
+	}
+	c.conn.Lock()
+	channelID := c.conn.ChannelID
+	c.conn.Unlock()
+	// the state follows the moves of the bot and the changes of the channel
+	channel, err := c.session.State.Channel(channelID)
+	if err != nil {
+		if channel, err = c.session.Channel(channelID); err != nil {
+			return 0
+		}
+	}
+	return channel.Bitrate / 1000
+}
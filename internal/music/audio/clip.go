@@ -0,0 +1,168 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/khodand/dca"
+	"github.com/pkg/errors"
+)
+
+// frameSendTimeout same as dca waits for the voice connection
+const frameSendTimeout = 5 * time.Second
+
+// EncodeClip the frames are stored in the dca format, the same as FrameCache files.
+// The encoding stops past maxDuration, then the duration is over it and the frames are cut.
+func (p *Player) EncodeClip(uri string, maxDuration time.Duration) ([]byte, time.Duration, error) {
+	session, err := p.encode(uri, p.Options)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "encode %s", uri)
+	}
+	defer session.Cleanup()
+	var buf bytes.Buffer
+	var duration time.Duration
+	for {
+		frame, err := session.OpusFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "read clip frame")
+		}
+		duration += session.FrameDuration()
+		if duration > maxDuration {
+			return buf.Bytes(), duration, nil
+		}
+		_ = binary.Write(&buf, binary.LittleEndian, int16(len(frame)))
+		buf.Write(frame)
+	}
+	if err := session.Error(); err != nil {
+		return nil, 0, errors.Wrapf(err, "encode %s", uri)
+	}
+	if duration == 0 {
+		return nil, 0, errors.Errorf("no audio in %s", uri)
+	}
+	return buf.Bytes(), duration, nil
+}
+
+// PlayClip the song is paused for the clip and continues after it, the clips wait for each other
+func (p *Player) PlayClip(v *discordgo.VoiceConnection, frames []byte) error {
+	if v == nil {
+		return errors.New("voice connection doesn't exists")
+	}
+	return p.over(v, bytesReader(frames))
+}
+
+// Hold pauses the song until release, the clips play meanwhile and the song continues after the last release
+func (p *Player) Hold() (release func()) {
+	p.statsLock.Lock()
+	p.held++
+	if p.stream != nil {
+		p.stream.SetPaused(true)
+	}
+	p.statsLock.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.statsLock.Lock()
+			defer p.statsLock.Unlock()
+			p.held--
+			if p.held == 0 && !p.paused && p.stream != nil {
+				p.stream.SetPaused(false)
+			}
+		})
+	}
+}
+
+// PlayExcerpt streams length of the song from start over the music like a clip, the song is not encoded to the end
+func (p *Player) PlayExcerpt(v *discordgo.VoiceConnection, uri string, start, length time.Duration) error {
+	if v == nil {
+		return errors.New("voice connection doesn't exists")
+	}
+	opts := *p.Options
+	opts.StartTime = int(start.Seconds())
+	session, err := p.encode(uri, &opts)
+	if err != nil {
+		return errors.Wrapf(err, "encode %s", uri)
+	}
+	defer session.Cleanup()
+	if err := p.over(v, limit(session, int(length/session.FrameDuration()))); err != nil {
+		return err
+	}
+	return session.Error()
+}
+
+// over the song is paused for the frames and continues after them, the clips wait for each other
+func (p *Player) over(v *discordgo.VoiceConnection, src dca.OpusReader) error {
+	p.clipMx.Lock()
+	defer p.clipMx.Unlock()
+
+	p.statsLock.Lock()
+	stream := p.stream
+	p.statsLock.Unlock()
+	if stream != nil {
+		stream.SetPaused(true)
+		defer func() {
+			// the song could be stopped meanwhile
+			p.statsLock.Lock()
+			if p.stream == stream && p.held == 0 && !p.paused {
+				stream.SetPaused(false)
+			}
+			p.statsLock.Unlock()
+		}()
+	} else {
+		if err := p.speaking(v, true); err != nil {
+			return errors.Wrap(err, "set speaking true")
+		}
+		defer func() { _ = p.speaking(v, false) }()
+	}
+
+	return p.sendFrames(v, src)
+}
+
+// sendFrames directly to the voice connection, the caller makes sure no stream is sending meanwhile
+func (p *Player) sendFrames(v *discordgo.VoiceConnection, src dca.OpusReader) error {
+	for {
+		frame, err := src.OpusFrame()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "read frame")
+		}
+		select {
+		case v.OpusSend <- frame:
+			p.record(frame, p.Options.FrameDuration)
+		case <-time.After(frameSendTimeout):
+			return ErrVoiceClosed
+		}
+	}
+}
+
+// bytesReader the frames stored in the dca format
+func bytesReader(frames []byte) dca.OpusReader {
+	return &frameReader{r: bufio.NewReader(bytes.NewReader(frames))}
+}
+
+// limitReader bounds the playback, the source ends after n frames
+type limitReader struct {
+	dca.OpusReader
+	n int
+}
+
+func limit(src dca.OpusReader, n int) dca.OpusReader {
+	return &limitReader{OpusReader: src, n: n}
+}
+
+func (l *limitReader) OpusFrame() ([]byte, error) {
+	if l.n <= 0 {
+		return nil, io.EOF
+	}
+	l.n--
+	return l.OpusReader.OpusFrame()
+}
@@ -0,0 +1,153 @@
+package audio
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/khodand/dca"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// inputArg is replaced by the stream URL in FFmpegConfig.Args
+const inputArg = "{input}"
+
+// DefaultFFmpegArgs reconnect to the dropped streams and pass the audio to the encoder as raw pcm
+var DefaultFFmpegArgs = []string{
+	"-loglevel", "error", "-nostats",
+	"-reconnect", "1", "-reconnect_streamed", "1", "-reconnect_delay_max", "5",
+	"-i", inputArg,
+	"-vn", "-c:a", "pcm_s16le", "-f", "nut", "pipe:1",
+}
+
+// FFmpegConfig the songs are read by this ffmpeg before the encoder, for the inputs the encoder can't open itself
+type FFmpegConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"`
+	// Args of the process, "{input}" is the stream URL and the output must go to stdout in a format ffmpeg detects
+	Args []string `json:"args"`
+}
+
+// EncodeSession the opus frames of a song
+type EncodeSession interface {
+	dca.OpusReader
+	Stats() *dca.EncodeStats
+	Error() error
+	// FFMPEGMessages printed by the encoder, complete when the frames end
+	FFMPEGMessages() string
+	Cleanup()
+}
+
+// Encoder starts encoding the song at uri
+type Encoder func(uri string, opts *dca.EncodeOptions) (EncodeSession, error)
+
+// EncodeFile the encoder opens the uri itself
+func EncodeFile(uri string, opts *dca.EncodeOptions) (EncodeSession, error) {
+	return dca.EncodeFile(uri, opts)
+}
+
+// NewFFmpegEncoder the stream is piped from the external ffmpeg into the encoder
+func NewFFmpegEncoder(cfg FFmpegConfig, logger zap.Logger) Encoder {
+	path := cfg.Path
+	if path == "" {
+		path = "ffmpeg"
+	}
+	args := cfg.Args
+	if len(args) == 0 {
+		args = DefaultFFmpegArgs
+	}
+	return func(uri string, opts *dca.EncodeOptions) (EncodeSession, error) {
+		return encodeProcess(path, replaceArg(args, inputArg, uri), opts, logger)
+	}
+}
+
+func replaceArg(args []string, placeholder, value string) []string {
+	res := make([]string, len(args))
+	for i, a := range args {
+		res[i] = strings.ReplaceAll(a, placeholder, value)
+	}
+	return res
+}
+
+// encodeProcess the stdout of the process goes into the encoder
+func encodeProcess(path string, args []string, opts *dca.EncodeOptions, logger zap.Logger) (EncodeSession, error) {
+	cmd := exec.Command(path, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s stdout", path)
+	}
+	s := &processSession{cmd: cmd, logger: logger, done: make(chan struct{})}
+	cmd.Stderr = &s.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "start %s", path)
+	}
+	go s.wait()
+	s.EncodeSession, err = dca.EncodeMem(stdout, opts)
+	if err != nil {
+		s.kill()
+		return nil, errors.Wrapf(err, "encode %s output", path)
+	}
+	return s, nil
+}
+
+// processSession the process is stopped with the encoder
+type processSession struct {
+	*dca.EncodeSession
+	cmd    *exec.Cmd
+	logger zap.Logger
+	done   chan struct{}
+
+	mx      sync.Mutex
+	stderr  bytes.Buffer
+	err     error
+	stopped bool
+}
+
+func (s *processSession) wait() {
+	err := s.cmd.Wait()
+	s.mx.Lock()
+	if err != nil && !s.stopped {
+		s.err = errors.Wrapf(err, "%s: %s", s.cmd.Path, lastLine(s.stderr.String()))
+		s.logger.Warnw("input process failed", "err", s.err)
+	}
+	s.mx.Unlock()
+	close(s.done)
+}
+
+// Error the song is cut if the process failed in the middle
+func (s *processSession) Error() error {
+	if err := s.EncodeSession.Error(); err != nil {
+		return err
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.err
+}
+
+func (s *processSession) Cleanup() {
+	s.kill()
+	s.EncodeSession.Cleanup()
+}
+
+func (s *processSession) kill() {
+	s.mx.Lock()
+	s.stopped = true
+	s.mx.Unlock()
+	select {
+	case <-s.done:
+	default:
+		_ = s.cmd.Process.Kill()
+		<-s.done
+	}
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
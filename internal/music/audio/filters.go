@@ -0,0 +1,185 @@
+package audio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/khodand/dca"
+	"github.com/pkg/errors"
+)
+
+// MaxFilters in a chain, every filter slows down the encoder
+const MaxFilters = 8
+
+// Filter of the chain as name=value, the value is optional for some filters
+type Filter struct {
+	Name  string
+	Value string
+}
+
+func (f Filter) String() string {
+	if f.Value == "" {
+		return f.Name
+	}
+	return f.Name + "=" + f.Value
+}
+
+// Filters are applied in order after the volume, the empty chain changes nothing
+type Filters []Filter
+
+type filterSpec struct {
+	usage string
+	// def value if it is omitted, the value is required if empty
+	def string
+	// ffmpeg builds the filter for the sample rate of the encoder
+	ffmpeg func(value string, rate int) (string, error)
+	// tempo of the song the filter plays it at
+	tempo func(value string) float64
+}
+
+var filterSpecs = map[string]filterSpec{
+	"speed": {
+		usage: "speed=<0.5-2>",
+		ffmpeg: func(value string, _ int) (string, error) {
+			v, err := parseRange(value, 0.5, 2)
+			return fmt.Sprintf("atempo=%g", v), err
+		},
+		tempo: parseFloat,
+	},
+	"nightcore": {
+		usage: "nightcore[=<1.1-1.5>]",
+		def:   "1.25",
+		ffmpeg: func(value string, rate int) (string, error) {
+			v, err := parseRange(value, 1.1, 1.5)
+			return fmt.Sprintf("asetrate=%d,aresample=%d", int(float64(rate)*v), rate), err
+		},
+		tempo: parseFloat,
+	},
+	"bassboost": {
+		usage: "bassboost[=<1-20>]",
+		def:   "6",
+		ffmpeg: func(value string, _ int) (string, error) {
+			v, err := parseRange(value, 1, 20)
+			return fmt.Sprintf("bass=g=%g", v), err
+		},
+	},
+	"eq": {
+		usage: "eq=<20-20000 Hz>:<-20-20 dB>",
+		ffmpeg: func(value string, _ int) (string, error) {
+			parts := strings.Split(value, ":")
+			if len(parts) != 2 {
+				return "", errors.New("two values")
+			}
+			freq, err := parseRange(parts[0], 20, 20000)
+			if err != nil {
+				return "", err
+			}
+			gain, err := parseRange(parts[1], -20, 20)
+			return fmt.Sprintf("equalizer=f=%g:t=q:w=1:g=%g", freq, gain), err
+		},
+	},
+	"normalize": {
+		usage: "normalize",
+		ffmpeg: func(value string, _ int) (string, error) {
+			if value != "" {
+				return "", errors.New("no value")
+			}
+			return "dynaudnorm", nil
+		},
+	},
+}
+
+// FilterUsage of all the filters
+func FilterUsage() []string {
+	res := make([]string, 0, len(filterSpecs))
+	for _, name := range []string{"speed", "nightcore", "bassboost", "eq", "normalize"} {
+		res = append(res, filterSpecs[name].usage)
+	}
+	return res
+}
+
+// ParseFilters of the form name or name=value, the omitted values get the defaults
+func ParseFilters(specs []string) (Filters, error) {
+	if len(specs) > MaxFilters {
+		return nil, errors.Errorf("at most %d filters", MaxFilters)
+	}
+	res := make(Filters, 0, len(specs))
+	for _, s := range specs {
+		name, value := s, ""
+		if i := strings.Index(s, "="); i >= 0 {
+			name, value = s[:i], s[i+1:]
+		}
+		name = strings.ToLower(name)
+		spec, ok := filterSpecs[name]
+		if !ok {
+			return nil, errors.Errorf("unknown filter %s", name)
+		}
+		if value == "" {
+			value = spec.def
+		}
+		// the sample rate doesn't matter for the validation
+		if _, err := spec.ffmpeg(value, oggSampleRate); err != nil {
+			return nil, errors.Errorf("%s is %s", name, spec.usage)
+		}
+		res = append(res, Filter{Name: name, Value: value})
+	}
+	return res, nil
+}
+
+// Strings of the filters as they are parsed
+func (f Filters) Strings() []string {
+	res := make([]string, len(f))
+	for i := range f {
+		res[i] = f[i].String()
+	}
+	return res
+}
+
+// Tempo the position in the song moves by it per second of playback
+func (f Filters) Tempo() float64 {
+	tempo := 1.0
+	for _, filter := range f {
+		if spec := filterSpecs[filter.Name]; spec.tempo != nil {
+			tempo *= spec.tempo(filter.Value)
+		}
+	}
+	return tempo
+}
+
+// withFilters appends the chain to the filters of the encoder, the chain was validated by ParseFilters
+func withFilters(opts *dca.EncodeOptions, filters Filters) *dca.EncodeOptions {
+	if len(filters) == 0 {
+		return opts
+	}
+	chain := make([]string, 0, len(filters)+1)
+	if opts.AudioFilter != "" {
+		chain = append(chain, opts.AudioFilter)
+	}
+	for _, filter := range filters {
+		f, err := filterSpecs[filter.Name].ffmpeg(filter.Value, opts.FrameRate)
+		if err != nil {
+			continue
+		}
+		chain = append(chain, f)
+	}
+	filtered := *opts
+	filtered.AudioFilter = strings.Join(chain, ",")
+	return &filtered
+}
+
+func parseRange(value string, min, max float64) (float64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v < min || v > max {
+		return 0, errors.Errorf("%s is out of %g-%g", value, min, max)
+	}
+	return v, nil
+}
+
+func parseFloat(value string) float64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 1
+	}
+	return v
+}
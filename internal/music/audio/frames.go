@@ -0,0 +1,328 @@
+package audio
+
+import (
+	"bufio"
+	"container/list"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khodand/dca"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	framesExt = ".dca"
+	tmpExt    = ".tmp"
+	// maxCounted songs not recorded yet whose plays are counted, the least recently played are forgotten
+	maxCounted = 10000
+)
+
+type frameFile struct {
+	name string
+	size int64
+}
+
+type playCount struct {
+	name string
+	n    int
+}
+
+// FrameCache of the encoded songs on the disk, the replays skip the download and ffmpeg.
+// A song is recorded after it was requested minPlays times, the least recently played files are evicted.
+type FrameCache struct {
+	dir      string
+	maxSize  int64
+	minPlays int
+	logger   zap.Logger
+
+	mx    sync.Mutex
+	size  int64
+	order *list.List // front is the most recently played
+	files map[string]*list.Element
+	// plays of the songs without a file, playOrder front is the most recently played
+	plays     map[string]*list.Element
+	playOrder *list.List
+}
+
+// NewFrameCache adopts the files left by the previous run, the unfinished recordings are removed
+func NewFrameCache(dir string, maxSize int64, minPlays int, logger zap.Logger) (*FrameCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "create frames dir")
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read frames dir")
+	}
+	c := &FrameCache{
+		dir:       dir,
+		maxSize:   maxSize,
+		minPlays:  minPlays,
+		logger:    logger,
+		order:     list.New(),
+		files:     make(map[string]*list.Element),
+		plays:     make(map[string]*list.Element),
+		playOrder: list.New(),
+	}
+	files := make([]os.FileInfo, 0, len(infos))
+	for _, info := range infos {
+		switch {
+		case info.IsDir():
+		case strings.HasSuffix(info.Name(), tmpExt):
+			_ = os.Remove(c.path(info.Name()))
+		case strings.HasSuffix(info.Name(), framesExt):
+			files = append(files, info)
+		}
+	}
+	// the modification time is updated on every play
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+	for _, info := range files {
+		c.files[info.Name()] = c.order.PushBack(&frameFile{name: info.Name(), size: info.Size()})
+		c.size += info.Size()
+	}
+	c.evict()
+	return c, nil
+}
+
+// Usage total size of the files and the limit in bytes
+func (c *FrameCache) Usage() (size, maxSize int64, files int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.size, c.maxSize, len(c.files)
+}
+
+func (c *FrameCache) path(name string) string {
+	return filepath.Join(c.dir, name)
+}
+
+// frameName the same song encoded with other options, e.g. the volume, is another file
+func frameName(key string, opts *dca.EncodeOptions) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%+v", *opts)))
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, key)
+	return safe + "." + hex.EncodeToString(sum[:6]) + framesExt
+}
+
+// open returns the recorded frames, otherwise the play is counted
+func (c *FrameCache) open(name string, frameDuration time.Duration) (*frameReader, bool) {
+	if c == nil || name == "" {
+		return nil, false
+	}
+	c.mx.Lock()
+	e, ok := c.files[name]
+	if !ok {
+		c.countPlay(name)
+		c.mx.Unlock()
+		frameRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	c.mx.Unlock()
+	f, err := os.Open(c.path(name))
+	if err != nil {
+		c.logger.Error(errors.Wrap(err, "open frames"))
+		c.remove(name)
+		frameRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(c.path(name), now, now)
+	frameRequests.WithLabelValues("hit").Inc()
+	return &frameReader{f: f, r: bufio.NewReader(f), duration: frameDuration}, true
+}
+
+// record returns nil if the song is not played often enough yet
+func (c *FrameCache) record(name string, src EncodeSession) *recorder {
+	if c == nil || name == "" {
+		return nil
+	}
+	c.mx.Lock()
+	plays := 0
+	if e, ok := c.plays[name]; ok {
+		plays = e.Value.(*playCount).n
+	}
+	c.mx.Unlock()
+	if plays < c.minPlays {
+		return nil
+	}
+	f, err := ioutil.TempFile(c.dir, name+".*"+tmpExt)
+	if err != nil {
+		c.logger.Error(errors.Wrap(err, "create frames"))
+		return nil
+	}
+	return &recorder{cache: c, name: name, src: src, f: f, w: bufio.NewWriter(f)}
+}
+
+func (c *FrameCache) add(name string, size int64) {
+	c.mx.Lock()
+	if e, ok := c.files[name]; ok {
+		c.size -= e.Value.(*frameFile).size
+		c.order.Remove(e)
+	}
+	c.files[name] = c.order.PushFront(&frameFile{name: name, size: size})
+	c.size += size
+	if e, ok := c.plays[name]; ok {
+		c.playOrder.Remove(e)
+		delete(c.plays, name)
+	}
+	c.mx.Unlock()
+	c.evict()
+}
+
+// countPlay of the song without a file, called under mx
+func (c *FrameCache) countPlay(name string) {
+	if e, ok := c.plays[name]; ok {
+		e.Value.(*playCount).n++
+		c.playOrder.MoveToFront(e)
+		return
+	}
+	c.plays[name] = c.playOrder.PushFront(&playCount{name: name, n: 1})
+	if c.playOrder.Len() > maxCounted {
+		delete(c.plays, c.playOrder.Remove(c.playOrder.Back()).(*playCount).name)
+	}
+}
+
+func (c *FrameCache) remove(name string) {
+	c.mx.Lock()
+	if e, ok := c.files[name]; ok {
+		c.size -= e.Value.(*frameFile).size
+		c.order.Remove(e)
+		delete(c.files, name)
+	}
+	framesSize.Set(float64(c.size))
+	c.mx.Unlock()
+}
+
+// evict keeps the most recent file even if it alone is over the limit
+func (c *FrameCache) evict() {
+	c.mx.Lock()
+	evicted := make([]string, 0)
+	for c.size > c.maxSize && c.order.Len() > 1 {
+		f := c.order.Remove(c.order.Back()).(*frameFile)
+		delete(c.files, f.name)
+		c.size -= f.size
+		evicted = append(evicted, f.name)
+	}
+	framesSize.Set(float64(c.size))
+	c.mx.Unlock()
+	for _, name := range evicted {
+		// playing files stay readable until closed
+		if err := os.Remove(c.path(name)); err != nil && !os.IsNotExist(err) {
+			c.logger.Error(errors.Wrap(err, "remove frames"))
+		}
+		frameEvictions.Inc()
+	}
+}
+
+// frameReader plays the recorded file, the frames are stored in the dca format without the metadata
+type frameReader struct {
+	f        *os.File
+	r        *bufio.Reader
+	duration time.Duration
+}
+
+func (r *frameReader) OpusFrame() ([]byte, error) {
+	frame, err := dca.DecodeFrame(r.r)
+	if err == io.ErrUnexpectedEOF {
+		return nil, io.EOF
+	}
+	return frame, err
+}
+
+// skip the frames before the position
+func (r *frameReader) skip(pos time.Duration) error {
+	for i := time.Duration(0); i+r.duration <= pos; i += r.duration {
+		if _, err := r.OpusFrame(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *frameReader) FrameDuration() time.Duration {
+	return r.duration
+}
+
+func (r *frameReader) Close() error {
+	return r.f.Close()
+}
+
+// recorder writes the frames while they are streamed, only a song played to the end is kept
+type recorder struct {
+	cache *FrameCache
+	name  string
+	src   EncodeSession
+
+	mx     sync.Mutex
+	f      *os.File
+	w      *bufio.Writer
+	size   int64
+	err    error
+	ended  bool
+	closed bool
+}
+
+func (r *recorder) OpusFrame() ([]byte, error) {
+	frame, err := r.src.OpusFrame()
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.closed {
+		return frame, err
+	}
+	if err == io.EOF {
+		r.ended = true
+	}
+	if err == nil && r.err == nil {
+		r.err = binary.Write(r.w, binary.LittleEndian, int16(len(frame)))
+		if r.err == nil {
+			_, r.err = r.w.Write(frame)
+		}
+		r.size += int64(len(frame)) + 2
+	}
+	return frame, err
+}
+
+func (r *recorder) FrameDuration() time.Duration {
+	return r.src.FrameDuration()
+}
+
+func (r *recorder) close() {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.closed = true
+	err := r.err
+	if err == nil {
+		err = r.w.Flush()
+	}
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	// ffmpeg could fail in the middle, the song is cut then
+	if err == nil && r.ended && r.src.Error() == nil {
+		if err = os.Rename(r.f.Name(), r.cache.path(r.name)); err == nil {
+			r.cache.add(r.name, r.size)
+			return
+		}
+	}
+	if err != nil {
+		r.cache.logger.Error(errors.Wrap(err, "record frames"))
+	}
+	_ = os.Remove(r.f.Name())
+}
@@ -0,0 +1,202 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/khodand/dca"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// HeadlessConfig the bot doesn't join the voice channels, the frames go to a sink instead of Discord
+type HeadlessConfig struct {
+	Enabled bool `json:"enabled"`
+	// GuildID and ChannelID the player is in from the start and goes back to, the REST API plays there
+	GuildID   string `json:"guild"`
+	ChannelID string `json:"channel"`
+	// Dir the sessions are written to as ogg files, the frames are dropped if it is empty
+	Dir string `json:"dir"`
+}
+
+// HeadlessClient simulates the voice connection, it is always connected and takes the frames at the pace of Discord
+type HeadlessClient struct {
+	cfg      HeadlessConfig
+	conn     *discordgo.VoiceConnection
+	frame    time.Duration
+	channels int
+	logger   zap.Logger
+
+	mx sync.Mutex
+	// session is the ogg file of the current channel, nil until the first frame
+	session *headlessSession
+	failed  bool
+}
+
+type headlessSession struct {
+	f   *os.File
+	w   *bufio.Writer
+	ogg *oggWriter
+}
+
+// NewHeadlessClient takes the frames until the context is done
+func NewHeadlessClient(ctx context.Context, cfg HeadlessConfig, options *dca.EncodeOptions, logger zap.Logger) *HeadlessClient {
+	c := &HeadlessClient{
+		cfg: cfg,
+		conn: &discordgo.VoiceConnection{
+			GuildID:   cfg.GuildID,
+			ChannelID: cfg.ChannelID,
+			Ready:     true,
+			OpusSend:  make(chan []byte, 2),
+		},
+		frame:    time.Duration(options.FrameDuration) * time.Millisecond,
+		channels: options.Channels,
+		logger:   logger,
+	}
+	supervisor.Go(ctx, logger, "headless voice", c.run)
+	return c
+}
+
+func (c *HeadlessClient) Connection() *discordgo.VoiceConnection {
+	return c.conn
+}
+
+// Connect moves the simulated connection, a new channel starts a new file
+func (c *HeadlessClient) Connect(guildID, channelID string) error {
+	c.conn.Lock()
+	moved := c.conn.GuildID != guildID || c.conn.ChannelID != channelID
+	c.conn.GuildID = guildID
+	c.conn.ChannelID = channelID
+	c.conn.Unlock()
+	if moved {
+		c.closeSession()
+	}
+	return nil
+}
+
+// Reconnect never happens without Discord, the connection just stays
+func (c *HeadlessClient) Reconnect(guildID, channelID string) error {
+	return c.Connect(guildID, channelID)
+}
+
+// Channel the player is in, the configured one after a disconnect
+func (c *HeadlessClient) Channel() (guildID, channelID string) {
+	c.conn.Lock()
+	defer c.conn.Unlock()
+	return c.conn.GuildID, c.conn.ChannelID
+}
+
+// IsConnected is always true, the REST API plays without joining a channel first
+func (c *HeadlessClient) IsConnected() bool {
+	return true
+}
+
+// Disconnect closes the file of the session and goes back to the configured channel
+func (c *HeadlessClient) Disconnect() error {
+	c.closeSession()
+	c.conn.Lock()
+	c.conn.GuildID = c.cfg.GuildID
+	c.conn.ChannelID = c.cfg.ChannelID
+	c.conn.Unlock()
+	return nil
+}
+
+// Bitrate is unknown, the songs are encoded with the options of the player
+func (c *HeadlessClient) Bitrate() int {
+	return 0
+}
+
+// run takes a frame per its duration like discordgo does, so the songs play in real time
+func (c *HeadlessClient) run(ctx context.Context) {
+	defer c.closeSession()
+	ticker := time.NewTicker(c.frame)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame := <-c.conn.OpusSend:
+			headlessFrames.Inc()
+			c.write(frame)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// write the frame into the file of the session, the file is dropped after the first error
+func (c *HeadlessClient) write(frame []byte) {
+	if c.cfg.Dir == "" {
+		return
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.failed {
+		return
+	}
+	if c.session == nil {
+		s, err := c.openSession()
+		if err != nil {
+			c.failed = true
+			c.logger.Error(errors.Wrap(err, "open headless session"))
+			return
+		}
+		c.session = s
+	}
+	if err := c.session.ogg.WriteFrame(frame, int64(c.frame/time.Millisecond)*oggSampleRate/1000); err != nil {
+		c.failed = true
+		c.logger.Error(errors.Wrap(err, "write headless session"))
+	}
+}
+
+// openSession is called under mx
+func (c *HeadlessClient) openSession() (*headlessSession, error) {
+	guildID, channelID := c.Channel()
+	path := filepath.Join(c.cfg.Dir, fmt.Sprintf("%s-%s-%s.ogg", guildID, channelID, time.Now().Format("20060102-150405")))
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "create file")
+	}
+	w := bufio.NewWriter(f)
+	ogg, err := newOggWriter(w, rand.Uint32(), c.channels)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, errors.Wrap(err, "write header")
+	}
+	c.logger.Infow("headless session", "path", path)
+	return &headlessSession{f: f, w: w, ogg: ogg}, nil
+}
+
+func (c *HeadlessClient) closeSession() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	s := c.session
+	c.session = nil
+	c.failed = false
+	if s == nil {
+		return
+	}
+	err := s.ogg.Close()
+	if ferr := s.w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		c.logger.Error(errors.Wrapf(err, "close headless session %s", s.f.Name()))
+	}
+}
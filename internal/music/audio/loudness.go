@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/khodand/dca"
+)
+
+const (
+	// loudnessFilter ffmpeg prints the mean volume of the song when it ends
+	loudnessFilter = "volumedetect"
+	meanVolumeTag  = "mean_volume:"
+	// targetLoudness mean volume in dBFS the songs are brought to
+	targetLoudness = -18.0
+	// maxGain keeps the nearly silent and the clipped songs listenable
+	maxGain = 12.0
+	// normalVolume of dca, the gain is measured relative to it
+	normalVolume = 256
+)
+
+// withLoudnessFilter the filter goes last to measure what is sent to Discord
+func withLoudnessFilter(opts *dca.EncodeOptions) *dca.EncodeOptions {
+	measured := *opts
+	if measured.AudioFilter == "" {
+		measured.AudioFilter = loudnessFilter
+	} else {
+		measured.AudioFilter += "," + loudnessFilter
+	}
+	return &measured
+}
+
+// parseGain from the ffmpeg messages, the volume of the options is excluded so the gain doesn't depend on the guild
+func parseGain(messages string, volume int) (float64, bool) {
+	i := strings.LastIndex(messages, meanVolumeTag)
+	if i < 0 || volume <= 0 {
+		return 0, false
+	}
+	var mean float64
+	if _, err := fmt.Sscanf(messages[i+len(meanVolumeTag):], "%f", &mean); err != nil {
+		return 0, false
+	}
+	mean -= 20 * math.Log10(float64(volume)/normalVolume)
+	gain := targetLoudness - mean
+	return math.Max(-maxGain, math.Min(maxGain, gain)), true
+}
+
+// withGain the dca volume is linear
+func withGain(volume int, gain float64) int {
+	return int(math.Round(float64(volume) * math.Pow(10, gain/20)))
+}
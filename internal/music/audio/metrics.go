@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	encodeStart = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "halvabot",
+		Subsystem: "audio",
+		Name:      "encode_start_seconds",
+		Help:      "Time to start encoding a song.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	})
+	encodeBitrate = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "audio",
+		Name:      "bitrate_kbps",
+		Help:      "Bitrate the current song is encoded at, it follows the voice channel.",
+	})
+	voiceConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "audio",
+		Name:      "voice_connections",
+		Help:      "Open voice connections.",
+	})
+	voiceReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "audio",
+		Name:      "voice_reconnects_total",
+		Help:      "Voice connections replaced by a new one.",
+	})
+	headlessFrames = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "audio",
+		Name:      "headless_frames_total",
+		Help:      "Frames taken by the headless voice instead of Discord.",
+	})
+	framesSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "frames",
+		Name:      "size_bytes",
+		Help:      "Total size of the encoded songs on the disk.",
+	})
+	frameRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "frames",
+		Name:      "requests_total",
+		Help:      "Lookups of encoded songs by result.",
+	}, []string{"result"})
+	frameEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "frames",
+		Name:      "evictions_total",
+		Help:      "Encoded songs removed to stay under the size limit.",
+	})
+)
@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	oggBOS = 0x02
+	oggEOS = 0x04
+	// oggSampleRate the granule of opus is always counted at 48 kHz
+	oggSampleRate = 48000
+)
+
+var oggCRC = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+// oggWriter writes the opus frames into an ogg file, a page per frame.
+// The last frame is held to mark its page as the end of the stream.
+type oggWriter struct {
+	w       io.Writer
+	serial  uint32
+	seq     uint32
+	granule int64
+	pending []byte
+}
+
+func newOggWriter(w io.Writer, serial uint32, channels int) (*oggWriter, error) {
+	o := &oggWriter{w: w, serial: serial}
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1
+	head[9] = byte(channels)
+	binary.LittleEndian.PutUint32(head[12:], oggSampleRate)
+	if err := o.page(oggBOS, head); err != nil {
+		return nil, err
+	}
+	vendor := "halvabot"
+	tags := make([]byte, 8+4+len(vendor)+4)
+	copy(tags, "OpusTags")
+	binary.LittleEndian.PutUint32(tags[8:], uint32(len(vendor)))
+	copy(tags[12:], vendor)
+	if err := o.page(0, tags); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// WriteFrame samples of the frame at 48 kHz
+func (o *oggWriter) WriteFrame(frame []byte, samples int64) error {
+	if o.pending != nil {
+		if err := o.page(0, o.pending); err != nil {
+			return err
+		}
+	}
+	o.granule += samples
+	o.pending = append(o.pending[:0], frame...)
+	return nil
+}
+
+func (o *oggWriter) Close() error {
+	return o.page(oggEOS, o.pending)
+}
+
+func (o *oggWriter) page(kind byte, packet []byte) error {
+	segments := len(packet)/255 + 1
+	p := make([]byte, 27+segments+len(packet))
+	copy(p, "OggS")
+	p[5] = kind
+	binary.LittleEndian.PutUint64(p[6:], uint64(o.granule))
+	binary.LittleEndian.PutUint32(p[14:], o.serial)
+	binary.LittleEndian.PutUint32(p[18:], o.seq)
+	p[26] = byte(segments)
+	for i := 0; i < segments-1; i++ {
+		p[27+i] = 255
+	}
+	p[27+segments-1] = byte(len(packet) % 255)
+	copy(p[27+segments:], packet)
+	var crc uint32
+	for _, b := range p {
+		crc = crc<<8 ^ oggCRC[byte(crc>>24)^b]
+	}
+	binary.LittleEndian.PutUint32(p[22:], crc)
+	o.seq++
+	_, err := o.w.Write(p)
+	return err
+}
@@ -1,6 +1,8 @@
 package audio
 
 import (
+	"context"
+	"io"
 	"sync"
 	"time"
 
@@ -9,75 +11,260 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
 var (
 	ErrManualStop = errors.New("stop")
+	// ErrVoiceClosed the frames couldn't be sent, the voice connection is gone
+	ErrVoiceClosed = dca.ErrVoiceConnClosed
 )
 
+// seekError stops the song to play it again from pos
+type seekError struct {
+	pos time.Duration
+}
+
+func (e *seekError) Error() string {
+	return "seek to " + e.pos.String()
+}
+
+// maxVolume allowed by dca
+const maxVolume = 512
+
 type SongRequest struct {
 	Voice *discordgo.VoiceConnection
 	URI   string
+	// Key of the song in the FrameCache, the song is not cached if empty
+	Key string
+	// Volume in percent of Player.Options, unchanged if 0
+	Volume int
+	// Start position of the song, used to resume it
+	Start time.Duration
+	// TrimSilence skips the silence at the beginning and the end of the song
+	TrimSilence bool
+	// Gain in dB applied over the volume
+	Gain float64
+	// Bitrate of the voice channel in kbps, Player.Options are kept if 0
+	Bitrate int
+	// Filters of the song, the filtered songs are neither cached nor measured
+	Filters Filters
+	// Announce is spoken before the song if the Player has a TTS
+	Announce string
+	// Measured is called with the gain of the song when it was encoded to the end, the song is measured if set
+	Measured func(gain float64)
 }
 
 type Player struct {
 	Options *dca.EncodeOptions `json:"encodingOptions"`
-	logger  zap.Logger
-	done    chan error
+	// maxBitrate the songs are encoded at the bitrate of the channel up to it
+	maxBitrate int
+	encode     Encoder
+	speak      Encoder
+	frames     *FrameCache
+	logger     zap.Logger
+	done       chan error
 
 	isPlayingLock sync.Mutex
 	isPlaying     bool
 
 	statsLock sync.Mutex
 	stats     pkg.SessionStats
+	// stream is nil between the songs, then the position where the last one stopped is kept in start
+	stream *dca.StreamingSession
+	start  time.Duration
+	// tempo of the filters, the song moves faster or slower than the playback
+	tempo float64
+
+	// held the songs stay paused between the clips while it is positive
+	held int
+	// paused by Pause until Resume or the end of the song
+	paused bool
+	// ended is closed when the current song stops, nil between the songs
+	ended chan struct{}
+
+	// clipMx a song doesn't start while a clip or an announcement is playing
+	clipMx sync.Mutex
+
+	recordMx sync.Mutex
+	rec      *recording
+
+	// headless the connections are simulated and have no websocket to tell that the bot speaks
+	headless bool
 }
 
-func NewPlayer(options *dca.EncodeOptions, logger zap.Logger) *Player {
+// NewPlayer frames may be nil, then every song is encoded, speak may be nil, then nothing is announced.
+// maxBitrate 0 keeps the bitrate of the options for every channel.
+func NewPlayer(options *dca.EncodeOptions, maxBitrate int, encode, speak Encoder, frames *FrameCache, logger zap.Logger) *Player {
 	return &Player{
-		Options: options,
-		logger:  logger,
-		done:    make(chan error),
+		Options:    options,
+		maxBitrate: maxBitrate,
+		encode:     encode,
+		speak:      speak,
+		frames:     frames,
+		logger:     logger,
+		done:       make(chan error),
 	}
 }
 
-func (p *Player) Process(requests <-chan *SongRequest) <-chan error {
+// SetHeadless before Process, the player plays to the HeadlessClient
+func (p *Player) SetHeadless() {
+	p.headless = true
+}
+
+// Process plays the requests until the channel is closed
+func (p *Player) Process(ctx context.Context, requests <-chan *SongRequest) <-chan error {
 	out := make(chan error)
-	go func() {
-		defer close(out)
+	started := false
+	supervisor.Go(ctx, p.logger, "audio", func(_ context.Context) {
+		if started {
+			// the song that panicked is over, the queue goes on
+			p.endStream(false)
+			out <- nil
+		}
+		started = true
 		for req := range requests {
 			p.logger.Debugf("get req")
 			p.logger.Debugf("play %s", req.URI)
-			err := p.play(req.Voice, req.URI)
+			err := p.play(req)
+			// the same request again from the new position, the song is neither announced nor measured twice
+			var seek *seekError
+			for errors.As(err, &seek) {
+				from := *req
+				from.Start = seek.pos
+				from.Announce = ""
+				from.Measured = nil
+				err = p.play(&from)
+			}
 			p.logger.Debugf("stop playing %s", err)
 			out <- err
 		}
-	}()
+		close(out)
+	})
 	return out
 }
 
 func (p *Player) Stop() {
-	if p.IsPlaying() {
-		p.done <- ErrManualStop
+	p.interrupt(ErrManualStop)
+}
+
+// Seek restarts the encoder of the current song at pos, false if nothing is playing.
+// A paused song stays paused at the new position.
+func (p *Player) Seek(pos time.Duration) bool {
+	return p.interrupt(&seekError{pos: pos})
+}
+
+// interrupt the current song with the error, false if it ended meanwhile, the next song never gets it
+func (p *Player) interrupt(err error) bool {
+	p.statsLock.Lock()
+	ended := p.ended
+	p.statsLock.Unlock()
+	if ended == nil {
+		return false
+	}
+	select {
+	case p.done <- err:
+		return true
+	case <-ended:
+		return false
 	}
 }
 
 func (p *Player) Stats() pkg.SessionStats {
 	p.statsLock.Lock()
 	defer p.statsLock.Unlock()
-	return p.stats
+	s := p.stats
+	s.Pos = p.position().Seconds()
+	s.Paused = p.paused
+	s.Filters = append([]string(nil), s.Filters...)
+	return s
 }
 
-func (p *Player) setStatsDuration(d time.Duration) {
+// Position in the current song: the frames sent to Discord by the frame duration
+func (p *Player) Position() time.Duration {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	return p.position()
+}
+
+func (p *Player) position() time.Duration {
+	if p.stream == nil {
+		return p.start
+	}
+	return p.start + time.Duration(float64(p.stream.PlaybackPosition())*p.tempo)
+}
+
+func (p *Player) setStats(d time.Duration, filters Filters) {
 	p.statsLock.Lock()
 	defer p.statsLock.Unlock()
 	p.stats.Duration = d.Seconds()
+	p.stats.Filters = filters.Strings()
+}
+
+func (p *Player) setStream(stream *dca.StreamingSession, start time.Duration, tempo float64) {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	p.stream = stream
+	p.start = start
+	p.tempo = tempo
+	if p.held > 0 || p.paused {
+		stream.SetPaused(true)
+	}
+}
+
+// startSong the song plays until endStream
+func (p *Player) startSong() {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	p.ended = make(chan struct{})
+	p.setPlaying(true)
 }
 
-func (p *Player) setStatsPos(d time.Duration) {
+// endStream the song stops playing under statsLock, so Pause can't catch it in between and pause the next one.
+// The pause is kept for a seek, the same song goes on. Only the first call for the song counts.
+func (p *Player) endStream(keepPause bool) {
 	p.statsLock.Lock()
 	defer p.statsLock.Unlock()
-	p.stats.Pos = d.Seconds()
+	if p.ended == nil {
+		return
+	}
+	close(p.ended)
+	p.ended = nil
+	p.start = p.position()
+	p.stream = nil
+	p.paused = p.paused && keepPause
+	p.setPlaying(false)
+}
+
+// Pause the song where it is until Resume, the encoder waits meanwhile.
+// Returns false if nothing is playing or the song is paused already.
+func (p *Player) Pause() bool {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	if p.paused || !p.IsPlaying() {
+		return false
+	}
+	p.paused = true
+	// the song starting after an announcement is paused by setStream
+	if p.stream != nil {
+		p.stream.SetPaused(true)
+	}
+	return true
+}
+
+// Resume the paused song, it stays paused while a clip or a game holds it. Returns false if it isn't paused.
+func (p *Player) Resume() bool {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	if !p.paused {
+		return false
+	}
+	p.paused = false
+	if p.held == 0 && p.stream != nil {
+		p.stream.SetPaused(false)
+	}
+	return true
 }
 
 func (p *Player) IsPlaying() bool {
@@ -92,40 +279,116 @@ func (p *Player) setPlaying(b bool) {
 	p.isPlaying = b
 }
 
-func (p *Player) play(v *discordgo.VoiceConnection, uri string) error {
+func (p *Player) play(req *SongRequest) error {
+	v, uri := req.Voice, req.URI
 	if v == nil {
 		return errors.New("voice connection doesn't exists")
 	}
-	err := v.Speaking(true)
+	err := p.speaking(v, true)
 	if err != nil {
 		return errors.Wrap(err, "set speaking true")
 	}
-	p.setPlaying(true)
+	p.startSong()
+	// the song that failed to start isn't playing either, a Pause meanwhile doesn't stay for the next song
+	defer p.endStream(false)
 
-	encodeSession, err := dca.EncodeFile(uri, p.Options)
-	if err != nil {
-		return errors.Wrapf(err, "encode %s", uri)
+	opts := withBitrate(p.options(req.Volume, req.Gain), req.Bitrate, p.maxBitrate)
+	encodeBitrate.Set(float64(opts.Bitrate))
+	// a resumed song is already past the leading silence
+	trim := req.TrimSilence && req.Start == 0
+	if trim {
+		opts = withSilenceFilter(opts)
+	}
+	filtered := len(req.Filters) > 0
+	opts = withFilters(opts, req.Filters)
+	name := ""
+	if req.Key != "" && !filtered {
+		name = frameName(req.Key, opts)
+	}
+	// only the whole song is measured
+	measure := req.Measured != nil && req.Start == 0 && !filtered
+	if measure {
+		opts = withLoudnessFilter(opts)
+	}
+	// the encoder starts at a whole second
+	start := req.Start.Truncate(time.Second)
+	if start > 0 {
+		resumed := *opts
+		resumed.StartTime = int(start.Seconds())
+		opts = &resumed
+	}
+	var source dca.OpusReader
+	var encoded EncodeSession
+	if frames, ok := p.frames.open(name, time.Duration(opts.FrameDuration)*time.Millisecond); ok {
+		defer frames.Close()
+		if err := frames.skip(start); err != nil {
+			return errors.Wrapf(err, "resume %s", uri)
+		}
+		source = frames
+		p.setStats(0, req.Filters)
+	} else {
+		encodeTime := time.Now()
+		encodeSession, err := p.encode(uri, opts)
+		if err != nil {
+			return errors.Wrapf(err, "encode %s", uri)
+		}
+		encodeStart.Observe(time.Since(encodeTime).Seconds())
+		defer encodeSession.Cleanup()
+		p.setStats(encodeSession.Stats().Duration, req.Filters)
+		source = encodeSession
+		encoded = encodeSession
+		if start == 0 {
+			if rec := p.frames.record(name, encodeSession); rec != nil {
+				defer rec.close()
+				source = rec
+			}
+		}
 	}
-	defer encodeSession.Cleanup()
-
-	p.setStatsDuration(encodeSession.Stats().Duration)
 
-	stream := dca.NewStream(encodeSession, v, p.done)
-	err = p.updatePosition(stream)
-	p.setPlaying(false)
-	_ = v.Speaking(false)
+	if trim {
+		source = trimTrailingSilence(source)
+	}
+	source = &tapReader{OpusReader: source, p: p, frameDuration: opts.FrameDuration}
+	p.clipMx.Lock()
+	// the song is already being encoded while the announcement plays
+	if start == 0 {
+		p.announce(v, req.Announce)
+	}
+	stream := dca.NewStream(source, v, p.done)
+	p.setStream(stream, start, req.Filters.Tempo())
+	p.clipMx.Unlock()
+	err = <-p.done
+	stream.SetPaused(true)
+	var seek *seekError
+	p.endStream(errors.As(err, &seek))
+	_ = p.speaking(v, false)
+	if measure && encoded != nil && err == io.EOF && encoded.Error() == nil {
+		if gain, ok := parseGain(encoded.FFMPEGMessages(), opts.Volume); ok {
+			req.Measured(gain)
+		}
+	}
 	return err
 }
 
-func (p *Player) updatePosition(stream *dca.StreamingSession) error {
-	ticker := time.NewTicker(500 * time.Millisecond)
-	for {
-		select {
-		case err := <-p.done:
-			stream.SetPaused(true)
-			return err
-		case <-ticker.C:
-			p.setStatsPos(stream.PlaybackPosition())
-		}
+func (p *Player) speaking(v *discordgo.VoiceConnection, b bool) error {
+	if p.headless {
+		return nil
+	}
+	return v.Speaking(b)
+}
+
+// options with the volume in percent and the gain in dB applied
+func (p *Player) options(volume int, gain float64) *dca.EncodeOptions {
+	if (volume == 0 || volume == 100) && gain == 0 {
+		return p.Options
+	}
+	opts := *p.Options
+	if volume != 0 {
+		opts.Volume = opts.Volume * volume / 100
+	}
+	opts.Volume = withGain(opts.Volume, gain)
+	if opts.Volume > maxVolume {
+		opts.Volume = maxVolume
 	}
+	return &opts
 }
@@ -0,0 +1,133 @@
+package audio
+
+import (
+	"bufio"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/khodand/dca"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+)
+
+// ErrRecording only one recording at a time, the player serves one guild
+var ErrRecording = errors.New("already recording")
+
+// Recording of the frames sent to Discord, Err is set if it was cut by a write error
+type Recording struct {
+	Path     string
+	Duration time.Duration
+	Size     int64
+	Err      error
+}
+
+// recording the songs, clips and announcements are written as they are sent, the pauses between them are skipped
+type recording struct {
+	f       *os.File
+	w       *bufio.Writer
+	ogg     *oggWriter
+	samples int64
+	max     int64
+	size    int64
+	done    func(Recording)
+}
+
+// StartRecording into an ogg file at path until StopRecording or maxDuration, done is called when it ends
+func (p *Player) StartRecording(path string, maxDuration time.Duration, done func(Recording)) error {
+	p.recordMx.Lock()
+	defer p.recordMx.Unlock()
+	if p.rec != nil {
+		return ErrRecording
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "create recording")
+	}
+	w := bufio.NewWriter(f)
+	ogg, err := newOggWriter(w, rand.Uint32(), p.Options.Channels)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return errors.Wrap(err, "write recording header")
+	}
+	p.rec = &recording{
+		f:    f,
+		w:    w,
+		ogg:  ogg,
+		max:  int64(maxDuration.Seconds() * oggSampleRate),
+		done: done,
+	}
+	return nil
+}
+
+// StopRecording returns false if nothing was recorded
+func (p *Player) StopRecording() bool {
+	p.recordMx.Lock()
+	defer p.recordMx.Unlock()
+	if p.rec == nil {
+		return false
+	}
+	p.finishRecording(nil)
+	return true
+}
+
+// record the frame of frameDuration ms if a recording is on, it ends at the limit or on the first error
+func (p *Player) record(frame []byte, frameDuration int) {
+	p.recordMx.Lock()
+	defer p.recordMx.Unlock()
+	r := p.rec
+	if r == nil {
+		return
+	}
+	samples := int64(frameDuration) * oggSampleRate / 1000
+	if err := r.ogg.WriteFrame(frame, samples); err != nil {
+		p.finishRecording(errors.Wrap(err, "write recording"))
+		return
+	}
+	r.samples += samples
+	if r.samples >= r.max {
+		p.finishRecording(nil)
+	}
+}
+
+// finishRecording is called under recordMx
+func (p *Player) finishRecording(err error) {
+	r := p.rec
+	p.rec = nil
+	if cerr := r.ogg.Close(); err == nil && cerr != nil {
+		err = errors.Wrap(cerr, "close recording")
+	}
+	if ferr := r.w.Flush(); err == nil && ferr != nil {
+		err = errors.Wrap(ferr, "flush recording")
+	}
+	if cerr := r.f.Close(); err == nil && cerr != nil {
+		err = errors.Wrap(cerr, "close recording")
+	}
+	if info, serr := os.Stat(r.f.Name()); serr == nil {
+		r.size = info.Size()
+	}
+	res := Recording{
+		Path:     r.f.Name(),
+		Duration: time.Duration(r.samples) * time.Second / oggSampleRate,
+		Size:     r.size,
+		Err:      err,
+	}
+	go supervisor.Safe(p.logger, "recording done", func() { r.done(res) })
+}
+
+// tapReader records the frames of the song as the stream takes them
+type tapReader struct {
+	dca.OpusReader
+	p             *Player
+	frameDuration int
+}
+
+func (t *tapReader) OpusFrame() ([]byte, error) {
+	frame, err := t.OpusReader.OpusFrame()
+	if err == nil {
+		t.p.record(frame, t.frameDuration)
+	}
+	return frame, err
+}
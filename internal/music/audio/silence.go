@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"io"
+
+	"github.com/khodand/dca"
+)
+
+const (
+	// leadingSilenceFilter ffmpeg drops the audio below -60dB until the first sound
+	leadingSilenceFilter = "silenceremove=start_periods=1:start_threshold=-60dB"
+	// silentFrameSize opus encodes the digital silence in a few bytes, the quietest sound takes more
+	silentFrameSize = 8
+	// maxHeldFrames of silence in the middle of the song are sent as they are, 10 seconds of 20ms frames
+	maxHeldFrames = 500
+)
+
+// withSilenceFilter the leading silence is removed by the encoder
+func withSilenceFilter(opts *dca.EncodeOptions) *dca.EncodeOptions {
+	trimmed := *opts
+	if trimmed.AudioFilter == "" {
+		trimmed.AudioFilter = leadingSilenceFilter
+	} else {
+		trimmed.AudioFilter = leadingSilenceFilter + "," + trimmed.AudioFilter
+	}
+	return &trimmed
+}
+
+// silenceTrimmer holds the silent frames until a sound follows them, so the silence at the end is never sent
+type silenceTrimmer struct {
+	dca.OpusReader
+	held  [][]byte
+	next  []byte
+	ended error
+}
+
+func trimTrailingSilence(src dca.OpusReader) dca.OpusReader {
+	return &silenceTrimmer{OpusReader: src}
+}
+
+func (t *silenceTrimmer) OpusFrame() ([]byte, error) {
+	if len(t.held) > 0 {
+		frame := t.held[0]
+		t.held = t.held[1:]
+		return frame, nil
+	}
+	if t.next != nil {
+		frame := t.next
+		t.next = nil
+		return frame, nil
+	}
+	if t.ended != nil {
+		return nil, t.ended
+	}
+	for {
+		frame, err := t.OpusReader.OpusFrame()
+		if err != nil {
+			// the held silence is dropped only at the end of the song
+			if err != io.EOF && len(t.held) > 0 {
+				t.ended = err
+				return t.OpusFrame()
+			}
+			t.held = nil
+			return nil, err
+		}
+		if len(frame) > silentFrameSize {
+			if len(t.held) == 0 {
+				return frame, nil
+			}
+			t.next = frame
+			return t.OpusFrame()
+		}
+		t.held = append(t.held, frame)
+		if len(t.held) >= maxHeldFrames {
+			return t.OpusFrame()
+		}
+	}
+}
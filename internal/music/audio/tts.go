@@ -0,0 +1,52 @@
+package audio
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/khodand/dca"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// textArg is replaced by the announcement in TTSConfig.Args
+const textArg = "{text}"
+
+// DefaultTTSArgs of espeak-ng, the wav goes to stdout
+var DefaultTTSArgs = []string{"--stdout", textArg}
+
+// TTSConfig the announcements are spoken by this command, it has to write the audio to stdout
+type TTSConfig struct {
+	Enabled bool     `json:"enabled"`
+	Path    string   `json:"path"`
+	Args    []string `json:"args"`
+}
+
+// NewTTS the Encoder takes the text instead of the uri
+func NewTTS(cfg TTSConfig, logger zap.Logger) Encoder {
+	path := cfg.Path
+	if path == "" {
+		path = "espeak-ng"
+	}
+	args := cfg.Args
+	if len(args) == 0 {
+		args = DefaultTTSArgs
+	}
+	return func(text string, opts *dca.EncodeOptions) (EncodeSession, error) {
+		return encodeProcess(path, replaceArg(args, textArg, text), opts, logger)
+	}
+}
+
+// announce speaks before the song, a failed announcement doesn't stop the song
+func (p *Player) announce(v *discordgo.VoiceConnection, text string) {
+	if p.speak == nil || text == "" {
+		return
+	}
+	session, err := p.speak(text, p.Options)
+	if err != nil {
+		p.logger.Warnw("announce", "err", err)
+		return
+	}
+	defer session.Cleanup()
+	if err := p.sendFrames(v, session); err != nil {
+		p.logger.Warnw("announce", "err", err)
+	}
+}
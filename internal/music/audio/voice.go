@@ -2,6 +2,7 @@ package audio
 
 import (
 	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
 )
 
 type Client struct {
@@ -32,6 +33,7 @@ func (c *Client) Connect(guildID, channelID string) error {
 		}
 		_ = c.Disconnect()
 		c.conn = nil
+		voiceReconnects.Inc()
 	}
 
 	conn, err := c.session.ChannelVoiceJoin(guildID, channelID, false, true)
@@ -40,9 +42,38 @@ func (c *Client) Connect(guildID, channelID string) error {
 		return err
 	}
 	c.conn = conn
+	voiceConnections.Inc()
 	return nil
 }
 
+// Reconnect joins the channel with a new connection, the old one could be broken while it looks ready
+func (c *Client) Reconnect(guildID, channelID string) error {
+	if c.conn != nil {
+		_ = c.conn.Disconnect()
+		c.conn = nil
+		voiceConnections.Dec()
+	}
+	voiceReconnects.Inc()
+	conn, err := c.session.ChannelVoiceJoin(guildID, channelID, false, true)
+	if err != nil {
+		return errors.Wrapf(err, "rejoin gid:%s cid:%s", guildID, channelID)
+	}
+	c.conn = conn
+	voiceConnections.Inc()
+	return nil
+}
+
+// Channel the bot is connected to, empty if it isn't
+func (c *Client) Channel() (guildID, channelID string) {
+	conn := c.conn
+	if conn == nil {
+		return "", ""
+	}
+	conn.Lock()
+	defer conn.Unlock()
+	return conn.GuildID, conn.ChannelID
+}
+
 func (c *Client) IsConnected() bool {
 	return c.conn != nil
 }
@@ -52,5 +83,6 @@ func (c *Client) Disconnect() error {
 		return err
 	}
 	c.conn = nil
+	voiceConnections.Dec()
 	return nil
 }
@@ -0,0 +1,57 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const publishTimeout = 5 * time.Second
+
+// Publisher sends the event encoded as JSON to the broker
+type Publisher interface {
+	Name() string
+	Publish(ctx context.Context, e *player.Event, data []byte) error
+}
+
+// Forwarder publishes the events of the player to the brokers, the failed events are dropped
+type Forwarder struct {
+	publishers []Publisher
+	logger     zap.Logger
+}
+
+func NewForwarder(logger zap.Logger, publishers ...Publisher) *Forwarder {
+	return &Forwarder{
+		publishers: publishers,
+		logger:     logger,
+	}
+}
+
+func (f *Forwarder) Len() int {
+	return len(f.publishers)
+}
+
+// Forward is the handler of the events
+func (f *Forwarder) Forward(e player.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		f.logger.Error(errors.Wrap(err, "marshal event"))
+		return
+	}
+	for _, p := range f.publishers {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := p.Publish(ctx, &e, data)
+		cancel()
+		if err != nil {
+			published.WithLabelValues(p.Name(), "error").Inc()
+			f.logger.Warnw("event not published", "broker", p.Name(), "event", e.Type, "err", err)
+			continue
+		}
+		published.WithLabelValues(p.Name(), "ok").Inc()
+	}
+}
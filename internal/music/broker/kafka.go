@@ -0,0 +1,62 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+)
+
+// Kafka publishes the events to the topic through the REST proxy, the guild is the key of the record,
+// so the events of a guild stay in order in one partition
+type Kafka struct {
+	url    string
+	client *http.Client
+}
+
+// NewKafka the url of the REST proxy, e.g. http://kafka-rest:8082, and the topic
+func NewKafka(proxyURL, topic string) *Kafka {
+	return &Kafka{
+		url:    strings.TrimSuffix(proxyURL, "/") + "/topics/" + topic,
+		client: &http.Client{Timeout: publishTimeout},
+	}
+}
+
+func (k *Kafka) Name() string {
+	return "kafka"
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (k *Kafka) Publish(ctx context.Context, e *player.Event, data []byte) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Key: e.GuildID, Value: data}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("rest proxy status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package broker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var published = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "broker",
+	Name:      "events_total",
+	Help:      "Player events published to the brokers by the broker and the result.",
+}, []string{"broker", "result"})
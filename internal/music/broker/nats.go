@@ -0,0 +1,149 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const natsDialTimeout = 5 * time.Second
+
+// NATS publishes every event to the subject with the type of the event appended, e.g. halva.player.track_started.
+// It speaks the text protocol of the core NATS, without the acknowledgements, and connects again on the next event
+// after the connection is lost.
+type NATS struct {
+	addr    string
+	user    string
+	pass    string
+	subject string
+	logger  zap.Logger
+
+	mx   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// NewNATS the url is nats://[user:password@]host[:port]
+func NewNATS(rawURL, subject string, logger zap.Logger) (*NATS, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse nats url")
+	}
+	if u.Scheme != "nats" || u.Hostname() == "" {
+		return nil, errors.Errorf("nats url %s is not nats://host[:port]", rawURL)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	n := &NATS{addr: addr, subject: subject, logger: logger}
+	if u.User != nil {
+		n.user = u.User.Username()
+		n.pass, _ = u.User.Password()
+	}
+	return n, nil
+}
+
+func (n *NATS) Name() string {
+	return "nats"
+}
+
+func (n *NATS) Publish(ctx context.Context, e *player.Event, data []byte) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return errors.Wrap(err, "connect to nats")
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = n.conn.SetWriteDeadline(deadline)
+	}
+	_, _ = fmt.Fprintf(n.w, "PUB %s.%s %d\r\n", n.subject, e.Type, len(data))
+	_, _ = n.w.Write(data)
+	_, _ = n.w.WriteString("\r\n")
+	if err := n.w.Flush(); err != nil {
+		n.close()
+		return errors.Wrap(err, "publish to nats")
+	}
+	return nil
+}
+
+// connect reads the INFO of the server, sends CONNECT and answers the PINGs until the connection breaks
+func (n *NATS) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: natsDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(natsDialTimeout))
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "read info")
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		conn.Close()
+		return errors.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "name": "halvabot", "lang": "go"}
+	if n.user != "" {
+		options["user"], options["pass"] = n.user, n.pass
+	}
+	connect, _ := json.Marshal(options)
+	w := bufio.NewWriter(conn)
+	_, _ = fmt.Fprintf(w, "CONNECT %s\r\n", connect)
+	if err := w.Flush(); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "send connect")
+	}
+	_ = conn.SetDeadline(time.Time{})
+	n.conn, n.w = conn, w
+	go n.read(conn, r)
+	return nil
+}
+
+func (n *NATS) read(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			n.mx.Lock()
+			if n.conn == conn {
+				n.close()
+			}
+			n.mx.Unlock()
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			n.mx.Lock()
+			if n.conn == conn {
+				_, _ = n.w.WriteString("PONG\r\n")
+				_ = n.w.Flush()
+			}
+			n.mx.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			n.logger.Warnw("nats error", "err", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// close must be called with the mutex held
+func (n *NATS) close() {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	n.conn, n.w = nil, nil
+}
@@ -0,0 +1,59 @@
+package music
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/api/discord"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/api/rest"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/recording"
+)
+
+// Name of the cog in the config
+const Name = "music"
+
+// Cog joins the discord commands and the http api of the player
+type Cog struct {
+	*discord.Service
+	player     *player.Service
+	recordings *recording.Service
+	session    *discordgo.Session
+}
+
+// NewCog recordings may be nil if they are disabled
+func NewCog(commands *discord.Service, player *player.Service, recordings *recording.Service, session *discordgo.Session) *Cog {
+	return &Cog{
+		Service:    commands,
+		player:     player,
+		recordings: recordings,
+		session:    session,
+	}
+}
+
+func (c *Cog) Name() string {
+	return Name
+}
+
+func (c *Cog) RegisterRoutes(router *gin.RouterGroup) {
+	var recordings rest.Recordings
+	if c.recordings != nil {
+		recordings = c.recordings
+	}
+	rest.NewHandler(c.player, recordings, router).Router()
+}
+
+// Shutdown warns the listeners and saves the queue
+func (c *Cog) Shutdown(ctx context.Context) error {
+	c.AnnounceRestart(c.session)
+	if c.recordings != nil {
+		c.recordings.Stop()
+	}
+	if err := c.player.Shutdown(ctx); err != nil {
+		return errors.Wrap(err, "shutdown player")
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package download
+
+import (
+	"container/list"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// PartSuffix of the files being downloaded, they are renamed when complete
+const PartSuffix = ".part"
+
+// File downloaded song, Name is the file name in the cache directory
+type File struct {
+	Name     string    `firestore:"-"`
+	Size     int64     `firestore:"size"`
+	LastUsed time.Time `firestore:"last_used"`
+}
+
+type Storage interface {
+	AllDownloads(ctx context.Context) ([]File, error)
+	SetDownload(ctx context.Context, f *File) error
+	DeleteDownload(ctx context.Context, name string) error
+}
+
+// Cache of the downloaded songs bounded by the total size, the least recently used files are evicted.
+// The metadata is kept in Firestore so the files are reused after a restart.
+type Cache struct {
+	dir     string
+	maxSize int64
+	storage Storage
+	logger  zap.Logger
+
+	mx     sync.Mutex
+	size   int64
+	order  *list.List // front is the most recently used
+	files  map[string]*list.Element
+	loaded bool
+}
+
+// NewCache serves the files found on the disk without tracking them until Load
+func NewCache(dir string, maxSize int64, storage Storage, logger zap.Logger) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "create download dir")
+	}
+	return &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+		storage: storage,
+		logger:  logger,
+		order:   list.New(),
+		files:   make(map[string]*list.Element),
+	}, nil
+}
+
+// Load reconciles the directory with the stored metadata: unknown files are adopted, metadata of missing files is dropped
+func (c *Cache) Load(ctx context.Context) error {
+	stored, err := c.storage.AllDownloads(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load downloads")
+	}
+	known := make(map[string]File, len(stored))
+	for _, f := range stored {
+		known[f.Name] = f
+	}
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return errors.Wrap(err, "read download dir")
+	}
+	files := make([]File, 0, len(infos))
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		// left by a restart in the middle of a download
+		if strings.HasSuffix(info.Name(), PartSuffix) {
+			if err := os.Remove(c.Path(info.Name())); err != nil {
+				c.logger.Error(errors.Wrap(err, "remove partial download"))
+			}
+			continue
+		}
+		f, ok := known[info.Name()]
+		delete(known, info.Name())
+		if !ok {
+			f = File{Name: info.Name(), LastUsed: info.ModTime()}
+		}
+		if f.Size != info.Size() || !ok {
+			f.Size = info.Size()
+			if err := c.storage.SetDownload(ctx, &f); err != nil {
+				return errors.Wrap(err, "adopt download")
+			}
+		}
+		files = append(files, f)
+	}
+	for name := range known {
+		if err := c.storage.DeleteDownload(ctx, name); err != nil {
+			return errors.Wrap(err, "drop missing download")
+		}
+	}
+	// the oldest go to the back
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].LastUsed.After(files[j].LastUsed)
+	})
+	c.mx.Lock()
+	c.order.Init()
+	c.files = make(map[string]*list.Element, len(files))
+	c.size = 0
+	for _, f := range files {
+		f := f
+		c.files[f.Name] = c.order.PushBack(&f)
+		c.size += f.Size
+	}
+	c.loaded = true
+	c.mx.Unlock()
+	c.evict(ctx)
+	return nil
+}
+
+// Usage total size of the files and the limit in bytes
+func (c *Cache) Usage() (size, maxSize int64, files int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.size, c.maxSize, len(c.files)
+}
+
+func (c *Cache) Path(name string) string {
+	return filepath.Join(c.dir, name)
+}
+
+// Get returns the path of the downloaded file and marks it used
+func (c *Cache) Get(ctx context.Context, name string) (string, bool) {
+	c.mx.Lock()
+	if !c.loaded {
+		c.mx.Unlock()
+		return c.unloadedGet(name)
+	}
+	e, ok := c.files[name]
+	if !ok {
+		c.mx.Unlock()
+		downloadRequests.WithLabelValues("miss").Inc()
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	f := e.Value.(*File)
+	f.LastUsed = time.Now()
+	stored := *f
+	c.mx.Unlock()
+	downloadRequests.WithLabelValues("hit").Inc()
+	if err := c.storage.SetDownload(ctx, &stored); err != nil {
+		c.logger.Error(errors.Wrap(err, "touch download"))
+	}
+	return c.Path(name), true
+}
+
+// Put registers the file downloaded to Path(name) and evicts the old ones over the limit
+func (c *Cache) Put(ctx context.Context, name string) error {
+	info, err := os.Stat(c.Path(name))
+	if err != nil {
+		return errors.Wrap(err, "stat download")
+	}
+	if !c.isLoaded() {
+		// Load adopts the file
+		return nil
+	}
+	f := &File{Name: name, Size: info.Size(), LastUsed: time.Now()}
+	if err := c.storage.SetDownload(ctx, f); err != nil {
+		return errors.Wrap(err, "save download")
+	}
+	c.mx.Lock()
+	if e, ok := c.files[name]; ok {
+		c.size -= e.Value.(*File).Size
+		c.order.Remove(e)
+	}
+	c.files[name] = c.order.PushFront(f)
+	c.size += f.Size
+	c.mx.Unlock()
+	c.evict(ctx)
+	return nil
+}
+
+func (c *Cache) isLoaded() bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.loaded
+}
+
+// unloadedGet any file on the disk is served before the metadata is loaded
+func (c *Cache) unloadedGet(name string) (string, bool) {
+	if _, err := os.Stat(c.Path(name)); err != nil {
+		downloadRequests.WithLabelValues("miss").Inc()
+		return "", false
+	}
+	downloadRequests.WithLabelValues("hit").Inc()
+	return c.Path(name), true
+}
+
+// evict keeps the most recent file even if it alone is over the limit
+func (c *Cache) evict(ctx context.Context) {
+	c.mx.Lock()
+	evicted := make([]string, 0)
+	for c.size > c.maxSize && c.order.Len() > 1 {
+		f := c.order.Remove(c.order.Back()).(*File)
+		delete(c.files, f.Name)
+		c.size -= f.Size
+		evicted = append(evicted, f.Name)
+	}
+	downloadsSize.Set(float64(c.size))
+	c.mx.Unlock()
+	for _, name := range evicted {
+		// playing files stay readable until closed
+		if err := os.Remove(c.Path(name)); err != nil && !os.IsNotExist(err) {
+			c.logger.Error(errors.Wrap(err, "remove download"))
+		}
+		if err := c.storage.DeleteDownload(ctx, name); err != nil {
+			c.logger.Error(errors.Wrap(err, "delete download"))
+		}
+		downloadEvictions.Inc()
+	}
+}
@@ -0,0 +1,27 @@
+package download
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	downloadsSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "downloads",
+		Name:      "size_bytes",
+		Help:      "Total size of the downloaded songs.",
+	})
+	downloadRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "downloads",
+		Name:      "requests_total",
+		Help:      "Lookups of downloaded songs by result.",
+	}, []string{"result"})
+	downloadEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "downloads",
+		Name:      "evictions_total",
+		Help:      "Downloaded songs removed to stay under the size limit.",
+	})
+)
@@ -0,0 +1,118 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+type EventType string
+
+const (
+	TrackStarted  EventType = "track_started"
+	TrackFinished EventType = "track_finished"
+	QueueUpdated  EventType = "queue_updated"
+	// QueueEnded nothing is left to play, the radio picks the next song
+	QueueEnded   EventType = "queue_ended"
+	Connected    EventType = "connected"
+	Disconnected EventType = "disconnected"
+	// Reconnected the voice connection was lost and joined again, the song continues from Pos
+	Reconnected EventType = "reconnected"
+	// Paused and Resumed the song at Pos
+	Paused  EventType = "paused"
+	Resumed EventType = "resumed"
+	// Seeked the song jumped to Pos
+	Seeked EventType = "seeked"
+	// Failed the player or the audio returned an error, the end of the songs isn't one
+	Failed EventType = "error"
+)
+
+// eventBuffer of a subscriber, the events are dropped while it is full
+const eventBuffer = 64
+
+// Event of the player, the fields which don't apply to the type are empty
+type Event struct {
+	Type      EventType `json:"type"`
+	GuildID   string    `json:"guild_id,omitempty"`
+	ChannelID string    `json:"channel_id,omitempty"`
+	Song      *pkg.Song `json:"song,omitempty"`
+	// Queue songs waiting after the current one
+	Queue int           `json:"queue"`
+	Pos   time.Duration `json:"-"`
+	Err   error         `json:"-"`
+	// Error the text of Err for the clients
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+type EventHandler func(e Event)
+
+type subscriber struct {
+	types  map[EventType]bool // all if nil
+	events chan Event
+	once   sync.Once
+}
+
+// Bus delivers the events to every subscriber in their order, a slow subscriber doesn't hold the player
+type Bus struct {
+	logger zap.Logger
+
+	mx          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+func NewBus(logger zap.Logger) *Bus {
+	return &Bus{
+		logger:      logger,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe to the types of the events, all of them if none are passed
+func (b *Bus) Subscribe(h EventHandler, types ...EventType) (unsubscribe func()) {
+	sub := &subscriber{events: make(chan Event, eventBuffer)}
+	if len(types) != 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	go func() {
+		for e := range sub.events {
+			supervisor.Safe(b.logger, "player event handler", func() { h(e) })
+		}
+	}()
+	b.mx.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mx.Unlock()
+	return func() {
+		sub.once.Do(func() {
+			b.mx.Lock()
+			delete(b.subscribers, sub)
+			close(sub.events)
+			b.mx.Unlock()
+		})
+	}
+}
+
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	events.WithLabelValues(string(e.Type)).Inc()
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+	for sub := range b.subscribers {
+		if sub.types != nil && !sub.types[e.Type] {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			droppedEvents.Inc()
+		}
+	}
+}
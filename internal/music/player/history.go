@@ -0,0 +1,187 @@
+package player
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	maxSnapshots = 5
+	// undoWindow the older snapshots are dropped, the queue has changed too much since
+	undoWindow = 5 * time.Minute
+)
+
+// ErrNothingToUndo there is no action in the undo window
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// Actions which can be undone
+const (
+	ActionClear   = "clear"
+	ActionShuffle = "shuffle"
+	ActionRemove  = "remove"
+	ActionSkipTo  = "skipto"
+	ActionMove    = "move"
+)
+
+// snapshot of the queue before an action
+type snapshot struct {
+	action string
+	songs  []*pkg.Song
+	taken  time.Time
+}
+
+// history keeps the last snapshots of the queue, the songs played since a snapshot are taken out of it
+type history struct {
+	mx        sync.Mutex
+	snapshots []snapshot
+}
+
+func (h *history) push(action string, songs []*pkg.Song) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.snapshots = append(h.snapshots, snapshot{action: action, songs: songs, taken: time.Now()})
+	if len(h.snapshots) > maxSnapshots {
+		h.snapshots = h.snapshots[len(h.snapshots)-maxSnapshots:]
+	}
+}
+
+// pop the last snapshot in the undo window
+func (h *history) pop() (snapshot, bool) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	for len(h.snapshots) != 0 {
+		last := h.snapshots[len(h.snapshots)-1]
+		h.snapshots = h.snapshots[:len(h.snapshots)-1]
+		if time.Since(last.taken) <= undoWindow {
+			return last, true
+		}
+	}
+	return snapshot{}, false
+}
+
+// played the song isn't restored, it would play twice
+func (h *history) played(s *pkg.Song) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	for i := range h.snapshots {
+		songs := h.snapshots[i].songs
+		for j := range songs {
+			if songs[j].ID == s.ID {
+				h.snapshots[i].songs = append(songs[:j:j], songs[j+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (h *history) reset() {
+	h.mx.Lock()
+	h.snapshots = nil
+	h.mx.Unlock()
+}
+
+// Clear the queue, the current song plays to the end
+func (p *Player) Clear() error {
+	p.editMx.Lock()
+	defer p.editMx.Unlock()
+	songs := p.queue.Entries()
+	if len(songs) == 0 {
+		return ErrQueueEmpty
+	}
+	p.history.push(ActionClear, songs)
+	if err := p.queue.Replace(nil); err != nil {
+		return err
+	}
+	p.publish(Event{Type: QueueUpdated})
+	return nil
+}
+
+// Shuffle the queued songs, the current one plays on
+func (p *Player) Shuffle() error {
+	p.editMx.Lock()
+	defer p.editMx.Unlock()
+	songs := p.queue.Entries()
+	if len(songs) == 0 {
+		return ErrQueueEmpty
+	}
+	p.history.push(ActionShuffle, songs)
+	shuffled := make([]*pkg.Song, len(songs))
+	copy(shuffled, songs)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	if err := p.queue.Replace(shuffled); err != nil {
+		return err
+	}
+	p.publish(Event{Type: QueueUpdated})
+	return nil
+}
+
+// SkipTo the song at the position from 0, the songs before it are dropped with the current one
+func (p *Player) SkipTo(pos int) (*pkg.Song, error) {
+	p.editMx.Lock()
+	defer p.editMx.Unlock()
+	songs := p.queue.Entries()
+	if pos < 0 || pos >= len(songs) {
+		return nil, ErrNotQueued
+	}
+	before := songs
+	if current := p.NowPlaying(); current != nil {
+		before = append([]*pkg.Song{current}, songs...)
+	}
+	p.history.push(ActionSkipTo, before)
+	if err := p.queue.Replace(songs[pos:]); err != nil {
+		return nil, err
+	}
+	// the player takes the next song under editMx
+	go p.Skip()
+	return songs[pos], nil
+}
+
+// Move the song from the position to another one, both from 0, the songs between them shift by one
+func (p *Player) Move(from, to int) (*pkg.Song, error) {
+	p.editMx.Lock()
+	defer p.editMx.Unlock()
+	songs := p.queue.Entries()
+	if from < 0 || from >= len(songs) || to < 0 || to >= len(songs) {
+		return nil, ErrNotQueued
+	}
+	song := songs[from]
+	if from == to {
+		return song, nil
+	}
+	p.history.push(ActionMove, songs)
+	moved := make([]*pkg.Song, 0, len(songs))
+	moved = append(moved, songs[:from]...)
+	moved = append(moved, songs[from+1:]...)
+	moved = append(moved[:to], append([]*pkg.Song{song}, moved[to:]...)...)
+	if err := p.queue.Replace(moved); err != nil {
+		return nil, err
+	}
+	p.publish(Event{Type: QueueUpdated})
+	return song, nil
+}
+
+// Undo the last clear, shuffle, remove, skipto or move in the undo window, returns the action
+func (p *Player) Undo() (string, error) {
+	p.editMx.Lock()
+	defer p.editMx.Unlock()
+	last, ok := p.history.pop()
+	if !ok {
+		return "", ErrNothingToUndo
+	}
+	if err := p.queue.Replace(last.songs); err != nil {
+		return "", err
+	}
+	p.publish(Event{Type: QueueUpdated})
+	// the queue could end meanwhile, nothing is done if a song plays
+	go func() {
+		p.commands <- &command{Type: next}
+	}()
+	return last.action, nil
+}
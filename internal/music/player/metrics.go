@@ -0,0 +1,57 @@
+package player
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tracksPlayed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "tracks_played_total",
+		Help:      "Songs sent to the audio player.",
+	})
+	skips = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "skips_total",
+		Help:      "Skipped songs.",
+	})
+	queueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "queue_length",
+		Help:      "Songs waiting in the queue.",
+	})
+	queueStoreErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "queue_store_errors_total",
+		Help:      "Failed operations of the queue store.",
+	})
+	prefetchedSongs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "prefetched_songs_total",
+		Help:      "Queued songs resolved in the background before they came next.",
+	})
+	unresolvedSongs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "unresolved_songs_total",
+		Help:      "Queued songs skipped because their stream wasn't found.",
+	})
+	events = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "events_total",
+		Help:      "Events published by the player by the type.",
+	}, []string{"type"})
+	droppedEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "player",
+		Name:      "dropped_events_total",
+		Help:      "Events not delivered to a subscriber which fell behind.",
+	})
+)
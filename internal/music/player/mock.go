@@ -1,20 +1,23 @@
 package player
 
 import (
+	"context"
 	"sync"
 	"time"
 
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
-	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
 )
 
 type MockPlayer struct {
 	statusMx    sync.Mutex
 	loopStatus  bool
 	radioStatus bool
+	paused      bool
+	filters     audio.Filters
 }
 
-func (m *MockPlayer) Play(ctx contexts.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error) {
+func (m *MockPlayer) Play(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error) {
 	song := &pkg.Song{
 		Title:        query,
 		URL:          "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
@@ -36,8 +39,30 @@ func (m *MockPlayer) Play(ctx contexts.Context, query, userID, guildID, channelI
 	return song, 11, nil
 }
 
+func (m *MockPlayer) PlayNext(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error) {
+	return m.Play(ctx, query, userID, guildID, channelID)
+}
+
+func (m *MockPlayer) FindPlaylist(ctx context.Context, link string, progress func(loaded, total int)) (string, []*pkg.Song, error) {
+	songs := make([]*pkg.Song, 0, 3)
+	for i := 0; i < 3; i++ {
+		song, _, _ := m.Play(ctx, link, "", "", "")
+		songs = append(songs, song)
+	}
+	if progress != nil {
+		progress(len(songs), len(songs))
+	}
+	return "Mock playlist", songs, nil
+}
+
+func (m *MockPlayer) PlayAll(ctx context.Context, songs []*pkg.Song, userID, guildID, channelID string) (int, error) {
+	return len(songs), nil
+}
+
 func (m *MockPlayer) Skip() {}
 
+func (m *MockPlayer) Stop() {}
+
 func (m *MockPlayer) SetLoop(b bool) {
 	m.statusMx.Lock()
 	m.loopStatus = b
@@ -72,13 +97,43 @@ func (m *MockPlayer) NowPlaying() *pkg.Song {
 }
 
 func (m *MockPlayer) SongStatus() pkg.SessionStats {
+	m.statusMx.Lock()
+	defer m.statusMx.Unlock()
 	return pkg.SessionStats{
 		Pos:      111,
 		Duration: 212,
+		Paused:   m.paused,
 	}
 }
 
-func (m *MockPlayer) SetRadio(ctx contexts.Context, b bool, guildID, channelID string) error {
+func (m *MockPlayer) Pause(ctx context.Context) error {
+	m.statusMx.Lock()
+	defer m.statusMx.Unlock()
+	if m.paused {
+		return ErrPaused
+	}
+	m.paused = true
+	return nil
+}
+
+func (m *MockPlayer) Seek(pos time.Duration) error {
+	if pos < 0 || pos.Seconds() >= 212 {
+		return ErrInvalidPosition
+	}
+	return nil
+}
+
+func (m *MockPlayer) Resume(ctx context.Context) error {
+	m.statusMx.Lock()
+	defer m.statusMx.Unlock()
+	if !m.paused {
+		return ErrNotPaused
+	}
+	m.paused = false
+	return nil
+}
+
+func (m *MockPlayer) SetRadio(ctx context.Context, b bool, guildID, channelID string) error {
 	m.statusMx.Lock()
 	m.radioStatus = b
 	m.statusMx.Unlock()
@@ -92,6 +147,19 @@ func (m *MockPlayer) RadioStatus() bool {
 	return b
 }
 
+func (m *MockPlayer) Filters(guildID string) (audio.Filters, error) {
+	m.statusMx.Lock()
+	defer m.statusMx.Unlock()
+	return m.filters, nil
+}
+
+func (m *MockPlayer) SetFilters(ctx context.Context, guildID string, filters audio.Filters) error {
+	m.statusMx.Lock()
+	m.filters = filters
+	m.statusMx.Unlock()
+	return nil
+}
+
 func (m *MockPlayer) Status() pkg.PlayerStatus {
 	return pkg.PlayerStatus{
 		Loop:  m.LoopStatus(),
@@ -100,3 +168,30 @@ func (m *MockPlayer) Status() pkg.PlayerStatus {
 		Now:   m.NowPlaying(),
 	}
 }
+
+// Subscribe the mock doesn't play, so there are no events
+func (m *MockPlayer) Subscribe(h EventHandler, types ...EventType) (unsubscribe func()) {
+	return func() {}
+}
+
+func (m *MockPlayer) Queue() []*pkg.Song {
+	return []*pkg.Song{m.NowPlaying()}
+}
+
+func (m *MockPlayer) Remove(pos int) (*pkg.Song, error) {
+	if pos != 0 {
+		return nil, ErrNotQueued
+	}
+	return m.NowPlaying(), nil
+}
+
+func (m *MockPlayer) Shuffle() error {
+	return nil
+}
+
+func (m *MockPlayer) Move(from, to int) (*pkg.Song, error) {
+	if from != 0 || to != 0 {
+		return nil, ErrNotQueued
+	}
+	return m.NowPlaying(), nil
+}
@@ -1,6 +1,7 @@
 package player
 
 import (
+	"context"
 	"io"
 	"sync"
 	"time"
@@ -10,28 +11,66 @@ import (
 
 	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
-	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
 var ErrNotConnected = errors.New("player not connected")
 var ErrQueueEmpty = errors.New("queue is empty")
+var ErrQueueFull = errors.New("queue is full")
+var ErrNotPlaying = errors.New("nothing is playing")
+var ErrPaused = errors.New("song is paused already")
+var ErrNotPaused = errors.New("song is not paused")
+var ErrInvalidPosition = errors.New("position is outside of the song")
+
+// ErrBlocked the song is in the blocklist of the guild
+var ErrBlocked = errors.New("song is blocked")
+
+// ErrTooLong the song is longer than the limit of the guild, it is returned with the song for a DJ to confirm it
+var ErrTooLong = errors.New("song is too long")
 
 type MediaPlayer interface {
-	Process(requests <-chan *audio.SongRequest) <-chan error
+	Process(ctx context.Context, requests <-chan *audio.SongRequest) <-chan error
 	Stats() pkg.SessionStats
+	// Position in the current song, or where the last one stopped
+	Position() time.Duration
 	IsPlaying() bool
 	Stop()
+	// PlayExcerpt plays length of the song from start over the music and returns when it is over
+	PlayExcerpt(v *discordgo.VoiceConnection, uri string, start, length time.Duration) error
+	// Hold pauses the song between the excerpts until release
+	Hold() (release func())
+	// Pause returns false if nothing is playing or it is paused already
+	Pause() bool
+	// Resume returns false if the song isn't paused
+	Resume() bool
+	// Seek plays the current song from pos, false if nothing is playing
+	Seek(pos time.Duration) bool
 }
 
 type VoiceClient interface {
 	Connection() *discordgo.VoiceConnection
 	Connect(guildID, channelID string) error
+	Reconnect(guildID, channelID string) error
 	IsConnected() bool
 	Disconnect() error
+	// Bitrate of the connected channel in kbps, 0 if it is unknown
+	Bitrate() int
 }
 
-type ErrorHandler func(err error)
+// GainHandler receives the gain measured on the first full play of the song
+type GainHandler func(song *pkg.Song, gain float64)
+
+// Resolver finds the stream of a song queued without it, the song is about to play
+type Resolver func(ctx context.Context, song *pkg.Song) (*pkg.Song, error)
+
+const (
+	maxReconnects = 3
+	// resumeRewind the song resumes a bit earlier than it was cut, the last frames could be lost
+	resumeRewind = 2 * time.Second
+	// resolveTimeout of the stream of a queued song, the player waits for it if the prefetch didn't finish
+	resolveTimeout = 30 * time.Second
+)
 
 type commandType int
 
@@ -44,6 +83,10 @@ const (
 	disconnect
 	shuffle
 	loop
+	shutdown
+	reconnect
+	playNext
+	playAll
 )
 
 func (c commandType) String() string {
@@ -64,6 +107,14 @@ func (c commandType) String() string {
 		return "shuffle"
 	case loop:
 		return "loop"
+	case shutdown:
+		return "shutdown"
+	case reconnect:
+		return "reconnect"
+	case playNext:
+		return "play next"
+	case playAll:
+		return "play all"
 	}
 	return ""
 }
@@ -73,7 +124,11 @@ type command struct {
 	guildID   string
 	channelID string
 	entry     *pkg.Song
+	entries   []*pkg.Song
 	loop      bool
+	state     chan<- *pkg.PlayerState
+	pos       time.Duration
+	attempt   int
 }
 
 // Player all public methods are concurrent and
@@ -82,25 +137,48 @@ type Player struct {
 	voice VoiceClient
 	audio MediaPlayer
 
-	currentLock   sync.Mutex
-	current       *pkg.Song
-	isWaited      bool
-	queue         Queue
-	errs          chan error
-	commands      chan *command
-	errorHandlers chan ErrorHandler
+	currentLock sync.Mutex
+	current     *pkg.Song
+	isWaited    bool
+	queue       *Queue
+	volumeLock  sync.Mutex
+	volume      int
+	trimSilence bool
+	announce    bool
+	filters     audio.Filters
+	errs        chan error
+	commands    chan *command
+	events      *Bus
+	// reconnecting is owned by the goroutine of processCommands, the pending retries are dropped when it is reset
+	reconnecting bool
+
+	subscribeMx  sync.Mutex
+	gainHandlers []GainHandler
+	resolver     Resolver
+
+	prefetchMx sync.Mutex
+	prefetched map[string]*prefetch // song id
+
+	// editMx the snapshot and the edit of the queue are one step
+	editMx  sync.Mutex
+	history history
 
 	logger zap.Logger
 }
 
-func NewPlayer(ctx contexts.Context, voice VoiceClient, audio MediaPlayer, logger zap.Logger) *Player {
+// NewPlayer the queue is kept in memory if the store is nil
+func NewPlayer(ctx context.Context, voice VoiceClient, audio MediaPlayer, queue QueueStore, logger zap.Logger) *Player {
 	p := Player{
 		logger: logger,
 		voice:  voice,
 		audio:  audio,
+		queue:  newQueue(queue, logger),
+		events: NewBus(logger),
+
+		prefetched: make(map[string]*prefetch),
 	}
 	p.commands, p.errs = p.processCommands(ctx)
-	p.errorHandlers = p.processErrors(p.errs)
+	p.publishErrors(ctx, p.errs)
 	return &p
 }
 
@@ -112,6 +190,22 @@ func (p *Player) Play(s *pkg.Song) {
 	}
 }
 
+// PlayAll enqueues the songs in their order, the ones without the stream are resolved when they come next
+func (p *Player) PlayAll(songs []*pkg.Song) {
+	p.commands <- &command{
+		Type:    playAll,
+		entries: songs,
+	}
+}
+
+// PlayNext puts the song at the front of the queue
+func (p *Player) PlayNext(s *pkg.Song) {
+	p.commands <- &command{
+		Type:  playNext,
+		entry: s,
+	}
+}
+
 func (p *Player) Skip() {
 	p.commands <- &command{
 		Type: skip,
@@ -149,6 +243,106 @@ func (p *Player) Disconnect() {
 	}
 }
 
+// Shutdown stops the playback, disconnects from the voice channel and returns what was playing.
+// The player connects again on the next play, the standby bot hands the guilds back this way.
+func (p *Player) Shutdown(ctx context.Context) (*pkg.PlayerState, error) {
+	state := make(chan *pkg.PlayerState, 1)
+	select {
+	case p.commands <- &command{Type: shutdown, state: state}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case s := <-state:
+		return s, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// State snapshot of what is playing, GuildID is empty if not connected
+func (p *Player) State() *pkg.PlayerState {
+	state := &pkg.PlayerState{
+		Current: p.NowPlaying(),
+		Queue:   p.queue.Entries(),
+		Loop:    p.queue.LoopStatus(),
+	}
+	if p.voice.IsConnected() {
+		state.GuildID = p.voice.Connection().GuildID
+		state.ChannelID = p.voice.Connection().ChannelID
+	}
+	return state
+}
+
+// SetVolume in percent applies from the next song
+func (p *Player) SetVolume(percent int) {
+	p.volumeLock.Lock()
+	p.volume = percent
+	p.volumeLock.Unlock()
+}
+
+// SetTrimSilence applies from the next song
+func (p *Player) SetTrimSilence(b bool) {
+	p.volumeLock.Lock()
+	p.trimSilence = b
+	p.volumeLock.Unlock()
+}
+
+func (p *Player) TrimSilence() bool {
+	p.volumeLock.Lock()
+	defer p.volumeLock.Unlock()
+	return p.trimSilence
+}
+
+// SetAnnounce applies from the next song
+func (p *Player) SetAnnounce(b bool) {
+	p.volumeLock.Lock()
+	p.announce = b
+	p.volumeLock.Unlock()
+}
+
+func (p *Player) Announce() bool {
+	p.volumeLock.Lock()
+	defer p.volumeLock.Unlock()
+	return p.announce
+}
+
+// SetFilters applies from the next song
+func (p *Player) SetFilters(f audio.Filters) {
+	p.volumeLock.Lock()
+	p.filters = f
+	p.volumeLock.Unlock()
+}
+
+func (p *Player) Volume() int {
+	p.volumeLock.Lock()
+	defer p.volumeLock.Unlock()
+	return p.volume
+}
+
+func (p *Player) QueueLength() int {
+	return p.queue.Len()
+}
+
+// Queue returns the queued songs without the current one
+func (p *Player) Queue() []*pkg.Song {
+	return p.queue.Entries()
+}
+
+// Remove the song at the position from 0, ErrNotQueued if there is none
+func (p *Player) Remove(pos int) (*pkg.Song, error) {
+	p.editMx.Lock()
+	defer p.editMx.Unlock()
+	songs := p.queue.Entries()
+	song, err := p.queue.Remove(pos)
+	if err != nil {
+		return nil, err
+	}
+	p.history.push(ActionRemove, songs)
+	p.publish(Event{Type: QueueUpdated})
+	return song, nil
+}
+
 func (p *Player) NowPlaying() *pkg.Song {
 	p.currentLock.Lock()
 	defer p.currentLock.Unlock()
@@ -161,34 +355,51 @@ func (p *Player) setNowPlaying(s *pkg.Song) {
 	p.current = s
 }
 
+// SongStatus the position is counted from the frames sent to Discord, the duration falls back to the song metadata
 func (p *Player) SongStatus() pkg.SessionStats {
+	now := p.NowPlaying()
+	if now == nil {
+		return pkg.SessionStats{}
+	}
 	s := p.audio.Stats()
 	if s.Duration == 0 {
-		now := p.NowPlaying()
-		if now == nil {
-			return pkg.SessionStats{}
-		}
 		s.Duration = now.Duration
 	}
 	return s
 }
 
-func (p *Player) SubscribeOnErrors(h ErrorHandler) {
-	p.errorHandlers <- h
+// Subscribe to the events of the player, all of them if no types are passed
+func (p *Player) Subscribe(h EventHandler, types ...EventType) (unsubscribe func()) {
+	return p.events.Subscribe(h, types...)
 }
 
-func (p *Player) processCommands(ctx contexts.Context) (chan *command, chan error) {
+func (p *Player) SubscribeOnGain(h GainHandler) {
+	p.subscribeMx.Lock()
+	p.gainHandlers = append(p.gainHandlers, h)
+	p.subscribeMx.Unlock()
+}
+
+// SetResolver of the songs queued without the stream, they are skipped without one
+func (p *Player) SetResolver(r Resolver) {
+	p.subscribeMx.Lock()
+	p.resolver = r
+	p.subscribeMx.Unlock()
+}
+
+func (p *Player) processCommands(ctx context.Context) (chan *command, chan error) {
 	requests := make(chan *audio.SongRequest)
-	playerErrors := p.audio.Process(requests)
+	playerErrors := p.audio.Process(ctx, requests)
 	commands := make(chan *command)
 	out := make(chan error)
-	go func() {
-		defer func() {
-			close(requests)
-			close(out)
-			close(commands)
-		}()
-
+	started := false
+	supervisor.Go(ctx, p.logger, "player", func(ctx context.Context) {
+		if started {
+			// the command that panicked could leave the player waiting for nothing
+			go func() {
+				p.commands <- &command{Type: next}
+			}()
+		}
+		started = true
 		for {
 			select {
 			case c := <-commands:
@@ -196,7 +407,15 @@ func (p *Player) processCommands(ctx contexts.Context) (chan *command, chan erro
 					out <- err
 				}
 			case err := <-playerErrors:
+				if errors.Is(err, audio.ErrVoiceClosed) {
+					pos := p.audio.Position()
+					go func() {
+						p.commands <- &command{Type: reconnect, pos: pos}
+					}()
+					continue
+				}
 				if err == nil || errors.Is(err, audio.ErrManualStop) || errors.Is(err, io.EOF) {
+					p.publish(Event{Type: TrackFinished, Song: p.NowPlaying()})
 					go func() {
 						p.commands <- &command{Type: next}
 					}()
@@ -207,10 +426,13 @@ func (p *Player) processCommands(ctx contexts.Context) (chan *command, chan erro
 			case <-ctx.Done():
 				p.queue.Clear()
 				p.audio.Stop()
+				close(requests)
+				close(out)
+				close(commands)
 				return
 			}
 		}
-	}()
+	})
 
 	return commands, out
 }
@@ -222,38 +444,102 @@ func (p *Player) processCommand(c *command, out chan *audio.SongRequest) error {
 	}
 	switch c.Type {
 	case play:
-		return p.processPlay(c.entry, out)
+		return p.processPlay(c.entry, false, out)
+	case playNext:
+		return p.processPlay(c.entry, true, out)
+	case playAll:
+		return p.processPlayAll(c.entries, out)
 	case next:
 		return p.processNext(out)
 	case loop:
 		p.queue.SetLoop(c.loop)
 	case skip:
+		skips.Inc()
 		p.audio.Stop()
 	case stop:
 		p.reset()
 	case disconnect:
 		p.reset()
+		p.disconnect()
 		if err := p.voice.Disconnect(); err != nil {
 			return err
 		}
 	case connect:
 		return p.processConnect(c.guildID, c.channelID)
+	case shutdown:
+		return p.processShutdown(c.state)
+	case reconnect:
+		return p.processReconnect(c, out)
 	}
 	return nil
 }
 
-func (p *Player) processPlay(entry *pkg.Song, out chan *audio.SongRequest) error {
+func (p *Player) processPlay(entry *pkg.Song, front bool, out chan *audio.SongRequest) error {
 	if !p.voice.IsConnected() {
 		return ErrNotConnected
 	}
 	p.logger.Debugf("adding to queue %s", entry.Title)
-	p.queue.Add(entry)
+	add := p.queue.Add
+	if front {
+		add = p.queue.AddFront
+	}
+	// the song added during an edit would be lost by its replace
+	p.editMx.Lock()
+	err := add(entry)
+	p.editMx.Unlock()
+	if err != nil {
+		return err
+	}
 	if !p.audio.IsPlaying() {
-		s := p.queue.Next()
-		p.setNowPlaying(s)
-		p.logger.Debugf("pushing song req")
-		out <- requestFromEntry(s, p.voice.Connection())
+		return p.start(out)
+	}
+	p.publish(Event{Type: QueueUpdated, Song: entry})
+	p.prefetch()
+	return nil
+}
+
+func (p *Player) processPlayAll(entries []*pkg.Song, out chan *audio.SongRequest) error {
+	if !p.voice.IsConnected() {
+		return ErrNotConnected
+	}
+	p.logger.Debugf("adding to queue %d songs", len(entries))
+	p.editMx.Lock()
+	err := p.addAll(entries)
+	p.editMx.Unlock()
+	if err != nil {
+		return err
+	}
+	if !p.audio.IsPlaying() {
+		return p.start(out)
 	}
+	p.publish(Event{Type: QueueUpdated})
+	p.prefetch()
+	return nil
+}
+
+// addAll is called under editMx
+func (p *Player) addAll(entries []*pkg.Song) error {
+	for _, entry := range entries {
+		if err := p.queue.Add(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start the next song of the queue
+func (p *Player) start(out chan *audio.SongRequest) error {
+	s := p.next()
+	p.setNowPlaying(s)
+	if s == nil {
+		// the store failed or another process took the songs
+		return ErrQueueEmpty
+	}
+	p.logger.Debugf("pushing song req")
+	tracksPlayed.Inc()
+	out <- p.request(s)
+	p.publish(Event{Type: TrackStarted, Song: s})
+	p.prefetch()
 	return nil
 }
 
@@ -265,14 +551,18 @@ func (p *Player) processNext(out chan *audio.SongRequest) error {
 	if p.audio.IsPlaying() {
 		return nil
 	}
-	if s := p.queue.Next(); s != nil {
+	if s := p.next(); s != nil {
 		p.setNowPlaying(s)
-		out <- requestFromEntry(s, p.voice.Connection())
+		tracksPlayed.Inc()
+		out <- p.request(s)
+		p.publish(Event{Type: TrackStarted, Song: s})
+		p.prefetch()
 		return nil
 	}
 	p.setNowPlaying(nil)
 	if p.isWaited {
 		p.isWaited = false
+		p.disconnect()
 		err := p.voice.Disconnect()
 		if err != nil {
 			return errors.Wrap(err, "player: disconnecting because there is nothing to play next")
@@ -293,34 +583,139 @@ func (p *Player) processConnect(gID, cID string) error {
 	if err := p.voice.Connect(gID, cID); err != nil {
 		return errors.Wrapf(err, "connect on gid:%s cid:%s", gID, cID)
 	}
+	p.publish(Event{Type: Connected, GuildID: gID, ChannelID: cID})
 	return nil
 }
 
+func (p *Player) processShutdown(out chan<- *pkg.PlayerState) error {
+	state := p.State()
+	out <- state
+	p.reset()
+	p.setNowPlaying(nil)
+	if state.GuildID != "" {
+		p.disconnect()
+		return p.voice.Disconnect()
+	}
+	return nil
+}
+
+// processReconnect joins the same channel again and resumes the current song near the position it was cut at
+func (p *Player) processReconnect(c *command, out chan *audio.SongRequest) error {
+	current := p.NowPlaying()
+	if c.attempt == 0 {
+		if current == nil || !p.voice.IsConnected() || p.audio.IsPlaying() {
+			return nil
+		}
+		c.guildID = p.voice.Connection().GuildID
+		c.channelID = p.voice.Connection().ChannelID
+		p.reconnecting = true
+	} else if !p.reconnecting || current == nil {
+		return nil
+	}
+	p.logger.Warnw("voice connection lost, reconnecting",
+		"guild", c.guildID,
+		"attempt", c.attempt+1)
+	if err := p.voice.Reconnect(c.guildID, c.channelID); err != nil {
+		if c.attempt+1 < maxReconnects {
+			retry := *c
+			retry.attempt++
+			p.retryAfter(time.Duration(retry.attempt)*5*time.Second, &retry)
+			return nil
+		}
+		p.reconnecting = false
+		p.reset()
+		p.setNowPlaying(nil)
+		p.publish(Event{Type: Disconnected, GuildID: c.guildID, ChannelID: c.channelID})
+		return errors.Wrapf(err, "voice reconnect after %d attempts", maxReconnects)
+	}
+	p.reconnecting = false
+	pos := c.pos - resumeRewind
+	if pos < 0 {
+		pos = 0
+	}
+	req := p.request(current)
+	req.Start = pos
+	out <- req
+	p.publish(Event{Type: Reconnected, GuildID: c.guildID, ChannelID: c.channelID, Song: current, Pos: pos})
+	return nil
+}
+
+// request the song is measured until it has the gain
+func (p *Player) request(s *pkg.Song) *audio.SongRequest {
+	req := requestFromEntry(s, p.voice.Connection(), p.Volume(), p.TrimSilence())
+	if p.Announce() {
+		req.Announce = announcement(s)
+	}
+	p.volumeLock.Lock()
+	req.Filters = p.filters
+	p.volumeLock.Unlock()
+	// taken for every song, the bot could be moved to another channel
+	req.Bitrate = p.voice.Bitrate()
+	if s.Gain == nil {
+		req.Measured = func(gain float64) {
+			p.subscribeMx.Lock()
+			handlers := p.gainHandlers
+			p.subscribeMx.Unlock()
+			for _, h := range handlers {
+				h := h
+				go supervisor.Safe(p.logger, "player gain handler", func() { h(s, gain) })
+			}
+		}
+	}
+	return req
+}
+
 func (p *Player) reset() {
+	p.reconnecting = false
+	p.prefetchMx.Lock()
+	p.prefetched = make(map[string]*prefetch)
+	p.prefetchMx.Unlock()
+	p.history.reset()
+	cleared := p.queue.Len() != 0
 	p.queue.Clear()
 	p.audio.Stop()
+	if cleared {
+		p.publish(Event{Type: QueueUpdated})
+	}
 }
 
-func (p *Player) processErrors(errs <-chan error) chan ErrorHandler {
-	handlers := make([]ErrorHandler, 0)
-	newHandlers := make(chan ErrorHandler)
-	go func() {
-		defer close(newHandlers)
-		for {
-			select {
-			case err, ok := <-errs:
-				if !ok {
-					return
-				}
-				for _, h := range handlers {
-					go h(err)
-				}
-			case h := <-newHandlers:
-				handlers = append(handlers, h)
+// disconnect is published before the voice connection is closed, the event keeps the channel
+func (p *Player) disconnect() {
+	if p.voice.IsConnected() {
+		p.publish(Event{Type: Disconnected})
+	}
+}
+
+// publish the guild and the channel are the connected ones if the event has none
+func (p *Player) publish(e Event) {
+	if e.GuildID == "" && p.voice.IsConnected() {
+		e.GuildID = p.voice.Connection().GuildID
+		e.ChannelID = p.voice.Connection().ChannelID
+	}
+	e.Queue = p.queue.Len()
+	p.events.Publish(e)
+}
+
+// publishErrors the end of the queue is published as QueueEnded, the end of a song is TrackFinished already
+func (p *Player) publishErrors(ctx context.Context, errs <-chan error) {
+	supervisor.Go(ctx, p.logger, "player errors", func(_ context.Context) {
+		for err := range errs {
+			switch {
+			case errors.Is(err, ErrQueueEmpty):
+				p.publish(Event{Type: QueueEnded})
+			case errors.Is(err, io.EOF) || errors.Is(err, audio.ErrManualStop):
+			default:
+				p.publish(Event{Type: Failed, Err: err, Error: err.Error()})
 			}
 		}
+	})
+}
+
+func (p *Player) retryAfter(d time.Duration, c *command) {
+	go func() {
+		time.Sleep(d)
+		p.commands <- c
 	}()
-	return newHandlers
 }
 
 func (p *Player) tryNextAfterTimeout(d time.Duration) {
@@ -331,3 +726,99 @@ func (p *Player) tryNextAfterTimeout(d time.Duration) {
 		}
 	}()
 }
+
+// prefetch the stream of a queued song, done is closed when the song or the error is set
+type prefetch struct {
+	done chan struct{}
+	song *pkg.Song
+	err  error
+}
+
+// next pops the songs until one has the stream, nil if the queue ends
+func (p *Player) next() *pkg.Song {
+	for {
+		// an edit replaces the whole queue, the song ending meanwhile waits for it to take the next one
+		p.editMx.Lock()
+		s := p.queue.Next()
+		p.editMx.Unlock()
+		if s == nil {
+			return nil
+		}
+		ready, err := p.ready(s)
+		if err == nil {
+			if ready != s {
+				// the looped song isn't resolved again
+				p.queue.SetCurrent(ready)
+			}
+			p.history.played(s)
+			return ready
+		}
+		unresolvedSongs.Inc()
+		p.logger.Warnw("queued song skipped, no stream",
+			"song", s.ID.String(),
+			"err", err)
+		if p.queue.LoopStatus() {
+			return nil
+		}
+	}
+}
+
+// ready returns the song with the stream, the prefetched one if it was resolved in the background
+func (p *Player) ready(s *pkg.Song) (*pkg.Song, error) {
+	p.subscribeMx.Lock()
+	resolve := p.resolver
+	p.subscribeMx.Unlock()
+	if s.StreamURL != "" || resolve == nil {
+		return s, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	key := s.ID.String()
+	p.prefetchMx.Lock()
+	f, ok := p.prefetched[key]
+	delete(p.prefetched, key)
+	p.prefetchMx.Unlock()
+	if !ok {
+		return resolve(ctx, s)
+	}
+	select {
+	case <-f.done:
+		return f.song, f.err
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "wait for the prefetched song")
+	}
+}
+
+// prefetch resolves the first queued song in the background, so it starts without waiting for YouTube
+func (p *Player) prefetch() {
+	p.subscribeMx.Lock()
+	resolve := p.resolver
+	p.subscribeMx.Unlock()
+	if resolve == nil || p.queue.LoopStatus() {
+		return
+	}
+	entries := p.queue.Entries()
+	if len(entries) == 0 || entries[0].StreamURL != "" {
+		return
+	}
+	// the queued song isn't changed, the api could read it meanwhile
+	song := *entries[0]
+	key := song.ID.String()
+	f := &prefetch{done: make(chan struct{})}
+	p.prefetchMx.Lock()
+	if _, ok := p.prefetched[key]; ok {
+		p.prefetchMx.Unlock()
+		return
+	}
+	p.prefetched[key] = f
+	p.prefetchMx.Unlock()
+	go supervisor.Safe(p.logger, "player prefetch", func() {
+		defer close(f.done)
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		defer cancel()
+		f.song, f.err = resolve(ctx, &song)
+		if f.err == nil {
+			prefetchedSongs.Inc()
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package player
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	// PreviewLength of the excerpt played by Preview
+	PreviewLength = 30 * time.Second
+	// previewConnectTimeout the preview waits for the player to join the channel
+	previewConnectTimeout = 10 * time.Second
+)
+
+// ErrOtherGuild the player is connected in another guild and can't be taken over by a preview or an excerpt
+var ErrOtherGuild = errors.New("player is connected in another guild")
+
+// Preview plays PreviewLength from the middle of the song over the music without queueing it, started is called
+// with the song before. The player joins the channel if it isn't connected, the preview returns when the excerpt is over.
+func (s *Service) Preview(ctx context.Context, query, guildID, channelID string, started func(song *pkg.Song)) (*pkg.Song, error) {
+	if err := s.canExcerpt(guildID, channelID); err != nil {
+		return nil, err
+	}
+	song, err := s.Find(ctx, query, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Excerpt(ctx, song, guildID, channelID, PreviewLength, started); err != nil {
+		return nil, err
+	}
+	return song, nil
+}
+
+// Excerpt plays length from the middle of the song over the music like Preview, the song may come without the stream
+func (s *Service) Excerpt(ctx context.Context, song *pkg.Song, guildID, channelID string, length time.Duration, started func(song *pkg.Song)) error {
+	if err := s.canExcerpt(guildID, channelID); err != nil {
+		return err
+	}
+	if song.StreamURL == "" {
+		var err error
+		if song, err = s.youtube.EnsureStreamInfo(ctx, song); err != nil {
+			return errors.Wrap(err, "stream of the excerpt")
+		}
+	}
+	if s.currentGuild() == "" {
+		if err := s.join(ctx, guildID, channelID); err != nil {
+			return err
+		}
+	}
+	started(song)
+	start := excerptStart(time.Duration(song.Duration*float64(time.Second)), length)
+	if err := s.Player.audio.PlayExcerpt(s.Player.voice.Connection(), song.StreamURL, start, length); err != nil {
+		return errors.Wrapf(err, "excerpt of %s", song.ID.ID)
+	}
+	return nil
+}
+
+// Hold the song stays paused between the excerpts until release, e.g. during a game
+func (s *Service) Hold() (release func()) {
+	return s.Player.audio.Hold()
+}
+
+// canExcerpt the player plays in the guild or can join the channel
+func (s *Service) canExcerpt(guildID, channelID string) error {
+	if s.inMaintenance() {
+		return maintenance.ErrMaintenance
+	}
+	current := s.currentGuild()
+	if current != "" && current != guildID {
+		return ErrOtherGuild
+	}
+	if current == "" && channelID == "" {
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// join connects the player and waits until it is in the channel
+func (s *Service) join(ctx context.Context, guildID, channelID string) error {
+	connected := make(chan struct{}, 1)
+	unsubscribe := s.Subscribe(func(e Event) {
+		if e.GuildID == guildID {
+			select {
+			case connected <- struct{}{}:
+			default:
+			}
+		}
+	}, Connected)
+	defer unsubscribe()
+	s.connect(guildID, channelID)
+	ctx, cancel := context.WithTimeout(ctx, previewConnectTimeout)
+	defer cancel()
+	select {
+	case <-connected:
+		return nil
+	case <-ctx.Done():
+		return ErrNotConnected
+	}
+}
+
+// excerptStart the excerpt is centered in the song, a short song is played from the beginning
+func excerptStart(duration, length time.Duration) time.Duration {
+	if duration <= length {
+		return 0
+	}
+	return (duration - length) / 2
+}
@@ -1,69 +1,326 @@
 package player
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
 
 	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
+// queueTimeout of an operation of the store, the player waits for it
+const queueTimeout = 2 * time.Second
+
+// ErrNotQueued there is no song at the position
+var ErrNotQueued = errors.New("no song at the position in the queue")
+
+// QueueStore keeps the queued songs, the current one and the loop.
+// The memory one is the default, the Redis one is shared with the other processes and outlives the bot.
+type QueueStore interface {
+	Push(ctx context.Context, song *pkg.Song) error
+	PushFront(ctx context.Context, song *pkg.Song) error
+	// Next the current song again if it loops, otherwise the first song is popped and becomes the current one.
+	// It is one step, another process sees the queue before or after it. Nil if the queue is empty.
+	Next(ctx context.Context) (*pkg.Song, error)
+	// Replace the queued songs in one step keeping the loop and the current song,
+	// the queue stays as it was if it fails
+	Replace(ctx context.Context, songs []*pkg.Song) error
+	// Remove the song at the position from 0, nil if there is none
+	Remove(ctx context.Context, pos int) (*pkg.Song, error)
+	Songs(ctx context.Context) ([]*pkg.Song, error)
+	Len(ctx context.Context) (int, error)
+	// Clear the queued songs, the loop is turned off
+	Clear(ctx context.Context) error
+	Current(ctx context.Context) (*pkg.Song, error)
+	SetCurrent(ctx context.Context, song *pkg.Song) error
+	Loop(ctx context.Context) (bool, error)
+	SetLoop(ctx context.Context, b bool) error
+}
+
+// Queue of the player over the store, the errors of the store are logged and the queue looks empty then
 type Queue struct {
-	entries []*pkg.Song
-	current *pkg.Song
+	store  QueueStore
+	logger zap.Logger
+}
 
-	loopLock sync.Mutex
-	loop     bool
+func newQueue(store QueueStore, logger zap.Logger) *Queue {
+	if store == nil {
+		store = NewMemoryQueue()
+	}
+	return &Queue{store: store, logger: logger}
 }
 
 func (q *Queue) Next() *pkg.Song {
-	if q.LoopStatus() {
-		return q.current
-	}
-	if len(q.entries) == 0 {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	song, err := q.store.Next(ctx)
+	if q.check(err, "next song") {
 		return nil
 	}
-	q.current = q.entries[0]
-	q.entries = q.entries[1:]
-	return q.current
+	q.updateLength(ctx)
+	return song
+}
+
+// SetCurrent replaces the current song, the loop repeats it
+func (q *Queue) SetCurrent(s *pkg.Song) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	q.check(q.store.SetCurrent(ctx, s), "set current song")
+}
+
+func (q *Queue) Add(e *pkg.Song) error {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	if err := q.store.Push(ctx, e); err != nil {
+		queueStoreErrors.Inc()
+		return errors.Wrap(err, "push song")
+	}
+	q.updateLength(ctx)
+	return nil
 }
 
-func (q *Queue) Add(e *pkg.Song) {
-	q.entries = append(q.entries, e)
+// AddFront the song plays next
+func (q *Queue) AddFront(e *pkg.Song) error {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	if err := q.store.PushFront(ctx, e); err != nil {
+		queueStoreErrors.Inc()
+		return errors.Wrap(err, "push song to the front")
+	}
+	q.updateLength(ctx)
+	return nil
+}
+
+// Remove the song at the position from 0
+func (q *Queue) Remove(pos int) (*pkg.Song, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	song, err := q.store.Remove(ctx, pos)
+	if err != nil {
+		queueStoreErrors.Inc()
+		return nil, errors.Wrap(err, "remove song")
+	}
+	if song == nil {
+		return nil, ErrNotQueued
+	}
+	q.updateLength(ctx)
+	return song, nil
 }
 
 func (q *Queue) Clear() {
-	q.entries = nil
-	q.SetLoop(false)
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	if !q.check(q.store.Clear(ctx), "clear queue") {
+		queueLength.Set(0)
+	}
+}
+
+// Replace the queued songs keeping the loop and the current song
+func (q *Queue) Replace(songs []*pkg.Song) error {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	if err := q.store.Replace(ctx, songs); err != nil {
+		queueStoreErrors.Inc()
+		return errors.Wrap(err, "replace queue")
+	}
+	q.updateLength(ctx)
+	return nil
 }
 
 func (q *Queue) IsEmpty() bool {
-	return len(q.entries) == 0
+	return q.Len() == 0
+}
+
+func (q *Queue) Len() int {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	n, err := q.store.Len(ctx)
+	q.check(err, "queue length")
+	return n
 }
 
 func (q *Queue) SetLoop(b bool) {
-	q.loopLock.Lock()
-	defer q.loopLock.Unlock()
-	q.loop = b
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	q.check(q.store.SetLoop(ctx, b), "set loop")
 }
 
 func (q *Queue) LoopStatus() bool {
-	q.loopLock.Lock()
-	defer q.loopLock.Unlock()
-	return q.loop
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	b, err := q.store.Loop(ctx)
+	q.check(err, "loop status")
+	return b
+}
+
+// Entries returns a copy of the queued songs
+func (q *Queue) Entries() []*pkg.Song {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+	songs, err := q.store.Songs(ctx)
+	q.check(err, "queued songs")
+	return songs
+}
+
+func (q *Queue) updateLength(ctx context.Context) {
+	if n, err := q.store.Len(ctx); !q.check(err, "queue length") {
+		queueLength.Set(float64(n))
+	}
+}
+
+// check logs the error of the store, true if there is one
+func (q *Queue) check(err error, action string) bool {
+	if err == nil {
+		return false
+	}
+	queueStoreErrors.Inc()
+	q.logger.Error(errors.Wrap(err, action))
+	return true
+}
+
+// MemoryQueue is the queue store of a single process
+type MemoryQueue struct {
+	mx      sync.Mutex
+	entries []*pkg.Song
+	current *pkg.Song
+	loop    bool
 }
 
-func (q *Queue) Front() *pkg.Song {
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{}
+}
+
+func (q *MemoryQueue) Push(_ context.Context, song *pkg.Song) error {
+	q.mx.Lock()
+	q.entries = append(q.entries, song)
+	q.mx.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) PushFront(_ context.Context, song *pkg.Song) error {
+	q.mx.Lock()
+	q.entries = append([]*pkg.Song{song}, q.entries...)
+	q.mx.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) Next(_ context.Context) (*pkg.Song, error) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if q.loop {
+		return q.current, nil
+	}
 	if len(q.entries) == 0 {
-		return nil
+		return nil, nil
 	}
-	return q.entries[0]
+	q.current = q.entries[0]
+	q.entries = q.entries[1:]
+	return q.current, nil
 }
 
-func requestFromEntry(e *pkg.Song, connection *discordgo.VoiceConnection) *audio.SongRequest {
+func (q *MemoryQueue) Replace(_ context.Context, songs []*pkg.Song) error {
+	q.mx.Lock()
+	q.entries = append([]*pkg.Song(nil), songs...)
+	q.mx.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) Remove(_ context.Context, pos int) (*pkg.Song, error) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if pos < 0 || pos >= len(q.entries) {
+		return nil, nil
+	}
+	song := q.entries[pos]
+	q.entries = append(q.entries[:pos:pos], q.entries[pos+1:]...)
+	return song, nil
+}
+
+func (q *MemoryQueue) Songs(_ context.Context) ([]*pkg.Song, error) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	res := make([]*pkg.Song, len(q.entries))
+	copy(res, q.entries)
+	return res, nil
+}
+
+func (q *MemoryQueue) Len(_ context.Context) (int, error) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return len(q.entries), nil
+}
+
+func (q *MemoryQueue) Clear(_ context.Context) error {
+	q.mx.Lock()
+	q.entries = nil
+	q.loop = false
+	q.mx.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) Current(_ context.Context) (*pkg.Song, error) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.current, nil
+}
+
+func (q *MemoryQueue) SetCurrent(_ context.Context, song *pkg.Song) error {
+	q.mx.Lock()
+	q.current = song
+	q.mx.Unlock()
+	return nil
+}
+
+func (q *MemoryQueue) Loop(_ context.Context) (bool, error) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.loop, nil
+}
+
+func (q *MemoryQueue) SetLoop(_ context.Context, b bool) error {
+	q.mx.Lock()
+	q.loop = b
+	q.mx.Unlock()
+	return nil
+}
+
+func requestFromEntry(e *pkg.Song, connection *discordgo.VoiceConnection, volume int, trim bool) *audio.SongRequest {
 	return &audio.SongRequest{
-		Voice: connection,
-		URI:   e.StreamURL,
+		Voice:       connection,
+		URI:         e.StreamURL,
+		Key:         e.ID.String(),
+		Volume:      songVolume(e, volume),
+		TrimSilence: trim,
+		Gain:        gain(e),
+	}
+}
+
+// songVolume the volume of the song is relative to the guild one
+func songVolume(e *pkg.Song, volume int) int {
+	if e.Volume == 0 {
+		return volume
+	}
+	if volume == 0 {
+		volume = 100
+	}
+	return volume * e.Volume / 100
+}
+
+func announcement(e *pkg.Song) string {
+	if e.ArtistName == "" {
+		return "Now playing " + e.Title
+	}
+	return "Now playing " + e.Title + " by " + e.ArtistName
+}
+
+func gain(e *pkg.Song) float64 {
+	if e.Gain == nil {
+		return 0
 	}
+	return *e.Gain
 }
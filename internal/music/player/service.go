@@ -1,65 +1,295 @@
 package player
 
 import (
-	"io"
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
 
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
 	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/search/youtube"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/suggest"
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
+const (
+	saveGainTimeout = 10 * time.Second
+	// maxRadioPicks the radio gives up if the random songs are blocked or not downloaded so many times in a row
+	maxRadioPicks = 10
+)
+
 type Firestore interface {
-	UpsertSongIncPlaybacks(ctx contexts.Context, new *pkg.Song) (int, error)
-	IncrementUserRequests(ctx contexts.Context, song *pkg.Song, userID string)
-	GetRandomSongs(ctx contexts.Context, n int) ([]*pkg.Song, error)
+	UpsertSongIncPlaybacks(ctx context.Context, new *pkg.Song) (int, error)
+	IncrementUserRequests(ctx context.Context, song *pkg.Song, userID string)
+	AddPlay(ctx context.Context, play *pkg.Play)
+	GetRandomSongs(ctx context.Context, n int) ([]*pkg.Song, error)
+	SetSongGain(ctx context.Context, id pkg.SongID, gain float64) error
+	SetSongVolume(ctx context.Context, id pkg.SongID, percent int) error
+	SetSongExplicit(ctx context.Context, id pkg.SongID, explicit bool) error
+	SetSongAltURL(ctx context.Context, id pkg.SongID, url string) error
+	SavePlayerState(ctx context.Context, state *pkg.PlayerState) error
+	PopPlayerState(ctx context.Context) (*pkg.PlayerState, error)
+}
+
+type GuildSettings interface {
+	Get(guildID string) guild.Settings
+	Enabled(guildID string, f guild.Flag) bool
+	Update(ctx context.Context, guildID string, update func(*guild.Settings)) (guild.Settings, error)
 }
 
 type YouTube interface {
-	FindSong(ctx contexts.Context, query string) (*pkg.Song, error)
-	EnsureStreamInfo(ctx contexts.Context, song *pkg.Song) (*pkg.Song, error)
+	FindSong(ctx context.Context, query string, safe bool) (*pkg.Song, error)
+	Rate(ctx context.Context, song *pkg.Song) error
+	FindAlternative(ctx context.Context, song *pkg.Song, safe bool) (*pkg.Song, error)
+	EnsureStreamInfo(ctx context.Context, song *pkg.Song) (*pkg.Song, error)
+	CancelDownload(id pkg.SongID) bool
+	FindPlaylist(ctx context.Context, link string, progress func(loaded, total int)) (string, []*pkg.Song, error)
+}
+
+// Suggester of what the member could mean by a query without a good result
+type Suggester interface {
+	Record(query string)
+	Suggest(query string) (suggest.Suggestion, bool)
+}
+
+// Maintenance of the bot, the new requests are refused while it is enabled
+type Maintenance interface {
+	Enabled() bool
+}
+
+// PoorMatchError the song found on YouTube has nothing of the query, the suggestion is likely what the member meant
+type PoorMatchError struct {
+	Song       *pkg.Song
+	Suggestion suggest.Suggestion
+}
+
+func (e *PoorMatchError) Error() string {
+	return "poor match, did you mean " + e.Suggestion.Text
 }
 
 type Service struct {
 	*Player
-	storage Firestore
-	youtube YouTube
+	storage  Firestore
+	youtube  YouTube
+	settings GuildSettings
+	suggest  Suggester
+	// maintenance is nil if the mode is never enabled
+	maintenance Maintenance
 
 	radioMutex sync.Mutex
 	isRadio    bool
+	// stateSaved by SaveState, Shutdown drops it if the queue ended since
+	stateSaved int32 // atomic
 	logger     zap.Logger
 }
 
-func NewMusicService(ctx contexts.Context, storage Firestore, youtube YouTube, voice VoiceClient, audio MediaPlayer, logger zap.Logger) *Service {
+// NewMusicService the queue is kept in memory if the store is nil
+func NewMusicService(ctx context.Context, storage Firestore, youtube YouTube, settings GuildSettings, voice VoiceClient, audio MediaPlayer, queue QueueStore, logger zap.Logger) *Service {
 	s := &Service{
-		Player:  NewPlayer(ctx, voice, audio, logger),
-		storage: storage,
-		youtube: youtube,
-		logger:  logger,
+		Player:   NewPlayer(ctx, voice, audio, queue, logger),
+		storage:  storage,
+		youtube:  youtube,
+		settings: settings,
+		logger:   logger,
 	}
-	s.Player.SubscribeOnErrors(s.handleError)
+	s.Player.Subscribe(s.handleEvent, QueueEnded, Failed)
+	s.Player.SubscribeOnGain(s.saveGain)
+	s.Player.SetResolver(s.resolve)
 	return s
 }
 
-func (s *Service) Play(ctx contexts.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error) {
-	if !s.Player.voice.IsConnected() && (channelID == "" || guildID == "") {
-		return nil, 0, ErrNotConnected
+func (s *Service) Play(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error) {
+	return s.enqueue(ctx, query, userID, guildID, channelID, false)
+}
+
+// PlayNext the song goes to the front of the queue
+func (s *Service) PlayNext(ctx context.Context, query, userID, guildID, channelID string) (*pkg.Song, int, error) {
+	return s.enqueue(ctx, query, userID, guildID, channelID, true)
+}
+
+// PlayConfirmed the song returned with ErrTooLong, after a DJ confirmed it
+func (s *Service) PlayConfirmed(ctx context.Context, song *pkg.Song, userID, guildID, channelID string, next bool) (int, error) {
+	guildID, err := s.checkQueue(guildID, channelID)
+	if err != nil {
+		return 0, err
+	}
+	return s.add(ctx, song, userID, guildID, channelID, next)
+}
+
+func (s *Service) enqueue(ctx context.Context, query, userID, guildID, channelID string, next bool) (*pkg.Song, int, error) {
+	guildID, err := s.checkQueue(guildID, channelID)
+	if err != nil {
+		return nil, 0, err
 	}
 
+	song, err := s.Find(ctx, query, guildID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.suggest != nil && suggest.PoorMatch(query, song) {
+		if sug, ok := s.suggest.Suggest(query); ok && sug.URL != song.URL {
+			return song, 0, &PoorMatchError{Song: song, Suggestion: sug}
+		}
+	}
+	if max := s.settings.Get(guildID).Limits.MaxDuration; max > 0 && song.Duration > float64(max*60) {
+		return song, 0, ErrTooLong
+	}
+	playbacks, err := s.add(ctx, song, userID, guildID, channelID, next)
+	if err == nil && s.suggest != nil {
+		s.suggest.Record(query)
+	}
+	return song, playbacks, err
+}
+
+// SetSuggester the poor matches of the queries aren't queued when it has a suggestion, the queries are recorded
+func (s *Service) SetSuggester(sg Suggester) {
+	s.suggest = sg
+}
+
+// SetMaintenance the queue and the radio take no new songs while the mode is enabled, the queued ones play
+func (s *Service) SetMaintenance(m Maintenance) {
+	s.maintenance = m
+}
+
+func (s *Service) inMaintenance() bool {
+	return s.maintenance != nil && s.maintenance.Enabled()
+}
+
+// Suggest what the query could mean, nothing without a suggester
+func (s *Service) Suggest(query string) (suggest.Suggestion, bool) {
+	if s.suggest == nil {
+		return suggest.Suggestion{}, false
+	}
+	return s.suggest.Suggest(query)
+}
+
+// Find the song without queueing it, the safe search and the blocklist of the guild apply
+func (s *Service) Find(ctx context.Context, query, guildID string) (*pkg.Song, error) {
+	settings := s.settings.Get(guildID)
 	s.logger.Debug("Finding song")
-	song, err := s.youtube.FindSong(ctx, query)
+	song, err := s.youtube.FindSong(ctx, query, settings.SafeSearch)
+	if err != nil {
+		return nil, errors.Wrap(err, "find and load song from youtube")
+	}
+	if settings.Blocks(song.ID.ID, song.Title) {
+		return nil, ErrBlocked
+	}
+	return song, nil
+}
+
+// checkQueue returns the guild of the player if guildID is empty
+func (s *Service) checkQueue(guildID, channelID string) (string, error) {
+	if s.inMaintenance() {
+		return "", maintenance.ErrMaintenance
+	}
+	if !s.Player.voice.IsConnected() && (channelID == "" || guildID == "") {
+		return "", ErrNotConnected
+	}
+	if guildID == "" {
+		guildID = s.currentGuild()
+	}
+	if max := s.settings.Get(guildID).Limits.MaxQueue; max > 0 && s.Player.QueueLength() >= max {
+		return "", ErrQueueFull
+	}
+	return guildID, nil
+}
+
+// PlayAll queues the songs of a playlist in their order, the ones blocked or longer than the limit of the guild are skipped
+// and the rest is cut to the free places in the queue. A song is counted and its stream is found when it comes next,
+// so a long playlist doesn't make a burst of YouTube calls.
+func (s *Service) PlayAll(ctx context.Context, songs []*pkg.Song, userID, guildID, channelID string) (int, error) {
+	guildID, err := s.checkQueue(guildID, channelID)
 	if err != nil {
-		return nil, 0, errors.Wrap(err, "find and load song from youtube")
+		return 0, err
+	}
+	settings := s.settings.Get(guildID)
+	room := len(songs)
+	if max := settings.Limits.MaxQueue; max > 0 && max-s.Player.QueueLength() < room {
+		room = max - s.Player.QueueLength()
+	}
+	var requester *discordgo.User
+	if userID != "" {
+		requester = &discordgo.User{ID: userID}
+	}
+	queued := make([]*pkg.Song, 0, room)
+	for _, song := range songs {
+		if len(queued) == room {
+			break
+		}
+		if settings.Blocks(song.ID.ID, song.Title) {
+			continue
+		}
+		if max := settings.Limits.MaxDuration; max > 0 && song.Duration > float64(max*60) {
+			continue
+		}
+		song.Requester = requester
+		queued = append(queued, song)
 	}
+	if len(queued) == 0 {
+		return 0, nil
+	}
+	if channelID != "" {
+		s.connect(guildID, channelID)
+	}
+	go s.Player.PlayAll(queued)
+	return len(queued), nil
+}
 
-	if channelID != "" || guildID != "" {
-		s.Connect(guildID, channelID)
+// FindPlaylist title and songs of the YouTube playlist link to queue with PlayAll, progress may be nil
+func (s *Service) FindPlaylist(ctx context.Context, link string, progress func(loaded, total int)) (string, []*pkg.Song, error) {
+	return s.youtube.FindPlaylist(ctx, link, progress)
+}
+
+// resolve the song queued by PlayAll, it passes the safe search and is counted as requested when it comes next
+func (s *Service) resolve(ctx context.Context, song *pkg.Song) (*pkg.Song, error) {
+	ctx = contexts.WithLogger(ctx, s.logger)
+	if !s.allowed(ctx, song) {
+		return nil, ErrBlocked
+	}
+	userID := ""
+	if song.Requester != nil {
+		userID = song.Requester.ID
 	}
+	if _, err := s.count(ctx, song, userID, s.currentGuild()); err != nil {
+		s.logger.Error(err)
+	}
+	ensured, err := s.youtube.EnsureStreamInfo(ctx, song)
+	if errors.Is(err, youtube.ErrLibraryOnly) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Warnw("queued song can't be extracted, searching another upload", "song", song.ID, "err", err)
+		return s.alternative(ctx, song)
+	}
+	return ensured, nil
+}
 
+func (s *Service) add(ctx context.Context, song *pkg.Song, userID, guildID, channelID string, next bool) (int, error) {
+	if userID != "" {
+		song.Requester = &discordgo.User{ID: userID}
+	}
+	if channelID != "" {
+		s.connect(guildID, channelID)
+	}
+	playbacks, err := s.count(ctx, song, userID, guildID)
+	if next {
+		go s.Player.PlayNext(song)
+	} else {
+		go s.Player.Play(song)
+	}
+	return playbacks, err
+}
+
+// count the request of the song: its playbacks, the songs of the user and the history of the guild
+func (s *Service) count(ctx context.Context, song *pkg.Song, userID, guildID string) (int, error) {
 	song.LastPlay = pkg.PlayDate{Time: time.Now()}
 	playbacks, err := s.storage.UpsertSongIncPlaybacks(ctx, song)
 	if err != nil {
@@ -69,16 +299,69 @@ func (s *Service) Play(ctx contexts.Context, query, userID, guildID, channelID s
 	if userID != "" {
 		s.storage.IncrementUserRequests(ctx, song, userID)
 	}
+	if guildID != "" {
+		s.storage.AddPlay(ctx, &pkg.Play{
+			GuildID:  guildID,
+			UserID:   userID,
+			SongID:   song.ID.String(),
+			Title:    song.Title,
+			Duration: song.Duration,
+			Time:     song.LastPlay.Time,
+		})
+	}
+	return playbacks, err
+}
 
-	go s.Player.Play(song)
-	return song, playbacks, err
+// SetSongVolume of the current song, applies from its next play
+func (s *Service) SetSongVolume(ctx context.Context, percent int) (*pkg.Song, error) {
+	song := s.NowPlaying()
+	if song == nil {
+		return nil, ErrNotPlaying
+	}
+	if err := s.storage.SetSongVolume(ctx, song.ID, percent); err != nil {
+		return nil, errors.Wrap(err, "set song volume")
+	}
+	return song, nil
 }
 
-func (s *Service) Random(ctx contexts.Context, n int) ([]*pkg.Song, error) {
+// Filters of the guild, the current one if guildID is empty
+func (s *Service) Filters(guildID string) (audio.Filters, error) {
+	if guildID == "" {
+		guildID = s.currentGuild()
+	}
+	if guildID == "" {
+		return nil, ErrNotConnected
+	}
+	return audio.ParseFilters(s.settings.Get(guildID).Filters)
+}
+
+// SetFilters of the guild, the current one if guildID is empty, they apply from the next song
+func (s *Service) SetFilters(ctx context.Context, guildID string, filters audio.Filters) error {
+	if guildID == "" {
+		guildID = s.currentGuild()
+	}
+	if guildID == "" {
+		return ErrNotConnected
+	}
+	if _, err := s.settings.Update(ctx, guildID, func(g *guild.Settings) {
+		g.Filters = filters.Strings()
+	}); err != nil {
+		return errors.Wrap(err, "save filters")
+	}
+	if s.currentGuild() == guildID {
+		s.Player.SetFilters(filters)
+	}
+	return nil
+}
+
+func (s *Service) Random(ctx context.Context, n int) ([]*pkg.Song, error) {
 	return s.storage.GetRandomSongs(ctx, n)
 }
 
-func (s *Service) SetRadio(ctx contexts.Context, b bool, guildID, channelID string) error {
+func (s *Service) SetRadio(ctx context.Context, b bool, guildID, channelID string) error {
+	if b && s.inMaintenance() {
+		return maintenance.ErrMaintenance
+	}
 	s.setRadio(b)
 	if !b {
 		return nil
@@ -87,7 +370,7 @@ func (s *Service) SetRadio(ctx contexts.Context, b bool, guildID, channelID stri
 		if guildID == "" || channelID == "" {
 			return ErrNotConnected
 		}
-		s.Player.Connect(guildID, channelID)
+		s.connect(guildID, channelID)
 	}
 	if s.NowPlaying() == nil {
 		return s.playRandomSong(ctx)
@@ -101,21 +384,79 @@ func (s *Service) setRadio(b bool) {
 	s.radioMutex.Unlock()
 }
 
-func (s *Service) playRandomSong(ctx contexts.Context) error {
-	songs, err := s.storage.GetRandomSongs(ctx, 1)
+func (s *Service) playRandomSong(ctx context.Context) error {
+	for i := 0; i < maxRadioPicks; i++ {
+		songs, err := s.storage.GetRandomSongs(ctx, 1)
+		if err != nil {
+			return errors.Wrap(err, "get 1 random song from bd")
+		}
+		song := songs[0]
+		if !s.allowed(ctx, song) {
+			continue
+		}
+		if song.StreamURL == "" {
+			ensured, err := s.youtube.EnsureStreamInfo(ctx, song)
+			// in the library mode only the downloaded songs are picked
+			if errors.Is(err, youtube.ErrLibraryOnly) {
+				continue
+			}
+			if err != nil {
+				s.logger.Warnw("radio song can't be extracted, searching another upload", "song", song.ID, "err", err)
+				ensured, err = s.alternative(ctx, song)
+				if err != nil {
+					s.logger.Error(errors.Wrap(err, "ensure stream info for radio"))
+					return s.playRandomSong(ctx)
+				}
+			}
+			song = ensured
+		}
+		s.Player.Play(song)
+		return nil
+	}
+	return errors.Errorf("%d random songs in a row are blocked or not downloaded", maxRadioPicks)
+}
+
+// alternative the library song is played from another upload, which is saved for the next time
+func (s *Service) alternative(ctx context.Context, song *pkg.Song) (*pkg.Song, error) {
+	settings := s.settings.Get(s.currentGuild())
+	alt, err := s.youtube.FindAlternative(ctx, song, settings.SafeSearch)
 	if err != nil {
-		return errors.Wrap(err, "get 1 random song from bd")
+		return nil, err
 	}
-	song := songs[0]
-	if song.StreamURL == "" {
-		song, err = s.youtube.EnsureStreamInfo(ctx, song)
-		if err != nil {
-			s.logger.Error(errors.Wrap(err, "ensure stream info for radio"))
-			return s.playRandomSong(ctx)
+	if settings.Blocks(alt.ID.ID, alt.Title) {
+		return nil, ErrBlocked
+	}
+	song.AltURL = alt.URL
+	song.StreamURL = alt.StreamURL
+	song.Duration = alt.Duration
+	if err := s.storage.SetSongAltURL(ctx, song.ID, alt.URL); err != nil {
+		s.logger.Error(errors.Wrap(err, "save alternative upload"))
+	}
+	return song, nil
+}
+
+// allowed the random song passes the blocklist and the safe search of the current guild, the rating is saved
+func (s *Service) allowed(ctx context.Context, song *pkg.Song) bool {
+	settings := s.settings.Get(s.currentGuild())
+	if settings.Blocks(song.ID.ID, song.Title) {
+		return false
+	}
+	if !settings.SafeSearch {
+		return true
+	}
+	if song.Explicit == nil {
+		// the cached song isn't changed, another guild could play it meanwhile
+		rated := *song
+		if err := s.youtube.Rate(ctx, &rated); err != nil {
+			s.logger.Warnw("rate radio song", "song", song.ID, "err", err)
+			return false
 		}
+		if err := s.storage.SetSongExplicit(ctx, song.ID, *rated.Explicit); err != nil {
+			s.logger.Error(errors.Wrap(err, "save song rating"))
+		}
+		return !*rated.Explicit
 	}
-	s.Player.Play(song)
-	return nil
+	return !*song.Explicit
 }
 
 func (s *Service) RadioStatus() bool {
@@ -125,10 +466,39 @@ func (s *Service) RadioStatus() bool {
 	return b
 }
 
-func (s *Service) handleError(err error) {
-	if errors.Is(err, ErrQueueEmpty) {
+// connect applies the settings of the guild, the player serves one guild at a time
+func (s *Service) connect(guildID, channelID string) {
+	s.Player.SetVolume(s.settings.Get(guildID).Volume)
+	s.Player.SetTrimSilence(s.settings.Enabled(guildID, guild.TrimSilence))
+	s.Player.SetAnnounce(s.settings.Enabled(guildID, guild.Announce))
+	filters, err := audio.ParseFilters(s.settings.Get(guildID).Filters)
+	if err != nil {
+		s.logger.Warnw("stored filters", "guild", guildID, "err", err)
+	}
+	s.Player.SetFilters(filters)
+	s.Player.Connect(guildID, channelID)
+}
+
+func (s *Service) currentGuild() string {
+	if !s.Player.voice.IsConnected() {
+		return ""
+	}
+	return s.Player.voice.Connection().GuildID
+}
+
+// handleEvent the radio continues the ended queue and stops on the errors
+func (s *Service) handleEvent(e Event) {
+	if e.Type == QueueEnded {
+		// the radio stays on in the saved state and continues after the deploy
+		if s.inMaintenance() {
+			return
+		}
+		guildID := s.currentGuild()
+		if !s.RadioStatus() && s.settings.Enabled(guildID, guild.Autoplay) && s.settings.Get(guildID).Radio.AutoStart {
+			s.setRadio(true)
+		}
 		if s.RadioStatus() {
-			err := s.playRandomSong(contexts.Context{Context: contexts.Background()})
+			err := s.playRandomSong(contexts.WithLogger(context.Background(), s.logger))
 			if err != nil {
 				s.logger.Error(errors.Wrap(err, "radio failed"))
 				s.setRadio(false)
@@ -136,19 +506,36 @@ func (s *Service) handleError(err error) {
 		}
 		return
 	}
-	if !errors.Is(err, audio.ErrManualStop) && !errors.Is(err, io.EOF) {
-		s.setRadio(false)
-		s.logger.Error("error from player", err)
+	s.setRadio(false)
+	s.logger.Errorw("error from player",
+		"guild", e.GuildID,
+		"err", e.Err)
+}
+
+// saveGain the next plays of the song are not measured
+func (s *Service) saveGain(song *pkg.Song, gain float64) {
+	ctx, cancel := context.WithTimeout(contexts.WithLogger(context.Background(), s.logger), saveGainTimeout)
+	defer cancel()
+	if err := s.storage.SetSongGain(ctx, song.ID, gain); err != nil {
+		s.logger.Error(errors.Wrap(err, "save song gain"))
+		return
 	}
+	s.logger.Debugw("song gain measured", "song", song.ID.String(), "gain", gain)
 }
 
-func (s *Service) SubscribeOnErrors(h ErrorHandler) {
-	s.Player.SubscribeOnErrors(func(err error) {
-		if errors.Is(err, io.EOF) || errors.Is(err, audio.ErrManualStop) || errors.Is(err, ErrQueueEmpty) {
-			return
-		}
-		h(err)
-	})
+// Skip cancels the download of the song, it won't be played to the end
+func (s *Service) Skip() {
+	if song := s.NowPlaying(); song != nil {
+		s.youtube.CancelDownload(song.ID)
+	}
+	s.Player.Skip()
+}
+
+func (s *Service) SkipTo(pos int) (*pkg.Song, error) {
+	if song := s.NowPlaying(); song != nil {
+		s.youtube.CancelDownload(song.ID)
+	}
+	return s.Player.SkipTo(pos)
 }
 
 func (s *Service) Stop() {
@@ -156,11 +543,162 @@ func (s *Service) Stop() {
 	s.Player.Stop()
 }
 
+// Pause the current song, it keeps its place and the queue waits
+func (s *Service) Pause(_ context.Context) error {
+	song := s.NowPlaying()
+	if song == nil || !s.Player.audio.IsPlaying() {
+		return ErrNotPlaying
+	}
+	if !s.Player.audio.Pause() {
+		return ErrPaused
+	}
+	s.Player.publish(Event{Type: Paused, Song: song, Pos: s.Player.audio.Position()})
+	return nil
+}
+
+// Seek the current song to the position, the queue, the loop and the radio stay as they are.
+// A paused song plays from the position.
+func (s *Service) Seek(pos time.Duration) error {
+	song := s.NowPlaying()
+	if song == nil || !s.Player.audio.IsPlaying() {
+		return ErrNotPlaying
+	}
+	// the songs of the library may have no duration, the one of the encoder is taken then, an unknown one isn't checked
+	duration := s.SongStatus().Duration
+	if pos < 0 || duration > 0 && pos.Seconds() >= duration {
+		return ErrInvalidPosition
+	}
+	if !s.Player.audio.Seek(pos) {
+		return ErrNotPlaying
+	}
+	s.Player.publish(Event{Type: Seeked, Song: song, Pos: pos})
+	return nil
+}
+
+// Resume the paused song where it stopped
+func (s *Service) Resume(_ context.Context) error {
+	if !s.Player.audio.Resume() {
+		return ErrNotPaused
+	}
+	s.Player.publish(Event{Type: Resumed, Song: s.NowPlaying(), Pos: s.Player.audio.Position()})
+	return nil
+}
+
 func (s *Service) Disconnect() {
 	s.setRadio(false)
 	s.Player.Disconnect()
 }
 
+// Shutdown stops the playback and saves the queue to resume it after restart
+func (s *Service) Shutdown(ctx context.Context) error {
+	radio := s.RadioStatus()
+	s.setRadio(false)
+	state, err := s.Player.Shutdown(ctx)
+	if err != nil {
+		return errors.Wrap(err, "stop player")
+	}
+	state.Radio = radio
+	if state.Empty() || state.GuildID == "" {
+		if atomic.LoadInt32(&s.stateSaved) == 1 {
+			_, err := s.storage.PopPlayerState(ctx)
+			return errors.Wrap(err, "drop saved player state")
+		}
+		return nil
+	}
+	if err := s.storage.SavePlayerState(ctx, state); err != nil {
+		return errors.Wrap(err, "save player state")
+	}
+	return nil
+}
+
+// SaveState saves the current state now, e.g. before a deploy, KeepState keeps it up to date
+func (s *Service) SaveState(ctx context.Context) error {
+	state := s.Player.State()
+	state.Radio = s.RadioStatus()
+	if state.Empty() || state.GuildID == "" {
+		return nil
+	}
+	if err := s.storage.SavePlayerState(ctx, state); err != nil {
+		return errors.Wrap(err, "save player state")
+	}
+	atomic.StoreInt32(&s.stateSaved, 1)
+	return nil
+}
+
+// KeepState saves the state periodically so another instance can resume the playback if this one dies
+func (s *Service) KeepState(ctx context.Context, interval time.Duration) {
+	supervisor.Go(ctx, s.logger, "player state", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		saved := false
+		for {
+			select {
+			case <-ticker.C:
+				state := s.Player.State()
+				state.Radio = s.RadioStatus()
+				if state.Empty() || state.GuildID == "" {
+					// a stale state would be resumed on the takeover
+					if saved {
+						if _, err := s.storage.PopPlayerState(ctx); err != nil {
+							s.logger.Error(errors.Wrap(err, "drop player state"))
+							continue
+						}
+						saved = false
+					}
+					continue
+				}
+				if err := s.storage.SavePlayerState(ctx, state); err != nil {
+					s.logger.Error(errors.Wrap(err, "keep player state"))
+					continue
+				}
+				saved = true
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// Restore resumes the playback saved by Shutdown, the current song starts from the beginning
+func (s *Service) Restore(ctx context.Context) error {
+	state, err := s.storage.PopPlayerState(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load player state")
+	}
+	if state == nil {
+		return nil
+	}
+	// the saved queue replaces the one left in a shared store
+	s.Player.queue.Clear()
+	s.connect(state.GuildID, state.ChannelID)
+	songs := state.Queue
+	if state.Current != nil {
+		songs = append([]*pkg.Song{state.Current}, songs...)
+	}
+	played := 0
+	for _, song := range songs {
+		song.ID = pkg.GetIDFromURL(song.URL)
+		song, err := s.youtube.EnsureStreamInfo(ctx, song)
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "ensure stream info for restore"))
+			continue
+		}
+		s.Player.Play(song)
+		played++
+	}
+	// loop repeats the current song so it needs one
+	if played != 0 {
+		s.Player.SetLoop(state.Loop)
+	}
+	if state.Radio {
+		s.setRadio(true)
+		if played == 0 {
+			return s.playRandomSong(ctx)
+		}
+	}
+	return nil
+}
+
 func (s *Service) Status() pkg.PlayerStatus {
 	return pkg.PlayerStatus{
 		Loop:  s.LoopStatus(),
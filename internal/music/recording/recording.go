@@ -0,0 +1,145 @@
+package recording
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/audio"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	ext = ".ogg"
+	// DownloadPath of the recordings in the api
+	DownloadPath = "/music/recordings/"
+	cleanupEvery = time.Hour
+)
+
+var nameRe = regexp.MustCompile(`^[0-9a-f-]{36}\.ogg$`)
+
+type Config struct {
+	Dir string
+	// MaxLength of a recording, it stops by itself then
+	MaxLength time.Duration
+	// Keep the files are removed after it, it has to be longer than MaxLength
+	Keep time.Duration
+	// URL of the api the links are built with, e.g. https://bot.example.com/api/v1
+	URL string
+}
+
+// Result of a finished recording, URL is empty if it failed
+type Result struct {
+	URL      string
+	Duration time.Duration
+	Size     int64
+	Err      error
+}
+
+type Recorder interface {
+	StartRecording(path string, maxDuration time.Duration, done func(audio.Recording)) error
+	StopRecording() bool
+}
+
+// Service keeps the recordings of the listening sessions for a while, the names are random so the links can't be guessed
+type Service struct {
+	config   Config
+	recorder Recorder
+	logger   zap.Logger
+}
+
+func NewService(config Config, recorder Recorder, logger zap.Logger) (*Service, error) {
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "create recordings dir")
+	}
+	return &Service{
+		config:   config,
+		recorder: recorder,
+		logger:   logger,
+	}, nil
+}
+
+// MaxLength the recording stops by itself after it
+func (s *Service) MaxLength() time.Duration {
+	return s.config.MaxLength
+}
+
+// Keep how long the files are downloadable
+func (s *Service) Keep() time.Duration {
+	return s.config.Keep
+}
+
+// Start returns audio.ErrRecording if a recording is on already, done gets the link when it ends
+func (s *Service) Start(done func(Result)) error {
+	name := uuid.New().String() + ext
+	err := s.recorder.StartRecording(filepath.Join(s.config.Dir, name), s.config.MaxLength, func(r audio.Recording) {
+		res := Result{Duration: r.Duration, Size: r.Size, Err: r.Err}
+		if r.Err == nil && r.Duration > 0 {
+			res.URL = strings.TrimSuffix(s.config.URL, "/") + DownloadPath + name
+		} else {
+			_ = os.Remove(r.Path)
+		}
+		done(res)
+	})
+	if err != nil {
+		return errors.Wrap(err, "start recording")
+	}
+	return nil
+}
+
+// Stop returns false if nothing was recording
+func (s *Service) Stop() bool {
+	return s.recorder.StopRecording()
+}
+
+// Path of the recording for the download
+func (s *Service) Path(name string) (string, bool) {
+	if !nameRe.MatchString(name) {
+		return "", false
+	}
+	path := filepath.Join(s.config.Dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// Cleanup removes the recordings older than Keep until the ctx is done
+func (s *Service) Cleanup(ctx context.Context) {
+	supervisor.Go(ctx, s.logger, "recordings cleanup", func(ctx context.Context) {
+		ticker := time.NewTicker(cleanupEvery)
+		defer ticker.Stop()
+		for {
+			s.removeOld()
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+func (s *Service) removeOld() {
+	infos, err := ioutil.ReadDir(s.config.Dir)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "read recordings dir"))
+		return
+	}
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ext) || time.Since(info.ModTime()) < s.config.Keep {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.Dir, info.Name())); err != nil {
+			s.logger.Error(errors.Wrap(err, "remove recording"))
+		}
+	}
+}
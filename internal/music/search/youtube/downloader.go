@@ -8,30 +8,47 @@ import (
 
 	"github.com/kkdai/youtube/v2"
 	"github.com/kkdai/youtube/v2/downloader"
+	"github.com/pkg/errors"
 
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/download"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
+// progressStep percents between the reports of a download
+const progressStep = 10
+
 type Downloader struct {
 	logger zap.Logger
 	downloader.Downloader
 }
 
-func (dl *Downloader) Download(ctx context.Context, v *youtube.Video, format *youtube.Format, outputFile string) error {
+// Download into a partial file renamed when it is complete, report gets the percents every progressStep
+func (dl *Downloader) Download(ctx context.Context, v *youtube.Video, format *youtube.Format, outputFile string, report func(percent int)) error {
 	dl.logger.Infof("Video '%s'- Codec '%s'", v.Title, format.MimeType)
 	destFile, err := dl.getOutputFile(outputFile)
 	if err != nil {
 		return err
 	}
 
-	out, err := os.Create(destFile)
+	partFile := destFile + download.PartSuffix
+	out, err := os.Create(partFile)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
 
 	dl.logger.Infof("Download to file=%s", destFile)
-	return dl.videoDLWorker(ctx, out, v, format)
+	err = dl.videoDLWorker(ctx, out, v, format, report)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(partFile, destFile)
+	}
+	if err != nil {
+		_ = os.Remove(partFile)
+		return errors.Wrap(err, "download")
+	}
+	return nil
 }
 
 func (dl *Downloader) getOutputFile(outputFile string) (string, error) {
@@ -44,34 +61,35 @@ func (dl *Downloader) getOutputFile(outputFile string) (string, error) {
 	return outputFile, nil
 }
 
-func (dl *Downloader) videoDLWorker(ctx context.Context, out *os.File, video *youtube.Video, format *youtube.Format) error {
+func (dl *Downloader) videoDLWorker(ctx context.Context, out *os.File, video *youtube.Video, format *youtube.Format, report func(percent int)) error {
 	stream, size, err := dl.GetStreamContext(ctx, video, format)
 	if err != nil {
 		return err
 	}
+	defer stream.Close()
 
-	reader := stream
-	prog := &progress{contentLength: float64(size)}
+	prog := &progress{contentLength: size, report: report}
 	mw := io.MultiWriter(out, prog)
-	_, err = io.Copy(mw, reader)
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err = io.Copy(mw, stream)
+	return err
 }
 
 type progress struct {
-	contentLength     float64
-	totalWrittenBytes float64
-	downloadLevel     float64
+	contentLength int64
+	written       int64
+	reported      int
+	report        func(percent int)
 }
 
-func (dl *progress) Write(p []byte) (n int, err error) {
-	n = len(p)
-	dl.totalWrittenBytes += float64(n)
-	currentPercent := (dl.totalWrittenBytes / dl.contentLength) * 100
-	if (dl.downloadLevel <= currentPercent) && (dl.downloadLevel < 100) {
-		dl.downloadLevel++
+func (p *progress) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if p.contentLength <= 0 || p.report == nil {
+		return len(b), nil
+	}
+	percent := int(p.written * 100 / p.contentLength)
+	if percent >= p.reported+progressStep && percent < 100 {
+		p.reported = percent - percent%progressStep
+		p.report(p.reported)
 	}
-	return
+	return len(b), nil
 }
@@ -0,0 +1,151 @@
+package youtube
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// DefaultDownloadWorkers songs are downloaded at the same time, the rest wait for a slot
+const DefaultDownloadWorkers = 2
+
+// ErrDownloadCanceled the song was skipped before its download completed
+var ErrDownloadCanceled = errors.New("download canceled")
+
+// Progress of a background download, the last one is Done and has Err if the download failed
+type Progress struct {
+	Percent int
+	Done    bool
+	Err     error
+}
+
+type downloadTask struct {
+	cancel   context.CancelFunc
+	last     Progress
+	watchers []chan Progress
+}
+
+// downloads run in the background with bounded concurrency, the song streams until its file is complete
+type downloads struct {
+	slots chan struct{}
+
+	mx     sync.Mutex
+	active map[string]*downloadTask
+}
+
+func newDownloads(workers int) *downloads {
+	if workers <= 0 {
+		workers = DefaultDownloadWorkers
+	}
+	return &downloads{
+		slots:  make(chan struct{}, workers),
+		active: make(map[string]*downloadTask),
+	}
+}
+
+// start the download of the song unless it is running already
+func (d *downloads) start(id string, logger zap.Logger, run func(ctx context.Context, report func(percent int)) error) {
+	d.mx.Lock()
+	if _, ok := d.active[id]; ok {
+		d.mx.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.active[id] = &downloadTask{cancel: cancel}
+	d.mx.Unlock()
+	activeDownloads.Inc()
+
+	go supervisor.Safe(logger, "download "+id, func() {
+		defer cancel()
+		// a panic in the download is reported as a failure
+		err := errors.New("download panicked")
+		defer func() { d.finish(id, err) }()
+		select {
+		case d.slots <- struct{}{}:
+			defer func() { <-d.slots }()
+			err = run(ctx, func(percent int) { d.report(id, Progress{Percent: percent}) })
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			err = ErrDownloadCanceled
+		}
+		if err != nil && !errors.Is(err, ErrDownloadCanceled) {
+			logger.Error(errors.Wrapf(err, "download %s", id))
+		}
+	})
+}
+
+// report keeps only the latest progress for a watcher which is behind
+func (d *downloads) report(id string, p Progress) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	t, ok := d.active[id]
+	if !ok {
+		return
+	}
+	t.last = p
+	for _, ch := range t.watchers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- p
+	}
+}
+
+func (d *downloads) finish(id string, err error) {
+	activeDownloads.Dec()
+	switch {
+	case err == nil:
+		downloadResults.WithLabelValues("completed").Inc()
+	case errors.Is(err, ErrDownloadCanceled):
+		downloadResults.WithLabelValues("canceled").Inc()
+	default:
+		downloadResults.WithLabelValues("failed").Inc()
+	}
+	d.report(id, Progress{Percent: 100, Done: true, Err: err})
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for _, ch := range d.active[id].watchers {
+		close(ch)
+	}
+	delete(d.active, id)
+}
+
+func (d *downloads) watch(id string) (<-chan Progress, bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	t, ok := d.active[id]
+	if !ok {
+		return nil, false
+	}
+	ch := make(chan Progress, 1)
+	ch <- t.last
+	t.watchers = append(t.watchers, ch)
+	return ch, true
+}
+
+func (d *downloads) cancel(id string) bool {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	t, ok := d.active[id]
+	if ok {
+		t.cancel()
+	}
+	return ok
+}
+
+// WatchDownload of the song, false if it isn't downloading. The channel is closed after the Done progress.
+func (y *YouTube) WatchDownload(id pkg.SongID) (<-chan Progress, bool) {
+	return y.downloads.watch(id.ID)
+}
+
+// CancelDownload of the song, the file is removed. False if it isn't downloading.
+func (y *YouTube) CancelDownload(id pkg.SongID) bool {
+	return y.downloads.cancel(id.ID)
+}
@@ -0,0 +1,75 @@
+package youtube
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	apiCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "api_calls_total",
+		Help:      "Requests to YouTube by method: search, videos, playlist_items and playlists are the Data API, video, stream and playlist are extraction.",
+	}, []string{"method"})
+	extractionFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "extraction_failures_total",
+		Help:      "Songs which stream info couldn't be loaded.",
+	})
+	quotaLeft = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "quota_left_units",
+		Help:      "Estimated Data API units left for today by key, updated on every search.",
+	}, []string{"key"})
+	quotaUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "quota_used_units",
+		Help:      "Data API units spent today by this instance by key.",
+	}, []string{"key"})
+	alternatives = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "alternatives_total",
+		Help:      "Searches for another upload of a radio song which couldn't be extracted, by result: found or failed.",
+	}, []string{"result"})
+	cacheSearches = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "cache_searches_total",
+		Help:      "Searches served from the songs cache to save the quota.",
+	})
+	activeDownloads = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "downloads_active",
+		Help:      "Songs downloading in the background or waiting for a slot.",
+	})
+	downloadResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "downloads_total",
+		Help:      "Background downloads by result: completed, canceled or failed.",
+	}, []string{"result"})
+	searchCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "search_cache_requests_total",
+		Help:      "Searches looked up in the cache of the search results by result, hit or miss.",
+	}, []string{"result"})
+	searchCacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "search_cache_size",
+		Help:      "Queries with the cached search results.",
+	})
+	libraryOnly = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "youtube",
+		Name:      "library_only",
+		Help:      "1 while only the downloaded songs play and YouTube isn't called.",
+	})
+)
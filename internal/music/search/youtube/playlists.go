@@ -0,0 +1,222 @@
+package youtube
+
+import (
+	"context"
+	"strings"
+
+	ytdl "github.com/kkdai/youtube/v2"
+	"github.com/pkg/errors"
+	"google.golang.org/api/youtube/v3"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/breaker"
+)
+
+const (
+	// maxPlaylistSongs the rest of a longer playlist is dropped, the queue is limited anyway
+	maxPlaylistSongs = 200
+	// topicSuffix of the channels YouTube Music makes for the artists
+	topicSuffix = " - Topic"
+)
+
+// ErrEmptyPlaylist the playlist is private, deleted or has no videos
+var ErrEmptyPlaylist = errors.New("playlist has no songs")
+
+// maxPlaylistItems in one page of the playlist items API
+const maxPlaylistItems = 50
+
+// FindPlaylist title and songs of the playlist link in its order. The Data API loads the playlist items and
+// the songs are resolved in batches, progress is called after each batch. The extraction loads the playlist
+// without the quota when the Data API fails, e.g. the quota is exhausted.
+func (y *YouTube) FindPlaylist(ctx context.Context, link string, progress func(loaded, total int)) (string, []*pkg.Song, error) {
+	listID := pkg.GetPlaylistIDFromURL(link)
+	if listID == "" {
+		return "", nil, ErrSongNotFound
+	}
+	if y.LibraryOnly() {
+		return "", nil, ErrLibraryOnly
+	}
+	title, songs, err := y.apiPlaylist(ctx, listID, progress)
+	if err == nil || errors.Is(err, ErrEmptyPlaylist) {
+		return title, songs, err
+	}
+	return y.Playlist(ctx, listID)
+}
+
+// apiPlaylist the playlist by the Data API, a unit per page of 50 items and per batch of 50 videos
+func (y *YouTube) apiPlaylist(ctx context.Context, listID string, progress func(loaded, total int)) (string, []*pkg.Song, error) {
+	ids, err := y.playlistItems(ctx, listID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(ids) == 0 {
+		return "", nil, ErrEmptyPlaylist
+	}
+	// the title is only shown, the songs are queued without it
+	title, _ := y.playlistTitle(ctx, listID)
+	songs := make([]*pkg.Song, 0, len(ids))
+	for start := 0; start < len(ids); start += maxVideoIDs {
+		end := start + maxVideoIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch, err := y.videos(ctx, ids[start:end])
+		if err != nil {
+			return "", nil, err
+		}
+		songs = append(songs, inOrder(ids[start:end], batch)...)
+		if progress != nil {
+			progress(end, len(ids))
+		}
+	}
+	if len(songs) == 0 {
+		return "", nil, ErrEmptyPlaylist
+	}
+	return title, songs, nil
+}
+
+// playlistItems the video ids of the playlist by the Data API, up to maxPlaylistSongs
+func (y *YouTube) playlistItems(ctx context.Context, listID string) ([]string, error) {
+	ids := make([]string, 0, maxPlaylistItems)
+	token := ""
+	for len(ids) < maxPlaylistSongs {
+		response, err := y.playlistPage(ctx, listID, token)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range response.Items {
+			if item.ContentDetails != nil && item.ContentDetails.VideoId != "" && len(ids) < maxPlaylistSongs {
+				ids = append(ids, item.ContentDetails.VideoId)
+			}
+		}
+		token = response.NextPageToken
+		if token == "" {
+			break
+		}
+	}
+	return ids, nil
+}
+
+func (y *YouTube) playlistPage(ctx context.Context, listID, token string) (*youtube.PlaylistItemListResponse, error) {
+	if y.LibraryOnly() {
+		return nil, ErrLibraryOnly
+	}
+	q := y.quotas.pick(playlistCost)
+	if q == nil {
+		return nil, ErrQuotaExhausted
+	}
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	call := q.key.Service.PlaylistItems.List([]string{"contentDetails"}).PlaylistId(listID).MaxResults(maxPlaylistItems)
+	if token != "" {
+		call.PageToken(token)
+	}
+	call.Context(ctx)
+	var response *youtube.PlaylistItemListResponse
+	err := y.searchBreaker.Do(func() error {
+		apiCalls.WithLabelValues("playlist_items").Inc()
+		q.spend(playlistCost)
+		var err error
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			return nil, ErrUnavailable
+		}
+		return nil, errors.Wrapf(err, "list items of playlist %s", listID)
+	}
+	return response, nil
+}
+
+// playlistTitle by the Data API, empty if it isn't found
+func (y *YouTube) playlistTitle(ctx context.Context, listID string) (string, error) {
+	q := y.quotas.pick(playlistCost)
+	if q == nil {
+		return "", ErrQuotaExhausted
+	}
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	call := q.key.Service.Playlists.List([]string{"snippet"}).Id(listID)
+	call.Context(ctx)
+	var response *youtube.PlaylistListResponse
+	err := y.searchBreaker.Do(func() error {
+		apiCalls.WithLabelValues("playlists").Inc()
+		q.spend(playlistCost)
+		var err error
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "get playlist %s", listID)
+	}
+	if len(response.Items) == 0 || response.Items[0].Snippet == nil {
+		return "", nil
+	}
+	return response.Items[0].Snippet.Title, nil
+}
+
+// inOrder of the ids, the videos API doesn't promise the order and leaves out the unavailable videos
+func inOrder(ids []string, songs []*pkg.Song) []*pkg.Song {
+	byID := make(map[string]*pkg.Song, len(songs))
+	for _, song := range songs {
+		byID[song.ID.ID] = song
+	}
+	res := make([]*pkg.Song, 0, len(songs))
+	for _, id := range ids {
+		if song, ok := byID[id]; ok {
+			res = append(res, song)
+		}
+	}
+	return res
+}
+
+// Playlist title and songs of a YouTube or YouTube Music playlist in its order. It is loaded by the extraction,
+// so the quota isn't spent, the streams are found when the songs come next.
+func (y *YouTube) Playlist(ctx context.Context, listID string) (string, []*pkg.Song, error) {
+	if y.LibraryOnly() {
+		return "", nil, ErrLibraryOnly
+	}
+	var playlist *ytdl.Playlist
+	err := y.extractionBreaker.Do(func() error {
+		apiCalls.WithLabelValues("playlist").Inc()
+		var err error
+		playlist, err = y.ytdl.GetPlaylistContext(ctx, listID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			return "", nil, ErrUnavailable
+		}
+		return "", nil, errors.Wrapf(err, "load playlist %s", listID)
+	}
+	songs := make([]*pkg.Song, 0, len(playlist.Videos))
+	for _, v := range playlist.Videos {
+		if len(songs) == maxPlaylistSongs {
+			break
+		}
+		art, thumb := getYTDLImages(v.Thumbnails)
+		songs = append(songs, &pkg.Song{
+			Title:        v.Title,
+			URL:          videoPrefix + v.ID,
+			Service:      pkg.ServiceYouTube,
+			ArtistName:   artistName(v.Author),
+			ArtworkURL:   art,
+			ThumbnailURL: thumb,
+			ID: pkg.SongID{
+				ID:      v.ID,
+				Service: pkg.ServiceYouTube,
+			},
+			Duration: v.Duration.Seconds(),
+		})
+	}
+	if len(songs) == 0 {
+		return "", nil, ErrEmptyPlaylist
+	}
+	return playlist.Title, songs, nil
+}
+
+// artistName the name of the artist without the suffix of its YouTube Music channel
+func artistName(channel string) string {
+	return strings.TrimSuffix(channel, topicSuffix)
+}
@@ -0,0 +1,126 @@
+package youtube
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	// DefaultDailyQuota units of the YouTube Data API project
+	DefaultDailyQuota = 10000
+	// DefaultReserveQuota is enough for 10 searches
+	DefaultReserveQuota = 1000
+	searchCost          = 100
+	videosCost          = 1
+	playlistCost        = 1
+)
+
+// the quota resets at midnight Pacific time
+var quotaZone = func() *time.Location {
+	if loc, err := time.LoadLocation("America/Los_Angeles"); err == nil {
+		return loc
+	}
+	return time.FixedZone("PST", -8*60*60)
+}()
+
+// Key a client of the Data API with its own quota, Name is used in the metrics instead of the key itself
+type Key struct {
+	Name    string
+	Service *youtube.Service
+}
+
+// quota counts the units spent by this instance only, other clients of the project are not seen
+type quota struct {
+	key   Key
+	mx    sync.Mutex
+	day   time.Time
+	used  int64
+	daily int64
+}
+
+func (q *quota) spend(units int64) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.reset()
+	q.used += units
+	quotaLeft.WithLabelValues(q.key.Name).Set(float64(q.daily - q.used))
+	quotaUsed.WithLabelValues(q.key.Name).Set(float64(q.used))
+}
+
+func (q *quota) left() int64 {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.reset()
+	quotaLeft.WithLabelValues(q.key.Name).Set(float64(q.daily - q.used))
+	quotaUsed.WithLabelValues(q.key.Name).Set(float64(q.used))
+	return q.daily - q.used
+}
+
+func (q *quota) reset() {
+	y, m, d := time.Now().In(quotaZone).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, quotaZone)
+	if !day.Equal(q.day) {
+		q.day = day
+		q.used = 0
+	}
+}
+
+// quotas the keys are used in turn, the one with the most units left goes first
+type quotas []*quota
+
+func newQuotas(keys []Key, daily int64) quotas {
+	qs := make(quotas, 0, len(keys))
+	for _, k := range keys {
+		q := &quota{key: k, daily: daily}
+		quotaLeft.WithLabelValues(k.Name).Set(float64(daily))
+		quotaUsed.WithLabelValues(k.Name).Set(0)
+		qs = append(qs, q)
+	}
+	return qs
+}
+
+// pick returns nil if no key can afford the units
+func (qs quotas) pick(units int64) *quota {
+	var best *quota
+	var bestLeft int64
+	for _, q := range qs {
+		if left := q.left(); left >= units && (best == nil || left > bestLeft) {
+			best, bestLeft = q, left
+		}
+	}
+	return best
+}
+
+func (qs quotas) left() (left, daily int64) {
+	for _, q := range qs {
+		if l := q.left(); l > 0 {
+			left += l
+		}
+		daily += q.daily
+	}
+	return left, daily
+}
+
+// QuotaLeft estimated units of the YouTube Data API left for today and the daily quota of all keys
+func (y *YouTube) QuotaLeft() (left, daily int64) {
+	return y.quotas.left()
+}
+
+// QuotaByKey units left for today of every key
+func (y *YouTube) QuotaByKey() string {
+	parts := make([]string, 0, len(y.quotas))
+	for _, q := range y.quotas {
+		parts = append(parts, q.key.Name+": "+strconv.FormatInt(q.left(), 10))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lowQuota the searches are served from the cache first
+func (y *YouTube) lowQuota() bool {
+	left, _ := y.quotas.left()
+	return left < y.config.ReserveQuota
+}
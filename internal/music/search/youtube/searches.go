@@ -0,0 +1,125 @@
+package youtube
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+// maxSearches cached queries, the ones expiring first are dropped to make room
+const maxSearches = 1000
+
+type searchResult struct {
+	songs   []pkg.Song
+	expires time.Time
+}
+
+// searches results of the Data API by query, repeated queries don't spend the quota until they expire
+type searches struct {
+	mx      sync.Mutex
+	ttl     time.Duration
+	results map[string]searchResult
+}
+
+func newSearches() *searches {
+	return &searches{results: make(map[string]searchResult)}
+}
+
+func searchKey(query string, safe bool) string {
+	key := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if safe {
+		key += "\x00safe"
+	}
+	return key
+}
+
+func (s *searches) setTTL(ttl time.Duration) {
+	s.mx.Lock()
+	s.ttl = ttl
+	s.mx.Unlock()
+}
+
+// get copies of the songs, the callers change them
+func (s *searches) get(key string) ([]*pkg.Song, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.ttl <= 0 {
+		return nil, false
+	}
+	r, ok := s.results[key]
+	if ok && time.Now().After(r.expires) {
+		delete(s.results, key)
+		searchCacheSize.Set(float64(len(s.results)))
+		ok = false
+	}
+	if !ok {
+		searchCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	searchCacheRequests.WithLabelValues("hit").Inc()
+	songs := make([]*pkg.Song, len(r.songs))
+	for i := range r.songs {
+		song := r.songs[i]
+		songs[i] = &song
+	}
+	return songs, true
+}
+
+func (s *searches) set(key string, songs []*pkg.Song) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.ttl <= 0 {
+		return
+	}
+	if _, ok := s.results[key]; !ok && len(s.results) >= maxSearches {
+		s.evict()
+	}
+	r := searchResult{songs: make([]pkg.Song, len(songs)), expires: time.Now().Add(s.ttl)}
+	for i := range songs {
+		r.songs[i] = *songs[i]
+	}
+	s.results[key] = r
+	searchCacheSize.Set(float64(len(s.results)))
+}
+
+// evict the expired results or the one expiring first
+func (s *searches) evict() {
+	now := time.Now()
+	first := ""
+	for k, r := range s.results {
+		if now.After(r.expires) {
+			delete(s.results, k)
+			continue
+		}
+		if first == "" || r.expires.Before(s.results[first].expires) {
+			first = k
+		}
+	}
+	if len(s.results) >= maxSearches {
+		delete(s.results, first)
+	}
+}
+
+func (s *searches) flush() {
+	s.mx.Lock()
+	s.results = make(map[string]searchResult)
+	s.mx.Unlock()
+	searchCacheSize.Set(0)
+}
+
+// SetSearchTTL of the cached search results, 0 turns the cache off
+func (y *YouTube) SetSearchTTL(ttl time.Duration) {
+	y.searches.setTTL(ttl)
+	if ttl <= 0 {
+		y.searches.flush()
+	}
+}
+
+// FlushSearches drops the cached search results, the next queries spend the quota again
+func (y *YouTube) FlushSearches(_ context.Context) error {
+	y.searches.flush()
+	return nil
+}
@@ -1,8 +1,10 @@
 package youtube
 
 import (
-	"path/filepath"
+	"context"
 	"sort"
+	"sync/atomic"
+	"time"
 
 	ytdl "github.com/kkdai/youtube/v2"
 	"github.com/kkdai/youtube/v2/downloader"
@@ -10,46 +12,124 @@ import (
 	"google.golang.org/api/youtube/v3"
 
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/breaker"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
 )
 
 const (
-	videoPrefix     = "https://youtube.com/watch?v="
-	channelPrefix   = "https://youtube.com/channel/"
-	videoKind       = "youtube#video"
-	videoFormat     = ".m4a"
-	videoType       = "audio/mp4"
-	maxSearchResult = 10
+	videoPrefix   = "https://youtube.com/watch?v="
+	channelPrefix = "https://youtube.com/channel/"
+	videoKind     = "youtube#video"
+	// ageRestricted content rating of the explicit videos
+	ageRestricted = "ytAgeRestricted"
+
+	// the breakers open after breakerThreshold failures in a row
+	breakerThreshold  = 5
+	breakerMinBackoff = 30 * time.Second
+	breakerMaxBackoff = 10 * time.Minute
+
+	// searchTimeout the caller's deadline is kept if it is earlier
+	searchTimeout = 10 * time.Second
+	// maxAlternatives uploads are tried for a song which can't be extracted
+	maxAlternatives = 3
 )
 
 type SongsCache interface {
 	Get(k string) (*pkg.Song, bool)
+	// Find a cached song by its title
+	Find(query string) (*pkg.Song, bool)
 	KeyFromID(s pkg.SongID) string
 }
 
+// Files downloaded songs, used only with Config.Download
+type Files interface {
+	Get(ctx context.Context, name string) (string, bool)
+	Put(ctx context.Context, name string) error
+	Path(name string) string
+}
+
 var (
 	ErrSongNotFound = errors.New("song not found")
+	// ErrUnavailable YouTube fails, the calls are paused for a while
+	ErrUnavailable = errors.New("youtube is unavailable")
+	// ErrQuotaExhausted no key has the quota for a search until the reset
+	ErrQuotaExhausted = errors.New("youtube quota is exhausted")
+	// ErrExplicit the video is age restricted and the search is safe
+	ErrExplicit = errors.New("song is age restricted")
+	// ErrPlaylistLink the playlists are queued by Playlist, a search of the link finds a wrong video
+	ErrPlaylistLink = errors.New("playlist link is not a song")
+	// ErrLibraryOnly the song isn't downloaded and YouTube isn't called in the library mode
+	ErrLibraryOnly = errors.New("only the downloaded songs play in the library mode")
 )
 
 type Config struct {
 	Download  bool   `json:"download"`
 	OutputDir string `json:"output"`
+	// CacheSizeMB limit of the downloaded files, the least recently played are removed
+	CacheSizeMB int64 `json:"cache_size_mb"`
+	// MaxSearchResult number of videos requested from the search API
+	MaxSearchResult int64 `json:"max_search_result"`
+	// Format extension of the downloaded files
+	Format string `json:"format"`
+	// MimeType of the audio stream
+	MimeType string `json:"mime_type"`
+	// DailyQuota of every key of the YouTube Data API, only used to estimate the headroom
+	DailyQuota int64 `json:"daily_quota"`
+	// ReserveQuota below it the cached songs and the links are played without a search
+	ReserveQuota int64 `json:"reserve_quota"`
+	// APIKeys of the Data API used in turn, the google credentials are used if empty
+	APIKeys []string `json:"api_keys"`
+	// DownloadWorkers songs downloaded at the same time, the others stream until their turn
+	DownloadWorkers int `json:"download_workers"`
+	// LibraryOnly only the downloaded songs play and YouTube isn't called, it can be switched at runtime
+	LibraryOnly bool `json:"library_only"`
 }
 
 type YouTube struct {
-	ytdl    *ytdl.Client
-	youtube *youtube.Service
-	cache   SongsCache
-	config  Config
+	ytdl   *ytdl.Client
+	cache  SongsCache
+	files  Files
+	config Config
+
+	searchBreaker     *breaker.Breaker
+	extractionBreaker *breaker.Breaker
+	quotas            quotas
+	downloads         *downloads
+	searches          *searches
+	libraryOnly       int32 // atomic
+}
+
+// NewYouTubeClient files may be nil if the songs are streamed
+func NewYouTubeClient(ytdl *ytdl.Client, keys []Key, cache SongsCache, files Files, config Config) *YouTube {
+	y := &YouTube{
+		ytdl:  ytdl,
+		files: files,
+		// the songs in the cache keep playing while YouTube is down
+		searchBreaker:     breaker.New("youtube_search", breakerThreshold, breakerMinBackoff, breakerMaxBackoff),
+		extractionBreaker: breaker.New("youtube_extraction", breakerThreshold, breakerMinBackoff, breakerMaxBackoff),
+		cache:             cache,
+		config:            config,
+		quotas:            newQuotas(keys, config.DailyQuota),
+		downloads:         newDownloads(config.DownloadWorkers),
+		searches:          newSearches(),
+	}
+	y.SetLibraryOnly(config.LibraryOnly)
+	return y
 }
 
-func NewYouTubeClient(ytdl *ytdl.Client, yt *youtube.Service, cache SongsCache, config Config) *YouTube {
-	return &YouTube{
-		ytdl:    ytdl,
-		youtube: yt,
-		cache:   cache,
-		config:  config,
+// SetLibraryOnly switches the library mode, the songs being downloaded finish
+func (y *YouTube) SetLibraryOnly(on bool) {
+	var v int32
+	if on {
+		v = 1
 	}
+	atomic.StoreInt32(&y.libraryOnly, v)
+	libraryOnly.Set(float64(v))
+}
+
+// LibraryOnly true if only the downloaded songs play
+func (y *YouTube) LibraryOnly() bool {
+	return atomic.LoadInt32(&y.libraryOnly) == 1
 }
 
 func getImages(details *youtube.ThumbnailDetails) (string, string) {
@@ -94,25 +174,86 @@ func getYTDLImages(ts ytdl.Thumbnails) (string, string) {
 	return thumbnails[maxIter].URL, thumbnails[maxIter].URL
 }
 
-func (y *YouTube) findSong(ctx contexts.Context, query string) (*pkg.Song, error) {
-	call := y.youtube.Search.List([]string{"id, snippet"}).
+func (y *YouTube) findSong(ctx context.Context, query string, safe bool) (*pkg.Song, error) {
+	if pkg.GetPlaylistIDFromURL(query) != "" {
+		return nil, ErrPlaylistLink
+	}
+	// a link doesn't need the search
+	var link *pkg.Song
+	if id := pkg.GetIDFromURL(query); id.Service == pkg.ServiceYouTube {
+		link = &pkg.Song{URL: videoPrefix + id.ID, Service: pkg.ServiceYouTube, ID: id}
+	}
+	if y.LibraryOnly() || y.lowQuota() {
+		if link != nil {
+			return link, nil
+		}
+		if s, ok := y.cache.Find(query); ok {
+			cacheSearches.Inc()
+			song := *s
+			return &song, nil
+		}
+	}
+	if y.LibraryOnly() {
+		return nil, ErrLibraryOnly
+	}
+	songs, err := y.search(ctx, query, safe)
+	if err != nil {
+		if link != nil {
+			return link, nil
+		}
+		return nil, err
+	}
+	return songs[0], nil
+}
+
+// search returns the videos found in order, at least one
+func (y *YouTube) search(ctx context.Context, query string, safe bool) ([]*pkg.Song, error) {
+	if y.LibraryOnly() {
+		return nil, ErrLibraryOnly
+	}
+	key := searchKey(query, safe)
+	if songs, ok := y.searches.get(key); ok {
+		return songs, nil
+	}
+	q := y.quotas.pick(searchCost)
+	if q == nil {
+		return nil, ErrQuotaExhausted
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	call := q.key.Service.Search.List([]string{"id, snippet"}).
 		Q(query).
-		MaxResults(maxSearchResult)
+		MaxResults(y.config.MaxSearchResult)
+	if safe {
+		call.SafeSearch("strict")
+	}
 	call.Context(ctx)
-	response, err := call.Do()
-	if err != nil || response.Items == nil {
-		// TODO: NOT FOUND?
+	var response *youtube.SearchListResponse
+	err := y.searchBreaker.Do(func() error {
+		apiCalls.WithLabelValues("search").Inc()
+		// a failed call costs the quota too
+		q.spend(searchCost)
+		var err error
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			return nil, ErrUnavailable
+		}
 		return nil, ErrSongNotFound
 	}
 
+	var songs []*pkg.Song
 	for _, item := range response.Items {
 		if item.Id.Kind == videoKind {
 			art, thumb := getImages(item.Snippet.Thumbnails)
-			return &pkg.Song{
+			songs = append(songs, &pkg.Song{
 				Title:        item.Snippet.Title,
 				URL:          videoPrefix + item.Id.VideoId,
 				Service:      pkg.ServiceYouTube,
-				ArtistName:   item.Snippet.ChannelTitle,
+				ArtistName:   artistName(item.Snippet.ChannelTitle),
 				ArtistURL:    channelPrefix + item.Snippet.ChannelId,
 				ArtworkURL:   art,
 				ThumbnailURL: thumb,
@@ -120,53 +261,111 @@ func (y *YouTube) findSong(ctx contexts.Context, query string) (*pkg.Song, error
 					ID:      item.Id.VideoId,
 					Service: pkg.ServiceYouTube,
 				},
-			}, nil
+			})
+		}
+	}
+	if len(songs) == 0 {
+		return nil, ErrSongNotFound
+	}
+	y.searches.set(key, songs)
+	return songs, nil
+}
+
+// FindAlternative upload of the song which stream info can be loaded, the first of maxAlternatives search results that works
+func (y *YouTube) FindAlternative(ctx context.Context, song *pkg.Song, safe bool) (*pkg.Song, error) {
+	query := song.Title
+	if song.ArtistName != "" {
+		query += " " + song.ArtistName
+	}
+	candidates, err := y.search(ctx, query, safe)
+	if err != nil {
+		alternatives.WithLabelValues("failed").Inc()
+		return nil, errors.Wrapf(err, "search alternative for %s", song.ID.ID)
+	}
+	tried := 0
+	for _, alt := range candidates {
+		if alt.ID == song.ID || alt.URL == song.AltURL {
+			continue
+		}
+		if tried == maxAlternatives {
+			break
+		}
+		tried++
+		if safe {
+			if err := y.Rate(ctx, alt); err != nil || *alt.Explicit {
+				continue
+			}
 		}
+		alt, err := y.EnsureStreamInfo(ctx, alt)
+		if err != nil {
+			continue
+		}
+		alternatives.WithLabelValues("found").Inc()
+		return alt, nil
 	}
-	return nil, ErrSongNotFound
+	alternatives.WithLabelValues("failed").Inc()
+	return nil, errors.Wrapf(ErrSongNotFound, "no alternative for %s", song.ID.ID)
 }
 
-func (y *YouTube) EnsureStreamInfo(ctx contexts.Context, song *pkg.Song) (*pkg.Song, error) {
+func (y *YouTube) EnsureStreamInfo(ctx context.Context, song *pkg.Song) (*pkg.Song, error) {
+	fileName := song.ID.ID + y.config.Format
+	if y.LibraryOnly() {
+		return y.downloaded(ctx, song, fileName)
+	}
 	if s, ok := y.cache.Get(y.cache.KeyFromID(song.ID)); ok {
-		song.StreamURL = s.StreamURL
-		song.Duration = s.Duration
-		return song, nil
+		// the file could be evicted since the song was cached
+		if !y.config.Download {
+			song.StreamURL = s.StreamURL
+			song.Duration = s.Duration
+			return song, nil
+		}
+		if path, ok := y.files.Get(ctx, fileName); ok {
+			song.StreamURL = path
+			song.Duration = s.Duration
+			return song, nil
+		}
 	}
 
 	url := song.URL
-	videoInfo, err := y.ytdl.GetVideo(url)
+	if song.AltURL != "" {
+		url = song.AltURL
+	}
+	var videoInfo *ytdl.Video
+	err := y.extractionBreaker.Do(func() error {
+		apiCalls.WithLabelValues("video").Inc()
+		var err error
+		videoInfo, err = y.ytdl.GetVideo(url)
+		return err
+	})
 	if err != nil {
+		extractionFailures.Inc()
+		if errors.Is(err, breaker.ErrOpen) {
+			return nil, ErrUnavailable
+		}
 		return nil, errors.Wrapf(err, "loag video metadata by url %s", url)
 	}
-	formats := videoInfo.Formats.WithAudioChannels().Type(videoType)
+	formats := videoInfo.Formats.WithAudioChannels().Type(y.config.MimeType)
 	if len(formats) == 0 {
+		extractionFailures.Inc()
 		return nil, errors.New("unable to get list of formats")
 	}
 
+	downloaded := false
 	if y.config.Download {
-		formats.Sort()
-		format := formats[len(formats)-1]
-		fileName := videoInfo.ID + videoFormat
-		song.StreamURL = filepath.Join(y.config.OutputDir, fileName)
-		dl := Downloader{
-			logger: ctx.LoggerFromContext(),
-			Downloader: downloader.Downloader{
-				Client:    *y.ytdl,
-				OutputDir: y.config.OutputDir},
+		fileName = videoInfo.ID + y.config.Format
+		var path string
+		if path, downloaded = y.files.Get(ctx, fileName); downloaded {
+			song.StreamURL = path
+		} else {
+			// the song streams while it is downloading, the next play takes the file
+			y.download(ctx, song.ID, videoInfo, formats, fileName)
 		}
-		err := dl.Download(ctx, videoInfo, &format, fileName)
+	}
+	if !downloaded {
+		streamURL, err := y.streamURL(ctx, videoInfo, formats)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		sort.SliceStable(formats, func(i, j int) bool {
-			return formats[i].ItagNo < formats[j].ItagNo
-		})
-		format := formats[0]
-		streamURL, err := y.ytdl.GetStreamURLContext(ctx, videoInfo, &format)
-		if err != nil {
-			return nil, errors.Wrapf(err, "unable to get streamURL %s", videoInfo.Title)
-		}
 		song.StreamURL = streamURL
 	}
 
@@ -175,13 +374,80 @@ func (y *YouTube) EnsureStreamInfo(ctx contexts.Context, song *pkg.Song) (*pkg.S
 	return song, nil
 }
 
+// downloaded the file of the song, the stream urls in the cache lead to YouTube
+func (y *YouTube) downloaded(ctx context.Context, song *pkg.Song, fileName string) (*pkg.Song, error) {
+	if !y.config.Download {
+		return nil, ErrLibraryOnly
+	}
+	path, ok := y.files.Get(ctx, fileName)
+	if !ok {
+		return nil, ErrLibraryOnly
+	}
+	song.StreamURL = path
+	if s, ok := y.cache.Get(y.cache.KeyFromID(song.ID)); ok {
+		song.MergeNoOverride(s)
+	}
+	return song, nil
+}
+
+func (y *YouTube) streamURL(ctx context.Context, videoInfo *ytdl.Video, formats ytdl.FormatList) (string, error) {
+	sort.SliceStable(formats, func(i, j int) bool {
+		return formats[i].ItagNo < formats[j].ItagNo
+	})
+	format := formats[0]
+	var streamURL string
+	err := y.extractionBreaker.Do(func() error {
+		apiCalls.WithLabelValues("stream").Inc()
+		var err error
+		streamURL, err = y.ytdl.GetStreamURLContext(ctx, videoInfo, &format)
+		return err
+	})
+	if err != nil {
+		extractionFailures.Inc()
+		return "", errors.Wrapf(err, "unable to get streamURL %s", videoInfo.Title)
+	}
+	return streamURL, nil
+}
+
+// download the best format in the background, a canceled download isn't counted by the breaker
+func (y *YouTube) download(ctx context.Context, id pkg.SongID, videoInfo *ytdl.Video, formats ytdl.FormatList, fileName string) {
+	formats = append(ytdl.FormatList(nil), formats...)
+	formats.Sort()
+	format := formats[len(formats)-1]
+	logger := contexts.LoggerFromContext(ctx)
+	y.downloads.start(id.ID, logger, func(ctx context.Context, report func(int)) error {
+		dl := Downloader{
+			logger: logger,
+			Downloader: downloader.Downloader{
+				Client:    *y.ytdl,
+				OutputDir: y.config.OutputDir},
+		}
+		err := y.extractionBreaker.Do(func() error {
+			apiCalls.WithLabelValues("download").Inc()
+			err := dl.Download(ctx, videoInfo, &format, fileName, report)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		})
+		if ctx.Err() != nil {
+			return ErrDownloadCanceled
+		}
+		if err != nil {
+			extractionFailures.Inc()
+			return err
+		}
+		return errors.Wrap(y.files.Put(ctx, fileName), "cache download")
+	})
+}
+
 func songFromInfo(v *ytdl.Video) *pkg.Song {
 	art, thumb := getYTDLImages(v.Thumbnails)
 	return &pkg.Song{
 		Title:        v.Title,
 		URL:          videoPrefix + v.ID,
 		Service:      pkg.ServiceYouTube,
-		ArtistName:   v.Author,
+		ArtistName:   artistName(v.Author),
 		ArtworkURL:   art,
 		ThumbnailURL: thumb,
 		ID: pkg.SongID{
@@ -192,11 +458,59 @@ func songFromInfo(v *ytdl.Video) *pkg.Song {
 	}
 }
 
-func (y *YouTube) FindSong(ctx contexts.Context, query string) (*pkg.Song, error) {
-	song, err := y.findSong(ctx, query)
+// Rate sets Explicit of the song from its content rating if it is unknown
+func (y *YouTube) Rate(ctx context.Context, song *pkg.Song) error {
+	if song.Explicit != nil {
+		return nil
+	}
+	if y.LibraryOnly() {
+		return ErrLibraryOnly
+	}
+	q := y.quotas.pick(videosCost)
+	if q == nil {
+		return ErrQuotaExhausted
+	}
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	call := q.key.Service.Videos.List([]string{"contentDetails"}).Id(song.ID.ID)
+	call.Context(ctx)
+	var response *youtube.VideoListResponse
+	err := y.searchBreaker.Do(func() error {
+		apiCalls.WithLabelValues("videos").Inc()
+		q.spend(videosCost)
+		var err error
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			return ErrUnavailable
+		}
+		return errors.Wrapf(err, "rate video %s", song.ID.ID)
+	}
+	if len(response.Items) == 0 {
+		return ErrSongNotFound
+	}
+	details := response.Items[0].ContentDetails
+	explicit := details != nil && details.ContentRating != nil && details.ContentRating.YtRating == ageRestricted
+	song.Explicit = &explicit
+	return nil
+}
+
+// FindSong the safe search skips the explicit results and the song is rated, ErrExplicit is returned for an explicit link
+func (y *YouTube) FindSong(ctx context.Context, query string, safe bool) (*pkg.Song, error) {
+	song, err := y.findSong(ctx, query, safe)
 	if err != nil {
 		return nil, err
 	}
+	if safe {
+		if err := y.Rate(ctx, song); err != nil {
+			return nil, errors.Wrap(err, "rate song")
+		}
+		if *song.Explicit {
+			return nil, ErrExplicit
+		}
+	}
 
 	song, err = y.EnsureStreamInfo(ctx, song)
 	if err != nil {
@@ -0,0 +1,109 @@
+package youtube
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/youtube/v3"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/breaker"
+)
+
+// maxVideoIDs in one call of the videos API
+const maxVideoIDs = 50
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+)D)?T?(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?$`)
+
+// Videos resolves the metadata of the video ids in batches, the unknown and private videos are left out
+func (y *YouTube) Videos(ctx context.Context, ids []string) ([]*pkg.Song, error) {
+	songs := make([]*pkg.Song, 0, len(ids))
+	for start := 0; start < len(ids); start += maxVideoIDs {
+		end := start + maxVideoIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch, err := y.videos(ctx, ids[start:end])
+		if err != nil {
+			return songs, err
+		}
+		songs = append(songs, batch...)
+	}
+	return songs, nil
+}
+
+func (y *YouTube) videos(ctx context.Context, ids []string) ([]*pkg.Song, error) {
+	if y.LibraryOnly() {
+		return nil, ErrLibraryOnly
+	}
+	q := y.quotas.pick(videosCost)
+	if q == nil {
+		return nil, ErrQuotaExhausted
+	}
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	call := q.key.Service.Videos.List([]string{"snippet", "contentDetails"}).Id(ids...).MaxResults(maxVideoIDs)
+	call.Context(ctx)
+	var response *youtube.VideoListResponse
+	err := y.searchBreaker.Do(func() error {
+		apiCalls.WithLabelValues("videos").Inc()
+		q.spend(videosCost)
+		var err error
+		response, err = call.Do()
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) {
+			return nil, ErrUnavailable
+		}
+		return nil, errors.Wrapf(err, "list %d videos", len(ids))
+	}
+	songs := make([]*pkg.Song, 0, len(response.Items))
+	for _, item := range response.Items {
+		if item.Snippet == nil {
+			continue
+		}
+		art, thumb := getImages(item.Snippet.Thumbnails)
+		song := &pkg.Song{
+			Title:        item.Snippet.Title,
+			URL:          videoPrefix + item.Id,
+			Service:      pkg.ServiceYouTube,
+			ArtistName:   item.Snippet.ChannelTitle,
+			ArtistURL:    channelPrefix + item.Snippet.ChannelId,
+			ArtworkURL:   art,
+			ThumbnailURL: thumb,
+			ID: pkg.SongID{
+				ID:      item.Id,
+				Service: pkg.ServiceYouTube,
+			},
+		}
+		if details := item.ContentDetails; details != nil {
+			explicit := details.ContentRating != nil && details.ContentRating.YtRating == ageRestricted
+			song.Explicit = &explicit
+			song.Duration = parseISODuration(details.Duration).Seconds()
+		}
+		songs = append(songs, song)
+	}
+	return songs, nil
+}
+
+// parseISODuration of the videos API like PT4M13S, 0 if it isn't one
+func parseISODuration(s string) time.Duration {
+	m := isoDurationRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+1])
+		d += time.Duration(n) * unit
+	}
+	return d
+}
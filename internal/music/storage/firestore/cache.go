@@ -1,12 +1,14 @@
 package firestore
 
 import (
-	"os"
+	"context"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
 )
 
 type Item struct {
@@ -21,7 +23,7 @@ type SongsCache struct {
 	songs map[string]Item
 }
 
-func NewSongsCache(ctx contexts.Context, expirationTime time.Duration) *SongsCache {
+func NewSongsCache(ctx context.Context, expirationTime time.Duration) *SongsCache {
 	c := &SongsCache{
 		songs: make(map[string]Item),
 	}
@@ -34,8 +36,10 @@ func (c *SongsCache) Get(k string) (*pkg.Song, bool) {
 	defer c.Unlock()
 	s, ok := c.songs[k]
 	if !ok {
+		cacheRequests.WithLabelValues("miss").Inc()
 		return nil, false
 	}
+	cacheRequests.WithLabelValues("hit").Inc()
 	s.updated = time.Now()
 	c.songs[k] = s
 	return &s.song, true
@@ -53,13 +57,44 @@ func (c *SongsCache) Set(k string, song *pkg.Song) {
 	c.Unlock()
 }
 
+// Find a song which artist and title contain all words of the query, ignoring case
+func (c *SongsCache) Find(query string) (*pkg.Song, bool) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, false
+	}
+	c.Lock()
+	defer c.Unlock()
+	for _, item := range c.songs {
+		name := strings.ToLower(item.song.ArtistName + " " + item.song.Title)
+		found := true
+		for _, w := range words {
+			if !strings.Contains(name, w) {
+				found = false
+				break
+			}
+		}
+		if found {
+			song := item.song
+			return &song, true
+		}
+	}
+	return nil, false
+}
+
+func (c *SongsCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.songs)
+}
+
 func (c *SongsCache) KeyFromID(s pkg.SongID) string {
 	return s.String()
 }
 
-func (c *SongsCache) expireProcess(ctx contexts.Context, expirationTime time.Duration) {
-	ticker := time.NewTicker(expirationTime)
-	go func() {
+func (c *SongsCache) expireProcess(ctx context.Context, expirationTime time.Duration) {
+	supervisor.Go(ctx, contexts.LoggerFromContext(ctx), "songs cache expiration", func(ctx context.Context) {
+		ticker := time.NewTicker(expirationTime)
 		defer ticker.Stop()
 		for {
 			select {
@@ -69,23 +104,20 @@ func (c *SongsCache) expireProcess(ctx contexts.Context, expirationTime time.Dur
 				c.Lock()
 				now := time.Now()
 				for k, v := range c.songs {
+					// the downloaded files are owned by the download cache
 					if v.updated.Before(now.Add(-expirationTime)) {
-						// If the song has not played before the expirationTime passed, there will be an error because
-						// we will delete it. But since the time is very long, we score we don't really care about such case.
-						_ = os.Remove(v.song.StreamURL)
 						delete(c.songs, k)
 					}
 				}
 				c.Unlock()
 			}
 		}
-	}()
+	})
 }
 
 func (c *SongsCache) Clear() {
 	c.Lock()
-	for k, v := range c.songs {
-		_ = os.Remove(v.song.StreamURL)
+	for k := range c.songs {
 		delete(c.songs, k)
 	}
 	c.Unlock()
@@ -0,0 +1,70 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/download"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const downloadsCollection = "downloads"
+
+func (c *Client) AllDownloads(ctx context.Context) ([]download.File, error) {
+	defer observe("all_downloads", time.Now())
+	contexts.LoggerFromContext(ctx).Info("DB: AllDownloads")
+	iter := c.Collection(downloadsCollection).Documents(ctx)
+	defer iter.Stop()
+	res := make([]download.File, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var f download.File
+		if err := doc.DataTo(&f); err != nil {
+			return nil, errors.Wrap(err, "failed to parse doc into struct")
+		}
+		f.Name = doc.Ref.ID
+		res = append(res, f)
+	}
+	return res, nil
+}
+
+func (c *Client) SetDownload(ctx context.Context, f *download.File) error {
+	defer observe("set_download", time.Now())
+	if c.debug {
+		return nil
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: SetDownload %s", f.Name)
+	err := write(ctx, "set_download", func() error {
+		_, err := c.Collection(downloadsCollection).Doc(f.Name).Set(ctx, f)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", f.Name, downloadsCollection)
+	}
+	return nil
+}
+
+func (c *Client) DeleteDownload(ctx context.Context, name string) error {
+	defer observe("delete_download", time.Now())
+	if c.debug {
+		return nil
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteDownload %s", name)
+	err := write(ctx, "delete_download", func() error {
+		_, err := c.Collection(downloadsCollection).Doc(name).Delete(ctx)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", name, downloadsCollection)
+	}
+	return nil
+}
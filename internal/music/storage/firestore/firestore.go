@@ -14,12 +14,19 @@ import (
 
 	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/retry"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
 	"google.golang.org/grpc/status"
 )
 
 const (
 	songsCollection = "songs"
 	usersCollection = "users"
+	// playsCollection is the history of the requested songs
+	playsCollection = "plays"
+	// healthCollection is never written
+	healthCollection = "health"
+	pingDoc          = "ping"
 	// Maximum batch size by firestore docs
 	batchSize              = 500
 	approximateSongsNumber = 1000
@@ -35,7 +42,24 @@ type Client struct {
 
 var ErrNotFound = errors.New("no docs found")
 
-func NewFirestoreClient(ctx contexts.Context, creds string, debug bool) (*Client, error) {
+// storageRetry is used only for plain gets, sets and deletes which are safe to repeat
+var storageRetry = retry.Policy{
+	Attempts:  4,
+	Base:      100 * time.Millisecond,
+	Max:       2 * time.Second,
+	Retryable: retry.Transient,
+}
+
+// write is storageRetry for the writes, the failed ones are counted for the alerts
+func write(ctx context.Context, operation string, f func() error) error {
+	err := storageRetry.Do(ctx, operation, f)
+	if err != nil {
+		writeFailures.WithLabelValues(operation).Inc()
+	}
+	return err
+}
+
+func NewFirestoreClient(ctx context.Context, creds string, debug bool) (*Client, error) {
 	sa := option.WithCredentialsFile(creds)
 	app, err := firebase.NewApp(ctx, nil, sa)
 	if err != nil {
@@ -56,8 +80,23 @@ func NewFirestoreClient(ctx contexts.Context, creds string, debug bool) (*Client
 	return client, nil
 }
 
-func (c *Client) GetSongByID(ctx contexts.Context, id pkg.SongID) (*pkg.Song, error) {
-	doc, err := c.Collection(songsCollection).Doc(id.String()).Get(ctx)
+// Ping reads a document that may not exist, only the connection matters
+func (c *Client) Ping(ctx context.Context) error {
+	defer observe("ping", time.Now())
+	_, err := c.Collection(healthCollection).Doc(pingDoc).Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return errors.Wrapf(err, "failed to get %s from %s", pingDoc, healthCollection)
+	}
+	return nil
+}
+
+func (c *Client) GetSongByID(ctx context.Context, id pkg.SongID) (*pkg.Song, error) {
+	defer observe("get_song", time.Now())
+	var doc *firestore.DocumentSnapshot
+	err := storageRetry.Do(ctx, "get_song", func() (err error) {
+		doc, err = c.Collection(songsCollection).Doc(id.String()).Get(ctx)
+		return err
+	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return nil, ErrNotFound
@@ -72,7 +111,7 @@ func (c *Client) GetSongByID(ctx contexts.Context, id pkg.SongID) (*pkg.Song, er
 	return &s, nil
 }
 
-func (c *Client) SetSong(ctx contexts.Context, song *pkg.Song) error {
+func (c *Client) SetSong(ctx context.Context, song *pkg.Song) error {
 	if c.debug {
 		return nil
 	}
@@ -82,21 +121,30 @@ func (c *Client) SetSong(ctx contexts.Context, song *pkg.Song) error {
 	return nil
 }
 
-func (c *Client) SetSongForced(ctx contexts.Context, song *pkg.Song) error {
+func (c *Client) SetSongForced(ctx context.Context, song *pkg.Song) error {
+	defer observe("set_song", time.Now())
 	if c.debug {
 		return nil
 	}
-	ctx.LoggerFromContext().Infof("DB: SetSongForced %s", song.ID)
-	_, err := c.Collection(songsCollection).Doc(song.ID.String()).Set(ctx, song)
+	contexts.LoggerFromContext(ctx).Infof("DB: SetSongForced %s", song.ID)
+	err := write(ctx, "set_song", func() error {
+		_, err := c.Collection(songsCollection).Doc(song.ID.String()).Set(ctx, song)
+		return err
+	})
 	if err != nil {
 		return errors.Wrapf(err, "failed to set %s from %s", song.ID.String(), songsCollection)
 	}
 	return nil
 }
 
-func (c *Client) GetUserSong(ctx contexts.Context, id pkg.SongID, user string) (*pkg.Song, error) {
-	ctx.LoggerFromContext().Infof("DB: GetUserSong id:%s user:%s", id, user)
-	doc, err := c.Collection(usersCollection).Doc(user).Collection(songsCollection).Doc(id.String()).Get(ctx)
+func (c *Client) GetUserSong(ctx context.Context, id pkg.SongID, user string) (*pkg.Song, error) {
+	defer observe("get_user_song", time.Now())
+	contexts.LoggerFromContext(ctx).Infof("DB: GetUserSong id:%s user:%s", id, user)
+	var doc *firestore.DocumentSnapshot
+	err := storageRetry.Do(ctx, "get_user_song", func() (err error) {
+		doc, err = c.Collection(usersCollection).Doc(user).Collection(songsCollection).Doc(id.String()).Get(ctx)
+		return err
+	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return nil, ErrNotFound
@@ -111,7 +159,7 @@ func (c *Client) GetUserSong(ctx contexts.Context, id pkg.SongID, user string) (
 	return &s, nil
 }
 
-func (c *Client) SetUserSong(ctx contexts.Context, song *pkg.Song, user string) error {
+func (c *Client) SetUserSong(ctx context.Context, song *pkg.Song, user string) error {
 	if c.debug {
 		return nil
 	}
@@ -124,13 +172,183 @@ func (c *Client) SetUserSong(ctx contexts.Context, song *pkg.Song, user string)
 	return nil
 }
 
-func (c *Client) GetAllSongsID(ctx contexts.Context) ([]pkg.SongID, error) {
+// UserSongs requested by the user with the playbacks of the user
+func (c *Client) UserSongs(ctx context.Context, user string) ([]pkg.Song, error) {
+	defer observe("get_user_songs", time.Now())
+	contexts.LoggerFromContext(ctx).Infof("DB: UserSongs user:%s", user)
+	iter := c.Collection(usersCollection).Doc(user).Collection(songsCollection).Documents(ctx)
+	defer iter.Stop()
+	res := make([]pkg.Song, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get songs of %s from %s", user, usersCollection)
+		}
+		var s pkg.Song
+		if err := doc.DataTo(&s); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		s.ID = pkg.GetIDFromURL(s.URL)
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// DeleteUserSongs the playbacks of the user which aren't written yet are dropped too
+func (c *Client) DeleteUserSongs(ctx context.Context, user string) (int, error) {
+	defer observe("delete_user_songs", time.Now())
+	c.updateMx.Lock()
+	delete(c.userSongs, user)
+	c.updateMx.Unlock()
+	iter := c.Collection(usersCollection).Doc(user).Collection(songsCollection).Documents(ctx)
+	defer iter.Stop()
+	deleted := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return deleted, errors.Wrapf(err, "failed to get songs of %s from %s", user, usersCollection)
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return deleted, errors.Wrapf(err, "failed to delete song %s of %s", doc.Ref.ID, user)
+		}
+		deleted++
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteUserSongs user:%s deleted:%d", user, deleted)
+	return deleted, nil
+}
+
+func (c *Client) AddPlay(ctx context.Context, play *pkg.Play) error {
+	defer observe("add_play", time.Now())
+	if c.debug {
+		return nil
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: AddPlay guild:%s song:%s", play.GuildID, play.SongID)
+	// the same doc is set on retries
+	doc := c.Collection(playsCollection).NewDoc()
+	err := write(ctx, "add_play", func() error {
+		_, err := doc.Set(ctx, play)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to add play of %s to %s", play.SongID, playsCollection)
+	}
+	return nil
+}
+
+// GuildPlays in [from, to), the oldest first
+func (c *Client) GuildPlays(ctx context.Context, guildID string, from, to time.Time) ([]pkg.Play, error) {
+	defer observe("get_guild_plays", time.Now())
+	contexts.LoggerFromContext(ctx).Infof("DB: GuildPlays guild:%s from:%s to:%s", guildID, from, to)
+	return c.plays(ctx, "guild", guildID, from, to)
+}
+
+// UserPlays in all guilds in [from, to), the oldest first
+func (c *Client) UserPlays(ctx context.Context, userID string, from, to time.Time) ([]pkg.Play, error) {
+	defer observe("get_user_plays", time.Now())
+	contexts.LoggerFromContext(ctx).Infof("DB: UserPlays user:%s from:%s to:%s", userID, from, to)
+	return c.plays(ctx, "user", userID, from, to)
+}
+
+// ForgetUserPlays removes the user from the plays, the plays stay in the history of the guilds
+func (c *Client) ForgetUserPlays(ctx context.Context, userID string) (int, error) {
+	defer observe("forget_user_plays", time.Now())
+	iter := c.Collection(playsCollection).Where("user", "==", userID).Documents(ctx)
+	defer iter.Stop()
+	updated := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return updated, errors.Wrapf(err, "failed to get plays of %s from %s", userID, playsCollection)
+		}
+		if _, err := doc.Ref.Update(ctx, []firestore.Update{{Path: "user", Value: firestore.Delete}}); err != nil {
+			return updated, errors.Wrapf(err, "failed to update play %s", doc.Ref.ID)
+		}
+		updated++
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: ForgetUserPlays user:%s updated:%d", userID, updated)
+	return updated, nil
+}
+
+// Plays in all guilds in [from, to), the oldest first
+func (c *Client) Plays(ctx context.Context, from, to time.Time) ([]pkg.Play, error) {
+	defer observe("get_plays", time.Now())
+	contexts.LoggerFromContext(ctx).Infof("DB: Plays from:%s to:%s", from, to)
+	return c.plays(ctx, "", "", from, to)
+}
+
+// plays of the field equal to the id, of all if the field is empty
+func (c *Client) plays(ctx context.Context, field, id string, from, to time.Time) ([]pkg.Play, error) {
+	query := c.Collection(playsCollection).Query
+	if field != "" {
+		query = query.Where(field, "==", id)
+	}
+	iter := query.
+		Where("time", ">=", from).
+		Where("time", "<", to).
+		OrderBy("time", firestore.Asc).
+		Documents(ctx)
+	defer iter.Stop()
+	res := make([]pkg.Play, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get plays of %s %s from %s", field, id, playsCollection)
+		}
+		var p pkg.Play
+		if err := doc.DataTo(&p); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+// TopSongs by the playbacks in all guilds
+func (c *Client) TopSongs(ctx context.Context, n int) ([]pkg.Song, error) {
+	defer observe("get_top_songs", time.Now())
+	contexts.LoggerFromContext(ctx).Infof("DB: TopSongs n:%d", n)
+	iter := c.Collection(songsCollection).OrderBy("playbacks", firestore.Desc).Limit(n).Documents(ctx)
+	defer iter.Stop()
+	res := make([]pkg.Song, 0, n)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get top songs from %s", songsCollection)
+		}
+		var s pkg.Song
+		if err := doc.DataTo(&s); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		s.ID = pkg.GetIDFromURL(s.URL)
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// GetAllSongs the short songs of the whole library
+func (c *Client) GetAllSongs(ctx context.Context) ([]ShortSong, error) {
+	defer observe("get_all_songs", time.Now())
 	if c.debug {
 		return nil, nil
 	}
-	ctx.LoggerFromContext().Info("DB: GetAllSongsID")
+	contexts.LoggerFromContext(ctx).Info("DB: GetAllSongs")
 	iter := c.Collection(songsCollection).Documents(ctx)
-	res := make([]pkg.SongID, 0, approximateSongsNumber)
+	res := make([]ShortSong, 0, approximateSongsNumber)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -147,15 +365,24 @@ func (c *Client) GetAllSongsID(ctx contexts.Context) ([]pkg.SongID, error) {
 		if s.ID.ID == "" {
 			s.ID = pkg.GetIDFromURL(s.URL)
 		}
-		res = append(res, s.ID)
+		res = append(res, ShortSong{
+			ID:         s.ID,
+			Title:      s.Title,
+			ArtistName: s.ArtistName,
+			ArtistURL:  s.ArtistURL,
+			URL:        s.URL,
+			Playbacks:  s.Playbacks,
+		})
 	}
 	return res, nil
 }
 
-// UpsertSongIncPlaybacks We don't use it because our cash of songs is always consistent
+// UpsertSongIncPlaybacks is not retried above the transaction, a lost response would count the playback twice.
+// We don't use it because our cash of songs is always consistent
 // As we have only one writer to the song db - this bot
-func (c *Client) UpsertSongIncPlaybacks(ctx contexts.Context, new *pkg.Song) (int, error) {
-	ctx.LoggerFromContext().Infof("DB: UpsertSongIncPlaybacks %s", new.ID)
+func (c *Client) UpsertSongIncPlaybacks(ctx context.Context, new *pkg.Song) (int, error) {
+	defer observe("upsert_song", time.Now())
+	contexts.LoggerFromContext(ctx).Infof("DB: UpsertSongIncPlaybacks %s", new.ID)
 	ref := c.Collection(songsCollection).Doc(new.ID.String())
 	playbacks := 0
 	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
@@ -178,73 +405,97 @@ func (c *Client) UpsertSongIncPlaybacks(ctx contexts.Context, new *pkg.Song) (in
 	return playbacks, nil
 }
 
-func (c *Client) updateSongs(ctx contexts.Context) {
-	ticker := time.NewTicker(time.Second * 30)
-	go func() {
+func (c *Client) updateSongs(ctx context.Context) {
+	supervisor.Go(ctx, contexts.LoggerFromContext(ctx), "firestore songs", func(ctx context.Context) {
+		ticker := time.NewTicker(time.Second * 30)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
-				c.updateMx.Lock()
-				if len(c.songs) == 0 {
-					c.updateMx.Unlock()
-					continue
-				}
-				toSend := make([]*pkg.Song, 0, len(c.songs))
-				for k, v := range c.songs {
-					toSend = append(toSend, v)
-					delete(c.songs, k)
-				}
-				c.updateMx.Unlock()
-				ctx.LoggerFromContext().Infof("DB: updating songs %d", len(toSend))
-				err := c.WriteBatch(ctx, toSend)
-				if err != nil {
-					ctx.LoggerFromContext().Error(err, "DB: unable to update songs")
+				if err := c.flushSongs(ctx); err != nil {
+					contexts.LoggerFromContext(ctx).Error(err, "DB: unable to update songs")
 				}
 			case <-ctx.Done():
 				return
 			}
 		}
-	}()
+	})
 }
 
-func (c *Client) updateUserSongs(ctx contexts.Context) {
-	ticker := time.NewTicker(time.Minute)
-	go func() {
+func (c *Client) updateUserSongs(ctx context.Context) {
+	supervisor.Go(ctx, contexts.LoggerFromContext(ctx), "firestore user songs", func(ctx context.Context) {
+		ticker := time.NewTicker(time.Minute)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
-				c.updateMx.Lock()
-				toSend := make(map[string][]*pkg.Song)
-				for user, songs := range c.userSongs {
-					toSend[user] = make([]*pkg.Song, 0, len(songs))
-					for k, v := range songs {
-						toSend[user] = append(toSend[user], v)
-						delete(c.songs, k)
-					}
-					delete(c.userSongs, user)
-				}
-				c.updateMx.Unlock()
-				go func() {
-					for user, songs := range toSend {
-						ctx.LoggerFromContext().Infof("DB: updateUserSongs user:%s songs:%d", user, len(songs))
-						for i := range songs {
-							_, err := c.Collection(usersCollection).Doc(user).Collection(songsCollection).Doc(songs[i].ID.String()).Set(ctx, songs[i])
-							if err != nil {
-								ctx.LoggerFromContext().Error("DB: while updating User:", user, len(songs), "Song:", songs[i], "Error", err)
-							}
-						}
-					}
-				}()
+				songs := c.takeUserSongs()
+				go supervisor.Safe(contexts.LoggerFromContext(ctx), "firestore user songs", func() { c.writeUserSongs(ctx, songs) })
 			case <-ctx.Done():
 				return
 			}
 		}
-	}()
+	})
+}
+
+// Flush writes the pending updates synchronously, call it before closing the client
+func (c *Client) Flush(ctx context.Context) error {
+	if err := c.flushSongs(ctx); err != nil {
+		return errors.Wrap(err, "flush songs")
+	}
+	c.writeUserSongs(ctx, c.takeUserSongs())
+	return nil
 }
 
-func (c *Client) WriteBatch(ctx contexts.Context, songs []*pkg.Song) error {
+func (c *Client) flushSongs(ctx context.Context) error {
+	c.updateMx.Lock()
+	if len(c.songs) == 0 {
+		c.updateMx.Unlock()
+		return nil
+	}
+	toSend := make([]*pkg.Song, 0, len(c.songs))
+	for k, v := range c.songs {
+		toSend = append(toSend, v)
+		delete(c.songs, k)
+	}
+	c.updateMx.Unlock()
+	contexts.LoggerFromContext(ctx).Infof("DB: updating songs %d", len(toSend))
+	return c.WriteBatch(ctx, toSend)
+}
+
+func (c *Client) takeUserSongs() map[string][]*pkg.Song {
+	c.updateMx.Lock()
+	defer c.updateMx.Unlock()
+	toSend := make(map[string][]*pkg.Song)
+	for user, songs := range c.userSongs {
+		toSend[user] = make([]*pkg.Song, 0, len(songs))
+		for _, v := range songs {
+			toSend[user] = append(toSend[user], v)
+		}
+		delete(c.userSongs, user)
+	}
+	return toSend
+}
+
+func (c *Client) writeUserSongs(ctx context.Context, toSend map[string][]*pkg.Song) {
+	for user, songs := range toSend {
+		contexts.LoggerFromContext(ctx).Infof("DB: updateUserSongs user:%s songs:%d", user, len(songs))
+		for i := range songs {
+			start := time.Now()
+			err := write(ctx, "set_user_song", func() error {
+				_, err := c.Collection(usersCollection).Doc(user).Collection(songsCollection).Doc(songs[i].ID.String()).Set(ctx, songs[i])
+				return err
+			})
+			observe("set_user_song", start)
+			if err != nil {
+				contexts.LoggerFromContext(ctx).Error("DB: while updating User:", user, len(songs), "Song:", songs[i], "Error", err)
+			}
+		}
+	}
+}
+
+func (c *Client) WriteBatch(ctx context.Context, songs []*pkg.Song) error {
+	defer observe("write_batch", time.Now())
 	size := len(songs)
 	for i := 0; i < size; i += batchSize {
 		k := i + batchSize
@@ -259,13 +510,16 @@ func (c *Client) WriteBatch(ctx contexts.Context, songs []*pkg.Song) error {
 	return nil
 }
 
-func (c *Client) doBatch(ctx contexts.Context, songs []*pkg.Song) error {
-	batch := c.Batch()
-	for s := range songs {
-		batch.Set(c.Collection(songsCollection).Doc(songs[s].ID.String()), songs[s])
-	}
-	_, err := batch.Commit(ctx)
-	return err
+func (c *Client) doBatch(ctx context.Context, songs []*pkg.Song) error {
+	// a committed batch can't be reused, so every attempt builds a new one
+	return write(ctx, "write_batch", func() error {
+		batch := c.Batch()
+		for s := range songs {
+			batch.Set(c.Collection(songsCollection).Doc(songs[s].ID.String()), songs[s])
+		}
+		_, err := batch.Commit(ctx)
+		return err
+	})
 }
 
 // Example of NOT FULL REWRITING (WITH DELETING) set (HACK with json)
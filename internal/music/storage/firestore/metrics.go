@@ -0,0 +1,47 @@
+package firestore
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "halvabot",
+		Subsystem: "firestore",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the firestore requests by operation.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 10),
+	}, []string{"operation"})
+	writeFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "firestore",
+		Name:      "write_failures_total",
+		Help:      "Writes which failed after all the retries by operation.",
+	}, []string{"operation"})
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "songs_cache",
+		Name:      "requests_total",
+		Help:      "Songs cache lookups by result, hit or miss.",
+	}, []string{"result"})
+	shortCacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "songs_cache",
+		Name:      "short_size",
+		Help:      "Songs in the short cache used by the radio and the search.",
+	})
+	shortCacheRefreshed = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "songs_cache",
+		Name:      "short_refreshed_timestamp_seconds",
+		Help:      "Time the short cache was last loaded from Firestore.",
+	})
+)
+
+// observe is deferred with the start time evaluated immediately
+func observe(operation string, start time.Time) {
+	requestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,105 @@
+package firestore
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+// minFuzzyWord shorter words of the query have to match exactly, the longer ones may have a typo
+const minFuzzyWord = 4
+
+type match struct {
+	song *ShortSong
+	// typos in the words of the query, an exact match has none
+	typos int
+}
+
+// SearchSongs in the short cache, the songs which artist and title contain every word of the query come first,
+// then the ones where a word differs by a typo. The most played songs are first among the equal matches.
+func (s *Service) SearchSongs(query string, n int) []pkg.Song {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 || n <= 0 {
+		return nil
+	}
+	s.songsShort.RLock()
+	matches := make([]match, 0)
+	for i := range s.songsShort.List {
+		song := &s.songsShort.List[i]
+		if typos, ok := matchSong(words, strings.ToLower(song.ArtistName+" "+song.Title)); ok {
+			matches = append(matches, match{song: song, typos: typos})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].typos != matches[j].typos {
+			return matches[i].typos < matches[j].typos
+		}
+		return matches[i].song.Playbacks > matches[j].song.Playbacks
+	})
+	if len(matches) > n {
+		matches = matches[:n]
+	}
+	res := make([]pkg.Song, 0, len(matches))
+	for _, m := range matches {
+		res = append(res, m.song.song())
+	}
+	s.songsShort.RUnlock()
+	return res
+}
+
+// matchSong every word is a substring of the name or is one typo away from a word of the name
+func matchSong(words []string, name string) (int, bool) {
+	typos := 0
+	var fields []string
+	for _, w := range words {
+		if strings.Contains(name, w) {
+			continue
+		}
+		if utf8.RuneCountInString(w) < minFuzzyWord {
+			return 0, false
+		}
+		if fields == nil {
+			fields = strings.FieldsFunc(name, func(r rune) bool {
+				return r == ' ' || r == '-' || r == '(' || r == ')' || r == '[' || r == ']' || r == ',' || r == '.'
+			})
+		}
+		found := false
+		for _, f := range fields {
+			if oneTypo(w, f) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		typos++
+	}
+	return typos, true
+}
+
+// oneTypo a letter is replaced, added or removed
+func oneTypo(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(ra) && ra[i] == rb[i] {
+		i++
+	}
+	if i == len(ra) {
+		return true
+	}
+	if len(ra) == len(rb) {
+		// replaced
+		return string(ra[i+1:]) == string(rb[i+1:])
+	}
+	// rb has one more letter at i
+	return string(ra[i:]) == string(rb[i+1:])
+}
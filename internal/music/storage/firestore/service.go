@@ -1,8 +1,10 @@
 package firestore
 
 import (
+	"context"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -11,11 +13,36 @@ import (
 	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
 )
 
+// ShortSong of the short cache, enough to pick a song for the radio and to find it by the name
+type ShortSong struct {
+	ID         pkg.SongID
+	Title      string
+	ArtistName string
+	ArtistURL  string
+	URL        string
+	Playbacks  int
+}
+
+func (s *ShortSong) song() pkg.Song {
+	return pkg.Song{
+		ID:         s.ID,
+		Service:    s.ID.Service,
+		Title:      s.Title,
+		ArtistName: s.ArtistName,
+		ArtistURL:  s.ArtistURL,
+		URL:        s.URL,
+		Playbacks:  s.Playbacks,
+	}
+}
+
 type shortCache struct {
 	sync.RWMutex
-	List []pkg.SongID
+	List []ShortSong
 }
 
+// ErrOffline Firestore was unavailable at startup and has not come back yet
+var ErrOffline = errors.New("firestore is offline")
+
 type Service struct {
 	songs  *SongsCache
 	client *Client
@@ -23,26 +50,44 @@ type Service struct {
 	songsShort   shortCache
 	updatesMutex sync.Mutex
 	updated      bool
+	// offline only the songs cache is used and the playbacks are not counted
+	offline int32
 }
 
-func NewFirestoreService(ctx contexts.Context, client *Client, songs *SongsCache) (*Service, error) {
+// NewFirestoreService loads the ids of all songs for the radio, RefreshShortCache keeps them fresh
+func NewFirestoreService(ctx context.Context, client *Client, songs *SongsCache) (*Service, error) {
 	f := Service{
 		songs:      songs,
 		client:     client,
 		songsShort: shortCache{},
 	}
 	go f.updateShortCache(ctx)
-	f.updateShortCacheProcess(ctx)
 	return &f, nil
 }
 
-func (s *Service) GetSong(ctx contexts.Context, id pkg.SongID) (*pkg.Song, error) {
+// SetOffline is set when Firestore is unavailable, the statistics are not collected meanwhile
+func (s *Service) SetOffline(offline bool) {
+	var v int32
+	if offline {
+		v = 1
+	}
+	atomic.StoreInt32(&s.offline, v)
+}
+
+func (s *Service) isOffline() bool {
+	return atomic.LoadInt32(&s.offline) == 1
+}
+
+func (s *Service) GetSong(ctx context.Context, id pkg.SongID) (*pkg.Song, error) {
 	key := s.songs.KeyFromID(id)
-	log := ctx.LoggerFromContext()
+	log := contexts.LoggerFromContext(ctx)
 	log.Debugf("Get song %s from cache", id)
 	if s, ok := s.songs.Get(key); ok {
 		return s, nil
 	}
+	if s.isOffline() {
+		return nil, ErrNotFound
+	}
 
 	log.Debugf("Get song %s from db", id)
 	song, err := s.client.GetSongByID(ctx, id)
@@ -57,7 +102,11 @@ func (s *Service) GetSong(ctx contexts.Context, id pkg.SongID) (*pkg.Song, error
 	return song, nil
 }
 
-func (s *Service) SetSong(ctx contexts.Context, song *pkg.Song) error {
+func (s *Service) SetSong(ctx context.Context, song *pkg.Song) error {
+	if s.isOffline() {
+		s.songs.Set(s.songs.KeyFromID(song.ID), song)
+		return nil
+	}
 	s.setUpdate(true)
 	if err := s.client.SetSong(ctx, song); err != nil {
 		return errors.Wrap(err, "firestore set song")
@@ -66,8 +115,13 @@ func (s *Service) SetSong(ctx contexts.Context, song *pkg.Song) error {
 	return nil
 }
 
-func (s *Service) UpsertSongIncPlaybacks(ctx contexts.Context, new *pkg.Song) (int, error) {
-	log := ctx.LoggerFromContext()
+func (s *Service) UpsertSongIncPlaybacks(ctx context.Context, new *pkg.Song) (int, error) {
+	if s.isOffline() {
+		// the song is cached for the next plays, the playbacks are unknown
+		s.songs.Set(s.songs.KeyFromID(new.ID), new)
+		return 0, nil
+	}
+	log := contexts.LoggerFromContext(ctx)
 	log.Debug("UpsertSongIncPlaybacks new", new)
 	old, err := s.GetSong(ctx, new.ID)
 	log.Debug("UpsertSongIncPlaybacks old", old)
@@ -84,24 +138,126 @@ func (s *Service) UpsertSongIncPlaybacks(ctx contexts.Context, new *pkg.Song) (i
 	return playbacks, nil
 }
 
-func (s *Service) IncrementUserRequests(ctx contexts.Context, song *pkg.Song, userID string) {
+func (s *Service) SetSongGain(ctx context.Context, id pkg.SongID, gain float64) error {
+	return s.updateSong(ctx, id, func(song *pkg.Song) { song.Gain = &gain })
+}
+
+// SetSongVolume 0 resets the volume to the guild one
+func (s *Service) SetSongVolume(ctx context.Context, id pkg.SongID, percent int) error {
+	return s.updateSong(ctx, id, func(song *pkg.Song) { song.Volume = percent })
+}
+
+func (s *Service) SetSongExplicit(ctx context.Context, id pkg.SongID, explicit bool) error {
+	return s.updateSong(ctx, id, func(song *pkg.Song) { song.Explicit = &explicit })
+}
+
+// SetSongAltURL the upload played instead of the song
+func (s *Service) SetSongAltURL(ctx context.Context, id pkg.SongID, url string) error {
+	return s.updateSong(ctx, id, func(song *pkg.Song) { song.AltURL = url })
+}
+
+// updateSong the stored song is copied, the playing one could be the same pointer
+func (s *Service) updateSong(ctx context.Context, id pkg.SongID, update func(song *pkg.Song)) error {
+	old, err := s.GetSong(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "get song %s", id)
+	}
+	song := *old
+	song.ID = id
+	update(&song)
+	if err := s.SetSong(ctx, &song); err != nil {
+		return errors.Wrapf(err, "update song %s", id)
+	}
+	return nil
+}
+
+// IncrementUserRequests logs the errors, a lost counter shouldn't fail the playback
+func (s *Service) IncrementUserRequests(ctx context.Context, song *pkg.Song, userID string) {
+	if s.isOffline() {
+		return
+	}
 	userSong, err := s.client.GetUserSong(ctx, song.ID, userID)
 	if err != nil {
-		if err == ErrNotFound {
-			song.Playbacks = 1
-		} else {
+		if err != ErrNotFound {
+			contexts.LoggerFromContext(ctx).Error(errors.Wrapf(err, "get user %s song %s", userID, song.ID))
 			return
 		}
+		song.Playbacks = 1
 	} else {
 		song.Playbacks = userSong.Playbacks + 1
 	}
-	err = s.client.SetUserSong(ctx, song, userID)
-	if err != nil {
+	if err := s.client.SetUserSong(ctx, song, userID); err != nil {
+		contexts.LoggerFromContext(ctx).Error(errors.Wrapf(err, "set user %s song %s", userID, song.ID))
+	}
+}
+
+// UserSongs the songs requested by the user, ErrOffline while Firestore is unavailable
+func (s *Service) UserSongs(ctx context.Context, userID string) ([]pkg.Song, error) {
+	if s.isOffline() {
+		return nil, ErrOffline
+	}
+	return s.client.UserSongs(ctx, userID)
+}
+
+// DeleteUserSongs of the user, ErrOffline while Firestore is unavailable
+func (s *Service) DeleteUserSongs(ctx context.Context, userID string) (int, error) {
+	if s.isOffline() {
+		return 0, ErrOffline
+	}
+	return s.client.DeleteUserSongs(ctx, userID)
+}
+
+// AddPlay logs the errors like IncrementUserRequests, the history is lost while Firestore is unavailable
+func (s *Service) AddPlay(ctx context.Context, play *pkg.Play) {
+	if s.isOffline() {
 		return
 	}
+	if err := s.client.AddPlay(ctx, play); err != nil {
+		contexts.LoggerFromContext(ctx).Error(errors.Wrapf(err, "add play of %s", play.SongID))
+	}
+}
+
+// GuildPlays requested in the guild in [from, to), ErrOffline while Firestore is unavailable
+func (s *Service) GuildPlays(ctx context.Context, guildID string, from, to time.Time) ([]pkg.Play, error) {
+	if s.isOffline() {
+		return nil, ErrOffline
+	}
+	return s.client.GuildPlays(ctx, guildID, from, to)
+}
+
+// UserPlays requested by the user in all guilds in [from, to), ErrOffline while Firestore is unavailable
+func (s *Service) UserPlays(ctx context.Context, userID string, from, to time.Time) ([]pkg.Play, error) {
+	if s.isOffline() {
+		return nil, ErrOffline
+	}
+	return s.client.UserPlays(ctx, userID, from, to)
+}
+
+// ForgetUserPlays keeps the plays without the user, ErrOffline while Firestore is unavailable
+func (s *Service) ForgetUserPlays(ctx context.Context, userID string) (int, error) {
+	if s.isOffline() {
+		return 0, ErrOffline
+	}
+	return s.client.ForgetUserPlays(ctx, userID)
+}
+
+// Plays in all guilds in [from, to), ErrOffline while Firestore is unavailable
+func (s *Service) Plays(ctx context.Context, from, to time.Time) ([]pkg.Play, error) {
+	if s.isOffline() {
+		return nil, ErrOffline
+	}
+	return s.client.Plays(ctx, from, to)
+}
+
+// TopSongs of all time, ErrOffline while Firestore is unavailable
+func (s *Service) TopSongs(ctx context.Context, n int) ([]pkg.Song, error) {
+	if s.isOffline() {
+		return nil, ErrOffline
+	}
+	return s.client.TopSongs(ctx, n)
 }
 
-func (s *Service) GetRandomSongs(ctx contexts.Context, n int) ([]*pkg.Song, error) {
+func (s *Service) GetRandomSongs(ctx context.Context, n int) ([]*pkg.Song, error) {
 	set := make(map[string]pkg.SongID)
 	max := len(s.songsShort.List)
 	if max == 0 {
@@ -115,7 +271,7 @@ func (s *Service) GetRandomSongs(ctx contexts.Context, n int) ([]*pkg.Song, erro
 		time.Sleep(time.Nanosecond * 2)
 		i := rand.Intn(max)
 		s.songsShort.RLock()
-		set[s.songsShort.List[i].ID] = s.songsShort.List[i]
+		set[s.songsShort.List[i].ID.ID] = s.songsShort.List[i].ID
 		s.songsShort.RUnlock()
 	}
 
@@ -130,36 +286,72 @@ func (s *Service) GetRandomSongs(ctx contexts.Context, n int) ([]*pkg.Song, erro
 	return result, nil
 }
 
-func (s *Service) updateShortCacheProcess(ctx contexts.Context) {
-	// TODO: in config
-	ticker := time.NewTicker(3 * time.Hour)
-	go func() {
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				if s.needUpdate() {
-					s.setUpdate(false)
-					s.updateShortCache(ctx)
-				}
-			case <-ctx.Done():
-				return
-			}
+func (s *Service) SavePlayerState(ctx context.Context, state *pkg.PlayerState) error {
+	if s.isOffline() {
+		return ErrOffline
+	}
+	return s.client.SetPlayerState(ctx, state)
+}
+
+// PopPlayerState returns the saved state only once, nil if there is nothing to restore
+func (s *Service) PopPlayerState(ctx context.Context) (*pkg.PlayerState, error) {
+	if s.isOffline() {
+		return nil, ErrOffline
+	}
+	state, err := s.client.GetPlayerState(ctx)
+	if err != nil {
+		if err == ErrNotFound {
+			return nil, nil
 		}
-	}()
+		return nil, err
+	}
+	if err := s.client.DeletePlayerState(ctx); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// RefreshShortCache reloads the ids of all songs if there were new songs since the last time
+func (s *Service) RefreshShortCache(ctx context.Context) error {
+	if s.needUpdate() {
+		s.setUpdate(false)
+		s.updateShortCache(ctx)
+	}
+	return nil
 }
 
-func (s *Service) updateShortCache(ctx contexts.Context) {
-	list, err := s.client.GetAllSongsID(ctx)
+func (s *Service) updateShortCache(ctx context.Context) {
+	list, err := s.client.GetAllSongs(ctx)
 	if err != nil {
 		s.setUpdate(true)
-		ctx.LoggerFromContext().Error(errors.Wrap(err, "getting all songs"))
+		contexts.LoggerFromContext(ctx).Error(errors.Wrap(err, "getting all songs"))
+	} else {
+		shortCacheRefreshed.SetToCurrentTime()
 	}
 	s.songsShort.Lock()
 	s.songsShort.List = list
 	size := len(list)
 	s.songsShort.Unlock()
-	ctx.LoggerFromContext().Infof("short cache updated with %d songs", size)
+	shortCacheSize.Set(float64(size))
+	contexts.LoggerFromContext(ctx).Infof("short cache updated with %d songs", size)
+}
+
+// ShortSongs a copy of the short cache, the songs have no stream info and no dates
+func (s *Service) ShortSongs() []pkg.Song {
+	s.songsShort.RLock()
+	defer s.songsShort.RUnlock()
+	res := make([]pkg.Song, 0, len(s.songsShort.List))
+	for i := range s.songsShort.List {
+		res = append(res, s.songsShort.List[i].song())
+	}
+	return res
+}
+
+// ShortCacheLen number of songs the radio picks from
+func (s *Service) ShortCacheLen() int {
+	s.songsShort.RLock()
+	defer s.songsShort.RUnlock()
+	return len(s.songsShort.List)
 }
 
 func (s *Service) setUpdate(b bool) {
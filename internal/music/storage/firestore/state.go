@@ -0,0 +1,70 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const (
+	playerCollection = "player"
+	stateDoc         = "state"
+)
+
+func (c *Client) SetPlayerState(ctx context.Context, state *pkg.PlayerState) error {
+	defer observe("set_player_state", time.Now())
+	if c.debug {
+		return nil
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: SetPlayerState queue:%d", len(state.Queue))
+	err := write(ctx, "set_player_state", func() error {
+		_, err := c.Collection(playerCollection).Doc(stateDoc).Set(ctx, state)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", stateDoc, playerCollection)
+	}
+	return nil
+}
+
+func (c *Client) GetPlayerState(ctx context.Context) (*pkg.PlayerState, error) {
+	defer observe("get_player_state", time.Now())
+	contexts.LoggerFromContext(ctx).Info("DB: GetPlayerState")
+	var doc *firestore.DocumentSnapshot
+	err := storageRetry.Do(ctx, "get_player_state", func() (err error) {
+		doc, err = c.Collection(playerCollection).Doc(stateDoc).Get(ctx)
+		return err
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "failed to get %s from %s", stateDoc, playerCollection)
+	}
+	var s pkg.PlayerState
+	if err := doc.DataTo(&s); err != nil {
+		return nil, errors.Wrap(err, "failed to parse doc into struct")
+	}
+	return &s, nil
+}
+
+func (c *Client) DeletePlayerState(ctx context.Context) error {
+	if c.debug {
+		return nil
+	}
+	err := write(ctx, "delete_player_state", func() error {
+		_, err := c.Collection(playerCollection).Doc(stateDoc).Delete(ctx)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", stateDoc, playerCollection)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/redis"
+)
+
+// removeScript takes the song at the position out of the list at once, LREM alone removes by the value
+const removeScript = `local v = redis.call('LINDEX', KEYS[1], ARGV[1])
+if not v then return nil end
+redis.call('LSET', KEYS[1], ARGV[1], '__removed__')
+redis.call('LREM', KEYS[1], 1, '__removed__')
+return v`
+
+// nextScript repeats the looped current song or pops the next one into current, at once
+const nextScript = `if redis.call('GET', KEYS[3]) == '1' then return redis.call('GET', KEYS[2]) end
+local v = redis.call('LPOP', KEYS[1])
+if v then redis.call('SET', KEYS[2], v) end
+return v`
+
+// replaceScript fills a new list and renames it over the queue, the queue is untouched if the pushes fail.
+// unpack takes a limited number of values, so the songs are pushed a thousand at a time.
+const replaceScript = `redis.call('DEL', KEYS[2])
+for i = 1, #ARGV, 1000 do
+  redis.call('RPUSH', KEYS[2], unpack(ARGV, i, math.min(i + 999, #ARGV)))
+end
+if #ARGV == 0 then redis.call('DEL', KEYS[1]) else redis.call('RENAME', KEYS[2], KEYS[1]) end
+return #ARGV`
+
+// entry the song with the fields which aren't in its JSON, the player needs them to play it
+type entry struct {
+	Song      *pkg.Song       `json:"song"`
+	ID        string          `json:"id"`
+	Service   pkg.ServiceName `json:"service"`
+	StreamURL string          `json:"stream_url,omitempty"`
+	Duration  float64         `json:"duration,omitempty"`
+	Requester *discordgo.User `json:"requester,omitempty"`
+}
+
+// Queue keeps the queue of the player in Redis: the list <prefix>:queue of the songs as JSON,
+// the song <prefix>:current and <prefix>:loop set to 1 when the current song repeats.
+// The edits of several keys are scripts, so the other processes never see them halfway.
+type Queue struct {
+	client  *redis.Client
+	queue   string
+	replace string
+	current string
+	loop    string
+}
+
+func NewQueue(client *redis.Client, prefix string) *Queue {
+	return &Queue{
+		client:  client,
+		queue:   prefix + ":queue",
+		replace: prefix + ":queue:replace",
+		current: prefix + ":current",
+		loop:    prefix + ":loop",
+	}
+}
+
+func (q *Queue) Push(ctx context.Context, song *pkg.Song) error {
+	data, err := encode(song)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.Do(ctx, "RPUSH", q.queue, data)
+	return err
+}
+
+func (q *Queue) PushFront(ctx context.Context, song *pkg.Song) error {
+	data, err := encode(song)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.Do(ctx, "LPUSH", q.queue, data)
+	return err
+}
+
+func (q *Queue) Next(ctx context.Context) (*pkg.Song, error) {
+	return q.song(q.client.Bytes(ctx, "EVAL", nextScript, "3", q.queue, q.current, q.loop))
+}
+
+func (q *Queue) Replace(ctx context.Context, songs []*pkg.Song) error {
+	args := make([]string, 0, len(songs)+5)
+	args = append(args, "EVAL", replaceScript, "2", q.queue, q.replace)
+	for _, song := range songs {
+		data, err := encode(song)
+		if err != nil {
+			return err
+		}
+		args = append(args, data)
+	}
+	_, err := q.client.Do(ctx, args...)
+	return err
+}
+
+func (q *Queue) Remove(ctx context.Context, pos int) (*pkg.Song, error) {
+	if pos < 0 {
+		return nil, nil
+	}
+	return q.song(q.client.Bytes(ctx, "EVAL", removeScript, "1", q.queue, strconv.Itoa(pos)))
+}
+
+func (q *Queue) Songs(ctx context.Context) ([]*pkg.Song, error) {
+	items, err := q.client.List(ctx, "LRANGE", q.queue, "0", "-1")
+	if err != nil {
+		return nil, err
+	}
+	songs := make([]*pkg.Song, 0, len(items))
+	for _, item := range items {
+		song, err := decode(item)
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, song)
+	}
+	return songs, nil
+}
+
+func (q *Queue) Len(ctx context.Context) (int, error) {
+	n, err := q.client.Int(ctx, "LLEN", q.queue)
+	return int(n), err
+}
+
+func (q *Queue) Clear(ctx context.Context) error {
+	_, err := q.client.Do(ctx, "DEL", q.queue, q.loop)
+	return err
+}
+
+func (q *Queue) Current(ctx context.Context) (*pkg.Song, error) {
+	return q.song(q.client.Bytes(ctx, "GET", q.current))
+}
+
+func (q *Queue) SetCurrent(ctx context.Context, song *pkg.Song) error {
+	data, err := encode(song)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.Do(ctx, "SET", q.current, data)
+	return err
+}
+
+func (q *Queue) Loop(ctx context.Context) (bool, error) {
+	b, err := q.client.Bytes(ctx, "GET", q.loop)
+	if errors.Is(err, redis.ErrNil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return string(b) == "1", nil
+}
+
+func (q *Queue) SetLoop(ctx context.Context, b bool) error {
+	var err error
+	if b {
+		_, err = q.client.Do(ctx, "SET", q.loop, "1")
+	} else {
+		_, err = q.client.Do(ctx, "DEL", q.loop)
+	}
+	return err
+}
+
+// song the missing key is no song
+func (q *Queue) song(data []byte, err error) (*pkg.Song, error) {
+	if errors.Is(err, redis.ErrNil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+func encode(song *pkg.Song) (string, error) {
+	data, err := json.Marshal(entry{
+		Song:      song,
+		ID:        song.ID.ID,
+		Service:   song.ID.Service,
+		StreamURL: song.StreamURL,
+		Duration:  song.Duration,
+		Requester: song.Requester,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "encode song %s", song.Title)
+	}
+	return string(data), nil
+}
+
+func decode(data []byte) (*pkg.Song, error) {
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, errors.Wrap(err, "decode song")
+	}
+	if e.Song == nil {
+		return nil, errors.New("decode song: no song in the entry")
+	}
+	song := e.Song
+	song.ID = pkg.SongID{ID: e.ID, Service: e.Service}
+	song.StreamURL = e.StreamURL
+	song.Duration = e.Duration
+	song.Requester = e.Requester
+	return song, nil
+}
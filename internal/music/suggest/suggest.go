@@ -0,0 +1,149 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
+)
+
+const (
+	// maxQueries popular queries are kept in memory, the least used go first
+	maxQueries = 1000
+	// minMatchWord shorter words of the query don't tell whether the found song matches it
+	minMatchWord = 3
+)
+
+// Suggestion of a song from the library or of a popular query
+type Suggestion struct {
+	// Text shown to the member
+	Text string
+	// URL of the library song, the Text is played as the query if empty
+	URL string
+}
+
+// Songs of the library, the search allows a typo in a word
+type Songs interface {
+	SearchSongs(query string, n int) []pkg.Song
+}
+
+// Service suggests what the member could mean by a query YouTube has no good result for
+type Service struct {
+	songs Songs
+
+	mx      sync.Mutex
+	queries map[string]int
+}
+
+func NewService(songs Songs) *Service {
+	return &Service{
+		songs:   songs,
+		queries: make(map[string]int),
+	}
+}
+
+// Record the query a song was played by, the links are not queries
+func (s *Service) Record(query string) {
+	query = normalize(query)
+	if query == "" || pkg.GetIDFromURL(query).Service != "" {
+		return
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if _, ok := s.queries[query]; !ok && len(s.queries) >= maxQueries {
+		s.evict()
+	}
+	s.queries[query]++
+}
+
+// evict the least used query
+func (s *Service) evict() {
+	least, n := "", 0
+	for q, c := range s.queries {
+		if least == "" || c < n {
+			least, n = q, c
+		}
+	}
+	delete(s.queries, least)
+}
+
+// Suggest the most played library song matching the query with typos, then the closest popular query
+func (s *Service) Suggest(query string) (Suggestion, bool) {
+	query = normalize(query)
+	if query == "" {
+		return Suggestion{}, false
+	}
+	if songs := s.songs.SearchSongs(query, 1); len(songs) > 0 {
+		song := songs[0]
+		text := song.Title
+		if song.ArtistName != "" {
+			text = song.ArtistName + " - " + song.Title
+		}
+		return Suggestion{Text: text, URL: song.URL}, true
+	}
+	if q, ok := s.closestQuery(query); ok {
+		return Suggestion{Text: q}, true
+	}
+	return Suggestion{}, false
+}
+
+type candidate struct {
+	query    string
+	distance int
+	count    int
+}
+
+// closestQuery within a quarter of the length in edits, at least 2, the popular one among the equally close
+func (s *Service) closestQuery(query string) (string, bool) {
+	maxDistance := utf8.RuneCountInString(query) / 4
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	s.mx.Lock()
+	candidates := make([]candidate, 0)
+	for q, c := range s.queries {
+		if q == query {
+			continue
+		}
+		if d := util.Distance(query, q); d <= maxDistance {
+			candidates = append(candidates, candidate{query: q, distance: d, count: c})
+		}
+	}
+	s.mx.Unlock()
+	if len(candidates) == 0 {
+		return "", false
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].count > candidates[j].count
+	})
+	return candidates[0].query, true
+}
+
+// PoorMatch none of the words of the query is in the artist or the title of the song, the links always match
+func PoorMatch(query string, song *pkg.Song) bool {
+	if pkg.GetIDFromURL(query).Service != "" {
+		return false
+	}
+	name := normalize(song.ArtistName + " " + song.Title)
+	checked := false
+	for _, w := range strings.Fields(normalize(query)) {
+		if utf8.RuneCountInString(w) < minMatchWord {
+			continue
+		}
+		checked = true
+		if strings.Contains(name, w) {
+			return false
+		}
+	}
+	return checked
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
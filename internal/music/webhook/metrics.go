@@ -0,0 +1,13 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var deliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "webhook",
+	Name:      "deliveries_total",
+	Help:      "Player events posted to the webhooks by the result.",
+}, []string{"result"})
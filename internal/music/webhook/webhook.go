@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const timeout = 5 * time.Second
+
+// Notifier posts every event of the player as JSON to the urls, the failed posts aren't retried
+type Notifier struct {
+	urls   []string
+	client *http.Client
+	logger zap.Logger
+}
+
+func NewNotifier(urls []string, logger zap.Logger) *Notifier {
+	return &Notifier{
+		urls:   urls,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+// Notify is the handler of the events
+func (n *Notifier) Notify(e player.Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		n.logger.Error(errors.Wrap(err, "marshal event"))
+		return
+	}
+	for _, url := range n.urls {
+		if err := n.post(url, body); err != nil {
+			deliveries.WithLabelValues("error").Inc()
+			n.logger.Warnw("webhook failed", "url", url, "event", e.Type, "err", err)
+			continue
+		}
+		deliveries.WithLabelValues("ok").Inc()
+	}
+}
+
+func (n *Notifier) post(url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
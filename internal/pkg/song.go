@@ -3,6 +3,7 @@ package pkg
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -10,6 +11,11 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+var (
+	videoIDRe    = regexp.MustCompile(`^[\w-]{11}$`)
+	playlistIDRe = regexp.MustCompile(`^[\w-]{2,}$`)
+)
+
 type ServiceName string
 
 const (
@@ -35,11 +41,15 @@ type Song struct {
 	ThumbnailURL string      `firestore:"thumbnail_url,omitempty" csv:"thumbnail_url,omitempty" json:"thumbnail_url,omitempty"`
 	Playbacks    int         `firestore:"playbacks,omitempty" csv:"playbacks" json:"playbacks,omitempty"`
 	LastPlay     PlayDate    `firestore:"last_play,omitempty" csv:"last_play,omitempty" json:"last_play,omitempty"`
+	Gain         *float64    `firestore:"gain,omitempty" csv:"-" json:"gain,omitempty"`         // dB to the common loudness, nil until measured
+	Volume       int         `firestore:"volume,omitempty" csv:"-" json:"volume,omitempty"`     // percent over the guild volume set by a DJ, 0 if unset
+	Explicit     *bool       `firestore:"explicit,omitempty" csv:"-" json:"explicit,omitempty"` // age restricted on YouTube, nil until rated
+	AltURL       string      `firestore:"alt_url,omitempty" csv:"-" json:"alt_url,omitempty"`   // another upload played instead of URL, which can't be extracted anymore
+	Duration     float64     `firestore:"duration,omitempty" csv:"-" json:"-"`                  // seconds, kept for the listening time of the users
 
 	ID        SongID          `firestore:"-" csv:"-" json:"-"`
 	Requester *discordgo.User `firestore:"-" csv:"-" json:"-"`
 	StreamURL string          `firestore:"-" csv:"-" json:"-"`
-	Duration  float64         `firestore:"-" csv:"-" json:"-"`
 }
 
 type User struct {
@@ -47,9 +57,13 @@ type User struct {
 	Songs []Song
 }
 
+// SessionStats of the current song, Pos is counted from the audio frames sent to Discord
 type SessionStats struct {
 	Pos      float64 `json:"position"` // seconds
 	Duration float64 `json:"duration"` // seconds
+	// Filters of the audio as they were set
+	Filters []string `json:"filters,omitempty"`
+	Paused  bool     `json:"paused"`
 }
 
 type PlayerStatus struct {
@@ -59,6 +73,30 @@ type PlayerStatus struct {
 	Now   *Song        `json:"now,omitempty"`
 }
 
+// Play of a song requested in a guild, the user is empty for the anonymous api requests
+type Play struct {
+	GuildID  string    `firestore:"guild"`
+	UserID   string    `firestore:"user,omitempty"`
+	SongID   string    `firestore:"song"`
+	Title    string    `firestore:"title"`
+	Duration float64   `firestore:"duration,omitempty"` // seconds
+	Time     time.Time `firestore:"time"`
+}
+
+// PlayerState is saved on shutdown to resume the playback after restart
+type PlayerState struct {
+	GuildID   string  `firestore:"guild_id"`
+	ChannelID string  `firestore:"channel_id"`
+	Current   *Song   `firestore:"current"`
+	Queue     []*Song `firestore:"queue"`
+	Loop      bool    `firestore:"loop"`
+	Radio     bool    `firestore:"radio"`
+}
+
+func (s *PlayerState) Empty() bool {
+	return s.Current == nil && len(s.Queue) == 0 && !s.Radio
+}
+
 func (date *PlayDate) UnmarshalCSV(csv string) error {
 	in := strings.Split(csv, "/")
 	if len(in) < 3 {
@@ -112,6 +150,18 @@ func (s *Song) MergeNoOverride(new *Song) {
 	if s.LastPlay.IsZero() {
 		s.LastPlay = new.LastPlay
 	}
+	if s.Volume == 0 {
+		s.Volume = new.Volume
+	}
+	if s.Gain == nil {
+		s.Gain = new.Gain
+	}
+	if s.Explicit == nil {
+		s.Explicit = new.Explicit
+	}
+	if s.AltURL == "" {
+		s.AltURL = new.AltURL
+	}
 	if s.Duration == 0 {
 		s.Duration = new.Duration
 	}
@@ -120,20 +170,66 @@ func (s *Song) MergeNoOverride(new *Song) {
 	}
 }
 
-func GetIDFromURL(url string) SongID {
+// GetIDFromURL of the YouTube video links: watch, youtu.be, shorts, embed and music.youtube.com,
+// the other parameters of the link like the playlist are dropped. Empty for the playlist and the other links.
+func GetIDFromURL(link string) SongID {
 	var id SongID
-	if TestYoutubeURL(url) {
-		id.Service = ServiceYouTube
-		// TODO: trim all urls https://stackoverflow.com/questions/19377262/regex-for-youtube-url
-		url = strings.TrimPrefix(url, `https://www.youtube.com/watch?v=`)
-		url = strings.TrimPrefix(url, `https://youtube.com/watch?v=`)
-		id.ID = url
+	u, ok := parseYoutubeURL(link)
+	if !ok {
 		return id
 	}
+	video := ""
+	if strings.TrimPrefix(strings.ToLower(u.Host), "www.") == "youtu.be" {
+		video = strings.Trim(u.Path, "/")
+	} else if v := u.Query().Get("v"); v != "" {
+		video = v
+	} else {
+		for _, prefix := range []string{"/shorts/", "/embed/", "/v/", "/live/"} {
+			if strings.HasPrefix(u.Path, prefix) {
+				video = strings.Trim(strings.TrimPrefix(u.Path, prefix), "/")
+			}
+		}
+	}
+	if !videoIDRe.MatchString(video) {
+		return id
+	}
+	id.Service = ServiceYouTube
+	id.ID = video
 	return id
 }
 
-func TestYoutubeURL(url string) bool {
-	test, _ := regexp.MatchString("^((?:https?:)?\\/\\/)?((?:www|m)\\.)?((?:youtube(-nocookie)?\\.com|youtu.be))(\\/(?:[\\w\\-]+\\?v=|embed\\/|v\\/)?)([\\w\\-]+)(\\S+)?$", url)
+// GetPlaylistIDFromURL of the YouTube and YouTube Music playlist links, the albums of YouTube Music are shared as playlists.
+// Empty for a video in a playlist, the video is played then.
+func GetPlaylistIDFromURL(link string) string {
+	u, ok := parseYoutubeURL(link)
+	if !ok || strings.TrimRight(u.Path, "/") != "/playlist" {
+		return ""
+	}
+	list := u.Query().Get("list")
+	if !playlistIDRe.MatchString(list) {
+		return ""
+	}
+	return list
+}
+
+func parseYoutubeURL(link string) (*url.URL, bool) {
+	link = strings.TrimSpace(link)
+	if !TestYoutubeURL(link) {
+		return nil, false
+	}
+	if strings.HasPrefix(link, "//") {
+		link = "https:" + link
+	} else if !strings.Contains(link, "//") {
+		link = "https://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, false
+	}
+	return u, true
+}
+
+func TestYoutubeURL(link string) bool {
+	test, _ := regexp.MatchString("^((?:https?:)?\\/\\/)?((?:www|m|music)\\.)?((?:youtube(-nocookie)?\\.com|youtu.be))(\\/(?:[\\w\\-]+\\?v=|embed\\/|v\\/)?)([\\w\\-]+)(\\S+)?$", link)
 	return test
 }
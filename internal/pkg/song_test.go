@@ -17,6 +17,22 @@ func TestGetIDFromURL(t *testing.T) {
 			in:  "https://youtube.com/watch?v=hDfFXWinkAk",
 			out: "youtube_hDfFXWinkAk",
 		},
+		{
+			in:  "https://music.youtube.com/watch?v=hDfFXWinkAk&si=ZsPf3WqCmQ",
+			out: "youtube_hDfFXWinkAk",
+		},
+		{
+			in:  "https://www.youtube.com/watch?v=hDfFXWinkAk&list=PLFgquLnL59alCl_2TQvOiD5Vgm1hCaGSI",
+			out: "youtube_hDfFXWinkAk",
+		},
+		{
+			in:  "https://youtu.be/hDfFXWinkAk?t=42",
+			out: "youtube_hDfFXWinkAk",
+		},
+		{
+			in:  "https://music.youtube.com/playlist?list=OLAK5uy_kX1cSL0m3tbNmnbbpR5vgYVj0z5p2ByxM",
+			out: "_",
+		},
 	}
 
 	for i := range testCases {
@@ -43,6 +59,10 @@ func TestTestYoutubeURL(t *testing.T) {
 			in:  "https://youtube.com/watch?v=hDfFXWinkAk",
 			out: true,
 		},
+		{
+			in:  "https://music.youtube.com/watch?v=hDfFXWinkAk",
+			out: true,
+		},
 		{
 			in:  "httssps://www.youtube.com/watch?v=hDfFXWinkAk",
 			out: false,
@@ -61,3 +81,33 @@ func TestTestYoutubeURL(t *testing.T) {
 		}
 	}
 }
+
+func TestGetPlaylistIDFromURL(t *testing.T) {
+	type test struct {
+		in  string
+		out string
+	}
+
+	testCases := []test{
+		{
+			in:  "https://music.youtube.com/playlist?list=OLAK5uy_kX1cSL0m3tbNmnbbpR5vgYVj0z5p2ByxM",
+			out: "OLAK5uy_kX1cSL0m3tbNmnbbpR5vgYVj0z5p2ByxM",
+		},
+		{
+			in:  "https://www.youtube.com/playlist?list=PLFgquLnL59alCl_2TQvOiD5Vgm1hCaGSI",
+			out: "PLFgquLnL59alCl_2TQvOiD5Vgm1hCaGSI",
+		},
+		{
+			in:  "https://www.youtube.com/watch?v=hDfFXWinkAk&list=PLFgquLnL59alCl_2TQvOiD5Vgm1hCaGSI",
+			out: "",
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		id := GetPlaylistIDFromURL(tc.in)
+		if id != tc.out {
+			t.Errorf("input: %s got %q, wanted %q", tc.in, id, tc.out)
+		}
+	}
+}
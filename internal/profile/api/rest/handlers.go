@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/storage/firestore"
+)
+
+// profile godoc
+// @summary  Top songs and artists, listening hours, favorites and playlists of the user, updated every 10 minutes
+// @produce  json
+// @param    id   path      string  true  "Discord user ID or me for the linked account"
+// @success  200  {object}  profile.Profile
+// @failure  401  {object}  Response  "The client isn't linked"
+// @failure  503  {object}  Response  "Firestore is unavailable"
+// @failure  500  {object}  Response  "Database error"
+// @router   /users/{id}/profile [get]
+func (h *Handler) profileHandler(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "me" {
+		userID = v1.UserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, Response{Message: "link the account first"})
+			return
+		}
+	}
+	p, err := h.profiles.Get(c.Request.Context(), userID)
+	if errors.Is(err, firestore.ErrOffline) {
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
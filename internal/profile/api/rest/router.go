@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/profile"
+)
+
+type Profiles interface {
+	Get(ctx context.Context, userID string) (*profile.Profile, error)
+}
+
+type Handler struct {
+	profiles Profiles
+	super    *gin.RouterGroup
+}
+
+func NewHandler(profiles Profiles, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		profiles: profiles,
+		super:    superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/users", v1.RequireScope(account.ScopeProfile))
+	group.GET("/:id/profile", h.profileHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
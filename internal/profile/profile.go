@@ -0,0 +1,206 @@
+package profile
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/library"
+	"github.com/HalvaPovidlo/discordBotGo/internal/listening"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	topSongs   = 10
+	topArtists = 10
+	// maxFavorites shown on the profile, the recently added
+	maxFavorites = 20
+	// cacheTTL the profile reads every song of the user, so it is computed at most this often
+	cacheTTL = 10 * time.Minute
+	// listenedDays of the listened hours, today included
+	listenedDays = 30
+)
+
+type Songs interface {
+	UserSongs(ctx context.Context, userID string) ([]pkg.Song, error)
+}
+
+type Library interface {
+	Favorites(ctx context.Context, userID string) ([]library.Entry, error)
+	Playlists(ctx context.Context, userID string) ([]library.Playlist, error)
+}
+
+type Listening interface {
+	Days(ctx context.Context, userID, from, to string) ([]listening.Day, error)
+}
+
+type Song struct {
+	Title      string `json:"title"`
+	ArtistName string `json:"artist_name,omitempty"`
+	URL        string `json:"url"`
+	ArtworkURL string `json:"artwork_url,omitempty"`
+	Playbacks  int    `json:"playbacks"`
+}
+
+type Artist struct {
+	Name      string `json:"name"`
+	Playbacks int    `json:"playbacks"`
+}
+
+type Playlist struct {
+	Name    string    `json:"name"`
+	Songs   int       `json:"songs"`
+	Updated time.Time `json:"updated"`
+}
+
+// Profile of the songs a user requested, the same for Discord and the linked clients
+type Profile struct {
+	UserID    string `json:"user_id"`
+	Playbacks int    `json:"playbacks"`
+	Songs     int    `json:"songs"`
+	// ListeningHours of the requested songs, the songs of unknown duration aren't counted
+	ListeningHours float64 `json:"listening_hours"`
+	// ListenedHours in the voice channel while the bot played, the last 30 days
+	ListenedHours float64         `json:"listened_hours"`
+	TopSongs      []Song          `json:"top_songs"`
+	TopArtists    []Artist        `json:"top_artists"`
+	Favorites     []library.Entry `json:"favorites"`
+	Playlists     []Playlist      `json:"playlists"`
+	Computed      time.Time       `json:"computed"`
+}
+
+type cached struct {
+	profile *Profile
+	expires time.Time
+}
+
+// Service computes the profiles from Firestore and keeps them for a while
+type Service struct {
+	songs     Songs
+	library   Library
+	listening Listening
+
+	mx    sync.Mutex
+	cache map[string]cached // user id
+}
+
+func NewService(songs Songs, library Library, listening Listening) *Service {
+	return &Service{
+		songs:     songs,
+		library:   library,
+		listening: listening,
+		cache:     make(map[string]cached),
+	}
+}
+
+// Get the profile of the user, the user without songs has an empty profile
+func (s *Service) Get(ctx context.Context, userID string) (*Profile, error) {
+	now := time.Now()
+	s.mx.Lock()
+	for k, v := range s.cache {
+		if now.After(v.expires) {
+			delete(s.cache, k)
+		}
+	}
+	c, ok := s.cache[userID]
+	s.mx.Unlock()
+	if ok {
+		return c.profile, nil
+	}
+
+	songs, err := s.songs.UserSongs(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "user songs")
+	}
+	favorites, err := s.library.Favorites(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	playlists, err := s.library.Playlists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	today := now.UTC()
+	days, err := s.listening.Days(ctx, userID,
+		today.AddDate(0, 0, 1-listenedDays).Format(listening.DateLayout), today.Format(listening.DateLayout))
+	if err != nil {
+		return nil, errors.Wrap(err, "listening days")
+	}
+	p := compute(userID, songs)
+	for i := range days {
+		p.ListenedHours += days[i].Seconds / time.Hour.Seconds()
+	}
+	if len(favorites) > maxFavorites {
+		favorites = favorites[:maxFavorites]
+	}
+	p.Favorites = favorites
+	p.Playlists = make([]Playlist, 0, len(playlists))
+	for i := range playlists {
+		p.Playlists = append(p.Playlists, Playlist{
+			Name:    playlists[i].Name,
+			Songs:   len(playlists[i].Songs),
+			Updated: playlists[i].Updated,
+		})
+	}
+	p.Computed = now
+
+	s.mx.Lock()
+	s.cache[userID] = cached{profile: p, expires: now.Add(cacheTTL)}
+	s.mx.Unlock()
+	return p, nil
+}
+
+func compute(userID string, songs []pkg.Song) *Profile {
+	p := &Profile{UserID: userID, Songs: len(songs)}
+	seconds := 0.0
+	artists := make(map[string]*Artist)
+	for i := range songs {
+		song := &songs[i]
+		p.Playbacks += song.Playbacks
+		seconds += song.Duration * float64(song.Playbacks)
+		if song.ArtistName == "" {
+			continue
+		}
+		key := strings.ToLower(song.ArtistName)
+		a, ok := artists[key]
+		if !ok {
+			a = &Artist{Name: song.ArtistName}
+			artists[key] = a
+		}
+		a.Playbacks += song.Playbacks
+	}
+	p.ListeningHours = seconds / time.Hour.Seconds()
+
+	sort.SliceStable(songs, func(i, j int) bool {
+		return songs[i].Playbacks > songs[j].Playbacks
+	})
+	p.TopSongs = make([]Song, 0, topSongs)
+	for i := 0; i < len(songs) && i < topSongs; i++ {
+		p.TopSongs = append(p.TopSongs, Song{
+			Title:      songs[i].Title,
+			ArtistName: songs[i].ArtistName,
+			URL:        songs[i].URL,
+			ArtworkURL: songs[i].ArtworkURL,
+			Playbacks:  songs[i].Playbacks,
+		})
+	}
+
+	p.TopArtists = make([]Artist, 0, len(artists))
+	for _, a := range artists {
+		p.TopArtists = append(p.TopArtists, *a)
+	}
+	sort.Slice(p.TopArtists, func(i, j int) bool {
+		if p.TopArtists[i].Playbacks != p.TopArtists[j].Playbacks {
+			return p.TopArtists[i].Playbacks > p.TopArtists[j].Playbacks
+		}
+		return p.TopArtists[i].Name < p.TopArtists[j].Name
+	})
+	if len(p.TopArtists) > topArtists {
+		p.TopArtists = p.TopArtists[:topArtists]
+	}
+	return p
+}
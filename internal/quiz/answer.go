@@ -0,0 +1,58 @@
+package quiz
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
+)
+
+var (
+	// bracketsRe (Official Video), [Lyrics] and the like
+	bracketsRe = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+	// featRe the featured artists are not asked
+	featRe = regexp.MustCompile(`(?i)\s(feat\.?|ft\.|featuring)\s.*$`)
+)
+
+// title of the song without the artist the YouTube titles often start with
+func title(song *pkg.Song) string {
+	t := bracketsRe.ReplaceAllString(song.Title, "")
+	if i := strings.Index(t, " - "); i >= 0 {
+		t = t[i+3:]
+	}
+	return clean(featRe.ReplaceAllString(t, ""))
+}
+
+// artist of the song without the suffixes of the channels
+func artist(song *pkg.Song) string {
+	a := clean(song.ArtistName)
+	a = strings.TrimSuffix(a, "vevo")
+	a = strings.TrimSuffix(a, " official")
+	return strings.TrimSpace(a)
+}
+
+// clean lowercases the text and drops the punctuation
+func clean(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	return util.StandardizeSpaces(s)
+}
+
+// matches the answer contains the name or is the name with a typo in every 4 letters
+func matches(answer, name string) bool {
+	answer = clean(answer)
+	if answer == "" || name == "" {
+		return false
+	}
+	if strings.Contains(" "+answer+" ", " "+name+" ") {
+		return true
+	}
+	return util.Distance(answer, name) <= utf8.RuneCountInString(name)/4
+}
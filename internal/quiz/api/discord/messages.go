@@ -0,0 +1,138 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/internal/quiz"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+)
+
+const (
+	messageNotInVoice   = ":x: **You have to be in a voice channel to start a quiz**"
+	messageNoPermission = ":x: **Only the member who started the quiz or server managers can stop it**"
+	messageInvalidValue = ":x: **%s**"
+	messageOtherGuild   = ":x: **The bot is playing on another server**"
+	messageMaintenance  = ":construction: **The bot is under maintenance, the quiz can't be played now**"
+	messageStarted      = ":game_die: **Music quiz** of %d rounds by <@%s>! Guess the title (%d points) and the artist (%d point) " +
+		"of every excerpt right here, the song continues after the game"
+	messageRound    = ":musical_note: **Round %d of %d**, %d seconds to listen and %d more to answer"
+	messageGuessed  = ":white_check_mark: <@%s> guessed %s, **+%d**"
+	messageRoundWon = ":tada: It was `%s - %s`, guessed by <@%s>"
+	messageMissed   = ":hourglass: **Nobody guessed** `%s - %s`"
+	messageStopping = ":stop_button: **The quiz stops after this excerpt**"
+	messageNoScores = "**Nobody scored**"
+	messageUsage    = "`%[1]squiz start [rounds]` guess the songs of the server, %[2]d rounds by default and up to %[3]d\n" +
+		"`%[1]squiz stop` end the game\n" +
+		"`%[1]squiz top` the best players of the server"
+)
+
+// notifier posts the progress of the game to its channel
+type notifier struct {
+	service *Service
+	session *discordgo.Session
+}
+
+func (n *notifier) RoundStarted(g *quiz.Game, round int) {
+	n.service.sendStringMessage(n.session, g.ChannelID, fmt.Sprintf(messageRound, round, g.Rounds,
+		int(quiz.ExcerptLength.Seconds()), int(quiz.AnswerTime.Seconds())))
+}
+
+func (n *notifier) RoundEnded(g *quiz.Game, _ int, song *pkg.Song, title quiz.Guess) {
+	if title.UserID == "" {
+		n.service.sendStringMessage(n.session, g.ChannelID, fmt.Sprintf(messageMissed, song.ArtistName, song.Title))
+		return
+	}
+	n.service.sendStringMessage(n.session, g.ChannelID, fmt.Sprintf(messageRoundWon, song.ArtistName, song.Title, title.UserID))
+}
+
+func (n *notifier) Finished(g *quiz.Game, scores []quiz.Score) {
+	n.service.sendScoresMessage(n.session, g.ChannelID, "Quiz results", scores, false)
+}
+
+func (n *notifier) Failed(g *quiz.Game, err error) {
+	n.service.sendErrorMessage(n.session, g.ChannelID, err)
+}
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, channelID, msg string) {
+	s.sendComplexMessage(ds, channelID, &discordgo.MessageSend{Content: msg})
+}
+
+func (s *Service) sendUsageMessage(ds *discordgo.Session, channelID string) {
+	s.sendStringMessage(ds, channelID, fmt.Sprintf(messageUsage, s.prefix, quiz.DefaultRounds, quiz.MaxRounds))
+}
+
+func (s *Service) sendStartedMessage(ds *discordgo.Session, g *quiz.Game) {
+	s.sendStringMessage(ds, g.ChannelID, fmt.Sprintf(messageStarted, g.Rounds, g.StartedBy, quiz.TitlePoints, quiz.ArtistPoints))
+}
+
+func (s *Service) sendGuessMessage(ds *discordgo.Session, m *discordgo.MessageCreate, guess quiz.Guess) {
+	parts := make([]string, 0, 2)
+	if guess.Title {
+		parts = append(parts, "the title")
+	}
+	if guess.Artist {
+		parts = append(parts, "the artist")
+	}
+	s.sendStringMessage(ds, m.ChannelID, fmt.Sprintf(messageGuessed, guess.UserID, strings.Join(parts, " and "), guess.Points))
+}
+
+func (s *Service) sendLeaderboardMessage(ds *discordgo.Session, channelID string, scores []quiz.Score) {
+	s.sendScoresMessage(ds, channelID, "Quiz leaderboard", scores, true)
+}
+
+// sendScoresMessage the games and the wins are shown in the leaderboard
+func (s *Service) sendScoresMessage(ds *discordgo.Session, channelID, title string, scores []quiz.Score, total bool) {
+	if len(scores) == 0 {
+		s.sendStringMessage(ds, channelID, messageNoScores)
+		return
+	}
+	lines := make([]string, 0, len(scores))
+	for i, sc := range scores {
+		line := fmt.Sprintf("%d. <@%s> **%d** points", i+1, sc.UserID, sc.Points)
+		if total {
+			line += fmt.Sprintf(", %d wins in %d games", sc.Wins, sc.Games)
+		}
+		lines = append(lines, line)
+	}
+	s.sendComplexMessage(ds, channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{Title: title, Description: strings.Join(lines, "\n")}},
+	})
+}
+
+func (s *Service) sendErrorMessage(ds *discordgo.Session, channelID string, err error) {
+	var msg string
+	switch {
+	case errors.Is(err, quiz.ErrRunning), errors.Is(err, quiz.ErrNotRunning),
+		errors.Is(err, quiz.ErrInvalidRounds), errors.Is(err, quiz.ErrNoSongs):
+		msg = fmt.Sprintf(messageInvalidValue, err)
+	case errors.Is(err, player.ErrOtherGuild):
+		msg = messageOtherGuild
+	case errors.Is(err, player.ErrNotConnected):
+		msg = messageNotInVoice
+	case errors.Is(err, maintenance.ErrMaintenance):
+		msg = messageMaintenance
+	default:
+		s.logger.Error(errors.Wrap(err, "quiz"))
+		msg = discord.MessageInternalError
+	}
+	s.sendStringMessage(ds, channelID, msg)
+}
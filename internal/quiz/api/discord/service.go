@@ -0,0 +1,150 @@
+package discord
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/quiz"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "quiz"
+
+const (
+	quizCommand = "quiz"
+
+	start = "start"
+	stop  = "stop"
+	top   = "top"
+
+	// topPlayers in the leaderboard
+	topPlayers = 10
+)
+
+type Quiz interface {
+	Start(guildID, channelID, voiceID, userID string, rounds int, notifier quiz.Notifier) (*quiz.Game, error)
+	Game(guildID string) *quiz.Game
+	Stop(guildID string) error
+	Answer(guildID, channelID, userID, text string) (quiz.Guess, bool)
+	Leaderboard(ctx context.Context, guildID string, n int) ([]quiz.Score, error)
+}
+
+type Service struct {
+	ctx    context.Context
+	quiz   Quiz
+	prefix string
+	logger zap.Logger
+}
+
+func NewCog(ctx context.Context, quiz Quiz, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:    ctx,
+		quiz:   quiz,
+		prefix: prefix,
+		logger: logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+quizCommand, s.quizMessageHandler, debug).RegisterCommand(session, logger)
+	session.AddHandler(s.answerHandler)
+}
+
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// quizMessageHandler the game is started by a member in a voice channel and stopped by them or a server manager
+func (s *Service) quizMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+quizCommand))
+	if len(args) == 0 {
+		s.sendUsageMessage(ds, m.ChannelID)
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case start:
+		s.start(ds, m, args[1:])
+	case stop:
+		s.stop(ds, m)
+	case top:
+		scores, err := s.quiz.Leaderboard(s.ctx, m.GuildID, topPlayers)
+		if err != nil {
+			s.sendErrorMessage(ds, m.ChannelID, err)
+			return
+		}
+		s.sendLeaderboardMessage(ds, m.ChannelID, scores)
+	default:
+		s.sendUsageMessage(ds, m.ChannelID)
+	}
+}
+
+func (s *Service) start(ds *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	rounds := quiz.DefaultRounds
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			s.sendUsageMessage(ds, m.ChannelID)
+			return
+		}
+		rounds = n
+	}
+	voiceID, err := discord.VoiceChannelID(ds, m.GuildID, m.Author.ID)
+	if err != nil {
+		s.sendStringMessage(ds, m.ChannelID, messageNotInVoice)
+		return
+	}
+	g, err := s.quiz.Start(m.GuildID, m.ChannelID, voiceID, m.Author.ID, rounds, &notifier{service: s, session: ds})
+	if err != nil {
+		s.sendErrorMessage(ds, m.ChannelID, err)
+		return
+	}
+	s.sendStartedMessage(ds, g)
+}
+
+func (s *Service) stop(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	g := s.quiz.Game(m.GuildID)
+	if g == nil {
+		s.sendErrorMessage(ds, m.ChannelID, quiz.ErrNotRunning)
+		return
+	}
+	if g.StartedBy != m.Author.ID && !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m.ChannelID, messageNoPermission)
+		return
+	}
+	if err := s.quiz.Stop(m.GuildID); err != nil {
+		s.sendErrorMessage(ds, m.ChannelID, err)
+		return
+	}
+	s.sendStringMessage(ds, m.ChannelID, messageStopping)
+}
+
+// answerHandler every message in the channel of a game is an answer except the commands
+func (s *Service) answerHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot || !command.Handles(m.GuildID) {
+		return
+	}
+	if strings.HasPrefix(command.NormalizePrefix(m.GuildID, m.Content), s.prefix) {
+		return
+	}
+	guess, ok := s.quiz.Answer(m.GuildID, m.ChannelID, m.Author.ID, m.Content)
+	if !ok {
+		return
+	}
+	s.sendGuessMessage(ds, m, guess)
+}
@@ -0,0 +1,21 @@
+package quiz
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	gamesPlayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "quiz",
+		Name:      "games_total",
+		Help:      "Quiz games by how they ended: finished, stopped or failed.",
+	}, []string{"result"})
+	roundsPlayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "quiz",
+		Name:      "rounds_total",
+		Help:      "Quiz rounds by result, guessed when the title was guessed or missed.",
+	}, []string{"result"})
+)
@@ -0,0 +1,380 @@
+package quiz
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/maintenance"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/player"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	DefaultRounds = 5
+	MaxRounds     = 20
+	// ExcerptLength of the song played in a round
+	ExcerptLength = 20 * time.Second
+	// AnswerTime the answers are still taken after the excerpt
+	AnswerTime = 10 * time.Second
+	// TitlePoints for the first to guess the title, it ends the round
+	TitlePoints = 2
+	// ArtistPoints for the first to guess the artist
+	ArtistPoints = 1
+
+	// roundPause between the rounds for the results to be read
+	roundPause = 3 * time.Second
+	// extraSongs are picked for the songs which can't be streamed
+	extraSongs = 5
+	// saveTimeout the scores are saved even if the game was stopped by the shutdown
+	saveTimeout = 10 * time.Second
+)
+
+var (
+	ErrRunning       = errors.New("a quiz is already running on the server")
+	ErrNotRunning    = errors.New("no quiz is running on the server")
+	ErrInvalidRounds = errors.Errorf("rounds are from 1 to %d", MaxRounds)
+	ErrNoSongs       = errors.New("not enough songs in the library")
+)
+
+// Score of a member, in a game or in the leaderboard of the guild
+type Score struct {
+	GuildID string `firestore:"guild_id" json:"guild_id"`
+	UserID  string `firestore:"user_id" json:"user_id"`
+	Points  int    `firestore:"points" json:"points"`
+	Games   int    `firestore:"games" json:"games"`
+	Wins    int    `firestore:"wins" json:"wins"`
+}
+
+// Guess the part of the song the member was the first to guess
+type Guess struct {
+	UserID string
+	Title  bool
+	Artist bool
+	Points int
+}
+
+type Player interface {
+	Random(ctx context.Context, n int) ([]*pkg.Song, error)
+	Excerpt(ctx context.Context, song *pkg.Song, guildID, channelID string, length time.Duration, started func(song *pkg.Song)) error
+	Hold() (release func())
+}
+
+type Storage interface {
+	// AddScores adds the points of the game to the leaderboard, the winners get a win
+	AddScores(ctx context.Context, scores []Score) error
+	GuildScores(ctx context.Context, guildID string) ([]Score, error)
+}
+
+// Notifier of the game, the cog posts the messages to its channel
+type Notifier interface {
+	RoundStarted(g *Game, round int)
+	// RoundEnded the guess has no user if nobody guessed the title
+	RoundEnded(g *Game, round int, song *pkg.Song, title Guess)
+	Finished(g *Game, scores []Score)
+	Failed(g *Game, err error)
+}
+
+// Game of one guild, the answers are taken in its text channel
+type Game struct {
+	GuildID   string
+	ChannelID string
+	// StartedBy the member who can stop the game besides the server managers
+	StartedBy string
+	Rounds    int
+
+	cancel context.CancelFunc
+
+	mx       sync.Mutex
+	song     *pkg.Song
+	title    string
+	artist   string
+	titleBy  string
+	artistBy string
+	guessed  chan struct{}
+	points   map[string]int
+}
+
+// answer the title is guessed once, then the round ends
+func (g *Game) answer(userID, text string) (Guess, bool) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	if g.song == nil || g.titleBy != "" {
+		return Guess{}, false
+	}
+	guess := Guess{UserID: userID}
+	if g.artistBy == "" && matches(text, g.artist) {
+		g.artistBy = userID
+		guess.Artist = true
+		guess.Points += ArtistPoints
+	}
+	if matches(text, g.title) {
+		g.titleBy = userID
+		guess.Title = true
+		guess.Points += TitlePoints
+		close(g.guessed)
+	}
+	if guess.Points == 0 {
+		return Guess{}, false
+	}
+	g.points[userID] += guess.Points
+	return guess, true
+}
+
+func (g *Game) startRound(song *pkg.Song) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.song = song
+	g.title = title(song)
+	if g.title == "" {
+		g.title = clean(song.Title)
+	}
+	g.artist = artist(song)
+	g.titleBy, g.artistBy = "", ""
+	g.guessed = make(chan struct{})
+}
+
+// endRound no more answers are taken until the next round
+func (g *Game) endRound() Guess {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.song = nil
+	if g.titleBy == "" {
+		return Guess{}
+	}
+	return Guess{UserID: g.titleBy, Title: true, Points: TitlePoints}
+}
+
+// Scores of the game, the best first
+func (g *Game) Scores() []Score {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	scores := make([]Score, 0, len(g.points))
+	for userID, points := range g.points {
+		scores = append(scores, Score{GuildID: g.GuildID, UserID: userID, Points: points, Games: 1})
+	}
+	sortScores(scores)
+	for i := range scores {
+		if scores[i].Points == scores[0].Points {
+			scores[i].Wins = 1
+		}
+	}
+	return scores
+}
+
+// Service runs a game per guild, the music is held for the game and continues after it
+type Service struct {
+	ctx     context.Context
+	player  Player
+	storage Storage
+	logger  zap.Logger
+
+	mx    sync.Mutex
+	games map[string]*Game
+}
+
+func NewService(ctx context.Context, player Player, storage Storage, logger zap.Logger) *Service {
+	return &Service{
+		ctx:     ctx,
+		player:  player,
+		storage: storage,
+		logger:  logger,
+		games:   make(map[string]*Game),
+	}
+}
+
+// Start the game of the rounds in the background, the excerpts play in the voice channel
+func (s *Service) Start(guildID, channelID, voiceID, userID string, rounds int, notifier Notifier) (*Game, error) {
+	if rounds < 1 || rounds > MaxRounds {
+		return nil, ErrInvalidRounds
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if _, ok := s.games[guildID]; ok {
+		return nil, ErrRunning
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	g := &Game{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		StartedBy: userID,
+		Rounds:    rounds,
+		cancel:    cancel,
+		points:    make(map[string]int),
+	}
+	s.games[guildID] = g
+	go s.run(ctx, g, voiceID, notifier)
+	return g, nil
+}
+
+// Game running in the guild, nil if there is none
+func (s *Service) Game(guildID string) *Game {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.games[guildID]
+}
+
+// Stop the game after the current excerpt, the points are kept
+func (s *Service) Stop(guildID string) error {
+	g := s.Game(guildID)
+	if g == nil {
+		return ErrNotRunning
+	}
+	g.cancel()
+	return nil
+}
+
+// Answer of the member in the channel of the game, false if it guessed nothing
+func (s *Service) Answer(guildID, channelID, userID, text string) (Guess, bool) {
+	g := s.Game(guildID)
+	if g == nil || g.ChannelID != channelID {
+		return Guess{}, false
+	}
+	return g.answer(userID, text)
+}
+
+// Leaderboard of the guild, the n best
+func (s *Service) Leaderboard(ctx context.Context, guildID string, n int) ([]Score, error) {
+	scores, err := s.storage.GuildScores(ctx, guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "guild scores")
+	}
+	sortScores(scores)
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	return scores, nil
+}
+
+func (s *Service) run(ctx context.Context, g *Game, voiceID string, notifier Notifier) {
+	defer func() {
+		s.mx.Lock()
+		delete(s.games, g.GuildID)
+		s.mx.Unlock()
+		g.cancel()
+	}()
+	defer supervisor.Recover(s.logger, "quiz", "guild", g.GuildID)
+	release := s.player.Hold()
+	defer release()
+
+	if err := s.play(ctx, g, voiceID, notifier); err != nil {
+		gamesPlayed.WithLabelValues("failed").Inc()
+		notifier.Failed(g, err)
+		return
+	}
+	result := "finished"
+	if ctx.Err() != nil {
+		result = "stopped"
+	}
+	gamesPlayed.WithLabelValues(result).Inc()
+	scores := g.Scores()
+	if len(scores) > 0 {
+		saveCtx, cancel := context.WithTimeout(s.ctx, saveTimeout)
+		if err := s.storage.AddScores(saveCtx, scores); err != nil {
+			s.logger.Error(errors.Wrapf(err, "save quiz scores of %s", g.GuildID))
+		}
+		cancel()
+	}
+	notifier.Finished(g, scores)
+}
+
+// play the rounds, a song which can't be streamed is replaced by the next one
+func (s *Service) play(ctx context.Context, g *Game, voiceID string, notifier Notifier) error {
+	songs, err := s.player.Random(ctx, g.Rounds+extraSongs)
+	if err != nil {
+		return errors.Wrap(err, "random songs")
+	}
+	songs = unique(songs)
+	if len(songs) < g.Rounds {
+		return ErrNoSongs
+	}
+	round := 1
+	for _, song := range songs {
+		if round > g.Rounds || ctx.Err() != nil {
+			return nil
+		}
+		g.startRound(song)
+		err := s.player.Excerpt(ctx, song, g.GuildID, voiceID, ExcerptLength, func(_ *pkg.Song) {
+			notifier.RoundStarted(g, round)
+		})
+		if err != nil {
+			g.endRound()
+			switch {
+			case ctx.Err() != nil:
+				return nil
+			case errors.Is(err, player.ErrOtherGuild), errors.Is(err, player.ErrNotConnected), errors.Is(err, maintenance.ErrMaintenance):
+				return err
+			}
+			// the song can't be streamed, the others may
+			s.logger.Warnw("quiz song skipped", "song", song.ID, "err", err)
+			continue
+		}
+		s.wait(ctx, g)
+		guess := g.endRound()
+		if guess.Title {
+			roundsPlayed.WithLabelValues("guessed").Inc()
+		} else {
+			roundsPlayed.WithLabelValues("missed").Inc()
+		}
+		notifier.RoundEnded(g, round, song, guess)
+		round++
+		if round <= g.Rounds {
+			sleep(ctx, roundPause)
+		}
+	}
+	if round <= g.Rounds {
+		return ErrNoSongs
+	}
+	return nil
+}
+
+// wait for the title or until the answer time is over
+func (s *Service) wait(ctx context.Context, g *Game) {
+	g.mx.Lock()
+	guessed := g.guessed
+	g.mx.Unlock()
+	timer := time.NewTimer(AnswerTime)
+	defer timer.Stop()
+	select {
+	case <-guessed:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func unique(songs []*pkg.Song) []*pkg.Song {
+	seen := make(map[pkg.SongID]struct{}, len(songs))
+	res := make([]*pkg.Song, 0, len(songs))
+	for _, song := range songs {
+		if _, ok := seen[song.ID]; ok {
+			continue
+		}
+		seen[song.ID] = struct{}{}
+		res = append(res, song)
+	}
+	return res
+}
+
+// sortScores the most points first, then the most wins
+func sortScores(scores []Score) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Points != scores[j].Points {
+			return scores[i].Points > scores[j].Points
+		}
+		return scores[i].Wins > scores[j].Wins
+	})
+}
@@ -0,0 +1,89 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/quiz"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const scoresCollection = "quiz_scores"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func scoreDoc(guildID, userID string) string {
+	return guildID + "_" + userID
+}
+
+// AddScores the docs of the new players are created by the first write
+func (s *Storage) AddScores(ctx context.Context, scores []quiz.Score) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: AddScores players:%d", len(scores))
+	batch := s.client.Batch()
+	for _, sc := range scores {
+		batch.Set(s.client.Collection(scoresCollection).Doc(scoreDoc(sc.GuildID, sc.UserID)), map[string]interface{}{
+			"guild_id": sc.GuildID,
+			"user_id":  sc.UserID,
+			"points":   firestore.Increment(sc.Points),
+			"games":    firestore.Increment(sc.Games),
+			"wins":     firestore.Increment(sc.Wins),
+		}, firestore.MergeAll)
+	}
+	if _, err := batch.Commit(ctx); err != nil {
+		return errors.Wrapf(err, "failed to add %d scores to %s", len(scores), scoresCollection)
+	}
+	return nil
+}
+
+func (s *Storage) GuildScores(ctx context.Context, guildID string) ([]quiz.Score, error) {
+	return s.scores(s.client.Collection(scoresCollection).Where("guild_id", "==", guildID).Documents(ctx))
+}
+
+func (s *Storage) UserScores(ctx context.Context, userID string) ([]quiz.Score, error) {
+	return s.scores(s.client.Collection(scoresCollection).Where("user_id", "==", userID).Documents(ctx))
+}
+
+// DeleteUserScores in all guilds
+func (s *Storage) DeleteUserScores(ctx context.Context, userID string) (int, error) {
+	scores, err := s.UserScores(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i, sc := range scores {
+		if _, err := s.client.Collection(scoresCollection).Doc(scoreDoc(sc.GuildID, userID)).Delete(ctx); err != nil {
+			return i, errors.Wrapf(err, "failed to delete score of %s in %s", userID, sc.GuildID)
+		}
+	}
+	return len(scores), nil
+}
+
+func (s *Storage) scores(iter *firestore.DocumentIterator) ([]quiz.Score, error) {
+	defer iter.Stop()
+	res := make([]quiz.Score, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var sc quiz.Score
+		if err := doc.DataTo(&sc); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, sc)
+	}
+	return res, nil
+}
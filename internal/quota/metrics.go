@@ -0,0 +1,21 @@
+package quota
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	exceeded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "quota",
+		Name:      "exceeded_total",
+		Help:      "Song requests refused by the daily quota of the user.",
+	})
+	reset = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "quota",
+		Name:      "reset_total",
+		Help:      "Daily quotas of the users deleted by the reset job.",
+	})
+)
@@ -0,0 +1,69 @@
+package quota
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DateLayout of the days of the quotas, the days are in UTC
+const DateLayout = "2006-01-02"
+
+var ErrExceeded = errors.New("daily request quota exceeded")
+
+type Storage interface {
+	// Take adds a request of the day, ErrExceeded if the user made the limit already, it returns the requests left
+	Take(ctx context.Context, guildID, userID, date string, limit int) (int, error)
+	// Refund a request of the day
+	Refund(ctx context.Context, guildID, userID, date string) error
+	// Reset deletes the requests of the days before the date
+	Reset(ctx context.Context, date string) (int, error)
+}
+
+// Service counts the songs every user requested in a guild today
+type Service struct {
+	storage Storage
+}
+
+func NewService(storage Storage) *Service {
+	return &Service{storage: storage}
+}
+
+// Take a request of the user, unlimited if the limit is 0, it returns the requests left today
+func (s *Service) Take(ctx context.Context, guildID, userID string, limit int) (int, error) {
+	if limit <= 0 {
+		return -1, nil
+	}
+	left, err := s.storage.Take(ctx, guildID, userID, today(), limit)
+	if errors.Is(err, ErrExceeded) {
+		exceeded.Inc()
+		return 0, ErrExceeded
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "take request quota")
+	}
+	return left, nil
+}
+
+// Refund the request which didn't queue a song
+func (s *Service) Refund(ctx context.Context, guildID, userID string, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+	return errors.Wrap(s.storage.Refund(ctx, guildID, userID, today()), "refund request quota")
+}
+
+// Reset the quotas of the previous days, the counters of today stay
+func (s *Service) Reset(ctx context.Context) error {
+	n, err := s.storage.Reset(ctx, today())
+	if err != nil {
+		return errors.Wrap(err, "reset request quotas")
+	}
+	reset.Add(float64(n))
+	return nil
+}
+
+func today() string {
+	return time.Now().UTC().Format(DateLayout)
+}
@@ -0,0 +1,160 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/quota"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const quotasCollection = "request_quotas"
+
+// Usage of a user in a guild, only the day of the date counts
+type Usage struct {
+	GuildID  string `firestore:"guild_id" json:"guild_id"`
+	UserID   string `firestore:"user_id" json:"user_id"`
+	Date     string `firestore:"date" json:"date"`
+	Requests int    `firestore:"requests" json:"requests"`
+}
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) doc(guildID, userID string) *firestore.DocumentRef {
+	return s.client.Collection(quotasCollection).Doc(guildID + "_" + userID)
+}
+
+// Take the transaction keeps the concurrent requests of the user within the limit
+func (s *Storage) Take(ctx context.Context, guildID, userID, date string, limit int) (int, error) {
+	ref := s.doc(guildID, userID)
+	left := 0
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		u, err := get(tx, ref)
+		if err != nil {
+			return err
+		}
+		if u.Date != date {
+			u = Usage{GuildID: guildID, UserID: userID, Date: date}
+		}
+		if u.Requests >= limit {
+			return quota.ErrExceeded
+		}
+		u.Requests++
+		left = limit - u.Requests
+		return tx.Set(ref, u)
+	})
+	if errors.Is(err, quota.ErrExceeded) {
+		return 0, quota.ErrExceeded
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to take %s_%s in %s", guildID, userID, quotasCollection)
+	}
+	return left, nil
+}
+
+func (s *Storage) Refund(ctx context.Context, guildID, userID, date string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: Refund request guild:%s user:%s", guildID, userID)
+	ref := s.doc(guildID, userID)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		u, err := get(tx, ref)
+		if err != nil {
+			return err
+		}
+		if u.Date != date || u.Requests == 0 {
+			return nil
+		}
+		u.Requests--
+		return tx.Set(ref, u)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to refund %s_%s in %s", guildID, userID, quotasCollection)
+	}
+	return nil
+}
+
+func (s *Storage) Reset(ctx context.Context, date string) (int, error) {
+	iter := s.client.Collection(quotasCollection).Where("date", "<", date).Documents(ctx)
+	defer iter.Stop()
+	n := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return n, errors.Wrapf(err, "failed to get old %s", quotasCollection)
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return n, errors.Wrapf(err, "failed to delete %s from %s", doc.Ref.ID, quotasCollection)
+		}
+		n++
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: Reset %d request quotas before %s", n, date)
+	return n, nil
+}
+
+// UserUsage in all guilds, for the export of the user data
+func (s *Storage) UserUsage(ctx context.Context, userID string) ([]Usage, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: UserUsage %s", userID)
+	iter := s.client.Collection(quotasCollection).Where("user_id", "==", userID).Documents(ctx)
+	defer iter.Stop()
+	res := make([]Usage, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s of %s", quotasCollection, userID)
+		}
+		var u Usage
+		if err := doc.DataTo(&u); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, u)
+	}
+	return res, nil
+}
+
+// DeleteUserUsage in all guilds, the next request of the user starts the day over
+func (s *Storage) DeleteUserUsage(ctx context.Context, userID string) (int, error) {
+	usages, err := s.UserUsage(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range usages {
+		if _, err := s.doc(usages[i].GuildID, userID).Delete(ctx); err != nil {
+			return i, errors.Wrapf(err, "failed to delete %s_%s from %s", usages[i].GuildID, userID, quotasCollection)
+		}
+	}
+	return len(usages), nil
+}
+
+// get the empty usage if the user made no requests yet
+func get(tx *firestore.Transaction, ref *firestore.DocumentRef) (Usage, error) {
+	var u Usage
+	doc, err := tx.Get(ref)
+	if status.Code(err) == codes.NotFound {
+		return u, nil
+	}
+	if err != nil {
+		return u, err
+	}
+	if err := doc.DataTo(&u); err != nil {
+		return u, errors.Wrap(err, "unable to marshal data")
+	}
+	return u, nil
+}
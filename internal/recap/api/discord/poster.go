@@ -0,0 +1,173 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/internal/recap"
+	"github.com/HalvaPovidlo/discordBotGo/internal/scheduler"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// recapSpec the first day of the month at noon
+const recapSpec = "0 12 1 * *"
+
+type Jobs interface {
+	Add(name, spec string, job scheduler.Job) error
+}
+
+type Recaps interface {
+	Guild(ctx context.Context, guildID string, month time.Time) (*recap.Recap, error)
+	User(ctx context.Context, userID string, month time.Time) (*recap.Recap, error)
+}
+
+type GuildSettings interface {
+	Get(guildID string) guild.Settings
+	Enabled(guildID string, f guild.Flag) bool
+}
+
+// Poster of the monthly recaps in the guilds with the recap feature
+type Poster struct {
+	recaps   Recaps
+	settings GuildSettings
+	logger   zap.Logger
+}
+
+func NewPoster(recaps Recaps, settings GuildSettings, logger zap.Logger) *Poster {
+	return &Poster{
+		recaps:   recaps,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+func (p *Poster) RegisterJobs(session *discordgo.Session, jobs Jobs) error {
+	return jobs.Add("music-recap", recapSpec, func(ctx context.Context) error {
+		return p.post(contexts.WithLogger(ctx, p.logger), session)
+	})
+}
+
+// post the recaps of the previous month, a user is sent one DM even if the user is on top in several guilds
+func (p *Poster) post(ctx context.Context, session *discordgo.Session) error {
+	month, err := recap.ParseMonth("")
+	if err != nil {
+		return err
+	}
+	session.State.RLock()
+	guilds := make([]*discordgo.Guild, len(session.State.Guilds))
+	copy(guilds, session.State.Guilds)
+	session.State.RUnlock()
+	notified := make(map[string]bool)
+	for _, g := range guilds {
+		channelID := p.settings.Get(g.ID).AnnounceChannel
+		if channelID == "" || !p.settings.Enabled(g.ID, guild.Recap) {
+			continue
+		}
+		r, err := p.recaps.Guild(ctx, g.ID, month)
+		if err != nil {
+			return errors.Wrapf(err, "recap of guild %s", g.ID)
+		}
+		if r.Empty() {
+			continue
+		}
+		if _, err := session.ChannelMessageSendComplex(channelID, guildMessage(g.Name, r)); err != nil {
+			p.logger.Error(errors.Wrapf(err, "post recap in %s", channelID))
+		}
+		for i := range r.TopRequesters {
+			userID := r.TopRequesters[i].UserID
+			if notified[userID] {
+				continue
+			}
+			notified[userID] = true
+			if err := p.sendUser(ctx, session, userID, month); err != nil {
+				p.logger.Error(err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Poster) sendUser(ctx context.Context, session *discordgo.Session, userID string, month time.Time) error {
+	r, err := p.recaps.User(ctx, userID, month)
+	if err != nil {
+		return errors.Wrapf(err, "recap of user %s", userID)
+	}
+	channel, err := session.UserChannelCreate(userID)
+	if err != nil {
+		return errors.Wrapf(err, "open dm with %s", userID)
+	}
+	if _, err := session.ChannelMessageSendComplex(channel.ID, userMessage(r)); err != nil {
+		return errors.Wrapf(err, "dm %s", userID)
+	}
+	return nil
+}
+
+func guildMessage(name string, r *recap.Recap) *discordgo.MessageSend {
+	fields := commonFields(r)
+	fields = append(fields, &discordgo.MessageEmbedField{Name: "Listeners", Value: fmt.Sprint(r.Listeners), Inline: true})
+	if len(r.TopRequesters) != 0 {
+		lines := make([]string, 0, len(r.TopRequesters))
+		for i := range r.TopRequesters {
+			lines = append(lines, fmt.Sprintf("%d. <@%s> %d songs", i+1, r.TopRequesters[i].UserID, r.TopRequesters[i].Plays))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Top requesters", Value: strings.Join(lines, "\n")})
+	}
+	return &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Monthly recap of " + name,
+				Description: monthName(r.Month),
+				Fields:      fields,
+			},
+		},
+	}
+}
+
+func userMessage(r *recap.Recap) *discordgo.MessageSend {
+	return &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Your monthly recap",
+				Description: monthName(r.Month),
+				Fields:      commonFields(r),
+			},
+		},
+	}
+}
+
+func commonFields(r *recap.Recap) []*discordgo.MessageEmbedField {
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Songs", Value: fmt.Sprint(r.Plays), Inline: true},
+		{Name: "Hours", Value: fmt.Sprintf("%.1f", r.Hours), Inline: true},
+	}
+	if r.MostActiveDay != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Most active day",
+			Value:  fmt.Sprintf("%s, %d songs", r.MostActiveDay, r.MostActiveDayPlays),
+			Inline: true,
+		})
+	}
+	if len(r.TopSongs) != 0 {
+		lines := make([]string, 0, len(r.TopSongs))
+		for i := range r.TopSongs {
+			lines = append(lines, fmt.Sprintf("%d. %s, %d plays", i+1, r.TopSongs[i].Title, r.TopSongs[i].Plays))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Top songs", Value: strings.Join(lines, "\n")})
+	}
+	return fields
+}
+
+func monthName(month string) string {
+	t, err := time.Parse(recap.MonthLayout, month)
+	if err != nil {
+		return month
+	}
+	return t.Format("January 2006")
+}
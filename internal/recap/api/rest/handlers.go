@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/recap"
+)
+
+// guild godoc
+// @summary  Top songs, hours, listeners, top requesters and the most active day of the guild in the month
+// @produce  json
+// @param    id     path      string  true   "Guild ID"
+// @param    month  query     string  false  "YYYY-MM in UTC, the previous month by default"
+// @success  200    {object}  recap.Recap
+// @failure  400    {object}  Response  "Incorrect month"
+// @failure  503    {object}  Response  "Firestore is unavailable"
+// @failure  500    {object}  Response  "Database error"
+// @router   /guilds/{id}/recap [get]
+func (h *Handler) guildHandler(c *gin.Context) {
+	month, err := recap.ParseMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	r, err := h.recaps.Guild(c.Request.Context(), c.Param("id"), month)
+	h.respond(c, r, err)
+}
+
+// user godoc
+// @summary  Top songs, hours in the voice channel and the most active day of the user in the month
+// @produce  json
+// @param    id     path      string  true   "Discord user ID or me for the linked account"
+// @param    month  query     string  false  "YYYY-MM in UTC, the previous month by default"
+// @success  200    {object}  recap.Recap
+// @failure  400    {object}  Response  "Incorrect month"
+// @failure  401    {object}  Response  "The client isn't linked"
+// @failure  503    {object}  Response  "Firestore is unavailable"
+// @failure  500    {object}  Response  "Database error"
+// @router   /users/{id}/recap [get]
+func (h *Handler) userHandler(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "me" {
+		userID = v1.UserID(c)
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, Response{Message: "link the account first"})
+			return
+		}
+	}
+	month, err := recap.ParseMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	r, err := h.recaps.User(c.Request.Context(), userID, month)
+	h.respond(c, r, err)
+}
+
+func (h *Handler) respond(c *gin.Context, r *recap.Recap, err error) {
+	if errors.Is(err, firestore.ErrOffline) {
+		c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
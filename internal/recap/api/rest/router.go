@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/recap"
+)
+
+type Recaps interface {
+	Guild(ctx context.Context, guildID string, month time.Time) (*recap.Recap, error)
+	User(ctx context.Context, userID string, month time.Time) (*recap.Recap, error)
+}
+
+type Handler struct {
+	recaps Recaps
+	super  *gin.RouterGroup
+}
+
+func NewHandler(recaps Recaps, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		recaps: recaps,
+		super:  superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	h.super.GET("/guilds/:id/recap", h.guildHandler)
+	h.super.GET("/users/:id/recap", v1.RequireScope(account.ScopeProfile), h.userHandler)
+	return h.super
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,216 @@
+package recap
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/listening"
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	// MonthLayout of the months in the queries, the months are in UTC
+	MonthLayout = "2006-01"
+
+	topSongs      = 5
+	topRequesters = 5
+	// cacheTTL the current month changes, the past ones are read from Firestore at most this often as well
+	cacheTTL = time.Hour
+)
+
+var ErrInvalidMonth = errors.New("the month is YYYY-MM and it has started")
+
+type Plays interface {
+	GuildPlays(ctx context.Context, guildID string, from, to time.Time) ([]pkg.Play, error)
+	UserPlays(ctx context.Context, userID string, from, to time.Time) ([]pkg.Play, error)
+}
+
+type Listening interface {
+	Days(ctx context.Context, userID, from, to string) ([]listening.Day, error)
+}
+
+type Song struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Plays int    `json:"plays"`
+}
+
+type Requester struct {
+	UserID string `json:"user_id"`
+	Plays  int    `json:"plays"`
+}
+
+// Recap of a guild or a user over a month
+type Recap struct {
+	GuildID string `json:"guild_id,omitempty"`
+	UserID  string `json:"user_id,omitempty"`
+	Month   string `json:"month"`
+	Plays   int    `json:"plays"`
+	// Hours of the requested songs in the guild, the hours in the voice channel for the user
+	Hours float64 `json:"hours"`
+	// Listeners requested a song in the guild
+	Listeners          int         `json:"listeners,omitempty"`
+	TopSongs           []Song      `json:"top_songs"`
+	TopRequesters      []Requester `json:"top_requesters,omitempty"`
+	MostActiveDay      string      `json:"most_active_day,omitempty"`
+	MostActiveDayPlays int         `json:"most_active_day_plays,omitempty"`
+}
+
+func (r *Recap) Empty() bool {
+	return r.Plays == 0 && r.Hours == 0
+}
+
+// ParseMonth the first day of the month, the empty month is the previous one
+func ParseMonth(month string) (time.Time, error) {
+	now := time.Now().UTC()
+	if month == "" {
+		return time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	t, err := time.Parse(MonthLayout, month)
+	if err != nil {
+		return t, errors.Wrap(ErrInvalidMonth, err.Error())
+	}
+	if t.After(now) {
+		return t, ErrInvalidMonth
+	}
+	return t, nil
+}
+
+type cached struct {
+	recap   *Recap
+	expires time.Time
+}
+
+// Service computes the recaps from the history of the requested songs and the listening time
+type Service struct {
+	plays     Plays
+	listening Listening
+
+	mx    sync.Mutex
+	cache map[string]cached // guild or user, the id and the month
+}
+
+func NewService(plays Plays, listening Listening) *Service {
+	return &Service{
+		plays:     plays,
+		listening: listening,
+		cache:     make(map[string]cached),
+	}
+}
+
+// Guild recap of the month starting at the time from ParseMonth
+func (s *Service) Guild(ctx context.Context, guildID string, month time.Time) (*Recap, error) {
+	return s.get("guild/"+guildID+"/"+month.Format(MonthLayout), func() (*Recap, error) {
+		plays, err := s.plays.GuildPlays(ctx, guildID, month, month.AddDate(0, 1, 0))
+		if err != nil {
+			return nil, errors.Wrap(err, "guild plays")
+		}
+		r := compute(month, plays)
+		r.GuildID = guildID
+		requesters := make(map[string]int)
+		for i := range plays {
+			r.Hours += plays[i].Duration / time.Hour.Seconds()
+			if plays[i].UserID != "" {
+				requesters[plays[i].UserID]++
+			}
+		}
+		r.Listeners = len(requesters)
+		r.TopRequesters = make([]Requester, 0, len(requesters))
+		for id, n := range requesters {
+			r.TopRequesters = append(r.TopRequesters, Requester{UserID: id, Plays: n})
+		}
+		sort.Slice(r.TopRequesters, func(i, j int) bool {
+			if r.TopRequesters[i].Plays != r.TopRequesters[j].Plays {
+				return r.TopRequesters[i].Plays > r.TopRequesters[j].Plays
+			}
+			return r.TopRequesters[i].UserID < r.TopRequesters[j].UserID
+		})
+		if len(r.TopRequesters) > topRequesters {
+			r.TopRequesters = r.TopRequesters[:topRequesters]
+		}
+		return r, nil
+	})
+}
+
+// User recap of the month in all guilds
+func (s *Service) User(ctx context.Context, userID string, month time.Time) (*Recap, error) {
+	return s.get("user/"+userID+"/"+month.Format(MonthLayout), func() (*Recap, error) {
+		plays, err := s.plays.UserPlays(ctx, userID, month, month.AddDate(0, 1, 0))
+		if err != nil {
+			return nil, errors.Wrap(err, "user plays")
+		}
+		last := month.AddDate(0, 1, -1)
+		days, err := s.listening.Days(ctx, userID, month.Format(listening.DateLayout), last.Format(listening.DateLayout))
+		if err != nil {
+			return nil, errors.Wrap(err, "listening days")
+		}
+		r := compute(month, plays)
+		r.UserID = userID
+		for i := range days {
+			r.Hours += days[i].Seconds / time.Hour.Seconds()
+		}
+		return r, nil
+	})
+}
+
+func (s *Service) get(key string, build func() (*Recap, error)) (*Recap, error) {
+	now := time.Now()
+	s.mx.Lock()
+	for k, v := range s.cache {
+		if now.After(v.expires) {
+			delete(s.cache, k)
+		}
+	}
+	c, ok := s.cache[key]
+	s.mx.Unlock()
+	if ok {
+		return c.recap, nil
+	}
+	r, err := build()
+	if err != nil {
+		return nil, err
+	}
+	s.mx.Lock()
+	s.cache[key] = cached{recap: r, expires: now.Add(cacheTTL)}
+	s.mx.Unlock()
+	return r, nil
+}
+
+// compute the top songs and the most active day of the plays
+func compute(month time.Time, plays []pkg.Play) *Recap {
+	r := &Recap{Month: month.Format(MonthLayout), Plays: len(plays)}
+	songs := make(map[string]*Song)
+	days := make(map[string]int)
+	for i := range plays {
+		p := &plays[i]
+		song, ok := songs[p.SongID]
+		if !ok {
+			song = &Song{ID: p.SongID, Title: p.Title}
+			songs[p.SongID] = song
+		}
+		song.Plays++
+		day := p.Time.UTC().Format(listening.DateLayout)
+		days[day]++
+		if days[day] > r.MostActiveDayPlays || days[day] == r.MostActiveDayPlays && day < r.MostActiveDay {
+			r.MostActiveDay, r.MostActiveDayPlays = day, days[day]
+		}
+	}
+	r.TopSongs = make([]Song, 0, len(songs))
+	for _, song := range songs {
+		r.TopSongs = append(r.TopSongs, *song)
+	}
+	sort.Slice(r.TopSongs, func(i, j int) bool {
+		if r.TopSongs[i].Plays != r.TopSongs[j].Plays {
+			return r.TopSongs[i].Plays > r.TopSongs[j].Plays
+		}
+		return r.TopSongs[i].Title < r.TopSongs[j].Title
+	})
+	if len(r.TopSongs) > topSongs {
+		r.TopSongs = r.TopSongs[:topSongs]
+	}
+	return r
+}
@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/reload"
+)
+
+// caches godoc
+// @summary   Names of the memory caches which can be reloaded
+// @produce   json
+// @security  AdminToken
+// @success   200  {object}  CachesResponse
+// @failure   401  {object}  Response  "Wrong admin token"
+// @router    /admin/caches [get]
+func (h *AdminHandler) cachesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, CachesResponse{Caches: h.caches.Names()})
+}
+
+// reload godoc
+// @summary   Read the caches from the storage again, e.g. after the documents were changed by hand
+// @accept    json
+// @produce   json
+// @security  AdminToken
+// @param     request  body      reloadRequest   false  "The caches, all of them if empty"
+// @success   200      {object}  CachesResponse  "The reloaded caches"
+// @failure   400      {object}  Response        "Unknown cache"
+// @failure   401      {object}  Response        "Wrong admin token"
+// @failure   500      {object}  Response        "A cache couldn't be read, the ones before it are reloaded"
+// @router    /admin/caches/reload [post]
+func (h *AdminHandler) reloadHandler(c *gin.Context) {
+	var req reloadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+			return
+		}
+	}
+	reloaded, err := h.caches.Reload(c.Request.Context(), req.Caches...)
+	if errors.Is(err, reload.ErrUnknown) {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, CachesResponse{Caches: reloaded})
+}
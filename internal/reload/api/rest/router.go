@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Caches interface {
+	Names() []string
+	Reload(ctx context.Context, names ...string) ([]string, error)
+}
+
+// AdminHandler of the memory caches, it is mounted on the admin group
+type AdminHandler struct {
+	caches Caches
+	super  *gin.RouterGroup
+}
+
+func NewAdminHandler(caches Caches, superGroup *gin.RouterGroup) *AdminHandler {
+	return &AdminHandler{
+		caches: caches,
+		super:  superGroup,
+	}
+}
+
+func (h *AdminHandler) Router() *gin.RouterGroup {
+	group := h.super.Group("/caches")
+	group.GET("", h.cachesHandler)
+	group.POST("/reload", h.reloadHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
+
+type CachesResponse struct {
+	Caches []string `json:"caches"`
+}
+
+type reloadRequest struct {
+	// Caches to reload, all of them if empty
+	Caches []string `json:"caches"`
+}
@@ -0,0 +1,69 @@
+package reload
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Loader reads the cache from the storage again
+type Loader func(ctx context.Context) error
+
+// ErrUnknown there is no cache with the name
+var ErrUnknown = errors.New("unknown cache")
+
+// Registry of the memory caches, the operators reload them after changing the stored documents by hand
+type Registry struct {
+	mx      sync.RWMutex
+	loaders map[string]Loader
+}
+
+func NewRegistry() *Registry {
+	return &Registry{loaders: make(map[string]Loader)}
+}
+
+func (r *Registry) Add(name string, l Loader) {
+	r.mx.Lock()
+	r.loaders[name] = l
+	r.mx.Unlock()
+}
+
+// Names of the caches sorted
+func (r *Registry) Names() []string {
+	r.mx.RLock()
+	names := make([]string, 0, len(r.loaders))
+	for name := range r.loaders {
+		names = append(names, name)
+	}
+	r.mx.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Reload the caches by name, all of them if none is given. It stops at the first error and returns the reloaded ones.
+func (r *Registry) Reload(ctx context.Context, names ...string) ([]string, error) {
+	if len(names) == 0 {
+		names = r.Names()
+	}
+	r.mx.RLock()
+	loaders := make([]Loader, 0, len(names))
+	for _, name := range names {
+		l, ok := r.loaders[name]
+		if !ok {
+			r.mx.RUnlock()
+			return nil, errors.Wrap(ErrUnknown, name)
+		}
+		loaders = append(loaders, l)
+	}
+	r.mx.RUnlock()
+	reloaded := make([]string, 0, len(names))
+	for i, l := range loaders {
+		if err := l(ctx); err != nil {
+			return reloaded, errors.Wrapf(err, "reload %s", names[i])
+		}
+		reloaded = append(reloaded, names[i])
+	}
+	return reloaded, nil
+}
@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/reminder"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
+)
+
+const (
+	messageNoPermission      = ":x: **Only server managers can change the announcements**"
+	messageInvalidValue      = ":x: **%s**"
+	messageNotFound          = ":x: **No reminder at the position, see the list**"
+	messageReminderAdded     = ":alarm_clock: **I will remind you** <t:%d:R>"
+	messageAnnouncementAdded = ":bell: **Scheduled** %s, next <t:%d:R>"
+	messageDeleted           = ":x: **Deleted** %s"
+	messageNoReminders       = "**Nothing is scheduled**"
+	messageUsage             = "`%[1]sremind` show your reminders and the announcements of the server\n" +
+		"`%[1]sremind me in <30m|2h|1d12h> <text>` remind you in the channel\n" +
+		"`%[1]sremind me at <HH:MM> <text>` remind you at the time of the server\n" +
+		"`%[1]sremind here <HH:MM> <daily|weekdays|weekends|mon,wed,...> <text>` announce in the channel\n" +
+		"`%[1]sremind delete <n>` delete the reminder"
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, m *discordgo.MessageCreate, msg string) {
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{Content: msg})
+}
+
+func (s *Service) sendUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageUsage, s.prefix))
+}
+
+func (s *Service) sendRemindersMessage(ds *discordgo.Session, m *discordgo.MessageCreate, reminders []reminder.Reminder) {
+	if len(reminders) == 0 {
+		s.sendStringMessage(ds, m, messageNoReminders)
+		return
+	}
+	lines := make([]string, 0, len(reminders))
+	for i := range reminders {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, reminderLine(&reminders[i])))
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{Title: "Reminders", Description: strings.Join(lines, "\n")}},
+	})
+}
+
+func reminderLine(r *reminder.Reminder) string {
+	text := util.StandardizeSpaces(r.Text)
+	if runes := []rune(text); len(runes) > 50 {
+		text = string(runes[:50]) + "…"
+	}
+	if !r.Recurring() {
+		return fmt.Sprintf("<t:%d:f> %s", r.Next.Unix(), text)
+	}
+	zone := r.Timezone
+	if zone == "" {
+		zone = "UTC"
+	}
+	return fmt.Sprintf("`%s %s` %s in <#%s> %s", r.Time, r.Days, zone, r.ChannelID, text)
+}
@@ -0,0 +1,184 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/reminder"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "reminders"
+
+const (
+	remind = "remind"
+
+	me     = "me"
+	in     = "in"
+	at     = "at"
+	here   = "here"
+	remove = "delete"
+)
+
+type Reminders interface {
+	List(ctx context.Context, guildID, userID string) ([]reminder.Reminder, error)
+	RemindIn(ctx context.Context, guildID, channelID, userID string, delay time.Duration, text string) (*reminder.Reminder, error)
+	RemindAt(ctx context.Context, guildID, channelID, userID, clock, text string) (*reminder.Reminder, error)
+	Announce(ctx context.Context, guildID, channelID, userID, clock, days, text string) (*reminder.Reminder, error)
+	Delete(ctx context.Context, guildID, userID string, pos int, manager bool) (*reminder.Reminder, error)
+}
+
+type Service struct {
+	ctx       context.Context
+	reminders Reminders
+	prefix    string
+	logger    zap.Logger
+}
+
+func NewCog(ctx context.Context, reminders Reminders, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:       ctx,
+		reminders: reminders,
+		prefix:    prefix,
+		logger:    logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+remind, s.remindMessageHandler, debug).RegisterCommand(session, logger)
+}
+
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// remindMessageHandler lists the reminders, adds the personal ones and the announcements of the server managers
+func (s *Service) remindMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(m.Content, s.prefix+remind))
+	if rest == "" {
+		reminders, err := s.reminders.List(s.ctx, m.GuildID, m.Author.ID)
+		if err != nil {
+			s.sendStringMessage(ds, m, s.errorMessage(err))
+			return
+		}
+		s.sendRemindersMessage(ds, m, reminders)
+		return
+	}
+	target, rest := nextWord(rest)
+	switch strings.ToLower(target) {
+	case me:
+		s.remindMe(ds, m, rest)
+	case here:
+		s.announce(ds, m, rest)
+	case remove:
+		pos, err := strconv.Atoi(rest)
+		if err != nil {
+			s.sendUsageMessage(ds, m)
+			return
+		}
+		manager := discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer)
+		r, err := s.reminders.Delete(s.ctx, m.GuildID, m.Author.ID, pos-1, manager)
+		if err != nil {
+			s.sendStringMessage(ds, m, s.errorMessage(err))
+			return
+		}
+		s.sendStringMessage(ds, m, fmt.Sprintf(messageDeleted, reminderLine(r)))
+	default:
+		s.sendUsageMessage(ds, m)
+	}
+}
+
+// remindMe in <delay> <text> or at <HH:MM> <text>
+func (s *Service) remindMe(ds *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	when, args := nextWord(args)
+	value, text := nextWord(args)
+	if value == "" || text == "" {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	var (
+		r   *reminder.Reminder
+		err error
+	)
+	switch strings.ToLower(when) {
+	case in:
+		var delay time.Duration
+		if delay, err = reminder.ParseDelay(value); err == nil {
+			r, err = s.reminders.RemindIn(s.ctx, m.GuildID, m.ChannelID, m.Author.ID, delay, text)
+		}
+	case at:
+		r, err = s.reminders.RemindAt(s.ctx, m.GuildID, m.ChannelID, m.Author.ID, value, text)
+	default:
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	if err != nil {
+		s.sendStringMessage(ds, m, s.errorMessage(err))
+		return
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageReminderAdded, r.Next.Unix()))
+}
+
+// announce <HH:MM> <days> <text> in the channel of the message
+func (s *Service) announce(ds *discordgo.Session, m *discordgo.MessageCreate, args string) {
+	clock, args := nextWord(args)
+	days, text := nextWord(args)
+	if days == "" || text == "" {
+		s.sendUsageMessage(ds, m)
+		return
+	}
+	if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+		s.sendStringMessage(ds, m, messageNoPermission)
+		return
+	}
+	r, err := s.reminders.Announce(s.ctx, m.GuildID, m.ChannelID, m.Author.ID, clock, days, text)
+	if err != nil {
+		s.sendStringMessage(ds, m, s.errorMessage(err))
+		return
+	}
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageAnnouncementAdded, reminderLine(r), r.Next.Unix()))
+}
+
+// nextWord splits off the first word, the rest keeps its spaces and lines
+func nextWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexAny(s, " \t\n")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+func (s *Service) errorMessage(err error) string {
+	switch {
+	case errors.Is(err, reminder.ErrNotFound):
+		return messageNotFound
+	case errors.Is(err, reminder.ErrForbidden):
+		return messageNoPermission
+	case errors.Is(err, reminder.ErrTooMany), errors.Is(err, reminder.ErrTooManyGuild),
+		errors.Is(err, reminder.ErrInvalidDelay), errors.Is(err, reminder.ErrInvalidTime),
+		errors.Is(err, reminder.ErrInvalidDays), errors.Is(err, reminder.ErrInvalidText):
+		return fmt.Sprintf(messageInvalidValue, err)
+	}
+	s.logger.Error(errors.Wrap(err, "reminders"))
+	return discord.MessageInternalError
+}
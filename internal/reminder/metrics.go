@@ -0,0 +1,21 @@
+package reminder
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	remindersSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "reminder",
+		Name:      "sent_total",
+		Help:      "Reminders posted, by kind.",
+	}, []string{"kind"})
+	remindersMissed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "reminder",
+		Name:      "missed_total",
+		Help:      "Announcements skipped because they were due too long ago.",
+	})
+)
@@ -0,0 +1,335 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/guild"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/util"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	// MaxReminders of a member in a guild
+	MaxReminders = 25
+	// MaxAnnouncements per guild
+	MaxAnnouncements = 10
+	// MaxText of a reminder in characters
+	MaxText = 1000
+	// MaxDelay of a personal reminder
+	MaxDelay = 365 * 24 * time.Hour
+	// maxLateness an announcement missed for longer, e.g. while the bot was down, waits for its next time
+	maxLateness = 10 * time.Minute
+)
+
+var (
+	ErrNotFound     = errors.New("reminder not found")
+	ErrTooMany      = errors.Errorf("you have %d reminders already", MaxReminders)
+	ErrTooManyGuild = errors.Errorf("guild has %d announcements already", MaxAnnouncements)
+	ErrInvalidDelay = errors.New("the delay is like 30m, 2h or 1d12h, up to a year")
+	ErrInvalidTime  = errors.New("time is HH:MM in 24 hours")
+	ErrInvalidDays  = errors.New("days are daily, weekdays, weekends or like mon,wed,fri")
+	ErrInvalidText  = errors.Errorf("the text is from 1 to %d characters", MaxText)
+	ErrForbidden    = errors.New("only server managers change the announcements")
+)
+
+// Reminder is posted in the channel once at the next time, an announcement has the days and repeats
+type Reminder struct {
+	ID        string `firestore:"-" json:"-"`
+	GuildID   string `firestore:"guild_id" json:"guild_id"`
+	ChannelID string `firestore:"channel_id" json:"channel_id"`
+	UserID    string `firestore:"user_id" json:"user_id"`
+	Text      string `firestore:"text" json:"text"`
+	// Time is HH:MM in the timezone of the guild when the announcement was scheduled
+	Time     string    `firestore:"time,omitempty" json:"time,omitempty"`
+	Days     string    `firestore:"days,omitempty" json:"days,omitempty"`
+	Timezone string    `firestore:"timezone,omitempty" json:"timezone,omitempty"`
+	Next     time.Time `firestore:"next" json:"next"`
+	Created  time.Time `firestore:"created" json:"created"`
+}
+
+// Recurring is an announcement of the guild
+func (r *Reminder) Recurring() bool {
+	return r.Days != ""
+}
+
+func (r *Reminder) schedule() (cron.Schedule, error) {
+	clock, err := time.Parse("15:04", r.Time)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	days, ok := util.CronDays(r.Days)
+	if !ok {
+		return nil, ErrInvalidDays
+	}
+	spec := fmt.Sprintf("%d %d * * %s", clock.Minute(), clock.Hour(), days)
+	if r.Timezone != "" {
+		spec = "CRON_TZ=" + r.Timezone + " " + spec
+	}
+	return cron.ParseStandard(spec)
+}
+
+// ParseDelay like 30m, 2h or 1d12h
+func ParseDelay(s string) (time.Duration, error) {
+	d, err := util.ParseDuration(s)
+	if err != nil || d < time.Minute || d > MaxDelay {
+		return 0, ErrInvalidDelay
+	}
+	return d, nil
+}
+
+type Storage interface {
+	GuildReminders(ctx context.Context, guildID string) ([]Reminder, error)
+	UserReminders(ctx context.Context, userID string) ([]Reminder, error)
+	// DueReminders the ones with the next time before now
+	DueReminders(ctx context.Context, now time.Time) ([]Reminder, error)
+	// AddReminder sets the id
+	AddReminder(ctx context.Context, r *Reminder) error
+	SetNext(ctx context.Context, id string, next time.Time) error
+	DeleteReminder(ctx context.Context, id string) error
+}
+
+// Sender posts the reminders, discordgo.Session is one
+type Sender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error)
+}
+
+type Settings interface {
+	Get(guildID string) guild.Settings
+}
+
+// Service of the reminders, they are read from Firestore when due so any instance of a cluster can post them
+type Service struct {
+	storage  Storage
+	sender   Sender
+	settings Settings
+	// handles the guild on this instance, the reminders of the other guilds are left to their instances
+	handles func(guildID string) bool
+	logger  zap.Logger
+}
+
+func NewService(storage Storage, sender Sender, settings Settings, handles func(guildID string) bool, logger zap.Logger) *Service {
+	return &Service{
+		storage:  storage,
+		sender:   sender,
+		settings: settings,
+		handles:  handles,
+		logger:   logger,
+	}
+}
+
+// List the announcements of the guild and the reminders of the user there, the announcements go first
+func (s *Service) List(ctx context.Context, guildID, userID string) ([]Reminder, error) {
+	all, err := s.storage.GuildReminders(ctx, guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "guild reminders")
+	}
+	res := make([]Reminder, 0, len(all))
+	for i := range all {
+		if all[i].Recurring() || all[i].UserID == userID {
+			res = append(res, all[i])
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Recurring() != res[j].Recurring() {
+			return res[i].Recurring()
+		}
+		if res[i].Recurring() {
+			return res[i].Created.Before(res[j].Created)
+		}
+		return res[i].Next.Before(res[j].Next)
+	})
+	return res, nil
+}
+
+// RemindIn posts the text with a mention of the user in the channel after the delay
+func (s *Service) RemindIn(ctx context.Context, guildID, channelID, userID string, delay time.Duration, text string) (*Reminder, error) {
+	return s.remind(ctx, guildID, channelID, userID, time.Now().Add(delay), text)
+}
+
+// RemindAt posts the text at the next clock in the timezone of the guild
+func (s *Service) RemindAt(ctx context.Context, guildID, channelID, userID, clock, text string) (*Reminder, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	settings := s.settings.Get(guildID)
+	now := time.Now().In(settings.Location())
+	at := time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return s.remind(ctx, guildID, channelID, userID, at, text)
+}
+
+func (s *Service) remind(ctx context.Context, guildID, channelID, userID string, at time.Time, text string) (*Reminder, error) {
+	if err := checkText(text); err != nil {
+		return nil, err
+	}
+	all, err := s.storage.GuildReminders(ctx, guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "guild reminders")
+	}
+	n := 0
+	for i := range all {
+		if !all[i].Recurring() && all[i].UserID == userID {
+			n++
+		}
+	}
+	if n >= MaxReminders {
+		return nil, ErrTooMany
+	}
+	r := &Reminder{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		UserID:    userID,
+		Text:      text,
+		Next:      at,
+		Created:   time.Now(),
+	}
+	if err := s.storage.AddReminder(ctx, r); err != nil {
+		return nil, errors.Wrap(err, "add reminder")
+	}
+	return r, nil
+}
+
+// Announce the text in the channel at the clock on the days
+func (s *Service) Announce(ctx context.Context, guildID, channelID, userID, clock, days, text string) (*Reminder, error) {
+	if err := checkText(text); err != nil {
+		return nil, err
+	}
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	r := &Reminder{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		UserID:    userID,
+		Text:      text,
+		Time:      parsed.Format("15:04"),
+		Days:      strings.ToLower(days),
+		Timezone:  s.settings.Get(guildID).Timezone,
+		Created:   time.Now(),
+	}
+	schedule, err := r.schedule()
+	if err != nil {
+		return nil, err
+	}
+	r.Next = schedule.Next(time.Now())
+	all, err := s.storage.GuildReminders(ctx, guildID)
+	if err != nil {
+		return nil, errors.Wrap(err, "guild reminders")
+	}
+	n := 0
+	for i := range all {
+		if all[i].Recurring() {
+			n++
+		}
+	}
+	if n >= MaxAnnouncements {
+		return nil, ErrTooManyGuild
+	}
+	if err := s.storage.AddReminder(ctx, r); err != nil {
+		return nil, errors.Wrap(err, "add announcement")
+	}
+	return r, nil
+}
+
+// Delete the reminder at the position of List from 0, manager allows deleting the announcements
+func (s *Service) Delete(ctx context.Context, guildID, userID string, pos int, manager bool) (*Reminder, error) {
+	reminders, err := s.List(ctx, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pos < 0 || pos >= len(reminders) {
+		return nil, ErrNotFound
+	}
+	r := &reminders[pos]
+	if r.Recurring() && !manager {
+		return nil, ErrForbidden
+	}
+	if err := s.storage.DeleteReminder(ctx, r.ID); err != nil {
+		return nil, errors.Wrap(err, "delete reminder")
+	}
+	return r, nil
+}
+
+// Run posts the due reminders, it is a job of the scheduler running every minute.
+// A late personal reminder is still posted, a late announcement waits for its next time.
+func (s *Service) Run(ctx context.Context) error {
+	now := time.Now()
+	due, err := s.storage.DueReminders(ctx, now)
+	if err != nil {
+		return errors.Wrap(err, "due reminders")
+	}
+	for i := range due {
+		r := &due[i]
+		if !s.handles(r.GuildID) {
+			continue
+		}
+		if !r.Recurring() {
+			if err := s.post(r); err != nil {
+				s.logger.Error(errors.Wrapf(err, "post reminder %s", r.ID))
+			}
+			// the reminder is not retried, the channel is likely gone
+			if err := s.storage.DeleteReminder(ctx, r.ID); err != nil {
+				s.logger.Error(errors.Wrapf(err, "delete reminder %s", r.ID))
+			}
+			continue
+		}
+		if now.Sub(r.Next) > maxLateness {
+			remindersMissed.Inc()
+			s.logger.Warnw("announcement missed",
+				"guild", r.GuildID,
+				"reminder", r.ID,
+				"time", r.Next)
+		} else if err := s.post(r); err != nil {
+			s.logger.Error(errors.Wrapf(err, "post announcement %s", r.ID))
+		}
+		schedule, err := r.schedule()
+		if err != nil {
+			s.logger.Error(errors.Wrapf(err, "schedule of announcement %s", r.ID))
+			continue
+		}
+		if err := s.storage.SetNext(ctx, r.ID, schedule.Next(now)); err != nil {
+			s.logger.Error(errors.Wrapf(err, "set next time of announcement %s", r.ID))
+		}
+	}
+	return nil
+}
+
+// post the personal reminder mentions only the author, the announcement may mention the members and the roles
+func (s *Service) post(r *Reminder) error {
+	msg := &discordgo.MessageSend{
+		Content: ":bell: " + r.Text,
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers, discordgo.AllowedMentionTypeRoles},
+		},
+	}
+	kind := "announcement"
+	if !r.Recurring() {
+		kind = "reminder"
+		msg.Content = fmt.Sprintf(":alarm_clock: <@%s> %s", r.UserID, r.Text)
+		msg.AllowedMentions = &discordgo.MessageAllowedMentions{Users: []string{r.UserID}}
+	}
+	if _, err := s.sender.ChannelMessageSendComplex(r.ChannelID, msg); err != nil {
+		return err
+	}
+	remindersSent.WithLabelValues(kind).Inc()
+	return nil
+}
+
+func checkText(text string) error {
+	if n := len([]rune(strings.TrimSpace(text))); n == 0 || n > MaxText {
+		return ErrInvalidText
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/reminder"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const remindersCollection = "reminders"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) GuildReminders(ctx context.Context, guildID string) ([]reminder.Reminder, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: GuildReminders %s", guildID)
+	return s.reminders(s.client.Collection(remindersCollection).Where("guild_id", "==", guildID).Documents(ctx))
+}
+
+func (s *Storage) UserReminders(ctx context.Context, userID string) ([]reminder.Reminder, error) {
+	contexts.LoggerFromContext(ctx).Infof("DB: UserReminders %s", userID)
+	return s.reminders(s.client.Collection(remindersCollection).Where("user_id", "==", userID).Documents(ctx))
+}
+
+func (s *Storage) DueReminders(ctx context.Context, now time.Time) ([]reminder.Reminder, error) {
+	return s.reminders(s.client.Collection(remindersCollection).Where("next", "<=", now).Documents(ctx))
+}
+
+func (s *Storage) reminders(iter *firestore.DocumentIterator) ([]reminder.Reminder, error) {
+	defer iter.Stop()
+	res := make([]reminder.Reminder, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var r reminder.Reminder
+		if err := doc.DataTo(&r); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		r.ID = doc.Ref.ID
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func (s *Storage) AddReminder(ctx context.Context, r *reminder.Reminder) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: AddReminder %s", r.GuildID)
+	ref, _, err := s.client.Collection(remindersCollection).Add(ctx, r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add reminder to %s", remindersCollection)
+	}
+	r.ID = ref.ID
+	return nil
+}
+
+func (s *Storage) SetNext(ctx context.Context, id string, next time.Time) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetNext %s", id)
+	_, err := s.client.Collection(remindersCollection).Doc(id).Update(ctx, []firestore.Update{{Path: "next", Value: next}})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update %s in %s", id, remindersCollection)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteReminder(ctx context.Context, id string) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteReminder %s", id)
+	if _, err := s.client.Collection(remindersCollection).Doc(id).Delete(ctx); err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", id, remindersCollection)
+	}
+	return nil
+}
+
+// DeleteUserReminders in all guilds, the announcements the user scheduled too
+func (s *Storage) DeleteUserReminders(ctx context.Context, userID string) (int, error) {
+	reminders, err := s.UserReminders(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range reminders {
+		if err := s.DeleteReminder(ctx, reminders[i].ID); err != nil {
+			return i, err
+		}
+	}
+	return len(reminders), nil
+}
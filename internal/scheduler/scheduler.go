@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Job errors are logged, the job runs again at the next time of its schedule
+type Job func(ctx context.Context) error
+
+// Schedule of a job stored in Firestore.
+// Spec overrides the one from the code, LastRun lets a restarted bot catch up the run it missed.
+type Schedule struct {
+	Name    string    `firestore:"-"`
+	Spec    string    `firestore:"spec,omitempty"`
+	LastRun time.Time `firestore:"last_run"`
+}
+
+type Storage interface {
+	AllSchedules(ctx context.Context) ([]Schedule, error)
+	SetSchedule(ctx context.Context, s *Schedule) error
+}
+
+// Locker makes only one instance of the cluster run a job
+type Locker interface {
+	Acquire(ctx context.Context, key string) (takeover bool, err error)
+}
+
+type entry struct {
+	name     string
+	spec     string
+	override string
+	schedule cron.Schedule
+	job      Job
+	// local jobs run on every instance and are not persisted
+	local   bool
+	lastRun time.Time
+}
+
+type Scheduler struct {
+	storage Storage
+	locker  Locker
+	logger  zap.Logger
+
+	mx      sync.Mutex
+	entries map[string]*entry
+	started bool
+	// loaded the runs are not saved before the stored schedules are applied, it would reset the overrides
+	loaded bool
+}
+
+// New locker is nil if the bot runs as a single instance
+func New(storage Storage, locker Locker, logger zap.Logger) *Scheduler {
+	return &Scheduler{
+		storage: storage,
+		locker:  locker,
+		logger:  logger,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Add the job runs on one instance of the cluster.
+// The spec is a cron expression or a descriptor like "@every 5m" or "@weekly".
+func (s *Scheduler) Add(name, spec string, job Job) error {
+	return s.add(name, spec, job, false)
+}
+
+// AddLocal the job runs on every instance, e.g. to refresh the memory caches
+func (s *Scheduler) AddLocal(name, spec string, job Job) error {
+	return s.add(name, spec, job, true)
+}
+
+func (s *Scheduler) add(name, spec string, job Job, local bool) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return errors.Wrapf(err, "parse schedule of %s", name)
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.started {
+		return errors.Errorf("add %s: scheduler is already started", name)
+	}
+	if _, ok := s.entries[name]; ok {
+		return errors.Errorf("job %s already exists", name)
+	}
+	s.entries[name] = &entry{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		job:      job,
+		local:    local,
+	}
+	return nil
+}
+
+// Load applies the stored schedules, the jobs may be already running
+func (s *Scheduler) Load(ctx context.Context) error {
+	stored, err := s.storage.AllSchedules(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load schedules")
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for i := range stored {
+		e, ok := s.entries[stored[i].Name]
+		if !ok {
+			continue
+		}
+		if spec := stored[i].Spec; spec != "" {
+			schedule, err := cron.ParseStandard(spec)
+			if err != nil {
+				s.logger.Errorw("invalid stored schedule",
+					"job", e.name,
+					"spec", spec,
+					"err", err)
+			} else {
+				e.override, e.schedule = spec, schedule
+			}
+		}
+		if !e.local && stored[i].LastRun.After(e.lastRun) {
+			e.lastRun = stored[i].LastRun
+		}
+	}
+	s.loaded = true
+	return nil
+}
+
+// Start runs the jobs until the context is done, the schedules from the code are used until Load
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.started = true
+	for _, e := range s.entries {
+		e := e
+		// lastRun is set before the job runs, so a job that panicked waits for its next time
+		supervisor.Go(ctx, s.logger, "job "+e.name, func(ctx context.Context) { s.run(ctx, e) })
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	for {
+		s.mx.Lock()
+		from, schedule := e.lastRun, e.schedule
+		s.mx.Unlock()
+		if from.IsZero() {
+			from = time.Now()
+		}
+		// a run missed while the bot was down starts right away
+		timer := time.NewTimer(time.Until(schedule.Next(from)))
+		select {
+		case <-timer.C:
+			s.execute(ctx, e)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, e *entry) {
+	start := time.Now()
+	s.mx.Lock()
+	e.lastRun = start
+	s.mx.Unlock()
+	if !e.local && s.locker != nil {
+		if _, err := s.locker.Acquire(ctx, "job-"+e.name); err != nil {
+			// another instance runs it
+			return
+		}
+	}
+	if err := e.job(ctx); err != nil {
+		s.logger.Errorw("scheduled job failed",
+			"job", e.name,
+			"err", err)
+	}
+	s.logger.Infow("scheduled job executed",
+		"job", e.name,
+		"elapsed", time.Since(start))
+	s.mx.Lock()
+	loaded, override := s.loaded, e.override
+	s.mx.Unlock()
+	if e.local || !loaded {
+		return
+	}
+	if err := s.storage.SetSchedule(ctx, &Schedule{Name: e.name, Spec: override, LastRun: start}); err != nil {
+		s.logger.Error(errors.Wrap(err, "save schedule"))
+	}
+}
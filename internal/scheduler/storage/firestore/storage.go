@@ -0,0 +1,56 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/scheduler"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+const schedulesCollection = "schedules"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) AllSchedules(ctx context.Context) ([]scheduler.Schedule, error) {
+	contexts.LoggerFromContext(ctx).Info("DB: AllSchedules")
+	iter := s.client.Collection(schedulesCollection).Documents(ctx)
+	defer iter.Stop()
+	res := make([]scheduler.Schedule, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var schedule scheduler.Schedule
+		if err := doc.DataTo(&schedule); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		schedule.Name = doc.Ref.ID
+		res = append(res, schedule)
+	}
+	return res, nil
+}
+
+func (s *Storage) SetSchedule(ctx context.Context, schedule *scheduler.Schedule) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetSchedule %s", schedule.Name)
+	_, err := s.client.Collection(schedulesCollection).Doc(schedule.Name).Set(ctx, schedule)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", schedule.Name, schedulesCollection)
+	}
+	return nil
+}
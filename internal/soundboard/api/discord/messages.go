@@ -0,0 +1,74 @@
+package discord
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/soundboard"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	messageNoPermission = ":x: **Only server managers can change the soundboard**"
+	messageInvalidValue = ":x: **%s**"
+	messageUnavailable  = ":x: **The soundboard can't be changed right now, try again later**"
+	messageNotFound     = ":x: **No such clip**"
+	messageNotConnected = ":x: **The bot has to be in a voice channel, play something first**"
+	messageNoAttachment = ":x: **Attach one audio file**"
+	messageClipAdded    = ":white_check_mark: **Clip `%s` added**, %.1fs"
+	messageClipRemoved  = ":white_check_mark: **Clip `%s` removed**"
+	messageNoClips      = "**No clips yet**"
+	messageUsage        = "`%[1]ssounds` show the clips\n" +
+		"`%[1]ssound <name>` play the clip over the music\n" +
+		"`%[1]ssound add <name>` with an audio file attached, up to %.0[2]f seconds\n" +
+		"`%[1]ssound remove <name>`"
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, m *discordgo.MessageCreate, msg string) {
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{Content: msg})
+}
+
+func (s *Service) sendUsageMessage(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageUsage, s.prefix, soundboard.MaxClipDuration.Seconds()))
+}
+
+func (s *Service) sendClipAddedMessage(ds *discordgo.Session, m *discordgo.MessageCreate, c *soundboard.Clip) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageClipAdded, c.Name, c.Duration.Seconds()))
+}
+
+func (s *Service) sendClipRemovedMessage(ds *discordgo.Session, m *discordgo.MessageCreate, name string) {
+	s.sendStringMessage(ds, m, fmt.Sprintf(messageClipRemoved, name))
+}
+
+// sendSoundboardMessage a button per clip, MaxClips fit into one message
+func (s *Service) sendSoundboardMessage(ds *discordgo.Session, m *discordgo.MessageCreate, clips []*soundboard.Clip) {
+	if len(clips) == 0 {
+		s.sendStringMessage(ds, m, messageNoClips)
+		return
+	}
+	buttons := make([]discordgo.Button, 0, len(clips))
+	for _, c := range clips {
+		buttons = append(buttons, discordgo.Button{
+			Label:    c.Name,
+			Style:    discordgo.SecondaryButton,
+			CustomID: clipButtonPrefix + c.Name,
+		})
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Content:    "**Soundboard**",
+		Components: command.ButtonRows(buttons),
+	})
+}
@@ -0,0 +1,167 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/soundboard"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "soundboard"
+
+const (
+	sound  = "sound "
+	sounds = "sounds"
+
+	add    = "add"
+	remove = "remove"
+
+	clipButtonPrefix = "soundboard:"
+)
+
+type Soundboard interface {
+	List(guildID string) []*soundboard.Clip
+	Add(ctx context.Context, guildID, name, uri, author string) (*soundboard.Clip, error)
+	Remove(ctx context.Context, guildID, name string) error
+	Play(guildID, name string) error
+}
+
+type Service struct {
+	ctx        context.Context
+	soundboard Soundboard
+	prefix     string
+	logger     zap.Logger
+}
+
+func NewCog(ctx context.Context, soundboard Soundboard, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:        ctx,
+		soundboard: soundboard,
+		prefix:     prefix,
+		logger:     logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+sound, s.soundMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewMessageCommand(s.prefix+sounds, s.soundsMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewComponentCommand(clipButtonPrefix, s.clipButtonHandler).RegisterCommand(session, logger)
+}
+
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// soundMessageHandler plays the clip, server managers add and remove them
+func (s *Service) soundMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+sound))
+	switch {
+	case len(args) == 1:
+		if err := s.soundboard.Play(m.GuildID, strings.ToLower(args[0])); err != nil {
+			s.sendStringMessage(ds, m, s.playErrorMessage(err))
+		}
+	case len(args) == 2 && (strings.EqualFold(args[0], add) || strings.EqualFold(args[0], remove)):
+		if !discord.HasPermission(ds, m.Author.ID, m.ChannelID, discordgo.PermissionManageServer) {
+			s.sendStringMessage(ds, m, messageNoPermission)
+			return
+		}
+		name := strings.ToLower(args[1])
+		if strings.EqualFold(args[0], remove) {
+			s.removeClip(ds, m, name)
+			return
+		}
+		s.addClip(ds, m, name)
+	default:
+		s.sendUsageMessage(ds, m)
+	}
+}
+
+func (s *Service) addClip(ds *discordgo.Session, m *discordgo.MessageCreate, name string) {
+	if len(m.Attachments) != 1 {
+		s.sendStringMessage(ds, m, messageNoAttachment)
+		return
+	}
+	clip, err := s.soundboard.Add(s.ctx, m.GuildID, name, m.Attachments[0].URL, m.Author.ID)
+	if err != nil {
+		s.sendStringMessage(ds, m, s.changeErrorMessage(err))
+		return
+	}
+	s.sendClipAddedMessage(ds, m, clip)
+}
+
+func (s *Service) removeClip(ds *discordgo.Session, m *discordgo.MessageCreate, name string) {
+	if err := s.soundboard.Remove(s.ctx, m.GuildID, name); err != nil {
+		s.sendStringMessage(ds, m, s.changeErrorMessage(err))
+		return
+	}
+	s.sendClipRemovedMessage(ds, m, name)
+}
+
+func (s *Service) soundsMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" {
+		return
+	}
+	s.sendSoundboardMessage(ds, m, s.soundboard.List(m.GuildID))
+}
+
+// clipButtonHandler the click is answered before the clip plays, discord waits only 3 seconds
+func (s *Service) clipButtonHandler(ds *discordgo.Session, i *discordgo.InteractionCreate, name string) {
+	err := ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to clip button"))
+		return
+	}
+	if err := s.soundboard.Play(i.GuildID, name); err != nil {
+		_, err := ds.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+			Content: s.playErrorMessage(err),
+			Flags:   uint64(discordgo.MessageFlagsEphemeral),
+		})
+		if err != nil {
+			s.logger.Error(errors.Wrap(err, "follow up clip button"))
+		}
+	}
+}
+
+func (s *Service) playErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, soundboard.ErrNotFound):
+		return messageNotFound
+	case errors.Is(err, soundboard.ErrNotConnected):
+		return messageNotConnected
+	}
+	s.logger.Error(errors.Wrap(err, "play clip"))
+	return discord.MessageInternalError
+}
+
+func (s *Service) changeErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, soundboard.ErrNotFound):
+		return messageNotFound
+	case errors.Is(err, soundboard.ErrNotLoaded):
+		return messageUnavailable
+	case errors.Is(err, soundboard.ErrInvalidName), errors.Is(err, soundboard.ErrTooMany), errors.Is(err, soundboard.ErrTooLong):
+		return fmt.Sprintf(messageInvalidValue, err)
+	}
+	s.logger.Error(errors.Wrap(err, "change clips"))
+	return discord.MessageInternalError
+}
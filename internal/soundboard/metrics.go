@@ -0,0 +1,13 @@
+package soundboard
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var clipsPlayed = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "soundboard",
+	Name:      "clips_played_total",
+	Help:      "Soundboard clips played in voice channels.",
+})
@@ -0,0 +1,194 @@
+package soundboard
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxClips per guild, all of them fit in the buttons of one message
+	MaxClips = 25
+	// MaxClipDuration keeps the encoded clip well under the Firestore document limit
+	MaxClipDuration = 15 * time.Second
+)
+
+var (
+	ErrNotFound     = errors.New("clip not found")
+	ErrNotConnected = errors.New("bot is not in a voice channel of the guild")
+	ErrInvalidName  = errors.New("clip name is 1-32 lowercase letters, digits, - or _")
+	ErrTooMany      = errors.Errorf("guild has %d clips already", MaxClips)
+	ErrTooLong      = errors.Errorf("clip is longer than %s", MaxClipDuration)
+	// ErrNotLoaded the clips can't be changed before they are loaded, a write could override a stored one
+	ErrNotLoaded = errors.New("clips are not loaded")
+)
+
+var nameRe = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Clip a short sound of the guild, Frames are the opus frames in the dca format
+type Clip struct {
+	GuildID  string        `firestore:"guild_id"`
+	Name     string        `firestore:"name"`
+	Frames   []byte        `firestore:"frames"`
+	Duration time.Duration `firestore:"duration"`
+	Author   string        `firestore:"author"`
+	Created  time.Time     `firestore:"created"`
+}
+
+type Storage interface {
+	AllClips(ctx context.Context) ([]Clip, error)
+	SetClip(ctx context.Context, c *Clip) error
+	DeleteClip(ctx context.Context, guildID, name string) error
+}
+
+// Audio encodes the uploaded clips and plays them over the music
+type Audio interface {
+	// EncodeClip stops past maxDuration and returns a longer duration then
+	EncodeClip(uri string, maxDuration time.Duration) ([]byte, time.Duration, error)
+	PlayClip(v *discordgo.VoiceConnection, frames []byte) error
+}
+
+// Voice the connection of the music player is reused
+type Voice interface {
+	Connection() *discordgo.VoiceConnection
+}
+
+// Service keeps all the clips in memory, they are small
+type Service struct {
+	storage Storage
+	audio   Audio
+	voice   Voice
+
+	mx     sync.RWMutex
+	clips  map[string]map[string]*Clip // guild, name
+	loaded bool
+}
+
+func NewService(storage Storage, audio Audio, voice Voice) *Service {
+	return &Service{
+		storage: storage,
+		audio:   audio,
+		voice:   voice,
+		clips:   make(map[string]map[string]*Clip),
+	}
+}
+
+func (s *Service) Load(ctx context.Context) error {
+	all, err := s.storage.AllClips(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load clips")
+	}
+	clips := make(map[string]map[string]*Clip)
+	for i := range all {
+		c := &all[i]
+		if clips[c.GuildID] == nil {
+			clips[c.GuildID] = make(map[string]*Clip)
+		}
+		clips[c.GuildID][c.Name] = c
+	}
+	s.mx.Lock()
+	s.clips = clips
+	s.loaded = true
+	s.mx.Unlock()
+	return nil
+}
+
+// List the clips of the guild by name
+func (s *Service) List(guildID string) []*Clip {
+	s.mx.RLock()
+	res := make([]*Clip, 0, len(s.clips[guildID]))
+	for _, c := range s.clips[guildID] {
+		res = append(res, c)
+	}
+	s.mx.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// Add encodes the clip from uri, a clip with the same name is replaced
+func (s *Service) Add(ctx context.Context, guildID, name, uri, author string) (*Clip, error) {
+	if !nameRe.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	s.mx.RLock()
+	_, exists := s.clips[guildID][name]
+	count, loaded := len(s.clips[guildID]), s.loaded
+	s.mx.RUnlock()
+	if !loaded {
+		return nil, ErrNotLoaded
+	}
+	if !exists && count >= MaxClips {
+		return nil, ErrTooMany
+	}
+	frames, duration, err := s.audio.EncodeClip(uri, MaxClipDuration)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode clip")
+	}
+	if duration > MaxClipDuration {
+		return nil, ErrTooLong
+	}
+	c := &Clip{
+		GuildID:  guildID,
+		Name:     name,
+		Frames:   frames,
+		Duration: duration,
+		Author:   author,
+		Created:  time.Now(),
+	}
+	if err := s.storage.SetClip(ctx, c); err != nil {
+		return nil, errors.Wrap(err, "save clip")
+	}
+	s.mx.Lock()
+	if s.clips[guildID] == nil {
+		s.clips[guildID] = make(map[string]*Clip)
+	}
+	s.clips[guildID][name] = c
+	s.mx.Unlock()
+	return c, nil
+}
+
+func (s *Service) Remove(ctx context.Context, guildID, name string) error {
+	s.mx.RLock()
+	_, ok := s.clips[guildID][name]
+	loaded := s.loaded
+	s.mx.RUnlock()
+	if !loaded {
+		return ErrNotLoaded
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	if err := s.storage.DeleteClip(ctx, guildID, name); err != nil {
+		return errors.Wrap(err, "delete clip")
+	}
+	s.mx.Lock()
+	delete(s.clips[guildID], name)
+	s.mx.Unlock()
+	return nil
+}
+
+// Play the clip in the voice channel of the bot, the music is paused meanwhile
+func (s *Service) Play(guildID, name string) error {
+	s.mx.RLock()
+	c, ok := s.clips[guildID][name]
+	s.mx.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	conn := s.voice.Connection()
+	if conn == nil || conn.GuildID != guildID {
+		return ErrNotConnected
+	}
+	if err := s.audio.PlayClip(conn, c.Frames); err != nil {
+		return errors.Wrapf(err, "play clip %s", name)
+	}
+	clipsPlayed.Inc()
+	return nil
+}
@@ -0,0 +1,86 @@
+package firestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/soundboard"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/retry"
+)
+
+const clipsCollection = "soundboard"
+
+// writeRetry the clips are written and deleted as a whole document, so a repeated write is harmless
+var writeRetry = retry.Policy{
+	Attempts:  4,
+	Base:      100 * time.Millisecond,
+	Max:       2 * time.Second,
+	Retryable: retry.Transient,
+}
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func clipID(guildID, name string) string {
+	return guildID + "_" + name
+}
+
+func (s *Storage) AllClips(ctx context.Context) ([]soundboard.Clip, error) {
+	contexts.LoggerFromContext(ctx).Info("DB: AllClips")
+	iter := s.client.Collection(clipsCollection).Documents(ctx)
+	defer iter.Stop()
+	res := make([]soundboard.Clip, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "iteration failed")
+		}
+		var c soundboard.Clip
+		if err := doc.DataTo(&c); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+func (s *Storage) SetClip(ctx context.Context, c *soundboard.Clip) error {
+	id := clipID(c.GuildID, c.Name)
+	contexts.LoggerFromContext(ctx).Infof("DB: SetClip %s", id)
+	err := writeRetry.Do(ctx, "set_clip", func() error {
+		_, err := s.client.Collection(clipsCollection).Doc(id).Set(ctx, c)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s to %s", id, clipsCollection)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteClip(ctx context.Context, guildID, name string) error {
+	id := clipID(guildID, name)
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteClip %s", id)
+	err := writeRetry.Do(ctx, "delete_clip", func() error {
+		_, err := s.client.Collection(clipsCollection).Doc(id).Delete(ctx)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s from %s", id, clipsCollection)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/trends"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/contexts"
+)
+
+// snapshotsCollection the docs are named by the dates
+const snapshotsCollection = "song_snapshots"
+
+type Storage struct {
+	client *firestore.Client
+}
+
+func NewStorage(client *firestore.Client) *Storage {
+	return &Storage{
+		client: client,
+	}
+}
+
+func (s *Storage) SetSnapshot(ctx context.Context, snapshot *trends.Snapshot) error {
+	contexts.LoggerFromContext(ctx).Infof("DB: SetSnapshot date:%s songs:%d", snapshot.Date, len(snapshot.Plays))
+	if _, err := s.client.Collection(snapshotsCollection).Doc(snapshot.Date).Set(ctx, snapshot); err != nil {
+		return errors.Wrapf(err, "failed to set snapshot %s", snapshot.Date)
+	}
+	return nil
+}
+
+func (s *Storage) Snapshots(ctx context.Context, from, to string) ([]trends.Snapshot, error) {
+	iter := s.client.Collection(snapshotsCollection).
+		Where("date", ">=", from).
+		Where("date", "<=", to).
+		OrderBy("date", firestore.Asc).
+		Documents(ctx)
+	defer iter.Stop()
+	res := make([]trends.Snapshot, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get snapshots from %s", snapshotsCollection)
+		}
+		var snapshot trends.Snapshot
+		if err := doc.DataTo(&snapshot); err != nil {
+			return nil, errors.Wrap(err, "unable to marshal data")
+		}
+		res = append(res, snapshot)
+	}
+	return res, nil
+}
+
+func (s *Storage) DeleteBefore(ctx context.Context, date string) (int, error) {
+	iter := s.client.Collection(snapshotsCollection).Where("date", "<", date).Documents(ctx)
+	defer iter.Stop()
+	deleted := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return deleted, errors.Wrapf(err, "failed to get snapshots from %s", snapshotsCollection)
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return deleted, errors.Wrapf(err, "failed to delete snapshot %s", doc.Ref.ID)
+		}
+		deleted++
+	}
+	contexts.LoggerFromContext(ctx).Infof("DB: DeleteBefore date:%s deleted:%d", date, deleted)
+	return deleted, nil
+}
@@ -0,0 +1,165 @@
+package trends
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/pkg"
+)
+
+const (
+	// DateLayout of the snapshots, the days are in UTC
+	DateLayout = "2006-01-02"
+	// Window of the recent days compared to as many days before them
+	Window = 7
+	// Retention of the snapshots, the older ones are deleted by Compact
+	Retention = 90
+
+	// minPlays in the recent window of a rising song, one play is not a trend
+	minPlays = 2
+)
+
+// Snapshot of the playbacks of the songs in a day
+type Snapshot struct {
+	Date   string            `firestore:"date"`
+	Plays  map[string]int    `firestore:"plays"`  // song id
+	Titles map[string]string `firestore:"titles"` // song id
+}
+
+// Trend of a song, the change of the playbacks in the recent days
+type Trend struct {
+	SongID   string
+	Title    string
+	Recent   int
+	Previous int
+}
+
+func (t *Trend) Change() int {
+	return t.Recent - t.Previous
+}
+
+type Storage interface {
+	SetSnapshot(ctx context.Context, s *Snapshot) error
+	// Snapshots in [from, to], the dates are in DateLayout
+	Snapshots(ctx context.Context, from, to string) ([]Snapshot, error)
+	// DeleteBefore the date, returns the number of the deleted snapshots
+	DeleteBefore(ctx context.Context, date string) (int, error)
+}
+
+type Songs interface {
+	Plays(ctx context.Context, from, to time.Time) ([]pkg.Play, error)
+	TopSongs(ctx context.Context, n int) ([]pkg.Song, error)
+}
+
+// Service takes the snapshots from the history of the requested songs
+type Service struct {
+	songs   Songs
+	storage Storage
+}
+
+func NewService(songs Songs, storage Storage) *Service {
+	return &Service{
+		songs:   songs,
+		storage: storage,
+	}
+}
+
+// Top songs of all time
+func (s *Service) Top(ctx context.Context, n int) ([]pkg.Song, error) {
+	songs, err := s.songs.TopSongs(ctx, n)
+	if err != nil {
+		return nil, errors.Wrap(err, "top songs")
+	}
+	return songs, nil
+}
+
+// Snapshot the previous day, the snapshot is replaced if the job runs again
+func (s *Service) Snapshot(ctx context.Context) error {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	plays, err := s.songs.Plays(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return errors.Wrap(err, "plays of the day")
+	}
+	snapshot := &Snapshot{
+		Date:   day.Format(DateLayout),
+		Plays:  make(map[string]int),
+		Titles: make(map[string]string),
+	}
+	for i := range plays {
+		snapshot.Plays[plays[i].SongID]++
+		snapshot.Titles[plays[i].SongID] = plays[i].Title
+	}
+	if err := s.storage.SetSnapshot(ctx, snapshot); err != nil {
+		return errors.Wrap(err, "set snapshot")
+	}
+	return nil
+}
+
+// Compact deletes the snapshots older than Retention days
+func (s *Service) Compact(ctx context.Context) error {
+	before := time.Now().UTC().AddDate(0, 0, -Retention).Format(DateLayout)
+	if _, err := s.storage.DeleteBefore(ctx, before); err != nil {
+		return errors.Wrap(err, "delete old snapshots")
+	}
+	return nil
+}
+
+// Trending the n songs rising the most and the n falling the most
+// in the last Window days compared to the Window days before them
+func (s *Service) Trending(ctx context.Context, n int) (rising, falling []Trend, err error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	split := today.AddDate(0, 0, -Window)
+	from := split.AddDate(0, 0, -Window)
+	snapshots, err := s.storage.Snapshots(ctx, from.Format(DateLayout), today.AddDate(0, 0, -1).Format(DateLayout))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "snapshots")
+	}
+	trends := make(map[string]*Trend)
+	for i := range snapshots {
+		recent := snapshots[i].Date >= split.Format(DateLayout)
+		for id, plays := range snapshots[i].Plays {
+			t, ok := trends[id]
+			if !ok {
+				t = &Trend{SongID: id}
+				trends[id] = t
+			}
+			if title := snapshots[i].Titles[id]; title != "" {
+				t.Title = title
+			}
+			if recent {
+				t.Recent += plays
+			} else {
+				t.Previous += plays
+			}
+		}
+	}
+
+	all := make([]Trend, 0, len(trends))
+	for _, t := range trends {
+		all = append(all, *t)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Change() != all[j].Change() {
+			return all[i].Change() > all[j].Change()
+		}
+		return all[i].Title < all[j].Title
+	})
+	for i := 0; i < len(all) && len(rising) < n; i++ {
+		if all[i].Change() <= 0 {
+			break
+		}
+		if all[i].Recent >= minPlays {
+			rising = append(rising, all[i])
+		}
+	}
+	for i := len(all) - 1; i >= 0 && len(falling) < n; i-- {
+		if all[i].Change() >= 0 {
+			break
+		}
+		falling = append(falling, all[i])
+	}
+	return rising, falling, nil
+}
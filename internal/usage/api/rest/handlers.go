@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// usage godoc
+// @summary   Calls, failures and latency of the commands of Discord and the api since the start, the most used first
+// @produce   json
+// @security  AdminToken
+// @param     guild_id  query     string  false  "Only the commands of the guild"
+// @success   200       {object}  usage.Report
+// @failure   401       {object}  Response  "Wrong admin token"
+// @router    /admin/usage [get]
+func (h *AdminHandler) usageHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.stats.Report(c.Query("guild_id")))
+}
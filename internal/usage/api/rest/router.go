@@ -0,0 +1,34 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/usage"
+)
+
+type Stats interface {
+	Report(guildID string) usage.Report
+}
+
+// AdminHandler of the command usage, it is mounted on the admin group
+type AdminHandler struct {
+	stats Stats
+	super *gin.RouterGroup
+}
+
+func NewAdminHandler(stats Stats, superGroup *gin.RouterGroup) *AdminHandler {
+	return &AdminHandler{
+		stats: stats,
+		super: superGroup,
+	}
+}
+
+func (h *AdminHandler) Router() *gin.RouterGroup {
+	group := h.super.Group("/usage")
+	group.GET("", h.usageHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
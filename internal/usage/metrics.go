@@ -0,0 +1,22 @@
+package usage
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	calls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "commands",
+		Name:      "calls_total",
+		Help:      "Executed commands of Discord and the api by command and result, ok or failed.",
+	}, []string{"command", "result"})
+	latency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "halvabot",
+		Subsystem: "commands",
+		Name:      "duration_seconds",
+		Help:      "Time the commands took to handle by command.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"command"})
+)
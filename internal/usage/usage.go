@@ -0,0 +1,125 @@
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+const (
+	resultOK     = "ok"
+	resultFailed = "failed"
+)
+
+// Command usage since the start of the bot
+type Command struct {
+	Command  string `json:"command"`
+	Calls    int64  `json:"calls"`
+	Failures int64  `json:"failures"`
+	// AvgMS and MaxMS the time the handler took
+	AvgMS float64 `json:"avg_ms"`
+	MaxMS float64 `json:"max_ms"`
+}
+
+// Report of the commands, the most used first
+type Report struct {
+	GuildID  string    `json:"guild_id,omitempty"`
+	Since    time.Time `json:"since"`
+	Commands []Command `json:"commands"`
+}
+
+type counter struct {
+	calls    int64
+	failures int64
+	total    time.Duration
+	max      time.Duration
+}
+
+func (c *counter) add(e *command.Execution, failed bool) {
+	c.calls++
+	if failed {
+		c.failures++
+	}
+	c.total += e.Elapsed
+	if e.Elapsed > c.max {
+		c.max = e.Elapsed
+	}
+}
+
+// Stats of the commands in memory, by command for the whole bot and by guild, the history is in the audit log
+type Stats struct {
+	mx     sync.Mutex
+	since  time.Time
+	all    map[string]*counter
+	guilds map[string]map[string]*counter
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		since:  time.Now(),
+		all:    make(map[string]*counter),
+		guilds: make(map[string]map[string]*counter),
+	}
+}
+
+// Record is a command.Auditor, the commands of the api have no guild and are counted only for the whole bot
+func (s *Stats) Record(e command.Execution) {
+	failed := e.Outcome != command.OutcomeOK
+	result := resultOK
+	if failed {
+		result = resultFailed
+	}
+	calls.WithLabelValues(e.Command, result).Inc()
+	latency.WithLabelValues(e.Command).Observe(e.Elapsed.Seconds())
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	get(s.all, e.Command).add(&e, failed)
+	if e.GuildID == "" {
+		return
+	}
+	guild, ok := s.guilds[e.GuildID]
+	if !ok {
+		guild = make(map[string]*counter)
+		s.guilds[e.GuildID] = guild
+	}
+	get(guild, e.Command).add(&e, failed)
+}
+
+func get(counters map[string]*counter, name string) *counter {
+	c, ok := counters[name]
+	if !ok {
+		c = &counter{}
+		counters[name] = c
+	}
+	return c
+}
+
+// Report of the guild, of the whole bot if guildID is empty
+func (s *Stats) Report(guildID string) Report {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	counters := s.all
+	if guildID != "" {
+		counters = s.guilds[guildID]
+	}
+	r := Report{GuildID: guildID, Since: s.since, Commands: make([]Command, 0, len(counters))}
+	for name, c := range counters {
+		r.Commands = append(r.Commands, Command{
+			Command:  name,
+			Calls:    c.calls,
+			Failures: c.failures,
+			AvgMS:    float64(c.total.Milliseconds()) / float64(c.calls),
+			MaxMS:    float64(c.max.Milliseconds()),
+		})
+	}
+	sort.Slice(r.Commands, func(i, j int) bool {
+		if r.Commands[i].Calls != r.Commands[j].Calls {
+			return r.Commands[i].Calls > r.Commands[j].Calls
+		}
+		return r.Commands[i].Command < r.Commands[j].Command
+	})
+	return r
+}
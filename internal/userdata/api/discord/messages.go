@@ -0,0 +1,34 @@
+package discord
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	messageUsage         = "`%[1]smydata export` sends the data the bot keeps about you in DM, `%[1]smydata delete` erases it"
+	messageInternalError = ":x: **Something went wrong, try again later**"
+	messageExport        = "The data the bot keeps about you. The commands you used stay in the audit log of the servers."
+	messageExportSent    = ":envelope: **The data is sent in DM**"
+	messageDMClosed      = ":x: **The DM couldn't be sent, allow the direct messages from the server members**"
+	messageConfirm       = "<@%s> this erases your requested songs, favorites, playlists, listening time and linked clients, " +
+		"and removes you from the history of the played songs. It can't be undone, the button works for 5 minutes."
+	messageWrongConfirmation = ":x: **The button expired or isn't yours, use `mydata delete` again**"
+	messageDeleted           = ":white_check_mark: **The data of <@%s> is deleted, %d items**"
+	messageDeletedPartly     = ":warning: **The data of <@%s> is deleted partly, %d items. Try `%smydata delete` again later**"
+)
+
+func (s *Service) sendComplexMessage(ds *discordgo.Session, channelID string, msg *discordgo.MessageSend) {
+	go func() {
+		_, err := ds.ChannelMessageSendComplex(channelID, msg)
+		if err != nil {
+			s.logger.Errorw("sending message",
+				"channel", channelID,
+				"msg", msg,
+				"err", err)
+		}
+	}()
+}
+
+func (s *Service) sendStringMessage(ds *discordgo.Session, channelID, msg string) {
+	s.sendComplexMessage(ds, channelID, &discordgo.MessageSend{Content: msg})
+}
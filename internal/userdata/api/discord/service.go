@@ -0,0 +1,183 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/userdata"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Name of the cog in the config
+const Name = "mydata"
+
+const (
+	mydataCommand = "mydata"
+	// deleteButtonPrefix the confirmation token follows it
+	deleteButtonPrefix = "mydata:delete:"
+)
+
+type UserData interface {
+	Export(ctx context.Context, userID string) (*userdata.Export, error)
+	Confirm(userID string) (string, error)
+	Delete(ctx context.Context, userID, token string) (*userdata.Deletion, error)
+}
+
+type Service struct {
+	ctx      context.Context
+	userData UserData
+	prefix   string
+	logger   zap.Logger
+}
+
+func NewCog(ctx context.Context, userData UserData, prefix string, logger zap.Logger) *Service {
+	return &Service{
+		ctx:      ctx,
+		userData: userData,
+		prefix:   prefix,
+		logger:   logger,
+	}
+}
+
+func (s *Service) Name() string {
+	return Name
+}
+
+func (s *Service) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	command.NewMessageCommand(s.prefix+mydataCommand, s.mydataMessageHandler, debug).RegisterCommand(session, logger)
+	command.NewComponentCommand(deleteButtonPrefix, s.deleteButtonHandler).RegisterCommand(session, logger)
+}
+
+// RegisterRoutes the api of the data is served without the cog, see internal/userdata/api/rest
+func (s *Service) RegisterRoutes(_ *gin.RouterGroup) {}
+
+func (s *Service) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func (s *Service) mydataMessageHandler(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	args := strings.Fields(strings.TrimPrefix(m.Content, s.prefix+mydataCommand))
+	if len(args) != 1 {
+		s.sendStringMessage(ds, m.ChannelID, fmt.Sprintf(messageUsage, s.prefix))
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "export":
+		s.export(ds, m)
+	case "delete":
+		s.confirmDeletion(ds, m)
+	default:
+		s.sendStringMessage(ds, m.ChannelID, fmt.Sprintf(messageUsage, s.prefix))
+	}
+}
+
+// export the file is sent in DM, the data of the user isn't posted in the channel
+func (s *Service) export(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	e, err := s.userData.Export(s.ctx, m.Author.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "export user data"))
+		s.sendStringMessage(ds, m.ChannelID, messageInternalError)
+		return
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "marshal user data"))
+		s.sendStringMessage(ds, m.ChannelID, messageInternalError)
+		return
+	}
+	channel, err := ds.UserChannelCreate(m.Author.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "create dm channel"))
+		s.sendStringMessage(ds, m.ChannelID, messageInternalError)
+		return
+	}
+	_, err = ds.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+		Content: messageExport,
+		Files: []*discordgo.File{{
+			Name:        "mydata-" + m.Author.ID + ".json",
+			ContentType: "application/json",
+			Reader:      bytes.NewReader(data),
+		}},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "send user data"))
+		s.sendStringMessage(ds, m.ChannelID, messageDMClosed)
+		return
+	}
+	if m.GuildID != "" {
+		s.sendStringMessage(ds, m.ChannelID, messageExportSent)
+	}
+}
+
+// confirmDeletion the button works only for the author for 5 minutes
+func (s *Service) confirmDeletion(ds *discordgo.Session, m *discordgo.MessageCreate) {
+	token, err := s.userData.Confirm(m.Author.ID)
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "confirm deletion"))
+		s.sendStringMessage(ds, m.ChannelID, messageInternalError)
+		return
+	}
+	s.sendComplexMessage(ds, m.ChannelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf(messageConfirm, m.Author.ID),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Delete my data",
+					Style:    discordgo.DangerButton,
+					CustomID: deleteButtonPrefix + token,
+				},
+			}},
+		},
+	})
+}
+
+func (s *Service) deleteButtonHandler(ds *discordgo.Session, i *discordgo.InteractionCreate, token string) {
+	user := command.InteractionUser(i)
+	if user == nil {
+		return
+	}
+	// the deletion may take longer than discord waits for the response
+	err := ds.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "respond to delete button"))
+		return
+	}
+	d, err := s.userData.Delete(s.ctx, user.ID, token)
+	if errors.Is(err, userdata.ErrWrongConfirmation) {
+		s.followup(ds, i, messageWrongConfirmation)
+		return
+	}
+	content := fmt.Sprintf(messageDeleted, user.ID, d.Total())
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "delete user data"))
+		content = fmt.Sprintf(messageDeletedPartly, user.ID, d.Total(), s.prefix)
+	}
+	// the empty components remove the button
+	_, err = ds.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    content,
+		Components: []discordgo.MessageComponent{},
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "edit delete confirmation"))
+	}
+}
+
+func (s *Service) followup(ds *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	_, err := ds.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   uint64(discordgo.MessageFlagsEphemeral),
+	})
+	if err != nil {
+		s.logger.Error(errors.Wrap(err, "send followup"))
+	}
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/music/storage/firestore"
+	"github.com/HalvaPovidlo/discordBotGo/internal/userdata"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/discord/command"
+)
+
+// export godoc
+// @summary   Everything the bot keeps about the user of the token
+// @produce   json
+// @success   200  {object}  userdata.Export
+// @failure   401  {object}  Response  "The client isn't linked"
+// @failure   503  {object}  Response  "Firestore is unavailable"
+// @failure   500  {object}  Response  "Database error"
+// @security  AccountToken
+// @router    /mydata [get]
+func (h *Handler) exportHandler(c *gin.Context) {
+	e := command.Execution{Command: "GET /mydata", UserID: v1.UserID(c), Outcome: command.OutcomeOK, Time: time.Now()}
+	data, err := h.userData.Export(c.Request.Context(), e.UserID)
+	if err != nil {
+		e.Outcome = err.Error()
+		command.Record(e)
+		if errors.Is(err, firestore.ErrOffline) {
+			c.JSON(http.StatusServiceUnavailable, Response{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	command.Record(e)
+	c.JSON(http.StatusOK, data)
+}
+
+// confirm godoc
+// @summary   The token to confirm the deletion of the data of the user with
+// @produce   json
+// @success   200  {object}  ConfirmResponse
+// @failure   401  {object}  Response  "The client isn't linked"
+// @failure   500  {object}  Response  "Internal error"
+// @security  AccountToken
+// @router    /mydata/confirm [post]
+func (h *Handler) confirmHandler(c *gin.Context) {
+	token, err := h.userData.Confirm(v1.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ConfirmResponse{Token: token})
+}
+
+// delete godoc
+// @summary   Erase the data of the user of the token, the linked clients stop working
+// @accept    json
+// @produce   json
+// @param     request  body      deleteRequest      true  "The token from /mydata/confirm"
+// @success   200      {object}  userdata.Deletion  "How many items were erased"
+// @failure   400      {object}  Response           "Incorrect input"
+// @failure   401      {object}  Response           "The client isn't linked"
+// @failure   403      {object}  Response           "Wrong or expired confirmation"
+// @failure   500      {object}  Response           "The data is erased partly, the deletion can be repeated"
+// @security  AccountToken
+// @router    /mydata [delete]
+func (h *Handler) deleteHandler(c *gin.Context) {
+	var req deleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Message: err.Error()})
+		return
+	}
+	e := command.Execution{Command: "DELETE /mydata", UserID: v1.UserID(c), Outcome: command.OutcomeOK, Time: time.Now()}
+	d, err := h.userData.Delete(c.Request.Context(), e.UserID, req.Token)
+	if errors.Is(err, userdata.ErrWrongConfirmation) {
+		c.JSON(http.StatusForbidden, Response{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		e.Outcome = err.Error()
+		command.Record(e)
+		c.JSON(http.StatusInternalServerError, Response{Message: err.Error()})
+		return
+	}
+	command.Record(e)
+	c.JSON(http.StatusOK, d)
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/HalvaPovidlo/discordBotGo/internal/account"
+	v1 "github.com/HalvaPovidlo/discordBotGo/internal/api/v1"
+	"github.com/HalvaPovidlo/discordBotGo/internal/userdata"
+)
+
+type UserData interface {
+	Export(ctx context.Context, userID string) (*userdata.Export, error)
+	Confirm(userID string) (string, error)
+	Delete(ctx context.Context, userID, token string) (*userdata.Deletion, error)
+}
+
+// Handler the super group must identify the users with v1.Identify
+type Handler struct {
+	userData UserData
+	super    *gin.RouterGroup
+}
+
+func NewHandler(userData UserData, superGroup *gin.RouterGroup) *Handler {
+	return &Handler{
+		userData: userData,
+		super:    superGroup,
+	}
+}
+
+func (h *Handler) Router() *gin.RouterGroup {
+	group := h.super.Group("/mydata", v1.RequireUser(), v1.RequireScope(account.ScopeMyData))
+	group.GET("", h.exportHandler)
+	group.POST("/confirm", h.confirmHandler)
+	group.DELETE("", h.deleteHandler)
+	return group
+}
+
+type Response struct {
+	Message string `json:"message"`
+}
+
+type ConfirmResponse struct {
+	// Token confirms the deletion once for 5 minutes
+	Token string `json:"token"`
+}
+
+type deleteRequest struct {
+	Token string `json:"token" binding:"required"`
+}
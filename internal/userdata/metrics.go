@@ -0,0 +1,21 @@
+package userdata
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	exports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "userdata",
+		Name:      "exports_total",
+		Help:      "Exports of the data of the users by the result.",
+	}, []string{"result"})
+	deletions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "userdata",
+		Name:      "deletions_total",
+		Help:      "Confirmed deletions of the data of the users by the result.",
+	}, []string{"result"})
+)
@@ -0,0 +1,153 @@
+package userdata
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	confirmExpiration = 5 * time.Minute
+	confirmBytes      = 16
+)
+
+var ErrWrongConfirmation = errors.New("wrong or expired confirmation")
+
+// ExportFunc returns the data of the user kept by the source, nil if there is none
+type ExportFunc func(ctx context.Context, userID string) (interface{}, error)
+
+// EraseFunc deletes or anonymizes the data of the user, returns how many items it changed
+type EraseFunc func(ctx context.Context, userID string) (int, error)
+
+type source struct {
+	name   string
+	export ExportFunc
+	erase  EraseFunc
+}
+
+// Export of everything stored about the user, the data of every source under its name
+type Export struct {
+	UserID  string                 `json:"user_id"`
+	Created time.Time              `json:"created"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// Deletion how many items every source erased
+type Deletion struct {
+	UserID string         `json:"user_id"`
+	Erased map[string]int `json:"erased"`
+}
+
+// Total of the erased items
+func (d *Deletion) Total() int {
+	n := 0
+	for _, v := range d.Erased {
+		n += v
+	}
+	return n
+}
+
+type pending struct {
+	userID  string
+	created time.Time
+}
+
+// Service exports and erases the data of a user in every module that stores it
+type Service struct {
+	sources []source
+
+	mx      sync.Mutex
+	pending map[string]pending // confirmation token
+}
+
+func NewService() *Service {
+	return &Service{
+		pending: make(map[string]pending),
+	}
+}
+
+// Add the source, a nil export or erase means the source only erases or only exports
+func (s *Service) Add(name string, export ExportFunc, erase EraseFunc) {
+	s.sources = append(s.sources, source{name: name, export: export, erase: erase})
+}
+
+// Export fails if any source fails, a partial export would look like the whole data
+func (s *Service) Export(ctx context.Context, userID string) (*Export, error) {
+	e := &Export{UserID: userID, Created: time.Now().UTC(), Data: make(map[string]interface{}, len(s.sources))}
+	for _, src := range s.sources {
+		if src.export == nil {
+			continue
+		}
+		data, err := src.export(ctx, userID)
+		if err != nil {
+			exports.WithLabelValues("error").Inc()
+			return nil, errors.Wrapf(err, "export %s", src.name)
+		}
+		e.Data[src.name] = data
+	}
+	exports.WithLabelValues("ok").Inc()
+	return e, nil
+}
+
+// Confirm returns the token the deletion is confirmed with, it works once for 5 minutes
+func (s *Service) Confirm(userID string) (string, error) {
+	b := make([]byte, confirmBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "generate token")
+	}
+	token := hex.EncodeToString(b)
+	now := time.Now()
+	s.mx.Lock()
+	for k, v := range s.pending {
+		if now.Sub(v.created) > confirmExpiration {
+			delete(s.pending, k)
+		}
+	}
+	s.pending[token] = pending{userID: userID, created: now}
+	s.mx.Unlock()
+	return token, nil
+}
+
+// Delete erases the data of the user confirmed with the token from Confirm.
+// Every source is tried even if some fail, the deletion can be repeated for the failed ones.
+func (s *Service) Delete(ctx context.Context, userID, token string) (*Deletion, error) {
+	s.mx.Lock()
+	p, ok := s.pending[token]
+	if ok && p.userID == userID {
+		delete(s.pending, token)
+	}
+	s.mx.Unlock()
+	if !ok || p.userID != userID || time.Since(p.created) > confirmExpiration {
+		return nil, ErrWrongConfirmation
+	}
+
+	d := &Deletion{UserID: userID, Erased: make(map[string]int, len(s.sources))}
+	failed := make([]string, 0)
+	var first error
+	for _, src := range s.sources {
+		if src.erase == nil {
+			continue
+		}
+		n, err := src.erase(ctx, userID)
+		d.Erased[src.name] = n
+		if err != nil {
+			failed = append(failed, src.name)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	if len(failed) != 0 {
+		deletions.WithLabelValues("error").Inc()
+		sort.Strings(failed)
+		return d, errors.Wrapf(first, "erase %s", strings.Join(failed, ", "))
+	}
+	deletions.WithLabelValues("ok").Inc()
+	return d, nil
+}
@@ -0,0 +1,93 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrOpen the call was rejected without trying
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Breaker opens after threshold failures in a row and rejects the calls for the backoff.
+// Then a single call probes the service: success closes the breaker, failure doubles the backoff up to maxBackoff.
+type Breaker struct {
+	name       string
+	threshold  int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mx        sync.Mutex
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+	probing   bool
+}
+
+func New(name string, threshold int, minBackoff, maxBackoff time.Duration) *Breaker {
+	breakerOpen.WithLabelValues(name).Set(0)
+	return &Breaker{
+		name:       name,
+		threshold:  threshold,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		backoff:    minBackoff,
+	}
+}
+
+// Do the errors of f are returned as is
+func (b *Breaker) Do(f func() error) error {
+	if !b.allow() {
+		breakerRejections.WithLabelValues(b.name).Inc()
+		return errors.Wrap(ErrOpen, b.name)
+	}
+	err := f()
+	b.done(err == nil)
+	return err
+}
+
+// Open is true while the calls are rejected
+func (b *Breaker) Open() bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.failures >= b.threshold && (b.probing || time.Now().Before(b.openUntil))
+}
+
+func (b *Breaker) allow() bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *Breaker) done(ok bool) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	probe := b.probing
+	b.probing = false
+	if ok {
+		b.failures = 0
+		b.backoff = b.minBackoff
+		breakerOpen.WithLabelValues(b.name).Set(0)
+		return
+	}
+	b.failures++
+	if b.failures < b.threshold {
+		return
+	}
+	if probe {
+		b.backoff *= 2
+		if b.backoff > b.maxBackoff {
+			b.backoff = b.maxBackoff
+		}
+	}
+	b.openUntil = time.Now().Add(b.backoff)
+	breakerOpen.WithLabelValues(b.name).Set(1)
+}
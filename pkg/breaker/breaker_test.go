@@ -0,0 +1,74 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestBreaker(t *testing.T) {
+	errFail := errors.New("fail")
+	fail := func() error { return errFail }
+	ok := func() error { return nil }
+
+	type step struct {
+		f    func() error
+		wait time.Duration
+		err  error
+	}
+
+	testCases := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after threshold",
+			steps: []step{
+				{f: fail, err: errFail},
+				{f: fail, err: errFail},
+				{f: ok, err: ErrOpen},
+			},
+		},
+		{
+			name: "success resets failures",
+			steps: []step{
+				{f: fail, err: errFail},
+				{f: ok},
+				{f: fail, err: errFail},
+				{f: ok},
+			},
+		},
+		{
+			name: "probe closes",
+			steps: []step{
+				{f: fail, err: errFail},
+				{f: fail, err: errFail},
+				{f: ok, wait: 20 * time.Millisecond},
+				{f: ok},
+			},
+		},
+		{
+			name: "failed probe doubles backoff",
+			steps: []step{
+				{f: fail, err: errFail},
+				{f: fail, err: errFail},
+				{f: fail, wait: 20 * time.Millisecond, err: errFail},
+				{f: ok, wait: 20 * time.Millisecond, err: ErrOpen},
+				{f: ok, wait: 20 * time.Millisecond},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		b := New("test", 2, 15*time.Millisecond, time.Second)
+		for j, s := range tc.steps {
+			time.Sleep(s.wait)
+			err := b.Do(s.f)
+			if !errors.Is(err, s.err) {
+				t.Errorf("%s step %d: got %v, wanted %v", tc.name, j, err, s.err)
+			}
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package breaker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	breakerOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "halvabot",
+		Subsystem: "breaker",
+		Name:      "open",
+		Help:      "1 while the circuit breaker rejects the calls.",
+	}, []string{"name"})
+	breakerRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "breaker",
+		Name:      "rejections_total",
+		Help:      "Calls rejected by the open circuit breaker.",
+	}, []string{"name"})
+)
@@ -12,18 +12,13 @@ const (
 	loggerKey key = "loggerKey"
 )
 
-// Context TODO write normal context logger
-type Context struct {
-	context.Context
+// WithLogger the logger is returned by LoggerFromContext for the context and its children
+func WithLogger(ctx context.Context, logger zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
 }
 
-var Background = context.Background
-
-func WithLogger(ctx context.Context, logger zap.Logger) (Context, context.CancelFunc) {
-	ctx, f := context.WithCancel(context.WithValue(ctx, loggerKey, logger))
-	return Context{ctx}, f
-}
-func (ctx Context) LoggerFromContext() zap.Logger {
+// LoggerFromContext falls back to the development logger if the context has none
+func LoggerFromContext(ctx context.Context) zap.Logger {
 	if logger, ok := ctx.Value(loggerKey).(zap.Logger); ok {
 		return logger
 	}
@@ -0,0 +1,116 @@
+package dependency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Step of the startup which needs the dependency, it is repeated when the dependency comes back
+type Step func(ctx context.Context) error
+
+// Dependency an external service the bot can start without.
+// The subsystems which failed to start register their steps and work degraded until Watch repeats them.
+type Dependency struct {
+	name   string
+	probe  Step
+	logger zap.Logger
+
+	mx      sync.Mutex
+	pending []Step
+}
+
+func New(name string, probe Step, logger zap.Logger) *Dependency {
+	dependencyUp.WithLabelValues(name).Set(1)
+	return &Dependency{
+		name:   name,
+		probe:  probe,
+		logger: logger,
+	}
+}
+
+func (d *Dependency) Name() string {
+	return d.name
+}
+
+// Available is false until all failed steps are repeated successfully
+func (d *Dependency) Available() bool {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	return len(d.pending) == 0
+}
+
+// Fail marks the dependency down, the step is repeated once the probe passes
+func (d *Dependency) Fail(err error, step Step) {
+	d.logger.Warnw("dependency is unavailable, running degraded",
+		"dependency", d.name,
+		"err", err)
+	d.mx.Lock()
+	d.pending = append(d.pending, step)
+	d.mx.Unlock()
+	dependencyUp.WithLabelValues(d.name).Set(0)
+}
+
+// Run runs the step now if the dependency is available, otherwise or if the step fails it is repeated on recovery
+func (d *Dependency) Run(ctx context.Context, step Step) bool {
+	if !d.Available() {
+		d.mx.Lock()
+		d.pending = append(d.pending, step)
+		d.mx.Unlock()
+		return false
+	}
+	if err := step(ctx); err != nil {
+		d.Fail(err, step)
+		return false
+	}
+	return true
+}
+
+// Watch probes the dependency while it is down, the failed steps stay pending for the next try
+func (d *Dependency) Watch(ctx context.Context, interval time.Duration) {
+	supervisor.Go(ctx, d.logger, "dependency "+d.name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if !d.Available() {
+					d.recover(ctx)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+func (d *Dependency) recover(ctx context.Context) {
+	if err := d.probe(ctx); err != nil {
+		return
+	}
+	d.mx.Lock()
+	steps := d.pending
+	d.pending = nil
+	d.mx.Unlock()
+	failed := make([]Step, 0)
+	for _, step := range steps {
+		if err := step(ctx); err != nil {
+			d.logger.Error(errors.Wrapf(err, "recover with %s", d.name))
+			failed = append(failed, step)
+		}
+	}
+	d.mx.Lock()
+	// steps could fail while these ran
+	d.pending = append(failed, d.pending...)
+	up := len(d.pending) == 0
+	d.mx.Unlock()
+	if up {
+		dependencyUp.WithLabelValues(d.name).Set(1)
+		d.logger.Infow("dependency recovered", "dependency", d.name)
+	}
+}
@@ -0,0 +1,13 @@
+package dependency
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var dependencyUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "halvabot",
+	Subsystem: "dependency",
+	Name:      "up",
+	Help:      "0 while the bot runs degraded without the dependency.",
+}, []string{"name"})
@@ -0,0 +1,96 @@
+package cog
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// Cog is a module of the bot with its discord commands and http routes
+type Cog interface {
+	// Name is used to enable the cog in the config
+	Name() string
+	RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger)
+	RegisterRoutes(router *gin.RouterGroup)
+	// Shutdown is called before the bot goes down, commands are not accepted anymore
+	Shutdown(ctx context.Context) error
+}
+
+// Registry keeps the cogs enabled in the config in the order they were added
+type Registry struct {
+	enabled map[string]bool
+	added   map[string]bool
+	cogs    []Cog
+}
+
+func NewRegistry(enabled []string) *Registry {
+	r := &Registry{
+		enabled: make(map[string]bool, len(enabled)),
+		added:   make(map[string]bool, len(enabled)),
+	}
+	for _, name := range enabled {
+		r.enabled[name] = true
+	}
+	return r
+}
+
+// Enabled is used to skip building the cogs that are not listed in the config
+func (r *Registry) Enabled(name string) bool {
+	return r.enabled[name]
+}
+
+// Add skips the cog if it is not enabled
+func (r *Registry) Add(c Cog) {
+	if !r.enabled[c.Name()] || r.added[c.Name()] {
+		return
+	}
+	r.added[c.Name()] = true
+	r.cogs = append(r.cogs, c)
+}
+
+// Validate reports the enabled cogs which were never added
+func (r *Registry) Validate() error {
+	unknown := make([]string, 0)
+	for name := range r.enabled {
+		if !r.added[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return errors.Errorf("unknown cogs: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (r *Registry) RegisterCommands(session *discordgo.Session, debug bool, logger zap.Logger) {
+	for _, c := range r.cogs {
+		c.RegisterCommands(session, debug, logger)
+	}
+}
+
+func (r *Registry) RegisterRoutes(router *gin.RouterGroup) {
+	for _, c := range r.cogs {
+		c.RegisterRoutes(router)
+	}
+}
+
+// Shutdown goes in the reverse order and shuts down every cog even if some fail
+func (r *Registry) Shutdown(ctx context.Context) error {
+	failed := make([]string, 0)
+	for i := len(r.cogs) - 1; i >= 0; i-- {
+		if err := r.cogs[i].Shutdown(ctx); err != nil {
+			failed = append(failed, r.cogs[i].Name()+": "+err.Error())
+		}
+	}
+	if len(failed) != 0 {
+		return errors.Errorf("shutdown cogs: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	OutcomeOK    = "ok"
+	OutcomePanic = "panic"
+)
+
+// Execution of a command handler, Args are the text after the command name or the value of the component
+type Execution struct {
+	Command   string
+	Args      string
+	GuildID   string
+	ChannelID string
+	UserID    string
+	Outcome   string
+	Time      time.Time
+	Elapsed   time.Duration
+}
+
+// Auditor receives every executed command, it must not block
+type Auditor func(e Execution)
+
+var auditor struct {
+	sync.RWMutex
+	audit Auditor
+}
+
+func SetAuditor(a Auditor) {
+	auditor.Lock()
+	auditor.audit = a
+	auditor.Unlock()
+}
+
+// Record an execution that didn't come from Discord, e.g. through the api
+func Record(e Execution) {
+	outcome := e.Outcome
+	audit(&e, &outcome)
+}
+
+// audit is deferred before the handler runs, so the outcome stays OutcomePanic if the handler doesn't return
+func audit(e *Execution, outcome *string) {
+	auditor.RLock()
+	a := auditor.audit
+	auditor.RUnlock()
+	if a == nil {
+		return
+	}
+	e.Outcome = *outcome
+	e.Elapsed = time.Since(e.Time)
+	a(*e)
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ChannelFilter decides whether the commands of the group work in the channel of the message,
+// it tells the author where to go when they don't
+type ChannelFilter func(s *discordgo.Session, m *discordgo.MessageCreate, group string) bool
+
+var channelFilter struct {
+	sync.RWMutex
+	filter ChannelFilter
+}
+
+// SetChannelFilter is consulted only for the commands in a group
+func SetChannelFilter(filter ChannelFilter) {
+	channelFilter.Lock()
+	channelFilter.filter = filter
+	channelFilter.Unlock()
+}
+
+func allows(s *discordgo.Session, m *discordgo.MessageCreate, group string) bool {
+	if group == "" {
+		return true
+	}
+	channelFilter.RLock()
+	filter := channelFilter.filter
+	channelFilter.RUnlock()
+	return filter == nil || filter(s, m, group)
+}
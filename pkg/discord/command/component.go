@@ -0,0 +1,94 @@
+package command
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+// ComponentHandler receives the part of CustomID after the Component.Prefix
+type ComponentHandler func(s *discordgo.Session, i *discordgo.InteractionCreate, value string)
+
+type Component struct {
+	handler ComponentHandler
+	Prefix  string
+}
+
+// NewComponentCommand handles clicks on message components which CustomID starts with prefix
+func NewComponentCommand(prefix string, handler ComponentHandler) *Component {
+	return &Component{
+		handler: handler,
+		Prefix:  prefix,
+	}
+}
+
+func (c *Component) RegisterCommand(s *discordgo.Session, logger zap.Logger) {
+	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		if i.Type != discordgo.InteractionMessageComponent || !accepting() {
+			return
+		}
+		id := i.MessageComponentData().CustomID
+		if !strings.HasPrefix(id, c.Prefix) || !handles(i.GuildID) {
+			return
+		}
+		logger.Infow("component command handled",
+			"component", c.Prefix,
+			"customID", id)
+		outcome := OutcomePanic
+		e := &Execution{
+			Command:   c.Prefix,
+			Args:      strings.TrimPrefix(id, c.Prefix),
+			GuildID:   i.GuildID,
+			ChannelID: i.ChannelID,
+			Time:      time.Now(),
+		}
+		if u := InteractionUser(i); u != nil {
+			e.UserID = u.ID
+		}
+		defer audit(e, &outcome)
+		defer supervisor.Recover(logger, "command", "command", c.Prefix, "guild", i.GuildID)
+		c.handler(s, i, e.Args)
+		outcome = OutcomeOK
+	})
+}
+
+// InteractionUser returns the author of the interaction both in guilds and DMs
+func InteractionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// RespondEphemeral answers the interaction with the message only its author can see
+func RespondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   uint64(discordgo.MessageFlagsEphemeral),
+		},
+	})
+}
+
+// ButtonRows splits buttons into action rows, discord allows 5 buttons per row
+func ButtonRows(buttons []discordgo.Button) []discordgo.MessageComponent {
+	const perRow = 5
+	rows := make([]discordgo.MessageComponent, 0, (len(buttons)+perRow-1)/perRow)
+	for i := 0; i < len(buttons); i += perRow {
+		end := i + perRow
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		row := discordgo.ActionsRow{}
+		for _, b := range buttons[i:end] {
+			row.Components = append(row.Components, b)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,30 @@
+package command
+
+import "sync"
+
+// GuildFilter decides whether this instance handles the commands of the guild, guildID is empty in DMs
+type GuildFilter func(guildID string) bool
+
+var guildFilter struct {
+	sync.RWMutex
+	filter GuildFilter
+}
+
+// SetGuildFilter is consulted only for the messages that match a command
+func SetGuildFilter(filter GuildFilter) {
+	guildFilter.Lock()
+	guildFilter.filter = filter
+	guildFilter.Unlock()
+}
+
+// Handles the guild, for the handlers of the events which aren't commands
+func Handles(guildID string) bool {
+	return handles(guildID)
+}
+
+func handles(guildID string) bool {
+	guildFilter.RLock()
+	filter := guildFilter.filter
+	guildFilter.RUnlock()
+	return filter == nil || filter(guildID)
+}
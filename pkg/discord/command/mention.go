@@ -0,0 +1,44 @@
+package command
+
+import (
+	"strings"
+	"sync"
+)
+
+// MentionParser turns the text addressed to the bot into a command with the default prefix, ok is false if it isn't one
+type MentionParser func(text string) (command string, ok bool)
+
+var mentionParsers struct {
+	sync.RWMutex
+	parsers []MentionParser
+}
+
+// AddMentionParser lets the messages starting with the mention of the bot run the commands without the prefix.
+// The parsers are tried in the order they were added.
+func AddMentionParser(p MentionParser) {
+	mentionParsers.Lock()
+	mentionParsers.parsers = append(mentionParsers.parsers, p)
+	mentionParsers.Unlock()
+}
+
+// parseMention returns the content as is if it doesn't start with the mention of the bot or no parser knows the text
+func parseMention(botID, content string) string {
+	text := ""
+	for _, mention := range []string{"<@" + botID + ">", "<@!" + botID + ">"} {
+		if strings.HasPrefix(content, mention) {
+			text = strings.TrimSpace(content[len(mention):])
+			break
+		}
+	}
+	if text == "" {
+		return content
+	}
+	mentionParsers.RLock()
+	defer mentionParsers.RUnlock()
+	for _, p := range mentionParsers.parsers {
+		if command, ok := p(text); ok {
+			return command
+		}
+	}
+	return content
+}
@@ -8,17 +8,21 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/HalvaPovidlo/discordBotGo/pkg/discord"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
 
-const maxMessageLength = 50
+// MaxMessageLength the shorter messages are lowercased before the commands get them
+const MaxMessageLength = 50
 
 type MessageHandler func(s *discordgo.Session, m *discordgo.MessageCreate)
 
 type Message struct {
-	handler MessageHandler
-	Name    string
-	debug   bool
+	handler  MessageHandler
+	Name     string
+	debug    bool
+	group    string
+	keepCase bool
 }
 
 // NewMessageCommand Message.Name should be passed with prefix
@@ -30,26 +34,56 @@ func NewMessageCommand(name string, handler MessageHandler, debug bool) *Message
 	}
 }
 
+// InGroup the command works only in the channels the guild allows for the group
+func (m *Message) InGroup(group string) *Message {
+	m.group = group
+	return m
+}
+
+// KeepCase the message isn't lowercased, the name matches in any case and the arguments are passed as typed
+func (m *Message) KeepCase() *Message {
+	m.keepCase = true
+	return m
+}
+
 // RegisterCommand checks is every message starts with Message.Name and is it self-message than runs Message.handler
 func (m *Message) RegisterCommand(s *discordgo.Session, logger zap.Logger) {
 	s.AddHandler(func(s *discordgo.Session, i *discordgo.MessageCreate) {
-		if i.Author.ID == s.State.User.ID {
+		if i.Author.ID == s.State.User.ID || !accepting() {
 			return
 		}
 		if (i.ChannelID == discord.ChannelDebugID) != m.debug {
 			return
 		}
-		if len(i.Content) <= maxMessageLength {
-			i.Content = strings.ToLower(i.Content)
+		content := normalizePrefix(i.GuildID, parseMention(s.State.User.ID, i.Content))
+		if !m.keepCase && len(content) <= MaxMessageLength {
+			content = strings.ToLower(content)
 		}
-		if strings.HasPrefix(i.Content, m.Name) {
+		if m.matches(content) {
+			if !handles(i.GuildID) || !allows(s, i, m.group) {
+				return
+			}
+			// handlers run concurrently, so every command gets its own copy of the message
+			msg := *i.Message
+			msg.Content = m.Name + content[len(m.Name):]
 			uid := uuid.New()
 			logger.Infow("message command handled",
 				"command", m.Name,
-				"query", i.Content,
+				"query", msg.Content,
 				"traceID", uid)
 			start := time.Now()
-			m.handler(s, i)
+			outcome := OutcomePanic
+			defer audit(&Execution{
+				Command:   m.Name,
+				Args:      strings.TrimSpace(msg.Content[len(m.Name):]),
+				GuildID:   msg.GuildID,
+				ChannelID: msg.ChannelID,
+				UserID:    msg.Author.ID,
+				Time:      start,
+			}, &outcome)
+			defer supervisor.Recover(logger, "command", "command", m.Name, "guild", msg.GuildID)
+			m.handler(s, &discordgo.MessageCreate{Message: &msg})
+			outcome = OutcomeOK
 			logger.Infow("command executed",
 				"command", m.Name,
 				"traceID", uid,
@@ -57,3 +91,10 @@ func (m *Message) RegisterCommand(s *discordgo.Session, logger zap.Logger) {
 		}
 	})
 }
+
+func (m *Message) matches(content string) bool {
+	if m.keepCase {
+		return len(content) >= len(m.Name) && strings.EqualFold(content[:len(m.Name)], m.Name)
+	}
+	return strings.HasPrefix(content, m.Name)
+}
@@ -0,0 +1,44 @@
+package command
+
+import (
+	"strings"
+	"sync"
+)
+
+// PrefixResolver returns the custom command prefix of the guild
+type PrefixResolver func(guildID string) string
+
+var prefixes struct {
+	sync.RWMutex
+	defaultPrefix string
+	resolve       PrefixResolver
+}
+
+// SetPrefixResolver lets guilds use their own prefix instead of the default one the commands are registered with.
+// The default prefix keeps working everywhere.
+func SetPrefixResolver(defaultPrefix string, resolve PrefixResolver) {
+	prefixes.Lock()
+	prefixes.defaultPrefix = defaultPrefix
+	prefixes.resolve = resolve
+	prefixes.Unlock()
+}
+
+// NormalizePrefix for the handlers of the messages which aren't commands
+func NormalizePrefix(guildID, content string) string {
+	return normalizePrefix(guildID, content)
+}
+
+// normalizePrefix replaces the guild prefix with the default one
+func normalizePrefix(guildID, content string) string {
+	prefixes.RLock()
+	defaultPrefix, resolve := prefixes.defaultPrefix, prefixes.resolve
+	prefixes.RUnlock()
+	if resolve == nil || guildID == "" {
+		return content
+	}
+	prefix := resolve(guildID)
+	if prefix == "" || prefix == defaultPrefix || !strings.HasPrefix(content, prefix) {
+		return content
+	}
+	return defaultPrefix + content[len(prefix):]
+}
@@ -0,0 +1,14 @@
+package command
+
+import "sync/atomic"
+
+var stopped int32
+
+// StopAccepting makes all registered commands ignore new messages and interactions, used on shutdown
+func StopAccepting() {
+	atomic.StoreInt32(&stopped, 1)
+}
+
+func accepting() bool {
+	return atomic.LoadInt32(&stopped) == 0
+}
@@ -1,6 +1,15 @@
 package command
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/supervisor"
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
 
 type slashHandler func(s *discordgo.Session, i *discordgo.InteractionCreate)
 
@@ -38,3 +47,68 @@ func (c *Slash) RegisterCommand(s *discordgo.Session) (unregisterCommand func())
 		s.ApplicationCommandDelete(s.State.User.ID, "", cmd.ID)
 	}
 }
+
+// GuildSlashHandler receives the slash command of a guild
+type GuildSlashHandler func(s *discordgo.Session, i *discordgo.InteractionCreate)
+
+// GuildSlash is registered per guild, a guild gets all of them when the bot joins it
+type GuildSlash struct {
+	handler GuildSlashHandler
+	Command *discordgo.ApplicationCommand
+}
+
+var guildSlashes struct {
+	sync.Mutex
+	commands []*discordgo.ApplicationCommand
+}
+
+func NewGuildSlashCommand(command *discordgo.ApplicationCommand, handler GuildSlashHandler) *GuildSlash {
+	return &GuildSlash{
+		handler: handler,
+		Command: command,
+	}
+}
+
+// RegisterCommand handles the command, RegisterGuildCommands creates it in a guild
+func (c *GuildSlash) RegisterCommand(s *discordgo.Session, logger zap.Logger) {
+	guildSlashes.Lock()
+	guildSlashes.commands = append(guildSlashes.commands, c.Command)
+	guildSlashes.Unlock()
+	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		if i.Type != discordgo.InteractionApplicationCommand || i.GuildID == "" || !accepting() {
+			return
+		}
+		if i.ApplicationCommandData().Name != c.Command.Name || !handles(i.GuildID) {
+			return
+		}
+		logger.Infow("slash command handled",
+			"command", c.Command.Name,
+			"guild", i.GuildID)
+		outcome := OutcomePanic
+		e := &Execution{
+			Command:   "/" + c.Command.Name,
+			GuildID:   i.GuildID,
+			ChannelID: i.ChannelID,
+			Time:      time.Now(),
+		}
+		if u := InteractionUser(i); u != nil {
+			e.UserID = u.ID
+		}
+		defer audit(e, &outcome)
+		defer supervisor.Recover(logger, "command", "command", c.Command.Name, "guild", i.GuildID)
+		c.handler(s, i)
+		outcome = OutcomeOK
+	})
+}
+
+// RegisterGuildCommands replaces the slash commands of the guild with the registered ones
+func RegisterGuildCommands(s *discordgo.Session, guildID string) error {
+	guildSlashes.Lock()
+	commands := make([]*discordgo.ApplicationCommand, len(guildSlashes.commands))
+	copy(commands, guildSlashes.commands)
+	guildSlashes.Unlock()
+	if _, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, commands); err != nil {
+		return errors.Wrapf(err, "register %d slash commands in %s", len(commands), guildID)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package discord
 
 import (
 	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
 
 	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
 )
@@ -49,3 +50,26 @@ func OpenSession(token string, debug bool, logger zap.Logger) (*discordgo.Sessio
 	logger.Infow("Bot session opened", "SessionID", session.State.SessionID)
 	return session, nil
 }
+
+// HasPermission checks the permission of the user in the channel, false if it can't be fetched
+func HasPermission(s *discordgo.Session, userID, channelID string, permission int64) bool {
+	perms, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		return false
+	}
+	return perms&permission != 0
+}
+
+// VoiceChannelID the user is in, from the state of the session
+func VoiceChannelID(s *discordgo.Session, guildID, userID string) (string, error) {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return "", err
+	}
+	for _, voiceState := range guild.VoiceStates {
+		if voiceState.UserID == userID {
+			return voiceState.ChannelID, nil
+		}
+	}
+	return "", errors.New("unable to find user voice channel")
+}
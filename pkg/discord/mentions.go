@@ -0,0 +1,16 @@
+package discord
+
+import "regexp"
+
+var mentionRegexp = regexp.MustCompile(`<@!?(\d+)>`)
+
+// ParseMentions returns ids of mentioned users in the order they appear in the message.
+// discordgo.Message.Mentions doesn't keep the order.
+func ParseMentions(content string) []string {
+	matches := mentionRegexp.FindAllStringSubmatch(content, -1)
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m[1])
+	}
+	return ids
+}
@@ -24,3 +24,11 @@ func (c *Client) PostRequest(req *http.Request) (*http.Response, error) {
 	}
 	return resp, nil
 }
+
+func (c *Client) GetRequest(req *http.Request) (*http.Response, error) {
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
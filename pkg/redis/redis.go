@@ -0,0 +1,246 @@
+// Package redis is a small client of the Redis protocol (RESP2), enough for the keys, the lists and the scripts.
+// The commands of a client go one by one over a single connection, it connects again after the connection breaks.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const dialTimeout = 5 * time.Second
+
+// ErrNil the key or the element doesn't exist
+var ErrNil = errors.New("redis: nil")
+
+// Error is the error reply of the server, the connection stays usable
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+type Client struct {
+	addr     string
+	password string
+	db       int
+
+	mx   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// NewClient the url is redis://[:password@]host[:port][/db]
+func NewClient(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse redis url")
+	}
+	if u.Scheme != "redis" || u.Hostname() == "" {
+		return nil, errors.Errorf("redis url %s is not redis://host[:port][/db]", rawURL)
+	}
+	c := &Client{addr: u.Host}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		c.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, errors.Errorf("redis database %s is not a number", db)
+		}
+	}
+	return c, nil
+}
+
+// Do sends the command and reads the reply: a string, an int64, []byte, []interface{} or ErrNil
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return nil, errors.Wrap(err, "connect to redis")
+		}
+	}
+	reply, err := c.do(ctx, args)
+	if err != nil {
+		if _, ok := err.(Error); !ok && !errors.Is(err, ErrNil) {
+			c.close()
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Bytes of the bulk string reply
+func (c *Client) Bytes(ctx context.Context, args ...string) ([]byte, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, errors.Errorf("redis: %T reply is not a bulk string", reply)
+	}
+	return b, nil
+}
+
+// Int of the integer reply
+func (c *Client) Int(ctx context.Context, args ...string) (int64, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, errors.Errorf("redis: %T reply is not an integer", reply)
+	}
+	return n, nil
+}
+
+// List of the bulk strings, the nil elements are skipped
+func (c *Client) List(ctx context.Context, args ...string) ([][]byte, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("redis: %T reply is not an array", reply)
+	}
+	res := make([][]byte, 0, len(items))
+	for _, item := range items {
+		if b, ok := item.([]byte); ok {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+func (c *Client) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.close()
+	return nil
+}
+
+// connect must be called with the mutex held
+func (c *Client) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: dialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	c.conn, c.r, c.w = conn, bufio.NewReader(conn), bufio.NewWriter(conn)
+	if c.password != "" {
+		if _, err := c.do(ctx, []string{"AUTH", c.password}); err != nil {
+			c.close()
+			return errors.Wrap(err, "auth")
+		}
+	}
+	if c.db != 0 {
+		if _, err := c.do(ctx, []string{"SELECT", strconv.Itoa(c.db)}); err != nil {
+			c.close()
+			return errors.Wrap(err, "select database")
+		}
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, args []string) (interface{}, error) {
+	// no deadline without one in the context
+	deadline, _ := ctx.Deadline()
+	_ = c.conn.SetDeadline(deadline)
+	_, _ = c.w.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		_, _ = c.w.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n")
+		_, _ = c.w.WriteString(arg)
+		_, _ = c.w.WriteString("\r\n")
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, errors.Wrap(err, "send command")
+	}
+	return c.read()
+}
+
+func (c *Client) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "read reply")
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse integer reply")
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "parse bulk length")
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return nil, errors.Wrap(err, "read bulk string")
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "parse array length")
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := c.read()
+			if err != nil {
+				// the rest of the array has to be read anyway
+				if _, ok := err.(Error); !ok && !errors.Is(err, ErrNil) {
+					return nil, err
+				}
+				item = nil
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, errors.Errorf("redis: unexpected reply %q", line)
+}
+
+// close must be called with the mutex held
+func (c *Client) close() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.conn, c.r, c.w = nil, nil, nil
+}
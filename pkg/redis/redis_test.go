@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRead(t *testing.T) {
+	type test struct {
+		in  string
+		out interface{}
+		err error
+	}
+
+	testCases := []test{
+		{
+			in:  "+OK\r\n",
+			out: "OK",
+		},
+		{
+			in:  ":42\r\n",
+			out: int64(42),
+		},
+		{
+			in:  "$5\r\nhel\rl\r\n",
+			out: []byte("hel\rl"),
+		},
+		{
+			in:  "$0\r\n\r\n",
+			out: []byte{},
+		},
+		{
+			in:  "$-1\r\n",
+			err: ErrNil,
+		},
+		{
+			in:  "-ERR wrong type\r\n",
+			err: Error("ERR wrong type"),
+		},
+		{
+			// the nil and the error elements are nil, the rest of the array is read anyway
+			in:  "*4\r\n$1\r\na\r\n$-1\r\n:7\r\n-ERR no\r\n",
+			out: []interface{}{[]byte("a"), nil, int64(7), nil},
+		},
+		{
+			in:  "*0\r\n",
+			out: []interface{}{},
+		},
+		{
+			in:  "*-1\r\n",
+			err: ErrNil,
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		c := &Client{r: bufio.NewReader(strings.NewReader(tc.in))}
+		out, err := c.read()
+		if err != tc.err || !reflect.DeepEqual(out, tc.out) {
+			t.Errorf("input: %q got %#v %v, wanted %#v %v", tc.in, out, err, tc.out, tc.err)
+		}
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	testCases := []string{
+		"",
+		"\r\n",
+		"?what\r\n",
+		":four\r\n",
+		"$5\r\nhel",
+		"*2\r\n$1\r\na\r\n",
+	}
+
+	for _, in := range testCases {
+		c := &Client{r: bufio.NewReader(strings.NewReader(in))}
+		if out, err := c.read(); err == nil {
+			t.Errorf("input: %q got %#v, wanted an error", in, out)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	type test struct {
+		args  []string
+		sent  string
+		reply string
+		out   interface{}
+	}
+
+	testCases := []test{
+		{
+			args:  []string{"GET", "key"},
+			sent:  "*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n",
+			reply: "$5\r\nvalue\r\n",
+			out:   []byte("value"),
+		},
+		{
+			// the arguments are binary safe
+			args:  []string{"RPUSH", "q", "a b\r\nc", ""},
+			sent:  "*4\r\n$5\r\nRPUSH\r\n$1\r\nq\r\n$6\r\na b\r\nc\r\n$0\r\n\r\n",
+			reply: ":1\r\n",
+			out:   int64(1),
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		client, server := net.Pipe()
+		sent := make(chan string, 1)
+		go func() {
+			b := make([]byte, len(tc.sent))
+			_, _ = io.ReadFull(server, b)
+			sent <- string(b)
+			_, _ = server.Write([]byte(tc.reply))
+		}()
+		c := &Client{conn: client, r: bufio.NewReader(client), w: bufio.NewWriter(client)}
+		out, err := c.Do(context.Background(), tc.args...)
+		if got := <-sent; got != tc.sent {
+			t.Errorf("input: %q sent %q, wanted %q", tc.args, got, tc.sent)
+		}
+		if err != nil || !reflect.DeepEqual(out, tc.out) {
+			t.Errorf("input: %q got %#v %v, wanted %#v", tc.args, out, err, tc.out)
+		}
+		client.Close()
+		server.Close()
+	}
+}
+
+// TestDoBrokenConnection the connection is dropped after an error of the protocol, kept after an error reply
+func TestDoBrokenConnection(t *testing.T) {
+	type test struct {
+		reply string
+		kept  bool
+	}
+
+	testCases := []test{
+		{
+			reply: "-ERR unknown command\r\n",
+			kept:  true,
+		},
+		{
+			reply: "$-1\r\n",
+			kept:  true,
+		},
+		{
+			reply: "!garbage\r\n",
+			kept:  false,
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		client, server := net.Pipe()
+		go func() {
+			_, _ = bufio.NewReader(server).ReadString('\n')
+			_, _ = server.Write([]byte(tc.reply))
+		}()
+		c := &Client{conn: client, r: bufio.NewReader(client), w: bufio.NewWriter(client)}
+		if _, err := c.Do(context.Background(), "PING"); err == nil {
+			t.Errorf("input: %q got no error", tc.reply)
+		}
+		if kept := c.conn != nil; kept != tc.kept {
+			t.Errorf("input: %q got connection kept %v, wanted %v", tc.reply, kept, tc.kept)
+		}
+		client.Close()
+		server.Close()
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	type test struct {
+		in       string
+		addr     string
+		password string
+		db       int
+		err      bool
+	}
+
+	testCases := []test{
+		{
+			in:   "redis://localhost",
+			addr: "localhost:6379",
+		},
+		{
+			in:       "redis://:secret@cache:6380/2",
+			addr:     "cache:6380",
+			password: "secret",
+			db:       2,
+		},
+		{
+			in:  "http://localhost",
+			err: true,
+		},
+		{
+			in:  "redis://localhost/first",
+			err: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		c, err := NewClient(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("input: %s got no error", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("input: %s got %v", tc.in, errors.Cause(err))
+			continue
+		}
+		if c.addr != tc.addr || c.password != tc.password || c.db != tc.db {
+			t.Errorf("input: %s got %s %q %d, wanted %s %q %d", tc.in, c.addr, c.password, c.db, tc.addr, tc.password, tc.db)
+		}
+	}
+}
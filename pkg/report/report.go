@@ -0,0 +1,87 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+const flushTimeout = 2 * time.Second
+
+// tagKeys log fields that become sentry tags, the rest goes to extra
+var tagKeys = map[string]bool{
+	"guild":   true,
+	"command": true,
+}
+
+// Init until it is called the reports are dropped
+func Init(dsn, environment, release string) error {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+		Release:     release,
+	})
+	if err != nil {
+		return errors.Wrap(err, "sentry init")
+	}
+	return nil
+}
+
+func Flush() {
+	sentry.Flush(flushTimeout)
+}
+
+type core struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field
+}
+
+// NewCore sends error logs to sentry, "guild" and "command" fields are used as tags
+func NewCore() zapcore.Core {
+	return &core{LevelEnabler: zapcore.ErrorLevel}
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *core) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *core) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Message = e.Message
+	event.Logger = e.LoggerName
+	for k, v := range enc.Fields {
+		if tagKeys[k] {
+			event.Tags[k] = fmt.Sprint(v)
+			continue
+		}
+		event.Extra[k] = v
+	}
+	sentry.CaptureEvent(event)
+	return nil
+}
+
+func (c *core) Sync() error {
+	sentry.Flush(flushTimeout)
+	return nil
+}
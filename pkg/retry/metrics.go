@@ -0,0 +1,13 @@
+package retry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var retries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "retry",
+	Name:      "retries_total",
+	Help:      "Operations repeated after a transient error.",
+}, []string{"operation"})
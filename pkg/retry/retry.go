@@ -0,0 +1,54 @@
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Policy retries with exponential backoff and full jitter.
+// Only idempotent operations should be retried: the request may succeed while the response is lost.
+type Policy struct {
+	Attempts  int
+	Base      time.Duration
+	Max       time.Duration
+	Retryable func(err error) bool
+}
+
+// Do returns the last error, the operation names the retries in the metrics
+func (p Policy) Do(ctx context.Context, operation string, f func() error) error {
+	backoff := p.Base
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = f()
+		if err == nil || attempt >= p.Attempts || !p.Retryable(err) {
+			return err
+		}
+		retries.WithLabelValues(operation).Inc()
+		// full jitter spreads the retries of concurrent callers
+		timer := time.NewTimer(time.Duration(rand.Int63n(int64(backoff) + 1)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrap(err, ctx.Err().Error())
+		}
+		backoff *= 2
+		if backoff > p.Max {
+			backoff = p.Max
+		}
+	}
+}
+
+// Transient gRPC errors of the Google APIs which usually pass on another try
+func Transient(err error) bool {
+	switch status.Code(errors.Cause(err)) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Internal:
+		return true
+	}
+	return false
+}
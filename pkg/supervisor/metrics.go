@@ -0,0 +1,21 @@
+package supervisor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	panics = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "supervisor",
+		Name:      "panics_total",
+		Help:      "Panics recovered in the goroutines.",
+	}, []string{"name"})
+	restarts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "halvabot",
+		Subsystem: "supervisor",
+		Name:      "restarts_total",
+		Help:      "Goroutines restarted after a panic.",
+	}, []string{"name"})
+)
@@ -0,0 +1,78 @@
+package supervisor
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/HalvaPovidlo/discordBotGo/pkg/zap"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// Go runs f in a goroutine until it returns or the context is done.
+// After a panic f is started again, the backoff doubles while it keeps panicking faster than maxBackoff.
+func Go(ctx context.Context, logger zap.Logger, name string, f func(ctx context.Context)) {
+	go func() {
+		backoff := minBackoff
+		for {
+			start := time.Now()
+			if !run(ctx, logger, name, f) || ctx.Err() != nil {
+				return
+			}
+			if time.Since(start) > maxBackoff {
+				backoff = minBackoff
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			restarts.WithLabelValues(name).Inc()
+			logger.Warnw("restarting goroutine", "goroutine", name)
+		}
+	}()
+}
+
+// Safe runs f and logs its panic instead of crashing the bot, for short goroutines which are not restarted
+func Safe(logger zap.Logger, name string, f func()) {
+	defer Recover(logger, name)
+	f()
+}
+
+// Recover logs the panic with the stack, the error goes to sentry with the fields. Defer it directly.
+func Recover(logger zap.Logger, name string, keysAndValues ...interface{}) {
+	if r := recover(); r != nil {
+		logPanic(logger, name, r, keysAndValues)
+	}
+}
+
+func run(ctx context.Context, logger zap.Logger, name string, f func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(logger, name, r, nil)
+			panicked = true
+		}
+	}()
+	f(ctx)
+	return false
+}
+
+func logPanic(logger zap.Logger, name string, r interface{}, keysAndValues []interface{}) {
+	panics.WithLabelValues(name).Inc()
+	fields := append([]interface{}{
+		"goroutine", name,
+		"panic", r,
+		"stack", string(debug.Stack()),
+	}, keysAndValues...)
+	logger.Errorw("goroutine panicked", fields...)
+}
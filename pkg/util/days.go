@@ -0,0 +1,30 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+var weekdays = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+// CronDays the day of week field of cron for daily, weekdays, weekends or a list like mon,wed,fri
+func CronDays(days string) (string, bool) {
+	switch strings.ToLower(days) {
+	case "daily", "everyday":
+		return "*", true
+	case "weekdays":
+		return "1-5", true
+	case "weekends":
+		return "0,6", true
+	}
+	parts := strings.Split(strings.ToLower(days), ",")
+	res := make([]string, 0, len(parts))
+	for _, p := range parts {
+		n, ok := weekdays[strings.TrimSpace(p)]
+		if !ok {
+			return "", false
+		}
+		res = append(res, strconv.Itoa(n))
+	}
+	return strings.Join(res, ","), true
+}
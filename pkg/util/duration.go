@@ -0,0 +1,48 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParseDuration like time.ParseDuration with the days in front, e.g. 30d or 1d12h
+func ParseDuration(s string) (time.Duration, error) {
+	var days time.Duration
+	if i := strings.IndexAny(s, "dD"); i >= 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || n < 0 {
+			return 0, errors.Errorf("invalid days in %q", s)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[i+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+	rest, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return days + rest, nil
+}
+
+// ParseTimestamp like 83, 1:23 or 1:02:03
+func ParseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0, errors.Errorf("invalid timestamp %q", s)
+	}
+	var d time.Duration
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		// the minutes and the seconds after the first part are two digits
+		if err != nil || n < 0 || i > 0 && (len(part) != 2 || n > 59) {
+			return 0, errors.Errorf("invalid timestamp %q", s)
+		}
+		d = d*60 + time.Duration(n)*time.Second
+	}
+	return d, nil
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	type test struct {
+		in  string
+		out time.Duration
+		err bool
+	}
+
+	testCases := []test{
+		{
+			in:  "83",
+			out: 83 * time.Second,
+		},
+		{
+			in:  "0",
+			out: 0,
+		},
+		{
+			in:  "1:23",
+			out: time.Minute + 23*time.Second,
+		},
+		{
+			in:  " 1:02:03 ",
+			out: time.Hour + 2*time.Minute + 3*time.Second,
+		},
+		{
+			in:  "90:00",
+			out: 90 * time.Minute,
+		},
+		{
+			in:  "1:5",
+			err: true,
+		},
+		{
+			in:  "1:60",
+			err: true,
+		},
+		{
+			in:  "1:02:03:04",
+			err: true,
+		},
+		{
+			in:  "-5",
+			err: true,
+		},
+		{
+			in:  "1m",
+			err: true,
+		},
+		{
+			in:  "",
+			err: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		d, err := ParseTimestamp(tc.in)
+		if (err != nil) != tc.err {
+			t.Errorf("input: %q got error %v, wanted error %t", tc.in, err, tc.err)
+			continue
+		}
+		if d != tc.out {
+			t.Errorf("input: %q got %v, wanted %v", tc.in, d, tc.out)
+		}
+	}
+}
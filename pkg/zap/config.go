@@ -0,0 +1,208 @@
+package zap
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file"
+
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Config of the log output, the zero value writes everything from info to stderr like NewLogger
+type Config struct {
+	// Level debug, info, warn or error, debug if empty in the debug mode and info otherwise
+	Level  string `json:"level"`
+	Format string `json:"format"`
+	Sinks  []Sink `json:"sinks"`
+	// Subsystems override Level for the named loggers, "music" covers "music.player" too
+	Subsystems map[string]string `json:"subsystems"`
+}
+
+// Sink destination of the logs, the empty fields are taken from Config
+type Sink struct {
+	Type   string `json:"type"`
+	Format string `json:"format"`
+	// Level the minimum level the sink accepts on top of the subsystem levels
+	Level string `json:"level"`
+	// Path and rotation of the file sink
+	Path       string `json:"path"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxBackups int    `json:"max_backups"`
+	MaxAgeDays int    `json:"max_age_days"`
+	Compress   bool   `json:"compress"`
+}
+
+// Validate lists all the problems of the config
+func (c *Config) Validate() []string {
+	problems := make([]string, 0)
+	check := func(field, level string) {
+		if _, err := parseLevel(level, zapcore.InfoLevel); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: unknown level %q", field, level))
+		}
+	}
+	checkFormat := func(field, format string) {
+		if format != "" && format != FormatConsole && format != FormatJSON {
+			problems = append(problems, fmt.Sprintf("%s: format is console or json, got %q", field, format))
+		}
+	}
+	check("log.level", c.Level)
+	checkFormat("log.format", c.Format)
+	for name, level := range c.Subsystems {
+		check("log.subsystems."+name, level)
+	}
+	for i, s := range c.Sinks {
+		field := fmt.Sprintf("log.sinks[%d]", i)
+		check(field, s.Level)
+		checkFormat(field, s.Format)
+		switch s.Type {
+		case SinkStdout, SinkStderr:
+		case SinkFile:
+			if s.Path == "" {
+				problems = append(problems, field+": file sink needs a path")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s: type is stdout, stderr or file, got %q", field, s.Type))
+		}
+	}
+	return problems
+}
+
+// New builds the logger from the config, debug sets the default level and colors the console
+func New(c Config, debug bool) (Logger, error) {
+	if problems := c.Validate(); len(problems) != 0 {
+		return Logger{}, errors.New("invalid log config: " + strings.Join(problems, "; "))
+	}
+	defaultLevel := zapcore.InfoLevel
+	if debug {
+		defaultLevel = zapcore.DebugLevel
+	}
+	level, _ := parseLevel(c.Level, defaultLevel)
+	sinks := c.Sinks
+	if len(sinks) == 0 {
+		sinks = []Sink{{Type: SinkStderr}}
+	}
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for _, s := range sinks {
+		format := s.Format
+		if format == "" {
+			format = c.Format
+		}
+		// the subsystem levels filter the entries before the sinks
+		sinkLevel, _ := parseLevel(s.Level, zapcore.DebugLevel)
+		cores = append(cores, zapcore.NewCore(encoder(format, debug && s.Type != SinkFile), writer(s), sinkLevel))
+	}
+	subsystems := make(map[string]zapcore.Level, len(c.Subsystems))
+	for name, l := range c.Subsystems {
+		subsystems[name], _ = parseLevel(l, level)
+	}
+	core := &subsystemCore{
+		Core:       zapcore.NewTee(cores...),
+		level:      level,
+		subsystems: subsystems,
+	}
+	logger := zap.New(core, zap.Development(), zap.AddCaller(), zap.AddStacktrace(zapcore.WarnLevel), zap.Hooks(countErrors))
+	return Logger{
+		SugaredLogger: logger.Sugar(),
+	}, nil
+}
+
+func parseLevel(s string, def zapcore.Level) (zapcore.Level, error) {
+	if s == "" {
+		return def, nil
+	}
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return def, err
+	}
+	return l, nil
+}
+
+func encoder(format string, color bool) zapcore.Encoder {
+	config := zap.NewDevelopmentEncoderConfig()
+	if format == FormatJSON {
+		config = zap.NewProductionEncoderConfig()
+		config.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewJSONEncoder(config)
+	}
+	if color {
+		config.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	return zapcore.NewConsoleEncoder(config)
+}
+
+func writer(s Sink) zapcore.WriteSyncer {
+	switch s.Type {
+	case SinkStdout:
+		return zapcore.Lock(os.Stdout)
+	case SinkFile:
+		return zapcore.AddSync(&lumberjack.Logger{
+			Filename:   s.Path,
+			MaxSize:    s.MaxSizeMB,
+			MaxBackups: s.MaxBackups,
+			MaxAge:     s.MaxAgeDays,
+			Compress:   s.Compress,
+		})
+	}
+	return zapcore.Lock(os.Stderr)
+}
+
+// subsystemCore drops the entries below the level of the named logger
+type subsystemCore struct {
+	zapcore.Core
+	level      zapcore.Level
+	subsystems map[string]zapcore.Level
+}
+
+func (c *subsystemCore) Enabled(l zapcore.Level) bool {
+	if l >= c.level {
+		return c.Core.Enabled(l)
+	}
+	for _, sl := range c.subsystems {
+		if l >= sl {
+			return c.Core.Enabled(l)
+		}
+	}
+	return false
+}
+
+func (c *subsystemCore) With(fields []zapcore.Field) zapcore.Core {
+	return &subsystemCore{
+		Core:       c.Core.With(fields),
+		level:      c.level,
+		subsystems: c.subsystems,
+	}
+}
+
+func (c *subsystemCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if e.Level < c.levelOf(e.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(e, ce)
+}
+
+// levelOf the most specific subsystem wins
+func (c *subsystemCore) levelOf(name string) zapcore.Level {
+	for name != "" {
+		if l, ok := c.subsystems[name]; ok {
+			return l
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return c.level
+}
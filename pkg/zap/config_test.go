@@ -0,0 +1,62 @@
+package zap
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSubsystemLevel(t *testing.T) {
+	core := &subsystemCore{
+		level: zapcore.InfoLevel,
+		subsystems: map[string]zapcore.Level{
+			"music":        zapcore.WarnLevel,
+			"music.player": zapcore.DebugLevel,
+		},
+	}
+
+	type test struct {
+		in  string
+		out zapcore.Level
+	}
+
+	testCases := []test{
+		{
+			// root
+			in:  "",
+			out: zapcore.InfoLevel,
+		},
+		{
+			// unknown
+			in:  "chess",
+			out: zapcore.InfoLevel,
+		},
+		{
+			in:  "music",
+			out: zapcore.WarnLevel,
+		},
+		{
+			// nested
+			in:  "music.audio",
+			out: zapcore.WarnLevel,
+		},
+		{
+			// the most specific
+			in:  "music.player",
+			out: zapcore.DebugLevel,
+		},
+		{
+			// a prefix is not a parent
+			in:  "musicbot",
+			out: zapcore.InfoLevel,
+		},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		level := core.levelOf(tc.in)
+		if level != tc.out {
+			t.Errorf("input: %q got %s, wanted %s", tc.in, level, tc.out)
+		}
+	}
+}
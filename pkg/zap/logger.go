@@ -9,6 +9,7 @@ type Logger struct {
 	*zap.SugaredLogger
 }
 
+// NewLogger writes to stderr, New configures the output
 func NewLogger(debug bool) Logger {
 	config := zap.NewDevelopmentConfig()
 	if debug {
@@ -23,3 +24,20 @@ func NewLogger(debug bool) Logger {
 		SugaredLogger: zapLogger.Sugar(),
 	}
 }
+
+// Named logger of the subsystem, the names nest with dots
+func (l Logger) Named(name string) Logger {
+	return Logger{
+		SugaredLogger: l.SugaredLogger.Named(name),
+	}
+}
+
+// Tee duplicates the log entries to the core
+func (l Logger) Tee(core zapcore.Core) Logger {
+	tee := l.Desugar().WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, core)
+	}))
+	return Logger{
+		SugaredLogger: tee.Sugar(),
+	}
+}
@@ -0,0 +1,29 @@
+package zap
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap/zapcore"
+)
+
+var loggedErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "halvabot",
+	Subsystem: "log",
+	Name:      "errors_total",
+	Help:      "Entries logged at the error level and above by the top level logger.",
+}, []string{"logger"})
+
+// countErrors is a zap hook, the nested loggers are counted in their subsystem
+func countErrors(e zapcore.Entry) error {
+	if e.Level < zapcore.ErrorLevel {
+		return nil
+	}
+	name := e.LoggerName
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	loggedErrors.WithLabelValues(name).Inc()
+	return nil
+}